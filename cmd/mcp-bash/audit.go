@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
+
+	"github.com/code-aria/internal-mcp/internal/auditlog"
 )
 
 // Global audit configuration
@@ -17,7 +20,11 @@ var (
 
 // AuditLogger handles audit logging
 type AuditLogger struct {
-	logFile *os.File
+	mu       sync.Mutex
+	logFile  *os.File
+	path     string
+	maxBytes int64
+	maxFiles int
 }
 
 // InitAuditLogger initializes the audit logger
@@ -28,12 +35,7 @@ func InitAuditLogger() error {
 
 	// Determine audit log file path
 	if auditLogFile == "" {
-		// Default to REPO_PATH/.mcp_audit.log
-		repoPath := os.Getenv("REPO_PATH")
-		if repoPath == "" {
-			repoPath = os.TempDir()
-		}
-		auditLogFile = filepath.Join(repoPath, ".mcp_audit.log")
+		auditLogFile = defaultAuditLogFile()
 	}
 
 	// Create/open audit log file
@@ -43,16 +45,36 @@ func InitAuditLogger() error {
 	}
 
 	auditLogger = &AuditLogger{
-		logFile: file,
+		logFile:  file,
+		path:     auditLogFile,
+		maxBytes: auditlog.MaxBytes(),
+		maxFiles: auditlog.MaxFiles(),
 	}
 
 	return nil
 }
 
+// defaultAuditLogFile returns MCP_BASH_AUDIT_FILE, or REPO_PATH/.mcp_audit.log when unset
+func defaultAuditLogFile() string {
+	if path := os.Getenv("MCP_BASH_AUDIT_FILE"); path != "" {
+		return path
+	}
+
+	repoPath := os.Getenv("REPO_PATH")
+	if repoPath == "" {
+		repoPath = os.TempDir()
+	}
+	return filepath.Join(repoPath, ".mcp_audit.log")
+}
+
 // CloseAuditLogger closes the audit logger
 func CloseAuditLogger() {
-	if auditLogger != nil && auditLogger.logFile != nil {
-		auditLogger.logFile.Close()
+	if auditLogger != nil {
+		auditLogger.mu.Lock()
+		defer auditLogger.mu.Unlock()
+		if auditLogger.logFile != nil {
+			auditLogger.logFile.Close()
+		}
 	}
 }
 
@@ -97,7 +119,7 @@ func auditLog(operation, command, script, workingDir string, envVars map[string]
 
 	// Write to log file
 	logLine := string(jsonData) + "\n"
-	if _, err := auditLogger.logFile.WriteString(logLine); err != nil {
+	if err := auditLogger.write(logLine); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to write audit log entry: %v\n", err)
 	}
 
@@ -105,6 +127,76 @@ func auditLog(operation, command, script, workingDir string, envVars map[string]
 	fmt.Fprintf(os.Stderr, "[AUDIT] %s\n", logLine)
 }
 
+// write appends a line to the log file, rotating the file first if the line would push it
+// past maxBytes
+func (l *AuditLogger) write(line string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.logFile == nil {
+		return fmt.Errorf("audit log file not open")
+	}
+
+	if info, err := l.logFile.Stat(); err == nil && auditlog.ShouldRotate(info.Size(), int64(len(line)), l.maxBytes) {
+		if err := l.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to rotate audit log: %v\n", err)
+		}
+		if l.logFile == nil {
+			return fmt.Errorf("audit log file not open")
+		}
+	}
+
+	_, err := l.logFile.WriteString(line)
+	return err
+}
+
+// rotate moves the full log file aside and opens a fresh one. Callers must hold l.mu.
+func (l *AuditLogger) rotate() error {
+	l.logFile.Close()
+	l.logFile = nil
+
+	rotateErr := auditlog.Rotate(l.path, l.maxFiles)
+
+	// Reopen even if the rename failed so logging carries on in the old file
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to reopen audit log file: %w", err)
+	}
+	l.logFile = file
+
+	return rotateErr
+}
+
+// toolQueryAuditLog returns the most recent audit entries matching operation, since and success
+func toolQueryAuditLog(args map[string]interface{}) (string, error) {
+	filter, limit, err := auditlog.ParseQueryArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	path := auditLogFile
+	if path == "" {
+		path = defaultAuditLogFile()
+	}
+
+	startTime := time.Now()
+	result, err := auditlog.Query(path, filter, limit)
+	duration := time.Since(startTime).Milliseconds()
+
+	// Logged after the read so the query does not return itself
+	auditLog("query_audit_log", "", "", "", nil, nil, nil, duration, err == nil, 0, "")
+
+	if err != nil {
+		return "", err
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal audit entries: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
 // GetAuditStats returns audit statistics
 func GetAuditStats() (map[string]interface{}, error) {
 	if !auditEnabled || auditLogFile == "" {
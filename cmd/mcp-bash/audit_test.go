@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/code-aria/internal-mcp/internal/auditlog"
 )
 
 func TestInitAuditLogger(t *testing.T) {
@@ -157,6 +159,113 @@ func TestAuditLog(t *testing.T) {
 	}
 }
 
+func TestAuditLogRotation(t *testing.T) {
+	testDir := t.TempDir()
+	testLogFile := filepath.Join(testDir, "test_audit.log")
+	t.Setenv("MCP_AUDIT_MAX_BYTES", "1024")
+	t.Setenv("MCP_AUDIT_MAX_FILES", "2")
+
+	// Save original state
+	originalEnabled := auditEnabled
+	originalLogFile := auditLogFile
+	originalLogger := auditLogger
+
+	defer func() {
+		auditEnabled = originalEnabled
+		auditLogFile = originalLogFile
+		auditLogger = originalLogger
+	}()
+
+	auditEnabled = true
+	auditLogFile = testLogFile
+	if err := InitAuditLogger(); err != nil {
+		t.Fatalf("Failed to initialize audit logger: %v", err)
+	}
+	defer CloseAuditLogger()
+
+	for i := 0; i < 30; i++ {
+		auditLog("execute_command", "echo rotation", "", testDir, nil, nil, nil, 1, true, 0, "")
+	}
+
+	rotated, err := auditlog.RotatedFiles(testLogFile)
+	if err != nil {
+		t.Fatalf("RotatedFiles() error = %v", err)
+	}
+	if len(rotated) != 2 {
+		t.Fatalf("Expected 2 rotated files to be kept, got %v", rotated)
+	}
+
+	info, err := os.Stat(testLogFile)
+	if err != nil {
+		t.Fatalf("Expected a fresh audit log after rotation: %v", err)
+	}
+	if info.Size() > 1024 {
+		t.Errorf("Current audit log is %d bytes, expected at most 1024", info.Size())
+	}
+}
+
+func TestToolQueryAuditLog(t *testing.T) {
+	testDir := t.TempDir()
+	testLogFile := filepath.Join(testDir, "test_audit.log")
+
+	// Save original state
+	originalEnabled := auditEnabled
+	originalLogFile := auditLogFile
+	originalLogger := auditLogger
+
+	defer func() {
+		auditEnabled = originalEnabled
+		auditLogFile = originalLogFile
+		auditLogger = originalLogger
+	}()
+
+	auditEnabled = true
+	auditLogFile = testLogFile
+	if err := InitAuditLogger(); err != nil {
+		t.Fatalf("Failed to initialize audit logger: %v", err)
+	}
+	defer CloseAuditLogger()
+
+	auditLog("execute_command", "echo one", "", testDir, nil, nil, nil, 5, true, 0, "")
+	auditLog("execute_script", "", "exit 1", testDir, nil, nil, nil, 5, false, -32003, "Execution")
+	auditLog("execute_command", "echo two", "", testDir, nil, nil, nil, 5, true, 0, "")
+
+	result, err := toolQueryAuditLog(map[string]interface{}{"operation": "execute_command", "limit": float64(1)})
+	if err != nil {
+		t.Fatalf("toolQueryAuditLog() error = %v", err)
+	}
+
+	var response struct {
+		Entries   []map[string]interface{} `json:"entries"`
+		Matched   int                      `json:"matched"`
+		Truncated bool                     `json:"truncated"`
+	}
+	if err := json.Unmarshal([]byte(result), &response); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if len(response.Entries) != 1 || response.Entries[0]["command"] != "echo two" {
+		t.Errorf("Expected only the latest execute_command entry, got %v", response.Entries)
+	}
+	if response.Matched != 2 || !response.Truncated {
+		t.Errorf("Expected 2 matches truncated to 1, got matched=%d truncated=%v", response.Matched, response.Truncated)
+	}
+
+	result, err = toolQueryAuditLog(map[string]interface{}{"success": false})
+	if err != nil {
+		t.Fatalf("toolQueryAuditLog(success=false) error = %v", err)
+	}
+	if err := json.Unmarshal([]byte(result), &response); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if len(response.Entries) != 1 || response.Entries[0]["operation"] != "execute_script" {
+		t.Errorf("Expected only the failed script entry, got %v", response.Entries)
+	}
+
+	if _, err := toolQueryAuditLog(map[string]interface{}{"since": "last week"}); err == nil {
+		t.Error("Expected error for an invalid since")
+	}
+}
+
 func TestGetAuditStats(t *testing.T) {
 	testDir := t.TempDir()
 	testLogFile := filepath.Join(testDir, "test_audit.log")
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -72,7 +73,10 @@ var defaultSecurityPolicy = SecurityPolicy{
 	MaxScriptLen:    10000,
 	DefaultTimeout:  180, // 3 minutes default timeout for commands
 	MaxTimeout:      600, // 10 minutes maximum timeout for commands
+	DefaultMaxOutput: 4 * 1024 * 1024, // 4MB of captured stdout and stderr combined
+	MaxOutput:        4 * 1024 * 1024, // the result is JSON-encoded twice, which escaping grows, so keep well under the 10MB JSON-RPC line limit
 	AllowShellAccess: false,
+	InteractiveCommands: knownInteractiveCommands,
 }
 
 // toolExecuteCommand executes a single bash command
@@ -134,13 +138,17 @@ func toolExecuteCommand(args map[string]interface{}) (string, error) {
 	}
 
 	// Execute command
-	result, err := executeCommandWithTimeout(command, workingDir, envVars, allowShellAccess, time.Duration(timeout)*time.Second)
-	
+	result, err := executeCommandWithTimeout(command, workingDir, envVars, allowShellAccess, time.Duration(timeout)*time.Second, maxOutputBytes(args))
+	if err != nil {
+		// The process could not be started, so there is no result to return
+		auditLog("execute_command", command, "", workingDir, envVars, nil, securityResult, 0, false, -32003, "Execution")
+		return "", err
+	}
+
 	// Audit logging
-	success := err == nil && result.ExitCode == 0
 	errorCode := 0
 	errorType := ""
-	if !success {
+	if !result.Success {
 		if result.Timeout {
 			errorCode = -32002
 			errorType = "Timeout"
@@ -150,11 +158,7 @@ func toolExecuteCommand(args map[string]interface{}) (string, error) {
 		}
 	}
 	
-	auditLog("execute_command", command, "", workingDir, envVars, result, securityResult, result.DurationMs, success, errorCode, errorType)
-
-	if err != nil {
-		return "", err
-	}
+	auditLog("execute_command", command, "", workingDir, envVars, result, securityResult, result.DurationMs, result.Success, errorCode, errorType)
 
 	// Return JSON result
 	resultJSON, err := json.Marshal(result)
@@ -228,13 +232,17 @@ func toolExecuteScript(args map[string]interface{}) (string, error) {
 	}
 
 	// Execute script
-	result, err := executeScriptWithTimeout(script, workingDir, envVars, allowShellAccess, time.Duration(timeout)*time.Second, scriptName)
-	
+	result, err := executeScriptWithTimeout(script, workingDir, envVars, allowShellAccess, time.Duration(timeout)*time.Second, scriptName, maxOutputBytes(args))
+	if err != nil {
+		// The process could not be started, so there is no result to return
+		auditLog("execute_script", "", script, workingDir, envVars, nil, securityResult, 0, false, -32003, "Execution")
+		return "", err
+	}
+
 	// Audit logging
-	success := err == nil && result.ExitCode == 0
 	errorCode := 0
 	errorType := ""
-	if !success {
+	if !result.Success {
 		if result.Timeout {
 			errorCode = -32002
 			errorType = "Timeout"
@@ -244,11 +252,7 @@ func toolExecuteScript(args map[string]interface{}) (string, error) {
 		}
 	}
 	
-	auditLog("execute_script", "", script, workingDir, envVars, result, securityResult, result.DurationMs, success, errorCode, errorType)
-
-	if err != nil {
-		return "", err
-	}
+	auditLog("execute_script", "", script, workingDir, envVars, result, securityResult, result.DurationMs, result.Success, errorCode, errorType)
 
 	// Return JSON result
 	resultJSON, err := json.Marshal(result)
@@ -294,8 +298,55 @@ func toolCheckCommandExists(args map[string]interface{}) (string, error) {
 	return string(resultJSON), nil
 }
 
+// toolValidateCommand runs the security checks of execute_command (or execute_script when
+// a script is given) without executing anything, so callers can pre-flight a command
+func toolValidateCommand(args map[string]interface{}) (string, error) {
+	command, _ := args["command"].(string)
+	script, _ := args["script"].(string)
+	if command == "" && script == "" {
+		return "", fmt.Errorf("command or script is required")
+	}
+
+	var securityResult *SecurityResult
+	if script != "" {
+		securityResult = validateScript(script)
+	} else {
+		allowShellAccess := false
+		if asa, ok := args["allow_shell_access"].(bool); ok {
+			allowShellAccess = asa
+		}
+		securityResult = validateCommand(command, allowShellAccess)
+	}
+
+	result := &CommandValidationResult{
+		Allowed: securityResult.Valid,
+		Reason:  securityResult.Reason,
+		Rule:    securityResult.Rule,
+		Pattern: securityResult.Pattern,
+	}
+	if result.Allowed {
+		result.Reason = "Allowed by security policy"
+	}
+
+	// Audit logging (nothing is executed)
+	errorCode := 0
+	errorType := ""
+	if !result.Allowed {
+		errorCode = -32001
+		errorType = "Security"
+	}
+	auditLog("validate_command", command, script, "", nil, nil, securityResult, 0, result.Allowed, errorCode, errorType)
+
+	// Return JSON result
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
 // executeCommandWithTimeout executes a command with timeout
-func executeCommandWithTimeout(command, workingDir string, envVars map[string]string, allowShellAccess bool, timeout time.Duration) (*CommandResult, error) {
+func executeCommandWithTimeout(command, workingDir string, envVars map[string]string, allowShellAccess bool, timeout time.Duration, maxOutput int) (*CommandResult, error) {
 	startTime := time.Now()
 	
 	// Create context with timeout
@@ -334,10 +385,10 @@ func executeCommandWithTimeout(command, workingDir string, envVars map[string]st
 		cmd.Env = env
 	}
 
-	// Execute command
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	// Execute command, keeping at most maxOutput bytes of both streams together
+	stdout, stderr := newCappedBuffers(maxOutput)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
 
 	err := cmd.Run()
 	duration := time.Since(startTime)
@@ -351,21 +402,13 @@ func executeCommandWithTimeout(command, workingDir string, envVars map[string]st
 		WorkingDir: workingDir,
 		Timeout:    false,
 	}
+	setOutputTruncation(result, stdout, stderr)
 
-	if cmd.ProcessState != nil {
-		result.ExitCode = cmd.ProcessState.ExitCode()
-	}
-
-	if ctx.Err() == context.DeadlineExceeded {
-		result.Timeout = true
-		return result, fmt.Errorf("command timed out after %v", timeout)
-	}
-
-	return result, err
+	return finishResult(ctx, result, cmd, err)
 }
 
 // executeScriptWithTimeout executes a script with timeout
-func executeScriptWithTimeout(script, workingDir string, envVars map[string]string, allowShellAccess bool, timeout time.Duration, scriptName string) (*CommandResult, error) {
+func executeScriptWithTimeout(script, workingDir string, envVars map[string]string, allowShellAccess bool, timeout time.Duration, scriptName string, maxOutput int) (*CommandResult, error) {
 	startTime := time.Now()
 	
 	// Determine bash path for Windows
@@ -410,10 +453,10 @@ func executeScriptWithTimeout(script, workingDir string, envVars map[string]stri
 		cmd.Env = env
 	}
 
-	// Execute script
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	// Execute script, keeping at most maxOutput bytes of both streams together
+	stdout, stderr := newCappedBuffers(maxOutput)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
 
 	err := cmd.Run()
 	duration := time.Since(startTime)
@@ -432,17 +475,86 @@ func executeScriptWithTimeout(script, workingDir string, envVars map[string]stri
 		LinesExecuted: lines,
 		ScriptName:   scriptName,
 	}
+	setOutputTruncation(result, stdout, stderr)
+
+	return finishResult(ctx, result, cmd, err)
+}
+
+// finishResult fills in how the process ended. Only a process that could not be started
+// is an error: a non-zero exit or a timeout is reported through exit_code, timeout and
+// success alongside the captured output.
+func finishResult(ctx context.Context, result *CommandResult, cmd *exec.Cmd, runErr error) (*CommandResult, error) {
+	if cmd.ProcessState == nil {
+		return nil, fmt.Errorf("failed to start %s: %w", cmd.Path, runErr)
+	}
+	result.ExitCode = cmd.ProcessState.ExitCode()
+	result.Timeout = ctx.Err() == context.DeadlineExceeded
+	result.Success = result.ExitCode == 0 && !result.Timeout
+	return result, nil
+}
 
-	if cmd.ProcessState != nil {
-		result.ExitCode = cmd.ProcessState.ExitCode()
+// maxOutputBytes returns the max_output_bytes parameter clamped to the policy maximum
+func maxOutputBytes(args map[string]interface{}) int {
+	maxOutput := defaultSecurityPolicy.DefaultMaxOutput
+	if m, ok := args["max_output_bytes"].(float64); ok && m > 0 {
+		maxOutput = int(m)
+	}
+	if maxOutput > defaultSecurityPolicy.MaxOutput {
+		maxOutput = defaultSecurityPolicy.MaxOutput
 	}
+	return maxOutput
+}
+
+// outputBudget is the number of bytes the buffers sharing it may still keep. exec copies
+// stdout and stderr from separate goroutines, so it is locked.
+type outputBudget struct {
+	mu        sync.Mutex
+	remaining int
+}
+
+// cappedBuffer keeps what it is written while its budget lasts and counts the rest. Writes
+// past the budget still succeed, so the process keeps running instead of failing on a
+// closed pipe.
+type cappedBuffer struct {
+	buf    bytes.Buffer
+	budget *outputBudget
+	total  int64
+}
+
+// newCappedBuffers returns stdout and stderr buffers keeping at most limit bytes between them
+func newCappedBuffers(limit int) (*cappedBuffer, *cappedBuffer) {
+	budget := &outputBudget{remaining: limit}
+	return &cappedBuffer{budget: budget}, &cappedBuffer{budget: budget}
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.budget.mu.Lock()
+	defer b.budget.mu.Unlock()
 
-	if ctx.Err() == context.DeadlineExceeded {
-		result.Timeout = true
-		return result, fmt.Errorf("script timed out after %v", timeout)
+	b.total += int64(len(p))
+	keep := len(p)
+	if keep > b.budget.remaining {
+		keep = b.budget.remaining
 	}
+	b.buf.Write(p[:keep])
+	b.budget.remaining -= keep
+	return len(p), nil
+}
+
+func (b *cappedBuffer) String() string {
+	return b.buf.String()
+}
+
+func (b *cappedBuffer) truncated() bool {
+	return b.total > int64(b.buf.Len())
+}
 
-	return result, err
+// setOutputTruncation marks result as truncated when the streams went over their shared cap
+func setOutputTruncation(result *CommandResult, stdout, stderr *cappedBuffer) {
+	if stdout.truncated() || stderr.truncated() {
+		result.Truncated = true
+		result.TotalBytes = stdout.total + stderr.total
+	}
 }
 
 // checkCommandExists checks if a command exists in PATH or specified paths
@@ -283,6 +283,85 @@ func TestToolCheckCommandExists(t *testing.T) {
 	}
 }
 
+func TestToolValidateCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        map[string]interface{}
+		wantError   bool
+		wantAllowed bool
+		wantRule    string
+	}{
+		{
+			name: "allowed command",
+			args: map[string]interface{}{
+				"command": "ls -la",
+			},
+			wantAllowed: true,
+		},
+		{
+			name: "blocked pattern",
+			args: map[string]interface{}{
+				"command": "rm -rf /tmp/build",
+			},
+			wantAllowed: false,
+			wantRule:    "blocked_pattern",
+		},
+		{
+			name: "command not in allowlist",
+			args: map[string]interface{}{
+				"command": "nc -l 8080",
+			},
+			wantAllowed: false,
+			wantRule:    "allowed_commands",
+		},
+		{
+			name: "blocked script",
+			args: map[string]interface{}{
+				"script": "echo start\nshutdown now",
+			},
+			wantAllowed: false,
+			wantRule:    "blocked_pattern",
+		},
+		{
+			name:      "missing command and script",
+			args:      map[string]interface{}{},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := toolValidateCommand(tt.args)
+
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var validation CommandValidationResult
+			if err := json.Unmarshal([]byte(result), &validation); err != nil {
+				t.Fatalf("Failed to unmarshal result: %v", err)
+			}
+
+			if validation.Allowed != tt.wantAllowed {
+				t.Errorf("Expected allowed=%v, got %v (reason: %s)", tt.wantAllowed, validation.Allowed, validation.Reason)
+			}
+			if validation.Rule != tt.wantRule {
+				t.Errorf("Expected rule %q, got %q", tt.wantRule, validation.Rule)
+			}
+			if validation.Reason == "" {
+				t.Error("Expected a reason")
+			}
+		})
+	}
+}
+
 func TestExecuteCommandWithTimeout(t *testing.T) {
 	testDir := t.TempDir()
 
@@ -321,7 +400,7 @@ func TestExecuteCommandWithTimeout(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := executeCommandWithTimeout(tt.command, testDir, nil, false, tt.timeout)
+			result, err := executeCommandWithTimeout(tt.command, testDir, nil, false, tt.timeout, defaultSecurityPolicy.DefaultMaxOutput)
 
 			if tt.wantError {
 				if err == nil {
@@ -373,7 +452,7 @@ func TestExecuteScriptWithTimeout(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Create a simple script name without special characters
 			scriptName := "test_script"
-			result, err := executeScriptWithTimeout(tt.script, testDir, nil, true, tt.timeout, scriptName)
+			result, err := executeScriptWithTimeout(tt.script, testDir, nil, true, tt.timeout, scriptName, defaultSecurityPolicy.DefaultMaxOutput)
 
 			if tt.wantError {
 				if err == nil {
@@ -393,6 +472,118 @@ func TestExecuteScriptWithTimeout(t *testing.T) {
 	}
 }
 
+func TestExecuteCommandTruncatesOutput(t *testing.T) {
+	testDir := t.TempDir()
+
+	// 100000 bytes on stdout and 11 on stderr with a 1KB cap shared by both streams; the
+	// command must still run to completion. The streams are copied concurrently, so which one
+	// uses the budget first is not fixed, only their total.
+	result, err := executeCommandWithTimeout("echo start >&2; head -c 100000 /dev/zero; echo done >&2", testDir, nil, true, 10*time.Second, 1024)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.ExitCode != 0 {
+		t.Errorf("Expected exit code 0, got %d", result.ExitCode)
+	}
+	if got := len(result.Stdout) + len(result.Stderr); got != 1024 {
+		t.Errorf("Expected stdout and stderr to share the 1024 bytes, got %d", got)
+	}
+	if !result.Truncated || result.TotalBytes != 100011 {
+		t.Errorf("Expected truncated output of 100011 bytes, got truncated=%v total_bytes=%d", result.Truncated, result.TotalBytes)
+	}
+
+	small, err := executeCommandWithTimeout("echo hello", testDir, nil, false, 10*time.Second, 1024)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if small.Truncated || small.TotalBytes != 0 {
+		t.Errorf("Expected untruncated output, got truncated=%v total_bytes=%d", small.Truncated, small.TotalBytes)
+	}
+}
+
+// allowCommands adds commands to the allowlist for the rest of the test
+func allowCommands(t *testing.T, commands ...string) {
+	t.Helper()
+	original := defaultSecurityPolicy.AllowedCommands
+	allowed := make(map[string]bool, len(original)+len(commands))
+	for cmd, ok := range original {
+		allowed[cmd] = ok
+	}
+	for _, cmd := range commands {
+		allowed[cmd] = true
+	}
+	defaultSecurityPolicy.AllowedCommands = allowed
+	t.Cleanup(func() { defaultSecurityPolicy.AllowedCommands = original })
+}
+
+// TestNonZeroExitIsStructured tests that a command exiting non-zero or timing out returns its
+// exit code and output with success false instead of failing the operation, and that only a
+// command that cannot be started is an error
+func TestNonZeroExitIsStructured(t *testing.T) {
+	testDir := t.TempDir()
+	t.Setenv("REPO_PATH", testDir)
+	origAuditEnabled := auditEnabled
+	auditEnabled = false
+	defer func() { auditEnabled = origAuditEnabled }()
+	allowCommands(t, "false")
+
+	results := batchRunner.Run([]interface{}{
+		map[string]interface{}{"type": "execute_command", "command": "false", "timeout": float64(10)},
+		map[string]interface{}{"type": "execute_script", "script": "echo partial\necho oops >&2\nfalse", "timeout": float64(10)},
+	})
+	want := []struct {
+		exitCode       int
+		stdout, stderr string
+	}{
+		{1, "", ""},
+		{1, "partial\n", "oops\n"},
+	}
+	for i, res := range results {
+		if res["success"] != true {
+			t.Fatalf("Operation %d failed instead of returning its exit code: %v", i, res["error"])
+		}
+		var cmdResult CommandResult
+		data, _ := json.Marshal(res["result"])
+		if err := json.Unmarshal(data, &cmdResult); err != nil {
+			t.Fatalf("Failed to unmarshal result %d: %v", i, err)
+		}
+		if cmdResult.ExitCode != want[i].exitCode || cmdResult.Success || cmdResult.Timeout {
+			t.Errorf("Result %d = exit_code %d, success %v, timeout %v; want exit_code %d, success false", i, cmdResult.ExitCode, cmdResult.Success, cmdResult.Timeout, want[i].exitCode)
+		}
+		if cmdResult.Stdout != want[i].stdout || cmdResult.Stderr != want[i].stderr {
+			t.Errorf("Result %d output = %q, %q; want %q, %q", i, cmdResult.Stdout, cmdResult.Stderr, want[i].stdout, want[i].stderr)
+		}
+	}
+
+	result, err := executeCommandWithTimeout("echo started; sleep 5", testDir, nil, true, 200*time.Millisecond, 1024)
+	if err != nil {
+		t.Fatalf("Expected a timeout to be reported in the result, got error %v", err)
+	}
+	if !result.Timeout || result.Success || result.Stdout != "started\n" {
+		t.Errorf("Expected a timed out result with the output so far, got %+v", result)
+	}
+
+	if _, err := executeCommandWithTimeout("no-such-command-xyz", testDir, nil, false, 10*time.Second, 1024); err == nil {
+		t.Error("Expected an error for a command that cannot be started")
+	}
+	if _, err := toolExecuteCommand(map[string]interface{}{"command": "false", "allow_shell_access": true, "timeout": float64(10)}); err != nil {
+		t.Errorf("Expected no error for exit code 1, got %v", err)
+	}
+}
+
+func TestMaxOutputBytes(t *testing.T) {
+	if got := maxOutputBytes(map[string]interface{}{}); got != defaultSecurityPolicy.DefaultMaxOutput {
+		t.Errorf("Expected default %d, got %d", defaultSecurityPolicy.DefaultMaxOutput, got)
+	}
+	if got := maxOutputBytes(map[string]interface{}{"max_output_bytes": float64(2048)}); got != 2048 {
+		t.Errorf("Expected 2048, got %d", got)
+	}
+	if got := maxOutputBytes(map[string]interface{}{"max_output_bytes": float64(1 << 30)}); got != defaultSecurityPolicy.MaxOutput {
+		t.Errorf("Expected cap %d, got %d", defaultSecurityPolicy.MaxOutput, got)
+	}
+}
+
 func TestCheckCommandExists(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// knownInteractiveCommands are commands that wait on a terminal and would hang until the
+// timeout. A command with batch flags is allowed when one of them is passed.
+var knownInteractiveCommands = map[string]InteractiveCommand{
+	"top":   {Guidance: "run it in batch mode, e.g. top -b -n1", BatchFlags: []string{"-b"}},
+	"htop":  {Guidance: "use top -b -n1 or ps aux instead"},
+	"watch": {Guidance: "run the watched command once instead"},
+	"less":  {Guidance: "use cat, head or tail instead"},
+	"more":  {Guidance: "use cat, head or tail instead"},
+	"vi":    {Guidance: "edit files with sed or a file editing tool instead"},
+	"vim":   {Guidance: "edit files with sed or a file editing tool instead"},
+	"nano":  {Guidance: "edit files with sed or a file editing tool instead"},
+}
+
+// commandSeparatorPattern splits a command line into the commands of a pipeline or list
+var commandSeparatorPattern = regexp.MustCompile(`\|\|?|&&|;`)
+
+// InitInteractiveCommands replaces the interactive command list with the comma-separated
+// names in MCP_BASH_INTERACTIVE_COMMANDS. Known names keep their guidance and batch flags;
+// an empty value turns the check off.
+func InitInteractiveCommands() {
+	value, ok := os.LookupEnv("MCP_BASH_INTERACTIVE_COMMANDS")
+	if !ok {
+		return
+	}
+	defaultSecurityPolicy.InteractiveCommands = parseInteractiveCommands(value)
+}
+
+// parseInteractiveCommands builds an interactive command list from comma-separated names
+func parseInteractiveCommands(value string) map[string]InteractiveCommand {
+	commands := make(map[string]InteractiveCommand)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if known, ok := knownInteractiveCommands[name]; ok {
+			commands[name] = known
+		} else {
+			commands[name] = InteractiveCommand{Guidance: "run it with a non-interactive option instead"}
+		}
+	}
+	return commands
+}
+
+// checkInteractiveCommand rejects a command line when any of its commands is interactive
+// and not given one of its batch flags. It returns nil when the command line is fine.
+func checkInteractiveCommand(command string) *SecurityResult {
+	for _, segment := range commandSeparatorPattern.Split(command, -1) {
+		parts := strings.Fields(segment)
+		if len(parts) == 0 {
+			continue
+		}
+		interactive, ok := defaultSecurityPolicy.InteractiveCommands[parts[0]]
+		if !ok || hasBatchFlag(parts[1:], interactive.BatchFlags) {
+			continue
+		}
+		return &SecurityResult{
+			Valid:  false,
+			Reason: fmt.Sprintf("Interactive command not supported: %s waits for a terminal; %s", parts[0], interactive.Guidance),
+			Rule:   "interactive_command",
+		}
+	}
+	return nil
+}
+
+// hasBatchFlag reports whether args pass one of flags. Single-letter flags also match
+// when combined with others, as in top -bn1.
+func hasBatchFlag(args, flags []string) bool {
+	for _, arg := range args {
+		for _, flag := range flags {
+			if arg == flag || strings.HasPrefix(arg, flag+"=") {
+				return true
+			}
+			if len(flag) == 2 && flag[0] == '-' && strings.HasPrefix(arg, "-") &&
+				!strings.HasPrefix(arg, "--") && strings.ContainsRune(arg[1:], rune(flag[1])) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateCommandInteractive(t *testing.T) {
+	tests := []struct {
+		name             string
+		command          string
+		allowShellAccess bool
+		wantValid        bool
+	}{
+		{"top without batch mode", "top", false, false},
+		{"top with other flags only", "top -n1", false, false},
+		{"top in batch mode", "top -b -n1", false, true},
+		{"top with combined batch flags", "top -bn1", false, true},
+		{"editor outside the allowed list", "vim notes.txt", false, false},
+		{"pager at the end of a pipeline", "ls -la | less", true, false},
+		{"non-interactive command", "ps aux", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := validateCommand(tt.command, tt.allowShellAccess)
+			if result.Valid != tt.wantValid {
+				t.Fatalf("validateCommand(%q) valid = %v, want %v (reason: %s)", tt.command, result.Valid, tt.wantValid, result.Reason)
+			}
+			if !tt.wantValid && result.Rule != "interactive_command" {
+				t.Errorf("validateCommand(%q) rule = %q, want interactive_command", tt.command, result.Rule)
+			}
+		})
+	}
+
+	result := validateCommand("top", false)
+	if !strings.Contains(result.Reason, "top -b -n1") {
+		t.Errorf("Expected batch mode guidance, got %q", result.Reason)
+	}
+
+	script := validateScript("#!/bin/bash\necho start\ntop\n")
+	if script.Valid || script.Rule != "interactive_command" || !strings.Contains(script.Reason, "line 3") {
+		t.Errorf("Expected the script to be rejected at line 3, got %+v", script)
+	}
+}
+
+func TestInitInteractiveCommands(t *testing.T) {
+	original := defaultSecurityPolicy.InteractiveCommands
+	defer func() { defaultSecurityPolicy.InteractiveCommands = original }()
+
+	t.Setenv("MCP_BASH_INTERACTIVE_COMMANDS", "top, tail")
+	InitInteractiveCommands()
+
+	if result := validateCommand("tail -f app.log", false); result.Valid {
+		t.Error("Expected tail to be rejected once configured as interactive")
+	}
+	if result := validateCommand("top -b -n1", false); !result.Valid {
+		t.Errorf("Expected top to keep its batch flag, got %s", result.Reason)
+	}
+	if result := validateCommand("vim notes.txt", false); result.Rule == "interactive_command" {
+		t.Error("Expected vim to be dropped from the interactive list")
+	}
+
+	t.Setenv("MCP_BASH_INTERACTIVE_COMMANDS", "")
+	InitInteractiveCommands()
+	if result := validateCommand("top", false); !result.Valid {
+		t.Errorf("Expected an empty list to turn the check off, got %s", result.Reason)
+	}
+}
+
+func TestExecuteCommandInteractiveAudit(t *testing.T) {
+	testDir := t.TempDir()
+	t.Setenv("REPO_PATH", testDir)
+	testLogFile := filepath.Join(testDir, "test_audit.log")
+
+	// Save original state
+	originalEnabled := auditEnabled
+	originalLogFile := auditLogFile
+	originalLogger := auditLogger
+
+	defer func() {
+		auditEnabled = originalEnabled
+		auditLogFile = originalLogFile
+		auditLogger = originalLogger
+	}()
+
+	auditEnabled = true
+	auditLogFile = testLogFile
+	if err := InitAuditLogger(); err != nil {
+		t.Fatalf("Failed to initialize audit logger: %v", err)
+	}
+	defer CloseAuditLogger()
+
+	if _, err := toolExecuteCommand(map[string]interface{}{"command": "top"}); err == nil {
+		t.Fatal("Expected top without batch mode to be rejected")
+	}
+
+	data, err := os.ReadFile(testLogFile)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	var entry AuditLog
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(data))), &entry); err != nil {
+		t.Fatalf("Failed to parse audit entry: %v", err)
+	}
+	if entry.Success || entry.Security == nil || entry.Security.Rule != "interactive_command" {
+		t.Errorf("Expected a failed interactive_command entry, got %+v", entry)
+	}
+}
@@ -1,12 +1,13 @@
 package main
 
 import (
-	"bufio"
 	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+
+	"github.com/code-aria/internal-mcp/internal/mcp"
 )
 
 // nothing just a commit test
@@ -17,6 +18,9 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Failed to initialize audit logger: %v\n", err)
 	}
 
+	// Override the interactive command list from MCP_BASH_INTERACTIVE_COMMANDS
+	InitInteractiveCommands()
+
 	// Ensure cleanup on exit
 	defer CloseAuditLogger()
 
@@ -29,9 +33,8 @@ func main() {
 		os.Exit(0)
 	}()
 
-	scanner := bufio.NewScanner(os.Stdin)
-	// Increase buffer size to handle large JSON-RPC messages
-	scanner.Buffer(nil, 10*1024*1024) // 10MB buffer
+	// Messages up to MCP_MAX_MESSAGE_BYTES (default 10MB) are accepted
+	scanner := mcp.NewScanner(os.Stdin)
 	encoder := json.NewEncoder(os.Stdout)
 
 	if err := handleInitialize(scanner, encoder); err != nil {
@@ -39,30 +42,6 @@ func main() {
 		os.Exit(1)
 	}
 
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
-		}
-		// Log large requests for debugging (warn if > 500KB, error if > 1MB)
-		if len(line) > 1_000_000 {
-			fmt.Fprintf(os.Stderr, "[ERROR] mcp-bash: Request size %d bytes exceeds 1MB buffer\n", len(line))
-		} else if len(line) > 500_000 {
-			fmt.Fprintf(os.Stderr, "[WARN] mcp-bash: Large request size %d bytes\n", len(line))
-		}
-
-		var msg MCPMessage
-		if err := json.Unmarshal(line, &msg); err != nil {
-			continue
-		}
-
-		if msg.Method != "" {
-			handleRequest(&msg, encoder)
-		}
-	}
-
-	// Check for scanner errors (e.g., token too long)
-	if err := scanner.Err(); err != nil {
-		fmt.Fprintf(os.Stderr, "[ERROR] mcp-bash: Scanner error: %v (buffer max: 10MB)\n", err)
-	}
+	// Handle requests
+	mcp.Serve(scanner, encoder, "mcp-bash", handleRequest)
 }
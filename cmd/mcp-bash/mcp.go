@@ -4,58 +4,23 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+
+	"github.com/code-aria/internal-mcp/internal/mcp"
 )
 
 // handleInitialize processes the MCP initialize request
 func handleInitialize(scanner *bufio.Scanner, encoder *json.Encoder) error {
-	if !scanner.Scan() {
-		return fmt.Errorf("no initialize request")
-	}
-
-	var initReq MCPMessage
-	if err := json.Unmarshal(scanner.Bytes(), &initReq); err != nil {
-		return fmt.Errorf("failed to parse initialize: %w", err)
-	}
-
-	response := MCPMessage{
-		JSONRPC: "2.0",
-		ID:      initReq.ID,
-		Result: InitializeResponse{
-			ProtocolVersion: "2024-11-05",
-			Capabilities: map[string]interface{}{
-				"tools": map[string]interface{}{},
-			},
-			ServerInfo: ServerInfo{
-				Name:    "mcp-bash",
-				Version: "1.0.0",
-			},
-		},
-	}
-
-	if err := encoder.Encode(response); err != nil {
-		return fmt.Errorf("failed to send initialize response: %w", err)
-	}
-
-	if !scanner.Scan() {
-		return fmt.Errorf("no initialized notification")
-	}
-
-	var initializedMsg MCPMessage
-	if err := json.Unmarshal(scanner.Bytes(), &initializedMsg); err != nil {
-		return fmt.Errorf("failed to parse initialized notification: %w", err)
-	}
-
-	// Validate that this is the initialized notification
-	if initializedMsg.Method != "notifications/initialized" {
-		return fmt.Errorf("expected initialized notification, got method: %s", initializedMsg.Method)
-	}
-
-	return nil
+	return mcp.ReadInitialize(scanner, encoder, ServerInfo{
+		Name:    "mcp-bash",
+		Version: "1.0.0",
+	})
 }
 
 // handleRequest routes MCP requests to appropriate handlers
 func handleRequest(msg *MCPMessage, encoder *json.Encoder) {
 	switch msg.Method {
+	case "ping":
+		mcp.SendPing(encoder, msg.ID)
 	case "tools/list":
 		handleToolsList(msg, encoder)
 	case "tools/call":
@@ -70,7 +35,7 @@ func handleToolsList(msg *MCPMessage, encoder *json.Encoder) {
 	tools := []Tool{
 		{
 			Name:        "apply_operations",
-			Description: "Execute multiple bash operations in a single batch call. Each operation can specify a timeout parameter (default: 180 seconds, max: 600 seconds). The LLM should control the timeout based on the expected operation duration - use higher timeouts for long-running operations like builds, tests, or installations.",
+			Description: "Execute multiple bash operations in a single batch call. Each operation can specify a timeout parameter (default: 180 seconds, max: 600 seconds). The LLM should control the timeout based on the expected operation duration - use higher timeouts for long-running operations like builds, tests, or installations. execute_command and execute_script return {exit_code, success, stdout, stderr, timeout, ...} whatever the exit status: a non-zero exit or a timeout gives success: false in the result, not an operation error, so the output is kept. Operations only fail for security policy rejections, invalid params and commands that cannot be started.",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -83,7 +48,7 @@ func handleToolsList(msg *MCPMessage, encoder *json.Encoder) {
 							"properties": map[string]interface{}{
 								"type": map[string]interface{}{
 									"type":        "string",
-									"description": "Operation type: execute_command, execute_script, check_command_exists",
+									"description": "Operation type: execute_command, execute_script, check_command_exists, validate_command, query_audit_log",
 								},
 							},
 						},
@@ -127,130 +92,21 @@ func handleToolCall(msg *MCPMessage, encoder *json.Encoder) {
 	sendError(encoder, msg.ID, -32601, fmt.Sprintf("Unknown tool: %s. Use apply_operations for batch operations", req.Name), nil)
 }
 
+// batchRunner dispatches apply_operations to the operation handlers
+var batchRunner = mcp.NewBatchRunner(map[string]mcp.OperationHandler{
+	"execute_command":      toolExecuteCommand,
+	"execute_script":       toolExecuteScript,
+	"check_command_exists": toolCheckCommandExists,
+	"validate_command":     toolValidateCommand,
+	"query_audit_log":      toolQueryAuditLog,
+})
+
 // handleBatchOperations processes a batch of operations
 func handleBatchOperations(msg *MCPMessage, encoder *json.Encoder, args map[string]interface{}) {
-	operations, ok := args["operations"].([]interface{})
-	if !ok {
-		sendError(encoder, msg.ID, -32602, "operations array is required", nil)
-		return
-	}
-
-	if len(operations) == 0 {
-		sendError(encoder, msg.ID, -32602, "operations array cannot be empty", nil)
-		return
-	}
-
-	var results []map[string]interface{}
-
-	for _, op := range operations {
-		opMap, ok := op.(map[string]interface{})
-		if !ok {
-			results = append(results, map[string]interface{}{
-				"operation": "unknown",
-				"params":    map[string]interface{}{},
-				"status":    "Error",
-				"message":   "Invalid operation format",
-			})
-			continue
-		}
-
-		opType, ok := opMap["type"].(string)
-		if !ok {
-			results = append(results, map[string]interface{}{
-				"operation": "unknown",
-				"params":    map[string]interface{}{},
-				"status":    "Error",
-				"message":   "Operation type is required",
-			})
-			continue
-		}
-
-		// Extract operation-specific arguments as params
-		params := make(map[string]interface{})
-		for k, v := range opMap {
-			if k != "type" {
-				params[k] = v
-			}
-		}
-
-		// Execute operation based on type
-		var result string
-		var err error
-
-		switch opType {
-		case "execute_command":
-			result, err = toolExecuteCommand(params)
-		case "execute_script":
-			result, err = toolExecuteScript(params)
-		case "check_command_exists":
-			result, err = toolCheckCommandExists(params)
-		default:
-			err = fmt.Errorf("unknown operation type: %s", opType)
-		}
-
-		if err != nil {
-			results = append(results, map[string]interface{}{
-				"operation": opType,
-				"params":    params,
-				"status":    "Error",
-				"message":   err.Error(),
-			})
-		} else {
-			// Parse JSON result if possible, otherwise use as string
-			var parsedResult interface{}
-			if jsonErr := json.Unmarshal([]byte(result), &parsedResult); jsonErr == nil {
-				// Successfully parsed JSON, use parsed result
-			} else {
-				// Not JSON, use string as-is
-				parsedResult = result
-			}
-
-			results = append(results, map[string]interface{}{
-				"operation": opType,
-				"params":    params,
-				"status":    "Success",
-				"result":    parsedResult,
-			})
-		}
-	}
-
-	// Serialize results to JSON text for MCP-compliant response format
-	resultsJSON, err := json.Marshal(map[string]interface{}{
-		"results": results,
-	})
-	if err != nil {
-		sendError(encoder, msg.ID, -32700, fmt.Sprintf("Failed to marshal results: %v", err), nil)
-		return
-	}
-
-	// Return results in MCP-compliant format using ToolsCallResponse
-	response := MCPMessage{
-		JSONRPC: "2.0",
-		ID:      msg.ID,
-		Result: ToolsCallResponse{
-			Content: []Content{
-				{
-					Type: "text",
-					Text: string(resultsJSON),
-				},
-			},
-			IsError: false,
-		},
-	}
-
-	encoder.Encode(response)
+	batchRunner.Handle(msg, encoder, args)
 }
 
 // sendError sends an error response
 func sendError(encoder *json.Encoder, id interface{}, code int, message string, data interface{}) {
-	response := MCPMessage{
-		JSONRPC: "2.0",
-		ID:      id,
-		Error: &MCPError{
-			Code:    code,
-			Message: message,
-			Data:    data,
-		},
-	}
-	encoder.Encode(response)
-}
\ No newline at end of file
+	mcp.SendError(encoder, id, code, message, data)
+}
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestHandleToolsList(t *testing.T) {
@@ -117,6 +121,40 @@ func TestHandleToolCall(t *testing.T) {
 	}
 }
 
+// TestPingAfterHandshake tests that ping gets a prompt empty result with the same id
+func TestPingAfterHandshake(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{}}}
+{"jsonrpc":"2.0","method":"notifications/initialized"}
+{"jsonrpc":"2.0","id":7,"method":"ping"}
+`
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	var output bytes.Buffer
+	encoder := json.NewEncoder(&output)
+
+	if err := handleInitialize(scanner, encoder); err != nil {
+		t.Fatalf("handleInitialize failed: %v", err)
+	}
+	output.Reset()
+
+	if !scanner.Scan() {
+		t.Fatal("Expected ping request")
+	}
+	var msg MCPMessage
+	if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+		t.Fatalf("Failed to parse ping request: %v", err)
+	}
+
+	start := time.Now()
+	handleRequest(&msg, encoder)
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("ping took %v, expected an immediate response", elapsed)
+	}
+
+	if got := strings.TrimSpace(output.String()); got != `{"jsonrpc":"2.0","id":7,"result":{}}` {
+		t.Errorf("Unexpected ping response: %s", got)
+	}
+}
+
 func TestSendError(t *testing.T) {
 	var output bytes.Buffer
 	encoder := json.NewEncoder(&output)
@@ -141,3 +179,42 @@ func TestSendError(t *testing.T) {
 		t.Errorf("Expected error message 'Test error', got '%s'", response.Error.Message)
 	}
 }
+
+// batchResultFields returns the sorted field names of a batch result
+func batchResultFields(result map[string]interface{}) []string {
+	fields := make([]string, 0, len(result))
+	for field := range result {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// TestBatchResultSchema checks batch results carry the shared schema. mcp-systeminfo has the
+// same test so both servers are held to identical field presence.
+func TestBatchResultSchema(t *testing.T) {
+	origAuditEnabled := auditEnabled
+	auditEnabled = false
+	defer func() { auditEnabled = origAuditEnabled }()
+
+	results := batchRunner.Run([]interface{}{
+		map[string]interface{}{"type": "check_command_exists", "command": "ls"},
+		map[string]interface{}{"type": "no_such_operation"},
+	})
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+
+	wantSuccess := "index,operation,params,result,status,success"
+	if got := strings.Join(batchResultFields(results[0]), ","); got != wantSuccess {
+		t.Errorf("Success result fields = %s, want %s", got, wantSuccess)
+	}
+	wantError := "error,index,message,operation,params,status,success"
+	if got := strings.Join(batchResultFields(results[1]), ","); got != wantError {
+		t.Errorf("Error result fields = %s, want %s", got, wantError)
+	}
+
+	if results[0]["success"] != true || results[1]["success"] != false || results[1]["index"] != 1 {
+		t.Errorf("Unexpected success or index values: %v, %v", results[0], results[1])
+	}
+}
@@ -61,6 +61,12 @@ func validateCommand(command string, allowShellAccess bool) *SecurityResult {
 		}
 	}
 
+	// Interactive commands are checked first so the guidance is given even for
+	// commands outside the allowed list
+	if result := checkInteractiveCommand(command); result != nil {
+		return result
+	}
+
 	baseCmd := parts[0]
 	if !defaultSecurityPolicy.AllowedCommands[baseCmd] {
 		return &SecurityResult{
@@ -141,6 +147,11 @@ func validateScript(script string) *SecurityResult {
 			continue
 		}
 
+		if result := checkInteractiveCommand(line); result != nil {
+			result.Reason = fmt.Sprintf("%s (line %d)", result.Reason, i+1)
+			return result
+		}
+
 		if !defaultSecurityPolicy.AllowedCommands[baseCmd] {
 			return &SecurityResult{
 				Valid:  false,
@@ -1,61 +1,23 @@
 package main
 
 import (
-	"encoding/json"
 	"time"
-)
-
-// MCP protocol types
-type MCPMessage struct {
-	JSONRPC string          `json:"jsonrpc"`
-	ID      interface{}     `json:"id,omitempty"`
-	Method  string          `json:"method,omitempty"`
-	Params  json.RawMessage `json:"params,omitempty"`
-	Result  interface{}     `json:"result,omitempty"`
-	Error   *MCPError       `json:"error,omitempty"`
-}
-
-type MCPError struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-}
-
-type InitializeResponse struct {
-	ProtocolVersion string                 `json:"protocolVersion"`
-	Capabilities    map[string]interface{} `json:"capabilities"`
-	ServerInfo      ServerInfo             `json:"serverInfo"`
-}
-
-type ServerInfo struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
-}
-
-type Tool struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	InputSchema map[string]interface{} `json:"inputSchema"`
-}
-
-type ToolsListResponse struct {
-	Tools []Tool `json:"tools"`
-}
 
-type ToolsCallRequest struct {
-	Name      string                 `json:"name"`
-	Arguments map[string]interface{} `json:"arguments,omitempty"`
-}
-
-type ToolsCallResponse struct {
-	Content []Content `json:"content"`
-	IsError bool      `json:"isError,omitempty"`
-}
+	"github.com/code-aria/internal-mcp/internal/mcp"
+)
 
-type Content struct {
-	Type string `json:"type"`
-	Text string `json:"text,omitempty"`
-}
+// MCP protocol types, shared with the other servers
+type (
+	MCPMessage         = mcp.Message
+	MCPError           = mcp.Error
+	InitializeResponse = mcp.InitializeResponse
+	ServerInfo         = mcp.ServerInfo
+	Tool               = mcp.Tool
+	ToolsListResponse  = mcp.ToolsListResponse
+	ToolsCallRequest   = mcp.ToolsCallRequest
+	ToolsCallResponse  = mcp.ToolsCallResponse
+	Content            = mcp.Content
+)
 
 // Bash operation types
 type BashOperation struct {
@@ -77,6 +39,7 @@ type BashResult struct {
 // Command execution result
 type CommandResult struct {
 	ExitCode       int    `json:"exit_code"`
+	Success        bool   `json:"success"` // exit_code is 0 and the command did not time out
 	Stdout         string `json:"stdout"`
 	Stderr         string `json:"stderr"`
 	DurationMs     int64  `json:"duration_ms"`
@@ -85,6 +48,8 @@ type CommandResult struct {
 	Timeout        bool   `json:"timeout,omitempty"`
 	LinesExecuted  int    `json:"lines_executed,omitempty"`
 	ScriptName     string `json:"script_name,omitempty"`
+	Truncated      bool   `json:"truncated,omitempty"`
+	TotalBytes     int64  `json:"total_bytes,omitempty"`
 }
 
 // Security validation result
@@ -121,6 +86,23 @@ type SecurityPolicy struct {
 	DefaultTimeout  int             `json:"default_timeout"`
 	MaxTimeout      int             `json:"max_timeout"`
 	AllowShellAccess bool           `json:"allow_shell_access"`
+	DefaultMaxOutput int            `json:"default_max_output"`
+	MaxOutput        int            `json:"max_output"`
+	InteractiveCommands map[string]InteractiveCommand `json:"interactive_commands"`
+}
+
+// InteractiveCommand describes a command that waits on a terminal unless run in batch mode
+type InteractiveCommand struct {
+	Guidance   string   `json:"guidance"`
+	BatchFlags []string `json:"batch_flags,omitempty"`
+}
+
+// Command validation result, returned by validate_command
+type CommandValidationResult struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason"`
+	Rule    string `json:"rule,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
 }
 
 // Command exists result
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/code-aria/internal-mcp/internal/filelang"
+)
+
+// commentTokens maps the language param of comment_lines and uncomment_lines to the
+// language's line comment token
+var commentTokens = map[string]string{
+	"go":         "//",
+	"c":          "//",
+	"cpp":        "//",
+	"csharp":     "//",
+	"java":       "//",
+	"javascript": "//",
+	"typescript": "//",
+	"kotlin":     "//",
+	"rust":       "//",
+	"swift":      "//",
+	"php":        "//",
+	"python":     "#",
+	"shell":      "#",
+	"bash":       "#",
+	"ruby":       "#",
+	"perl":       "#",
+	"r":          "#",
+	"yaml":       "#",
+	"toml":       "#",
+	"makefile":   "#",
+	"dockerfile": "#",
+	"powershell": "#",
+	"sql":        "--",
+	"lua":        "--",
+	"haskell":    "--",
+	"lisp":       ";",
+	"clojure":    ";",
+}
+
+// toolCommentLines comments out lines start_line to end_line (1-based, inclusive) with the
+// line comment token of language, chosen from the file extension when language is not
+// given. The token goes after each line's indentation; blank and already commented lines
+// are left alone, so commenting twice changes nothing.
+func toolCommentLines(args map[string]interface{}) (string, error) {
+	return editCommentLines(args, true)
+}
+
+// toolUncommentLines removes the line comment token, and one space after it, from the
+// commented lines of start_line to end_line. Lines that are not commented are left alone.
+func toolUncommentLines(args map[string]interface{}) (string, error) {
+	return editCommentLines(args, false)
+}
+
+// editCommentLines implements comment_lines (comment true) and uncomment_lines
+func editCommentLines(args map[string]interface{}, comment bool) (string, error) {
+	filePath, err := getFilePath(args)
+	if err != nil {
+		return "", err
+	}
+	token, err := commentToken(args, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	startLine, okStart := args["start_line"].(float64)
+	endLine, okEnd := args["end_line"].(float64)
+	if !okStart || !okEnd {
+		return "", fmt.Errorf("start_line and end_line are required")
+	}
+
+	fullPath := resolvePath(filePath)
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	currentContent, err := os.ReadFile(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if err := checkExpectedHash(args, filePath, currentContent); err != nil {
+		return "", err
+	}
+
+	// Edit with LF line endings and convert back when writing
+	lineEnding := detectLineEnding(string(currentContent))
+	lines := strings.Split(normalizeLineEndings(string(currentContent)), "\n")
+	lineCount := len(lines)
+	if lines[lineCount-1] == "" {
+		// A final newline does not start a line
+		lineCount--
+	}
+	first, last := int(startLine), int(endLine)
+	if first < 1 || last < first || last > lineCount {
+		return "", fmt.Errorf("line range %d-%d is outside the file's %d lines", first, last, lineCount)
+	}
+
+	linesChanged := 0
+	for i := first - 1; i < last; i++ {
+		line := lines[i]
+		rest := strings.TrimLeft(line, " \t")
+		indent := line[:len(line)-len(rest)]
+		switch {
+		case comment && rest != "" && !strings.HasPrefix(rest, token):
+			lines[i] = indent + token + " " + rest
+		case !comment && strings.HasPrefix(rest, token) && !(i == 0 && strings.HasPrefix(line, "#!")):
+			// A shebang looks like a # comment but is not one
+			rest = strings.TrimPrefix(rest, token)
+			lines[i] = indent + strings.TrimPrefix(rest, " ")
+		default:
+			continue
+		}
+		linesChanged++
+	}
+
+	verb := "Uncommented"
+	if comment {
+		verb = "Commented"
+	}
+	message := fmt.Sprintf("%s %d lines", verb, linesChanged)
+	if linesChanged > 0 {
+		newFileContent := strings.Join(lines, "\n")
+		message, err = finishEdit(args, filePath, newFileContent, func() error {
+			return writeFilePreserving(fullPath, newFileContent, lineEnding, info.Mode())
+		}, message)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	resultJSON, err := json.Marshal(map[string]interface{}{
+		"message":       message,
+		"file_path":     filePath,
+		"comment_token": token,
+		"lines_changed": linesChanged,
+		"sha256":        fileSHA256(fullPath),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// commentToken returns the line comment token for the language param, or for the file's
+// extension or name (Makefile, Dockerfile) when language is not given
+func commentToken(args map[string]interface{}, filePath string) (string, error) {
+	language, _ := args["language"].(string)
+	if language != "" {
+		token, ok := commentTokens[strings.ToLower(language)]
+		if !ok {
+			return "", fmt.Errorf("unsupported language %q", language)
+		}
+		return token, nil
+	}
+
+	base := strings.ToLower(filepath.Base(filePath))
+	language = filelang.ForPath(base)
+	if base == "makefile" || base == "dockerfile" {
+		language = base
+	}
+	token, ok := commentTokens[language]
+	if !ok {
+		return "", fmt.Errorf("cannot tell the comment token of %s from its extension; pass language", filePath)
+	}
+	return token, nil
+}
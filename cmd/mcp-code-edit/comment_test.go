@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// commentLines runs comment_lines or uncomment_lines and returns lines_changed
+func commentLines(t *testing.T, comment bool, args map[string]interface{}) int {
+	t.Helper()
+	tool := toolUncommentLines
+	if comment {
+		tool = toolCommentLines
+	}
+	result, err := tool(args)
+	if err != nil {
+		t.Fatalf("comment=%v %v: error = %v", comment, args, err)
+	}
+	var decoded struct {
+		LinesChanged int `json:"lines_changed"`
+	}
+	if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	return decoded.LinesChanged
+}
+
+// TestCommentLinesGo tests commenting and uncommenting a Go range with //, keeping the
+// indentation and leaving blank and already commented lines alone
+func TestCommentLinesGo(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	original := "package main\n\nfunc main() {\n\tx := 1\n\n\t// keep\n\tprint(x)\n}\n"
+	writeTestFile(t, dir, "main.go", original)
+	args := map[string]interface{}{"file_path": "main.go", "start_line": float64(4), "end_line": float64(7)}
+
+	if n := commentLines(t, true, args); n != 2 {
+		t.Errorf("Expected 2 lines commented, got %d", n)
+	}
+	commented := "package main\n\nfunc main() {\n\t// x := 1\n\n\t// keep\n\t// print(x)\n}\n"
+	if got := readTestFile(t, dir, "main.go"); got != commented {
+		t.Fatalf("After comment_lines:\n%s\nwant:\n%s", got, commented)
+	}
+
+	// Commenting again is a no-op
+	if n := commentLines(t, true, args); n != 0 || readTestFile(t, dir, "main.go") != commented {
+		t.Errorf("Expected commenting twice to change nothing, changed %d lines", n)
+	}
+
+	if n := commentLines(t, false, args); n != 3 {
+		t.Errorf("Expected 3 lines uncommented, got %d", n)
+	}
+	if got, want := readTestFile(t, dir, "main.go"), strings.Replace(original, "// keep", "keep", 1); got != want {
+		t.Errorf("After uncomment_lines:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestCommentLinesShell tests # comments, chosen by the .sh extension or by language, and
+// that uncommenting lines that are not commented is a no-op
+func TestCommentLinesShell(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	original := "#!/bin/sh\nset -e\nif true; then\n  echo hi\nfi\n"
+	writeTestFile(t, dir, "run.sh", original)
+	writeTestFile(t, dir, "run", original)
+
+	if n := commentLines(t, false, map[string]interface{}{"file_path": "run.sh", "start_line": float64(1), "end_line": float64(5)}); n != 0 {
+		t.Errorf("Expected uncommenting plain lines and the shebang to change nothing, changed %d", n)
+	}
+	if got := readTestFile(t, dir, "run.sh"); got != original {
+		t.Errorf("Expected run.sh unchanged, got:\n%s", got)
+	}
+
+	commented := "#!/bin/sh\nset -e\n# if true; then\n  # echo hi\n# fi\n"
+	commentLines(t, true, map[string]interface{}{"file_path": "run.sh", "start_line": float64(3), "end_line": float64(5)})
+	if got := readTestFile(t, dir, "run.sh"); got != commented {
+		t.Errorf("After comment_lines:\n%s\nwant:\n%s", got, commented)
+	}
+
+	// A file without an extension needs language
+	if _, err := toolCommentLines(map[string]interface{}{"file_path": "run", "start_line": float64(3), "end_line": float64(5)}); err == nil || !strings.Contains(err.Error(), "pass language") {
+		t.Errorf("Expected an error asking for language, got %v", err)
+	}
+	// So does a recognized language without line comments
+	if _, err := commentToken(map[string]interface{}{}, "package.json"); err == nil || !strings.Contains(err.Error(), "pass language") {
+		t.Errorf("Expected an error asking for language for a JSON file, got %v", err)
+	}
+	commentLines(t, true, map[string]interface{}{"file_path": "run", "language": "shell", "start_line": float64(3), "end_line": float64(5)})
+	if got := readTestFile(t, dir, "run"); got != commented {
+		t.Errorf("After comment_lines with language:\n%s\nwant:\n%s", got, commented)
+	}
+
+	if _, err := toolCommentLines(map[string]interface{}{"file_path": "run.sh", "start_line": float64(4), "end_line": float64(9)}); err == nil {
+		t.Error("Expected an error for a range past the end of the file")
+	}
+}
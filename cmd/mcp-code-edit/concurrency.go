@@ -0,0 +1,54 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// checkExpectedHash implements optimistic concurrency for edits of existing files. When args
+// has expected_sha256, the hex SHA-256 of the file as it was read, content must still hash
+// to it; otherwise the file changed since the caller read it and the edit is refused with
+// the actual hash, so the caller can re-read and retry.
+func checkExpectedHash(args map[string]interface{}, filePath string, content []byte) error {
+	expected, ok := args["expected_sha256"].(string)
+	if !ok || expected == "" {
+		return nil
+	}
+
+	sum := sha256.Sum256(content)
+	actual := hex.EncodeToString(sum[:])
+	if !strings.EqualFold(strings.TrimSpace(expected), actual) {
+		return fmt.Errorf("conflict: %s changed since it was read: expected sha256 %s, actual %s", filePath, expected, actual)
+	}
+	return nil
+}
+
+// fileSHA256 returns the hex SHA-256 of the file at fullPath as it is on disk, or "" when it
+// cannot be read. Edit results report it so the next edit can pass it as expected_sha256
+// without reading the file again.
+func fileSHA256(fullPath string) string {
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// editResult is the result of an operation with nothing to report beyond its message: the
+// message, the file and the file's sha256 afterwards, unchanged by a dry run
+func editResult(filePath, message string) (string, error) {
+	resultJSON, err := json.Marshal(map[string]interface{}{
+		"message":   message,
+		"file_path": filePath,
+		"sha256":    fileSHA256(resolvePath(filePath)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return string(resultJSON), nil
+}
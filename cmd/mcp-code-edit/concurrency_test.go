@@ -0,0 +1,78 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// sha256Hex returns the hex SHA-256 of content
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// TestExpectedHash tests that edits apply when expected_sha256 matches the file and are
+// refused, leaving the file untouched, when it does not
+func TestExpectedHash(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	original := "package main\n\nconst name = \"a\"\n"
+	changed := "package main\n\nconst name = \"changed by someone else\"\n"
+
+	tests := []struct {
+		name string
+		op   func(args map[string]interface{}) (string, error)
+		args map[string]interface{}
+	}{
+		{"apply_diff", toolApplyDiff, map[string]interface{}{"old_content": "name", "new_content": "title"}},
+		{"replace_code", toolReplaceCode, map[string]interface{}{"old_code": "name", "new_code": "title"}},
+		{"multi_edit", toolMultiEdit, map[string]interface{}{"edits": []interface{}{
+			map[string]interface{}{"old_code": "name", "new_code": "title"},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := map[string]interface{}{"file_path": "main.go"}
+			for k, v := range tt.args {
+				args[k] = v
+			}
+
+			// The caller read original, but the file has changed since
+			writeTestFile(t, dir, "main.go", changed)
+			args["expected_sha256"] = sha256Hex(original)
+			_, err := tt.op(args)
+			if err == nil || !strings.Contains(err.Error(), "conflict") || !strings.Contains(err.Error(), sha256Hex(changed)) {
+				t.Fatalf("Expected a conflict error with the actual hash, got %v", err)
+			}
+			if got := readTestFile(t, dir, "main.go"); got != changed {
+				t.Errorf("Expected the file to be untouched, got %q", got)
+			}
+
+			writeTestFile(t, dir, "main.go", original)
+			args["expected_sha256"] = strings.ToUpper(sha256Hex(original))
+			result, err := tt.op(args)
+			if err != nil {
+				t.Fatalf("Expected a matching hash to apply, got %v", err)
+			}
+			got := readTestFile(t, dir, "main.go")
+			if !strings.Contains(got, "const title") {
+				t.Errorf("Expected the edit to be applied, got %q", got)
+			}
+
+			// The result carries the new hash, ready for the next edit
+			var decoded struct {
+				SHA256 string `json:"sha256"`
+			}
+			if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+				t.Fatalf("Failed to parse result %q: %v", result, err)
+			}
+			if decoded.SHA256 != sha256Hex(got) {
+				t.Errorf("Expected sha256 %s of the edited file, got %q", sha256Hex(got), decoded.SHA256)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// lineEndingTargets maps the target param of normalize_line_endings to the line ending
+var lineEndingTargets = map[string]string{
+	"lf":   "\n",
+	"crlf": "\r\n",
+}
+
+// toolNormalizeLineEndings rewrites a file so every line ends with the target line ending,
+// lf or crlf, and reports how many lines had to change. Binary files are refused.
+func toolNormalizeLineEndings(args map[string]interface{}) (string, error) {
+	filePath, err := getFilePath(args)
+	if err != nil {
+		return "", err
+	}
+
+	target, _ := args["target"].(string)
+	lineEnding, ok := lineEndingTargets[strings.ToLower(target)]
+	if !ok {
+		return "", fmt.Errorf("target must be lf or crlf, got %q", target)
+	}
+
+	fullPath := resolvePath(filePath)
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("not a regular file: %s", filePath)
+	}
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if bytes.IndexByte(content, 0) >= 0 {
+		return "", fmt.Errorf("refusing to normalize binary file: %s", filePath)
+	}
+
+	crlf := bytes.Count(content, []byte("\r\n"))
+	linesChanged := crlf
+	if lineEnding == "\r\n" {
+		linesChanged = bytes.Count(content, []byte("\n")) - crlf
+	}
+
+	if linesChanged > 0 {
+		if err := writeFilePreserving(fullPath, normalizeLineEndings(string(content)), lineEnding, info.Mode()); err != nil {
+			return "", err
+		}
+	}
+
+	resultJSON, err := json.Marshal(map[string]interface{}{
+		"message":       fmt.Sprintf("Line endings normalized to %s", strings.ToUpper(target)),
+		"file_path":     filePath,
+		"target":        strings.ToLower(target),
+		"lines_changed": linesChanged,
+		"sha256":        fileSHA256(fullPath),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return string(resultJSON), nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// normalizeTestFile runs normalize_line_endings and returns the lines_changed it reports
+func normalizeTestFile(t *testing.T, name, target string) int {
+	t.Helper()
+	result, err := toolNormalizeLineEndings(map[string]interface{}{"file_path": name, "target": target})
+	if err != nil {
+		t.Fatalf("toolNormalizeLineEndings(%s, %s) error = %v", name, target, err)
+	}
+	var decoded struct {
+		LinesChanged int `json:"lines_changed"`
+	}
+	if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	return decoded.LinesChanged
+}
+
+// TestNormalizeLineEndings tests converting CRLF to LF and back, including mixed files
+func TestNormalizeLineEndings(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	writeTestFile(t, dir, "mixed.txt", "one\r\ntwo\nthree\r\nfour")
+
+	if changed := normalizeTestFile(t, "mixed.txt", "lf"); changed != 2 {
+		t.Errorf("CRLF to LF changed %d lines, want 2", changed)
+	}
+	if got := readTestFile(t, dir, "mixed.txt"); got != "one\ntwo\nthree\nfour" {
+		t.Errorf("After LF normalization: %q", got)
+	}
+
+	if changed := normalizeTestFile(t, "mixed.txt", "crlf"); changed != 3 {
+		t.Errorf("LF to CRLF changed %d lines, want 3", changed)
+	}
+	if got := readTestFile(t, dir, "mixed.txt"); got != "one\r\ntwo\r\nthree\r\nfour" {
+		t.Errorf("After CRLF normalization: %q", got)
+	}
+
+	if changed := normalizeTestFile(t, "mixed.txt", "CRLF"); changed != 0 {
+		t.Errorf("Expected an already normalized file to change 0 lines, got %d", changed)
+	}
+}
+
+// TestNormalizeLineEndingsRejects tests that binary files and unknown targets are refused
+func TestNormalizeLineEndingsRejects(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	binary := "PNG\r\n\x00\x01\n"
+	writeTestFile(t, dir, "image.png", binary)
+	_, err := toolNormalizeLineEndings(map[string]interface{}{"file_path": "image.png", "target": "lf"})
+	if err == nil || !strings.Contains(err.Error(), "binary") {
+		t.Errorf("Expected a binary file error, got %v", err)
+	}
+	if got := readTestFile(t, dir, "image.png"); got != binary {
+		t.Errorf("Expected the binary file to be untouched, got %q", got)
+	}
+
+	writeTestFile(t, dir, "notes.txt", "a\r\n")
+	if _, err := toolNormalizeLineEndings(map[string]interface{}{"file_path": "notes.txt", "target": "cr"}); err == nil {
+		t.Error("Expected an error for an unknown target")
+	}
+}
@@ -7,12 +7,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/code-aria/internal-mcp/internal/mcp"
 )
 
 func main() {
-	scanner := bufio.NewScanner(os.Stdin)
-	// Increase buffer size to handle large JSON-RPC messages
-	scanner.Buffer(nil, 10*1024*1024) // 10MB buffer
+	// Messages up to MCP_MAX_MESSAGE_BYTES (default 10MB) are accepted
+	scanner := mcp.NewScanner(os.Stdin)
 	encoder := json.NewEncoder(os.Stdout)
 
 	if err := handleInitialize(scanner, encoder); err != nil {
@@ -20,32 +21,8 @@ func main() {
 		os.Exit(1)
 	}
 
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
-		}
-		// Log large requests for debugging (warn if > 500KB, error if > 1MB)
-		if len(line) > 1_000_000 {
-			fmt.Fprintf(os.Stderr, "[ERROR] mcp-code-edit: Request size %d bytes exceeds 1MB buffer\n", len(line))
-		} else if len(line) > 500_000 {
-			fmt.Fprintf(os.Stderr, "[WARN] mcp-code-edit: Large request size %d bytes\n", len(line))
-		}
-
-		var msg MCPMessage
-		if err := json.Unmarshal(line, &msg); err != nil {
-			continue
-		}
-
-		if msg.Method != "" {
-			handleRequest(&msg, encoder)
-		}
-	}
-
-	// Check for scanner errors (e.g., token too long)
-	if err := scanner.Err(); err != nil {
-		fmt.Fprintf(os.Stderr, "[ERROR] mcp-code-edit: Scanner error: %v (buffer max: 10MB)\n", err)
-	}
+	// Handle requests
+	mcp.Serve(scanner, encoder, "mcp-code-edit", handleRequest)
 }
 
 func handleInitialize(scanner *bufio.Scanner, encoder *json.Encoder) error {
@@ -96,6 +73,8 @@ func handleInitialize(scanner *bufio.Scanner, encoder *json.Encoder) error {
 
 func handleRequest(msg *MCPMessage, encoder *json.Encoder) {
 	switch msg.Method {
+	case "ping":
+		mcp.SendPing(encoder, msg.ID)
 	case "tools/list":
 		handleToolsList(msg, encoder)
 	case "tools/call":
@@ -122,11 +101,15 @@ func handleToolsList(msg *MCPMessage, encoder *json.Encoder) {
 							"properties": map[string]interface{}{
 								"type": map[string]interface{}{
 									"type":        "string",
-									"description": "Operation type: apply_diff, replace_code, create_file, delete_file, rename_file, move_file, copy_file",
+									"description": "Operation type: apply_diff, replace_code, create_file, append_to_file, delete_file, rename_file, move_file, copy_file, search_replace_files, normalize_line_endings, multi_edit, comment_lines, uncomment_lines, set_json_path. append_to_file takes file_path, content and optional ensure_newline (add a newline first if the file does not end with one); it creates the file if missing. create_file, apply_diff, replace_code, append_to_file and multi_edit accept validate (parse the resulting .go file and report syntax errors; the file is still written) and dry_run (write nothing, only validate). apply_diff, replace_code and multi_edit accept expected_sha256, the hex SHA-256 of the file when it was read; if the file no longer matches, the edit is refused with a conflict error giving the actual hash. Every operation except delete_file returns the file's sha256 after it ran, for the next expected_sha256: apply_diff, replace_code, create_file, append_to_file, rename_file and copy_file return {message, file_path, sha256}, and the others add sha256 to their results (per file for search_replace_files). search_replace_files takes file_patterns (globs matched against the file name, or the repository path when they contain a slash), search, replace, and optional regex, exclude_patterns and dry_run (only report the counts); it edits every matching file under REPO_PATH, skipping hidden, vendor, node_modules and third_party directories. normalize_line_endings takes file_path and target (lf or crlf), rewrites every line ending to the target and returns {file_path, target, lines_changed}; binary files are refused. multi_edit takes file_path and edits, an array of {old_code, new_code} (replace the first occurrence) or {start_line, end_line, new_code} (replace those lines, 1-based and inclusive), and applies them all in one write, returning {message, file_path, edits_applied}; every edit is located in the original file, so line numbers do not shift, and overlapping edits are rejected without writing. comment_lines and uncomment_lines take file_path, start_line and end_line (1-based, inclusive) and an optional language (e.g. go, python, shell, sql) choosing the line comment token, which otherwise follows the file extension; the token goes after each line's indentation, blank and already commented lines are left alone, and uncomment_lines leaves lines without the token alone, so both are idempotent. They return {message, file_path, comment_token, lines_changed} and accept expected_sha256 and dry_run. set_json_path takes file_path, json_path (dotted keys with an optional leading $., and brackets for array indexes or keys containing dots, e.g. servers[0].port or metadata[\"app.kubernetes.io/name\"]) and value (any JSON value); it sets the value in a .json, .yaml or .yml file, creating missing object keys on the way, and in JSON an index one past the end appends to an array. The path is named json_path because path is an alias of file_path. Only the changed value is rewritten, so the rest of the file keeps its formatting, key order and YAML comments; YAML must be block mappings along the path, and the edited YAML is parsed again and refused unless it holds the new value. It returns {message, file_path, json_path, format, created} and accepts expected_sha256 and dry_run",
 								},
 							},
 						},
 					},
+					"transactional": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Apply the batch all-or-nothing: stop at the first failing operation and restore every file the batch touched. The response includes a 'transaction' object with 'committed' and, on failure, 'failed_index' and 'failed_operation'. Default: false",
+					},
 				},
 				"required": []string{"operations"},
 			},
@@ -165,128 +148,50 @@ func handleToolCall(msg *MCPMessage, encoder *json.Encoder) {
 	sendError(encoder, msg.ID, -32601, fmt.Sprintf("Unknown tool: %s. Use apply_operations for batch operations", req.Name), nil)
 }
 
+// batchRunner dispatches apply_operations to the operation handlers
+var batchRunner = mcp.NewBatchRunner(map[string]mcp.OperationHandler{
+	"apply_diff":             toolApplyDiff,
+	"replace_code":           toolReplaceCode,
+	"create_file":            toolCreateFile,
+	"append_to_file":         toolAppendToFile,
+	"delete_file":            toolDeleteFile,
+	"rename_file":            toolRenameFile,
+	"move_file":              toolRenameFile,
+	"copy_file":              toolCopyFile,
+	"search_replace_files":   toolSearchReplaceFiles,
+	"normalize_line_endings": toolNormalizeLineEndings,
+	"multi_edit":             toolMultiEdit,
+	"comment_lines":          toolCommentLines,
+	"uncomment_lines":        toolUncommentLines,
+	"set_json_path":          toolSetJSONPath,
+}).WithParamsFilter(optimizeParams).WithRequiredParams(mcp.RequiredParams{
+	// A diff, or old_content with new_content, describes the edit
+	"apply_diff": {{"file_path", "path"}, {"diff", "old_content"}},
+})
+
+// handleBatchOperations processes a batch of operations. With "transactional": true the
+// batch is all-or-nothing and touched files are restored if any operation fails.
 func handleBatchOperations(msg *MCPMessage, encoder *json.Encoder, args map[string]interface{}) {
-	operations, ok := args["operations"].([]interface{})
-	if !ok {
-		sendError(encoder, msg.ID, -32602, "operations array is required", nil)
+	if transactional, _ := args["transactional"].(bool); !transactional {
+		batchRunner.Handle(msg, encoder, args)
 		return
 	}
 
-	if len(operations) == 0 {
-		sendError(encoder, msg.ID, -32602, "operations array cannot be empty", nil)
+	operations, err := mcp.Operations(args)
+	if err != nil {
+		sendError(encoder, msg.ID, mcp.CodeInvalidParams, err.Error(), nil)
 		return
 	}
 
-	var results []map[string]interface{}
-
-	for _, op := range operations {
-		opMap, ok := op.(map[string]interface{})
-		if !ok {
-			results = append(results, map[string]interface{}{
-				"operation": "unknown",
-				"params":    map[string]interface{}{},
-				"status":    "Error",
-				"message":   "Invalid operation format",
-			})
-			continue
-		}
-
-		opType, ok := opMap["type"].(string)
-		if !ok {
-			results = append(results, map[string]interface{}{
-				"operation": "unknown",
-				"params":    map[string]interface{}{},
-				"status":    "Error",
-				"message":   "Operation type is required",
-			})
-			continue
-		}
-
-		// Extract operation-specific arguments as params
-		params := make(map[string]interface{})
-		for k, v := range opMap {
-			if k != "type" {
-				params[k] = v
-			}
-		}
-
-		// Execute operation based on type
-		var result string
-		var err error
-
-		switch opType {
-		case "apply_diff":
-			result, err = toolApplyDiff(params)
-		case "replace_code":
-			result, err = toolReplaceCode(params)
-		case "create_file":
-			result, err = toolCreateFile(params)
-		case "delete_file":
-			result, err = toolDeleteFile(params)
-		case "rename_file", "move_file":
-			result, err = toolRenameFile(params)
-		case "copy_file":
-			result, err = toolCopyFile(params)
-		default:
-			err = fmt.Errorf("unknown operation type: %s", opType)
-		}
-
-		// Optimize params before adding to results
-		optimizedParams := optimizeParams(opType, params)
-
-		if err != nil {
-			results = append(results, map[string]interface{}{
-				"operation": opType,
-				"params":    optimizedParams,
-				"status":    "Error",
-				"message":   err.Error(),
-			})
-		} else {
-			// Parse JSON result if possible, otherwise use as string
-			var parsedResult interface{}
-			if jsonErr := json.Unmarshal([]byte(result), &parsedResult); jsonErr == nil {
-				// Successfully parsed JSON, use parsed result
-			} else {
-				// Not JSON, use string as-is
-				parsedResult = result
-			}
-
-			results = append(results, map[string]interface{}{
-				"operation": opType,
-				"params":    optimizedParams,
-				"status":    "Success",
-				"result":    parsedResult,
-			})
-		}
-	}
-
-	// Serialize results to JSON text for MCP-compliant response format
-	resultsJSON, err := json.Marshal(map[string]interface{}{
-		"results": results,
-	})
+	body, err := runTransaction(operations)
 	if err != nil {
-		sendError(encoder, msg.ID, -32700, fmt.Sprintf("Failed to marshal results: %v", err), nil)
+		sendError(encoder, msg.ID, mcp.CodeInvalidParams, fmt.Sprintf("transaction not started: %v", err), nil)
 		return
 	}
-
-	// Return results in MCP-compliant format using ToolsCallResponse
-	response := MCPMessage{
-		JSONRPC: "2.0",
-		ID:      msg.ID,
-		Result: ToolsCallResponse{
-			Content: []Content{
-				{
-					Type: "text",
-					Text: string(resultsJSON),
-				},
-			},
-			IsError: false,
-		},
-	}
-
-	encoder.Encode(response)
+	mcp.SendBatchResponse(encoder, msg.ID, body)
 }
 
+
 // optimizeParams optimizes params for response by omitting large content fields
 // for write operations and truncating long strings for other operations
 func optimizeParams(opType string, params map[string]interface{}) map[string]interface{} {
@@ -306,7 +211,7 @@ func optimizeParams(opType string, params map[string]interface{}) map[string]int
 	
 	// For write operations, omit content fields entirely
 	switch opType {
-	case "create_file":
+	case "create_file", "append_to_file":
 		// Omit content field
 		for k, v := range params {
 			if k == "content" {
@@ -326,6 +231,26 @@ func optimizeParams(opType string, params map[string]interface{}) map[string]int
 		}
 		return optimized
 		
+	case "multi_edit":
+		// Omit the edits, which hold the code
+		for k, v := range params {
+			if k == "edits" {
+				continue
+			}
+			optimized[k] = v
+		}
+		return optimized
+		
+	case "set_json_path":
+		// Omit the value, which may be a whole object
+		for k, v := range params {
+			if k == "value" {
+				continue
+			}
+			optimized[k] = v
+		}
+		return optimized
+		
 	case "apply_diff":
 		// Omit diff and content fields
 		for k, v := range params {
@@ -459,21 +384,30 @@ func toolApplyDiff(args map[string]interface{}) (string, error) {
 	fullPath := resolvePath(filePath)
 
 	// Read current file
-	currentContent, err := os.ReadFile(fullPath)
+	info, err := os.Stat(fullPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return "", fmt.Errorf("file does not exist: %s", filePath)
 		}
+		return "", fmt.Errorf("failed to stat file: %w", err)
+	}
+	currentContent, err := os.ReadFile(fullPath)
+	if err != nil {
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
+	if err := checkExpectedHash(args, filePath, currentContent); err != nil {
+		return "", err
+	}
 
-	currentStr := string(currentContent)
+	// Edit with LF line endings and convert back when writing
+	lineEnding := detectLineEnding(string(currentContent))
+	currentStr := normalizeLineEndings(string(currentContent))
 	var newFileContent string
 
 	// Check if diff format is provided
 	if diff, ok := args["diff"].(string); ok && diff != "" {
 		// Apply unified diff format
-		newFileContent, err = applyUnifiedDiff(currentStr, diff)
+		newFileContent, err = applyUnifiedDiff(currentStr, normalizeLineEndings(diff))
 		if err != nil {
 			return "", fmt.Errorf("failed to apply diff: %w", err)
 		}
@@ -489,6 +423,9 @@ func toolApplyDiff(args map[string]interface{}) (string, error) {
 			return "", fmt.Errorf("new_content or diff is required")
 		}
 
+		oldContent = normalizeLineEndings(oldContent)
+		newContent = normalizeLineEndings(newContent)
+
 		// Replace old_content with new_content
 		if !strings.Contains(currentStr, oldContent) {
 			return "", fmt.Errorf("old_content not found in file")
@@ -497,17 +434,14 @@ func toolApplyDiff(args map[string]interface{}) (string, error) {
 		newFileContent = strings.Replace(currentStr, oldContent, newContent, 1)
 	}
 
-	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
-		return "", fmt.Errorf("failed to create directory: %w", err)
-	}
-
-	// Write file
-	if err := os.WriteFile(fullPath, []byte(newFileContent), 0644); err != nil {
-		return "", fmt.Errorf("failed to write file: %w", err)
+	// Write file, keeping its permissions and line endings
+	message, err := finishEdit(args, filePath, newFileContent, func() error {
+		return writeFilePreserving(fullPath, newFileContent, lineEnding, info.Mode())
+	}, "Diff applied successfully")
+	if err != nil {
+		return "", err
 	}
-
-	return "Diff applied successfully", nil
+	return editResult(filePath, message)
 }
 
 func toolReplaceCode(args map[string]interface{}) (string, error) {
@@ -537,12 +471,23 @@ func toolReplaceCode(args map[string]interface{}) (string, error) {
 
 	fullPath := resolvePath(filePath)
 
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
 	currentContent, err := os.ReadFile(fullPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
+	if err := checkExpectedHash(args, filePath, currentContent); err != nil {
+		return "", err
+	}
 
-	currentStr := string(currentContent)
+	// Edit with LF line endings and convert back when writing
+	lineEnding := detectLineEnding(string(currentContent))
+	currentStr := normalizeLineEndings(string(currentContent))
+	oldCode = normalizeLineEndings(oldCode)
+	newCode = normalizeLineEndings(newCode)
 
 	if !strings.Contains(currentStr, oldCode) {
 		return "", fmt.Errorf("old_code not found in file")
@@ -550,11 +495,13 @@ func toolReplaceCode(args map[string]interface{}) (string, error) {
 
 	newFileContent := strings.Replace(currentStr, oldCode, newCode, 1)
 
-	if err := os.WriteFile(fullPath, []byte(newFileContent), 0644); err != nil {
-		return "", fmt.Errorf("failed to write file: %w", err)
+	message, err := finishEdit(args, filePath, newFileContent, func() error {
+		return writeFilePreserving(fullPath, newFileContent, lineEnding, info.Mode())
+	}, "Code replaced successfully")
+	if err != nil {
+		return "", err
 	}
-
-	return "Code replaced successfully", nil
+	return editResult(filePath, message)
 }
 
 func toolCreateFile(args map[string]interface{}) (string, error) {
@@ -575,17 +522,104 @@ func toolCreateFile(args map[string]interface{}) (string, error) {
 		return "", fmt.Errorf("file already exists")
 	}
 
-	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
-		return "", fmt.Errorf("failed to create directory: %w", err)
+	message, err := finishEdit(args, filePath, content, func() error {
+		// Ensure directory exists
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+
+		// Write file
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		return nil
+	}, "File created successfully")
+	if err != nil {
+		return "", err
+	}
+	return editResult(filePath, message)
+}
+
+func toolAppendToFile(args map[string]interface{}) (string, error) {
+	filePath, err := getFilePath(args)
+	if err != nil {
+		return "", err
+	}
+
+	content, ok := args["content"].(string)
+	if !ok {
+		return "", fmt.Errorf("content is required")
+	}
+
+	ensureNewline, _ := args["ensure_newline"].(bool)
+
+	fullPath := resolvePath(filePath)
+
+	// Validation and dry runs look at the whole file the append would produce
+	var result string
+	validate, _ := args["validate"].(bool)
+	dryRun, _ := args["dry_run"].(bool)
+	if validate || dryRun {
+		existing, err := os.ReadFile(fullPath)
+		if err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to read file: %w", err)
+		}
+		result = string(existing)
+		if ensureNewline && result != "" && !strings.HasSuffix(result, "\n") {
+			result += "\n"
+		}
+		result += content
+	}
+
+	message, err := finishEdit(args, filePath, result, func() error {
+		// Ensure directory exists
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+
+		file, err := os.OpenFile(fullPath, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open file: %w", err)
+		}
+		defer file.Close()
+
+		if ensureNewline {
+			missing, err := missingTrailingNewline(file)
+			if err != nil {
+				return err
+			}
+			if missing {
+				content = "\n" + content
+			}
+		}
+
+		if _, err := file.WriteString(content); err != nil {
+			return fmt.Errorf("failed to append to file: %w", err)
+		}
+		return nil
+	}, "Content appended successfully")
+	if err != nil {
+		return "", err
 	}
+	return editResult(filePath, message)
+}
 
-	// Write file
-	if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
-		return "", fmt.Errorf("failed to write file: %w", err)
+// missingTrailingNewline reports whether a non-empty file does not end in a newline,
+// reading only its last byte
+func missingTrailingNewline(file *os.File) (bool, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return false, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if info.Size() == 0 {
+		return false, nil
 	}
 
-	return "File created successfully", nil
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, info.Size()-1); err != nil {
+		return false, fmt.Errorf("failed to read file: %w", err)
+	}
+	return last[0] != '\n', nil
 }
 
 func toolDeleteFile(args map[string]interface{}) (string, error) {
@@ -643,7 +677,7 @@ func toolRenameFile(args map[string]interface{}) (string, error) {
 		return "", fmt.Errorf("failed to rename file: %w", err)
 	}
 
-	return "File renamed successfully", nil
+	return editResult(newPath, "File renamed successfully")
 }
 
 func toolCopyFile(args map[string]interface{}) (string, error) {
@@ -702,7 +736,38 @@ func toolCopyFile(args map[string]interface{}) (string, error) {
 		return "", fmt.Errorf("failed to write destination file: %w", err)
 	}
 
-	return "File copied successfully", nil
+	return editResult(destPath, "File copied successfully")
+}
+
+// detectLineEnding returns "\r\n" when most lines of content end in CRLF, and "\n" otherwise
+func detectLineEnding(content string) string {
+	crlf := strings.Count(content, "\r\n")
+	lf := strings.Count(content, "\n") - crlf
+	if crlf > lf {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// normalizeLineEndings converts CRLF line endings to LF
+func normalizeLineEndings(content string) string {
+	return strings.ReplaceAll(content, "\r\n", "\n")
+}
+
+// writeFilePreserving writes LF-normalized content using lineEnding and keeps the file's
+// original permission bits, e.g. the executable bit of scripts
+func writeFilePreserving(fullPath, content, lineEnding string, mode os.FileMode) error {
+	if lineEnding != "\n" {
+		content = strings.ReplaceAll(content, "\n", lineEnding)
+	}
+	if err := os.WriteFile(fullPath, []byte(content), mode.Perm()); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	// WriteFile only applies the mode when it creates the file
+	if err := os.Chmod(fullPath, mode.Perm()); err != nil {
+		return fmt.Errorf("failed to restore file permissions: %w", err)
+	}
+	return nil
 }
 
 func resolvePath(path string) string {
@@ -731,54 +796,16 @@ func sendError(encoder *json.Encoder, id interface{}, code int, message string,
 	encoder.Encode(response)
 }
 
-// MCP types
-type MCPMessage struct {
-	JSONRPC string          `json:"jsonrpc"`
-	ID      interface{}     `json:"id,omitempty"`
-	Method  string          `json:"method,omitempty"`
-	Params  json.RawMessage `json:"params,omitempty"`
-	Result  interface{}     `json:"result,omitempty"`
-	Error   *MCPError       `json:"error,omitempty"`
-}
-
-type MCPError struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-}
-
-type InitializeResponse struct {
-	ProtocolVersion string                 `json:"protocolVersion"`
-	Capabilities    map[string]interface{} `json:"capabilities"`
-	ServerInfo      ServerInfo             `json:"serverInfo"`
-}
-
-type ServerInfo struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
-}
-
-type Tool struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	InputSchema map[string]interface{} `json:"inputSchema"`
-}
-
-type ToolsListResponse struct {
-	Tools []Tool `json:"tools"`
-}
-
-type ToolsCallRequest struct {
-	Name      string                 `json:"name"`
-	Arguments map[string]interface{} `json:"arguments,omitempty"`
-}
-
-type ToolsCallResponse struct {
-	Content []Content `json:"content"`
-	IsError bool      `json:"isError,omitempty"`
-}
+// MCP protocol types, shared with the other servers
+type (
+	MCPMessage         = mcp.Message
+	MCPError           = mcp.Error
+	InitializeResponse = mcp.InitializeResponse
+	ServerInfo         = mcp.ServerInfo
+	Tool               = mcp.Tool
+	ToolsListResponse  = mcp.ToolsListResponse
+	ToolsCallRequest   = mcp.ToolsCallRequest
+	ToolsCallResponse  = mcp.ToolsCallResponse
+	Content            = mcp.Content
+)
 
-type Content struct {
-	Type string `json:"type"`
-	Text string `json:"text,omitempty"`
-}
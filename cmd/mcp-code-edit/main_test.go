@@ -0,0 +1,157 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestApplyDiffMissingRequiredParams tests that apply_diff reports the missing field before
+// reading the file
+func TestApplyDiffMissingRequiredParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		op      map[string]interface{}
+		missing string
+	}{
+		{"without file_path", map[string]interface{}{"type": "apply_diff", "diff": "@@ -1 +1 @@\n-a\n+b"}, "file_path"},
+		{"with empty path", map[string]interface{}{"type": "apply_diff", "path": "", "diff": "@@ -1 +1 @@\n-a\n+b"}, "file_path"},
+		{"without diff or old_content", map[string]interface{}{"type": "apply_diff", "file_path": "main.go", "new_content": "b"}, "diff"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := batchRunner.Run([]interface{}{tt.op})[0]
+
+			if result["success"] != false || result["error_code"] != "invalid_params" {
+				t.Fatalf("Expected invalid_params error, got %v", result)
+			}
+			if result["missing_field"] != tt.missing {
+				t.Errorf("missing_field = %v, want %s", result["missing_field"], tt.missing)
+			}
+			if message, _ := result["error"].(string); !strings.Contains(message, tt.missing) {
+				t.Errorf("Expected error to name %s, got %q", tt.missing, message)
+			}
+		})
+	}
+}
+
+// TestEditPreservesExecutableBit tests that editing a script keeps its +x permission
+func TestEditPreservesExecutableBit(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	path := filepath.Join(dir, "run.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\necho old\n"), 0755); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+
+	if _, err := toolApplyDiff(map[string]interface{}{"file_path": "run.sh", "old_content": "echo old", "new_content": "echo diff"}); err != nil {
+		t.Fatalf("toolApplyDiff() error = %v", err)
+	}
+	if _, err := toolReplaceCode(map[string]interface{}{"file_path": "run.sh", "old_code": "echo diff", "new_code": "echo new"}); err != nil {
+		t.Fatalf("toolReplaceCode() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat script: %v", err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("Expected mode 0755 after edit, got %o", info.Mode().Perm())
+	}
+	if got := readTestFile(t, dir, "run.sh"); got != "#!/bin/sh\necho new\n" {
+		t.Errorf("Unexpected script content %q", got)
+	}
+}
+
+// TestEditPreservesCRLF tests that CRLF files stay CRLF when edited with LF content
+func TestEditPreservesCRLF(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	writeTestFile(t, dir, "app.cs", "class App\r\n{\r\n    int x = 1;\r\n}\r\n")
+
+	if _, err := toolReplaceCode(map[string]interface{}{"file_path": "app.cs", "old_code": "{\n    int x = 1;", "new_code": "{\n    int x = 2;\n    int y = 3;"}); err != nil {
+		t.Fatalf("toolReplaceCode() error = %v", err)
+	}
+	want := "class App\r\n{\r\n    int x = 2;\r\n    int y = 3;\r\n}\r\n"
+	if got := readTestFile(t, dir, "app.cs"); got != want {
+		t.Errorf("After replace_code got %q, want %q", got, want)
+	}
+
+	diff := "@@ -1,3 +1,3 @@\n class App\n {\n-    int x = 2;\n+    int x = 4;"
+	if _, err := toolApplyDiff(map[string]interface{}{"file_path": "app.cs", "diff": diff}); err != nil {
+		t.Fatalf("toolApplyDiff() error = %v", err)
+	}
+	want = "class App\r\n{\r\n    int x = 4;\r\n    int y = 3;\r\n}\r\n"
+	if got := readTestFile(t, dir, "app.cs"); got != want {
+		t.Errorf("After apply_diff got %q, want %q", got, want)
+	}
+}
+
+// TestEditKeepsLF tests that LF files are not converted
+func TestEditKeepsLF(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	writeTestFile(t, dir, "main.go", "package main\n\nfunc main() {}\n")
+
+	if _, err := toolReplaceCode(map[string]interface{}{"file_path": "main.go", "old_code": "func main() {}", "new_code": "func main() {\n}"}); err != nil {
+		t.Fatalf("toolReplaceCode() error = %v", err)
+	}
+	if got := readTestFile(t, dir, "main.go"); strings.Contains(got, "\r") {
+		t.Errorf("Expected LF file to stay LF, got %q", got)
+	}
+}
+
+// TestAppendToFile tests appending to an existing file, with and without ensure_newline
+func TestAppendToFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	writeTestFile(t, dir, "TODO.md", "- first")
+
+	if _, err := toolAppendToFile(map[string]interface{}{"file_path": "TODO.md", "content": "- second\n", "ensure_newline": true}); err != nil {
+		t.Fatalf("toolAppendToFile() error = %v", err)
+	}
+	if got := readTestFile(t, dir, "TODO.md"); got != "- first\n- second\n" {
+		t.Errorf("Expected newline to be added before appending, got %q", got)
+	}
+
+	// Already ends with a newline, so none is added
+	if _, err := toolAppendToFile(map[string]interface{}{"file_path": "TODO.md", "content": "- third\n", "ensure_newline": true}); err != nil {
+		t.Fatalf("toolAppendToFile() error = %v", err)
+	}
+	if got := readTestFile(t, dir, "TODO.md"); got != "- first\n- second\n- third\n" {
+		t.Errorf("Expected no extra newline, got %q", got)
+	}
+
+	writeTestFile(t, dir, "log.txt", "a")
+	if _, err := toolAppendToFile(map[string]interface{}{"path": "log.txt", "content": "b"}); err != nil {
+		t.Fatalf("toolAppendToFile() error = %v", err)
+	}
+	if got := readTestFile(t, dir, "log.txt"); got != "ab" {
+		t.Errorf("Expected content appended as-is, got %q", got)
+	}
+}
+
+// TestAppendToFileCreatesNew tests that appending to a missing file creates it and its directory
+func TestAppendToFileCreatesNew(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	result := batchRunner.Run([]interface{}{
+		map[string]interface{}{"type": "append_to_file", "file_path": "logs/new.log", "content": "started\n", "ensure_newline": true},
+	})[0]
+	if result["success"] != true {
+		t.Fatalf("Expected append_to_file to succeed, got %v", result)
+	}
+	if params := result["params"].(map[string]interface{}); params["content"] != nil {
+		t.Error("Expected content to be omitted from echoed params")
+	}
+	if got := readTestFile(t, dir, "logs/new.log"); got != "started\n" {
+		t.Errorf("Expected new file content, got %q", got)
+	}
+}
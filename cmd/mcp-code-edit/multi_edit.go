@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// editSpan is the byte range of the original content one edit of multi_edit replaces
+type editSpan struct {
+	index      int
+	start, end int
+	newCode    string
+}
+
+// toolMultiEdit applies several edits to one file in a single read-modify-write pass. Each
+// edit is {old_code, new_code}, replacing the first occurrence of old_code, or
+// {start_line, end_line, new_code}, replacing those lines. Every edit is located in the
+// original content, so line numbers do not shift as earlier edits are applied; edits that
+// overlap are rejected and the file is left untouched.
+func toolMultiEdit(args map[string]interface{}) (string, error) {
+	filePath, err := getFilePath(args)
+	if err != nil {
+		return "", err
+	}
+
+	edits, ok := args["edits"].([]interface{})
+	if !ok || len(edits) == 0 {
+		return "", fmt.Errorf("edits is required")
+	}
+
+	fullPath := resolvePath(filePath)
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	currentContent, err := os.ReadFile(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if err := checkExpectedHash(args, filePath, currentContent); err != nil {
+		return "", err
+	}
+
+	// Edit with LF line endings and convert back when writing
+	lineEnding := detectLineEnding(string(currentContent))
+	currentStr := normalizeLineEndings(string(currentContent))
+
+	spans := make([]editSpan, 0, len(edits))
+	for i, rawEdit := range edits {
+		edit, ok := rawEdit.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("edit %d must be an object", i)
+		}
+		span, err := locateEdit(currentStr, edit)
+		if err != nil {
+			return "", fmt.Errorf("edit %d: %w", i, err)
+		}
+		span.index = i
+		spans = append(spans, span)
+	}
+
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].start < spans[j].start
+	})
+	for i := 1; i < len(spans); i++ {
+		if spans[i].start < spans[i-1].end {
+			return "", fmt.Errorf("edits %d and %d overlap", spans[i-1].index, spans[i].index)
+		}
+	}
+
+	// Apply from the end so the offsets of the remaining edits stay valid
+	newFileContent := currentStr
+	for i := len(spans) - 1; i >= 0; i-- {
+		span := spans[i]
+		newFileContent = newFileContent[:span.start] + span.newCode + newFileContent[span.end:]
+	}
+
+	message, err := finishEdit(args, filePath, newFileContent, func() error {
+		return writeFilePreserving(fullPath, newFileContent, lineEnding, info.Mode())
+	}, fmt.Sprintf("Applied %d edits", len(spans)))
+	if err != nil {
+		return "", err
+	}
+
+	resultJSON, err := json.Marshal(map[string]interface{}{
+		"message":       message,
+		"file_path":     filePath,
+		"edits_applied": len(spans),
+		"sha256":        fileSHA256(fullPath),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// locateEdit returns the span of content an edit replaces. content has LF line endings.
+func locateEdit(content string, edit map[string]interface{}) (editSpan, error) {
+	newCode, ok := edit["new_code"].(string)
+	if !ok {
+		return editSpan{}, fmt.Errorf("new_code is required")
+	}
+	newCode = normalizeLineEndings(newCode)
+
+	if oldCode, ok := edit["old_code"].(string); ok {
+		oldCode = normalizeLineEndings(oldCode)
+		if oldCode == "" {
+			return editSpan{}, fmt.Errorf("old_code must not be empty")
+		}
+		start := strings.Index(content, oldCode)
+		if start < 0 {
+			return editSpan{}, fmt.Errorf("old_code not found in file")
+		}
+		return editSpan{start: start, end: start + len(oldCode), newCode: newCode}, nil
+	}
+
+	startLine, okStart := edit["start_line"].(float64)
+	endLine, okEnd := edit["end_line"].(float64)
+	if !okStart || !okEnd {
+		return editSpan{}, fmt.Errorf("old_code, or start_line and end_line, is required")
+	}
+
+	// lineStarts[i] is the offset of line i+1; a final newline does not start a line
+	lineStarts := []int{0}
+	for i := 0; i < len(content)-1; i++ {
+		if content[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	first, last := int(startLine), int(endLine)
+	if first < 1 || last < first || last > len(lineStarts) {
+		return editSpan{}, fmt.Errorf("line range %d-%d is outside the file's %d lines", first, last, len(lineStarts))
+	}
+
+	// The range covers the lines' text but not the newline ending the last one
+	end := len(content)
+	if last < len(lineStarts) {
+		end = lineStarts[last] - 1
+	} else if strings.HasSuffix(content, "\n") {
+		end--
+	}
+	return editSpan{start: lineStarts[first-1], end: end, newCode: newCode}, nil
+}
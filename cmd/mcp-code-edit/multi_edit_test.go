@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestMultiEdit tests applying code and line-range edits to one file in a single pass
+func TestMultiEdit(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	writeTestFile(t, dir, "server.go", "package main\n\nconst port = 8080\n\nfunc start() {\n\tlisten(port)\n}\n\nfunc stop() {}\n")
+
+	result, err := toolMultiEdit(map[string]interface{}{
+		"file_path": "server.go",
+		"edits": []interface{}{
+			// Lines are numbered in the original file, before the first edit adds one
+			map[string]interface{}{"old_code": "const port = 8080", "new_code": "const (\n\tport = 8080\n)"},
+			map[string]interface{}{"start_line": float64(9), "end_line": float64(9), "new_code": "func stop() {\n\tclose()\n}"},
+			map[string]interface{}{"old_code": "listen(port)", "new_code": "listen(\"tcp\", port)"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("toolMultiEdit() error = %v", err)
+	}
+
+	var decoded struct {
+		EditsApplied int `json:"edits_applied"`
+	}
+	if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if decoded.EditsApplied != 3 {
+		t.Errorf("Expected 3 edits applied, got %d", decoded.EditsApplied)
+	}
+
+	want := "package main\n\nconst (\n\tport = 8080\n)\n\nfunc start() {\n\tlisten(\"tcp\", port)\n}\n\nfunc stop() {\n\tclose()\n}\n"
+	if got := readTestFile(t, dir, "server.go"); got != want {
+		t.Errorf("After multi_edit:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestMultiEditRejects tests that overlapping and unmatched edits leave the file untouched
+func TestMultiEditRejects(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	original := "one\ntwo\nthree\nfour\n"
+	writeTestFile(t, dir, "notes.txt", original)
+
+	tests := []struct {
+		name    string
+		edits   []interface{}
+		wantErr string
+	}{
+		{
+			name: "overlapping edits",
+			edits: []interface{}{
+				map[string]interface{}{"old_code": "one", "new_code": "1"},
+				map[string]interface{}{"start_line": float64(2), "end_line": float64(3), "new_code": "2-3"},
+				map[string]interface{}{"old_code": "three\nfour", "new_code": "3-4"},
+			},
+			wantErr: "edits 1 and 2 overlap",
+		},
+		{
+			name: "old_code not found",
+			edits: []interface{}{
+				map[string]interface{}{"old_code": "one", "new_code": "1"},
+				map[string]interface{}{"old_code": "five", "new_code": "5"},
+			},
+			wantErr: "edit 1: old_code not found",
+		},
+		{
+			name: "line range past the end",
+			edits: []interface{}{
+				map[string]interface{}{"start_line": float64(4), "end_line": float64(5), "new_code": "x"},
+			},
+			wantErr: "outside the file's 4 lines",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := toolMultiEdit(map[string]interface{}{"file_path": "notes.txt", "edits": tt.edits})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if got := readTestFile(t, dir, "notes.txt"); got != original {
+				t.Errorf("Expected the file to be untouched, got %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/code-aria/internal-mcp/internal/repowalk"
+)
+
+// fileReplacements is the number of replacements made in one file and its sha256 afterwards
+type fileReplacements struct {
+	Path         string `json:"path"`
+	Replacements int    `json:"replacements"`
+	SHA256       string `json:"sha256"`
+}
+
+// replacer applies a literal or regex replacement to LF-normalized content
+type replacer struct {
+	search  string
+	replace string
+	re      *regexp.Regexp
+}
+
+// newReplacer builds the replacement described by the search, replace and regex params
+func newReplacer(args map[string]interface{}) (*replacer, error) {
+	search, _ := args["search"].(string)
+	if search == "" {
+		return nil, fmt.Errorf("search is required")
+	}
+	replace, ok := args["replace"].(string)
+	if !ok {
+		return nil, fmt.Errorf("replace is required")
+	}
+
+	r := &replacer{search: normalizeLineEndings(search), replace: normalizeLineEndings(replace)}
+	if useRegex, _ := args["regex"].(bool); useRegex {
+		re, err := regexp.Compile(search)
+		if err != nil {
+			return nil, fmt.Errorf("invalid search regex: %w", err)
+		}
+		// A pattern matching the empty string would insert replace between every character
+		if re.MatchString("") {
+			return nil, fmt.Errorf("search regex must not match the empty string")
+		}
+		r.re = re
+	}
+	return r, nil
+}
+
+// apply returns content with every match replaced and the number of matches
+func (r *replacer) apply(content string) (string, int) {
+	if r.re != nil {
+		count := len(r.re.FindAllStringIndex(content, -1))
+		if count == 0 {
+			return content, 0
+		}
+		return r.re.ReplaceAllString(content, r.replace), count
+	}
+	count := strings.Count(content, r.search)
+	if count == 0 {
+		return content, 0
+	}
+	return strings.ReplaceAll(content, r.search, r.replace), count
+}
+
+// stringListParam reads a param given as a string array or a single string
+func stringListParam(args map[string]interface{}, key string) []string {
+	switch v := args[key].(type) {
+	case string:
+		if v != "" {
+			return []string{v}
+		}
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// searchReplaceRoot is the directory search_replace_files walks: REPO_PATH, or the working
+// directory when it is not set
+func searchReplaceRoot() (string, error) {
+	root := os.Getenv("REPO_PATH")
+	if root == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to determine repository root: %w", err)
+		}
+		root = wd
+	}
+	return filepath.Abs(root)
+}
+
+// findSearchReplaceFiles returns the regular files under root that match file_patterns and
+// none of exclude_patterns, selected by repowalk.Walk like the files of mcp-codebase's
+// preview_replace.
+func findSearchReplaceFiles(root string, args map[string]interface{}) ([]string, error) {
+	patterns := stringListParam(args, "file_patterns")
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("file_patterns is required")
+	}
+	excludes := stringListParam(args, "exclude_patterns")
+	if err := repowalk.ValidatePatterns(append(patterns, excludes...)); err != nil {
+		return nil, err
+	}
+
+	var files []string
+	err := repowalk.Walk(root, patterns, excludes, func(path, relPath string, d fs.DirEntry) error {
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// toolSearchReplaceFiles replaces search with replace in every repository file matching
+// file_patterns and reports the replacements per file. With dry_run nothing is written.
+func toolSearchReplaceFiles(args map[string]interface{}) (string, error) {
+	r, err := newReplacer(args)
+	if err != nil {
+		return "", err
+	}
+	root, err := searchReplaceRoot()
+	if err != nil {
+		return "", err
+	}
+	files, err := findSearchReplaceFiles(root, args)
+	if err != nil {
+		return "", err
+	}
+	dryRun, _ := args["dry_run"].(bool)
+
+	changed := []fileReplacements{}
+	total := 0
+	for _, fullPath := range files {
+		content, err := os.ReadFile(fullPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file: %w", err)
+		}
+		// Skip binary files
+		if bytes.IndexByte(content, 0) >= 0 {
+			continue
+		}
+
+		// Edit with LF line endings and convert back when writing
+		lineEnding := detectLineEnding(string(content))
+		newContent, count := r.apply(normalizeLineEndings(string(content)))
+		if count == 0 {
+			continue
+		}
+
+		relPath, _ := filepath.Rel(root, fullPath)
+		if !dryRun {
+			info, err := os.Stat(fullPath)
+			if err != nil {
+				return "", fmt.Errorf("failed to read file: %w", err)
+			}
+			if err := writeFilePreserving(fullPath, newContent, lineEnding, info.Mode()); err != nil {
+				return "", fmt.Errorf("%s: %w", relPath, err)
+			}
+		}
+		changed = append(changed, fileReplacements{Path: filepath.ToSlash(relPath), Replacements: count, SHA256: fileSHA256(fullPath)})
+		total += count
+	}
+
+	result, err := json.Marshal(map[string]interface{}{
+		"files":              changed,
+		"files_changed":      len(changed),
+		"total_replacements": total,
+		"dry_run":            dryRun,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+	return string(result), nil
+}
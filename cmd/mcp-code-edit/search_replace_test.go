@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// searchReplaceResult is the decoded output of toolSearchReplaceFiles
+type searchReplaceResult struct {
+	Files             []fileReplacements `json:"files"`
+	FilesChanged      int                `json:"files_changed"`
+	TotalReplacements int                `json:"total_replacements"`
+	DryRun            bool               `json:"dry_run"`
+}
+
+// setupRenameRepo writes a small repository that uses the identifier OldName
+func setupRenameRepo(t *testing.T) string {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	files := map[string]string{
+		"main.go":                  "package main\n\nfunc OldName() {}\n\nfunc main() { OldName() }\n",
+		"pkg/util/util.go":         "package util\r\n\r\n// OldName is called from main\r\nvar _ = \"OldName\"\r\n",
+		"README.md":                "Call OldName to start.\n",
+		"vendor/dep/dep.go":        "package dep\n\nfunc OldName() {}\n",
+		".git/HEAD.go":             "OldName\n",
+		"testdata/fixture.go":      "package testdata // OldName\n",
+		"pkg/util/util_unused.txt": "OldName\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func runSearchReplace(t *testing.T, args map[string]interface{}) searchReplaceResult {
+	output, err := toolSearchReplaceFiles(args)
+	if err != nil {
+		t.Fatalf("toolSearchReplaceFiles() error = %v", err)
+	}
+	var result searchReplaceResult
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Failed to parse result %q: %v", output, err)
+	}
+	return result
+}
+
+// TestSearchReplaceFilesRename tests a repo-wide rename of an identifier in Go files
+func TestSearchReplaceFilesRename(t *testing.T) {
+	dir := setupRenameRepo(t)
+	args := map[string]interface{}{
+		"file_patterns":    []interface{}{"*.go"},
+		"exclude_patterns": []interface{}{"testdata"},
+		"search":           "OldName",
+		"replace":          "NewName",
+	}
+
+	t.Run("Dry run", func(t *testing.T) {
+		dryArgs := map[string]interface{}{"dry_run": true}
+		for k, v := range args {
+			dryArgs[k] = v
+		}
+		result := runSearchReplace(t, dryArgs)
+		if !result.DryRun || result.TotalReplacements != 4 || result.FilesChanged != 2 {
+			t.Errorf("Expected 4 replacements in 2 files, got %+v", result)
+		}
+		if content := readTestFile(t, dir, "main.go"); content != "package main\n\nfunc OldName() {}\n\nfunc main() { OldName() }\n" {
+			t.Errorf("Dry run must not write, got %q", content)
+		}
+	})
+
+	t.Run("Apply", func(t *testing.T) {
+		result := runSearchReplace(t, args)
+		want := map[string]int{"main.go": 2, "pkg/util/util.go": 2}
+		if len(result.Files) != len(want) || result.TotalReplacements != 4 {
+			t.Fatalf("Expected %v, got %+v", want, result)
+		}
+		for _, file := range result.Files {
+			if want[file.Path] != file.Replacements {
+				t.Errorf("%s: %d replacements, want %d", file.Path, file.Replacements, want[file.Path])
+			}
+		}
+
+		if content := readTestFile(t, dir, "main.go"); content != "package main\n\nfunc NewName() {}\n\nfunc main() { NewName() }\n" {
+			t.Errorf("main.go = %q", content)
+		}
+		if content := readTestFile(t, dir, "pkg/util/util.go"); content != "package util\r\n\r\n// NewName is called from main\r\nvar _ = \"NewName\"\r\n" {
+			t.Errorf("Expected CRLF line endings to be kept, got %q", content)
+		}
+		for _, untouched := range []string{"README.md", "vendor/dep/dep.go", ".git/HEAD.go", "testdata/fixture.go", "pkg/util/util_unused.txt"} {
+			if content := readTestFile(t, dir, untouched); !strings.Contains(content, "OldName") {
+				t.Errorf("%s should not have been edited, got %q", untouched, content)
+			}
+		}
+	})
+}
+
+// TestSearchReplaceFilesRegex tests regex replacements with capture groups
+func TestSearchReplaceFilesRegex(t *testing.T) {
+	dir := setupRenameRepo(t)
+
+	result := runSearchReplace(t, map[string]interface{}{
+		"file_patterns": []interface{}{"main.go"},
+		"search":        `func (\w+)Name\(\)`,
+		"replace":       "func ${1}Func()",
+		"regex":         true,
+	})
+	if result.TotalReplacements != 1 {
+		t.Errorf("Expected 1 replacement, got %+v", result)
+	}
+	if content := readTestFile(t, dir, "main.go"); content != "package main\n\nfunc OldFunc() {}\n\nfunc main() { OldName() }\n" {
+		t.Errorf("main.go = %q", content)
+	}
+}
+
+// TestSearchReplaceFilesInvalidParams tests that bad params are rejected before any file is edited
+func TestSearchReplaceFilesInvalidParams(t *testing.T) {
+	setupRenameRepo(t)
+
+	tests := []struct {
+		name string
+		args map[string]interface{}
+	}{
+		{"without search", map[string]interface{}{"file_patterns": "*.go", "replace": "x"}},
+		{"without replace", map[string]interface{}{"file_patterns": "*.go", "search": "OldName"}},
+		{"without file_patterns", map[string]interface{}{"search": "OldName", "replace": "x"}},
+		{"with invalid regex", map[string]interface{}{"file_patterns": "*.go", "search": "(", "replace": "x", "regex": true}},
+		{"with empty-matching regex", map[string]interface{}{"file_patterns": "*.go", "search": "x*", "replace": "y", "regex": true}},
+		{"with invalid pattern", map[string]interface{}{"file_patterns": "[", "search": "OldName", "replace": "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := toolSearchReplaceFiles(tt.args); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
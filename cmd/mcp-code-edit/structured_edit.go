@@ -0,0 +1,668 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// toolSetJSONPath sets the value at path in a JSON or YAML file, creating missing object
+// keys on the way, and writes the file back. The document is edited in place rather than
+// re-serialized: only the text of the changed value is replaced, or the new key inserted
+// next to its siblings with their indentation, so formatting, key order and, in YAML,
+// comments are kept. The path is dotted, with an optional leading "$." and brackets for
+// array indexes or keys containing dots: servers[0].port, $.metadata["app.kubernetes.io/name"].
+// It is passed as json_path because path is already an alias of file_path in this server.
+// YAML files are edited line by line and must be block mappings along the path; the result
+// is parsed again and only written when it holds the new value.
+func toolSetJSONPath(args map[string]interface{}) (string, error) {
+	filePath, err := getFilePath(args)
+	if err != nil {
+		return "", err
+	}
+	pathExpr, _ := args["json_path"].(string)
+	if pathExpr == "" {
+		return "", fmt.Errorf("json_path is required")
+	}
+	value, ok := args["value"]
+	if !ok {
+		return "", fmt.Errorf("value is required")
+	}
+	segments, err := parseJSONPath(pathExpr)
+	if err != nil {
+		return "", err
+	}
+
+	fullPath := resolvePath(filePath)
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	currentContent, err := os.ReadFile(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if err := checkExpectedHash(args, filePath, currentContent); err != nil {
+		return "", err
+	}
+
+	// Edit with LF line endings and convert back when writing
+	lineEnding := detectLineEnding(string(currentContent))
+	currentStr := normalizeLineEndings(string(currentContent))
+
+	format := "json"
+	var newFileContent string
+	var created bool
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		format = "yaml"
+		newFileContent, created, err = setYAMLPath(currentStr, segments, value)
+		if err == nil {
+			err = checkYAMLEdit(newFileContent, segments, value)
+		}
+	default:
+		newFileContent, created, err = setJSONPath(currentStr, segments, value)
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot set %s in %s: %w", pathExpr, filePath, err)
+	}
+
+	verb := "Updated"
+	if created {
+		verb = "Created"
+	}
+	message, err := finishEdit(args, filePath, newFileContent, func() error {
+		return writeFilePreserving(fullPath, newFileContent, lineEnding, info.Mode())
+	}, fmt.Sprintf("%s %s", verb, pathExpr))
+	if err != nil {
+		return "", err
+	}
+
+	resultJSON, err := json.Marshal(map[string]interface{}{
+		"message":   message,
+		"file_path": filePath,
+		"json_path": pathExpr,
+		"format":    format,
+		"created":   created,
+		"sha256":    fileSHA256(fullPath),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// parseJSONPath splits a path such as $.a.b[0]["c.d"] into its keys and indexes. Indexes
+// are kept as strings and only read as numbers where the document has an array.
+func parseJSONPath(expr string) ([]string, error) {
+	rest := strings.TrimPrefix(strings.TrimPrefix(expr, "$"), ".")
+	if expr == "$" || rest == "" {
+		return nil, fmt.Errorf("json_path %q names no key", expr)
+	}
+
+	var segments []string
+	for rest != "" {
+		switch {
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid json_path %q: unterminated brackets", expr)
+			}
+			if quote := rest[1:2]; quote == `"` || quote == "'" {
+				closing := strings.Index(rest[2:], quote+"]")
+				if closing < 0 {
+					return nil, fmt.Errorf("invalid json_path %q: unterminated quoted key", expr)
+				}
+				segments = append(segments, rest[2:closing+2])
+				end = closing + 4
+			} else if end > 1 {
+				if _, err := strconv.Atoi(rest[1:end]); err != nil {
+					return nil, fmt.Errorf("invalid json_path %q: %q is not an index", expr, rest[1:end])
+				}
+				segments = append(segments, rest[1:end])
+				end++
+			} else {
+				return nil, fmt.Errorf("invalid json_path %q: empty brackets", expr)
+			}
+			rest = rest[end:]
+		case rest[0] == '.':
+			rest = rest[1:]
+			if rest == "" || rest[0] == '.' {
+				return nil, fmt.Errorf("invalid json_path %q: empty key", expr)
+			}
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			segments = append(segments, rest[:end])
+			rest = rest[end:]
+		}
+	}
+	return segments, nil
+}
+
+// nestedValue wraps value in one object per key, innermost last, for keys that do not
+// exist yet
+func nestedValue(keys []string, value interface{}) interface{} {
+	for i := len(keys) - 1; i >= 0; i-- {
+		value = map[string]interface{}{keys[i]: value}
+	}
+	return value
+}
+
+// jsonSpan is the byte range of a JSON value in the document
+type jsonSpan struct {
+	start, end int
+}
+
+// jsonMember is an object member: its key, where the key's text ends and its value
+type jsonMember struct {
+	key    string
+	keyPos jsonSpan
+	value  jsonSpan
+}
+
+// setJSONPath replaces the value at segments in the JSON document content, or inserts it
+// when the last keys are missing from an object, and returns the new document
+func setJSONPath(content string, segments []string, value interface{}) (string, bool, error) {
+	if !json.Valid([]byte(content)) {
+		var v interface{}
+		err := json.Unmarshal([]byte(content), &v)
+		return "", false, fmt.Errorf("invalid JSON: %v", err)
+	}
+	unit := jsonIndentUnit(content)
+
+	start := skipJSONSpace(content, 0)
+	cur := jsonSpan{start, scanJSONValue(content, start)}
+	for i, seg := range segments {
+		switch content[cur.start] {
+		case '{':
+			members := jsonObjectMembers(content, cur)
+			found := false
+			for _, m := range members {
+				if m.key == seg {
+					cur, found = m.value, true
+					break
+				}
+			}
+			if !found {
+				newValue := nestedValue(segments[i+1:], value)
+				return insertJSONMember(content, cur, members, seg, newValue, unit), true, nil
+			}
+		case '[':
+			elements := jsonArrayElements(content, cur)
+			index, err := strconv.Atoi(seg)
+			if err != nil || index < 0 {
+				return "", false, fmt.Errorf("%q is not an index of the array at %s", seg, strings.Join(segments[:i], "."))
+			}
+			if index == len(elements) && i == len(segments)-1 {
+				return insertJSONElement(content, cur, elements, value, unit), true, nil
+			}
+			if index >= len(elements) {
+				return "", false, fmt.Errorf("index %d is out of range of the %d-element array at %s", index, len(elements), strings.Join(segments[:i], "."))
+			}
+			cur = elements[index]
+		default:
+			at := strings.Join(segments[:i], ".")
+			if at == "" {
+				at = "the document root"
+			}
+			return "", false, fmt.Errorf("%s is a %s, not an object or array", at, jsonKind(content[cur.start]))
+		}
+	}
+
+	rendered := renderJSON(value, jsonLineIndent(content, cur.start), unit, strings.Contains(content, "\n"))
+	return content[:cur.start] + rendered + content[cur.end:], false, nil
+}
+
+// insertJSONMember adds "key": value to the object at obj after its last member, laid out
+// like the existing members
+func insertJSONMember(content string, obj jsonSpan, members []jsonMember, key string, value interface{}, unit string) string {
+	keyJSON := renderJSON(key, "", "", false)
+	if len(members) == 0 {
+		return insertIntoEmpty(content, obj, keyJSON+": ", value, unit)
+	}
+
+	first, last := members[0], members[len(members)-1]
+	separator := content[first.keyPos.end:first.value.start]
+	if strings.Contains(content[obj.start:first.keyPos.start], "\n") {
+		indent := jsonLineIndent(content, first.keyPos.start)
+		member := ",\n" + indent + keyJSON + separator + renderJSON(value, indent, unit, true)
+		return content[:last.value.end] + member + content[last.value.end:]
+	}
+	comma := ", "
+	if !strings.Contains(separator, " ") {
+		comma = ","
+	}
+	member := comma + keyJSON + separator + renderJSON(value, "", "", false)
+	return content[:last.value.end] + member + content[last.value.end:]
+}
+
+// insertJSONElement appends value to the array at arr, laid out like its elements
+func insertJSONElement(content string, arr jsonSpan, elements []jsonSpan, value interface{}, unit string) string {
+	if len(elements) == 0 {
+		return insertIntoEmpty(content, arr, "", value, unit)
+	}
+	last := elements[len(elements)-1]
+	if strings.Contains(content[arr.start:elements[0].start], "\n") {
+		indent := jsonLineIndent(content, elements[0].start)
+		return content[:last.end] + ",\n" + indent + renderJSON(value, indent, unit, true) + content[last.end:]
+	}
+	return content[:last.end] + ", " + renderJSON(value, "", "", false) + content[last.end:]
+}
+
+// insertIntoEmpty fills an empty object or array. In a multi-line document the new entry
+// goes on its own line, one indent unit in from the container's line.
+func insertIntoEmpty(content string, container jsonSpan, prefix string, value interface{}, unit string) string {
+	open, close := content[container.start:container.start+1], content[container.end-1:container.end]
+	if !strings.Contains(content, "\n") {
+		return content[:container.start] + open + prefix + renderJSON(value, "", "", false) + close + content[container.end:]
+	}
+	outer := jsonLineIndent(content, container.start)
+	inner := outer + unit
+	entry := "\n" + inner + prefix + renderJSON(value, inner, unit, true) + "\n" + outer
+	return content[:container.start] + open + entry + close + content[container.end:]
+}
+
+// renderJSON encodes value without escaping HTML characters. With multiline, objects and
+// arrays are indented by unit, continuation lines starting with prefix.
+func renderJSON(value interface{}, prefix, unit string, multiline bool) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if multiline && unit != "" {
+		enc.SetIndent(prefix, unit)
+	}
+	if err := enc.Encode(value); err != nil {
+		return "null"
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// jsonIndentUnit guesses the document's indentation from the first indented line,
+// defaulting to two spaces
+func jsonIndentUnit(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && len(trimmed) < len(line) {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return "  "
+}
+
+// jsonLineIndent returns the leading whitespace of the line containing pos
+func jsonLineIndent(content string, pos int) string {
+	lineStart := strings.LastIndexByte(content[:pos], '\n') + 1
+	line := content[lineStart:]
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// jsonKind names the type of the JSON value starting with c
+func jsonKind(c byte) string {
+	switch c {
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "boolean"
+	case 'n':
+		return "null"
+	}
+	return "number"
+}
+
+// skipJSONSpace returns the offset of the first non-whitespace byte at or after i
+func skipJSONSpace(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t' || s[i] == '\n' || s[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+// scanJSONValue returns the end of the value starting at i. s must be valid JSON.
+func scanJSONValue(s string, i int) int {
+	switch s[i] {
+	case '{', '[':
+		closing := byte('}')
+		if s[i] == '[' {
+			closing = ']'
+		}
+		i = skipJSONSpace(s, i+1)
+		if s[i] == closing {
+			return i + 1
+		}
+		for {
+			if closing == '}' {
+				i = skipJSONSpace(s, scanJSONValue(s, i)) + 1 // the key and its colon
+				i = skipJSONSpace(s, i)
+			}
+			i = skipJSONSpace(s, scanJSONValue(s, i))
+			if s[i] == closing {
+				return i + 1
+			}
+			i = skipJSONSpace(s, i+1) // the comma
+		}
+	case '"':
+		for i++; s[i] != '"'; i++ {
+			if s[i] == '\\' {
+				i++
+			}
+		}
+		return i + 1
+	}
+	for i < len(s) && !strings.ContainsRune(",}] \t\r\n", rune(s[i])) {
+		i++
+	}
+	return i
+}
+
+// jsonObjectMembers lists the members of the object at obj
+func jsonObjectMembers(s string, obj jsonSpan) []jsonMember {
+	var members []jsonMember
+	i := skipJSONSpace(s, obj.start+1)
+	for s[i] != '}' {
+		keyEnd := scanJSONValue(s, i)
+		var key string
+		json.Unmarshal([]byte(s[i:keyEnd]), &key)
+		valueStart := skipJSONSpace(s, skipJSONSpace(s, keyEnd)+1)
+		valueEnd := scanJSONValue(s, valueStart)
+		members = append(members, jsonMember{key: key, keyPos: jsonSpan{i, keyEnd}, value: jsonSpan{valueStart, valueEnd}})
+		i = skipJSONSpace(s, valueEnd)
+		if s[i] == ',' {
+			i = skipJSONSpace(s, i+1)
+		}
+	}
+	return members
+}
+
+// jsonArrayElements lists the elements of the array at arr
+func jsonArrayElements(s string, arr jsonSpan) []jsonSpan {
+	var elements []jsonSpan
+	i := skipJSONSpace(s, arr.start+1)
+	for s[i] != ']' {
+		end := scanJSONValue(s, i)
+		elements = append(elements, jsonSpan{i, end})
+		i = skipJSONSpace(s, end)
+		if s[i] == ',' {
+			i = skipJSONSpace(s, i+1)
+		}
+	}
+	return elements
+}
+
+// yamlKeyPattern matches a block mapping line: indentation, then a plain, double-quoted or
+// single-quoted key followed by a colon
+var yamlKeyPattern = regexp.MustCompile(`^(\s*)(?:"((?:[^"\\]|\\.)*)"|'((?:[^']|'')*)'|([^\s#'"\-?:][^#]*?|-[^\s#]*?))\s*:(?:\s|$)`)
+
+// yamlPlainScalar matches strings that can be written unquoted in YAML
+var yamlPlainScalar = regexp.MustCompile(`^[A-Za-z_/][A-Za-z0-9_ ./@+-]*[A-Za-z0-9_./@+-]$|^[A-Za-z_]$`)
+
+// yamlReserved are plain scalars that YAML would read as something other than a string
+var yamlReserved = map[string]bool{
+	"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true,
+	"y": true, "n": true, "null": true, "~": true,
+}
+
+// yamlLine is a content line of a YAML document: not blank and not only a comment
+type yamlLine struct {
+	index  int
+	indent int
+}
+
+// setYAMLPath sets the value at segments in a YAML document of block mappings by editing
+// its lines, and returns the new document. Values are written as plain or double-quoted
+// scalars, and objects and arrays in flow style, which YAML reads the same as JSON.
+func setYAMLPath(content string, segments []string, value interface{}) (string, bool, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return "", false, fmt.Errorf("invalid YAML: %v", err)
+	}
+
+	lines := strings.Split(content, "\n")
+	var entries []yamlLine
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if trimmed == "---" || trimmed == "..." {
+			if len(entries) > 0 {
+				return "", false, fmt.Errorf("multi-document YAML is not supported")
+			}
+			continue
+		}
+		entries = append(entries, yamlLine{index: i, indent: len(line) - len(strings.TrimLeft(line, " \t"))})
+	}
+	unit := "  "
+	for _, l := range entries {
+		if l.indent > 0 {
+			unit = strings.Repeat(" ", l.indent)
+			break
+		}
+	}
+
+	// The block being searched: content lines [from, to) and the indentation of the line
+	// owning it (-1 for the document)
+	from, to, ownerIndent := 0, len(entries), -1
+	insertAfter := -1
+	if len(entries) > 0 {
+		insertAfter = entries[len(entries)-1].index
+	}
+	for i, seg := range segments {
+		childIndent := -1
+		if from < to {
+			childIndent = entries[from].indent
+		}
+
+		found := -1
+		for j := from; j < to && childIndent >= 0; j++ {
+			l := entries[j]
+			if l.indent != childIndent {
+				continue
+			}
+			line := lines[l.index]
+			if isYAMLSequenceItem(line) {
+				// Items at the indentation of the key before them are that key's value,
+				// as go-yaml and kubectl write them
+				if j == from {
+					return "", false, fmt.Errorf("%s is a sequence; only block mappings are supported in YAML", yamlPathName(segments[:i]))
+				}
+				continue
+			}
+			m := yamlKeyPattern.FindStringSubmatch(line)
+			if m == nil {
+				return "", false, fmt.Errorf("line %d is not a block mapping key", l.index+1)
+			}
+			if yamlKey(m) == seg {
+				found = j
+				break
+			}
+		}
+
+		if found < 0 {
+			indent := childIndent
+			if indent < 0 {
+				indent = ownerIndent + len(unit)
+				if ownerIndent < 0 {
+					indent = 0
+				}
+			}
+			newLines := yamlNewKeyLines(segments[i:], value, indent, unit)
+			pos := insertAfter + 1
+			if insertAfter < 0 {
+				pos = 0
+				for pos < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[pos]), "#") {
+					pos++
+				}
+			}
+			lines = append(lines[:pos], append(newLines, lines[pos:]...)...)
+			return strings.Join(lines, "\n"), true, nil
+		}
+
+		// The found key's block runs until the next line indented no deeper than the key,
+		// other than sequence items at the key's own indentation
+		l := entries[found]
+		end := found + 1
+		for end < to && (entries[end].indent > l.indent || (entries[end].indent == l.indent && isYAMLSequenceItem(lines[entries[end].index]))) {
+			end++
+		}
+		line := lines[l.index]
+		m := yamlKeyPattern.FindStringSubmatch(line)
+		keyText := strings.TrimRight(line[:len(m[0])], " \t")
+		rest, comment := splitYAMLComment(line[len(m[0]):])
+		if comment != "" {
+			comment = " " + comment
+		}
+
+		if i == len(segments)-1 {
+			// Replace the value, dropping any nested block or block scalar it had
+			newLine := keyText + " " + renderYAMLValue(value) + comment
+			kept := append([]string{}, lines[:l.index]...)
+			kept = append(kept, newLine)
+			last := l.index
+			if end > found+1 {
+				last = entries[end-1].index
+			}
+			kept = append(kept, lines[last+1:]...)
+			return strings.Join(kept, "\n"), false, nil
+		}
+
+		if strings.TrimSpace(rest) != "" {
+			return "", false, fmt.Errorf("%s holds %q, not a block mapping", yamlPathName(segments[:i+1]), strings.TrimSpace(rest))
+		}
+		from, to, ownerIndent = found+1, end, l.indent
+		insertAfter = entries[end-1].index
+	}
+	return "", false, fmt.Errorf("empty path")
+}
+
+// isYAMLSequenceItem reports whether line is a block sequence item
+func isYAMLSequenceItem(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "-" || strings.HasPrefix(trimmed, "- ")
+}
+
+// checkYAMLEdit parses the edited document and checks that it holds value at segments, so
+// a layout the line editor misread is reported rather than written
+func checkYAMLEdit(edited string, segments []string, value interface{}) error {
+	var doc interface{}
+	if err := yaml.Unmarshal([]byte(edited), &doc); err != nil {
+		return fmt.Errorf("the edit would leave invalid YAML, so nothing was written: %v", err)
+	}
+	for _, seg := range segments {
+		mapping, ok := doc.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("the edited YAML does not hold the value at %s, so nothing was written", strings.Join(segments, "."))
+		}
+		doc = mapping[seg]
+	}
+	got, errGot := json.Marshal(doc)
+	want, errWant := json.Marshal(value)
+	if errGot != nil || errWant != nil || !bytes.Equal(got, want) {
+		return fmt.Errorf("the edited YAML holds %s at %s instead of %s, so nothing was written", got, strings.Join(segments, "."), want)
+	}
+	return nil
+}
+
+// yamlKey returns the key of a yamlKeyPattern match, unquoted
+func yamlKey(m []string) string {
+	switch {
+	case strings.HasPrefix(strings.TrimSpace(m[0]), `"`):
+		var key string
+		if err := json.Unmarshal([]byte(`"`+m[2]+`"`), &key); err == nil {
+			return key
+		}
+		return m[2]
+	case strings.HasPrefix(strings.TrimSpace(m[0]), "'"):
+		return strings.ReplaceAll(m[3], "''", "'")
+	}
+	return strings.TrimSpace(m[4])
+}
+
+// yamlNewKeyLines renders the lines of keys that do not exist yet, each nested one unit
+// deeper than the one before, the last holding value
+func yamlNewKeyLines(keys []string, value interface{}, indent int, unit string) []string {
+	var lines []string
+	for i, key := range keys {
+		line := strings.Repeat(" ", indent+i*len(unit)) + renderYAMLScalar(key) + ":"
+		if i == len(keys)-1 {
+			line += " " + renderYAMLValue(value)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// renderYAMLValue writes value as a YAML scalar, or in flow style for objects and arrays
+func renderYAMLValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return renderYAMLScalar(v)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, 0, len(keys))
+		for _, k := range keys {
+			parts = append(parts, renderYAMLScalar(k)+": "+renderYAMLValue(v[k]))
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, renderYAMLValue(item))
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	return renderJSON(value, "", "", false)
+}
+
+// renderYAMLScalar writes a string plain when YAML would read it back as the same string,
+// and double-quoted otherwise
+func renderYAMLScalar(s string) string {
+	if yamlPlainScalar.MatchString(s) && !yamlReserved[strings.ToLower(s)] && !strings.Contains(s, ": ") {
+		return s
+	}
+	return renderJSON(s, "", "", false)
+}
+
+// splitYAMLComment splits the value part of a line from a trailing comment, which starts
+// at a # preceded by whitespace outside quotes
+func splitYAMLComment(rest string) (string, string) {
+	var quote byte
+	for i := 0; i < len(rest); i++ {
+		c := rest[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || rest[i-1] == ' ' || rest[i-1] == '\t'):
+			return rest[:i], rest[i:]
+		}
+	}
+	return rest, ""
+}
+
+// yamlPathName names a path prefix in errors
+func yamlPathName(segments []string) string {
+	if len(segments) == 0 {
+		return "the document root"
+	}
+	return strings.Join(segments, ".")
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// setJSONPathArgs runs set_json_path and returns whether it created the key
+func setJSONPathArgs(t *testing.T, args map[string]interface{}) bool {
+	t.Helper()
+	result, err := toolSetJSONPath(args)
+	if err != nil {
+		t.Fatalf("set_json_path %v: error = %v", args, err)
+	}
+	var decoded struct {
+		Created bool `json:"created"`
+	}
+	if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	return decoded.Created
+}
+
+// TestSetJSONPathNested tests replacing nested values, including inside an array, with
+// the rest of the file byte for byte unchanged
+func TestSetJSONPathNested(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	original := "{\n    \"name\": \"app\",\n    \"server\": {\n        \"port\": 8080,\n        \"hosts\": [\"a\", \"b\"]\n    },\n    \"debug\": false\n}\n"
+	writeTestFile(t, dir, "config.json", original)
+
+	if setJSONPathArgs(t, map[string]interface{}{"file_path": "config.json", "json_path": "server.port", "value": float64(9090)}) {
+		t.Error("Expected server.port to be updated, not created")
+	}
+	setJSONPathArgs(t, map[string]interface{}{"file_path": "config.json", "json_path": "$.server.hosts[1]", "value": "<c>"})
+
+	want := "{\n    \"name\": \"app\",\n    \"server\": {\n        \"port\": 9090,\n        \"hosts\": [\"a\", \"<c>\"]\n    },\n    \"debug\": false\n}\n"
+	if got := readTestFile(t, dir, "config.json"); got != want {
+		t.Errorf("After set_json_path:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestSetJSONPathCreate tests creating a key, nested keys that do not exist yet and an
+// appended array element, each laid out like its siblings
+func TestSetJSONPathCreate(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	writeTestFile(t, dir, "package.json", "{\n  \"name\": \"app\",\n  \"scripts\": {},\n  \"files\": [\"dist\"]\n}\n")
+	for _, args := range []map[string]interface{}{
+		{"file_path": "package.json", "json_path": "version", "value": "1.0.0"},
+		{"file_path": "package.json", "json_path": "scripts.test", "value": "go test"},
+		{"file_path": "package.json", "json_path": `engines["node.version"].min`, "value": float64(18)},
+		{"file_path": "package.json", "json_path": "files[1]", "value": "README.md"},
+	} {
+		if !setJSONPathArgs(t, args) {
+			t.Errorf("Expected %v to create the key", args["json_path"])
+		}
+	}
+
+	want := "{\n  \"name\": \"app\",\n  \"scripts\": {\n    \"test\": \"go test\"\n  },\n  \"files\": [\"dist\", \"README.md\"],\n  \"version\": \"1.0.0\",\n" +
+		"  \"engines\": {\n    \"node.version\": {\n      \"min\": 18\n    }\n  }\n}\n"
+	if got := readTestFile(t, dir, "package.json"); got != want {
+		t.Errorf("After set_json_path:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Compact documents stay compact
+	writeTestFile(t, dir, "compact.json", `{"a":{"b":1}}`)
+	setJSONPathArgs(t, map[string]interface{}{"file_path": "compact.json", "json_path": "a.c", "value": []interface{}{true, nil}})
+	if got := readTestFile(t, dir, "compact.json"); got != `{"a":{"b":1,"c":[true,null]}}` {
+		t.Errorf("Expected the compact layout to be kept, got %s", got)
+	}
+}
+
+// TestSetJSONPathYAML tests updating and creating keys in a YAML file, keeping comments
+// and replacing a nested block
+func TestSetJSONPathYAML(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	writeTestFile(t, dir, "values.yaml", "# Service settings\nservice:\n  port: 80 # public port\n  tls:\n    enabled: true\n\nreplicas: 1\n")
+	for _, args := range []map[string]interface{}{
+		{"file_path": "values.yaml", "json_path": "service.port", "value": float64(8080)},
+		{"file_path": "values.yaml", "json_path": "service.tls", "value": "off"},
+		{"file_path": "values.yaml", "json_path": "service.labels.app", "value": "web"},
+		{"file_path": "values.yaml", "json_path": "image", "value": map[string]interface{}{"tag": "v1"}},
+	} {
+		setJSONPathArgs(t, args)
+	}
+
+	want := "# Service settings\nservice:\n  port: 8080 # public port\n  tls: \"off\"\n  labels:\n    app: web\n\nreplicas: 1\nimage: {tag: v1}\n"
+	if got := readTestFile(t, dir, "values.yaml"); got != want {
+		t.Errorf("After set_json_path:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestSetJSONPathYAMLSequences tests keys whose sequence items are written at the key's own
+// indentation, as go-yaml and kubectl write them: the items belong to the key, so replacing
+// it replaces them and keys after the sequence are still found
+func TestSetJSONPathYAMLSequences(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	writeTestFile(t, dir, "deploy.yaml", "items:\n- a\n- b\nspec:\n  ports:\n  - 80\n  - 443\n  name: web\nreplicas: 1\n")
+	for _, args := range []map[string]interface{}{
+		{"file_path": "deploy.yaml", "json_path": "replicas", "value": float64(3)},
+		{"file_path": "deploy.yaml", "json_path": "spec.name", "value": "api"},
+		{"file_path": "deploy.yaml", "json_path": "spec.ports", "value": []interface{}{float64(8080)}},
+		{"file_path": "deploy.yaml", "json_path": "items", "value": []interface{}{float64(1)}},
+	} {
+		setJSONPathArgs(t, args)
+	}
+	want := "items: [1]\nspec:\n  ports: [8080]\n  name: api\nreplicas: 3\n"
+	if got := readTestFile(t, dir, "deploy.yaml"); got != want {
+		t.Errorf("After set_json_path:\n%s\nwant:\n%s", got, want)
+	}
+
+	// A new key goes after the sequence closing the document, not between its items
+	writeTestFile(t, dir, "list.yaml", "name: app\nitems:\n- a\n- b\n")
+	if !setJSONPathArgs(t, map[string]interface{}{"file_path": "list.yaml", "json_path": "version", "value": "1"}) {
+		t.Error("Expected version to be created")
+	}
+	if got := readTestFile(t, dir, "list.yaml"); got != "name: app\nitems:\n- a\n- b\nversion: \"1\"\n" {
+		t.Errorf("Unexpected list.yaml after adding a key:\n%s", got)
+	}
+}
+
+// TestSetJSONPathErrors tests that invalid JSON and paths that cannot be created are
+// refused without writing
+func TestSetJSONPathErrors(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	writeTestFile(t, dir, "bad.json", "{\"a\": 1,}\n")
+	writeTestFile(t, dir, "config.json", "{\"a\": 1, \"list\": [1, 2]}\n")
+	writeTestFile(t, dir, "list.yaml", "items:\n  - a\n  - b\n")
+	writeTestFile(t, dir, "flat.yaml", "items:\n- a\n- b\n")
+	writeTestFile(t, dir, "bad.yaml", "a: [1\nb: 2\n")
+
+	for _, args := range []map[string]interface{}{
+		{"file_path": "bad.json", "json_path": "a", "value": float64(2)},
+		{"file_path": "config.json", "json_path": "a.b", "value": float64(2)},
+		{"file_path": "config.json", "json_path": "list[5]", "value": float64(2)},
+		{"file_path": "config.json", "json_path": "list.x", "value": float64(2)},
+		{"file_path": "config.json", "json_path": "a[", "value": float64(2)},
+		{"file_path": "config.json", "json_path": "a"},
+		{"file_path": "list.yaml", "json_path": "items.first", "value": "x"},
+		{"file_path": "flat.yaml", "json_path": "items.first", "value": "x"},
+		{"file_path": "bad.yaml", "json_path": "b", "value": float64(3)},
+	} {
+		if _, err := toolSetJSONPath(args); err == nil {
+			t.Errorf("Expected an error for %v", args)
+		}
+	}
+	if got := readTestFile(t, dir, "config.json"); got != "{\"a\": 1, \"list\": [1, 2]}\n" {
+		t.Errorf("Expected config.json to be unchanged, got %s", got)
+	}
+	if got := readTestFile(t, dir, "flat.yaml"); got != "items:\n- a\n- b\n" {
+		t.Errorf("Expected flat.yaml to be unchanged, got %s", got)
+	}
+}
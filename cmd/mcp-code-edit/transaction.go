@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/code-aria/internal-mcp/internal/mcp"
+)
+
+// pathParams are the params through which an operation names a file it reads or writes
+var pathParams = []string{"file_path", "path", "old_path", "new_path", "source_path", "destination_path"}
+
+// fileSnapshot is the state of a file before a transactional batch touched it
+type fileSnapshot struct {
+	path    string
+	exists  bool
+	content []byte
+	mode    os.FileMode
+}
+
+// snapshotFiles records every file the operations may touch, so a failed transaction can
+// put them back. Files that do not exist yet are recorded so they can be removed again.
+func snapshotFiles(operations []interface{}) ([]fileSnapshot, error) {
+	var snapshots []fileSnapshot
+	seen := make(map[string]bool)
+
+	for _, op := range operations {
+		opMap, ok := op.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, path := range operationPaths(opMap) {
+			fullPath := filepath.Clean(resolvePath(path))
+			if seen[fullPath] {
+				continue
+			}
+			seen[fullPath] = true
+
+			snapshot := fileSnapshot{path: fullPath}
+			info, err := os.Stat(fullPath)
+			if os.IsNotExist(err) {
+				snapshots = append(snapshots, snapshot)
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to snapshot %s: %w", path, err)
+			}
+			if info.IsDir() {
+				return nil, fmt.Errorf("failed to snapshot %s: path is a directory", path)
+			}
+
+			content, err := os.ReadFile(fullPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to snapshot %s: %w", path, err)
+			}
+			snapshot.exists = true
+			snapshot.content = content
+			snapshot.mode = info.Mode().Perm()
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+
+	return snapshots, nil
+}
+
+// operationPaths returns the files an operation may touch. search_replace_files names
+// no path, so the files it would edit are looked up the same way the operation does.
+func operationPaths(opMap map[string]interface{}) []string {
+	var paths []string
+	for _, key := range pathParams {
+		if path, ok := opMap[key].(string); ok && path != "" {
+			paths = append(paths, path)
+		}
+	}
+
+	if opMap["type"] == "search_replace_files" {
+		// An invalid operation fails before writing anything, so there is nothing to snapshot
+		if root, err := searchReplaceRoot(); err == nil {
+			if files, err := findSearchReplaceFiles(root, opMap); err == nil {
+				paths = append(paths, files...)
+			}
+		}
+	}
+	return paths
+}
+
+// restoreSnapshots puts every snapshotted file back in its original state and returns
+// the files that could not be restored
+func restoreSnapshots(snapshots []fileSnapshot) []string {
+	var failures []string
+
+	for _, snapshot := range snapshots {
+		if !snapshot.exists {
+			if err := os.Remove(snapshot.path); err != nil && !os.IsNotExist(err) {
+				failures = append(failures, fmt.Sprintf("%s: %v", snapshot.path, err))
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(snapshot.path), 0755); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", snapshot.path, err))
+			continue
+		}
+		if err := os.WriteFile(snapshot.path, snapshot.content, snapshot.mode); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", snapshot.path, err))
+			continue
+		}
+		// WriteFile only applies the mode when it creates the file
+		if err := os.Chmod(snapshot.path, snapshot.mode); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", snapshot.path, err))
+		}
+	}
+
+	return failures
+}
+
+// runTransaction executes operations as an all-or-nothing batch. It stops at the first
+// failing operation and restores every file the batch could have touched. The returned
+// body holds the results plus a "transaction" summary naming the failed operation.
+func runTransaction(operations []interface{}) (map[string]interface{}, error) {
+	snapshots, err := snapshotFiles(operations)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]interface{}, 0, len(operations))
+	failed := -1
+	for i, op := range operations {
+		result := batchRunner.RunOperation(i, op)
+		results = append(results, result)
+		if result["success"] != true {
+			failed = i
+			break
+		}
+	}
+
+	if failed < 0 {
+		return map[string]interface{}{
+			"results":     results,
+			"transaction": map[string]interface{}{"committed": true},
+		}, nil
+	}
+
+	failedResult := results[failed]
+	for _, result := range results[:failed] {
+		result["rolled_back"] = true
+	}
+	for i := failed + 1; i < len(operations); i++ {
+		opType := "unknown"
+		params := map[string]interface{}{}
+		if opMap, ok := operations[i].(map[string]interface{}); ok {
+			if t, ok := opMap["type"].(string); ok {
+				opType = t
+			}
+			params = optimizeParams(opType, mcp.OperationParams(opMap))
+		}
+		results = append(results, mcp.ErrorResult(i, opType, params,
+			fmt.Sprintf("skipped: transaction rolled back after operation %d failed", failed)))
+	}
+
+	transaction := map[string]interface{}{
+		"committed":        false,
+		"rolled_back":      true,
+		"failed_index":     failed,
+		"failed_operation": failedResult["operation"],
+		"error":            failedResult["error"],
+	}
+	if failures := restoreSnapshots(snapshots); len(failures) > 0 {
+		transaction["rolled_back"] = false
+		transaction["restore_errors"] = failures
+	}
+
+	return map[string]interface{}{
+		"results":     results,
+		"transaction": transaction,
+	}, nil
+}
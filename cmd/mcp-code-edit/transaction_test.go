@@ -0,0 +1,161 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestFile writes content to name under dir
+func writeTestFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+}
+
+// readTestFile returns the content of name under dir
+func readTestFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", name, err)
+	}
+	return string(data)
+}
+
+// TestRunTransactionRollsBack tests that a failure in the second of three operations
+// reverts the first and never runs the third
+func TestRunTransactionRollsBack(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	writeTestFile(t, dir, "a.go", "package a\n\nconst Name = \"a\"\n")
+	writeTestFile(t, dir, "b.go", "package b\n")
+
+	operations := []interface{}{
+		map[string]interface{}{"type": "replace_code", "file_path": "a.go", "old_code": "\"a\"", "new_code": "\"changed\""},
+		map[string]interface{}{"type": "replace_code", "file_path": "b.go", "old_code": "missing", "new_code": "x"},
+		map[string]interface{}{"type": "create_file", "file_path": "c.go", "content": "package c\n"},
+	}
+
+	body, err := runTransaction(operations)
+	if err != nil {
+		t.Fatalf("runTransaction() error = %v", err)
+	}
+
+	if got := readTestFile(t, dir, "a.go"); got != "package a\n\nconst Name = \"a\"\n" {
+		t.Errorf("Expected a.go to be reverted, got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "c.go")); !os.IsNotExist(err) {
+		t.Error("Expected c.go not to be created")
+	}
+
+	transaction := body["transaction"].(map[string]interface{})
+	if transaction["committed"] != false || transaction["rolled_back"] != true {
+		t.Errorf("Expected rolled back transaction, got %v", transaction)
+	}
+	if transaction["failed_index"] != 1 || transaction["failed_operation"] != "replace_code" {
+		t.Errorf("Expected operation 1 (replace_code) to be reported as failed, got %v", transaction)
+	}
+
+	results := body["results"].([]map[string]interface{})
+	if len(results) != 3 {
+		t.Fatalf("Expected a result for every operation, got %d", len(results))
+	}
+	if results[0]["success"] != true || results[0]["rolled_back"] != true {
+		t.Errorf("Expected first result to be marked rolled back, got %v", results[0])
+	}
+	if results[1]["success"] != false {
+		t.Errorf("Expected second result to fail, got %v", results[1])
+	}
+	if results[2]["success"] != false || results[2]["operation"] != "create_file" {
+		t.Errorf("Expected third operation to be skipped, got %v", results[2])
+	}
+}
+
+// TestRunTransactionRestoresRenamedAndCreatedFiles tests rollback of operations that move
+// files around rather than edit them
+func TestRunTransactionRestoresRenamedAndCreatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	writeTestFile(t, dir, "old.go", "package old\n")
+
+	operations := []interface{}{
+		map[string]interface{}{"type": "rename_file", "old_path": "old.go", "new_path": "new.go"},
+		map[string]interface{}{"type": "create_file", "file_path": "extra.go", "content": "package extra\n"},
+		map[string]interface{}{"type": "delete_file", "file_path": "missing.go"},
+	}
+
+	body, err := runTransaction(operations)
+	if err != nil {
+		t.Fatalf("runTransaction() error = %v", err)
+	}
+
+	if got := readTestFile(t, dir, "old.go"); got != "package old\n" {
+		t.Errorf("Expected old.go to be restored, got %q", got)
+	}
+	for _, name := range []string{"new.go", "extra.go"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed on rollback", name)
+		}
+	}
+	if transaction := body["transaction"].(map[string]interface{}); transaction["failed_index"] != 2 {
+		t.Errorf("Expected operation 2 to be reported as failed, got %v", transaction)
+	}
+}
+
+// TestRunTransactionCommits tests that a fully successful transaction keeps its changes
+func TestRunTransactionCommits(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	writeTestFile(t, dir, "a.go", "package a\n")
+
+	operations := []interface{}{
+		map[string]interface{}{"type": "replace_code", "file_path": "a.go", "old_code": "package a", "new_code": "package b"},
+		map[string]interface{}{"type": "create_file", "file_path": "c.go", "content": "package c\n"},
+	}
+
+	body, err := runTransaction(operations)
+	if err != nil {
+		t.Fatalf("runTransaction() error = %v", err)
+	}
+
+	if transaction := body["transaction"].(map[string]interface{}); transaction["committed"] != true {
+		t.Errorf("Expected committed transaction, got %v", transaction)
+	}
+	if got := readTestFile(t, dir, "a.go"); got != "package b\n" {
+		t.Errorf("Expected a.go to keep the edit, got %q", got)
+	}
+	if got := readTestFile(t, dir, "c.go"); got != "package c\n" {
+		t.Errorf("Expected c.go to be created, got %q", got)
+	}
+}
+
+// TestRunTransactionRestoresSearchReplaceFiles tests that files edited by search_replace_files,
+// which names no path, are reverted as well
+func TestRunTransactionRestoresSearchReplaceFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	writeTestFile(t, dir, "a.go", "package a\n\nfunc OldName() {}\n")
+	writeTestFile(t, dir, "b.go", "package b\n")
+
+	operations := []interface{}{
+		map[string]interface{}{"type": "search_replace_files", "file_patterns": []interface{}{"*.go"}, "search": "OldName", "replace": "NewName"},
+		map[string]interface{}{"type": "replace_code", "file_path": "b.go", "old_code": "missing", "new_code": "x"},
+	}
+
+	body, err := runTransaction(operations)
+	if err != nil {
+		t.Fatalf("runTransaction() error = %v", err)
+	}
+	if transaction := body["transaction"].(map[string]interface{}); transaction["rolled_back"] != true {
+		t.Fatalf("Expected rolled back transaction, got %v", transaction)
+	}
+	if got := readTestFile(t, dir, "a.go"); got != "package a\n\nfunc OldName() {}\n" {
+		t.Errorf("Expected a.go to be reverted, got %q", got)
+	}
+}
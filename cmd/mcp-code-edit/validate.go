@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+)
+
+// validateGoSource parses content when filePath is a Go file and returns the syntax error,
+// if any. Other files have no validator and always pass.
+func validateGoSource(filePath, content string) error {
+	if !strings.EqualFold(filepath.Ext(filePath), ".go") {
+		return nil
+	}
+	_, err := parser.ParseFile(token.NewFileSet(), filepath.Base(filePath), content, parser.AllErrors)
+	return err
+}
+
+// finishEdit writes the result of an edit operation and returns its message. With
+// "validate": true a Go file is parsed first; a file that does not parse is still written,
+// so existing workflows keep working, but the parse error is returned. With "dry_run": true
+// nothing is written and only the validation result is reported.
+func finishEdit(args map[string]interface{}, filePath, content string, write func() error, message string) (string, error) {
+	validate, _ := args["validate"].(bool)
+	dryRun, _ := args["dry_run"].(bool)
+
+	var validationErr error
+	if validate {
+		validationErr = validateGoSource(filePath, content)
+	}
+
+	if dryRun {
+		if validationErr != nil {
+			return "", fmt.Errorf("validation failed, file not written: %w", validationErr)
+		}
+		if validate {
+			return "Dry run: validation passed, file not written", nil
+		}
+		return "Dry run: file not written", nil
+	}
+
+	if err := write(); err != nil {
+		return "", err
+	}
+	if validationErr != nil {
+		return "", fmt.Errorf("file written but validation failed: %w", validationErr)
+	}
+	return message, nil
+}
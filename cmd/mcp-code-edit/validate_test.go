@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	validGo   = "package main\n\nfunc main() {}\n"
+	invalidGo = "package main\n\nfunc main() {\n"
+)
+
+// TestValidateGoSource tests parsing of Go and non-Go content
+func TestValidateGoSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		wantErr bool
+	}{
+		{"valid Go", "main.go", validGo, false},
+		{"invalid Go", "main.go", invalidGo, true},
+		{"missing package clause", "pkg/a.go", "func a() {}\n", true},
+		{"non-Go file is not checked", "notes.txt", invalidGo, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateGoSource(tt.path, tt.content)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateGoSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestCreateFileValidate tests validate and dry_run on create_file
+func TestCreateFileValidate(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	t.Run("Valid Go is written", func(t *testing.T) {
+		if _, err := toolCreateFile(map[string]interface{}{"file_path": "ok.go", "content": validGo, "validate": true}); err != nil {
+			t.Fatalf("toolCreateFile() error = %v", err)
+		}
+		if got := readTestFile(t, dir, "ok.go"); got != validGo {
+			t.Errorf("ok.go = %q", got)
+		}
+	})
+
+	t.Run("Invalid Go is written and reported", func(t *testing.T) {
+		_, err := toolCreateFile(map[string]interface{}{"file_path": "broken.go", "content": invalidGo, "validate": true})
+		if err == nil || !strings.Contains(err.Error(), "broken.go:") {
+			t.Fatalf("Expected a parse error naming broken.go, got %v", err)
+		}
+		if got := readTestFile(t, dir, "broken.go"); got != invalidGo {
+			t.Errorf("Expected the file to be written anyway, got %q", got)
+		}
+	})
+
+	t.Run("Invalid Go with dry_run is not written", func(t *testing.T) {
+		_, err := toolCreateFile(map[string]interface{}{"file_path": "dry.go", "content": invalidGo, "validate": true, "dry_run": true})
+		if err == nil {
+			t.Fatal("Expected a parse error")
+		}
+		if _, err := os.Stat(filepath.Join(dir, "dry.go")); !os.IsNotExist(err) {
+			t.Error("Expected dry.go not to be created")
+		}
+	})
+
+	t.Run("Invalid Go without validate", func(t *testing.T) {
+		if _, err := toolCreateFile(map[string]interface{}{"file_path": "unchecked.go", "content": invalidGo}); err != nil {
+			t.Errorf("Expected no validation by default, got %v", err)
+		}
+	})
+}
+
+// TestEditValidate tests validate and dry_run on the edit operations
+func TestEditValidate(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+	writeTestFile(t, dir, "main.go", validGo)
+
+	// Dry runs of a valid edit leave the file alone
+	message, err := toolReplaceCode(map[string]interface{}{
+		"file_path": "main.go", "old_code": "func main() {}", "new_code": "func main() { run() }",
+		"validate": true, "dry_run": true,
+	})
+	if err != nil || !strings.Contains(message, "validation passed") {
+		t.Fatalf("toolReplaceCode() = %q, %v", message, err)
+	}
+	if got := readTestFile(t, dir, "main.go"); got != validGo {
+		t.Errorf("Dry run must not write, got %q", got)
+	}
+
+	// Invalid dry runs are reported and leave the file alone
+	if _, err := toolApplyDiff(map[string]interface{}{
+		"file_path": "main.go", "old_content": "func main() {}", "new_content": "func main() {",
+		"validate": true, "dry_run": true,
+	}); err == nil {
+		t.Error("Expected apply_diff dry run to report the parse error")
+	}
+	if _, err := toolAppendToFile(map[string]interface{}{
+		"file_path": "main.go", "content": "func broken( {}\n", "validate": true, "dry_run": true,
+	}); err == nil {
+		t.Error("Expected append_to_file dry run to report the parse error")
+	}
+	if got := readTestFile(t, dir, "main.go"); got != validGo {
+		t.Errorf("Dry runs must not write, got %q", got)
+	}
+
+	// A valid append is written
+	if _, err := toolAppendToFile(map[string]interface{}{
+		"file_path": "main.go", "content": "func run() {}\n", "validate": true,
+	}); err != nil {
+		t.Fatalf("toolAppendToFile() error = %v", err)
+	}
+	if got := readTestFile(t, dir, "main.go"); got != validGo+"func run() {}\n" {
+		t.Errorf("main.go = %q", got)
+	}
+}
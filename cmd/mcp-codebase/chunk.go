@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+)
+
+const (
+	defaultChunkMaxLines = 100
+	maxChunkMaxLines     = 2000
+	defaultChunkOverlap  = 10
+)
+
+// Chunk is a piece of a file small enough to hand to an LLM. Lines are 1-based and
+// inclusive. Oversized is set when a single Go declaration is longer than max_lines and
+// was kept whole rather than split.
+type Chunk struct {
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Symbol    string `json:"symbol,omitempty"`
+	Content   string `json:"content"`
+	Oversized bool   `json:"oversized,omitempty"`
+}
+
+// chunkUnit is a run of lines that must stay in one chunk
+type chunkUnit struct {
+	start, end int
+	symbol     string
+}
+
+// toolChunkFile splits a file into chunks of at most max_lines lines. Go files are split
+// at top-level declaration boundaries so no declaration is cut in half; other files, and
+// Go files that do not parse, are split into fixed windows that overlap by overlap lines.
+func toolChunkFile(args map[string]interface{}) (string, error) {
+	filePath, ok := args["file_path"].(string)
+	if !ok || filePath == "" {
+		return "", fmt.Errorf("file_path is required")
+	}
+
+	maxLines := defaultChunkMaxLines
+	if v, ok := args["max_lines"].(float64); ok {
+		maxLines = int(v)
+		if maxLines < 1 {
+			maxLines = 1
+		}
+		if maxLines > maxChunkMaxLines {
+			maxLines = maxChunkMaxLines
+		}
+	}
+	overlap := defaultChunkOverlap
+	if v, ok := args["overlap"].(float64); ok {
+		overlap = int(v)
+	}
+	if overlap < 0 {
+		overlap = 0
+	}
+	if overlap >= maxLines {
+		overlap = maxLines - 1
+	}
+
+	data, err := os.ReadFile(resolvePath(filePath))
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if isBinary(data) {
+		return "", fmt.Errorf("cannot chunk binary file: %s", filePath)
+	}
+
+	content := string(data)
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	language := languageForPath(filePath)
+	strategy := "lines"
+	var chunks []Chunk
+	if language == "go" {
+		if units, err := goDeclarationUnits(filePath, data, len(lines)); err == nil {
+			strategy = "declarations"
+			chunks = chunksFromUnits(lines, units, maxLines)
+		}
+	}
+	if chunks == nil {
+		chunks = lineWindowChunks(lines, maxLines, overlap)
+	}
+
+	resultJSON, err := json.Marshal(map[string]interface{}{
+		"file_path": filePath,
+		"language":  language,
+		"strategy":  strategy,
+		"chunks":    chunks,
+		"count":     len(chunks),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal chunks: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// goDeclarationUnits divides a Go file into units: the package clause with any leading
+// comments, then one unit per top-level declaration. A declaration's unit starts at its
+// doc comment and runs up to the next declaration, so comments between declarations are
+// kept with the one that follows.
+func goDeclarationUnits(filePath string, src []byte, lineCount int) ([]chunkUnit, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filePath, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	starts := []chunkUnit{{start: 1, symbol: "package " + file.Name.Name}}
+	for _, decl := range file.Decls {
+		pos := decl.Pos()
+		if doc := declDoc(decl); doc != nil {
+			pos = doc.Pos()
+		}
+		starts = append(starts, chunkUnit{start: fset.Position(pos).Line, symbol: declSymbol(decl)})
+	}
+
+	units := make([]chunkUnit, 0, len(starts))
+	for i, unit := range starts {
+		unit.end = lineCount
+		if i+1 < len(starts) {
+			unit.end = starts[i+1].start - 1
+		}
+		if unit.end >= unit.start {
+			units = append(units, unit)
+		}
+	}
+	return units, nil
+}
+
+// declDoc returns the doc comment of a top-level declaration
+func declDoc(decl ast.Decl) *ast.CommentGroup {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		return d.Doc
+	case *ast.GenDecl:
+		return d.Doc
+	}
+	return nil
+}
+
+// declSymbol names a top-level declaration: "Name" for functions, "Type.Name" for methods,
+// the declared names for types, vars and consts, and "imports" for import blocks
+func declSymbol(decl ast.Decl) string {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Recv != nil && len(d.Recv.List) > 0 {
+			return receiverTypeName(d.Recv.List[0].Type) + "." + d.Name.Name
+		}
+		return d.Name.Name
+	case *ast.GenDecl:
+		if d.Tok == token.IMPORT {
+			return "imports"
+		}
+		var names []string
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				names = append(names, s.Name.Name)
+			case *ast.ValueSpec:
+				for _, name := range s.Names {
+					names = append(names, name.Name)
+				}
+			}
+		}
+		return strings.Join(names, ", ")
+	}
+	return ""
+}
+
+// receiverTypeName returns the type name of a method receiver, without pointer or type
+// parameters
+func receiverTypeName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return receiverTypeName(e.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(e.X)
+	case *ast.IndexListExpr:
+		return receiverTypeName(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
+
+// chunksFromUnits packs consecutive units into chunks of at most maxLines lines. A unit
+// longer than maxLines becomes a chunk of its own. Blank lines at the end of a unit are
+// left out, so they neither count towards the limit nor end a chunk.
+func chunksFromUnits(lines []string, units []chunkUnit, maxLines int) []Chunk {
+	chunks := []Chunk{}
+	var current []chunkUnit
+
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		start, end := current[0].start, current[len(current)-1].end
+		symbols := make([]string, 0, len(current))
+		for _, unit := range current {
+			if unit.symbol != "" {
+				symbols = append(symbols, unit.symbol)
+			}
+		}
+		chunks = append(chunks, Chunk{
+			StartLine: start,
+			EndLine:   end,
+			Symbol:    strings.Join(symbols, ", "),
+			Content:   strings.Join(lines[start-1:end], "\n"),
+			Oversized: end-start+1 > maxLines,
+		})
+		current = nil
+	}
+
+	for _, unit := range units {
+		for unit.end > unit.start && strings.TrimSpace(lines[unit.end-1]) == "" {
+			unit.end--
+		}
+		if len(current) > 0 && unit.end-current[0].start+1 > maxLines {
+			flush()
+		}
+		current = append(current, unit)
+	}
+	flush()
+
+	return chunks
+}
+
+// lineWindowChunks splits lines into windows of maxLines lines, each starting overlap
+// lines before the previous one ended
+func lineWindowChunks(lines []string, maxLines, overlap int) []Chunk {
+	chunks := []Chunk{}
+	step := maxLines - overlap
+	for start := 0; start < len(lines); start += step {
+		end := start + maxLines
+		if end > len(lines) {
+			end = len(lines)
+		}
+		chunks = append(chunks, Chunk{
+			StartLine: start + 1,
+			EndLine:   end,
+			Content:   strings.Join(lines[start:end], "\n"),
+		})
+		if end == len(lines) {
+			break
+		}
+	}
+	return chunks
+}
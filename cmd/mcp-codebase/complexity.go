@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultComplexityResults = 100
+	maxComplexityResults     = 1000
+)
+
+// FunctionComplexity is the cyclomatic complexity of one Go function or method. Methods
+// are named Type.Method.
+type FunctionComplexity struct {
+	File       string `json:"file"`
+	Function   string `json:"function"`
+	Complexity int    `json:"complexity"`
+	StartLine  int    `json:"start_line"`
+}
+
+// toolAnalyzeComplexity computes the cyclomatic complexity of the Go functions in
+// file_path, or in every Go file of the repository matching file_patterns, and returns
+// those of at least min_complexity, most complex first. Test files are skipped unless
+// include_tests is set, as are hidden and vendored directories and files that do not
+// parse.
+func toolAnalyzeComplexity(args map[string]interface{}) (string, error) {
+	repoPath := os.Getenv("REPO_PATH")
+	if repoPath == "" {
+		return "", fmt.Errorf("REPO_PATH not set")
+	}
+
+	minComplexity := 1
+	if mc, ok := args["min_complexity"].(float64); ok && mc > 1 {
+		minComplexity = int(mc)
+	}
+	maxResults := defaultComplexityResults
+	if mr, ok := args["max_results"].(float64); ok && mr > 0 {
+		maxResults = int(mr)
+		if maxResults > maxComplexityResults {
+			maxResults = maxComplexityResults
+		}
+	}
+	includeTests, _ := args["include_tests"].(bool)
+
+	var functions []FunctionComplexity
+	filesScanned := 0
+	if filePath, ok := args["file_path"].(string); ok && filePath != "" {
+		src, err := os.ReadFile(resolvePath(filePath))
+		if err != nil {
+			return "", fmt.Errorf("failed to read file: %w", err)
+		}
+		functions, err = goComplexity(filePath, src)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse %s: %w", filePath, err)
+		}
+		filesScanned = 1
+	} else {
+		filePatterns := stringSliceArg(args, "file_patterns")
+		if len(filePatterns) == 0 {
+			filePatterns = []string{"*.go"}
+		}
+
+		err := walkMatchingFiles(repoPath, filePatterns, func(path, relPath string, d fs.DirEntry) error {
+			if !strings.HasSuffix(path, ".go") || (!includeTests && strings.HasSuffix(path, "_test.go")) {
+				return nil
+			}
+
+			src, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			fileFunctions, err := goComplexity(relPath, src)
+			if err != nil {
+				return nil
+			}
+			functions = append(functions, fileFunctions...)
+			filesScanned++
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	filtered := []FunctionComplexity{}
+	for _, f := range functions {
+		if f.Complexity >= minComplexity {
+			filtered = append(filtered, f)
+		}
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		if filtered[i].Complexity != filtered[j].Complexity {
+			return filtered[i].Complexity > filtered[j].Complexity
+		}
+		if filtered[i].File != filtered[j].File {
+			return filtered[i].File < filtered[j].File
+		}
+		return filtered[i].StartLine < filtered[j].StartLine
+	})
+	truncated := len(filtered) > maxResults
+	if truncated {
+		filtered = filtered[:maxResults]
+	}
+
+	result, err := json.Marshal(map[string]interface{}{
+		"min_complexity": minComplexity,
+		"functions":      filtered,
+		"count":          len(filtered),
+		"files_scanned":  filesScanned,
+		"truncated":      truncated,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal complexity: %w", err)
+	}
+	return string(result), nil
+}
+
+// goComplexity parses src and returns the complexity of each function with a body
+func goComplexity(filePath string, src []byte) ([]FunctionComplexity, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filePath, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var functions []FunctionComplexity
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		functions = append(functions, FunctionComplexity{
+			File:       filePath,
+			Function:   declSymbol(fn),
+			Complexity: cyclomaticComplexity(fn.Body),
+			StartLine:  fset.Position(fn.Pos()).Line,
+		})
+	}
+	return functions, nil
+}
+
+// cyclomaticComplexity is 1 plus the number of decision points in body: if, for and range
+// statements, case and select clauses other than default, and && and || operators.
+// Function literals count towards the function containing them.
+func cyclomaticComplexity(body *ast.BlockStmt) int {
+	complexity := 1
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			complexity++
+		case *ast.CaseClause:
+			if node.List != nil {
+				complexity++
+			}
+		case *ast.CommClause:
+			if node.Comm != nil {
+				complexity++
+			}
+		case *ast.BinaryExpr:
+			if node.Op == token.LAND || node.Op == token.LOR {
+				complexity++
+			}
+		}
+		return true
+	})
+	return complexity
+}
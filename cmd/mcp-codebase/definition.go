@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Definition is a place where a symbol is declared
+type Definition struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	Kind      string `json:"kind"`
+	Receiver  string `json:"receiver,omitempty"`
+	Language  string `json:"language"`
+	Signature string `json:"signature"`
+}
+
+// symbolPattern matches a symbol name, optionally qualified by a Go receiver type or a
+// class name, e.g. Run or Server.Run
+var symbolPattern = regexp.MustCompile(`^(?:([A-Za-z_$][\w$]*)\.)?([A-Za-z_$][\w$]*)$`)
+
+// heuristicLanguages are the non-Go languages whose def, function and class declarations
+// goto_definition recognizes
+var heuristicLanguages = map[string]bool{
+	"python":     true,
+	"javascript": true,
+	"typescript": true,
+	"ruby":       true,
+	"php":        true,
+}
+
+// toolGotoDefinition finds where a symbol is declared. Go files are parsed and their
+// top-level funcs, methods, types, consts and vars matched by name; Python, JavaScript,
+// TypeScript, Ruby and PHP files are matched line by line against def, function and
+// class declarations. Every match is returned, so an ambiguous name yields several.
+func toolGotoDefinition(args map[string]interface{}) (string, error) {
+	symbol, _ := args["symbol"].(string)
+	m := symbolPattern.FindStringSubmatch(symbol)
+	if m == nil {
+		return "", fmt.Errorf("symbol must be a name such as Run or Server.Run, got %q", symbol)
+	}
+	qualifier, name := m[1], m[2]
+
+	filePatterns := stringSliceArg(args, "file_patterns")
+
+	repoPath := os.Getenv("REPO_PATH")
+	if repoPath == "" {
+		return "", fmt.Errorf("REPO_PATH not set")
+	}
+
+	declPatterns := heuristicDeclPatterns(name)
+	definitions := []Definition{}
+	err := walkMatchingFiles(repoPath, filePatterns, func(path, relPath string, d fs.DirEntry) error {
+		language := languageForPath(path)
+		if language != "go" && !heuristicLanguages[language] {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil || isBinary(data) {
+			return nil
+		}
+
+		var found []Definition
+		if language == "go" {
+			found = goDefinitions(path, data, qualifier, name)
+		} else {
+			found = heuristicDefinitions(string(data), declPatterns)
+		}
+		for _, def := range found {
+			def.File = relPath
+			def.Language = language
+			definitions = append(definitions, def)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sort.SliceStable(definitions, func(i, j int) bool {
+		if definitions[i].File != definitions[j].File {
+			return definitions[i].File < definitions[j].File
+		}
+		return definitions[i].Line < definitions[j].Line
+	})
+
+	result, err := json.Marshal(map[string]interface{}{
+		"symbol":      symbol,
+		"definitions": definitions,
+		"count":       len(definitions),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal definitions: %w", err)
+	}
+	return string(result), nil
+}
+
+// goDefinitions returns the top-level declarations of name in a Go file. With a qualifier
+// only methods on that receiver type match. Files that do not parse are skipped.
+func goDefinitions(path string, src []byte, qualifier, name string) []Definition {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(string(src), "\n")
+
+	var definitions []Definition
+	add := func(ident *ast.Ident, kind, receiver string) {
+		pos := fset.Position(ident.Pos())
+		definitions = append(definitions, Definition{
+			Line:      pos.Line,
+			Column:    pos.Column,
+			Kind:      kind,
+			Receiver:  receiver,
+			Signature: strings.TrimSpace(lines[pos.Line-1]),
+		})
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Name.Name != name {
+				continue
+			}
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				receiver := receiverTypeName(d.Recv.List[0].Type)
+				if qualifier == "" || qualifier == receiver {
+					add(d.Name, "method", receiver)
+				}
+			} else if qualifier == "" {
+				add(d.Name, "func", "")
+			}
+		case *ast.GenDecl:
+			if qualifier != "" {
+				continue
+			}
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.Name == name {
+						add(s.Name, "type", "")
+					}
+				case *ast.ValueSpec:
+					for _, ident := range s.Names {
+						if ident.Name == name {
+							add(ident, d.Tok.String(), "")
+						}
+					}
+				}
+			}
+		}
+	}
+	return definitions
+}
+
+// declPattern recognizes one kind of declaration line
+type declPattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+// heuristicDeclPatterns returns the declaration patterns for name: def and function
+// declarations, functions assigned to a const, let or var, and classes and modules.
+// Qualified names are matched on their last part.
+func heuristicDeclPatterns(name string) []declPattern {
+	quoted := regexp.QuoteMeta(name)
+	// end bounds the name; \b would let a $ continue it, as in JavaScript identifiers
+	end := `(?:[^\w$]|$)`
+	return []declPattern{
+		{"function", regexp.MustCompile(`^\s*(?:(?:export|default|async|public|private|protected|static|abstract|final)\s+)*(?:def|function\*?)\s+(?:self\.)?` + quoted + end)},
+		{"function", regexp.MustCompile(`^\s*(?:export\s+)?(?:const|let|var)\s+` + quoted + `\s*(?::[^=]*)?=\s*(?:async\s+)?(?:function\b|\([^)]*\)\s*(?::[^=]*)?=>|[A-Za-z_$][\w$]*\s*=>)`)},
+		{"class", regexp.MustCompile(`^\s*(?:(?:export|default|abstract|final)\s+)*(?:class|module)\s+` + quoted + end)},
+	}
+}
+
+// heuristicDefinitions returns the lines of content that declare the name behind patterns
+func heuristicDefinitions(content string, patterns []declPattern) []Definition {
+	var definitions []Definition
+	for i, line := range strings.Split(content, "\n") {
+		for _, p := range patterns {
+			if p.re.MatchString(line) {
+				definitions = append(definitions, Definition{
+					Line:      i + 1,
+					Column:    len(line) - len(strings.TrimLeft(line, " \t")) + 1,
+					Kind:      p.kind,
+					Signature: strings.TrimSpace(line),
+				})
+				break
+			}
+		}
+	}
+	return definitions
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultDuplicateMinLines = 6
+	maxDuplicateMinLines     = 200
+	defaultDuplicateGroups   = 100
+	maxDuplicateGroups       = 1000
+	// maxDuplicateFileBytes skips larger files, which are usually generated or minified
+	maxDuplicateFileBytes = 1 << 20
+)
+
+// DuplicateLocation is one copy of a duplicated block; lines are 1-based and inclusive
+type DuplicateLocation struct {
+	File      string `json:"file"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+}
+
+// DuplicateGroup is a block of code found at every one of its occurrences. Lines counts the
+// non-blank lines of the block.
+type DuplicateGroup struct {
+	Hash        string              `json:"hash"`
+	Lines       int                 `json:"lines"`
+	Occurrences []DuplicateLocation `json:"occurrences"`
+}
+
+// duplicateFile is a scanned file: the line numbers of its non-blank lines and the hash of
+// the window of min_lines non-blank lines starting at each of them
+type duplicateFile struct {
+	path   string
+	lines  []int
+	hashes []uint64
+}
+
+// windowRef is the window starting at non-blank line index of files[file]
+type windowRef struct {
+	file  int
+	index int
+}
+
+// toolFindDuplicates finds blocks of at least min_lines lines that appear more than once
+// across the repository. Lines are compared with surrounding whitespace trimmed and blank
+// lines are ignored, so a block copied at a different indentation still matches. Windows
+// of min_lines lines are hashed at every line; overlapping windows that repeat together
+// are reported once, as one longer block. Hidden and vendored directories, binary files,
+// files over 1MB and unrecognized extensions are skipped.
+func toolFindDuplicates(args map[string]interface{}) (string, error) {
+	repoPath := os.Getenv("REPO_PATH")
+	if repoPath == "" {
+		return "", fmt.Errorf("REPO_PATH not set")
+	}
+
+	minLines := defaultDuplicateMinLines
+	if ml, ok := args["min_lines"].(float64); ok {
+		minLines = int(ml)
+		if minLines < 2 {
+			minLines = 2
+		}
+		if minLines > maxDuplicateMinLines {
+			minLines = maxDuplicateMinLines
+		}
+	}
+
+	maxGroups := defaultDuplicateGroups
+	if mg, ok := args["max_groups"].(float64); ok && mg > 0 {
+		maxGroups = int(mg)
+		if maxGroups > maxDuplicateGroups {
+			maxGroups = maxDuplicateGroups
+		}
+	}
+
+	filePatterns := stringSliceArg(args, "file_patterns")
+
+	var files []duplicateFile
+	err := walkMatchingFiles(repoPath, filePatterns, func(path, relPath string, d fs.DirEntry) error {
+		if languageForPath(path) == unknownLanguage {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxDuplicateFileBytes {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil || isBinary(data) {
+			return nil
+		}
+		files = append(files, hashWindows(relPath, string(data), minLines))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	groups := duplicateGroups(files, minLines)
+	truncated := len(groups) > maxGroups
+	if truncated {
+		groups = groups[:maxGroups]
+	}
+
+	result, err := json.Marshal(map[string]interface{}{
+		"min_lines":     minLines,
+		"groups":        groups,
+		"count":         len(groups),
+		"files_scanned": len(files),
+		"truncated":     truncated,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal duplicates: %w", err)
+	}
+	return string(result), nil
+}
+
+// hashWindows hashes every window of minLines consecutive non-blank lines of content
+func hashWindows(path, content string, minLines int) duplicateFile {
+	file := duplicateFile{path: path}
+	var normalized []string
+	for i, line := range strings.Split(content, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			normalized = append(normalized, trimmed)
+			file.lines = append(file.lines, i+1)
+		}
+	}
+
+	for start := 0; start+minLines <= len(normalized); start++ {
+		h := fnv.New64a()
+		for _, line := range normalized[start : start+minLines] {
+			h.Write([]byte(line))
+			h.Write([]byte{'\n'})
+		}
+		file.hashes = append(file.hashes, h.Sum64())
+	}
+	return file
+}
+
+// duplicateGroups groups the windows of files by hash and merges runs of groups whose
+// occurrences advance together into single longer blocks, largest blocks first
+func duplicateGroups(files []duplicateFile, minLines int) []DuplicateGroup {
+	byHash := make(map[uint64][]windowRef)
+	for fi, file := range files {
+		for index, hash := range file.hashes {
+			refs := byHash[hash]
+			// A window overlapping the previous occurrence in the same file is a repetitive
+			// stretch of lines, not another copy
+			if n := len(refs); n > 0 && refs[n-1].file == fi && index < refs[n-1].index+minLines {
+				continue
+			}
+			byHash[hash] = append(refs, windowRef{file: fi, index: index})
+		}
+	}
+
+	// neighbour returns the hash shared by the windows offset lines after every ref, if
+	// there is one and it is another group with the same number of occurrences
+	neighbour := func(hash uint64, refs []windowRef, offset int) (uint64, bool) {
+		var next uint64
+		for i, ref := range refs {
+			index := ref.index + offset
+			if index < 0 || index >= len(files[ref.file].hashes) {
+				return 0, false
+			}
+			h := files[ref.file].hashes[index]
+			if i > 0 && h != next {
+				return 0, false
+			}
+			next = h
+		}
+		return next, next != hash && len(byHash[next]) == len(refs)
+	}
+
+	groups := []DuplicateGroup{}
+	for hash, refs := range byHash {
+		if len(refs) < 2 {
+			continue
+		}
+		// Groups continuing the one before them are reported as part of it
+		if _, ok := neighbour(hash, refs, -1); ok {
+			continue
+		}
+		extra := 0
+		for {
+			if _, ok := neighbour(hash, refs, extra+1); !ok {
+				break
+			}
+			extra++
+		}
+
+		group := DuplicateGroup{Hash: fmt.Sprintf("%016x", hash), Lines: minLines + extra}
+		for _, ref := range refs {
+			file := files[ref.file]
+			group.Occurrences = append(group.Occurrences, DuplicateLocation{
+				File:      file.path,
+				StartLine: file.lines[ref.index],
+				EndLine:   file.lines[ref.index+minLines-1+extra],
+			})
+		}
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		if a.Lines != b.Lines {
+			return a.Lines > b.Lines
+		}
+		if len(a.Occurrences) != len(b.Occurrences) {
+			return len(a.Occurrences) > len(b.Occurrences)
+		}
+		if a.Occurrences[0].File != b.Occurrences[0].File {
+			return a.Occurrences[0].File < b.Occurrences[0].File
+		}
+		return a.Occurrences[0].StartLine < b.Occurrences[0].StartLine
+	})
+	return groups
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultGraphMaxFiles = 2000
+	maxGraphMaxFiles     = 20000
+)
+
+// GraphNode is a package in the dependency graph. Internal packages belong to the
+// repository's module and report how many files were scanned for them.
+type GraphNode struct {
+	ID       string `json:"id"`
+	Internal bool   `json:"internal"`
+	Files    int    `json:"files,omitempty"`
+}
+
+// GraphEdge is an import of package To by package From
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// DependencyGraph is the package-level import graph of the Go code in a repository
+type DependencyGraph struct {
+	Module       string      `json:"module,omitempty"`
+	Nodes        []GraphNode `json:"nodes"`
+	Edges        []GraphEdge `json:"edges"`
+	FilesScanned int         `json:"files_scanned"`
+	Truncated    bool        `json:"truncated"`
+}
+
+// toolBuildDependencyGraph walks the Go files of the repository and returns the
+// package-to-package import graph
+func toolBuildDependencyGraph(args map[string]interface{}) (string, error) {
+	repoPath := os.Getenv("REPO_PATH")
+	if repoPath == "" {
+		return "", fmt.Errorf("REPO_PATH not set")
+	}
+
+	maxFiles := defaultGraphMaxFiles
+	if mf, ok := args["max_files"].(float64); ok && mf > 0 {
+		maxFiles = int(mf)
+		if maxFiles > maxGraphMaxFiles {
+			maxFiles = maxGraphMaxFiles
+		}
+	}
+	includeTests, _ := args["include_tests"].(bool)
+	includeExternal := true
+	if ie, ok := args["include_external"].(bool); ok {
+		includeExternal = ie
+	}
+
+	graph, err := buildDependencyGraph(repoPath, maxFiles, includeTests, includeExternal)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := json.Marshal(graph)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal dependency graph: %w", err)
+	}
+	return string(result), nil
+}
+
+// buildDependencyGraph parses the imports of up to maxFiles .go files under repoPath.
+// Packages are identified by import path when go.mod declares a module, and by their
+// directory relative to repoPath otherwise.
+func buildDependencyGraph(repoPath string, maxFiles int, includeTests, includeExternal bool) (*DependencyGraph, error) {
+	modulePath := readModulePath(filepath.Join(repoPath, "go.mod"))
+	graph := &DependencyGraph{Module: modulePath, Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+
+	packageFiles := make(map[string]int)
+	imports := make(map[string]map[string]bool)
+	fset := token.NewFileSet()
+
+	err := filepath.WalkDir(repoPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			// Skip hidden directories (including .git), vendored and testdata code
+			if path != repoPath && (shouldSkipDir(d.Name()) || d.Name() == "vendor" || d.Name() == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || (!includeTests && strings.HasSuffix(path, "_test.go")) {
+			return nil
+		}
+		if graph.FilesScanned >= maxFiles {
+			graph.Truncated = true
+			return filepath.SkipAll
+		}
+		graph.FilesScanned++
+
+		file, err := parser.ParseFile(fset, path, nil, parser.ImportsOnly)
+		if err != nil {
+			return nil
+		}
+
+		pkg := packageID(repoPath, modulePath, filepath.Dir(path))
+		packageFiles[pkg]++
+		if imports[pkg] == nil {
+			imports[pkg] = make(map[string]bool)
+		}
+		for _, imp := range file.Imports {
+			importPath, err := strconv.Unquote(imp.Path.Value)
+			if err != nil {
+				continue
+			}
+			imports[pkg][importPath] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk repository: %w", err)
+	}
+
+	nodes := make(map[string]GraphNode)
+	for pkg, files := range packageFiles {
+		nodes[pkg] = GraphNode{ID: pkg, Internal: true, Files: files}
+	}
+	for from, targets := range imports {
+		for to := range targets {
+			internal := isInternalImport(modulePath, to)
+			if !internal && !includeExternal {
+				continue
+			}
+			if _, ok := nodes[to]; !ok {
+				nodes[to] = GraphNode{ID: to, Internal: internal}
+			}
+			graph.Edges = append(graph.Edges, GraphEdge{From: from, To: to})
+		}
+	}
+
+	for _, node := range nodes {
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].ID < graph.Nodes[j].ID })
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].From != graph.Edges[j].From {
+			return graph.Edges[i].From < graph.Edges[j].From
+		}
+		return graph.Edges[i].To < graph.Edges[j].To
+	})
+
+	return graph, nil
+}
+
+// readModulePath returns the module path declared in a go.mod file, or "" if there is none
+func readModulePath(goModPath string) string {
+	file, err := os.Open(goModPath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module ") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`)
+		}
+	}
+	return ""
+}
+
+// packageID returns the import path of the package in dir, or its slash-separated
+// path relative to repoPath when the repository has no module path
+func packageID(repoPath, modulePath, dir string) string {
+	rel, err := filepath.Rel(repoPath, dir)
+	if err != nil {
+		rel = dir
+	}
+	rel = filepath.ToSlash(rel)
+	if modulePath == "" {
+		return rel
+	}
+	if rel == "." {
+		return modulePath
+	}
+	return modulePath + "/" + rel
+}
+
+// isInternalImport reports whether importPath belongs to the module
+func isInternalImport(modulePath, importPath string) bool {
+	return modulePath != "" && (importPath == modulePath || strings.HasPrefix(importPath, modulePath+"/"))
+}
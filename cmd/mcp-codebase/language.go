@@ -0,0 +1,14 @@
+package main
+
+import "github.com/code-aria/internal-mcp/internal/filelang"
+
+// unknownLanguage is reported for files whose extension filelang does not recognize
+const unknownLanguage = "unknown"
+
+// languageForPath returns the language for a file path based on its extension, or "unknown"
+func languageForPath(filePath string) string {
+	if lang := filelang.ForPath(filePath); lang != "" {
+		return lang
+	}
+	return unknownLanguage
+}
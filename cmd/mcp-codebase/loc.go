@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// commentSyntax is how a language marks comments. Detection is heuristic: a line counts as a
+// comment only if it starts with a comment marker or lies inside a block comment, so code
+// followed by a trailing comment counts as code.
+type commentSyntax struct {
+	line       []string
+	blockStart string
+	blockEnd   string
+}
+
+var (
+	cStyleComments = commentSyntax{line: []string{"//"}, blockStart: "/*", blockEnd: "*/"}
+	hashComments   = commentSyntax{line: []string{"#"}}
+)
+
+// languageComments maps languages from filelang to their comment syntax.
+// Languages without an entry (json, markdown) have no comments.
+var languageComments = map[string]commentSyntax{
+	"go":         cStyleComments,
+	"typescript": cStyleComments,
+	"javascript": cStyleComments,
+	"rust":       cStyleComments,
+	"java":       cStyleComments,
+	"kotlin":     cStyleComments,
+	"csharp":     cStyleComments,
+	"c":          cStyleComments,
+	"cpp":        cStyleComments,
+	"swift":      cStyleComments,
+	"php":        {line: []string{"//", "#"}, blockStart: "/*", blockEnd: "*/"},
+	"python":     hashComments,
+	"ruby":       hashComments,
+	"shell":      hashComments,
+	"yaml":       hashComments,
+	"toml":       hashComments,
+	"perl":       hashComments,
+	"r":          hashComments,
+	"makefile":   hashComments,
+	"powershell": {line: []string{"#"}, blockStart: "<#", blockEnd: "#>"},
+	"sql":        {line: []string{"--"}, blockStart: "/*", blockEnd: "*/"},
+	"lua":        {line: []string{"--"}, blockStart: "--[[", blockEnd: "]]"},
+	"haskell":    {line: []string{"--"}, blockStart: "{-", blockEnd: "-}"},
+	"lisp":       {line: []string{";"}},
+	"clojure":    {line: []string{";"}},
+}
+
+// vendoredDirs are dependency directories skipped by count_loc
+var vendoredDirs = map[string]bool{
+	"vendor":       true,
+	"node_modules": true,
+	"third_party":  true,
+}
+
+// LOCStats holds line counts for a language or for the whole repository
+type LOCStats struct {
+	Language     string `json:"language,omitempty"`
+	Files        int    `json:"files"`
+	CodeLines    int    `json:"code_lines"`
+	CommentLines int    `json:"comment_lines"`
+	BlankLines   int    `json:"blank_lines"`
+}
+
+func (s *LOCStats) add(other LOCStats) {
+	s.Files += other.Files
+	s.CodeLines += other.CodeLines
+	s.CommentLines += other.CommentLines
+	s.BlankLines += other.BlankLines
+}
+
+// toolCountLOC counts code, comment and blank lines per language across the repository,
+// skipping hidden and vendored directories, binary files and unrecognized extensions
+func toolCountLOC(args map[string]interface{}) (string, error) {
+	repoPath := os.Getenv("REPO_PATH")
+	if repoPath == "" {
+		return "", fmt.Errorf("REPO_PATH not set")
+	}
+
+	byLanguage := make(map[string]*LOCStats)
+	err := filepath.WalkDir(repoPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != repoPath && (shouldSkipDir(d.Name()) || vendoredDirs[d.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		language := languageForPath(path)
+		if language == unknownLanguage {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil || isBinary(data) {
+			return nil
+		}
+
+		stats := countLines(string(data), languageComments[language])
+		if byLanguage[language] == nil {
+			byLanguage[language] = &LOCStats{Language: language}
+		}
+		byLanguage[language].add(stats)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to walk repository: %w", err)
+	}
+
+	languages := make([]LOCStats, 0, len(byLanguage))
+	totals := LOCStats{}
+	for _, stats := range byLanguage {
+		languages = append(languages, *stats)
+		totals.add(*stats)
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		if languages[i].CodeLines != languages[j].CodeLines {
+			return languages[i].CodeLines > languages[j].CodeLines
+		}
+		return languages[i].Language < languages[j].Language
+	})
+
+	result, err := json.Marshal(map[string]interface{}{
+		"languages": languages,
+		"totals":    totals,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal line counts: %w", err)
+	}
+	return string(result), nil
+}
+
+// countLines classifies each line of a file as code, comment or blank
+func countLines(content string, syntax commentSyntax) LOCStats {
+	stats := LOCStats{Files: 1}
+	if content == "" {
+		return stats
+	}
+
+	inBlock := false
+	for _, line := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case inBlock:
+			stats.CommentLines++
+			if strings.Contains(trimmed, syntax.blockEnd) {
+				inBlock = false
+			}
+		case trimmed == "":
+			stats.BlankLines++
+		case hasLineComment(trimmed, syntax.line):
+			stats.CommentLines++
+		case syntax.blockStart != "" && strings.HasPrefix(trimmed, syntax.blockStart):
+			stats.CommentLines++
+			rest := trimmed[len(syntax.blockStart):]
+			inBlock = !strings.Contains(rest, syntax.blockEnd)
+		default:
+			stats.CodeLines++
+		}
+	}
+	return stats
+}
+
+// hasLineComment reports whether line starts with one of the line comment markers
+func hasLineComment(line string, markers []string) bool {
+	for _, marker := range markers {
+		if strings.HasPrefix(line, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBinary reports whether data looks binary, i.e. has a NUL byte in its first 8KB
+func isBinary(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
@@ -9,12 +9,14 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/code-aria/internal-mcp/internal/mcp"
+	"github.com/code-aria/internal-mcp/internal/repowalk"
 )
 
 func main() {
-	scanner := bufio.NewScanner(os.Stdin)
-	// Increase buffer size to handle large JSON-RPC messages
-	scanner.Buffer(nil, 10*1024*1024) // 10MB buffer
+	// Messages up to MCP_MAX_MESSAGE_BYTES (default 10MB) are accepted
+	scanner := mcp.NewScanner(os.Stdin)
 	encoder := json.NewEncoder(os.Stdout)
 
 	// Initialize handshake
@@ -24,32 +26,7 @@ func main() {
 	}
 
 	// Handle requests
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
-		}
-		// Log large requests for debugging (warn if > 500KB, error if > 1MB)
-		if len(line) > 1_000_000 {
-			fmt.Fprintf(os.Stderr, "[ERROR] mcp-codebase: Request size %d bytes exceeds 1MB buffer\n", len(line))
-		} else if len(line) > 500_000 {
-			fmt.Fprintf(os.Stderr, "[WARN] mcp-codebase: Large request size %d bytes\n", len(line))
-		}
-
-		var msg MCPMessage
-		if err := json.Unmarshal(line, &msg); err != nil {
-			continue
-		}
-
-		if msg.Method != "" {
-			handleRequest(&msg, encoder)
-		}
-	}
-
-	// Check for scanner errors (e.g., token too long)
-	if err := scanner.Err(); err != nil {
-		fmt.Fprintf(os.Stderr, "[ERROR] mcp-codebase: Scanner error: %v (buffer max: 10MB)\n", err)
-	}
+	mcp.Serve(scanner, encoder, "mcp-codebase", handleRequest)
 }
 
 func handleInitialize(scanner *bufio.Scanner, encoder *json.Encoder) error {
@@ -100,6 +77,8 @@ func handleInitialize(scanner *bufio.Scanner, encoder *json.Encoder) error {
 
 func handleRequest(msg *MCPMessage, encoder *json.Encoder) {
 	switch msg.Method {
+	case "ping":
+		mcp.SendPing(encoder, msg.ID)
 	case "tools/list":
 		handleToolsList(msg, encoder)
 	case "tools/call":
@@ -126,7 +105,7 @@ func handleToolsList(msg *MCPMessage, encoder *json.Encoder) {
 							"properties": map[string]interface{}{
 								"type": map[string]interface{}{
 									"type":        "string",
-									"description": "Operation type: search_code, get_file_dependencies, analyze_function, get_code_context",
+									"description": "Operation type: search_code, get_file_dependencies, analyze_function, get_code_context, build_dependency_graph, count_loc, chunk_file, goto_definition, preview_replace, find_duplicates, summarize_file, analyze_complexity. count_loc takes no params and returns per-language {language, files, code_lines, comment_lines, blank_lines} sorted by code lines, plus totals; hidden and vendored directories, binary files and unrecognized extensions are skipped. build_dependency_graph returns the package import graph of the repository's Go code as {module, nodes, edges, files_scanned, truncated}; it accepts max_files (default 2000, max 20000), include_tests (default false) and include_external (default true). search_code matches include a language field detected from the file extension ('unknown' if unrecognized) and accept an optional languages array to search only files of those languages. chunk_file takes file_path, max_lines (default 100, max 2000) and overlap (default 10) and returns {chunks: [{start_line, end_line, symbol, content}], strategy}; Go files are split between top-level declarations, which are never cut (a longer one becomes its own chunk marked oversized), other files into overlapping line windows. goto_definition takes symbol (a name, or Type.Method for Go methods) and optional file_patterns and returns {symbol, definitions: [{file, line, column, kind, receiver, language, signature}], count}; Go files are parsed for top-level func, method, type, const and var declarations, Python, JavaScript, TypeScript, Ruby and PHP files are matched against def, function and class declarations, and every match is returned when the name is ambiguous. preview_replace takes a search regex, a replace template ($1 or ${name} expand capture groups), optional file_patterns, exclude_patterns and max_changes (default 1000, max 10000) and returns {search, replace, files: [{file, changes: [{line, before, after}]}], files_changed, lines_changed, truncated} without modifying any file; files are selected like mcp-code-edit's search_replace_files (patterns match the file name, or the repository path when they contain a slash; hidden, vendor, node_modules and third_party directories are skipped), but matching is per line, so patterns do not span lines. find_duplicates takes min_lines (default 6, min 2, max 200), optional file_patterns and max_groups (default 100, max 1000) and returns {min_lines, groups: [{hash, lines, occurrences: [{file, start_line, end_line}]}], count, files_scanned, truncated}, longest blocks first; lines are compared with whitespace trimmed, blank lines are ignored, a block repeated over more than min_lines lines is one group, and hidden, vendored, binary, over-1MB and unrecognized files are skipped. summarize_file takes file_path and optional include_unexported (bool) and returns a structural overview without the file's content: {file_path, language, strategy, package, imports, symbols: [{name, kind, receiver, exported, line, signature}], exported_count, symbol_count, line_count}; Go files are parsed (strategy ast, a method is exported only when its receiver type is too), Python, JavaScript, TypeScript, Rust and Java are read line by line (strategy heuristic), and other files only get line_count. Only exported symbols are listed unless include_unexported is set. analyze_complexity takes an optional file_path (otherwise every Go file matching file_patterns, default *.go, is scanned), min_complexity (default 1), max_results (default 100, max 1000) and include_tests (default false) and returns {min_complexity, functions: [{file, function, complexity, start_line}], count, files_scanned, truncated}, most complex first; complexity is 1 plus each if, for, range, non-default case and select clause, && and || in the function, function literals included, and methods are named Type.Method",
 								},
 							},
 						},
@@ -169,119 +148,45 @@ func handleToolCall(msg *MCPMessage, encoder *json.Encoder) {
 	sendError(encoder, msg.ID, -32601, fmt.Sprintf("Unknown tool: %s. Use apply_operations for batch operations", req.Name), nil)
 }
 
+// batchRunner dispatches apply_operations to the operation handlers
+var batchRunner = mcp.NewBatchRunner(map[string]mcp.OperationHandler{
+	"search_code":            toolSearchCode,
+	"get_file_dependencies":  toolGetFileDependencies,
+	"analyze_function":       toolAnalyzeFunction,
+	"get_code_context":       toolGetCodeContext,
+	"build_dependency_graph": toolBuildDependencyGraph,
+	"count_loc":              toolCountLOC,
+	"chunk_file":             toolChunkFile,
+	"goto_definition":        toolGotoDefinition,
+	"preview_replace":        toolPreviewReplace,
+	"find_duplicates":        toolFindDuplicates,
+	"summarize_file":         toolSummarizeFile,
+	"analyze_complexity":     toolAnalyzeComplexity,
+})
+
+// handleBatchOperations processes a batch of operations
 func handleBatchOperations(msg *MCPMessage, encoder *json.Encoder, args map[string]interface{}) {
-	operations, ok := args["operations"].([]interface{})
-	if !ok {
-		sendError(encoder, msg.ID, -32602, "operations array is required", nil)
-		return
-	}
-
-	if len(operations) == 0 {
-		sendError(encoder, msg.ID, -32602, "operations array cannot be empty", nil)
-		return
-	}
-
-	var results []map[string]interface{}
-
-	for _, op := range operations {
-		opMap, ok := op.(map[string]interface{})
-		if !ok {
-			results = append(results, map[string]interface{}{
-				"operation": "unknown",
-				"params":    map[string]interface{}{},
-				"status":    "Error",
-				"message":   "Invalid operation format",
-			})
-			continue
-		}
-
-		opType, ok := opMap["type"].(string)
-		if !ok {
-			results = append(results, map[string]interface{}{
-				"operation": "unknown",
-				"params":    map[string]interface{}{},
-				"status":    "Error",
-				"message":   "Operation type is required",
-			})
-			continue
-		}
+	batchRunner.Handle(msg, encoder, args)
+}
 
-		// Extract operation-specific arguments as params
-		params := make(map[string]interface{})
-		for k, v := range opMap {
-			if k != "type" {
-				params[k] = v
+// stringSliceArg reads the non-empty strings of an array param
+func stringSliceArg(args map[string]interface{}, key string) []string {
+	var values []string
+	if items, ok := args[key].([]interface{}); ok {
+		for _, item := range items {
+			if value, ok := item.(string); ok && value != "" {
+				values = append(values, value)
 			}
 		}
-
-		// Execute operation based on type
-		var result string
-		var err error
-
-		switch opType {
-		case "search_code":
-			result, err = toolSearchCode(params)
-		case "get_file_dependencies":
-			result, err = toolGetFileDependencies(params)
-		case "analyze_function":
-			result, err = toolAnalyzeFunction(params)
-		case "get_code_context":
-			result, err = toolGetCodeContext(params)
-		default:
-			err = fmt.Errorf("unknown operation type: %s", opType)
-		}
-
-		if err != nil {
-			results = append(results, map[string]interface{}{
-				"operation": opType,
-				"params":    params,
-				"status":    "Error",
-				"message":   err.Error(),
-			})
-		} else {
-			// Parse JSON result if possible, otherwise use as string
-			var parsedResult interface{}
-			if jsonErr := json.Unmarshal([]byte(result), &parsedResult); jsonErr == nil {
-				// Successfully parsed JSON, use parsed result
-			} else {
-				// Not JSON, use string as-is
-				parsedResult = result
-			}
-
-			results = append(results, map[string]interface{}{
-				"operation": opType,
-				"params":    params,
-				"status":    "Success",
-				"result":    parsedResult,
-			})
-		}
-	}
-
-	// Serialize results to JSON text for MCP-compliant response format
-	resultsJSON, err := json.Marshal(map[string]interface{}{
-		"results": results,
-	})
-	if err != nil {
-		sendError(encoder, msg.ID, -32700, fmt.Sprintf("Failed to marshal results: %v", err), nil)
-		return
-	}
-
-	// Return results in MCP-compliant format using ToolsCallResponse
-	response := MCPMessage{
-		JSONRPC: "2.0",
-		ID:      msg.ID,
-		Result: ToolsCallResponse{
-			Content: []Content{
-				{
-					Type: "text",
-					Text: string(resultsJSON),
-				},
-			},
-			IsError: false,
-		},
 	}
+	return values
+}
 
-	encoder.Encode(response)
+// walkMatchingFiles calls fn, in lexical order, for every file under repoPath whose name,
+// or path for patterns with a slash, matches one of patterns; every file when patterns is
+// empty. Hidden and vendored directories and symlinks are skipped, as in repowalk.Walk.
+func walkMatchingFiles(repoPath string, patterns []string, fn func(path, relPath string, d fs.DirEntry) error) error {
+	return repowalk.Walk(repoPath, patterns, nil, fn)
 }
 
 func shouldSkipDir(dirName string) bool {
@@ -303,6 +208,17 @@ func toolSearchCode(args map[string]interface{}) (string, error) {
 		}
 	}
 
+	// Optional language filter, e.g. ["go", "python"]; "unknown" selects unrecognized extensions
+	var languages map[string]bool
+	if langs, ok := args["languages"].([]interface{}); ok && len(langs) > 0 {
+		languages = make(map[string]bool, len(langs))
+		for _, l := range langs {
+			if lang, ok := l.(string); ok && lang != "" {
+				languages[strings.ToLower(lang)] = true
+			}
+		}
+	}
+
 	repoPath := os.Getenv("REPO_PATH")
 	if repoPath == "" {
 		return "", fmt.Errorf("REPO_PATH not set")
@@ -338,6 +254,11 @@ func toolSearchCode(args map[string]interface{}) (string, error) {
 			return nil
 		}
 
+		language := languageForPath(path)
+		if languages != nil && !languages[language] {
+			return nil
+		}
+
 		data, err := os.ReadFile(path)
 		if err != nil {
 			return nil
@@ -348,9 +269,10 @@ func toolSearchCode(args map[string]interface{}) (string, error) {
 			if pattern.MatchString(line) {
 				relPath, _ := filepath.Rel(repoPath, path)
 				matches = append(matches, map[string]interface{}{
-					"file":  relPath,
-					"line":  i + 1,
-					"match": strings.TrimSpace(line),
+					"file":     relPath,
+					"line":     i + 1,
+					"match":    strings.TrimSpace(line),
+					"language": language,
 				})
 			}
 		}
@@ -516,55 +438,15 @@ func sendError(encoder *json.Encoder, id interface{}, code int, message string,
 	encoder.Encode(response)
 }
 
-// MCP types
-type MCPMessage struct {
-	JSONRPC string          `json:"jsonrpc"`
-	ID      interface{}     `json:"id,omitempty"`
-	Method  string          `json:"method,omitempty"`
-	Params  json.RawMessage `json:"params,omitempty"`
-	Result  interface{}     `json:"result,omitempty"`
-	Error   *MCPError       `json:"error,omitempty"`
-}
-
-type MCPError struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-}
-
-type InitializeResponse struct {
-	ProtocolVersion string                 `json:"protocolVersion"`
-	Capabilities    map[string]interface{} `json:"capabilities"`
-	ServerInfo      ServerInfo             `json:"serverInfo"`
-}
-
-type ServerInfo struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
-}
-
-type Tool struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	InputSchema map[string]interface{} `json:"inputSchema"`
-}
-
-type ToolsListResponse struct {
-	Tools []Tool `json:"tools"`
-}
-
-type ToolsCallRequest struct {
-	Name      string                 `json:"name"`
-	Arguments map[string]interface{} `json:"arguments,omitempty"`
-}
-
-type ToolsCallResponse struct {
-	Content []Content `json:"content"`
-	IsError bool      `json:"isError,omitempty"`
-}
-
-type Content struct {
-	Type string `json:"type"`
-	Text string `json:"text,omitempty"`
-}
-
+// MCP protocol types, shared with the other servers
+type (
+	MCPMessage         = mcp.Message
+	MCPError           = mcp.Error
+	InitializeResponse = mcp.InitializeResponse
+	ServerInfo         = mcp.ServerInfo
+	Tool               = mcp.Tool
+	ToolsListResponse  = mcp.ToolsListResponse
+	ToolsCallRequest   = mcp.ToolsCallRequest
+	ToolsCallResponse  = mcp.ToolsCallResponse
+	Content            = mcp.Content
+)
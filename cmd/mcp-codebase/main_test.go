@@ -0,0 +1,832 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/code-aria/internal-mcp/internal/mcp"
+)
+
+// TestLanguageForPath tests extension to language mapping
+func TestLanguageForPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"main.go", "go"},
+		{"scripts/build.py", "python"},
+		{"web/App.TSX", "typescript"},
+		{"data/file.xyz", "unknown"},
+		{"Makefile", "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := languageForPath(tt.path); got != tt.want {
+			t.Errorf("languageForPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestSearchCodeLanguages tests the language field and languages filter of search_code
+func TestSearchCodeLanguages(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	files := map[string]string{
+		"main.go":   "// TODO: handle errors\n",
+		"tool.py":   "# TODO: add typing\n",
+		"notes.xyz": "TODO: unknown format\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	search := func(args map[string]interface{}) map[string]string {
+		t.Helper()
+		result, err := toolSearchCode(args)
+		if err != nil {
+			t.Fatalf("toolSearchCode() error = %v", err)
+		}
+		var matches []struct {
+			File     string `json:"file"`
+			Language string `json:"language"`
+		}
+		if err := json.Unmarshal([]byte(result), &matches); err != nil {
+			t.Fatalf("Failed to parse result: %v", err)
+		}
+		languages := make(map[string]string)
+		for _, m := range matches {
+			languages[m.File] = m.Language
+		}
+		return languages
+	}
+
+	all := search(map[string]interface{}{"query": "TODO"})
+	want := map[string]string{"main.go": "go", "tool.py": "python", "notes.xyz": "unknown"}
+	for file, lang := range want {
+		if all[file] != lang {
+			t.Errorf("Expected %s to have language %q, got %q", file, lang, all[file])
+		}
+	}
+
+	filtered := search(map[string]interface{}{"query": "TODO", "languages": []interface{}{"Go", "unknown"}})
+	if len(filtered) != 2 || filtered["main.go"] != "go" || filtered["notes.xyz"] != "unknown" {
+		t.Errorf("Expected only go and unknown matches, got %v", filtered)
+	}
+}
+
+// TestBuildDependencyGraph tests the package import graph of a small multi-package module
+func TestBuildDependencyGraph(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	files := map[string]string{
+		"go.mod":                     "module example.com/app\n\ngo 1.21\n",
+		"main.go":                    "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/app/internal/store\"\n)\n\nfunc main() { fmt.Println(store.Name) }\n",
+		"internal/store/db.go":       "package store\n\nimport \"example.com/app/internal/util\"\n\nvar Name = util.Name\n",
+		"internal/util/util.go":      "package util\n\nconst Name = \"util\"\n",
+		"internal/util/util_test.go": "package util\n\nimport \"testing\"\n\nfunc TestName(t *testing.T) {}\n",
+		".hidden/skip.go":            "package skip\n\nimport \"os\"\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	parse := func(args map[string]interface{}) DependencyGraph {
+		t.Helper()
+		result, err := toolBuildDependencyGraph(args)
+		if err != nil {
+			t.Fatalf("toolBuildDependencyGraph() error = %v", err)
+		}
+		var graph DependencyGraph
+		if err := json.Unmarshal([]byte(result), &graph); err != nil {
+			t.Fatalf("Failed to parse result: %v", err)
+		}
+		return graph
+	}
+
+	graph := parse(map[string]interface{}{})
+	if graph.Module != "example.com/app" || graph.FilesScanned != 3 || graph.Truncated {
+		t.Errorf("Unexpected graph summary: module=%q files=%d truncated=%v", graph.Module, graph.FilesScanned, graph.Truncated)
+	}
+
+	wantEdges := []GraphEdge{
+		{From: "example.com/app", To: "example.com/app/internal/store"},
+		{From: "example.com/app", To: "fmt"},
+		{From: "example.com/app/internal/store", To: "example.com/app/internal/util"},
+	}
+	if len(graph.Edges) != len(wantEdges) {
+		t.Fatalf("Expected edges %v, got %v", wantEdges, graph.Edges)
+	}
+	for i, edge := range wantEdges {
+		if graph.Edges[i] != edge {
+			t.Errorf("Edge %d = %v, want %v", i, graph.Edges[i], edge)
+		}
+	}
+
+	nodes := make(map[string]GraphNode)
+	for _, node := range graph.Nodes {
+		nodes[node.ID] = node
+	}
+	if len(nodes) != 4 || !nodes["example.com/app/internal/util"].Internal || nodes["fmt"].Internal {
+		t.Errorf("Unexpected nodes: %v", graph.Nodes)
+	}
+
+	// Tests add the testing import; excluding external packages drops fmt and testing
+	graph = parse(map[string]interface{}{"include_tests": true, "include_external": false})
+	for _, edge := range graph.Edges {
+		if edge.To == "fmt" || edge.To == "testing" {
+			t.Errorf("Expected external imports to be excluded, got %v", edge)
+		}
+	}
+	if graph.FilesScanned != 4 {
+		t.Errorf("Expected test files to be scanned, got %d files", graph.FilesScanned)
+	}
+
+	graph = parse(map[string]interface{}{"max_files": 1.0})
+	if graph.FilesScanned != 1 || !graph.Truncated {
+		t.Errorf("Expected scan capped at 1 file, got %d (truncated=%v)", graph.FilesScanned, graph.Truncated)
+	}
+}
+
+// TestCountLOC tests per-language line counts on a small Go and Python fixture
+func TestCountLOC(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	files := map[string]string{
+		"main.go":           "package main\n\n// main runs the tool\nfunc main() {\n\t/* block\n\t   comment */\n\tprintln(\"hi\") // trailing\n}\n",
+		"util.go":           "package main\n",
+		"tool.py":           "#!/usr/bin/env python3\n# helper\n\ndef run():\n    return 1\n",
+		"vendor/dep/dep.go": "package dep\n",
+		".hidden/skip.py":   "x = 1\n",
+		"data.bin":          "\x00\x01",
+		"image.go":          "package x\x00",
+		"notes.xyz":         "not counted\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := toolCountLOC(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("toolCountLOC() error = %v", err)
+	}
+
+	var counts struct {
+		Languages []LOCStats `json:"languages"`
+		Totals    LOCStats   `json:"totals"`
+	}
+	if err := json.Unmarshal([]byte(result), &counts); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+
+	want := []LOCStats{
+		{Language: "go", Files: 2, CodeLines: 5, CommentLines: 3, BlankLines: 1},
+		{Language: "python", Files: 1, CodeLines: 2, CommentLines: 2, BlankLines: 1},
+	}
+	if len(counts.Languages) != len(want) {
+		t.Fatalf("Expected %d languages, got %+v", len(want), counts.Languages)
+	}
+	for i := range want {
+		if counts.Languages[i] != want[i] {
+			t.Errorf("Languages[%d] = %+v, want %+v", i, counts.Languages[i], want[i])
+		}
+	}
+	if counts.Totals != (LOCStats{Files: 3, CodeLines: 7, CommentLines: 5, BlankLines: 2}) {
+		t.Errorf("Unexpected totals %+v", counts.Totals)
+	}
+}
+
+// chunkFile calls toolChunkFile on content written to name and parses the result
+func chunkFile(t *testing.T, name, content string, args map[string]interface{}) (string, []Chunk) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	args["file_path"] = name
+	result, err := toolChunkFile(args)
+	if err != nil {
+		t.Fatalf("toolChunkFile() error = %v", err)
+	}
+	var decoded struct {
+		Strategy string  `json:"strategy"`
+		Chunks   []Chunk `json:"chunks"`
+	}
+	if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	return decoded.Strategy, decoded.Chunks
+}
+
+// TestChunkFileGo tests that Go chunks end between declarations and never split a function
+func TestChunkFileGo(t *testing.T) {
+	var src strings.Builder
+	src.WriteString("package demo\n\nimport \"fmt\"\n")
+	// Functions of 4, 5, ..., 9 lines, plus a 14-line one longer than max_lines
+	for i, body := range []int{2, 3, 4, 5, 6, 7, 12} {
+		fmt.Fprintf(&src, "\n// F%d is generated\nfunc F%d() {\n", i, i)
+		for j := 0; j < body; j++ {
+			fmt.Fprintf(&src, "\tfmt.Println(%d)\n", j)
+		}
+		src.WriteString("}\n")
+	}
+	src.WriteString("\ntype T struct{}\n\nfunc (t *T) Method() {}\n")
+
+	strategy, chunks := chunkFile(t, "demo.go", src.String(), map[string]interface{}{"max_lines": float64(12)})
+	if strategy != "declarations" {
+		t.Fatalf("Expected declarations strategy, got %q", strategy)
+	}
+
+	lines := strings.Split(src.String(), "\n")
+	var previousEnd int
+	for _, chunk := range chunks {
+		if chunk.StartLine <= previousEnd {
+			t.Errorf("Chunk %d-%d overlaps the previous chunk", chunk.StartLine, chunk.EndLine)
+		}
+		previousEnd = chunk.EndLine
+		if chunk.Content != strings.Join(lines[chunk.StartLine-1:chunk.EndLine], "\n") {
+			t.Errorf("Chunk %d-%d content does not match its lines", chunk.StartLine, chunk.EndLine)
+		}
+		if size := chunk.EndLine - chunk.StartLine + 1; size > 12 && !chunk.Oversized {
+			t.Errorf("Chunk %d-%d has %d lines but is not marked oversized", chunk.StartLine, chunk.EndLine, size)
+		}
+		// Every chunk ends with a complete declaration
+		if last := strings.TrimSpace(lines[chunk.EndLine-1]); chunk.StartLine > 1 && last != "}" && last != "type T struct{}" && !strings.HasSuffix(last, "{}") {
+			t.Errorf("Chunk %d-%d ends mid-declaration at %q", chunk.StartLine, chunk.EndLine, last)
+		}
+	}
+
+	// Each function's opening and closing lines fall in the same chunk
+	for i := 0; i < 7; i++ {
+		open := fmt.Sprintf("func F%d() {", i)
+		var found bool
+		for _, chunk := range chunks {
+			idx := strings.Index(chunk.Content, open)
+			if idx < 0 {
+				continue
+			}
+			found = true
+			if !strings.Contains(chunk.Content[idx:], "\n}") {
+				t.Errorf("F%d is split across chunks", i)
+			}
+			if !strings.Contains(chunk.Symbol, fmt.Sprintf("F%d", i)) {
+				t.Errorf("Expected symbol of chunk with F%d to name it, got %q", i, chunk.Symbol)
+			}
+		}
+		if !found {
+			t.Errorf("F%d not found in any chunk", i)
+		}
+	}
+
+	if last := chunks[len(chunks)-1]; !strings.Contains(last.Symbol, "T.Method") {
+		t.Errorf("Expected the last chunk to name T.Method, got %q", last.Symbol)
+	}
+}
+
+// TestChunkFileLines tests overlapping fixed-size windows for non-Go files
+func TestChunkFileLines(t *testing.T) {
+	var src strings.Builder
+	for i := 1; i <= 25; i++ {
+		fmt.Fprintf(&src, "line %d\n", i)
+	}
+
+	strategy, chunks := chunkFile(t, "notes.py", src.String(), map[string]interface{}{"max_lines": float64(10), "overlap": float64(2)})
+	if strategy != "lines" {
+		t.Fatalf("Expected lines strategy, got %q", strategy)
+	}
+	want := [][2]int{{1, 10}, {9, 18}, {17, 25}}
+	if len(chunks) != len(want) {
+		t.Fatalf("Expected %d chunks, got %+v", len(want), chunks)
+	}
+	for i, w := range want {
+		if chunks[i].StartLine != w[0] || chunks[i].EndLine != w[1] {
+			t.Errorf("Chunk %d = %d-%d, want %d-%d", i, chunks[i].StartLine, chunks[i].EndLine, w[0], w[1])
+		}
+	}
+	if !strings.HasPrefix(chunks[1].Content, "line 9\n") {
+		t.Errorf("Unexpected second chunk content %q", chunks[1].Content)
+	}
+
+	// Go that does not parse falls back to line windows
+	if strategy, _ := chunkFile(t, "broken.go", "package x\nfunc {\n", map[string]interface{}{}); strategy != "lines" {
+		t.Errorf("Expected lines strategy for unparsable Go, got %q", strategy)
+	}
+}
+
+// gotoDefinition runs goto_definition and returns the definitions it found
+func gotoDefinition(t *testing.T, args map[string]interface{}) []Definition {
+	t.Helper()
+	result, err := toolGotoDefinition(args)
+	if err != nil {
+		t.Fatalf("toolGotoDefinition(%v) error = %v", args, err)
+	}
+	var decoded struct {
+		Definitions []Definition `json:"definitions"`
+		Count       int          `json:"count"`
+	}
+	if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if decoded.Count != len(decoded.Definitions) {
+		t.Errorf("count %d does not match %d definitions", decoded.Count, len(decoded.Definitions))
+	}
+	return decoded.Definitions
+}
+
+// TestGotoDefinition tests finding Go funcs, types and methods and Python and JavaScript
+// declarations
+func TestGotoDefinition(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	files := map[string]string{
+		"server.go":     "package demo\n\n// Server serves requests\ntype Server struct{}\n\nfunc (s *Server) Run() error { return nil }\n\nconst DefaultPort = 8080\n",
+		"run.go":        "package demo\n\nfunc Run() {\n\tnew(Server).Run()\n}\n",
+		"tools/run.py":  "class Runner:\n    def Run(self):\n        pass\n\nRunner().Run()\n",
+		"web/app.js":    "export async function Run() {}\nconst Server = (opts) => opts\nRun()\n",
+		"vendor/x/x.go": "package x\n\nfunc Run() {}\n",
+		"docs/notes.md": "class Server is documented here\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("Go type", func(t *testing.T) {
+		defs := gotoDefinition(t, map[string]interface{}{"symbol": "Server", "file_patterns": []interface{}{"*.go"}})
+		if len(defs) != 1 {
+			t.Fatalf("Expected one definition, got %+v", defs)
+		}
+		want := Definition{File: "server.go", Line: 4, Column: 6, Kind: "type", Language: "go", Signature: "type Server struct{}"}
+		if defs[0] != want {
+			t.Errorf("Definition = %+v, want %+v", defs[0], want)
+		}
+	})
+
+	t.Run("Go func", func(t *testing.T) {
+		defs := gotoDefinition(t, map[string]interface{}{"symbol": "Server.Run", "file_patterns": []interface{}{"*.go"}})
+		if len(defs) != 1 || defs[0].File != "server.go" || defs[0].Line != 6 || defs[0].Kind != "method" || defs[0].Receiver != "Server" {
+			t.Errorf("Expected the Server.Run method, got %+v", defs)
+		}
+
+		defs = gotoDefinition(t, map[string]interface{}{"symbol": "DefaultPort"})
+		if len(defs) != 1 || defs[0].Kind != "const" || defs[0].Line != 8 {
+			t.Errorf("Expected the DefaultPort const, got %+v", defs)
+		}
+	})
+
+	t.Run("Ambiguous name returns every match", func(t *testing.T) {
+		defs := gotoDefinition(t, map[string]interface{}{"symbol": "Run"})
+		got := make([]string, len(defs))
+		for i, def := range defs {
+			got[i] = fmt.Sprintf("%s:%d:%s", def.File, def.Line, def.Kind)
+		}
+		want := []string{"run.go:3:func", "server.go:6:method", "tools/run.py:2:function", "web/app.js:1:function"}
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("Definitions = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Heuristic class and arrow function", func(t *testing.T) {
+		defs := gotoDefinition(t, map[string]interface{}{"symbol": "Runner"})
+		if len(defs) != 1 || defs[0].Kind != "class" || defs[0].Language != "python" {
+			t.Errorf("Expected the Runner class, got %+v", defs)
+		}
+		defs = gotoDefinition(t, map[string]interface{}{"symbol": "Server", "file_patterns": []interface{}{"*.js"}})
+		if len(defs) != 1 || defs[0].Line != 2 || defs[0].Kind != "function" {
+			t.Errorf("Expected the Server arrow function, got %+v", defs)
+		}
+	})
+
+	if _, err := toolGotoDefinition(map[string]interface{}{"symbol": "a b"}); err == nil {
+		t.Error("Expected an error for an invalid symbol")
+	}
+}
+
+// TestServeLargeMessage tests that a request larger than bufio's 64KB default is served
+func TestServeLargeMessage(t *testing.T) {
+	t.Setenv(mcp.MaxMessageBytesEnv, "")
+
+	request := `{"jsonrpc":"2.0","id":3,"method":"tools/list","params":{"padding":"` + strings.Repeat("x", 200*1024) + `"}}` + "\n"
+	var output bytes.Buffer
+	mcp.Serve(mcp.NewScanner(strings.NewReader(request)), json.NewEncoder(&output), "mcp-codebase", handleRequest)
+
+	var response struct {
+		ID     float64           `json:"id"`
+		Result ToolsListResponse `json:"result"`
+		Error  *MCPError         `json:"error"`
+	}
+	if err := json.Unmarshal(output.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response %q: %v", output.String(), err)
+	}
+	if response.Error != nil {
+		t.Fatalf("Unexpected error: %+v", response.Error)
+	}
+	if response.ID != 3 || len(response.Result.Tools) == 0 {
+		t.Errorf("Expected the tool list for request 3, got %+v", response)
+	}
+}
+
+func TestPreviewReplace(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	files := map[string]string{
+		"user.go":       "package demo\n\nfunc getUser(id int) {}\n\nfunc load() {\n\tgetUser(1)\n\tgetUsers()\n}\n",
+		"notes.md":      "Call getUser(id) to fetch one user.\n",
+		"vendor/lib.go": "package lib\n\nfunc getUser() {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := toolPreviewReplace(map[string]interface{}{
+		"search":        `\bget(User)\(`,
+		"replace":       "fetch${1}ByID(",
+		"file_patterns": []interface{}{"*.go"},
+	})
+	if err != nil {
+		t.Fatalf("toolPreviewReplace() error = %v", err)
+	}
+
+	var preview struct {
+		Files        []FileReplacePreview `json:"files"`
+		LinesChanged int                  `json:"lines_changed"`
+		Truncated    bool                 `json:"truncated"`
+	}
+	if err := json.Unmarshal([]byte(result), &preview); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+
+	if len(preview.Files) != 1 || preview.Files[0].File != "user.go" {
+		t.Fatalf("Expected changes only in user.go, got %+v", preview.Files)
+	}
+	want := []ReplaceChange{
+		{Line: 3, Before: "func getUser(id int) {}", After: "func fetchUserByID(id int) {}"},
+		{Line: 6, Before: "\tgetUser(1)", After: "\tfetchUserByID(1)"},
+	}
+	if len(preview.Files[0].Changes) != len(want) {
+		t.Fatalf("Changes = %+v, want %+v", preview.Files[0].Changes, want)
+	}
+	for i, change := range preview.Files[0].Changes {
+		if change != want[i] {
+			t.Errorf("Change %d = %+v, want %+v", i, change, want[i])
+		}
+	}
+	if preview.LinesChanged != 2 || preview.Truncated {
+		t.Errorf("Expected 2 lines changed without truncation, got %d (truncated=%v)", preview.LinesChanged, preview.Truncated)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "user.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != files["user.go"] {
+		t.Errorf("Expected user.go to be left untouched, got %q", data)
+	}
+
+	// Patterns with a slash match the path, as in mcp-code-edit's search_replace_files
+	if err := os.MkdirAll(filepath.Join(dir, "internal", "store"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "internal", "store", "store.go"), []byte("package store\n\nfunc getUser() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err = toolPreviewReplace(map[string]interface{}{
+		"search":           `getUser\(`,
+		"replace":          "fetchUser(",
+		"file_patterns":    []interface{}{"internal/*/*.go", "*.md"},
+		"exclude_patterns": []interface{}{"notes.md"},
+	})
+	if err != nil {
+		t.Fatalf("toolPreviewReplace() error = %v", err)
+	}
+	preview.Files = nil
+	if err := json.Unmarshal([]byte(result), &preview); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if len(preview.Files) != 1 || preview.Files[0].File != filepath.Join("internal", "store", "store.go") {
+		t.Errorf("Expected changes only in internal/store/store.go, got %+v", preview.Files)
+	}
+
+	if _, err := toolPreviewReplace(map[string]interface{}{"search": "(", "replace": ""}); err == nil {
+		t.Error("Expected an error for an invalid regex")
+	}
+}
+
+// findDuplicates calls toolFindDuplicates and parses its groups
+func findDuplicates(t *testing.T, args map[string]interface{}) []DuplicateGroup {
+	t.Helper()
+	result, err := toolFindDuplicates(args)
+	if err != nil {
+		t.Fatalf("toolFindDuplicates() error = %v", err)
+	}
+	var parsed struct {
+		Groups []DuplicateGroup `json:"groups"`
+		Count  int              `json:"count"`
+	}
+	if err := json.Unmarshal([]byte(result), &parsed); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if parsed.Count != len(parsed.Groups) {
+		t.Errorf("count = %d, but %d groups", parsed.Count, len(parsed.Groups))
+	}
+	return parsed.Groups
+}
+
+// TestFindDuplicates tests that a block copied between files is reported once, as a single
+// group spanning the whole copy, and that vendored and binary copies are ignored
+func TestFindDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	block := "total := 0\nfor _, item := range items {\n\tif item.Skip {\n\t\tcontinue\n\t}\n\ttotal += item.Price * item.Quantity\n}\nreturn total\n"
+	// The copy is indented differently and has a blank line inside, which must not matter
+	copied := strings.ReplaceAll("\t"+strings.ReplaceAll(block, "\n", "\n\t"), "\n\t}\n", "\n\t}\n\n")
+	files := map[string]string{
+		"orders.go":      "package shop\n\nfunc orderTotal(items []Item) int {\n" + block + "}\n",
+		"cart/cart.go":   "package cart\n\n// cartTotal sums the cart\nfunc cartTotal(items []Item) int {\n" + copied + "}\n",
+		"vendor/lib.go":  "package lib\n\nfunc total(items []Item) int {\n" + block + "}\n",
+		"data/blob.go":   "\x00" + block,
+		"unique.go":      "package shop\n\nfunc a() {}\nfunc b() {}\nfunc c() {}\nfunc d() {}\nfunc e() {}\nfunc f() {}\n",
+		"notes/copy.txt": block,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	groups := findDuplicates(t, map[string]interface{}{})
+	if len(groups) != 1 {
+		t.Fatalf("Expected one duplicate group, got %+v", groups)
+	}
+	// The block plus the closing brace of both functions
+	group := groups[0]
+	if group.Lines != 9 || len(group.Hash) != 16 {
+		t.Errorf("Expected a 9 line group with a hash, got %+v", group)
+	}
+	want := []DuplicateLocation{
+		{File: "cart/cart.go", StartLine: 5, EndLine: 14},
+		{File: "orders.go", StartLine: 4, EndLine: 12},
+	}
+	if len(group.Occurrences) != len(want) {
+		t.Fatalf("Occurrences = %+v, want %+v", group.Occurrences, want)
+	}
+	for i, occ := range group.Occurrences {
+		if occ != want[i] {
+			t.Errorf("Occurrence %d = %+v, want %+v", i, occ, want[i])
+		}
+	}
+
+	if groups := findDuplicates(t, map[string]interface{}{"min_lines": float64(10)}); len(groups) != 0 {
+		t.Errorf("Expected no group longer than the copy, got %+v", groups)
+	}
+	if groups := findDuplicates(t, map[string]interface{}{"file_patterns": []interface{}{"orders.go"}}); len(groups) != 0 {
+		t.Errorf("Expected no duplicates within orders.go alone, got %+v", groups)
+	}
+}
+
+// summarizeFile calls toolSummarizeFile on content written to name and parses the result
+func summarizeFile(t *testing.T, name, content string, includeUnexported bool) (string, []string, []SymbolSummary, int) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := toolSummarizeFile(map[string]interface{}{"file_path": name, "include_unexported": includeUnexported})
+	if err != nil {
+		t.Fatalf("toolSummarizeFile() error = %v", err)
+	}
+	var decoded struct {
+		Package   string          `json:"package"`
+		Imports   []string        `json:"imports"`
+		Symbols   []SymbolSummary `json:"symbols"`
+		LineCount int             `json:"line_count"`
+	}
+	if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	return decoded.Package, decoded.Imports, decoded.Symbols, decoded.LineCount
+}
+
+// TestSummarizeFileGo tests the package, imports, signatures and the exported-vs-unexported
+// classification of Go symbols, including methods on unexported types
+func TestSummarizeFileGo(t *testing.T) {
+	src := `package store
+
+import (
+	"context"
+	db "database/sql"
+)
+
+// MaxRetries bounds retries
+const MaxRetries, minDelay = 3, 1
+
+var ErrClosed error
+
+// Store keeps records
+type Store[K comparable] struct {
+	conn *db.DB
+}
+
+type cache interface {
+	Get(key string) ([]byte, bool)
+}
+
+// Open opens a store
+func Open(ctx context.Context, dsn string) (*Store[string], error) {
+	return nil, nil
+}
+
+func (s *Store[K]) Close() error { return nil }
+
+func (s *Store[K]) reset() {}
+
+func (c *lru) Get(key string) ([]byte, bool) { return nil, false }
+
+type lru struct{}
+
+func helper() {}
+`
+	pkg, imports, exported, lines := summarizeFile(t, "store.go", src, false)
+	if pkg != "store" || lines != 35 || strings.Join(imports, ",") != "context,database/sql" {
+		t.Errorf("Expected package store, 35 lines and both imports, got %q, %d, %v", pkg, lines, imports)
+	}
+
+	want := []SymbolSummary{
+		{Name: "MaxRetries", Kind: "const", Exported: true, Line: 9, Signature: "const MaxRetries"},
+		{Name: "ErrClosed", Kind: "var", Exported: true, Line: 11, Signature: "var ErrClosed error"},
+		{Name: "Store", Kind: "type", Exported: true, Line: 14, Signature: "type Store[K comparable] struct"},
+		{Name: "Open", Kind: "func", Exported: true, Line: 23, Signature: "func Open(ctx context.Context, dsn string) (*Store[string], error)"},
+		{Name: "Close", Kind: "method", Receiver: "Store", Exported: true, Line: 27, Signature: "func (s *Store[K]) Close() error"},
+	}
+	if len(exported) != len(want) {
+		t.Fatalf("Expected %d exported symbols, got %+v", len(want), exported)
+	}
+	for i, sym := range exported {
+		if sym != want[i] {
+			t.Errorf("Symbol %d = %+v, want %+v", i, sym, want[i])
+		}
+	}
+
+	// An exported method on an unexported type cannot be named from other packages
+	_, _, all, _ := summarizeFile(t, "store.go", src, true)
+	unexported := map[string]bool{}
+	for _, sym := range all {
+		if !sym.Exported {
+			unexported[sym.Name] = true
+		}
+	}
+	for _, name := range []string{"minDelay", "cache", "reset", "Get", "lru", "helper"} {
+		if !unexported[name] {
+			t.Errorf("Expected %s to be listed as unexported, got %+v", name, all)
+		}
+	}
+	if len(all) != len(want)+6 {
+		t.Errorf("Expected %d symbols in all, got %d", len(want)+6, len(all))
+	}
+}
+
+// TestSummarizeFileHeuristic tests the line-based summary of a language that is not parsed
+func TestSummarizeFileHeuristic(t *testing.T) {
+	src := "import os\nfrom typing import List\n\n\nclass Loader:\n    def load(self):\n        pass\n\n\ndef _private():\n    pass\n\n\nasync def fetch(urls: List[str]) -> None:\n    pass\n"
+	_, imports, symbols, lines := summarizeFile(t, "loader.py", src, true)
+	if len(imports) != 2 || lines != 15 {
+		t.Errorf("Expected 2 imports and 15 lines, got %v and %d", imports, lines)
+	}
+	got := make([]string, 0, len(symbols))
+	for _, sym := range symbols {
+		got = append(got, fmt.Sprintf("%s:%s:%v", sym.Kind, sym.Name, sym.Exported))
+	}
+	if strings.Join(got, ",") != "class:Loader:true,function:_private:false,function:fetch:true" {
+		t.Errorf("Unexpected symbols %v", got)
+	}
+}
+
+// analyzeComplexity calls toolAnalyzeComplexity and parses the functions it returns
+func analyzeComplexity(t *testing.T, args map[string]interface{}) []FunctionComplexity {
+	t.Helper()
+	result, err := toolAnalyzeComplexity(args)
+	if err != nil {
+		t.Fatalf("toolAnalyzeComplexity(%v) error = %v", args, err)
+	}
+	var decoded struct {
+		Functions []FunctionComplexity `json:"functions"`
+	}
+	if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	return decoded.Functions
+}
+
+// TestAnalyzeComplexity tests the complexity of a straight-line method and of a branchy
+// function, the ordering and the min_complexity threshold
+func TestAnalyzeComplexity(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	files := map[string]string{
+		"counter.go": "package shop\n\ntype Counter struct{ n int }\n\nfunc (c *Counter) Inc() {\n\tc.n++\n}\n",
+		"classify.go": `package shop
+
+func classify(n int, flags []bool) string {
+	if n < 0 || n > 100 {
+		return "out"
+	}
+	for _, f := range flags {
+		if f && n > 10 {
+			return "flag"
+		}
+	}
+	switch {
+	case n == 0:
+		return "zero"
+	case n%2 == 0:
+		return "even"
+	default:
+	}
+	return "odd"
+}
+`,
+		"classify_test.go":  "package shop\n\nfunc helper(a, b bool) bool { return a && b }\n",
+		"vendor/lib/lib.go": "package lib\n\nfunc Lib() {}\n",
+		"broken.go":         "package shop\n\nfunc broken( {\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []FunctionComplexity{
+		{File: "classify.go", Function: "classify", Complexity: 8, StartLine: 3},
+		{File: "counter.go", Function: "Counter.Inc", Complexity: 1, StartLine: 5},
+	}
+	functions := analyzeComplexity(t, map[string]interface{}{})
+	if len(functions) != len(want) {
+		t.Fatalf("Functions = %+v, want %+v", functions, want)
+	}
+	for i, f := range functions {
+		if f != want[i] {
+			t.Errorf("Function %d = %+v, want %+v", i, f, want[i])
+		}
+	}
+
+	if functions := analyzeComplexity(t, map[string]interface{}{"min_complexity": float64(2)}); len(functions) != 1 || functions[0].Function != "classify" {
+		t.Errorf("Expected only classify at min_complexity 2, got %+v", functions)
+	}
+	if functions := analyzeComplexity(t, map[string]interface{}{"include_tests": true, "file_patterns": []interface{}{"*_test.go"}}); len(functions) != 1 || functions[0].Complexity != 2 {
+		t.Errorf("Expected helper with complexity 2, got %+v", functions)
+	}
+	if functions := analyzeComplexity(t, map[string]interface{}{"file_path": "counter.go"}); len(functions) != 1 || functions[0] != want[1] {
+		t.Errorf("Expected only Counter.Inc for counter.go, got %+v", functions)
+	}
+	if _, err := toolAnalyzeComplexity(map[string]interface{}{"file_path": "broken.go"}); err == nil {
+		t.Error("Expected an error for a file that does not parse")
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/code-aria/internal-mcp/internal/repowalk"
+)
+
+const (
+	defaultPreviewMaxChanges = 1000
+	maxPreviewMaxChanges     = 10000
+)
+
+// ReplaceChange is one line a preview_replace would rewrite
+type ReplaceChange struct {
+	Line   int    `json:"line"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// FileReplacePreview is the changes a preview_replace would make to one file
+type FileReplacePreview struct {
+	File    string          `json:"file"`
+	Changes []ReplaceChange `json:"changes"`
+}
+
+// toolPreviewReplace reports what replacing every match of the search regex with the
+// replace template would change, line by line, without writing anything. The template
+// expands capture groups as $1 or ${name}, like regexp.ReplaceAllString. Files are
+// selected by repowalk.Walk, as by mcp-code-edit's search_replace_files, so the same
+// file_patterns and exclude_patterns preview exactly the files it would edit. Unlike
+// search_replace_files, which matches against whole files, matching is per line, so a
+// pattern spanning lines never matches. Files are walked in lexical order and the preview
+// stops after max_changes lines.
+func toolPreviewReplace(args map[string]interface{}) (string, error) {
+	search, _ := args["search"].(string)
+	if search == "" {
+		return "", fmt.Errorf("search is required")
+	}
+	replace, ok := args["replace"].(string)
+	if !ok {
+		return "", fmt.Errorf("replace is required")
+	}
+
+	pattern, err := regexp.Compile(search)
+	if err != nil {
+		return "", fmt.Errorf("invalid regex pattern: %w", err)
+	}
+
+	filePatterns := stringSliceArg(args, "file_patterns")
+	excludes := stringSliceArg(args, "exclude_patterns")
+	if err := repowalk.ValidatePatterns(append(filePatterns, excludes...)); err != nil {
+		return "", err
+	}
+
+	maxChanges := defaultPreviewMaxChanges
+	if mc, ok := args["max_changes"].(float64); ok && mc > 0 {
+		maxChanges = int(mc)
+		if maxChanges > maxPreviewMaxChanges {
+			maxChanges = maxPreviewMaxChanges
+		}
+	}
+
+	repoPath := os.Getenv("REPO_PATH")
+	if repoPath == "" {
+		return "", fmt.Errorf("REPO_PATH not set")
+	}
+
+	files := []FileReplacePreview{}
+	linesChanged := 0
+	truncated := false
+	err = repowalk.Walk(repoPath, filePatterns, excludes, func(path, relPath string, d fs.DirEntry) error {
+		data, err := os.ReadFile(path)
+		if err != nil || isBinary(data) {
+			return nil
+		}
+
+		var changes []ReplaceChange
+		for i, line := range strings.Split(string(data), "\n") {
+			if !pattern.MatchString(line) {
+				continue
+			}
+			after := pattern.ReplaceAllString(line, replace)
+			if after == line {
+				continue
+			}
+			if linesChanged == maxChanges {
+				truncated = true
+				break
+			}
+			changes = append(changes, ReplaceChange{Line: i + 1, Before: line, After: after})
+			linesChanged++
+		}
+
+		if len(changes) > 0 {
+			files = append(files, FileReplacePreview{File: relPath, Changes: changes})
+		}
+		if truncated {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	result, err := json.Marshal(map[string]interface{}{
+		"search":        search,
+		"replace":       replace,
+		"files":         files,
+		"files_changed": len(files),
+		"lines_changed": linesChanged,
+		"truncated":     truncated,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal preview: %w", err)
+	}
+	return string(result), nil
+}
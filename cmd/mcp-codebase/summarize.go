@@ -0,0 +1,296 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SymbolSummary is a top-level declaration of a file with its signature
+type SymbolSummary struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Receiver  string `json:"receiver,omitempty"`
+	Exported  bool   `json:"exported"`
+	Line      int    `json:"line"`
+	Signature string `json:"signature"`
+}
+
+// summaryPattern recognizes a top-level declaration line; the last submatch is the name
+type summaryPattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+// heuristicSummary describes how summarize_file reads a language it cannot parse: the
+// package or module clause, import lines, declarations and which of them are exported
+type heuristicSummary struct {
+	pkg      *regexp.Regexp
+	imports  *regexp.Regexp
+	symbols  []summaryPattern
+	exported func(name, line string) bool
+}
+
+var (
+	jsImports = regexp.MustCompile(`^import\s|\brequire\(\s*['"]`)
+	jsSymbols = []summaryPattern{
+		{"function", regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:async\s+)?function\*?\s+([A-Za-z_$][\w$]*)`)},
+		{"class", regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?class\s+([A-Za-z_$][\w$]*)`)},
+		{"variable", regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?(?:const|let|var)\s+([A-Za-z_$][\w$]*)`)},
+	}
+	tsSymbols = append(append([]summaryPattern{}, jsSymbols...),
+		summaryPattern{"interface", regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?interface\s+([A-Za-z_$][\w$]*)`)},
+		summaryPattern{"type", regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?type\s+([A-Za-z_$][\w$]*)`)},
+		summaryPattern{"enum", regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?(?:const\s+)?enum\s+([A-Za-z_$][\w$]*)`)},
+	)
+	jsExported = func(_, line string) bool { return strings.HasPrefix(line, "export ") }
+)
+
+// heuristicSummaries are the non-Go languages summarize_file extracts declarations from.
+// Only unindented declarations count as top level.
+var heuristicSummaries = map[string]heuristicSummary{
+	"python": {
+		imports: regexp.MustCompile(`^(?:import|from)\s+\S`),
+		symbols: []summaryPattern{
+			{"function", regexp.MustCompile(`^(?:async\s+)?def\s+([A-Za-z_]\w*)`)},
+			{"class", regexp.MustCompile(`^class\s+([A-Za-z_]\w*)`)},
+		},
+		// A leading underscore marks a name as private by convention
+		exported: func(name, _ string) bool { return !strings.HasPrefix(name, "_") },
+	},
+	"javascript": {imports: jsImports, symbols: jsSymbols, exported: jsExported},
+	"typescript": {imports: jsImports, symbols: tsSymbols, exported: jsExported},
+	"rust": {
+		imports: regexp.MustCompile(`^(?:pub\s+)?use\s`),
+		symbols: []summaryPattern{
+			{"function", regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:const\s+)?(?:async\s+)?(?:unsafe\s+)?fn\s+(\w+)`)},
+			{"struct", regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?struct\s+(\w+)`)},
+			{"enum", regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?enum\s+(\w+)`)},
+			{"trait", regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:unsafe\s+)?trait\s+(\w+)`)},
+			{"type", regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?type\s+(\w+)`)},
+			{"const", regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:const|static)\s+(?:mut\s+)?(\w+)`)},
+			{"module", regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?mod\s+(\w+)`)},
+		},
+		// pub(crate) and pub(super) items are not part of the crate's public API
+		exported: func(_, line string) bool { return strings.HasPrefix(line, "pub ") },
+	},
+	"java": {
+		pkg:     regexp.MustCompile(`^package\s+([\w.]+)`),
+		imports: regexp.MustCompile(`^import\s`),
+		symbols: []summaryPattern{
+			{"class", regexp.MustCompile(`^(?:(?:public|protected|private|abstract|final|static|sealed|non-sealed|strictfp)\s+)*(?:class|interface|enum|record|@interface)\s+(\w+)`)},
+		},
+		exported: func(_, line string) bool {
+			return strings.HasPrefix(line, "public ") || strings.Contains(line, " public ")
+		},
+	},
+}
+
+// toolSummarizeFile gives a compact structural overview of a file: its package, imports,
+// exported top-level symbols with their signatures and its line count, so an agent can
+// decide whether to read the whole file. Go files are parsed with go/parser; Python,
+// JavaScript, TypeScript, Rust and Java are read line by line. With include_unexported
+// the unexported symbols are listed too.
+func toolSummarizeFile(args map[string]interface{}) (string, error) {
+	filePath, ok := args["file_path"].(string)
+	if !ok || filePath == "" {
+		return "", fmt.Errorf("file_path is required")
+	}
+	includeUnexported, _ := args["include_unexported"].(bool)
+
+	data, err := os.ReadFile(resolvePath(filePath))
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if isBinary(data) {
+		return "", fmt.Errorf("cannot summarize binary file: %s", filePath)
+	}
+
+	content := string(data)
+	lineCount := 0
+	if content != "" {
+		lineCount = strings.Count(strings.TrimSuffix(content, "\n"), "\n") + 1
+	}
+
+	language := languageForPath(filePath)
+	strategy := "none"
+	var pkg, parseError string
+	imports := []string{}
+	var symbols []SymbolSummary
+	if language == "go" {
+		strategy = "ast"
+		pkg, imports, symbols, err = summarizeGo(filePath, data)
+		if err != nil {
+			// The partial syntax tree is still summarized
+			parseError = err.Error()
+		}
+	} else if h, ok := heuristicSummaries[language]; ok {
+		strategy = "heuristic"
+		pkg, imports, symbols = summarizeHeuristic(content, h)
+	}
+
+	listed := []SymbolSummary{}
+	exportedCount := 0
+	for _, sym := range symbols {
+		if sym.Exported {
+			exportedCount++
+		}
+		if sym.Exported || includeUnexported {
+			listed = append(listed, sym)
+		}
+	}
+
+	result := map[string]interface{}{
+		"file_path":      filePath,
+		"language":       language,
+		"strategy":       strategy,
+		"imports":        imports,
+		"symbols":        listed,
+		"exported_count": exportedCount,
+		"symbol_count":   len(symbols),
+		"line_count":     lineCount,
+	}
+	if pkg != "" {
+		result["package"] = pkg
+	}
+	if parseError != "" {
+		result["parse_error"] = parseError
+	}
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal summary: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// summarizeGo returns the package name, import paths and top-level symbols of a Go file.
+// A method is exported only when both it and its receiver type are, since otherwise other
+// packages cannot name it. On a syntax error whatever was parsed is returned with the error.
+func summarizeGo(filePath string, src []byte) (string, []string, []SymbolSummary, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filePath, src, parser.SkipObjectResolution)
+	imports := []string{}
+	symbols := []SymbolSummary{}
+	if file == nil {
+		return "", imports, symbols, err
+	}
+
+	for _, spec := range file.Imports {
+		if path, uerr := strconv.Unquote(spec.Path.Value); uerr == nil {
+			imports = append(imports, path)
+		}
+	}
+
+	add := func(ident *ast.Ident, kind, receiver, signature string) {
+		if ident.Name == "_" {
+			return
+		}
+		exported := ast.IsExported(ident.Name) && (receiver == "" || ast.IsExported(receiver))
+		symbols = append(symbols, SymbolSummary{
+			Name:      ident.Name,
+			Kind:      kind,
+			Receiver:  receiver,
+			Exported:  exported,
+			Line:      fset.Position(ident.Pos()).Line,
+			Signature: signature,
+		})
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			// Print the declaration without its body or doc comment
+			header := *d
+			header.Body = nil
+			header.Doc = nil
+			signature := printGoNode(fset, &header)
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				add(d.Name, "method", receiverTypeName(d.Recv.List[0].Type), signature)
+			} else {
+				add(d.Name, "func", "", signature)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					add(s.Name, "type", "", "type "+goTypeSignature(fset, s))
+				case *ast.ValueSpec:
+					typ := ""
+					if s.Type != nil {
+						typ = " " + printGoNode(fset, s.Type)
+					}
+					for _, ident := range s.Names {
+						add(ident, d.Tok.String(), "", d.Tok.String()+" "+ident.Name+typ)
+					}
+				}
+			}
+		}
+	}
+	return file.Name.Name, imports, symbols, err
+}
+
+// goTypeSignature prints a type spec, abbreviating struct and interface bodies to the
+// keyword so the signature stays one line
+func goTypeSignature(fset *token.FileSet, s *ast.TypeSpec) string {
+	spec := *s
+	spec.Doc = nil
+	spec.Comment = nil
+	switch s.Type.(type) {
+	case *ast.StructType:
+		spec.Type = ast.NewIdent("struct")
+	case *ast.InterfaceType:
+		spec.Type = ast.NewIdent("interface")
+	}
+	return printGoNode(fset, &spec)
+}
+
+// printGoNode formats a syntax tree node as Go source, collapsed to one line
+func printGoNode(fset *token.FileSet, node interface{}) string {
+	var sb strings.Builder
+	if err := printer.Fprint(&sb, fset, node); err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// summarizeHeuristic returns the package, import lines and top-level declarations that h
+// recognizes in content
+func summarizeHeuristic(content string, h heuristicSummary) (string, []string, []SymbolSummary) {
+	pkg := ""
+	imports := []string{}
+	symbols := []SymbolSummary{}
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if h.pkg != nil && pkg == "" {
+			if m := h.pkg.FindStringSubmatch(line); m != nil {
+				pkg = m[1]
+				continue
+			}
+		}
+		if h.imports.MatchString(line) {
+			imports = append(imports, line)
+			continue
+		}
+		for _, p := range h.symbols {
+			if m := p.re.FindStringSubmatch(line); m != nil {
+				name := m[len(m)-1]
+				symbols = append(symbols, SymbolSummary{
+					Name:      name,
+					Kind:      p.kind,
+					Exported:  h.exported(name, line),
+					Line:      i + 1,
+					Signature: strings.TrimSpace(strings.TrimSuffix(line, "{")),
+				})
+				break
+			}
+		}
+	}
+	return pkg, imports, symbols
+}
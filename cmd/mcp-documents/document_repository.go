@@ -5,14 +5,30 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/code-aria/internal-mcp/internal/pgschema"
+	"github.com/code-aria/internal-mcp/internal/uuid"
+	"github.com/lib/pq"
 )
 
 // DocumentRepository defines the persistence operations used by the MCP handlers.
 type DocumentRepository interface {
 	GetDocuments(ctx context.Context, tenantID, categoryID *string, tags []string, isActive *bool, limit int) ([]map[string]interface{}, error)
+	ListPaged(ctx context.Context, opts DocumentListOptions) ([]map[string]interface{}, int, error)
+	ListDocumentsByTag(ctx context.Context, tags []string, match string, tenantID *string, limit, offset int) ([]map[string]interface{}, int, error)
+	CreateDocument(ctx context.Context, doc NewDocument) (map[string]interface{}, error)
+	UpsertDocument(ctx context.Context, slug string, doc NewDocument) (map[string]interface{}, error)
 	GetDocumentContent(ctx context.Context, documentIDs []string) ([]map[string]interface{}, error)
+	GetDocumentWithRelated(ctx context.Context, documentID string, limit int) (map[string]interface{}, []map[string]interface{}, error)
 	SearchDocuments(ctx context.Context, query string, tenantID *string, limit int) ([]map[string]interface{}, error)
+	UpdateDocument(ctx context.Context, documentID string, update DocumentUpdate) (map[string]interface{}, error)
+	GetDocumentHistory(ctx context.Context, documentID string, limit int) ([]map[string]interface{}, error)
+	RestoreDocumentVersion(ctx context.Context, documentID string, version int) (map[string]interface{}, error)
+	DeleteDocument(ctx context.Context, documentID string) (map[string]interface{}, error)
+	RestoreDocument(ctx context.Context, documentID string) (map[string]interface{}, error)
 }
 
 // SQLDocumentRepository is a Postgres-backed implementation of DocumentRepository.
@@ -25,6 +41,19 @@ func NewSQLDocumentRepository(db *sql.DB) *SQLDocumentRepository {
 	return &SQLDocumentRepository{db: db}
 }
 
+// documentSchema is what this server needs beyond the API's documents table; the
+// statements adding it are in migrations/mcp-documents
+var documentSchema = []pgschema.Requirement{
+	{Table: "documents", Columns: []string{"related_ids", "deleted_at", "slug"}},
+	{Table: "document_versions", Columns: []string{"document_id", "version", "name", "description", "content", "created_at"}},
+}
+
+// CheckSchema returns an error naming any table or column from documentSchema that is
+// missing. The documents table is managed by the API, so the schema is never changed here.
+func (r *SQLDocumentRepository) CheckSchema(ctx context.Context) error {
+	return pgschema.Check(ctx, r.db, "migrations/mcp-documents", documentSchema)
+}
+
 func (r *SQLDocumentRepository) GetDocuments(
 	ctx context.Context,
 	tenantID, categoryID *string,
@@ -32,49 +61,132 @@ func (r *SQLDocumentRepository) GetDocuments(
 	isActive *bool,
 	limit int,
 ) ([]map[string]interface{}, error) {
-	// Build query
-	query := `
-		SELECT id, name, description, content, category_id, tags, tenant_id, is_active, metadata, created_at, updated_at
-		FROM documents
-		WHERE 1=1`
+	documents, _, err := r.ListPaged(ctx, DocumentListOptions{
+		TenantID:   tenantID,
+		CategoryID: categoryID,
+		Tags:       tags,
+		IsActive:   isActive,
+		Limit:      limit,
+	})
+	return documents, err
+}
+
+// documentSortColumns is the allowlist of columns documents can be sorted by.
+// sort_by values are only ever interpolated into SQL after being checked against it.
+var documentSortColumns = map[string]bool{
+	"name":        true,
+	"created_at":  true,
+	"updated_at":  true,
+	"category_id": true,
+	"tenant_id":   true,
+}
+
+// normalizeSort validates sortBy against documentSortColumns and sortOrder against asc/desc,
+// applying the defaults (name, ASC) for empty values.
+func normalizeSort(sortBy, sortOrder string) (string, string, error) {
+	if sortBy == "" {
+		sortBy = "name"
+	}
+	if !documentSortColumns[sortBy] {
+		allowed := make([]string, 0, len(documentSortColumns))
+		for column := range documentSortColumns {
+			allowed = append(allowed, column)
+		}
+		sort.Strings(allowed)
+		return "", "", fmt.Errorf("invalid sort_by %q: must be one of %s", sortBy, strings.Join(allowed, ", "))
+	}
 
+	switch strings.ToUpper(sortOrder) {
+	case "", "ASC":
+		sortOrder = "ASC"
+	case "DESC":
+		sortOrder = "DESC"
+	default:
+		return "", "", fmt.Errorf("invalid sort_order %q: must be asc or desc", sortOrder)
+	}
+
+	return sortBy, sortOrder, nil
+}
+
+// buildDocumentFilter builds the WHERE clause and args shared by the page and count queries
+func buildDocumentFilter(opts DocumentListOptions) (string, []interface{}) {
+	where := " WHERE 1=1"
 	args := []interface{}{}
 	argIndex := 1
 
-	if tenantID != nil {
-		query += fmt.Sprintf(" AND tenant_id = $%d", argIndex)
-		args = append(args, *tenantID)
+	if !opts.IncludeDeleted {
+		where += " AND deleted_at IS NULL"
+	}
+
+	if len(opts.IDs) > 0 {
+		where += fmt.Sprintf(" AND id = ANY($%d)", argIndex)
+		args = append(args, pq.Array(opts.IDs))
 		argIndex++
 	}
 
-	if categoryID != nil {
-		query += fmt.Sprintf(" AND category_id = $%d", argIndex)
-		args = append(args, *categoryID)
+	if opts.TenantID != nil {
+		where += fmt.Sprintf(" AND tenant_id = $%d", argIndex)
+		args = append(args, *opts.TenantID)
 		argIndex++
 	}
 
-	if isActive != nil {
-		query += fmt.Sprintf(" AND is_active = $%d", argIndex)
-		args = append(args, *isActive)
+	if opts.CategoryID != nil {
+		where += fmt.Sprintf(" AND category_id = $%d", argIndex)
+		args = append(args, *opts.CategoryID)
 		argIndex++
 	}
 
-	// Add tag filtering if specified
-	if len(tags) > 0 {
-		for _, tag := range tags {
-			query += fmt.Sprintf(" AND tags @> $%d", argIndex)
-			args = append(args, []string{tag})
-			argIndex++
+	if opts.IsActive != nil {
+		where += fmt.Sprintf(" AND is_active = $%d", argIndex)
+		args = append(args, *opts.IsActive)
+		argIndex++
+	}
+
+	// Add tag filtering if specified; tags is a JSONB array of strings, so ?| matches
+	// documents with any of the tags and ?& documents with all of them
+	if len(opts.Tags) > 0 {
+		operator := "?&"
+		if opts.TagMatch == TagMatchAny {
+			operator = "?|"
 		}
+		where += fmt.Sprintf(" AND tags %s $%d", operator, argIndex)
+		args = append(args, pq.Array(opts.Tags))
+		argIndex++
 	}
 
-	query += fmt.Sprintf(" ORDER BY name ASC LIMIT $%d", argIndex)
-	args = append(args, limit)
+	return where, args
+}
+
+// ListPaged returns one page of documents matching the filters in opts, along with the
+// total number of matching documents across all pages.
+func (r *SQLDocumentRepository) ListPaged(
+	ctx context.Context,
+	opts DocumentListOptions,
+) ([]map[string]interface{}, int, error) {
+	sortBy, sortOrder, err := normalizeSort(opts.SortBy, opts.SortOrder)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	where, args := buildDocumentFilter(opts)
+
+	// Count all matching documents so callers can page through them
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM documents"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count documents: %w", err)
+	}
+
+	// Build query; id is a tie-breaker so page boundaries are stable
+	query := `
+		SELECT id, name, description, content, category_id, tags, tenant_id, is_active, metadata, created_at, updated_at, deleted_at
+		FROM documents` + where
+	query += fmt.Sprintf(" ORDER BY %s %s, id ASC LIMIT $%d OFFSET $%d", sortBy, sortOrder, len(args)+1, len(args)+2)
+	args = append(args, opts.Limit, opts.Offset)
 
 	// Execute query
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query documents: %w", err)
+		return nil, 0, fmt.Errorf("failed to query documents: %w", err)
 	}
 	defer rows.Close()
 
@@ -85,15 +197,15 @@ func (r *SQLDocumentRepository) GetDocuments(
 		var tagsJSON []byte
 		var metadataJSON []byte
 		var isActive bool
-		var createdAt, updatedAt sql.NullTime
+		var createdAt, updatedAt, deletedAt sql.NullTime
 
 		err := rows.Scan(
 			&id, &name, &description, &content,
 			&categoryID, &tagsJSON, &tenantID, &isActive,
-			&metadataJSON, &createdAt, &updatedAt,
+			&metadataJSON, &createdAt, &updatedAt, &deletedAt,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan document row: %w", err)
+			return nil, 0, fmt.Errorf("failed to scan document row: %w", err)
 		}
 
 		doc := map[string]interface{}{
@@ -145,10 +257,47 @@ func (r *SQLDocumentRepository) GetDocuments(
 			doc["updated_at"] = updatedAt.Time
 		}
 
+		if deletedAt.Valid {
+			doc["deleted_at"] = deletedAt.Time
+		}
+
 		documents = append(documents, doc)
 	}
 
-	return documents, nil
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read document rows: %w", err)
+	}
+
+	return documents, total, nil
+}
+
+// ListDocumentsByTag returns documents tagged with any or all of tags, depending on match.
+func (r *SQLDocumentRepository) ListDocumentsByTag(
+	ctx context.Context,
+	tags []string,
+	match string,
+	tenantID *string,
+	limit, offset int,
+) ([]map[string]interface{}, int, error) {
+	if len(tags) == 0 {
+		return nil, 0, fmt.Errorf("at least one tag is required")
+	}
+
+	switch match {
+	case "":
+		match = TagMatchAny
+	case TagMatchAny, TagMatchAll:
+	default:
+		return nil, 0, fmt.Errorf("invalid match %q: must be %s or %s", match, TagMatchAny, TagMatchAll)
+	}
+
+	return r.ListPaged(ctx, DocumentListOptions{
+		TenantID: tenantID,
+		Tags:     tags,
+		TagMatch: match,
+		Limit:    limit,
+		Offset:   offset,
+	})
 }
 
 func (r *SQLDocumentRepository) GetDocumentContent(
@@ -171,6 +320,7 @@ func (r *SQLDocumentRepository) GetDocumentContent(
 		SELECT id, name, description, content, category_id, tags, tenant_id, is_active, metadata, created_at, updated_at
 		FROM documents
 		WHERE id IN (%s)
+		  AND deleted_at IS NULL
 		ORDER BY name`, strings.Join(placeholders, ", "))
 
 	// Execute query
@@ -248,20 +398,82 @@ func (r *SQLDocumentRepository) GetDocumentContent(
 	return documents, nil
 }
 
-func (r *SQLDocumentRepository) SearchDocuments(
+// relatedDocumentsQuery selects the ids of documents related to the document $1: those in the
+// same tenant sharing at least one of its tags, and those listed in its related_ids column.
+const relatedDocumentsQuery = `
+	SELECT d.id
+	FROM documents src
+	JOIN documents d ON d.id <> src.id AND d.tenant_id IS NOT DISTINCT FROM src.tenant_id
+	WHERE src.id = $1
+	  AND (d.tags ?| ARRAY(SELECT jsonb_array_elements_text(COALESCE(src.tags, '[]'::jsonb)))
+	       OR COALESCE(src.related_ids, '[]'::jsonb) ? d.id)`
+
+// GetDocumentWithRelated returns a document with its full content, and up to limit related
+// documents (see relatedDocumentsQuery) with content previews, ordered by name.
+func (r *SQLDocumentRepository) GetDocumentWithRelated(
 	ctx context.Context,
-	query string,
-	tenantID *string,
+	documentID string,
 	limit int,
-) ([]map[string]interface{}, error) {
-	// Build search query
-	searchQuery := `
-		SELECT id, name, description, content, category_id, tags, tenant_id, is_active, metadata, created_at, updated_at
+) (map[string]interface{}, []map[string]interface{}, error) {
+	documents, err := r.GetDocumentContent(ctx, []string{documentID})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(documents) == 0 {
+		return nil, nil, fmt.Errorf("document not found: %s", documentID)
+	}
+
+	rows, err := r.db.QueryContext(ctx, relatedDocumentsQuery, documentID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query related documents: %w", err)
+	}
+	defer rows.Close()
+
+	var relatedIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan related document id: %w", err)
+		}
+		relatedIDs = append(relatedIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read related document ids: %w", err)
+	}
+
+	if len(relatedIDs) == 0 {
+		return documents[0], []map[string]interface{}{}, nil
+	}
+
+	related, _, err := r.ListPaged(ctx, DocumentListOptions{IDs: relatedIDs, Limit: limit})
+	if err != nil {
+		return nil, nil, err
+	}
+	return documents[0], related, nil
+}
+
+// searchTextConfig is the Postgres text search configuration used for full-text matching.
+const searchTextConfig = "english"
+
+// buildSearchQuery builds the parameterized full-text search query used by SearchDocuments.
+// Documents match when every word of the query appears in the name, description or content
+// (to_tsvector), or when the raw query appears verbatim (ILIKE) for partial-word matches.
+// LIKE wildcards in the query are escaped so that '%' and '_' match literally.
+func buildSearchQuery(query string, tenantID *string, limit int) (string, []interface{}) {
+	document := "coalesce(name, '') || ' ' || coalesce(description, '') || ' ' || coalesce(content, '')"
+
+	searchQuery := fmt.Sprintf(`
+		SELECT id, name, description, content, category_id, tags, tenant_id, is_active, metadata, created_at, updated_at,
+		       ts_headline('%[1]s', coalesce(content, ''), plainto_tsquery('%[1]s', $1),
+		                   'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2') AS snippet,
+		       ts_rank(to_tsvector('%[1]s', %[2]s), plainto_tsquery('%[1]s', $1)) AS rank
 		FROM documents
-		WHERE (name ILIKE $1 OR description ILIKE $1 OR content ILIKE $1)`
+		WHERE (to_tsvector('%[1]s', %[2]s) @@ plainto_tsquery('%[1]s', $1)
+		       OR name ILIKE $2 ESCAPE '\' OR description ILIKE $2 ESCAPE '\' OR content ILIKE $2 ESCAPE '\')
+		  AND deleted_at IS NULL`, searchTextConfig, document)
 
-	args := []interface{}{"%" + query + "%"}
-	argIndex := 2
+	args := []interface{}{query, "%" + escapeLike(query) + "%"}
+	argIndex := 3
 
 	if tenantID != nil {
 		searchQuery += fmt.Sprintf(" AND tenant_id = $%d", argIndex)
@@ -269,9 +481,26 @@ func (r *SQLDocumentRepository) SearchDocuments(
 		argIndex++
 	}
 
-	searchQuery += fmt.Sprintf(" ORDER BY name ASC LIMIT $%d", argIndex)
+	searchQuery += fmt.Sprintf(" ORDER BY rank DESC, name ASC LIMIT $%d", argIndex)
 	args = append(args, limit)
 
+	return searchQuery, args
+}
+
+// escapeLike escapes the LIKE wildcards '%' and '_' (and the escape character itself)
+// so that s matches literally in a LIKE/ILIKE pattern using ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (r *SQLDocumentRepository) SearchDocuments(
+	ctx context.Context,
+	query string,
+	tenantID *string,
+	limit int,
+) ([]map[string]interface{}, error) {
+	searchQuery, args := buildSearchQuery(query, tenantID, limit)
+
 	// Execute query
 	rows, err := r.db.QueryContext(ctx, searchQuery, args...)
 	if err != nil {
@@ -282,16 +511,18 @@ func (r *SQLDocumentRepository) SearchDocuments(
 	var documents []map[string]interface{}
 	for rows.Next() {
 		var id, name, description, content, tenantID sql.NullString
-		var categoryID sql.NullString
+		var categoryID, snippet sql.NullString
 		var tagsJSON []byte
 		var metadataJSON []byte
 		var isActive bool
 		var createdAt, updatedAt sql.NullTime
+		var rank sql.NullFloat64
 
 		err := rows.Scan(
 			&id, &name, &description, &content,
 			&categoryID, &tagsJSON, &tenantID, &isActive,
 			&metadataJSON, &createdAt, &updatedAt,
+			&snippet, &rank,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan document row: %w", err)
@@ -307,7 +538,6 @@ func (r *SQLDocumentRepository) SearchDocuments(
 			doc["description"] = description.String
 		}
 
-		// Highlight matches in content preview
 		if content.Valid {
 			contentPreview := content.String
 			if len(contentPreview) > 1000 {
@@ -316,6 +546,15 @@ func (r *SQLDocumentRepository) SearchDocuments(
 			doc["content_preview"] = contentPreview
 		}
 
+		// Snippet of the content with matched terms wrapped in <mark></mark>
+		if snippet.Valid && snippet.String != "" {
+			doc["snippet"] = snippet.String
+		}
+
+		if rank.Valid {
+			doc["rank"] = rank.Float64
+		}
+
 		if categoryID.Valid {
 			doc["category_id"] = categoryID.String
 		}
@@ -349,9 +588,439 @@ func (r *SQLDocumentRepository) SearchDocuments(
 		documents = append(documents, doc)
 	}
 
-	return documents, nil
+	return documents, rows.Err()
+}
+
+// CreateDocument inserts a new document and returns its generated id.
+func (r *SQLDocumentRepository) CreateDocument(
+	ctx context.Context,
+	doc NewDocument,
+) (map[string]interface{}, error) {
+	id, err := uuid.New()
+	if err != nil {
+		return nil, err
+	}
+
+	tagsJSON, err := marshalTags(doc.Tags)
+	if err != nil {
+		return nil, err
+	}
+	if tagsJSON == nil {
+		tagsJSON = []byte("[]")
+	}
+	relatedJSON, err := marshalStringList("related_ids", doc.RelatedIDs)
+	if err != nil {
+		return nil, err
+	}
+	if relatedJSON == nil {
+		relatedJSON = []byte("[]")
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		`INSERT INTO documents (id, name, description, content, category_id, tags, related_ids, tenant_id, is_active, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, true, NOW(), NOW())`,
+		id, doc.Name, doc.Description, doc.Content, doc.CategoryID, string(tagsJSON), string(relatedJSON), doc.TenantID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create document: %w", err)
+	}
+
+	return map[string]interface{}{
+		"id":          id,
+		"name":        doc.Name,
+		"tags":        doc.Tags,
+		"related_ids": doc.RelatedIDs,
+	}, nil
+}
+
+// UpsertDocument creates the document with the given slug, or updates it if one exists.
+// As with UpdateDocument, the content an update replaces is saved to document_versions
+// first. Description, content, category, tags and related ids are kept when doc leaves
+// them unset.
+// Slugs are unique across tenants, so a slug held by a document of another tenant, or of no
+// tenant when doc names one, is refused rather than overwritten; an update never moves a
+// document to another tenant.
+// A soft-deleted document keeps its slug, so upserting it is refused until it is restored.
+func (r *SQLDocumentRepository) UpsertDocument(
+	ctx context.Context,
+	slug string,
+	doc NewDocument,
+) (map[string]interface{}, error) {
+	id, err := uuid.New()
+	if err != nil {
+		return nil, err
+	}
+
+	tagsJSON, err := marshalTags(doc.Tags)
+	if err != nil {
+		return nil, err
+	}
+	var tags interface{}
+	if tagsJSON != nil {
+		tags = string(tagsJSON)
+	}
+	relatedJSON, err := marshalStringList("related_ids", doc.RelatedIDs)
+	if err != nil {
+		return nil, err
+	}
+	var relatedIDs interface{}
+	if relatedJSON != nil {
+		relatedIDs = string(relatedJSON)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var savedVersion int
+	var existingID string
+	var deleted, sameTenant bool
+	err = tx.QueryRowContext(ctx,
+		`SELECT id, deleted_at IS NOT NULL, tenant_id IS NOT DISTINCT FROM $2 FROM documents WHERE slug = $1 FOR UPDATE`,
+		slug, doc.TenantID,
+	).Scan(&existingID, &deleted, &sameTenant)
+	switch {
+	case err == nil && !sameTenant:
+		return nil, tenantSlugError(slug)
+	case err == nil && deleted:
+		return nil, deletedSlugError(slug, existingID)
+	case err == nil:
+		if savedVersion, err = saveDocumentVersionTx(ctx, tx, existingID); err != nil {
+			return nil, err
+		}
+	case err != sql.ErrNoRows:
+		return nil, fmt.Errorf("failed to load document: %w", err)
+	}
+
+	// xmax is 0 only for a row this statement inserted, so it tells an insert from an update
+	// even when another session created the slug after the lookup above
+	var created bool
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO documents (id, slug, name, description, content, category_id, tags, related_ids, tenant_id, is_active, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, COALESCE($7::jsonb, '[]'::jsonb), COALESCE($9::jsonb, '[]'::jsonb), $8, true, NOW(), NOW())
+		 ON CONFLICT (slug) DO UPDATE
+		 SET name = EXCLUDED.name,
+		     description = COALESCE(EXCLUDED.description, documents.description),
+		     content = COALESCE(EXCLUDED.content, documents.content),
+		     category_id = COALESCE(EXCLUDED.category_id, documents.category_id),
+		     tags = COALESCE($7::jsonb, documents.tags),
+		     related_ids = COALESCE($9::jsonb, documents.related_ids),
+		     updated_at = NOW()
+		 WHERE documents.deleted_at IS NULL
+		   AND documents.tenant_id IS NOT DISTINCT FROM EXCLUDED.tenant_id
+		 RETURNING id, xmax = 0`,
+		id, slug, doc.Name, doc.Description, doc.Content, doc.CategoryID, tags, doc.TenantID, relatedIDs,
+	).Scan(&id, &created)
+	if err == sql.ErrNoRows {
+		// Another session created or soft-deleted the slug after the lookup above
+		return nil, fmt.Errorf("document with slug %q belongs to another tenant or is deleted", slug)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert document: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit document upsert: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"id":     id,
+		"slug":   slug,
+		"name":   doc.Name,
+		"action": "created",
+	}
+	if !created {
+		result["action"] = "updated"
+		if savedVersion > 0 {
+			result["saved_version"] = savedVersion
+		}
+	}
+	return result, nil
+}
+
+// deletedSlugError reports an upsert onto a soft-deleted document
+func deletedSlugError(slug, id string) error {
+	if id == "" {
+		return fmt.Errorf("document with slug %q is deleted; restore it with restore_document before upserting", slug)
+	}
+	return fmt.Errorf("document %s with slug %q is deleted; restore it with restore_document before upserting", id, slug)
+}
+
+// tenantSlugError reports an upsert onto a slug held by a document of another tenant
+func tenantSlugError(slug string) error {
+	return fmt.Errorf("slug %q is used by a document of another tenant", slug)
+}
+
+// marshalTags encodes tags for the JSONB tags column, returning nil when tags is nil
+// so that COALESCE in updates keeps the existing value.
+func marshalTags(tags []string) ([]byte, error) {
+	return marshalStringList("tags", tags)
+}
+
+// marshalStringList encodes values for the JSONB array column named field, returning nil
+// when values is nil so that COALESCE in updates keeps the existing value.
+func marshalStringList(field string, values []string) ([]byte, error) {
+	if values == nil {
+		return nil, nil
+	}
+	valuesJSON, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s: %w", field, err)
+	}
+	return valuesJSON, nil
+}
+
+// UpdateDocument applies update to a document, first saving its current name, description
+// and content as a new row in document_versions so the change can be rolled back.
+func (r *SQLDocumentRepository) UpdateDocument(
+	ctx context.Context,
+	documentID string,
+	update DocumentUpdate,
+) (map[string]interface{}, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	version, err := updateDocumentTx(ctx, tx, documentID, update)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit document update: %w", err)
+	}
+
+	return map[string]interface{}{
+		"id":            documentID,
+		"saved_version": version,
+	}, nil
+}
+
+// updateDocumentTx snapshots the current document into document_versions and applies update.
+// It returns the version number the previous content was saved under.
+func updateDocumentTx(ctx context.Context, tx *sql.Tx, documentID string, update DocumentUpdate) (int, error) {
+	version, err := saveDocumentVersionTx(ctx, tx, documentID)
+	if err != nil {
+		return 0, err
+	}
+
+	var tags interface{}
+	if update.Tags != nil {
+		tagsJSON, err := marshalTags(*update.Tags)
+		if err != nil {
+			return 0, err
+		}
+		tags = string(tagsJSON)
+	}
+	var relatedIDs interface{}
+	if update.RelatedIDs != nil {
+		relatedJSON, err := marshalStringList("related_ids", *update.RelatedIDs)
+		if err != nil {
+			return 0, err
+		}
+		relatedIDs = string(relatedJSON)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE documents
+		 SET name = COALESCE($2, name),
+		     description = COALESCE($3, description),
+		     content = COALESCE($4, content),
+		     tags = COALESCE($5::jsonb, tags),
+		     related_ids = COALESCE($6::jsonb, related_ids),
+		     updated_at = NOW()
+		 WHERE id = $1`,
+		documentID, update.Name, update.Description, update.Content, tags, relatedIDs,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update document: %w", err)
+	}
+
+	return version, nil
+}
+
+// saveDocumentVersionTx locks a document and saves its current name, description and content
+// as a new row in document_versions, returning the new version number. Soft-deleted documents
+// are not found, so they cannot be edited until restored.
+func saveDocumentVersionTx(ctx context.Context, tx *sql.Tx, documentID string) (int, error) {
+	var name, description, content sql.NullString
+	err := tx.QueryRowContext(ctx,
+		`SELECT name, description, content FROM documents WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`,
+		documentID,
+	).Scan(&name, &description, &content)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("document not found: %s", documentID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to load document: %w", err)
+	}
+
+	var version int
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO document_versions (document_id, version, name, description, content)
+		 SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4
+		 FROM document_versions WHERE document_id = $1
+		 RETURNING version`,
+		documentID, name, description, content,
+	).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save document version: %w", err)
+	}
+
+	return version, nil
+}
+
+// GetDocumentHistory returns the saved versions of a document, newest first.
+func (r *SQLDocumentRepository) GetDocumentHistory(
+	ctx context.Context,
+	documentID string,
+	limit int,
+) ([]map[string]interface{}, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT version, name, description, content, created_at
+		FROM document_versions
+		WHERE document_id = $1
+		ORDER BY version DESC
+		LIMIT $2`, documentID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query document history: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []map[string]interface{}
+	for rows.Next() {
+		var version int
+		var name, description, content sql.NullString
+		var createdAt time.Time
+
+		if err := rows.Scan(&version, &name, &description, &content, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan document version row: %w", err)
+		}
+
+		entry := map[string]interface{}{
+			"document_id": documentID,
+			"version":     version,
+			"created_at":  createdAt,
+		}
+
+		if name.Valid {
+			entry["name"] = name.String
+		}
+
+		if description.Valid {
+			entry["description"] = description.String
+		}
+
+		if content.Valid {
+			entry["content"] = content.String
+		}
+
+		versions = append(versions, entry)
+	}
+
+	return versions, rows.Err()
+}
+
+// RestoreDocumentVersion replaces a document's name, description and content with a saved
+// version. The content being replaced is itself saved as a new version, so a restore can be undone.
+func (r *SQLDocumentRepository) RestoreDocumentVersion(
+	ctx context.Context,
+	documentID string,
+	version int,
+) (map[string]interface{}, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var name, description, content sql.NullString
+	err = tx.QueryRowContext(ctx,
+		`SELECT name, description, content FROM document_versions WHERE document_id = $1 AND version = $2`,
+		documentID, version,
+	).Scan(&name, &description, &content)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("version %d not found for document %s", version, documentID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load document version: %w", err)
+	}
+
+	update := DocumentUpdate{}
+	if name.Valid {
+		update.Name = &name.String
+	}
+	if description.Valid {
+		update.Description = &description.String
+	}
+	if content.Valid {
+		update.Content = &content.String
+	}
+
+	savedVersion, err := updateDocumentTx(ctx, tx, documentID, update)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit document restore: %w", err)
+	}
+
+	return map[string]interface{}{
+		"id":               documentID,
+		"restored_version": version,
+		"saved_version":    savedVersion,
+	}, nil
 }
 
+// DeleteDocument soft-deletes a document by setting its deleted_at timestamp. Soft-deleted
+// documents are hidden from listings and search until restored with RestoreDocument.
+func (r *SQLDocumentRepository) DeleteDocument(
+	ctx context.Context,
+	documentID string,
+) (map[string]interface{}, error) {
+	var deletedAt time.Time
+	err := r.db.QueryRowContext(ctx,
+		`UPDATE documents SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL RETURNING deleted_at`,
+		documentID,
+	).Scan(&deletedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("document not found or already deleted: %s", documentID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete document: %w", err)
+	}
 
+	return map[string]interface{}{
+		"id":         documentID,
+		"deleted_at": deletedAt,
+	}, nil
+}
 
+// RestoreDocument clears the deleted_at timestamp of a soft-deleted document.
+func (r *SQLDocumentRepository) RestoreDocument(
+	ctx context.Context,
+	documentID string,
+) (map[string]interface{}, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE documents SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`,
+		documentID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore document: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to restore document: %w", err)
+	} else if n == 0 {
+		return nil, fmt.Errorf("document not found or not deleted: %s", documentID)
+	}
 
+	return map[string]interface{}{
+		"id":       documentID,
+		"restored": true,
+	}, nil
+}
@@ -0,0 +1,754 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+// setupTempDocumentsTable creates session-local documents and document_versions tables that shadow the real ones.
+// The pool is pinned to a single connection so every query sees the same temporary table.
+func setupTempDocumentsTable(t *testing.T, db *sql.DB) {
+	t.Helper()
+
+	db.SetMaxOpenConns(1)
+
+	_, err := db.Exec(`
+		CREATE TEMP TABLE documents (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			description TEXT,
+			content TEXT,
+			category_id TEXT,
+			tags JSONB,
+			tenant_id TEXT,
+			is_active BOOLEAN NOT NULL DEFAULT true,
+			metadata JSONB,
+			related_ids JSONB NOT NULL DEFAULT '[]',
+			deleted_at TIMESTAMPTZ,
+			slug TEXT UNIQUE,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW()
+		)`)
+	if err != nil {
+		t.Fatalf("Failed to create temp documents table: %v", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TEMP TABLE document_versions (
+			id BIGSERIAL PRIMARY KEY,
+			document_id TEXT NOT NULL,
+			version INTEGER NOT NULL,
+			name TEXT,
+			description TEXT,
+			content TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			UNIQUE (document_id, version)
+		)`)
+	if err != nil {
+		t.Fatalf("Failed to create temp document_versions table: %v", err)
+	}
+}
+
+// insertTestDocument inserts a document into the temp documents table
+func insertTestDocument(t *testing.T, db *sql.DB, id, name, content string) {
+	t.Helper()
+
+	_, err := db.Exec(`INSERT INTO documents (id, name, content) VALUES ($1, $2, $3)`, id, name, content)
+	if err != nil {
+		t.Fatalf("Failed to insert document %s: %v", id, err)
+	}
+}
+
+// TestBuildSearchQuery verifies the search query is parameterized for multi-word input
+func TestBuildSearchQuery(t *testing.T) {
+	tenantID := "tenant-1"
+	query := "deploy pipeline'; DROP TABLE documents; --"
+
+	sqlQuery, args := buildSearchQuery(query, &tenantID, 10)
+
+	if strings.Contains(sqlQuery, "DROP TABLE") {
+		t.Fatal("Search text must not be interpolated into the SQL query")
+	}
+
+	for _, want := range []string{"plainto_tsquery('english', $1)", "ILIKE $2", "tenant_id = $3", "LIMIT $4", "ts_headline"} {
+		if !strings.Contains(sqlQuery, want) {
+			t.Errorf("Expected query to contain %q", want)
+		}
+	}
+
+	if len(args) != 4 {
+		t.Fatalf("Expected 4 args, got %d", len(args))
+	}
+	if args[0] != query {
+		t.Errorf("Expected first arg to be the raw query, got %v", args[0])
+	}
+	if args[1] != "%"+escapeLike(query)+"%" {
+		t.Errorf("Expected second arg to be the ILIKE pattern, got %v", args[1])
+	}
+	if args[2] != tenantID {
+		t.Errorf("Expected third arg to be the tenant ID, got %v", args[2])
+	}
+	if args[3] != 10 {
+		t.Errorf("Expected fourth arg to be the limit, got %v", args[3])
+	}
+}
+
+// TestBuildSearchQueryEscapesLikeWildcards verifies '%' and '_' in the query match literally
+func TestBuildSearchQueryEscapesLikeWildcards(t *testing.T) {
+	sqlQuery, args := buildSearchQuery(`100%_done\`, nil, 5)
+
+	if !strings.Contains(sqlQuery, `ILIKE $2 ESCAPE '\'`) {
+		t.Errorf("Expected ILIKE to declare an escape character, got %s", sqlQuery)
+	}
+	if want := `%100\%\_done\\%`; args[1] != want {
+		t.Errorf("Expected ILIKE pattern %q, got %v", want, args[1])
+	}
+}
+
+// TestSearchDocumentsMultiWord tests full-text search with a multi-word query against a temp table
+func TestSearchDocumentsMultiWord(t *testing.T) {
+	db := setupTestServer(t)
+	if db == nil {
+		return // Skipped
+	}
+	defer db.Close()
+
+	setupTempDocumentsTable(t, db)
+	insertTestDocument(t, db, "doc-1", "Release guide", "The deployment pipeline runs after every merge to main.")
+	insertTestDocument(t, db, "doc-2", "Onboarding", "Set up your laptop and request pipeline access.")
+	insertTestDocument(t, db, "doc-3", "Runbook", "Restart the deployment if the health check fails.")
+
+	repo := NewSQLDocumentRepository(db)
+	documents, err := repo.SearchDocuments(context.Background(), "deployment pipeline", nil, 10)
+	if err != nil {
+		t.Fatalf("SearchDocuments() error = %v", err)
+	}
+
+	if len(documents) != 1 {
+		t.Fatalf("Expected 1 document matching all words, got %d", len(documents))
+	}
+
+	doc := documents[0]
+	if doc["id"] != "doc-1" {
+		t.Errorf("Expected doc-1, got %v", doc["id"])
+	}
+	if doc["name"] != "Release guide" {
+		t.Errorf("Expected name 'Release guide', got %v", doc["name"])
+	}
+
+	snippet, ok := doc["snippet"].(string)
+	if !ok {
+		t.Fatal("Result missing 'snippet' field")
+	}
+	if !strings.Contains(snippet, "<mark>deployment</mark>") || !strings.Contains(snippet, "<mark>pipeline</mark>") {
+		t.Errorf("Expected snippet to highlight both words, got %q", snippet)
+	}
+}
+
+// TestNormalizeSort tests that sort_by is checked against the column allowlist
+func TestNormalizeSort(t *testing.T) {
+	tests := []struct {
+		name      string
+		sortBy    string
+		sortOrder string
+		wantBy    string
+		wantOrder string
+		wantErr   bool
+	}{
+		{name: "Defaults", wantBy: "name", wantOrder: "ASC"},
+		{name: "Created desc", sortBy: "created_at", sortOrder: "desc", wantBy: "created_at", wantOrder: "DESC"},
+		{name: "Mixed case order", sortBy: "updated_at", sortOrder: "Asc", wantBy: "updated_at", wantOrder: "ASC"},
+		{name: "Unknown column", sortBy: "content", wantErr: true},
+		{name: "Injection attempt", sortBy: "name; DROP TABLE documents", wantErr: true},
+		{name: "Invalid order", sortBy: "name", sortOrder: "sideways", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotBy, gotOrder, err := normalizeSort(tt.sortBy, tt.sortOrder)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("normalizeSort() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if gotBy != tt.wantBy || gotOrder != tt.wantOrder {
+				t.Errorf("normalizeSort() = %s %s, want %s %s", gotBy, gotOrder, tt.wantBy, tt.wantOrder)
+			}
+		})
+	}
+}
+
+// TestListPaged tests ordering and page boundaries against a temp table
+func TestListPaged(t *testing.T) {
+	db := setupTestServer(t)
+	if db == nil {
+		return // Skipped
+	}
+	defer db.Close()
+
+	setupTempDocumentsTable(t, db)
+	for _, name := range []string{"Echo", "Alpha", "Delta", "Charlie", "Bravo"} {
+		insertTestDocument(t, db, "doc-"+strings.ToLower(name), name, "content for "+name)
+	}
+
+	repo := NewSQLDocumentRepository(db)
+	ctx := context.Background()
+
+	names := func(docs []map[string]interface{}) []string {
+		var result []string
+		for _, doc := range docs {
+			result = append(result, doc["name"].(string))
+		}
+		return result
+	}
+
+	tests := []struct {
+		name  string
+		opts  DocumentListOptions
+		want  []string
+		total int
+	}{
+		{
+			name:  "First page ascending",
+			opts:  DocumentListOptions{Limit: 2, Offset: 0},
+			want:  []string{"Alpha", "Bravo"},
+			total: 5,
+		},
+		{
+			name:  "Second page ascending",
+			opts:  DocumentListOptions{Limit: 2, Offset: 2},
+			want:  []string{"Charlie", "Delta"},
+			total: 5,
+		},
+		{
+			name:  "Last partial page",
+			opts:  DocumentListOptions{Limit: 2, Offset: 4},
+			want:  []string{"Echo"},
+			total: 5,
+		},
+		{
+			name:  "Past the end",
+			opts:  DocumentListOptions{Limit: 2, Offset: 10},
+			want:  nil,
+			total: 5,
+		},
+		{
+			name:  "Descending",
+			opts:  DocumentListOptions{Limit: 3, SortBy: "name", SortOrder: "desc"},
+			want:  []string{"Echo", "Delta", "Charlie"},
+			total: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, total, err := repo.ListPaged(ctx, tt.opts)
+			if err != nil {
+				t.Fatalf("ListPaged() error = %v", err)
+			}
+			if total != tt.total {
+				t.Errorf("Expected total %d, got %d", tt.total, total)
+			}
+			got := names(docs)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if _, _, err := repo.ListPaged(ctx, DocumentListOptions{Limit: 2, SortBy: "content"}); err == nil {
+		t.Error("Expected error for sort_by outside the allowlist")
+	}
+}
+
+// getTestDocumentContent reads a document's content straight from the table
+func getTestDocumentContent(t *testing.T, db *sql.DB, id string) string {
+	t.Helper()
+
+	var content string
+	if err := db.QueryRow(`SELECT content FROM documents WHERE id = $1`, id).Scan(&content); err != nil {
+		t.Fatalf("Failed to read document %s: %v", id, err)
+	}
+	return content
+}
+
+// TestDocumentVersioning tests that updates create versions and that versions can be restored
+func TestDocumentVersioning(t *testing.T) {
+	db := setupTestServer(t)
+	if db == nil {
+		return // Skipped
+	}
+	defer db.Close()
+
+	setupTempDocumentsTable(t, db)
+	insertTestDocument(t, db, "doc-1", "Guide", "original content")
+
+	repo := NewSQLDocumentRepository(db)
+	ctx := context.Background()
+
+	newContent := "edited content"
+	result, err := repo.UpdateDocument(ctx, "doc-1", DocumentUpdate{Content: &newContent})
+	if err != nil {
+		t.Fatalf("UpdateDocument() error = %v", err)
+	}
+	if result["saved_version"] != 1 {
+		t.Errorf("Expected saved_version 1, got %v", result["saved_version"])
+	}
+	if got := getTestDocumentContent(t, db, "doc-1"); got != newContent {
+		t.Errorf("Expected content %q after update, got %q", newContent, got)
+	}
+
+	history, err := repo.GetDocumentHistory(ctx, "doc-1", 10)
+	if err != nil {
+		t.Fatalf("GetDocumentHistory() error = %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("Expected 1 version, got %d", len(history))
+	}
+	if history[0]["content"] != "original content" {
+		t.Errorf("Expected version to hold the original content, got %v", history[0]["content"])
+	}
+	if _, ok := history[0]["created_at"]; !ok {
+		t.Error("Version missing 'created_at' field")
+	}
+
+	result, err = repo.RestoreDocumentVersion(ctx, "doc-1", 1)
+	if err != nil {
+		t.Fatalf("RestoreDocumentVersion() error = %v", err)
+	}
+	if result["saved_version"] != 2 {
+		t.Errorf("Expected restore to save version 2, got %v", result["saved_version"])
+	}
+	if got := getTestDocumentContent(t, db, "doc-1"); got != "original content" {
+		t.Errorf("Expected original content after restore, got %q", got)
+	}
+
+	history, err = repo.GetDocumentHistory(ctx, "doc-1", 10)
+	if err != nil {
+		t.Fatalf("GetDocumentHistory() error = %v", err)
+	}
+	if len(history) != 2 || history[0]["content"] != newContent {
+		t.Errorf("Expected newest version to hold the replaced content, got %v", history)
+	}
+
+	if _, err := repo.RestoreDocumentVersion(ctx, "doc-1", 99); err == nil {
+		t.Error("Expected error restoring a missing version")
+	}
+	if _, err := repo.UpdateDocument(ctx, "missing", DocumentUpdate{Content: &newContent}); err == nil {
+		t.Error("Expected error updating a missing document")
+	}
+}
+
+// TestUpsertDocument tests that upserting a slug creates the document once and then updates it
+func TestUpsertDocument(t *testing.T) {
+	db := setupTestServer(t)
+	if db == nil {
+		return // Skipped
+	}
+	defer db.Close()
+
+	setupTempDocumentsTable(t, db)
+
+	repo := NewSQLDocumentRepository(db)
+	ctx := context.Background()
+
+	description := "How to deploy"
+	content := "first draft"
+	created, err := repo.UpsertDocument(ctx, "deploy-guide", NewDocument{
+		Name:        "Deploy Guide",
+		Description: &description,
+		Content:     &content,
+		Tags:        []string{"ops"},
+	})
+	if err != nil {
+		t.Fatalf("UpsertDocument() insert error = %v", err)
+	}
+	if created["action"] != "created" {
+		t.Errorf("Expected action created, got %v", created["action"])
+	}
+	id := created["id"].(string)
+	if got := getTestDocumentContent(t, db, id); got != content {
+		t.Errorf("Expected content %q after insert, got %q", content, got)
+	}
+
+	newContent := "second draft"
+	updated, err := repo.UpsertDocument(ctx, "deploy-guide", NewDocument{Name: "Deploy Guide v2", Content: &newContent})
+	if err != nil {
+		t.Fatalf("UpsertDocument() update error = %v", err)
+	}
+	if updated["action"] != "updated" || updated["id"] != id {
+		t.Errorf("Expected document %s to be updated, got %v", id, updated)
+	}
+	if updated["saved_version"] != 1 {
+		t.Errorf("Expected the replaced content to be saved as version 1, got %v", updated["saved_version"])
+	}
+
+	var count int
+	var name, gotDescription, tags string
+	if err := db.QueryRow(`SELECT COUNT(*) FROM documents`).Scan(&count); err != nil || count != 1 {
+		t.Errorf("Expected one document after two upserts, got %d (err %v)", count, err)
+	}
+	err = db.QueryRow(`SELECT name, description, tags::text FROM documents WHERE id = $1`, id).Scan(&name, &gotDescription, &tags)
+	if err != nil {
+		t.Fatalf("Failed to read document: %v", err)
+	}
+	if name != "Deploy Guide v2" || getTestDocumentContent(t, db, id) != newContent {
+		t.Errorf("Expected name and content to be updated, got %q", name)
+	}
+	if gotDescription != description || tags != `["ops"]` {
+		t.Errorf("Expected unset description and tags to be kept, got %q and %s", gotDescription, tags)
+	}
+
+	history, err := repo.GetDocumentHistory(ctx, id, 10)
+	if err != nil {
+		t.Fatalf("GetDocumentHistory() error = %v", err)
+	}
+	if len(history) != 1 || history[0]["content"] != content {
+		t.Errorf("Expected the first draft in the history, got %v", history)
+	}
+
+	// A soft-deleted document still holds its slug and is not revived by an upsert
+	if _, err := repo.DeleteDocument(ctx, id); err != nil {
+		t.Fatalf("DeleteDocument() error = %v", err)
+	}
+	revived := "third draft"
+	if _, err := repo.UpsertDocument(ctx, "deploy-guide", NewDocument{Name: "Deploy Guide v3", Content: &revived}); err == nil || !strings.Contains(err.Error(), "restore_document") {
+		t.Errorf("Expected upserting a deleted slug to be refused, got %v", err)
+	}
+	if got := getTestDocumentContent(t, db, id); got != newContent {
+		t.Errorf("Expected the deleted document to be unchanged, got %q", got)
+	}
+	if _, err := repo.RestoreDocument(ctx, id); err != nil {
+		t.Fatalf("RestoreDocument() error = %v", err)
+	}
+	if updated, err := repo.UpsertDocument(ctx, "deploy-guide", NewDocument{Name: "Deploy Guide v3", Content: &revived}); err != nil || updated["action"] != "updated" {
+		t.Errorf("Expected the restored document to be updated, got %v (err %v)", updated, err)
+	}
+}
+
+// TestUpsertDocumentOtherTenant tests that a slug held by another tenant's document is refused
+// and the document is left unchanged
+func TestUpsertDocumentOtherTenant(t *testing.T) {
+	db := setupTestServer(t)
+	if db == nil {
+		return // Skipped
+	}
+	defer db.Close()
+
+	setupTempDocumentsTable(t, db)
+
+	repo := NewSQLDocumentRepository(db)
+	ctx := context.Background()
+
+	tenantA, tenantB := "tenant-a", "tenant-b"
+	content := "tenant b runbook"
+	created, err := repo.UpsertDocument(ctx, "runbook", NewDocument{Name: "Runbook", Content: &content, TenantID: &tenantB})
+	if err != nil {
+		t.Fatalf("UpsertDocument() insert error = %v", err)
+	}
+	id := created["id"].(string)
+
+	overwrite := "tenant a runbook"
+	for _, tenantID := range []*string{&tenantA, nil} {
+		if _, err := repo.UpsertDocument(ctx, "runbook", NewDocument{Name: "Hijacked", Content: &overwrite, TenantID: tenantID}); err == nil || !strings.Contains(err.Error(), "another tenant") {
+			t.Errorf("Expected upserting another tenant's slug to be refused, got %v", err)
+		}
+	}
+
+	var name, gotTenant string
+	if err := db.QueryRow(`SELECT name, tenant_id FROM documents WHERE id = $1`, id).Scan(&name, &gotTenant); err != nil {
+		t.Fatalf("Failed to read document: %v", err)
+	}
+	if name != "Runbook" || gotTenant != tenantB || getTestDocumentContent(t, db, id) != content {
+		t.Errorf("Expected tenant b's document to be unchanged, got name %q and tenant %q", name, gotTenant)
+	}
+
+	if updated, err := repo.UpsertDocument(ctx, "runbook", NewDocument{Name: "Runbook v2", TenantID: &tenantB}); err != nil || updated["action"] != "updated" {
+		t.Errorf("Expected the owning tenant to update its document, got %v (err %v)", updated, err)
+	}
+}
+
+// TestBuildDocumentFilterTags tests that tag filters are parameterized with the right operator
+func TestBuildDocumentFilterTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		match    string
+		operator string
+	}{
+		{name: "Any", match: TagMatchAny, operator: "tags ?| $1"},
+		{name: "All", match: TagMatchAll, operator: "tags ?& $1"},
+		{name: "Default is all", match: "", operator: "tags ?& $1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args := buildDocumentFilter(DocumentListOptions{
+				Tags:     []string{"go", "x' OR '1'='1"},
+				TagMatch: tt.match,
+			})
+			if !strings.Contains(where, tt.operator) {
+				t.Errorf("Expected %q in %q", tt.operator, where)
+			}
+			if strings.Contains(where, "OR '1'") {
+				t.Error("Tag values must not be interpolated into the SQL query")
+			}
+			if len(args) != 1 {
+				t.Errorf("Expected tags to be passed as a single array arg, got %d args", len(args))
+			}
+		})
+	}
+}
+
+// TestParseTags tests tag argument parsing
+func TestParseTags(t *testing.T) {
+	tags, err := parseTags([]interface{}{"go", "backend"})
+	if err != nil {
+		t.Fatalf("parseTags() error = %v", err)
+	}
+	if strings.Join(tags, ",") != "go,backend" {
+		t.Errorf("Expected [go backend], got %v", tags)
+	}
+
+	if _, err := parseTags("go"); err == nil {
+		t.Error("Expected error for non-array tags")
+	}
+	if _, err := parseTags([]interface{}{"go", 1.0}); err == nil {
+		t.Error("Expected error for non-string tag")
+	}
+}
+
+// TestDocumentTags tests creating tagged documents and listing them by tag
+func TestDocumentTags(t *testing.T) {
+	db := setupTestServer(t)
+	if db == nil {
+		return // Skipped
+	}
+	defer db.Close()
+
+	setupTempDocumentsTable(t, db)
+
+	repo := NewSQLDocumentRepository(db)
+	ctx := context.Background()
+
+	ids := map[string]string{}
+	for name, tags := range map[string][]string{
+		"Go style":      {"go", "style"},
+		"Go testing":    {"go", "testing"},
+		"Python style":  {"python", "style"},
+		"Untagged note": nil,
+	} {
+		result, err := repo.CreateDocument(ctx, NewDocument{Name: name, Tags: tags})
+		if err != nil {
+			t.Fatalf("CreateDocument(%s) error = %v", name, err)
+		}
+		ids[name] = result["id"].(string)
+	}
+
+	names := func(docs []map[string]interface{}) string {
+		var result []string
+		for _, doc := range docs {
+			result = append(result, doc["name"].(string))
+		}
+		return strings.Join(result, ",")
+	}
+
+	docs, total, err := repo.ListDocumentsByTag(ctx, []string{"go", "style"}, TagMatchAny, nil, 10, 0)
+	if err != nil {
+		t.Fatalf("ListDocumentsByTag(any) error = %v", err)
+	}
+	if total != 3 || names(docs) != "Go style,Go testing,Python style" {
+		t.Errorf("Expected 3 documents matching any tag, got %d: %s", total, names(docs))
+	}
+
+	docs, total, err = repo.ListDocumentsByTag(ctx, []string{"go", "style"}, TagMatchAll, nil, 10, 0)
+	if err != nil {
+		t.Fatalf("ListDocumentsByTag(all) error = %v", err)
+	}
+	if total != 1 || names(docs) != "Go style" {
+		t.Errorf("Expected only 'Go style' matching all tags, got %d: %s", total, names(docs))
+	}
+
+	// Retag a document and check the filter follows
+	newTags := []string{"go", "style", "testing"}
+	if _, err := repo.UpdateDocument(ctx, ids["Go testing"], DocumentUpdate{Tags: &newTags}); err != nil {
+		t.Fatalf("UpdateDocument(tags) error = %v", err)
+	}
+	docs, _, err = repo.ListDocumentsByTag(ctx, []string{"go", "style"}, TagMatchAll, nil, 10, 0)
+	if err != nil {
+		t.Fatalf("ListDocumentsByTag(all) error = %v", err)
+	}
+	if names(docs) != "Go style,Go testing" {
+		t.Errorf("Expected retagged document to match, got %s", names(docs))
+	}
+
+	if _, _, err := repo.ListDocumentsByTag(ctx, []string{"go"}, "some", nil, 10, 0); err == nil {
+		t.Error("Expected error for invalid match mode")
+	}
+}
+
+// TestGetDocumentWithRelated tests that related documents are found through shared tags and related_ids
+func TestGetDocumentWithRelated(t *testing.T) {
+	db := setupTestServer(t)
+	if db == nil {
+		return // Skipped
+	}
+	defer db.Close()
+
+	setupTempDocumentsTable(t, db)
+
+	repo := NewSQLDocumentRepository(db)
+	ctx := context.Background()
+
+	ids := map[string]string{}
+	for name, tags := range map[string][]string{
+		"Go style":     {"go", "style"},
+		"Go testing":   {"go", "testing"},
+		"Python style": {"python"},
+		"Glossary":     nil,
+	} {
+		result, err := repo.CreateDocument(ctx, NewDocument{Name: name, Content: &name, Tags: tags})
+		if err != nil {
+			t.Fatalf("CreateDocument(%s) error = %v", name, err)
+		}
+		ids[name] = result["id"].(string)
+	}
+
+	// The glossary shares no tags with Go style but is linked explicitly
+	relatedIDs := []string{ids["Glossary"]}
+	if _, err := repo.UpdateDocument(ctx, ids["Go style"], DocumentUpdate{RelatedIDs: &relatedIDs}); err != nil {
+		t.Fatalf("UpdateDocument(related_ids) error = %v", err)
+	}
+
+	document, related, err := repo.GetDocumentWithRelated(ctx, ids["Go style"], 10)
+	if err != nil {
+		t.Fatalf("GetDocumentWithRelated() error = %v", err)
+	}
+	if document["id"] != ids["Go style"] || document["content"] != "Go style" {
+		t.Errorf("Expected Go style with full content, got %v", document)
+	}
+
+	var names []string
+	for _, doc := range related {
+		names = append(names, doc["name"].(string))
+	}
+	if strings.Join(names, ",") != "Glossary,Go testing" {
+		t.Errorf("Expected Glossary and Go testing to be related, got %v", names)
+	}
+
+	// Relations through related_ids are one-way; shared tags work both ways
+	_, related, err = repo.GetDocumentWithRelated(ctx, ids["Go testing"], 10)
+	if err != nil {
+		t.Fatalf("GetDocumentWithRelated() error = %v", err)
+	}
+	if len(related) != 1 || related[0]["id"] != ids["Go style"] {
+		t.Errorf("Expected only Go style to be related to Go testing, got %v", related)
+	}
+
+	// An upsert that leaves related_ids unset keeps the links; an empty list clears them
+	if _, err := repo.UpsertDocument(ctx, "glossary-index", NewDocument{Name: "Glossary index", RelatedIDs: []string{ids["Glossary"]}}); err != nil {
+		t.Fatalf("UpsertDocument(related_ids) error = %v", err)
+	}
+	if _, err := repo.UpsertDocument(ctx, "glossary-index", NewDocument{Name: "Glossary index v2"}); err != nil {
+		t.Fatalf("UpsertDocument() error = %v", err)
+	}
+	var storedIDs string
+	if err := db.QueryRow(`SELECT related_ids::text FROM documents WHERE slug = 'glossary-index'`).Scan(&storedIDs); err != nil {
+		t.Fatalf("Failed to read related_ids: %v", err)
+	}
+	if !strings.Contains(storedIDs, ids["Glossary"]) {
+		t.Errorf("Expected upsert without related_ids to keep them, got %s", storedIDs)
+	}
+
+	noIDs := []string{}
+	if _, err := repo.UpdateDocument(ctx, ids["Go style"], DocumentUpdate{RelatedIDs: &noIDs}); err != nil {
+		t.Fatalf("UpdateDocument(related_ids) error = %v", err)
+	}
+	_, related, err = repo.GetDocumentWithRelated(ctx, ids["Go style"], 10)
+	if err != nil {
+		t.Fatalf("GetDocumentWithRelated() error = %v", err)
+	}
+	if len(related) != 1 || related[0]["id"] != ids["Go testing"] {
+		t.Errorf("Expected only Go testing after clearing related_ids, got %v", related)
+	}
+
+	if _, _, err := repo.GetDocumentWithRelated(ctx, "missing-id", 10); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected not found error for missing document, got %v", err)
+	}
+}
+
+// TestSoftDeleteDocument tests that deleted documents are hidden from listings until restored
+func TestSoftDeleteDocument(t *testing.T) {
+	db := setupTestServer(t)
+	if db == nil {
+		return // Skipped
+	}
+	defer db.Close()
+
+	setupTempDocumentsTable(t, db)
+	insertTestDocument(t, db, "doc-1", "Kept", "Still here.")
+	insertTestDocument(t, db, "doc-2", "Removed", "Deleted for a while.")
+
+	repo := NewSQLDocumentRepository(db)
+	ctx := context.Background()
+
+	listIDs := func(includeDeleted bool) string {
+		t.Helper()
+		docs, total, err := repo.ListPaged(ctx, DocumentListOptions{IncludeDeleted: includeDeleted, Limit: 10})
+		if err != nil {
+			t.Fatalf("ListPaged() error = %v", err)
+		}
+		var ids []string
+		for _, doc := range docs {
+			ids = append(ids, doc["id"].(string))
+		}
+		if total != len(ids) {
+			t.Errorf("Expected total %d to match the page, got %d", len(ids), total)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	if _, err := repo.DeleteDocument(ctx, "doc-2"); err != nil {
+		t.Fatalf("DeleteDocument() error = %v", err)
+	}
+	if got := listIDs(false); got != "doc-1" {
+		t.Errorf("Expected deleted document to be hidden, got %s", got)
+	}
+	if got := listIDs(true); got != "doc-1,doc-2" {
+		t.Errorf("Expected include_deleted to list both documents, got %s", got)
+	}
+	if docs, err := repo.SearchDocuments(ctx, "Deleted", nil, 10); err != nil || len(docs) != 0 {
+		t.Errorf("Expected search to hide deleted document, got %v (err %v)", docs, err)
+	}
+
+	if docs, err := repo.GetDocumentContent(ctx, []string{"doc-1", "doc-2"}); err != nil || len(docs) != 1 || docs[0]["id"] != "doc-1" {
+		t.Errorf("Expected get_document_content to hide deleted document, got %v (err %v)", docs, err)
+	}
+	if _, _, err := repo.GetDocumentWithRelated(ctx, "doc-2", 10); err == nil {
+		t.Error("Expected error getting a deleted document with related documents")
+	}
+	name := "Renamed"
+	if _, err := repo.UpdateDocument(ctx, "doc-2", DocumentUpdate{Name: &name}); err == nil {
+		t.Error("Expected error updating a deleted document")
+	}
+
+	if _, err := repo.DeleteDocument(ctx, "doc-2"); err == nil {
+		t.Error("Expected error deleting an already deleted document")
+	}
+
+	if _, err := repo.RestoreDocument(ctx, "doc-2"); err != nil {
+		t.Fatalf("RestoreDocument() error = %v", err)
+	}
+	if got := listIDs(false); got != "doc-1,doc-2" {
+		t.Errorf("Expected restored document to be listed, got %s", got)
+	}
+
+	if _, err := repo.RestoreDocument(ctx, "doc-1"); err == nil {
+		t.Error("Expected error restoring a document that is not deleted")
+	}
+	if _, err := repo.DeleteDocument(ctx, "missing-id"); err == nil {
+		t.Error("Expected error deleting a missing document")
+	}
+}
@@ -65,6 +65,9 @@ func toolGetDocuments(args map[string]interface{}) (string, error) {
 		}
 	}
 
+	// Soft-deleted documents are hidden unless include_deleted is set
+	includeDeleted, _ := args["include_deleted"].(bool)
+
 	// Limit with clamping
 	limit := 50
 	if lim, ok := args["limit"].(float64); ok {
@@ -77,8 +80,28 @@ func toolGetDocuments(args map[string]interface{}) (string, error) {
 		}
 	}
 
+	// Offset for pagination
+	offset := 0
+	if off, ok := args["offset"].(float64); ok && off > 0 {
+		offset = int(off)
+	}
+
+	// Sorting (validated against an allowlist by the repository)
+	sortBy, _ := args["sort_by"].(string)
+	sortOrder, _ := args["sort_order"].(string)
+
 	// Delegate to repository
-	documents, err := globalRepo.GetDocuments(ctx, tenantID, categoryID, tags, isActive, limit)
+	documents, total, err := globalRepo.ListPaged(ctx, DocumentListOptions{
+		TenantID:       tenantID,
+		CategoryID:     categoryID,
+		Tags:           tags,
+		IsActive:       isActive,
+		IncludeDeleted: includeDeleted,
+		Limit:          limit,
+		Offset:         offset,
+		SortBy:         sortBy,
+		SortOrder:      sortOrder,
+	})
 	if err != nil {
 		return "", fmt.Errorf("failed to get documents: %w", err)
 	}
@@ -86,7 +109,10 @@ func toolGetDocuments(args map[string]interface{}) (string, error) {
 	result := map[string]interface{}{
 		"documents": documents,
 		"count":     len(documents),
+		"total":     total,
 		"limit":     limit,
+		"offset":    offset,
+		"has_more":  offset+len(documents) < total,
 	}
 
 	resultJSON, err := json.Marshal(result)
@@ -144,6 +170,52 @@ func toolGetDocumentContent(args map[string]interface{}) (string, error) {
 	return string(resultJSON), nil
 }
 
+// toolGetDocumentWithRelated handles the get_document_with_related operation
+func toolGetDocumentWithRelated(args map[string]interface{}) (string, error) {
+	if globalRepo == nil {
+		return "", fmt.Errorf("repository not initialized")
+	}
+
+	ctx := context.Background()
+
+	documentID, ok := args["document_id"].(string)
+	if !ok || documentID == "" {
+		return "", fmt.Errorf("document_id is required and must be a non-empty string")
+	}
+
+	limit := 20
+	if lim, ok := args["limit"].(float64); ok {
+		limit = int(lim)
+		if limit > 100 {
+			limit = 100
+		}
+		if limit < 1 {
+			limit = 1
+		}
+	}
+
+	// Delegate to repository
+	document, related, err := globalRepo.GetDocumentWithRelated(ctx, documentID, limit)
+	if err != nil {
+		return "", fmt.Errorf("failed to get document: %w", err)
+	}
+	if related == nil {
+		related = []map[string]interface{}{}
+	}
+
+	result := map[string]interface{}{
+		"document": document,
+		"related":  related,
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(resultJSON), nil
+}
+
 // toolSearchDocuments handles the search_documents operation
 func toolSearchDocuments(args map[string]interface{}) (string, error) {
 	if globalRepo == nil {
@@ -195,3 +267,399 @@ func toolSearchDocuments(args map[string]interface{}) (string, error) {
 
 	return string(resultJSON), nil
 }
+
+// toolUpdateDocument handles the update_document operation
+func toolUpdateDocument(args map[string]interface{}) (string, error) {
+	if globalRepo == nil {
+		return "", fmt.Errorf("repository not initialized")
+	}
+
+	ctx := context.Background()
+
+	documentID, ok := args["document_id"].(string)
+	if !ok || documentID == "" {
+		return "", fmt.Errorf("document_id is required and must be a non-empty string")
+	}
+
+	var update DocumentUpdate
+	if v, ok := args["name"].(string); ok {
+		update.Name = &v
+	}
+	if v, ok := args["description"].(string); ok {
+		update.Description = &v
+	}
+	if v, ok := args["content"].(string); ok {
+		update.Content = &v
+	}
+	if _, ok := args["tags"]; ok {
+		tags, err := parseTags(args["tags"])
+		if err != nil {
+			return "", err
+		}
+		update.Tags = &tags
+	}
+	if _, ok := args["related_ids"]; ok {
+		relatedIDs, err := parseStringList("related_ids", args["related_ids"])
+		if err != nil {
+			return "", err
+		}
+		update.RelatedIDs = &relatedIDs
+	}
+
+	if update.Name == nil && update.Description == nil && update.Content == nil && update.Tags == nil && update.RelatedIDs == nil {
+		return "", fmt.Errorf("at least one of name, description, content, tags or related_ids is required")
+	}
+
+	// Delegate to repository
+	result, err := globalRepo.UpdateDocument(ctx, documentID, update)
+	if err != nil {
+		return "", fmt.Errorf("failed to update document: %w", err)
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(resultJSON), nil
+}
+
+// toolGetDocumentHistory handles the get_document_history operation
+func toolGetDocumentHistory(args map[string]interface{}) (string, error) {
+	if globalRepo == nil {
+		return "", fmt.Errorf("repository not initialized")
+	}
+
+	ctx := context.Background()
+
+	documentID, ok := args["document_id"].(string)
+	if !ok || documentID == "" {
+		return "", fmt.Errorf("document_id is required and must be a non-empty string")
+	}
+
+	limit := 50
+	if lim, ok := args["limit"].(float64); ok {
+		limit = int(lim)
+		if limit > 100 {
+			limit = 100
+		}
+		if limit < 1 {
+			limit = 1
+		}
+	}
+
+	// Delegate to repository
+	versions, err := globalRepo.GetDocumentHistory(ctx, documentID, limit)
+	if err != nil {
+		return "", fmt.Errorf("failed to get document history: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"document_id": documentID,
+		"versions":    versions,
+		"count":       len(versions),
+		"limit":       limit,
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(resultJSON), nil
+}
+
+// toolRestoreDocumentVersion handles the restore_document_version operation
+func toolRestoreDocumentVersion(args map[string]interface{}) (string, error) {
+	if globalRepo == nil {
+		return "", fmt.Errorf("repository not initialized")
+	}
+
+	ctx := context.Background()
+
+	documentID, ok := args["document_id"].(string)
+	if !ok || documentID == "" {
+		return "", fmt.Errorf("document_id is required and must be a non-empty string")
+	}
+
+	versionFloat, ok := args["version"].(float64)
+	if !ok || versionFloat < 1 {
+		return "", fmt.Errorf("version is required and must be a positive integer")
+	}
+
+	// Delegate to repository
+	result, err := globalRepo.RestoreDocumentVersion(ctx, documentID, int(versionFloat))
+	if err != nil {
+		return "", fmt.Errorf("failed to restore document version: %w", err)
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(resultJSON), nil
+}
+
+// toolDeleteDocument handles the delete_document operation
+func toolDeleteDocument(args map[string]interface{}) (string, error) {
+	if globalRepo == nil {
+		return "", fmt.Errorf("repository not initialized")
+	}
+
+	ctx := context.Background()
+
+	documentID, ok := args["document_id"].(string)
+	if !ok || documentID == "" {
+		return "", fmt.Errorf("document_id is required and must be a non-empty string")
+	}
+
+	// Delegate to repository
+	result, err := globalRepo.DeleteDocument(ctx, documentID)
+	if err != nil {
+		return "", fmt.Errorf("failed to delete document: %w", err)
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(resultJSON), nil
+}
+
+// toolRestoreDocument handles the restore_document operation
+func toolRestoreDocument(args map[string]interface{}) (string, error) {
+	if globalRepo == nil {
+		return "", fmt.Errorf("repository not initialized")
+	}
+
+	ctx := context.Background()
+
+	documentID, ok := args["document_id"].(string)
+	if !ok || documentID == "" {
+		return "", fmt.Errorf("document_id is required and must be a non-empty string")
+	}
+
+	// Delegate to repository
+	result, err := globalRepo.RestoreDocument(ctx, documentID)
+	if err != nil {
+		return "", fmt.Errorf("failed to restore document: %w", err)
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(resultJSON), nil
+}
+
+// parseTags converts a tags argument into a string slice, rejecting non-string entries
+func parseTags(v interface{}) ([]string, error) {
+	return parseStringList("tags", v)
+}
+
+// parseStringList converts the array argument named field into a string slice,
+// rejecting non-string and empty entries
+func parseStringList(field string, v interface{}) ([]string, error) {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be an array of strings", field)
+	}
+
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		str, ok := item.(string)
+		if !ok || str == "" {
+			return nil, fmt.Errorf("%s must be an array of non-empty strings", field)
+		}
+		values = append(values, str)
+	}
+
+	return values, nil
+}
+
+// toolCreateDocument handles the create_document operation
+func toolCreateDocument(args map[string]interface{}) (string, error) {
+	if globalRepo == nil {
+		return "", fmt.Errorf("repository not initialized")
+	}
+
+	ctx := context.Background()
+
+	name, ok := args["name"].(string)
+	if !ok || name == "" {
+		return "", fmt.Errorf("name is required and must be a non-empty string")
+	}
+
+	doc := NewDocument{Name: name}
+	if v, ok := args["description"].(string); ok {
+		doc.Description = &v
+	}
+	if v, ok := args["content"].(string); ok {
+		doc.Content = &v
+	}
+	if v, ok := args["category_id"].(string); ok && v != "" {
+		doc.CategoryID = &v
+	}
+	if v, ok := args["tenant_id"].(string); ok && v != "" {
+		doc.TenantID = &v
+	}
+	if _, ok := args["tags"]; ok {
+		tags, err := parseTags(args["tags"])
+		if err != nil {
+			return "", err
+		}
+		doc.Tags = tags
+	}
+	if _, ok := args["related_ids"]; ok {
+		relatedIDs, err := parseStringList("related_ids", args["related_ids"])
+		if err != nil {
+			return "", err
+		}
+		doc.RelatedIDs = relatedIDs
+	}
+
+	// Delegate to repository
+	result, err := globalRepo.CreateDocument(ctx, doc)
+	if err != nil {
+		return "", fmt.Errorf("failed to create document: %w", err)
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(resultJSON), nil
+}
+
+// toolUpsertDocument handles the upsert_document operation
+func toolUpsertDocument(args map[string]interface{}) (string, error) {
+	if globalRepo == nil {
+		return "", fmt.Errorf("repository not initialized")
+	}
+
+	ctx := context.Background()
+
+	slug, ok := args["slug"].(string)
+	if !ok || slug == "" {
+		return "", fmt.Errorf("slug is required and must be a non-empty string")
+	}
+	name, ok := args["name"].(string)
+	if !ok || name == "" {
+		return "", fmt.Errorf("name is required and must be a non-empty string")
+	}
+
+	doc := NewDocument{Name: name}
+	if v, ok := args["description"].(string); ok {
+		doc.Description = &v
+	}
+	if v, ok := args["content"].(string); ok {
+		doc.Content = &v
+	}
+	if v, ok := args["category_id"].(string); ok && v != "" {
+		doc.CategoryID = &v
+	}
+	if v, ok := args["tenant_id"].(string); ok && v != "" {
+		doc.TenantID = &v
+	}
+	if _, ok := args["tags"]; ok {
+		tags, err := parseTags(args["tags"])
+		if err != nil {
+			return "", err
+		}
+		doc.Tags = tags
+	}
+	if _, ok := args["related_ids"]; ok {
+		relatedIDs, err := parseStringList("related_ids", args["related_ids"])
+		if err != nil {
+			return "", err
+		}
+		doc.RelatedIDs = relatedIDs
+	}
+
+	// Delegate to repository
+	result, err := globalRepo.UpsertDocument(ctx, slug, doc)
+	if err != nil {
+		return "", fmt.Errorf("failed to upsert document: %w", err)
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(resultJSON), nil
+}
+
+// toolListDocumentsByTag handles the list_documents_by_tag operation
+func toolListDocumentsByTag(args map[string]interface{}) (string, error) {
+	if globalRepo == nil {
+		return "", fmt.Errorf("repository not initialized")
+	}
+
+	ctx := context.Background()
+
+	tags, err := parseTags(args["tags"])
+	if err != nil {
+		return "", err
+	}
+	if len(tags) == 0 {
+		return "", fmt.Errorf("tags must contain at least one tag")
+	}
+
+	// any (default) or all
+	match, _ := args["match"].(string)
+	if match == "" {
+		match = TagMatchAny
+	}
+
+	var tenantID *string
+	if v, ok := args["tenant_id"]; ok {
+		if tid, ok := v.(string); ok && tid != "" {
+			tenantID = &tid
+		}
+	}
+
+	limit := 50
+	if lim, ok := args["limit"].(float64); ok {
+		limit = int(lim)
+		if limit > 100 {
+			limit = 100
+		}
+		if limit < 1 {
+			limit = 1
+		}
+	}
+
+	offset := 0
+	if off, ok := args["offset"].(float64); ok && off > 0 {
+		offset = int(off)
+	}
+
+	// Delegate to repository
+	documents, total, err := globalRepo.ListDocumentsByTag(ctx, tags, match, tenantID, limit, offset)
+	if err != nil {
+		return "", fmt.Errorf("failed to list documents by tag: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"tags":      tags,
+		"match":     match,
+		"documents": documents,
+		"count":     len(documents),
+		"total":     total,
+		"limit":     limit,
+		"offset":    offset,
+		"has_more":  offset+len(documents) < total,
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(resultJSON), nil
+}
@@ -1,13 +1,15 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"os"
 
 	_ "github.com/lib/pq"
+
+	"github.com/code-aria/internal-mcp/internal/mcp"
 )
 
 func main() {
@@ -34,11 +36,14 @@ func main() {
 
 	// Create repository and set as global
 	repo := NewSQLDocumentRepository(db)
+	if err := repo.CheckSchema(context.Background()); err != nil {
+		fmt.Fprintf(os.Stderr, "Schema check failed: %v\n", err)
+		os.Exit(1)
+	}
 	setGlobalRepository(repo)
 
-	scanner := bufio.NewScanner(os.Stdin)
-	// Increase buffer size to handle large JSON-RPC messages
-	scanner.Buffer(nil, 10*1024*1024) // 10MB buffer
+	// Messages up to MCP_MAX_MESSAGE_BYTES (default 10MB) are accepted
+	scanner := mcp.NewScanner(os.Stdin)
 	encoder := json.NewEncoder(os.Stdout)
 
 	if err := handleInitialize(scanner, encoder); err != nil {
@@ -46,30 +51,6 @@ func main() {
 		os.Exit(1)
 	}
 
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
-		}
-		// Log large requests for debugging (warn if > 500KB, error if > 1MB)
-		if len(line) > 1_000_000 {
-			fmt.Fprintf(os.Stderr, "[ERROR] mcp-documents: Request size %d bytes exceeds 1MB buffer\n", len(line))
-		} else if len(line) > 500_000 {
-			fmt.Fprintf(os.Stderr, "[WARN] mcp-documents: Large request size %d bytes\n", len(line))
-		}
-
-		var msg MCPMessage
-		if err := json.Unmarshal(line, &msg); err != nil {
-			continue
-		}
-
-		if msg.Method != "" {
-			handleRequest(&msg, encoder)
-		}
-	}
-
-	// Check for scanner errors (e.g., token too long)
-	if err := scanner.Err(); err != nil {
-		fmt.Fprintf(os.Stderr, "[ERROR] mcp-documents: Scanner error: %v (buffer max: 10MB)\n", err)
-	}
+	// Handle requests
+	mcp.Serve(scanner, encoder, "mcp-documents", handleRequest)
 }
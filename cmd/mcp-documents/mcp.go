@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+
+	"github.com/code-aria/internal-mcp/internal/mcp"
 )
 
 // handleInitialize processes the MCP initialize request
@@ -56,6 +58,8 @@ func handleInitialize(scanner *bufio.Scanner, encoder *json.Encoder) error {
 // handleRequest routes MCP requests to appropriate handlers
 func handleRequest(msg *MCPMessage, encoder *json.Encoder) {
 	switch msg.Method {
+	case "ping":
+		mcp.SendPing(encoder, msg.ID)
 	case "tools/list":
 		handleToolsList(msg, encoder)
 	case "tools/call":
@@ -83,7 +87,7 @@ func handleToolsList(msg *MCPMessage, encoder *json.Encoder) {
 							"properties": map[string]interface{}{
 								"type": map[string]interface{}{
 									"type":        "string",
-									"description": "Operation type: get_documents, get_document_content, search_documents",
+									"description": "Operation type: get_documents, get_document_content, get_document_with_related, search_documents, list_documents_by_tag, create_document, upsert_document, update_document, get_document_history, restore_document_version, delete_document, restore_document",
 								},
 							},
 						},
@@ -127,120 +131,28 @@ func handleToolCall(msg *MCPMessage, encoder *json.Encoder) {
 	sendError(encoder, msg.ID, -32601, fmt.Sprintf("Unknown tool: %s. Use apply_operations for batch operations", req.Name), nil)
 }
 
+// batchRunner dispatches apply_operations to the operation handlers
+var batchRunner = mcp.NewBatchRunner(map[string]mcp.OperationHandler{
+	"get_documents":             toolGetDocuments,
+	"get_document_content":      toolGetDocumentContent,
+	"get_document_with_related": toolGetDocumentWithRelated,
+	"search_documents":          toolSearchDocuments,
+	"list_documents_by_tag":     toolListDocumentsByTag,
+	"create_document":           toolCreateDocument,
+	"upsert_document":           toolUpsertDocument,
+	"update_document":           toolUpdateDocument,
+	"get_document_history":      toolGetDocumentHistory,
+	"restore_document_version":  toolRestoreDocumentVersion,
+	"delete_document":           toolDeleteDocument,
+	"restore_document":          toolRestoreDocument,
+})
+
 // handleBatchOperations processes a batch of operations
 func handleBatchOperations(msg *MCPMessage, encoder *json.Encoder, args map[string]interface{}) {
-	operations, ok := args["operations"].([]interface{})
-	if !ok {
-		sendError(encoder, msg.ID, -32602, "operations array is required", nil)
-		return
-	}
-
-	if len(operations) == 0 {
-		sendError(encoder, msg.ID, -32602, "operations array cannot be empty", nil)
-		return
-	}
-
-	var results []map[string]interface{}
-
-	for _, op := range operations {
-		opMap, ok := op.(map[string]interface{})
-		if !ok {
-			results = append(results, map[string]interface{}{
-				"operation": "unknown",
-				"params":    map[string]interface{}{},
-				"status":    "Error",
-				"message":   "Invalid operation format",
-			})
-			continue
-		}
-
-		opType, ok := opMap["type"].(string)
-		if !ok {
-			results = append(results, map[string]interface{}{
-				"operation": "unknown",
-				"params":    map[string]interface{}{},
-				"status":    "Error",
-				"message":   "Operation type is required",
-			})
-			continue
-		}
-
-		// Extract operation-specific arguments as params
-		params := make(map[string]interface{})
-		for k, v := range opMap {
-			if k != "type" {
-				params[k] = v
-			}
-		}
-
-		// Execute operation based on type
-		var result string
-		var err error
-
-		switch opType {
-		case "get_documents":
-			result, err = toolGetDocuments(params)
-		case "get_document_content":
-			result, err = toolGetDocumentContent(params)
-		case "search_documents":
-			result, err = toolSearchDocuments(params)
-		default:
-			err = fmt.Errorf("unknown operation type: %s", opType)
-		}
-
-		if err != nil {
-			results = append(results, map[string]interface{}{
-				"operation": opType,
-				"params":    params,
-				"status":    "Error",
-				"message":   err.Error(),
-			})
-		} else {
-			// Parse JSON result if possible, otherwise use as string
-			var parsedResult interface{}
-			if jsonErr := json.Unmarshal([]byte(result), &parsedResult); jsonErr == nil {
-				// Successfully parsed JSON, use parsed result
-			} else {
-				// Not JSON, use string as-is
-				parsedResult = result
-			}
-
-			results = append(results, map[string]interface{}{
-				"operation": opType,
-				"params":    params,
-				"status":    "Success",
-				"result":    parsedResult,
-			})
-		}
-	}
-
-	// Serialize results to JSON text for MCP-compliant response format
-	resultsJSON, err := json.Marshal(map[string]interface{}{
-		"results": results,
-	})
-	if err != nil {
-		sendError(encoder, msg.ID, -32700, fmt.Sprintf("Failed to marshal results: %v", err), nil)
-		return
-	}
-
-	// Return results in MCP-compliant format using ToolsCallResponse
-	response := MCPMessage{
-		JSONRPC: "2.0",
-		ID:      msg.ID,
-		Result: ToolsCallResponse{
-			Content: []Content{
-				{
-					Type: "text",
-					Text: string(resultsJSON),
-				},
-			},
-			IsError: false,
-		},
-	}
-
-	encoder.Encode(response)
+	batchRunner.Handle(msg, encoder, args)
 }
 
+
 // sendError sends an error response
 func sendError(encoder *json.Encoder, id interface{}, code int, message string, data interface{}) {
 	response := MCPMessage{
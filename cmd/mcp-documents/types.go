@@ -1,56 +1,57 @@
 package main
 
-import "encoding/json"
-
-// MCP protocol types
-type MCPMessage struct {
-	JSONRPC string          `json:"jsonrpc"`
-	ID      interface{}     `json:"id,omitempty"`
-	Method  string          `json:"method,omitempty"`
-	Params  json.RawMessage `json:"params,omitempty"`
-	Result  interface{}     `json:"result,omitempty"`
-	Error   *MCPError        `json:"error,omitempty"`
-}
-
-type MCPError struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-}
-
-type InitializeResponse struct {
-	ProtocolVersion string                 `json:"protocolVersion"`
-	Capabilities    map[string]interface{} `json:"capabilities"`
-	ServerInfo      ServerInfo             `json:"serverInfo"`
-}
-
-type ServerInfo struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
-}
-
-type Tool struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	InputSchema map[string]interface{} `json:"inputSchema"`
-}
-
-type ToolsListResponse struct {
-	Tools []Tool `json:"tools"`
-}
-
-type ToolsCallRequest struct {
-	Name      string                 `json:"name"`
-	Arguments map[string]interface{} `json:"arguments,omitempty"`
-}
-
-type ToolsCallResponse struct {
-	Content []Content `json:"content"`
-	IsError bool      `json:"isError,omitempty"`
-}
-
-type Content struct {
-	Type string `json:"type"`
-	Text string `json:"text,omitempty"`
-}
-
+import "github.com/code-aria/internal-mcp/internal/mcp"
+
+// MCP protocol types, shared with the other servers
+type (
+	MCPMessage         = mcp.Message
+	MCPError           = mcp.Error
+	InitializeResponse = mcp.InitializeResponse
+	ServerInfo         = mcp.ServerInfo
+	Tool               = mcp.Tool
+	ToolsListResponse  = mcp.ToolsListResponse
+	ToolsCallRequest   = mcp.ToolsCallRequest
+	ToolsCallResponse  = mcp.ToolsCallResponse
+	Content            = mcp.Content
+)
+
+// DocumentListOptions holds the filters, paging and sorting used when listing documents
+type DocumentListOptions struct {
+	IDs            []string // restricts the listing to these document ids when non-empty
+	TenantID       *string
+	CategoryID     *string
+	Tags           []string
+	TagMatch       string // TagMatchAny or TagMatchAll; defaults to TagMatchAll
+	IsActive       *bool
+	IncludeDeleted bool // include soft-deleted documents, which are hidden by default
+	Limit          int
+	Offset         int
+	SortBy         string // must be one of documentSortColumns; defaults to name
+	SortOrder      string // asc or desc; defaults to asc
+}
+
+// DocumentUpdate holds the fields to change on a document; nil fields are left unchanged
+type DocumentUpdate struct {
+	Name        *string
+	Description *string
+	Content     *string
+	Tags        *[]string // replaces the full tag list when set
+	RelatedIDs  *[]string // replaces the full related_ids list when set
+}
+
+// NewDocument holds the fields for creating a document
+type NewDocument struct {
+	Name        string
+	Description *string
+	Content     *string
+	CategoryID  *string
+	TenantID    *string
+	Tags        []string
+	RelatedIDs  []string // ids of documents get_document_with_related lists alongside tag matches
+}
+
+// Tag match modes for filtering documents by tag
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
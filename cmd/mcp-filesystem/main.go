@@ -2,19 +2,26 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/code-aria/internal-mcp/internal/mcp"
 )
 
 func main() {
-	scanner := bufio.NewScanner(os.Stdin)
-	// Increase buffer size to handle large JSON-RPC messages (e.g., file contents)
-	// Default buffer is 64KB, which is too small for large file read responses
-	scanner.Buffer(nil, 10*1024*1024) // 10MB buffer
+	// Messages up to MCP_MAX_MESSAGE_BYTES (default 10MB) are accepted
+	scanner := mcp.NewScanner(os.Stdin)
 	encoder := json.NewEncoder(os.Stdout)
 
 	// Initialize handshake
@@ -24,32 +31,7 @@ func main() {
 	}
 
 	// Handle requests
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
-		}
-		// Log large requests for debugging (warn if > 500KB, error if > 1MB)
-		if len(line) > 1_000_000 {
-			fmt.Fprintf(os.Stderr, "[ERROR] mcp-filesystem: Request size %d bytes exceeds 1MB buffer\n", len(line))
-		} else if len(line) > 500_000 {
-			fmt.Fprintf(os.Stderr, "[WARN] mcp-filesystem: Large request size %d bytes\n", len(line))
-		}
-
-		var msg MCPMessage
-		if err := json.Unmarshal(line, &msg); err != nil {
-			continue
-		}
-
-		if msg.Method != "" {
-			handleRequest(&msg, encoder)
-		}
-	}
-
-	// Check for scanner errors (e.g., token too long)
-	if err := scanner.Err(); err != nil {
-		fmt.Fprintf(os.Stderr, "[ERROR] mcp-filesystem: Scanner error: %v (buffer max: 10MB)\n", err)
-	}
+	mcp.Serve(scanner, encoder, "mcp-filesystem", handleRequest)
 }
 
 func handleInitialize(scanner *bufio.Scanner, encoder *json.Encoder) error {
@@ -103,6 +85,8 @@ func handleInitialize(scanner *bufio.Scanner, encoder *json.Encoder) error {
 
 func handleRequest(msg *MCPMessage, encoder *json.Encoder) {
 	switch msg.Method {
+	case "ping":
+		mcp.SendPing(encoder, msg.ID)
 	case "tools/list":
 		handleToolsList(msg, encoder)
 	case "tools/call":
@@ -129,7 +113,7 @@ func handleToolsList(msg *MCPMessage, encoder *json.Encoder) {
 							"properties": map[string]interface{}{
 								"type": map[string]interface{}{
 									"type":        "string",
-									"description": "Operation type: read_file, list_directory, get_file_tree, file_exists, create_directory",
+									"description": "Operation type: read_file, list_directory, get_file_tree, file_exists, create_directory, list_changed_since, read_multiple_files, write_file, tail_file, get_directory_size, find_files_containing, resolve_symlink. read_file takes path and returns the file's content, or {content, sha256} with with_sha256: true; the hash is what mcp-code-edit takes as expected_sha256. read_multiple_files takes paths (array, up to 100) and returns a map of path to {content, sha256} or {error}; each file must be inside REPO_PATH and at most 1MB. list_changed_since takes since (RFC3339, required), root_path and max_depth and returns {since, files: [{path, mod_time}], count}, newest first. write_file takes path, content and overwrite (default false) and creates parent directories; the path must be inside REPO_PATH, and the operation is disabled unless the server runs with MCP_FILESYSTEM_ALLOW_WRITE=true. tail_file takes path and lines (default 50, max 10000) and returns {path, lines, count, size_bytes, truncated, total_lines}, reading backwards from the end of the file so large logs are not read whole; total_lines is only included when reading reached the start of the file, e.g. for files shorter than lines. At most 1MB is read from the end: truncated is true when that ran out before lines were found, and the first line is then the end of a longer one. get_directory_size takes path and max_depth (directory levels to descend, 0 for only the files directly in path; unlimited by default) and returns {path, total_bytes, file_count}, summing the sizes of regular files below path, including hidden ones; symlinks are not followed, and depth_limited: true is added when max_depth left files out. find_files_containing takes pattern (a regular expression, or plain text with literal: true), case_insensitive, optional file_patterns and exclude_patterns (globs matched against the file name, or the path from root_path when they contain a slash), root_path and max_results (default 1000, max 10000) and returns {pattern, files, count, files_searched, truncated}: only the paths of files with a matching line, each read up to its first match; hidden directories, excluded directories, symlinks and binary files are skipped. Symlinks: no walk follows them, so link cycles cannot loop (get_file_tree lists a link as an entry without descending into it). file_exists reports is_symlink and describes the link's target otherwise; list_directory takes details (bool) to return [{name, is_dir, is_symlink}] instead of names, where is_dir is false for a link to a directory. resolve_symlink takes path (a symlink inside REPO_PATH) and returns {path, target, exists, resolved_path, is_directory, inside_repo}: target as written in the link and resolved_path with every link followed; the target may be outside REPO_PATH, which inside_repo reports, and then only {target, inside_repo} is returned",
 								},
 							},
 						},
@@ -172,137 +156,48 @@ func handleToolCall(msg *MCPMessage, encoder *json.Encoder) {
 	sendError(encoder, msg.ID, -32601, fmt.Sprintf("Unknown tool: %s. Use apply_operations for batch operations", req.Name), nil)
 }
 
+// batchRunner dispatches apply_operations to the operation handlers
+var batchRunner = mcp.NewBatchRunner(map[string]mcp.OperationHandler{
+	"read_file":             toolReadFile,
+	"list_directory":        toolListDirectory,
+	"get_file_tree":         toolGetFileTree,
+	"file_exists":           toolFileExists,
+	"create_directory":      toolCreateDirectory,
+	"list_changed_since":    toolListChangedSince,
+	"read_multiple_files":   toolReadMultipleFiles,
+	"write_file":            toolWriteFile,
+	"tail_file":             toolTailFile,
+	"get_directory_size":    toolGetDirectorySize,
+	"find_files_containing": toolFindFilesContaining,
+	"resolve_symlink":       toolResolveSymlink,
+}).WithParamsFilter(optimizeParams)
+
+// handleBatchOperations processes a batch of operations
 func handleBatchOperations(msg *MCPMessage, encoder *json.Encoder, args map[string]interface{}) {
-	operations, ok := args["operations"].([]interface{})
-	if !ok {
-		sendError(encoder, msg.ID, -32602, "operations array is required", nil)
-		return
-	}
-
-	if len(operations) == 0 {
-		sendError(encoder, msg.ID, -32602, "operations array cannot be empty", nil)
-		return
-	}
-
-	var results []map[string]interface{}
-
-	for _, op := range operations {
-		opMap, ok := op.(map[string]interface{})
-		if !ok {
-			results = append(results, map[string]interface{}{
-				"operation": "unknown",
-				"params":    map[string]interface{}{},
-				"status":    "Error",
-				"message":   "Invalid operation format",
-			})
-			continue
-		}
-
-		opType, ok := opMap["type"].(string)
-		if !ok {
-			results = append(results, map[string]interface{}{
-				"operation": "unknown",
-				"params":    map[string]interface{}{},
-				"status":    "Error",
-				"message":   "Operation type is required",
-			})
-			continue
-		}
-
-		// Extract operation-specific arguments as params
-		params := make(map[string]interface{})
-		for k, v := range opMap {
-			if k != "type" {
-				params[k] = v
-			}
-		}
-
-		// Execute operation based on type
-		var result string
-		var err error
-
-		switch opType {
-		case "read_file":
-			result, err = toolReadFile(params)
-		case "list_directory":
-			result, err = toolListDirectory(params)
-		case "get_file_tree":
-			result, err = toolGetFileTree(params)
-		case "file_exists":
-			result, err = toolFileExists(params)
-		case "create_directory":
-			result, err = toolCreateDirectory(params)
-		default:
-			err = fmt.Errorf("unknown operation type: %s", opType)
-		}
-
-		// Optimize params before adding to results
-		optimizedParams := optimizeParams(opType, params)
-
-		if err != nil {
-			results = append(results, map[string]interface{}{
-				"operation": opType,
-				"params":    optimizedParams,
-				"status":    "Error",
-				"message":   err.Error(),
-			})
-		} else {
-			// Parse JSON result if possible, otherwise use as string
-			var parsedResult interface{}
-			if jsonErr := json.Unmarshal([]byte(result), &parsedResult); jsonErr == nil {
-				// Successfully parsed JSON, use parsed result
-			} else {
-				// Not JSON, use string as-is
-				parsedResult = result
-			}
-
-			results = append(results, map[string]interface{}{
-				"operation": opType,
-				"params":    optimizedParams,
-				"status":    "Success",
-				"result":    parsedResult,
-			})
-		}
-	}
-
-	// Serialize results to JSON text for MCP-compliant response format
-	resultsJSON, err := json.Marshal(map[string]interface{}{
-		"results": results,
-	})
-	if err != nil {
-		sendError(encoder, msg.ID, -32700, fmt.Sprintf("Failed to marshal results: %v", err), nil)
-		return
-	}
-
-	// Return results in MCP-compliant format using ToolsCallResponse
-	response := MCPMessage{
-		JSONRPC: "2.0",
-		ID:      msg.ID,
-		Result: ToolsCallResponse{
-			Content: []Content{
-				{
-					Type: "text",
-					Text: string(resultsJSON),
-				},
-			},
-			IsError: false,
-		},
-	}
-
-	encoder.Encode(response)
+	batchRunner.Handle(msg, encoder, args)
 }
 
 // optimizeParams optimizes params for response by truncating long string values (> 20 lines)
 func optimizeParams(opType string, params map[string]interface{}) map[string]interface{} {
 	optimized := make(map[string]interface{})
-	
+
 	// Fields to always preserve as-is (metadata)
 	preserveFields := map[string]bool{
-		"path":        true,
-		"root_path":   true,
-		"max_depth":   true,
+		"path":      true,
+		"root_path": true,
+		"max_depth": true,
+	}
+
+	// The written content is omitted entirely
+	if opType == "write_file" {
+		for k, v := range params {
+			if k != "content" {
+				optimized[k] = v
+			}
+		}
+		return optimized
 	}
-	
+
 	// For filesystem operations, truncate long string values (> 20 lines)
 	for k, v := range params {
 		if preserveFields[k] {
@@ -322,7 +217,7 @@ func optimizeParams(opType string, params map[string]interface{}) map[string]int
 			optimized[k] = v
 		}
 	}
-	
+
 	return optimized
 }
 
@@ -339,9 +234,239 @@ func toolReadFile(args map[string]interface{}) (string, error) {
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
 
+	// The content alone stays the default result; with_sha256 wraps it with the hash that
+	// mcp-code-edit takes as expected_sha256
+	if withHash, _ := args["with_sha256"].(bool); withHash {
+		resultJSON, err := json.Marshal(map[string]interface{}{
+			"content": string(data),
+			"sha256":  contentSHA256(data),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal file content: %w", err)
+		}
+		return string(resultJSON), nil
+	}
+	return string(data), nil
+}
+
+// contentSHA256 returns the hex SHA-256 of data, in the form mcp-code-edit's
+// expected_sha256 takes
+func contentSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+const (
+	// maxMultiReadFiles caps the number of paths in one read_multiple_files operation
+	maxMultiReadFiles = 100
+	// maxMultiReadFileBytes caps the size of each file read by read_multiple_files
+	maxMultiReadFileBytes = 1024 * 1024
+)
+
+// fileReadResult is the outcome of reading one file in read_multiple_files
+type fileReadResult struct {
+	Content *string `json:"content,omitempty"`
+	SHA256  string  `json:"sha256,omitempty"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// toolReadMultipleFiles reads every path in paths and returns a map of path to
+// {content} or {error}, so one unreadable file does not fail the others
+func toolReadMultipleFiles(args map[string]interface{}) (string, error) {
+	rawPaths, ok := args["paths"].([]interface{})
+	if !ok || len(rawPaths) == 0 {
+		return "", fmt.Errorf("paths is required")
+	}
+	if len(rawPaths) > maxMultiReadFiles {
+		return "", fmt.Errorf("at most %d paths can be read at once, got %d", maxMultiReadFiles, len(rawPaths))
+	}
+
+	results := make(map[string]fileReadResult, len(rawPaths))
+	for _, rawPath := range rawPaths {
+		path, ok := rawPath.(string)
+		if !ok || path == "" {
+			return "", fmt.Errorf("all paths must be non-empty strings")
+		}
+
+		content, err := readContainedFile(path)
+		if err != nil {
+			results[path] = fileReadResult{Error: err.Error()}
+			continue
+		}
+		results[path] = fileReadResult{Content: &content, SHA256: contentSHA256([]byte(content))}
+	}
+
+	resultJSON, err := json.Marshal(results)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal file contents: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// readContainedFile reads a file that must resolve inside REPO_PATH (when set), symlinks
+// included, and be no larger than maxMultiReadFileBytes
+func readContainedFile(path string) (string, error) {
+	fullPath, err := resolveReadablePath(path)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("file does not exist: %s", path)
+		}
+		return "", fmt.Errorf("failed to stat file: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("path is a directory: %s", path)
+	}
+	if info.Size() > maxMultiReadFileBytes {
+		return "", fmt.Errorf("file is %d bytes, larger than the %d byte limit; use read_file instead", info.Size(), maxMultiReadFileBytes)
+	}
+
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
 	return string(data), nil
 }
 
+const (
+	// defaultTailLines and maxTailLines bound the lines param of tail_file
+	defaultTailLines = 50
+	maxTailLines     = 10000
+	// tailChunkSize is how much tail_file reads at a time, moving back from the end
+	tailChunkSize = 64 * 1024
+	// maxTailBytes caps how much tail_file reads from the end of the file, so a file of
+	// very long lines cannot be read whole
+	maxTailBytes = 1024 * 1024
+)
+
+// toolTailFile returns the last lines of a file, reading backwards from the end in chunks
+// so a large log is never read whole. total_lines is only reported when reading reached
+// the start of the file anyway, e.g. for a file shorter than lines. truncated is set when
+// maxTailBytes ran out before lines were found, and the first line is then cut short.
+func toolTailFile(args map[string]interface{}) (string, error) {
+	path, ok := args["path"].(string)
+	if !ok || path == "" {
+		return "", fmt.Errorf("path is required")
+	}
+
+	n := defaultTailLines
+	if l, ok := args["lines"].(float64); ok {
+		n = int(l)
+		if n < 1 {
+			n = 1
+		}
+		if n > maxTailLines {
+			n = maxTailLines
+		}
+	}
+
+	fullPath, err := resolveReadablePath(path)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("file does not exist: %s", path)
+		}
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to stat file: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("path is a directory: %s", path)
+	}
+
+	lines, total, truncated, err := tailLines(file, info.Size(), n, maxTailBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"path":       path,
+		"lines":      lines,
+		"count":      len(lines),
+		"size_bytes": info.Size(),
+		"truncated":  truncated,
+	}
+	if total >= 0 {
+		result["total_lines"] = total
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal tail: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// tailLines returns the last n lines of the size bytes of r without line endings, and the
+// total number of lines, or -1 when the start was never read. A final newline does not
+// start another line. At most maxBytes are read; when they run out before n lines are
+// found, the first line returned is the end of a longer one and truncated is true.
+func tailLines(r io.ReaderAt, size int64, n int, maxBytes int64) ([]string, int, bool, error) {
+	end := size
+	var buf []byte
+	if end > 0 {
+		last := make([]byte, 1)
+		if _, err := r.ReadAt(last, end-1); err != nil {
+			return nil, 0, false, err
+		}
+		if last[0] == '\n' {
+			end--
+		}
+	}
+
+	// Read chunks backwards until the buffer holds n newlines, so the lines after the
+	// nth one from the end are the last n
+	offset := end
+	newlines := 0
+	for offset > 0 && newlines < n && end-offset < maxBytes {
+		chunkSize := int64(tailChunkSize)
+		if offset < chunkSize {
+			chunkSize = offset
+		}
+		if remaining := maxBytes - (end - offset); remaining < chunkSize {
+			chunkSize = remaining
+		}
+		offset -= chunkSize
+
+		chunk := make([]byte, chunkSize, chunkSize+int64(len(buf)))
+		if _, err := r.ReadAt(chunk, offset); err != nil && err != io.EOF {
+			return nil, 0, false, err
+		}
+		newlines += bytes.Count(chunk, []byte("\n"))
+		buf = append(chunk, buf...)
+	}
+
+	if size == 0 {
+		return []string{}, 0, false, nil
+	}
+	truncated := offset > 0 && newlines < n
+
+	lines := strings.Split(string(buf), "\n")
+	total := -1
+	if offset == 0 {
+		total = len(lines)
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines, total, truncated, nil
+}
+
 func toolListDirectory(args map[string]interface{}) (string, error) {
 	path, ok := args["path"].(string)
 	if !ok {
@@ -360,6 +485,29 @@ func toolListDirectory(args map[string]interface{}) (string, error) {
 		return "", fmt.Errorf("failed to list directory '%s': %w", path, err)
 	}
 
+	// With details, each entry says what it is. ReadDir does not follow symlinks, so a
+	// link to a directory has is_symlink set and is_dir unset.
+	if details, _ := args["details"].(bool); details {
+		type dirEntry struct {
+			Name      string `json:"name"`
+			IsDir     bool   `json:"is_dir"`
+			IsSymlink bool   `json:"is_symlink"`
+		}
+		detailed := []dirEntry{}
+		for _, entry := range entries {
+			detailed = append(detailed, dirEntry{
+				Name:      entry.Name(),
+				IsDir:     entry.IsDir(),
+				IsSymlink: entry.Type()&os.ModeSymlink != 0,
+			})
+		}
+		result, err := json.Marshal(detailed)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal directory listing: %w", err)
+		}
+		return string(result), nil
+	}
+
 	var names []string
 	for _, entry := range entries {
 		names = append(names, entry.Name())
@@ -377,6 +525,21 @@ func shouldSkipDir(dirName string) bool {
 	return len(dirName) > 0 && dirName[0] == '.'
 }
 
+// pathDepth returns the number of components of a path relative to a walk root, 0 for the root itself
+func pathDepth(rel string) int {
+	if rel == "." {
+		return 0
+	}
+	depth := 0
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		// Skip empty parts (can happen with trailing separators)
+		if part != "" {
+			depth++
+		}
+	}
+	return depth
+}
+
 func toolGetFileTree(args map[string]interface{}) (string, error) {
 	// root_path is optional, default to "." (repo root)
 	rootPath := "."
@@ -403,22 +566,7 @@ func toolGetFileTree(args map[string]interface{}) (string, error) {
 		}
 
 		rel, _ := filepath.Rel(fullPath, path)
-		// Handle root directory correctly - if rel is ".", depth should be 0
-		var depth int
-		if rel == "." {
-			depth = 0
-		} else {
-			parts := strings.Split(rel, string(filepath.Separator))
-			// Filter out empty parts (can happen with trailing separators)
-			var nonEmptyParts []string
-			for _, part := range parts {
-				if part != "" {
-					nonEmptyParts = append(nonEmptyParts, part)
-				}
-			}
-			depth = len(nonEmptyParts)
-		}
-		if depth > maxDepth {
+		if pathDepth(rel) > maxDepth {
 			return filepath.SkipDir
 		}
 
@@ -444,6 +592,352 @@ func toolGetFileTree(args map[string]interface{}) (string, error) {
 	return string(result), nil
 }
 
+// toolListChangedSince lists files under root_path modified after the RFC3339 since
+// timestamp, newest first. Like get_file_tree it skips hidden directories and honors max_depth.
+// root_path must resolve inside REPO_PATH, symlinks included.
+func toolListChangedSince(args map[string]interface{}) (string, error) {
+	sinceStr, ok := args["since"].(string)
+	if !ok || sinceStr == "" {
+		return "", fmt.Errorf("since is required")
+	}
+	since, err := time.Parse(time.RFC3339, sinceStr)
+	if err != nil {
+		return "", fmt.Errorf("since must be an RFC3339 timestamp: %w", err)
+	}
+
+	rootPath := "."
+	if rp, ok := args["root_path"].(string); ok && rp != "" {
+		rootPath = rp
+	}
+
+	maxDepth := 10
+	if md, ok := args["max_depth"].(float64); ok {
+		maxDepth = int(md)
+	}
+
+	type changedFile struct {
+		Path    string    `json:"path"`
+		ModTime time.Time `json:"mod_time"`
+	}
+	files := []changedFile{}
+
+	fullPath, err := resolveReadablePath(rootPath)
+	if err != nil {
+		return "", err
+	}
+	err = filepath.WalkDir(fullPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip hidden directories (including .git)
+		if d.IsDir() && shouldSkipDir(d.Name()) {
+			return filepath.SkipDir
+		}
+
+		rel, _ := filepath.Rel(fullPath, path)
+		if pathDepth(rel) > maxDepth {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if info.ModTime().After(since) {
+			files = append(files, changedFile{Path: rel, ModTime: info.ModTime()})
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].ModTime.Equal(files[j].ModTime) {
+			return files[i].ModTime.After(files[j].ModTime)
+		}
+		return files[i].Path < files[j].Path
+	})
+
+	result, err := json.Marshal(map[string]interface{}{
+		"since": since.Format(time.RFC3339),
+		"files": files,
+		"count": len(files),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal changed files: %w", err)
+	}
+	return string(result), nil
+}
+
+// toolGetDirectorySize sums the sizes of the regular files below path without keeping the
+// entries, so large trees stay cheap. path itself may be a symlink to a directory in the
+// repository; symlinks below it are neither followed nor counted, which also rules out
+// cycles. max_depth limits how many directory levels below path are entered, 0
+// counting only the files directly in path; depth_limited is set when it skipped any.
+func toolGetDirectorySize(args map[string]interface{}) (string, error) {
+	path, ok := args["path"].(string)
+	if !ok || path == "" {
+		return "", fmt.Errorf("path is required")
+	}
+
+	maxDepth := -1
+	if md, ok := args["max_depth"].(float64); ok {
+		if md < 0 {
+			return "", fmt.Errorf("max_depth must not be negative")
+		}
+		maxDepth = int(md)
+	}
+
+	fullPath, err := resolveReadablePath(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat '%s': %w", path, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", path)
+	}
+
+	var totalBytes int64
+	fileCount := 0
+	depthLimited := false
+	err = filepath.WalkDir(fullPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == fullPath {
+				return err
+			}
+			// Unreadable entries below the root are left out rather than failing the walk
+			return nil
+		}
+
+		if d.IsDir() {
+			if maxDepth >= 0 && p != fullPath {
+				rel, _ := filepath.Rel(fullPath, p)
+				if pathDepth(rel) > maxDepth {
+					depthLimited = true
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		fileInfo, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		totalBytes += fileInfo.Size()
+		fileCount++
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"path":        path,
+		"total_bytes": totalBytes,
+		"file_count":  fileCount,
+	}
+	if depthLimited {
+		result["depth_limited"] = true
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal directory size: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+const (
+	defaultFindContainingMaxResults = 1000
+	maxFindContainingMaxResults     = 10000
+	// maxFindContainingLineBytes is the longest line searched; a file with a longer line
+	// is searched up to that line
+	maxFindContainingLineBytes = 1 << 20
+)
+
+// toolFindFilesContaining lists the files under root_path with at least one line matching
+// pattern, in lexical order. Reading a file stops at its first match. pattern is a regular
+// expression unless literal is set. file_patterns and exclude_patterns are globs matched
+// against the file name, or against the path from root_path when they contain a slash; an
+// excluded directory is not entered. Hidden directories, symlinks and binary files are
+// skipped. The list stops after max_results files. root_path must resolve inside REPO_PATH,
+// symlinks included.
+func toolFindFilesContaining(args map[string]interface{}) (string, error) {
+	pattern, ok := args["pattern"].(string)
+	if !ok || pattern == "" {
+		return "", fmt.Errorf("pattern is required")
+	}
+	literal, _ := args["literal"].(bool)
+	caseInsensitive, _ := args["case_insensitive"].(bool)
+
+	expr := pattern
+	if literal {
+		expr = regexp.QuoteMeta(pattern)
+	}
+	if caseInsensitive {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	filePatterns := globListParam(args, "file_patterns")
+	excludePatterns := globListParam(args, "exclude_patterns")
+	for _, glob := range append(append([]string{}, filePatterns...), excludePatterns...) {
+		if _, err := filepath.Match(glob, ""); err != nil {
+			return "", fmt.Errorf("invalid glob %q: %w", glob, err)
+		}
+	}
+
+	maxResults := defaultFindContainingMaxResults
+	if mr, ok := args["max_results"].(float64); ok && mr > 0 {
+		maxResults = int(mr)
+		if maxResults > maxFindContainingMaxResults {
+			maxResults = maxFindContainingMaxResults
+		}
+	}
+
+	rootPath := "."
+	if rp, ok := args["root_path"].(string); ok && rp != "" {
+		rootPath = rp
+	}
+	fullPath, err := resolveReadablePath(rootPath)
+	if err != nil {
+		return "", err
+	}
+
+	files := []string{}
+	filesSearched := 0
+	truncated := false
+	err = filepath.WalkDir(fullPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == fullPath {
+				return err
+			}
+			return nil
+		}
+		rel, _ := filepath.Rel(fullPath, path)
+
+		if d.IsDir() {
+			if path != fullPath && (shouldSkipDir(d.Name()) || matchesGlob(rel, excludePatterns)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if len(filePatterns) > 0 && !matchesGlob(rel, filePatterns) {
+			return nil
+		}
+		if matchesGlob(rel, excludePatterns) {
+			return nil
+		}
+
+		filesSearched++
+		if !fileContainsMatch(path, re) {
+			return nil
+		}
+		if len(files) == maxResults {
+			truncated = true
+			return filepath.SkipAll
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	result, err := json.Marshal(map[string]interface{}{
+		"pattern":        pattern,
+		"files":          files,
+		"count":          len(files),
+		"files_searched": filesSearched,
+		"truncated":      truncated,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal matching files: %w", err)
+	}
+	return string(result), nil
+}
+
+// fileContainsMatch reports whether a line of the file at path matches re, reading only up
+// to the first match. Unreadable and binary files (a NUL byte in the first 8KB) never match.
+func fileContainsMatch(path string, re *regexp.Regexp) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	head, _ := reader.Peek(8000)
+	if bytes.IndexByte(head, 0) >= 0 {
+		return false
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, maxFindContainingLineBytes)
+	for scanner.Scan() {
+		if re.Match(scanner.Bytes()) {
+			return true
+		}
+	}
+	return false
+}
+
+// globListParam returns a param given as a glob string or an array of them
+func globListParam(args map[string]interface{}, key string) []string {
+	switch v := args[key].(type) {
+	case string:
+		if v != "" {
+			return []string{v}
+		}
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// matchesGlob reports whether rel matches one of the globs. Globs without a slash match the
+// base name, others the slash-separated path.
+func matchesGlob(rel string, globs []string) bool {
+	slashPath := filepath.ToSlash(rel)
+	for _, glob := range globs {
+		target := slashPath
+		if !strings.Contains(glob, "/") {
+			target = filepath.Base(rel)
+		}
+		if matched, _ := filepath.Match(glob, target); matched {
+			return true
+		}
+	}
+	return false
+}
+
 func toolFileExists(args map[string]interface{}) (string, error) {
 	path, ok := args["path"].(string)
 	if !ok {
@@ -458,10 +952,17 @@ func toolFileExists(args map[string]interface{}) (string, error) {
 		"exists": err == nil,
 	}
 
+	// Stat follows symlinks, so exists, is_file and is_directory describe the link's target
+	linkInfo, lerr := os.Lstat(fullPath)
+	isSymlink := lerr == nil && linkInfo.Mode()&os.ModeSymlink != 0
+	result["is_symlink"] = isSymlink
+
 	if err == nil {
 		result["is_file"] = !info.IsDir()
 		result["is_directory"] = info.IsDir()
 		result["resolved_path"] = fullPath
+	} else if os.IsNotExist(err) && isSymlink {
+		result["error"] = "symlink target does not exist"
 	} else if os.IsNotExist(err) {
 		result["error"] = "path does not exist"
 	} else {
@@ -476,6 +977,177 @@ func toolFileExists(args map[string]interface{}) (string, error) {
 	return string(resultJSON), nil
 }
 
+// toolResolveSymlink reports where the symlink at path points: target is the link's
+// content as written, resolved_path the absolute path after following every link on the
+// way. The link itself must be inside REPO_PATH; its target may be anywhere, and
+// inside_repo tells whether it is; for a target outside the repository only target and
+// inside_repo are returned. A dangling link has exists false and no resolved_path.
+func toolResolveSymlink(args map[string]interface{}) (string, error) {
+	path, ok := args["path"].(string)
+	if !ok || path == "" {
+		return "", fmt.Errorf("path is required")
+	}
+
+	fullPath, err := resolveContainedPath(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Lstat(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("path does not exist: %s", path)
+		}
+		return "", fmt.Errorf("failed to stat '%s': %w", path, err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return "", fmt.Errorf("not a symlink: %s", path)
+	}
+
+	target, err := os.Readlink(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read symlink '%s': %w", path, err)
+	}
+
+	result := map[string]interface{}{
+		"path":   path,
+		"target": target,
+	}
+	resolved, err := filepath.EvalSymlinks(fullPath)
+	if err != nil {
+		result["exists"] = false
+		result["error"] = err.Error()
+	} else {
+		resolved, _ = filepath.Abs(resolved)
+		repoPath := os.Getenv("REPO_PATH")
+		if repoPath != "" && !pathWithin(repoPath, resolved) {
+			// Say nothing about the filesystem outside the repository
+			result = map[string]interface{}{"target": target, "inside_repo": false}
+		} else {
+			targetInfo, statErr := os.Stat(resolved)
+			result["exists"] = statErr == nil
+			result["resolved_path"] = resolved
+			result["is_directory"] = statErr == nil && targetInfo.IsDir()
+			if repoPath != "" {
+				result["inside_repo"] = true
+			}
+		}
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// pathWithin reports whether the resolved path lies inside root, comparing against root
+// with its own symlinks resolved, e.g. a repository under a symlinked temp directory
+func pathWithin(root, resolved string) bool {
+	if realRoot, err := filepath.EvalSymlinks(root); err == nil {
+		root = realRoot
+	}
+	root, _ = filepath.Abs(root)
+	rel, err := filepath.Rel(root, resolved)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// parentWithinRepo reports whether the deepest existing directory above fullPath, with its
+// symlinks resolved, lies inside repoPath, so that creating fullPath cannot leave it
+func parentWithinRepo(repoPath, fullPath string) bool {
+	dir := filepath.Dir(fullPath)
+	for {
+		if _, err := os.Lstat(dir); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false
+	}
+	resolved, _ = filepath.Abs(resolved)
+	return pathWithin(repoPath, resolved)
+}
+
+// allowWriteEnv must be "true" for write_file to modify files
+const allowWriteEnv = "MCP_FILESYSTEM_ALLOW_WRITE"
+
+// toolWriteFile writes content to path inside REPO_PATH, creating parent directories.
+// An existing file is only replaced when overwrite is true. It is disabled unless
+// MCP_FILESYSTEM_ALLOW_WRITE=true.
+func toolWriteFile(args map[string]interface{}) (string, error) {
+	if os.Getenv(allowWriteEnv) != "true" {
+		return "", fmt.Errorf("write operations disabled: set %s=true to enable write_file", allowWriteEnv)
+	}
+	if os.Getenv("REPO_PATH") == "" {
+		return "", fmt.Errorf("REPO_PATH not set")
+	}
+
+	path, ok := args["path"].(string)
+	if !ok || path == "" {
+		return "", fmt.Errorf("path is required")
+	}
+	content, ok := args["content"].(string)
+	if !ok {
+		return "", fmt.Errorf("content is required")
+	}
+	overwrite, _ := args["overwrite"].(bool)
+
+	fullPath, err := resolveContainedPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	created := true
+	mode := os.FileMode(0644)
+	if info, err := os.Lstat(fullPath); err == nil {
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			// The link target may lie outside the repository
+			return "", fmt.Errorf("path is a symlink: %s", path)
+		case info.IsDir():
+			return "", fmt.Errorf("path is a directory: %s", path)
+		case !overwrite:
+			return "", fmt.Errorf("file already exists: %s (set overwrite to replace it)", path)
+		}
+		created = false
+		mode = info.Mode().Perm()
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	// A symlinked directory further up the path may lead outside the repository
+	if !parentWithinRepo(os.Getenv("REPO_PATH"), fullPath) {
+		return "", fmt.Errorf("path %s is outside repository", path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(fullPath, []byte(content), mode); err != nil {
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+
+	message := fmt.Sprintf("File created successfully: %s", path)
+	if !created {
+		message = fmt.Sprintf("File overwritten successfully: %s", path)
+	}
+	result, err := json.Marshal(map[string]interface{}{
+		"message":       message,
+		"path":          path,
+		"created":       created,
+		"bytes_written": len(content),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return string(result), nil
+}
+
 func toolCreateDirectory(args map[string]interface{}) (string, error) {
 	path, ok := args["path"].(string)
 	if !ok {
@@ -514,6 +1186,42 @@ func toolCreateDirectory(args map[string]interface{}) (string, error) {
 	return string(result), nil
 }
 
+// resolveContainedPath resolves path like resolvePath, rejecting paths that end up
+// outside REPO_PATH when it is set
+func resolveContainedPath(path string) (string, error) {
+	fullPath := resolvePath(path)
+
+	if repoPath := os.Getenv("REPO_PATH"); repoPath != "" {
+		relPath, err := filepath.Rel(repoPath, fullPath)
+		if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("path %s is outside repository", path)
+		}
+	}
+	return fullPath, nil
+}
+
+// resolveReadablePath resolves path like resolveContainedPath, then follows its symlinks,
+// since reading and walking follow them too. When REPO_PATH is set a path whose symlinks
+// lead outside it is rejected. The resolved path is returned, so a walk starting at a
+// symlinked directory enters it; a path that does not resolve is returned as is, for the
+// caller to report it missing.
+func resolveReadablePath(path string) (string, error) {
+	fullPath, err := resolveContainedPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := filepath.EvalSymlinks(fullPath)
+	if err != nil {
+		return fullPath, nil
+	}
+	resolved, _ = filepath.Abs(resolved)
+	if repoPath := os.Getenv("REPO_PATH"); repoPath != "" && !pathWithin(repoPath, resolved) {
+		return "", fmt.Errorf("path %s is outside repository", path)
+	}
+	return resolved, nil
+}
+
 func resolvePath(path string) string {
 	repoPath := os.Getenv("REPO_PATH")
 	if repoPath == "" {
@@ -540,55 +1248,15 @@ func sendError(encoder *json.Encoder, id interface{}, code int, message string,
 	encoder.Encode(response)
 }
 
-// MCP types (duplicated from internal/mcp for standalone server)
-type MCPMessage struct {
-	JSONRPC string          `json:"jsonrpc"`
-	ID      interface{}     `json:"id,omitempty"`
-	Method  string          `json:"method,omitempty"`
-	Params  json.RawMessage `json:"params,omitempty"`
-	Result  interface{}     `json:"result,omitempty"`
-	Error   *MCPError       `json:"error,omitempty"`
-}
-
-type MCPError struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-}
-
-type InitializeResponse struct {
-	ProtocolVersion string                 `json:"protocolVersion"`
-	Capabilities    map[string]interface{} `json:"capabilities"`
-	ServerInfo      ServerInfo             `json:"serverInfo"`
-}
-
-type ServerInfo struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
-}
-
-type Tool struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	InputSchema map[string]interface{} `json:"inputSchema"`
-}
-
-type ToolsListResponse struct {
-	Tools []Tool `json:"tools"`
-}
-
-type ToolsCallRequest struct {
-	Name      string                 `json:"name"`
-	Arguments map[string]interface{} `json:"arguments,omitempty"`
-}
-
-type ToolsCallResponse struct {
-	Content []Content `json:"content"`
-	IsError bool      `json:"isError,omitempty"`
-}
-
-type Content struct {
-	Type string `json:"type"`
-	Text string `json:"text,omitempty"`
-}
-
+// MCP protocol types, shared with the other servers
+type (
+	MCPMessage         = mcp.Message
+	MCPError           = mcp.Error
+	InitializeResponse = mcp.InitializeResponse
+	ServerInfo         = mcp.ServerInfo
+	Tool               = mcp.Tool
+	ToolsListResponse  = mcp.ToolsListResponse
+	ToolsCallRequest   = mcp.ToolsCallRequest
+	ToolsCallResponse  = mcp.ToolsCallResponse
+	Content            = mcp.Content
+)
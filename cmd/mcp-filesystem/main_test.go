@@ -0,0 +1,697 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestListChangedSince tests that only files modified after since are listed
+func TestListChangedSince(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	old := time.Now().Add(-2 * time.Hour)
+	for _, name := range []string{"a.txt", "src/b.go", "src/c.go", ".git/HEAD"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatalf("Failed to set mod time of %s: %v", name, err)
+		}
+	}
+
+	// Touch one file and a hidden one, which must still be skipped
+	now := time.Now().Truncate(time.Second)
+	for _, name := range []string{"src/b.go", ".git/HEAD"} {
+		if err := os.Chtimes(filepath.Join(dir, name), now, now); err != nil {
+			t.Fatalf("Failed to touch %s: %v", name, err)
+		}
+	}
+
+	result, err := toolListChangedSince(map[string]interface{}{
+		"since": now.Add(-time.Hour).Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("toolListChangedSince() error = %v", err)
+	}
+
+	var parsed struct {
+		Files []struct {
+			Path    string    `json:"path"`
+			ModTime time.Time `json:"mod_time"`
+		} `json:"files"`
+		Count int `json:"count"`
+	}
+	if err := json.Unmarshal([]byte(result), &parsed); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if parsed.Count != 1 || len(parsed.Files) != 1 || parsed.Files[0].Path != filepath.Join("src", "b.go") {
+		t.Fatalf("Expected only src/b.go, got %s", result)
+	}
+	if !parsed.Files[0].ModTime.Equal(now) {
+		t.Errorf("Expected mod time %v, got %v", now, parsed.Files[0].ModTime)
+	}
+
+	// max_depth 1 only looks at the root, where nothing changed
+	result, err = toolListChangedSince(map[string]interface{}{
+		"since":     now.Add(-time.Hour).Format(time.RFC3339),
+		"max_depth": 1.0,
+	})
+	if err != nil {
+		t.Fatalf("toolListChangedSince(max_depth) error = %v", err)
+	}
+	if err := json.Unmarshal([]byte(result), &parsed); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if parsed.Count != 0 {
+		t.Errorf("Expected no changes within depth 1, got %s", result)
+	}
+
+	if _, err := toolListChangedSince(map[string]interface{}{"since": "yesterday"}); err == nil {
+		t.Error("Expected error for a non-RFC3339 since")
+	}
+
+	// root_path must stay inside the repository, also through a symlink
+	if err := os.Symlink(t.TempDir(), filepath.Join(dir, "outside-link")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	for _, rootPath := range []string{"/", "../..", "outside-link"} {
+		_, err := toolListChangedSince(map[string]interface{}{"since": now.Add(-time.Hour).Format(time.RFC3339), "root_path": rootPath})
+		if err == nil || !strings.Contains(err.Error(), "outside repository") {
+			t.Errorf("Expected root_path %s to be refused as outside the repository, got %v", rootPath, err)
+		}
+	}
+}
+
+// TestReadMultipleFiles tests that missing, oversized and out-of-repo files fail individually
+func TestReadMultipleFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("alpha"), 0644); err != nil {
+		t.Fatalf("Failed to write a.txt: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "big.bin"), make([]byte, maxMultiReadFileBytes+1), 0644); err != nil {
+		t.Fatalf("Failed to write big.bin: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "empty.txt"), nil, 0644); err != nil {
+		t.Fatalf("Failed to write empty.txt: %v", err)
+	}
+
+	result, err := toolReadMultipleFiles(map[string]interface{}{
+		"paths": []interface{}{"a.txt", "empty.txt", "missing.txt", "big.bin", "../outside.txt"},
+	})
+	if err != nil {
+		t.Fatalf("toolReadMultipleFiles() error = %v", err)
+	}
+
+	var files map[string]struct {
+		Content *string `json:"content"`
+		SHA256  string  `json:"sha256"`
+		Error   string  `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(result), &files); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+
+	if len(files) != 5 {
+		t.Fatalf("Expected an entry per path, got %s", result)
+	}
+	if f := files["a.txt"]; f.Content == nil || *f.Content != "alpha" || f.Error != "" {
+		t.Errorf("Expected a.txt content, got %+v", f)
+	}
+	if f := files["a.txt"]; f.SHA256 != "8ed3f6ad685b959ead7022518e1af76cd816f8e8ec7ccdda1ed4018e8f2223f8" {
+		t.Errorf("Expected the sha256 of a.txt, got %q", f.SHA256)
+	}
+	if f := files["empty.txt"]; f.Content == nil || *f.Content != "" {
+		t.Errorf("Expected empty content for empty.txt, got %+v", f)
+	}
+	for _, path := range []string{"missing.txt", "big.bin", "../outside.txt"} {
+		if f := files[path]; f.Content != nil || f.Error == "" {
+			t.Errorf("Expected an error for %s, got %+v", path, f)
+		}
+	}
+
+	if _, err := toolReadMultipleFiles(map[string]interface{}{"paths": []interface{}{}}); err == nil {
+		t.Error("Expected error for empty paths")
+	}
+}
+
+// TestReadFileSHA256 tests that read_file returns the content alone by default and with
+// its sha256 when asked
+func TestReadFileSHA256(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("alpha"), 0644); err != nil {
+		t.Fatalf("Failed to write a.txt: %v", err)
+	}
+
+	result, err := toolReadFile(map[string]interface{}{"path": "a.txt"})
+	if err != nil || result != "alpha" {
+		t.Fatalf("toolReadFile() = %q, %v; want the content", result, err)
+	}
+
+	result, err = toolReadFile(map[string]interface{}{"path": "a.txt", "with_sha256": true})
+	if err != nil {
+		t.Fatalf("toolReadFile() error = %v", err)
+	}
+	var decoded struct {
+		Content string `json:"content"`
+		SHA256  string `json:"sha256"`
+	}
+	if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if decoded.Content != "alpha" || decoded.SHA256 != "8ed3f6ad685b959ead7022518e1af76cd816f8e8ec7ccdda1ed4018e8f2223f8" {
+		t.Errorf("Unexpected result %+v", decoded)
+	}
+}
+
+// TestWriteFile tests creating, overwriting and refusing writes
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	t.Run("Blocked when flag unset", func(t *testing.T) {
+		t.Setenv(allowWriteEnv, "")
+		_, err := toolWriteFile(map[string]interface{}{"path": "blocked.txt", "content": "x"})
+		if err == nil || !strings.Contains(err.Error(), allowWriteEnv) {
+			t.Fatalf("Expected an error naming %s, got %v", allowWriteEnv, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "blocked.txt")); !os.IsNotExist(err) {
+			t.Error("Expected blocked.txt not to be written")
+		}
+	})
+
+	t.Setenv(allowWriteEnv, "true")
+
+	t.Run("Write new file", func(t *testing.T) {
+		result, err := toolWriteFile(map[string]interface{}{"path": "nested/dir/new.txt", "content": "hello\n"})
+		if err != nil {
+			t.Fatalf("toolWriteFile() error = %v", err)
+		}
+		var decoded map[string]interface{}
+		if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+			t.Fatalf("Failed to parse result: %v", err)
+		}
+		if decoded["created"] != true || decoded["bytes_written"] != float64(6) {
+			t.Errorf("Unexpected result %v", decoded)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "nested/dir/new.txt"))
+		if err != nil || string(data) != "hello\n" {
+			t.Errorf("Expected new.txt to contain hello, got %q (%v)", data, err)
+		}
+	})
+
+	t.Run("Overwrite", func(t *testing.T) {
+		path := filepath.Join(dir, "script.sh")
+		if err := os.WriteFile(path, []byte("old\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := toolWriteFile(map[string]interface{}{"path": "script.sh", "content": "new\n"}); err == nil {
+			t.Error("Expected an error without overwrite")
+		}
+		if _, err := toolWriteFile(map[string]interface{}{"path": "script.sh", "content": "new\n", "overwrite": true}); err != nil {
+			t.Fatalf("toolWriteFile() error = %v", err)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, _ := os.ReadFile(path); string(data) != "new\n" {
+			t.Errorf("Expected script.sh to be overwritten, got %q", data)
+		}
+		if info.Mode().Perm() != 0755 {
+			t.Errorf("Expected permissions to be kept, got %v", info.Mode().Perm())
+		}
+	})
+
+	t.Run("Outside repository", func(t *testing.T) {
+		for _, path := range []string{"../escape.txt", filepath.Join(t.TempDir(), "abs.txt")} {
+			if _, err := toolWriteFile(map[string]interface{}{"path": path, "content": "x"}); err == nil || !strings.Contains(err.Error(), "outside repository") {
+				t.Errorf("Expected %s to be rejected as outside the repository, got %v", path, err)
+			}
+		}
+	})
+
+	t.Run("Symlinked directory in the path", func(t *testing.T) {
+		outside := t.TempDir()
+		if err := os.Symlink(outside, filepath.Join(dir, "link")); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+		for _, path := range []string{"link/pwned.txt", "link/sub/pwned.txt"} {
+			if _, err := toolWriteFile(map[string]interface{}{"path": path, "content": "x"}); err == nil || !strings.Contains(err.Error(), "outside repository") {
+				t.Errorf("Expected %s to be rejected as outside the repository, got %v", path, err)
+			}
+		}
+		if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+			t.Errorf("Expected nothing written outside the repository, found %d entries", len(entries))
+		}
+	})
+}
+
+// tailFile runs tail_file and decodes its result; TotalLines is -1 when it is omitted
+func tailFile(t *testing.T, path string, lines int) ([]string, int) {
+	t.Helper()
+	decodedLines, total, _ := tailFileTruncated(t, path, lines)
+	return decodedLines, total
+}
+
+// tailFileTruncated is tailFile that also returns the truncated flag
+func tailFileTruncated(t *testing.T, path string, lines int) ([]string, int, bool) {
+	t.Helper()
+	result, err := toolTailFile(map[string]interface{}{"path": path, "lines": float64(lines)})
+	if err != nil {
+		t.Fatalf("toolTailFile(%s) error = %v", path, err)
+	}
+	decoded := struct {
+		Lines      []string `json:"lines"`
+		Count      int      `json:"count"`
+		Truncated  bool     `json:"truncated"`
+		TotalLines int      `json:"total_lines"`
+	}{TotalLines: -1}
+	if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if decoded.Count != len(decoded.Lines) {
+		t.Errorf("count = %d, but %d lines returned", decoded.Count, len(decoded.Lines))
+	}
+	return decoded.Lines, decoded.TotalLines, decoded.Truncated
+}
+
+// TestTailFile tests the last lines of a file spanning many read chunks and of short files
+func TestTailFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	var large strings.Builder
+	for i := 1; i <= 50000; i++ {
+		fmt.Fprintf(&large, "log line %d\n", i)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "app.log"), []byte(large.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lines, total := tailFile(t, "app.log", 3)
+	if want := []string{"log line 49998", "log line 49999", "log line 50000"}; strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("Last lines = %q, want %q", lines, want)
+	}
+	if total != -1 {
+		t.Errorf("Expected total_lines to be omitted for a large file, got %d", total)
+	}
+
+	// More lines than fit in one chunk
+	lines, _ = tailFile(t, "app.log", 8000)
+	if len(lines) != 8000 || lines[0] != "log line 42001" || lines[7999] != "log line 50000" {
+		t.Errorf("Expected lines 42001 to 50000, got %d lines from %q to %q", len(lines), lines[0], lines[len(lines)-1])
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "short.txt"), []byte("one\r\ntwo\r\nthree"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lines, total = tailFile(t, "short.txt", 50)
+	if strings.Join(lines, "|") != "one|two|three" || total != 3 {
+		t.Errorf("Expected all 3 lines of the short file, got %q (total %d)", lines, total)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "empty.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if lines, total = tailFile(t, "empty.txt", 10); len(lines) != 0 || total != 0 {
+		t.Errorf("Expected no lines for an empty file, got %q (total %d)", lines, total)
+	}
+
+	// One line longer than maxTailBytes is cut to the bytes read
+	long := "first\n" + strings.Repeat("x", maxTailBytes+100) + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "long.txt"), []byte(long), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lines, total, truncated := tailFileTruncated(t, "long.txt", 2)
+	if !truncated || len(lines) != 1 || len(lines[0]) != maxTailBytes || total != -1 {
+		t.Errorf("Expected one line cut to %d bytes and truncated, got %d lines (truncated %v, total %d)", maxTailBytes, len(lines), truncated, total)
+	}
+	if _, _, truncated = tailFileTruncated(t, "app.log", 3); truncated {
+		t.Error("Expected a short tail not to be truncated")
+	}
+
+	if _, err := toolTailFile(map[string]interface{}{"path": "../outside.log"}); err == nil {
+		t.Error("Expected an error for a path outside the repository")
+	}
+}
+
+// TestGetDirectorySize tests summing file sizes, max_depth and that symlinks are not followed
+func TestGetDirectorySize(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	files := map[string]int{
+		"root.bin":              100,
+		".git/objects/pack.bin": 1000,
+		"src/a.go":              10,
+		"src/deep/b.go":         20,
+		"src/deep/deeper/c.go":  30,
+	}
+	for name, size := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	// A link back to the root would loop forever if followed, and a file link would count twice
+	if err := os.Symlink(dir, filepath.Join(dir, "src", "loop")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "root.bin"), filepath.Join(dir, "src", "root-link.bin")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	// A linked directory inside the repository is measured; one leading outside it is refused
+	outside := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(outside, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "sub", "creds.txt"), make([]byte, 17), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for link, target := range map[string]string{"src-link": "src", "outside-link": outside} {
+		if err := os.Symlink(target, filepath.Join(dir, link)); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		args         map[string]interface{}
+		wantBytes    int64
+		wantFiles    int
+		depthLimited bool
+	}{
+		{"whole tree", map[string]interface{}{"path": "."}, 1160, 5, false},
+		{"subdirectory", map[string]interface{}{"path": "src"}, 60, 3, false},
+		{"max_depth 1", map[string]interface{}{"path": "src", "max_depth": float64(1)}, 30, 2, true},
+		{"max_depth 0", map[string]interface{}{"path": "src", "max_depth": float64(0)}, 10, 1, true},
+		{"max_depth covering the tree", map[string]interface{}{"path": "src", "max_depth": float64(2)}, 60, 3, false},
+		{"symlinked directory", map[string]interface{}{"path": "src-link"}, 60, 3, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := toolGetDirectorySize(tt.args)
+			if err != nil {
+				t.Fatalf("toolGetDirectorySize() error = %v", err)
+			}
+			var decoded struct {
+				TotalBytes   int64 `json:"total_bytes"`
+				FileCount    int   `json:"file_count"`
+				DepthLimited bool  `json:"depth_limited"`
+			}
+			if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+				t.Fatalf("Failed to parse result: %v", err)
+			}
+			if decoded.TotalBytes != tt.wantBytes || decoded.FileCount != tt.wantFiles || decoded.DepthLimited != tt.depthLimited {
+				t.Errorf("Expected %d bytes in %d files (depth_limited %v), got %s", tt.wantBytes, tt.wantFiles, tt.depthLimited, result)
+			}
+		})
+	}
+
+	for _, args := range []map[string]interface{}{
+		{},
+		{"path": "root.bin"},
+		{"path": "missing"},
+		{"path": "../"},
+		{"path": "outside-link"},
+		{"path": "outside-link/sub"},
+		{"path": "src", "max_depth": float64(-1)},
+	} {
+		if _, err := toolGetDirectorySize(args); err == nil {
+			t.Errorf("Expected an error for %v", args)
+		}
+	}
+}
+
+// TestFindFilesContaining tests that only the files with a matching line are listed, and
+// that the literal, case_insensitive, file_patterns and exclude_patterns params apply
+func TestFindFilesContaining(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	files := map[string]string{
+		"server.go":           "package main\n\n// TODO: handle shutdown\nfunc serve() {}\n",
+		"client.go":           "package main\n\nfunc dial() {}\n",
+		"docs/notes.md":       "todo: write docs\nRegexp a.b here\n",
+		"build/generated.go":  "// TODO: regenerate\n",
+		".git/hooks/pre-push": "# TODO: hidden\n",
+		"data/blob.bin":       "\x00TODO: binary\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		want []string
+	}{
+		{"regex", map[string]interface{}{"pattern": `TODO:\s+\w+`}, []string{"build/generated.go", "server.go"}},
+		{"case insensitive", map[string]interface{}{"pattern": "todo:", "case_insensitive": true}, []string{"build/generated.go", "docs/notes.md", "server.go"}},
+		{"file patterns", map[string]interface{}{"pattern": "TODO", "case_insensitive": true, "file_patterns": []interface{}{"*.md"}}, []string{"docs/notes.md"}},
+		{"exclude directory", map[string]interface{}{"pattern": "TODO", "exclude_patterns": "build"}, []string{"server.go"}},
+		{"exclude path", map[string]interface{}{"pattern": "TODO", "exclude_patterns": []interface{}{"build/*.go"}}, []string{"server.go"}},
+		{"literal", map[string]interface{}{"pattern": "a.b", "literal": true}, []string{"docs/notes.md"}},
+		{"regex metacharacters", map[string]interface{}{"pattern": "d.al"}, []string{"client.go"}},
+		{"root path", map[string]interface{}{"pattern": "TODO", "root_path": "build"}, []string{"generated.go"}},
+		{"no match", map[string]interface{}{"pattern": "nothing matches this"}, []string{}},
+		{"max results", map[string]interface{}{"pattern": "TODO", "max_results": float64(1)}, []string{"build/generated.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := toolFindFilesContaining(tt.args)
+			if err != nil {
+				t.Fatalf("toolFindFilesContaining() error = %v", err)
+			}
+			var got struct {
+				Files     []string `json:"files"`
+				Count     int      `json:"count"`
+				Truncated bool     `json:"truncated"`
+			}
+			if err := json.Unmarshal([]byte(result), &got); err != nil {
+				t.Fatalf("Failed to parse result: %v", err)
+			}
+			if strings.Join(got.Files, ",") != strings.Join(tt.want, ",") || got.Count != len(tt.want) {
+				t.Errorf("files = %v (count %d), want %v", got.Files, got.Count, tt.want)
+			}
+			if wantTruncated := tt.name == "max results"; got.Truncated != wantTruncated {
+				t.Errorf("truncated = %v, want %v", got.Truncated, wantTruncated)
+			}
+		})
+	}
+
+	for _, args := range []map[string]interface{}{
+		{},
+		{"pattern": "("},
+		{"pattern": "TODO", "file_patterns": "["},
+		{"pattern": "TODO", "root_path": "../"},
+	} {
+		if _, err := toolFindFilesContaining(args); err == nil {
+			t.Errorf("Expected an error for %v", args)
+		}
+	}
+}
+
+// TestFindFilesContainingOutsideSymlink tests that a root_path reached through a symlinked
+// directory leading outside the repository is not searched
+func TestFindFilesContainingOutsideSymlink(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	if err := os.MkdirAll(filepath.Join(outside, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "sub", "creds.txt"), []byte("SECRET=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "link")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	for _, rootPath := range []string{"link", "link/sub"} {
+		result, err := toolFindFilesContaining(map[string]interface{}{"pattern": "SECRET", "root_path": rootPath})
+		if err == nil || !strings.Contains(err.Error(), "outside repository") {
+			t.Errorf("Expected root_path %s to be refused as outside the repository, got %s (err %v)", rootPath, result, err)
+		}
+	}
+}
+
+// TestSymlinks tests that file_exists and list_directory flag symlinks, and that
+// resolve_symlink reports targets inside and outside the repository
+func TestSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	if err := os.MkdirAll(filepath.Join(dir, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "docs", "guide.md"), []byte("guide\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for link, target := range map[string]string{
+		"guide-link":   "docs/guide.md",
+		"docs-link":    "docs",
+		"outside-link": filepath.Join(outside, "secret.txt"),
+		"dangling":     "missing.txt",
+	} {
+		if err := os.Symlink(target, filepath.Join(dir, link)); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+	}
+
+	fileExists := func(path string) map[string]interface{} {
+		t.Helper()
+		result, err := toolFileExists(map[string]interface{}{"path": path})
+		if err != nil {
+			t.Fatalf("toolFileExists(%s) error = %v", path, err)
+		}
+		var decoded map[string]interface{}
+		if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+			t.Fatalf("Failed to parse result: %v", err)
+		}
+		return decoded
+	}
+	if got := fileExists("guide-link"); got["is_symlink"] != true || got["exists"] != true || got["is_file"] != true {
+		t.Errorf("Expected guide-link to be a symlink to a file, got %v", got)
+	}
+	if got := fileExists("docs/guide.md"); got["is_symlink"] != false || got["is_file"] != true {
+		t.Errorf("Expected docs/guide.md to be a regular file, got %v", got)
+	}
+	if got := fileExists("dangling"); got["is_symlink"] != true || got["exists"] != false {
+		t.Errorf("Expected dangling to be a symlink whose target is missing, got %v", got)
+	}
+
+	result, err := toolListDirectory(map[string]interface{}{"path": ".", "details": true})
+	if err != nil {
+		t.Fatalf("toolListDirectory() error = %v", err)
+	}
+	var entries []struct {
+		Name      string `json:"name"`
+		IsDir     bool   `json:"is_dir"`
+		IsSymlink bool   `json:"is_symlink"`
+	}
+	if err := json.Unmarshal([]byte(result), &entries); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	kinds := map[string]string{}
+	for _, e := range entries {
+		kinds[e.Name] = fmt.Sprintf("dir=%v link=%v", e.IsDir, e.IsSymlink)
+	}
+	if kinds["docs"] != "dir=true link=false" || kinds["docs-link"] != "dir=false link=true" || kinds["guide-link"] != "dir=false link=true" {
+		t.Errorf("Unexpected entries %v", kinds)
+	}
+
+	resolve := func(path string) map[string]interface{} {
+		t.Helper()
+		result, err := toolResolveSymlink(map[string]interface{}{"path": path})
+		if err != nil {
+			t.Fatalf("toolResolveSymlink(%s) error = %v", path, err)
+		}
+		var decoded map[string]interface{}
+		if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+			t.Fatalf("Failed to parse result: %v", err)
+		}
+		return decoded
+	}
+	inside := resolve("docs-link")
+	if inside["target"] != "docs" || inside["inside_repo"] != true || inside["is_directory"] != true || inside["exists"] != true {
+		t.Errorf("Expected docs-link to resolve inside the repository, got %v", inside)
+	}
+	if resolved, _ := inside["resolved_path"].(string); !strings.HasSuffix(resolved, string(filepath.Separator)+"docs") {
+		t.Errorf("Expected an absolute resolved_path ending in docs, got %v", inside["resolved_path"])
+	}
+	if out := resolve("outside-link"); out["inside_repo"] != false || out["target"] != filepath.Join(outside, "secret.txt") || len(out) != 2 {
+		t.Errorf("Expected only target and inside_repo for outside-link, got %v", out)
+	}
+	if dangling := resolve("dangling"); dangling["exists"] != false || dangling["target"] != "missing.txt" {
+		t.Errorf("Expected dangling to report its missing target, got %v", dangling)
+	}
+
+	for _, path := range []string{"docs/guide.md", "missing", "../elsewhere"} {
+		if _, err := toolResolveSymlink(map[string]interface{}{"path": path}); err == nil {
+			t.Errorf("Expected an error resolving %s", path)
+		}
+	}
+}
+
+// TestReadOutsideSymlink tests that a file reached through a symlink leading outside the
+// repository is not read, while one leading inside it still is
+func TestReadOutsideSymlink(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	t.Setenv("REPO_PATH", dir)
+
+	if err := os.WriteFile(filepath.Join(dir, "guide.md"), []byte("guide\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for link, target := range map[string]string{
+		"guide-link":   "guide.md",
+		"outside-link": filepath.Join(outside, "secret.txt"),
+		"outside-dir":  outside,
+	} {
+		if err := os.Symlink(target, filepath.Join(dir, link)); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+	}
+
+	result, err := toolReadMultipleFiles(map[string]interface{}{
+		"paths": []interface{}{"guide-link", "outside-link", "outside-dir/secret.txt"},
+	})
+	if err != nil {
+		t.Fatalf("toolReadMultipleFiles() error = %v", err)
+	}
+	if strings.Contains(result, `"secret\n"`) {
+		t.Errorf("Expected no content from outside the repository, got %s", result)
+	}
+	var files map[string]struct {
+		Content *string `json:"content"`
+		Error   string  `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(result), &files); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if f := files["guide-link"]; f.Content == nil || *f.Content != "guide\n" {
+		t.Errorf("Expected guide-link to be read, got %+v", f)
+	}
+	for _, path := range []string{"outside-link", "outside-dir/secret.txt"} {
+		if f := files[path]; f.Content != nil || !strings.Contains(f.Error, "outside repository") {
+			t.Errorf("Expected %s to be refused as outside the repository, got %+v", path, f)
+		}
+		if _, err := toolTailFile(map[string]interface{}{"path": path}); err == nil || !strings.Contains(err.Error(), "outside repository") {
+			t.Errorf("Expected tail_file to refuse %s, got %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// AuthorShare is the number and percentage of a file's lines last changed by an author
+type AuthorShare struct {
+	Author  string  `json:"author"`
+	Email   string  `json:"email"`
+	Lines   int     `json:"lines"`
+	Percent float64 `json:"percent"`
+}
+
+// toolGetAuthorship blames file_path as of HEAD and reports how many of its lines each
+// author last changed, most lines first. Percentages are of the file's line count, rounded
+// to two decimals.
+func toolGetAuthorship(args map[string]interface{}) (string, error) {
+	repoPath := os.Getenv("REPO_PATH")
+	if repoPath == "" {
+		return "", fmt.Errorf("REPO_PATH not set")
+	}
+
+	filePath, _ := args["file_path"].(string)
+	if filePath == "" {
+		return "", fmt.Errorf("file_path is required")
+	}
+	relPath, err := repoRelativePath(repoPath, filePath)
+	if err != nil {
+		return "", fmt.Errorf("invalid file_path: %w", err)
+	}
+
+	// Blame fails with a confusing message for paths HEAD does not have, so check first
+	if _, err := runGitCommand(repoPath, "rev-parse", "--verify", "--quiet", "HEAD:"+relPath); err != nil {
+		return "", fmt.Errorf("file %s is not tracked in HEAD", filePath)
+	}
+
+	output, err := runGitCommand(repoPath, "blame", "--line-porcelain", "HEAD", "--", relPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to blame %s: %w", filePath, err)
+	}
+
+	authors, total := parseBlameAuthorship(output)
+	resultJSON, err := json.Marshal(map[string]interface{}{
+		"file_path":   filePath,
+		"authors":     authors,
+		"total_lines": total,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal authorship: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// parseBlameAuthorship counts the lines of git blame --line-porcelain output per author,
+// keyed by name and email, and returns them sorted by lines descending and then by name,
+// with the total line count
+func parseBlameAuthorship(output string) ([]AuthorShare, int) {
+	type authorKey struct{ name, email string }
+	counts := make(map[authorKey]int)
+	total := 0
+
+	// --line-porcelain repeats the author headers for every line, and each line's content
+	// follows its headers prefixed with a tab
+	var name string
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case strings.HasPrefix(line, "author "):
+			name = strings.TrimPrefix(line, "author ")
+		case strings.HasPrefix(line, "author-mail "):
+			email := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(line, "author-mail "), "<"), ">")
+			counts[authorKey{name, email}]++
+			total++
+		}
+	}
+
+	authors := []AuthorShare{}
+	for key, lines := range counts {
+		authors = append(authors, AuthorShare{
+			Author:  key.name,
+			Email:   key.email,
+			Lines:   lines,
+			Percent: math.Round(float64(lines)*10000/float64(total)) / 100,
+		})
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		if authors[i].Lines != authors[j].Lines {
+			return authors[i].Lines > authors[j].Lines
+		}
+		if authors[i].Author != authors[j].Author {
+			return authors[i].Author < authors[j].Author
+		}
+		return authors[i].Email < authors[j].Email
+	})
+	return authors, total
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestToolGetAuthorship(t *testing.T) {
+	tmpDir := t.TempDir()
+	runGit(t, tmpDir, "init", "-b", "main")
+	runGit(t, tmpDir, "config", "user.email", "test@example.com")
+	runGit(t, tmpDir, "config", "user.name", "Test User")
+	t.Setenv("REPO_PATH", tmpDir)
+
+	// Alice writes four lines, then Bob rewrites one of them and appends two more
+	commitFileAs(t, tmpDir, "Alice", "alice@example.com", "notes.txt", "a1\na2\na3\na4\n")
+	commitFileAs(t, tmpDir, "Bob", "bob@example.com", "notes.txt", "a1\nb1\na3\na4\nb2\nb3\n")
+
+	resultJSON, err := toolGetAuthorship(map[string]interface{}{"file_path": "notes.txt"})
+	if err != nil {
+		t.Fatalf("toolGetAuthorship returned error: %v", err)
+	}
+	var result struct {
+		Authors    []AuthorShare `json:"authors"`
+		TotalLines int           `json:"total_lines"`
+	}
+	if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
+		t.Fatalf("failed to parse result JSON: %v", err)
+	}
+
+	want := []AuthorShare{
+		{Author: "Alice", Email: "alice@example.com", Lines: 3, Percent: 50},
+		{Author: "Bob", Email: "bob@example.com", Lines: 3, Percent: 50},
+	}
+	if result.TotalLines != 6 || len(result.Authors) != len(want) || result.Authors[0] != want[0] || result.Authors[1] != want[1] {
+		t.Fatalf("expected %+v over 6 lines, got %+v over %d", want, result.Authors, result.TotalLines)
+	}
+
+	commitFileAs(t, tmpDir, "Bob", "bob@example.com", "notes.txt", "a1\nb1\na3\na4\nb2\nb3\nb4\nb5\nb6\n")
+	resultJSON, err = toolGetAuthorship(map[string]interface{}{"file_path": "notes.txt"})
+	if err != nil {
+		t.Fatalf("toolGetAuthorship returned error: %v", err)
+	}
+	if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
+		t.Fatalf("failed to parse result JSON: %v", err)
+	}
+	if len(result.Authors) != 2 || result.Authors[0].Author != "Bob" || result.Authors[0].Lines != 6 || result.Authors[0].Percent != 66.67 || result.Authors[1].Percent != 33.33 {
+		t.Errorf("expected Bob with 6 lines (66.67%%) first, got %+v", result.Authors)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "scratch.txt"), []byte("draft\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := toolGetAuthorship(map[string]interface{}{"file_path": "scratch.txt"}); err == nil || !strings.Contains(err.Error(), "not tracked") {
+		t.Errorf("expected a not tracked error for an untracked file, got %v", err)
+	}
+	if _, err := toolGetAuthorship(map[string]interface{}{"file_path": "../outside.txt"}); err == nil {
+		t.Error("expected an error for a path outside the repository")
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// toolGetBranchStatus returns the merge base of two branches and how many commits the
+// target branch is ahead of and behind the base branch
+func toolGetBranchStatus(args map[string]interface{}) (string, error) {
+	repoPath := os.Getenv("REPO_PATH")
+	if repoPath == "" {
+		return "", fmt.Errorf("REPO_PATH not set")
+	}
+
+	baseBranch := "main"
+	if bb, ok := args["base_branch"].(string); ok && bb != "" {
+		baseBranch = bb
+	}
+	targetBranch := "HEAD"
+	if tb, ok := args["target_branch"].(string); ok && tb != "" {
+		targetBranch = tb
+	}
+
+	if err := validateRefName(baseBranch); err != nil {
+		return "", fmt.Errorf("invalid base_branch: %w", err)
+	}
+	if err := validateRefName(targetBranch); err != nil {
+		return "", fmt.Errorf("invalid target_branch: %w", err)
+	}
+
+	mergeBase, err := runGitCommand(repoPath, "merge-base", baseBranch, targetBranch)
+	if err != nil {
+		return "", fmt.Errorf("failed to find merge base of %s and %s: %w", baseBranch, targetBranch, err)
+	}
+
+	// Left counts commits only on base (behind), right counts commits only on target (ahead)
+	counts, err := runGitCommand(repoPath, "rev-list", "--left-right", "--count", baseBranch+"..."+targetBranch)
+	if err != nil {
+		return "", fmt.Errorf("failed to count commits between %s and %s: %w", baseBranch, targetBranch, err)
+	}
+	behind, ahead, err := parseLeftRightCount(counts)
+	if err != nil {
+		return "", err
+	}
+
+	resultJSON, err := json.Marshal(map[string]interface{}{
+		"merge_base": mergeBase,
+		"ahead":      ahead,
+		"behind":     behind,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal branch status: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// RepoInfo summarizes the state of the repository at REPO_PATH
+type RepoInfo struct {
+	CurrentBranch   string `json:"current_branch"`
+	DetachedHead    bool   `json:"detached_head"`
+	HeadCommit      string `json:"head_commit"`
+	RemoteURL       string `json:"remote_url"`
+	IsDirty         bool   `json:"is_dirty"`
+	Upstream        string `json:"upstream"`
+	AheadOfUpstream *int   `json:"ahead_of_upstream"`
+	BehindUpstream  *int   `json:"behind_upstream"`
+}
+
+// toolGetRepoInfo returns the current branch, HEAD commit, origin URL (password redacted),
+// dirty state and upstream divergence in one call. A detached HEAD has an empty
+// current_branch, an empty repository an empty head_commit, and a branch without upstream
+// null ahead/behind counts.
+func toolGetRepoInfo(args map[string]interface{}) (string, error) {
+	repoPath := os.Getenv("REPO_PATH")
+	if repoPath == "" {
+		return "", fmt.Errorf("REPO_PATH not set")
+	}
+
+	if _, err := runGitCommand(repoPath, "rev-parse", "--git-dir"); err != nil {
+		return "", fmt.Errorf("not a git repository: %w", err)
+	}
+
+	info := RepoInfo{}
+
+	if branch, err := runGitCommand(repoPath, "symbolic-ref", "--short", "-q", "HEAD"); err == nil {
+		info.CurrentBranch = branch
+	} else {
+		info.DetachedHead = true
+	}
+
+	if head, err := runGitCommand(repoPath, "rev-parse", "--verify", "-q", "HEAD"); err == nil {
+		info.HeadCommit = head
+	}
+
+	if url, err := runGitCommand(repoPath, "remote", "get-url", "origin"); err == nil {
+		info.RemoteURL = redactRemoteURL(url)
+	}
+
+	status, err := runGitCommand(repoPath, "status", "--porcelain")
+	if err != nil {
+		return "", fmt.Errorf("failed to get git status: %w", err)
+	}
+	info.IsDirty = status != ""
+
+	if !info.DetachedHead {
+		if upstream, err := runGitCommand(repoPath, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}"); err == nil {
+			info.Upstream = upstream
+			counts, err := runGitCommand(repoPath, "rev-list", "--left-right", "--count", "@{upstream}...HEAD")
+			if err != nil {
+				return "", fmt.Errorf("failed to count commits against %s: %w", upstream, err)
+			}
+			behind, ahead, err := parseLeftRightCount(counts)
+			if err != nil {
+				return "", err
+			}
+			info.AheadOfUpstream = &ahead
+			info.BehindUpstream = &behind
+		}
+	}
+
+	resultJSON, err := json.Marshal(info)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal repo info: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// parseLeftRightCount parses the "<left>\t<right>" output of git rev-list --left-right --count
+func parseLeftRightCount(output string) (int, int, error) {
+	fields := strings.Fields(output)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output: %q", output)
+	}
+	left, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("unexpected rev-list output: %q", output)
+	}
+	right, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("unexpected rev-list output: %q", output)
+	}
+	return left, right, nil
+}
+
+// runGitCommand runs git in repoPath and returns its trimmed stdout
+func runGitCommand(repoPath string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoPath
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Contributor is an author with the number of commits they made
+type Contributor struct {
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Commits int    `json:"commits"`
+}
+
+// shortlogLinePattern matches a git shortlog -sne line: "    42\tName <email>"
+var shortlogLinePattern = regexp.MustCompile(`^\s*(\d+)\t(.*?)\s*<([^>]*)>$`)
+
+// toolGetContributors lists the authors of HEAD's history by commit count, most commits
+// first, optionally limited to commits touching file_path
+func toolGetContributors(args map[string]interface{}) (string, error) {
+	repoPath := os.Getenv("REPO_PATH")
+	if repoPath == "" {
+		return "", fmt.Errorf("REPO_PATH not set")
+	}
+
+	// A bare shortlog reads a log from stdin, so the revision is always named. Literal
+	// pathspecs keep a path such as ":(top)" from being read as pathspec magic.
+	gitArgs := []string{"--literal-pathspecs", "shortlog", "-sne", "HEAD"}
+	filePath, _ := args["file_path"].(string)
+	if filePath != "" {
+		relPath, err := repoRelativePath(repoPath, filePath)
+		if err != nil {
+			return "", fmt.Errorf("invalid file_path: %w", err)
+		}
+		gitArgs = append(gitArgs, "--", relPath)
+	}
+
+	contributors := []Contributor{}
+	// An empty repository has no HEAD and therefore no contributors
+	if _, err := runGitCommand(repoPath, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil {
+		output, err := runGitCommand(repoPath, gitArgs...)
+		if err != nil {
+			return "", fmt.Errorf("failed to get contributors: %w", err)
+		}
+		contributors = parseShortlog(output)
+	}
+
+	result := map[string]interface{}{
+		"contributors": contributors,
+		"count":        len(contributors),
+	}
+	if filePath != "" {
+		result["file_path"] = filePath
+	}
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal contributors: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// parseShortlog parses git shortlog -sne output, sorted by commits descending and then by
+// name so ties are reported in a stable order
+func parseShortlog(output string) []Contributor {
+	contributors := []Contributor{}
+	for _, line := range strings.Split(output, "\n") {
+		m := shortlogLinePattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		commits, err := strconv.Atoi(m[1])
+		if err != nil {
+			continue
+		}
+		contributors = append(contributors, Contributor{Name: m[2], Email: m[3], Commits: commits})
+	}
+
+	sort.SliceStable(contributors, func(i, j int) bool {
+		if contributors[i].Commits != contributors[j].Commits {
+			return contributors[i].Commits > contributors[j].Commits
+		}
+		return contributors[i].Name < contributors[j].Name
+	})
+	return contributors
+}
+
+// repoRelativePath returns path relative to repoPath, rejecting paths that git could read
+// as an option or that point outside the repository
+func repoRelativePath(repoPath, path string) (string, error) {
+	if strings.HasPrefix(path, "-") {
+		return "", fmt.Errorf("path %q must not start with '-'", path)
+	}
+	if strings.ContainsRune(path, 0) {
+		return "", fmt.Errorf("path %q contains a NUL byte", path)
+	}
+
+	absRepo, err := filepath.Abs(repoPath)
+	if err != nil {
+		return "", err
+	}
+	fullPath := path
+	if !filepath.IsAbs(fullPath) {
+		fullPath = filepath.Join(absRepo, path)
+	}
+	relPath, err := filepath.Rel(absRepo, filepath.Clean(fullPath))
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q is outside the repository", path)
+	}
+	return filepath.ToSlash(relPath), nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// commitFileAs commits content to name under the given author
+func commitFileAs(t *testing.T, dir, author, email, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+		t.Fatalf("failed to create directory for %s: %v", name, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	runGit(t, dir, "add", name)
+	cmd := exec.Command("git", "commit", "-m", "update "+name, "--author", author+" <"+email+">")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v\n%s", err, string(out))
+	}
+}
+
+// getContributors calls toolGetContributors and parses its contributors
+func getContributors(t *testing.T, args map[string]interface{}) []Contributor {
+	t.Helper()
+	resultJSON, err := toolGetContributors(args)
+	if err != nil {
+		t.Fatalf("toolGetContributors returned error: %v", err)
+	}
+	var result struct {
+		Contributors []Contributor `json:"contributors"`
+		Count        int           `json:"count"`
+	}
+	if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
+		t.Fatalf("failed to parse result JSON: %v", err)
+	}
+	if result.Count != len(result.Contributors) {
+		t.Errorf("count = %d, but %d contributors", result.Count, len(result.Contributors))
+	}
+	return result.Contributors
+}
+
+func TestToolGetContributors(t *testing.T) {
+	tmpDir := t.TempDir()
+	runGit(t, tmpDir, "init", "-b", "main")
+	runGit(t, tmpDir, "config", "user.email", "test@example.com")
+	runGit(t, tmpDir, "config", "user.name", "Test User")
+	t.Setenv("REPO_PATH", tmpDir)
+
+	if contributors := getContributors(t, map[string]interface{}{}); len(contributors) != 0 {
+		t.Fatalf("expected no contributors in an empty repository, got %+v", contributors)
+	}
+
+	commitFileAs(t, tmpDir, "Alice", "alice@example.com", "docs/guide.md", "one\n")
+	commitFileAs(t, tmpDir, "Bob", "bob@example.com", "main.go", "package main\n")
+	commitFileAs(t, tmpDir, "Bob", "bob@example.com", "main.go", "package main\n\nfunc main() {}\n")
+	commitFileAs(t, tmpDir, "Bob", "bob@example.com", "docs/guide.md", "two\n")
+	commitFileAs(t, tmpDir, "Alice", "alice@example.com", "docs/guide.md", "three\n")
+
+	all := getContributors(t, map[string]interface{}{})
+	want := []Contributor{
+		{Name: "Bob", Email: "bob@example.com", Commits: 3},
+		{Name: "Alice", Email: "alice@example.com", Commits: 2},
+	}
+	if len(all) != len(want) || all[0] != want[0] || all[1] != want[1] {
+		t.Fatalf("expected %+v, got %+v", want, all)
+	}
+
+	guide := getContributors(t, map[string]interface{}{"file_path": "docs/guide.md"})
+	if len(guide) != 2 || guide[0].Name != "Alice" || guide[0].Commits != 2 || guide[1].Commits != 1 {
+		t.Errorf("expected Alice (2) then Bob (1) for docs/guide.md, got %+v", guide)
+	}
+	if mainGo := getContributors(t, map[string]interface{}{"file_path": "main.go"}); len(mainGo) != 1 || mainGo[0].Name != "Bob" {
+		t.Errorf("expected only Bob for main.go, got %+v", mainGo)
+	}
+
+	for _, bad := range []string{"--all", "../outside.txt"} {
+		if _, err := toolGetContributors(map[string]interface{}{"file_path": bad}); err == nil {
+			t.Errorf("expected error for file_path %q", bad)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 
@@ -113,6 +115,42 @@ func getWorkingDirDiff(repoPath, relPath string) (string, error) {
 	return result.String(), nil
 }
 
+// isUntracked reports whether relPath is an untracked file in the repository
+func isUntracked(repoPath, relPath string) (bool, error) {
+	status, err := runGitCommand(repoPath, "status", "--porcelain", "--untracked-files=all", "--", relPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to get file status: %w", err)
+	}
+	return strings.HasPrefix(status, "?? "), nil
+}
+
+// getUntrackedFileDiff returns an all-additions diff of an untracked file against /dev/null
+func getUntrackedFileDiff(repoPath, relPath string) (string, error) {
+	cmd := exec.Command("git", "diff", "--no-index", "--", "/dev/null", relPath)
+	cmd.Dir = repoPath
+	output, err := cmd.Output()
+	if err != nil {
+		// --no-index exits with 1 when the files differ, which is always the case here
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			return "", fmt.Errorf("failed to diff untracked file: %w", err)
+		}
+	}
+	return string(output), nil
+}
+
+// getStagedFileDiff returns the diff between HEAD and the index for a file, i.e. the
+// changes git commit would record
+func getStagedFileDiff(repoPath, relPath string) (string, error) {
+	cmd := exec.Command("git", "diff", "--cached", "--", relPath)
+	cmd.Dir = repoPath
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to get staged diff: %w\nOutput: %s", err, string(output))
+	}
+	return string(output), nil
+}
+
 // generateDiffWithGitObjects generates a unified diff using go-git objects for proper hash calculation
 func generateDiffWithGitObjects(filePath string, headFile *object.File, headContent, workContent string, r *git.Repository) (string, error) {
 	if headContent == workContent {
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DiffFileStat is the size of the change to one file in a diff summary
+type DiffFileStat struct {
+	FilePath   string `json:"file_path"`
+	Insertions int    `json:"insertions"`
+	Deletions  int    `json:"deletions"`
+	Binary     bool   `json:"binary,omitempty"`
+}
+
+// toolGetDiffSummary returns how many files, inserted lines and deleted lines a comparison
+// covers, from git diff --numstat, without fetching the diffs themselves. comparison_type
+// takes the values of get_changed_files; include_files adds the per-file stats.
+func toolGetDiffSummary(args map[string]interface{}) (string, error) {
+	repoPath := os.Getenv("REPO_PATH")
+	if repoPath == "" {
+		return "", fmt.Errorf("REPO_PATH not set")
+	}
+
+	comparisonType, _ := args["comparison_type"].(string)
+	if comparisonType == "" {
+		return "", fmt.Errorf("comparison_type is required (branch, commits, working, staged, last_commit)")
+	}
+
+	revisions, err := diffSummaryRevisions(comparisonType, args)
+	if err != nil {
+		return "", err
+	}
+	for _, rev := range revisions {
+		if rev == "--cached" {
+			continue
+		}
+		if err := validateRefName(rev); err != nil {
+			return "", fmt.Errorf("invalid ref: %w", err)
+		}
+	}
+
+	gitArgs := append([]string{"diff", "--numstat"}, revisions...)
+	output, err := runGitCommand(repoPath, append(gitArgs, "--")...)
+	if err != nil {
+		return "", fmt.Errorf("failed to get diff summary: %w", err)
+	}
+
+	files := parseFileStats(output)
+	insertions, deletions := 0, 0
+	for _, file := range files {
+		insertions += file.Insertions
+		deletions += file.Deletions
+	}
+
+	result := map[string]interface{}{
+		"comparison_type": comparisonType,
+		"files_changed":   len(files),
+		"insertions":      insertions,
+		"deletions":       deletions,
+	}
+	if includeFiles, _ := args["include_files"].(bool); includeFiles {
+		result["files"] = files
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal diff summary: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// diffSummaryRevisions returns the git diff arguments selecting the two sides of a
+// comparison. working compares the working tree with HEAD, so untracked files are left out.
+func diffSummaryRevisions(comparisonType string, args map[string]interface{}) ([]string, error) {
+	switch comparisonType {
+	case "working":
+		return []string{"HEAD"}, nil
+
+	case "staged":
+		return []string{"--cached"}, nil
+
+	case "branch":
+		baseBranch, _ := args["base_branch"].(string)
+		if baseBranch == "" {
+			return nil, fmt.Errorf("base_branch is required for branch comparison")
+		}
+		targetBranch := "HEAD"
+		if tb, ok := args["target_branch"].(string); ok && tb != "" {
+			targetBranch = tb
+		}
+		return []string{baseBranch, targetBranch}, nil
+
+	case "commits":
+		baseCommit, _ := args["base_commit"].(string)
+		if baseCommit == "" {
+			return nil, fmt.Errorf("base_commit is required for commit comparison")
+		}
+		targetCommit := "HEAD"
+		if tc, ok := args["target_commit"].(string); ok && tc != "" {
+			targetCommit = tc
+		}
+		return []string{baseCommit, targetCommit}, nil
+
+	case "last_commit":
+		return []string{"HEAD~1", "HEAD"}, nil
+
+	default:
+		return nil, fmt.Errorf("invalid comparison_type: %s (must be: branch, commits, working, staged, last_commit)", comparisonType)
+	}
+}
+
+// parseFileStats parses git diff --numstat output like parseNumstat, but flags binary
+// files, which report "-" for both counts, instead of counting them as 0.
+func parseFileStats(output string) []DiffFileStat {
+	files := []DiffFileStat{}
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		stat := DiffFileStat{FilePath: parts[2]}
+		if parts[0] == "-" && parts[1] == "-" {
+			stat.Binary = true
+		} else {
+			stat.Insertions, _ = strconv.Atoi(parts[0])
+			stat.Deletions, _ = strconv.Atoi(parts[1])
+		}
+		files = append(files, stat)
+	}
+	return files
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// diffSummaryResult is the decoded result of toolGetDiffSummary
+type diffSummaryResult struct {
+	FilesChanged int            `json:"files_changed"`
+	Insertions   int            `json:"insertions"`
+	Deletions    int            `json:"deletions"`
+	Files        []DiffFileStat `json:"files"`
+}
+
+// getDiffSummary calls toolGetDiffSummary and parses its result
+func getDiffSummary(t *testing.T, args map[string]interface{}) diffSummaryResult {
+	t.Helper()
+	resultJSON, err := toolGetDiffSummary(args)
+	if err != nil {
+		t.Fatalf("toolGetDiffSummary(%v) returned error: %v", args, err)
+	}
+	var result diffSummaryResult
+	if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
+		t.Fatalf("failed to parse result JSON: %v", err)
+	}
+	return result
+}
+
+func TestToolGetDiffSummary(t *testing.T) {
+	tmpDir := t.TempDir()
+	runGit(t, tmpDir, "init", "-b", "main")
+	runGit(t, tmpDir, "config", "user.email", "test@example.com")
+	runGit(t, tmpDir, "config", "user.name", "Test User")
+	t.Setenv("REPO_PATH", tmpDir)
+
+	commitFile(t, tmpDir, "a.txt", "one\ntwo\nthree\n", "add a")
+	commitFile(t, tmpDir, "b.txt", "keep\ndrop\n", "add b")
+	runGit(t, tmpDir, "checkout", "-b", "feature")
+	// a.txt: 1 line changed and 2 added; b.txt: 1 line removed; c.txt: 2 lines added
+	commitFile(t, tmpDir, "a.txt", "one\nTWO\nthree\nfour\nfive\n", "edit a")
+	commitFile(t, tmpDir, "b.txt", "keep\n", "edit b")
+	commitFile(t, tmpDir, "c.txt", "new\nfile\n", "add c")
+
+	branch := getDiffSummary(t, map[string]interface{}{
+		"comparison_type": "branch",
+		"base_branch":     "main",
+		"include_files":   true,
+	})
+	if branch.FilesChanged != 3 || branch.Insertions != 5 || branch.Deletions != 2 {
+		t.Errorf("expected 3 files, 5 insertions, 2 deletions, got %+v", branch)
+	}
+	if len(branch.Files) != 3 || branch.Files[0] != (DiffFileStat{FilePath: "a.txt", Insertions: 3, Deletions: 1}) {
+		t.Errorf("unexpected per-file stats: %+v", branch.Files)
+	}
+
+	last := getDiffSummary(t, map[string]interface{}{"comparison_type": "last_commit"})
+	if last.FilesChanged != 1 || last.Insertions != 2 || last.Deletions != 0 || last.Files != nil {
+		t.Errorf("expected c.txt's 2 insertions without files, got %+v", last)
+	}
+
+	// Unstaged and staged changes are summarised separately
+	if err := os.WriteFile(filepath.Join(tmpDir, "c.txt"), []byte("new\n"), 0o644); err != nil {
+		t.Fatalf("failed to write c.txt: %v", err)
+	}
+	if working := getDiffSummary(t, map[string]interface{}{"comparison_type": "working"}); working.FilesChanged != 1 || working.Deletions != 1 {
+		t.Errorf("expected 1 deletion in the working tree, got %+v", working)
+	}
+	if staged := getDiffSummary(t, map[string]interface{}{"comparison_type": "staged"}); staged.FilesChanged != 0 {
+		t.Errorf("expected nothing staged, got %+v", staged)
+	}
+	runGit(t, tmpDir, "add", "c.txt")
+	if staged := getDiffSummary(t, map[string]interface{}{"comparison_type": "staged"}); staged.FilesChanged != 1 || staged.Deletions != 1 {
+		t.Errorf("expected 1 staged deletion, got %+v", staged)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"comparison_type": "branch", "base_branch": "--output=/tmp/x"},
+		{"comparison_type": "commits", "base_commit": "main", "target_commit": "HEAD;rm"},
+		{"comparison_type": "branch"},
+		{"comparison_type": "everything"},
+	} {
+		if _, err := toolGetDiffSummary(args); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
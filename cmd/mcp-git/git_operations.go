@@ -73,6 +73,32 @@ func toolGetFileDiff(args map[string]interface{}) (string, error) {
 	fullPath := resolvePath(filePath)
 	relPath, _ := filepath.Rel(repoPath, fullPath)
 
+	// staged compares the index with HEAD, so only what git add has recorded is shown
+	staged, _ := args["staged"].(bool)
+
+	// git diff shows nothing for a file git does not track yet, so diff a new file
+	// against /dev/null whenever the working tree is being compared
+	untracked := false
+	if baseCommit, _ := args["base_commit"].(string); baseCommit == "" && !staged {
+		var err error
+		untracked, err = isUntracked(repoPath, relPath)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// word_diff returns the word-level changes as JSON instead of a line diff
+	if wordDiff, _ := args["word_diff"].(bool); wordDiff {
+		return getFileWordDiff(repoPath, relPath, args, untracked)
+	}
+	if untracked {
+		return getUntrackedFileDiff(repoPath, relPath)
+	}
+
+	if staged {
+		return getStagedFileDiff(repoPath, relPath)
+	}
+
 	// Priority 1: Check if compare_working is true (uncommitted changes)
 	if compareWorking, ok := args["compare_working"].(bool); ok && compareWorking {
 		// Get diff between working directory and HEAD using go-git
@@ -220,7 +246,7 @@ func toolGetChangedFiles(args map[string]interface{}) (string, error) {
 
 	comparisonType, ok := args["comparison_type"].(string)
 	if !ok {
-		return "", fmt.Errorf("comparison_type is required (branch, commits, working, last_commit)")
+		return "", fmt.Errorf("comparison_type is required (branch, commits, working, staged, last_commit)")
 	}
 
 	includeStatus := true
@@ -232,6 +258,9 @@ func toolGetChangedFiles(args map[string]interface{}) (string, error) {
 	case "working":
 		return getChangedFilesWorking(repoPath, includeStatus)
 
+	case "staged":
+		return getChangedFilesStaged(repoPath, includeStatus)
+
 	case "branch":
 		baseBranch, ok := args["base_branch"].(string)
 		if !ok || baseBranch == "" {
@@ -262,7 +291,7 @@ func toolGetChangedFiles(args map[string]interface{}) (string, error) {
 		return getChangedFilesCommits(repoPath, "HEAD~1", "HEAD", includeStatus)
 
 	default:
-		return "", fmt.Errorf("invalid comparison_type: %s (must be: branch, commits, working, last_commit)", comparisonType)
+		return "", fmt.Errorf("invalid comparison_type: %s (must be: branch, commits, working, staged, last_commit)", comparisonType)
 	}
 }
 
@@ -314,6 +343,22 @@ func getChangedFilesWorking(repoPath string, includeStatus bool) (string, error)
 	return string(jsonResult), nil
 }
 
+// getChangedFilesStaged returns the files staged for the next commit, leaving out
+// changes that are only in the working tree
+func getChangedFilesStaged(repoPath string, includeStatus bool) (string, error) {
+	nameFlag := "--name-only"
+	if includeStatus {
+		nameFlag = "--name-status"
+	}
+	cmd := exec.Command("git", "diff", "--cached", nameFlag)
+	cmd.Dir = repoPath
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to get staged files: %w\nOutput: %s", err, string(output))
+	}
+	return parseDiffNameOutput(string(output), includeStatus)
+}
+
 // getChangedFilesBranches returns changed files between two branches using go-git
 func getChangedFilesBranches(repoPath, baseBranch, targetBranch string, includeStatus bool) (string, error) {
 	r, err := git.PlainOpen(repoPath)
@@ -526,9 +571,14 @@ func parseDiffNameOutput(output string, includeStatus bool) (string, error) {
 
 		entry := make(map[string]interface{})
 		if includeStatus {
-			// Format: STATUS\tfile_path
-			parts := strings.SplitN(line, "\t", 2)
-			if len(parts) == 2 {
+			// Format: STATUS\tfile_path, or STATUS\told_path\tnew_path for renames
+			// and copies, whose status carries a similarity score such as R100
+			parts := strings.SplitN(line, "\t", 3)
+			if len(parts) == 3 {
+				entry["status"] = parts[0][:1]
+				entry["old_path"] = parts[1]
+				entry["file_path"] = parts[2]
+			} else if len(parts) == 2 {
 				entry["status"] = parts[0]
 				entry["file_path"] = parts[1]
 			} else {
@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -541,3 +542,112 @@ func TestStageCommitUnstageIntegration(t *testing.T) {
 		t.Fatalf("expected 1 unstaged file")
 	}
 }
+
+func TestToolGetFileDiffUntrackedFile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	runGit(t, tmpDir, "init", "-b", "main")
+	runGit(t, tmpDir, "config", "user.email", "test@example.com")
+	runGit(t, tmpDir, "config", "user.name", "Test User")
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("failed to write initial file: %v", err)
+	}
+	runGit(t, tmpDir, "add", "main.go")
+	runGit(t, tmpDir, "commit", "-m", "initial commit")
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "new.go"), []byte("package main\n\nfunc added() {}\n"), 0o644); err != nil {
+		t.Fatalf("failed to write untracked file: %v", err)
+	}
+
+	t.Setenv("REPO_PATH", tmpDir)
+
+	for _, args := range []map[string]interface{}{
+		{"file_path": "new.go"},
+		{"file_path": "new.go", "base_branch": "main"},
+		{"file_path": "new.go", "compare_working": true},
+	} {
+		diff, err := toolGetFileDiff(args)
+		if err != nil {
+			t.Fatalf("toolGetFileDiff(%v) returned error: %v", args, err)
+		}
+
+		if !strings.Contains(diff, "--- /dev/null") || !strings.Contains(diff, "+++ b/new.go") {
+			t.Errorf("expected diff against /dev/null, got:\n%s", diff)
+		}
+		if !strings.Contains(diff, "@@ -0,0 +1,3 @@") {
+			t.Errorf("expected a single all-added hunk, got:\n%s", diff)
+		}
+		for _, line := range []string{"+package main", "+func added() {}"} {
+			if !strings.Contains(diff, line) {
+				t.Errorf("expected diff to contain %q, got:\n%s", line, diff)
+			}
+		}
+		for _, line := range strings.Split(strings.TrimSpace(diff), "\n") {
+			if strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---") {
+				t.Errorf("expected only additions, got removed line %q", line)
+			}
+		}
+	}
+}
+
+func TestStagedComparisons(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	runGit(t, tmpDir, "init", "-b", "main")
+	runGit(t, tmpDir, "config", "user.email", "test@example.com")
+	runGit(t, tmpDir, "config", "user.name", "Test User")
+	commitFile(t, tmpDir, "staged.txt", "original\n", "add staged.txt")
+	commitFile(t, tmpDir, "unstaged.txt", "original\n", "add unstaged.txt")
+	commitFile(t, tmpDir, "old.txt", "moved content\n", "add old.txt")
+
+	// staged.txt is modified and staged, unstaged.txt only modified, old.txt renamed
+	for name, content := range map[string]string{"staged.txt": "staged change\n", "unstaged.txt": "working change\n"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	runGit(t, tmpDir, "add", "staged.txt")
+	runGit(t, tmpDir, "mv", "old.txt", "renamed.txt")
+
+	t.Setenv("REPO_PATH", tmpDir)
+
+	result, err := toolGetChangedFiles(map[string]interface{}{"comparison_type": "staged"})
+	if err != nil {
+		t.Fatalf("toolGetChangedFiles(staged) returned error: %v", err)
+	}
+	var files []map[string]string
+	if err := json.Unmarshal([]byte(result), &files); err != nil {
+		t.Fatalf("failed to parse changed files: %v", err)
+	}
+	want := []map[string]string{
+		{"file_path": "renamed.txt", "old_path": "old.txt", "status": "R"},
+		{"file_path": "staged.txt", "status": "M"},
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("staged changed files = %v, want %v", files, want)
+	}
+
+	diff, err := toolGetFileDiff(map[string]interface{}{"file_path": "staged.txt", "staged": true})
+	if err != nil {
+		t.Fatalf("toolGetFileDiff(staged.txt) returned error: %v", err)
+	}
+	if !strings.Contains(diff, "+staged change") || !strings.Contains(diff, "-original") {
+		t.Errorf("expected the staged change in the diff, got:\n%s", diff)
+	}
+
+	diff, err = toolGetFileDiff(map[string]interface{}{"file_path": "unstaged.txt", "staged": true})
+	if err != nil {
+		t.Fatalf("toolGetFileDiff(unstaged.txt, staged) returned error: %v", err)
+	}
+	if diff != "" {
+		t.Errorf("expected no staged diff for a file that is only modified, got:\n%s", diff)
+	}
+
+	diff, err = toolGetFileDiff(map[string]interface{}{"file_path": "unstaged.txt", "base_branch": "main"})
+	if err != nil {
+		t.Fatalf("toolGetFileDiff(unstaged.txt) returned error: %v", err)
+	}
+	if !strings.Contains(diff, "+working change") {
+		t.Errorf("expected the working tree change against main, got:\n%s", diff)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// lineHistoryFormat starts each commit of git log -L output with an ASCII record separator,
+// followed by the NUL-separated commit metadata; the commit's diff of the range follows
+const lineHistoryFormat = "%x1e%H%x00%an%x00%ae%x00%aI%x00%s"
+
+// functionNamePattern is the accepted form of function_name. git log -L reads it as a
+// regular expression, so only plain identifiers are passed through.
+var functionNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// LineChange is one commit in the history of a line range, with the range's diff
+type LineChange struct {
+	Commit  string `json:"commit"`
+	Author  string `json:"author"`
+	Email   string `json:"email"`
+	Date    string `json:"date"`
+	Subject string `json:"subject"`
+	Diff    string `json:"diff"`
+}
+
+// toolGetLineHistory traces how a line range or function of a file evolved with git log -L,
+// newest first. The range is start_line/end_line, or the function named function_name as
+// found by git's funcname detection. limit caps the number of commits (default 10, max 100).
+func toolGetLineHistory(args map[string]interface{}) (string, error) {
+	repoPath := os.Getenv("REPO_PATH")
+	if repoPath == "" {
+		return "", fmt.Errorf("REPO_PATH not set")
+	}
+
+	filePath, _ := args["file_path"].(string)
+	if filePath == "" {
+		return "", fmt.Errorf("file_path is required")
+	}
+	relPath, err := repoRelativePath(repoPath, filePath)
+	if err != nil {
+		return "", fmt.Errorf("invalid file_path: %w", err)
+	}
+	// git splits the -L argument at colons, so the path cannot contain one
+	if strings.Contains(relPath, ":") {
+		return "", fmt.Errorf("invalid file_path: %q must not contain ':'", filePath)
+	}
+
+	lineRange, err := lineHistoryRange(args)
+	if err != nil {
+		return "", err
+	}
+
+	limit := 10
+	if l, ok := args["limit"].(float64); ok {
+		limit = int(l)
+		if limit < 1 {
+			limit = 1
+		}
+		if limit > 100 {
+			limit = 100
+		}
+	}
+
+	output, err := runGitCommand(repoPath, "log", "-L"+lineRange+":"+relPath,
+		"--max-count="+strconv.Itoa(limit), "--format="+lineHistoryFormat, "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to get line history: %w", err)
+	}
+
+	changes := parseLineHistory(output)
+	result := map[string]interface{}{
+		"file_path": filePath,
+		"changes":   changes,
+		"count":     len(changes),
+	}
+	if name, ok := args["function_name"].(string); ok && name != "" {
+		result["function_name"] = name
+	} else {
+		result["start_line"] = args["start_line"]
+		result["end_line"] = args["end_line"]
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal line history: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// lineHistoryRange returns the range part of the -L argument: ":name" for function_name,
+// otherwise "start,end" from start_line and end_line
+func lineHistoryRange(args map[string]interface{}) (string, error) {
+	if name, ok := args["function_name"].(string); ok && name != "" {
+		if _, hasStart := args["start_line"]; hasStart {
+			return "", fmt.Errorf("give either function_name or start_line/end_line, not both")
+		}
+		if !functionNamePattern.MatchString(name) {
+			return "", fmt.Errorf("invalid function_name %q: must be an identifier", name)
+		}
+		return ":" + name, nil
+	}
+
+	start, okStart := args["start_line"].(float64)
+	end, okEnd := args["end_line"].(float64)
+	if !okStart || !okEnd {
+		return "", fmt.Errorf("function_name, or start_line and end_line, is required")
+	}
+	if start < 1 || end < start || start != float64(int(start)) || end != float64(int(end)) {
+		return "", fmt.Errorf("invalid line range %v-%v: lines are whole numbers from 1 and end_line must not be before start_line", start, end)
+	}
+	return fmt.Sprintf("%d,%d", int(start), int(end)), nil
+}
+
+// parseLineHistory splits git log -L output written with lineHistoryFormat into changes
+func parseLineHistory(output string) []LineChange {
+	changes := []LineChange{}
+	for _, record := range strings.Split(output, "\x1e") {
+		header, diff, _ := strings.Cut(record, "\n")
+		fields := strings.Split(header, "\x00")
+		if len(fields) != 5 {
+			continue
+		}
+		changes = append(changes, LineChange{
+			Commit:  fields[0],
+			Author:  fields[1],
+			Email:   fields[2],
+			Date:    fields[3],
+			Subject: fields[4],
+			Diff:    strings.TrimSpace(diff),
+		})
+	}
+	return changes
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// getLineHistory calls toolGetLineHistory and parses its changes
+func getLineHistory(t *testing.T, args map[string]interface{}) []LineChange {
+	t.Helper()
+	resultJSON, err := toolGetLineHistory(args)
+	if err != nil {
+		t.Fatalf("toolGetLineHistory(%v) returned error: %v", args, err)
+	}
+	var result struct {
+		Changes []LineChange `json:"changes"`
+		Count   int          `json:"count"`
+	}
+	if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
+		t.Fatalf("failed to parse result JSON: %v", err)
+	}
+	if result.Count != len(result.Changes) {
+		t.Errorf("count = %d, but %d changes", result.Count, len(result.Changes))
+	}
+	return result.Changes
+}
+
+// subjects returns the commit subjects of changes, newest first
+func subjects(changes []LineChange) string {
+	var s []string
+	for _, c := range changes {
+		s = append(s, c.Subject)
+	}
+	return strings.Join(s, ",")
+}
+
+func TestToolGetLineHistory(t *testing.T) {
+	tmpDir := t.TempDir()
+	runGit(t, tmpDir, "init", "-b", "main")
+	runGit(t, tmpDir, "config", "user.email", "test@example.com")
+	runGit(t, tmpDir, "config", "user.name", "Test User")
+	t.Setenv("REPO_PATH", tmpDir)
+
+	commitFile(t, tmpDir, "calc.go", "package calc\n\nfunc add(a, b int) int {\n\treturn a + b\n}\n\nfunc sub(a, b int) int {\n\treturn a - b\n}\n", "add calc")
+	commitFile(t, tmpDir, "calc.go", "package calc\n\nfunc add(a, b int) int {\n\tsum := a + b\n\treturn sum\n}\n\nfunc sub(a, b int) int {\n\treturn a - b\n}\n", "name the sum")
+	commitFile(t, tmpDir, "calc.go", "package calc\n\nfunc add(a, b int) int {\n\tsum := a + b\n\treturn sum\n}\n\nfunc sub(a, b int) int {\n\treturn b - a\n}\n", "fix sub")
+	commitFile(t, tmpDir, "calc.go", "package calc\n\n// add returns the sum of a and b\nfunc add(a, b int) int {\n\tsum := a + b\n\treturn sum\n}\n\nfunc sub(a, b int) int {\n\treturn b - a\n}\n", "document add")
+	commitFile(t, tmpDir, "calc.go", "package calc\n\n// add returns the sum of a and b\nfunc add(a, b int) int {\n\treturn a + b\n}\n\nfunc sub(a, b int) int {\n\treturn b - a\n}\n", "inline the sum")
+
+	// Changes to sub and to the comment above add are not part of add's body
+	add := getLineHistory(t, map[string]interface{}{"file_path": "calc.go", "function_name": "add"})
+	if got := subjects(add); got != "inline the sum,name the sum,add calc" {
+		t.Fatalf("expected add's history newest first, got %s", got)
+	}
+	if !strings.Contains(add[0].Diff, "-\tsum := a + b") || !strings.Contains(add[0].Diff, "+\treturn a + b") {
+		t.Errorf("expected the newest change's diff, got:\n%s", add[0].Diff)
+	}
+	if len(add[0].Commit) != 40 || add[0].Author != "Test User" || add[0].Email != "test@example.com" || add[0].Date == "" {
+		t.Errorf("expected commit metadata, got %+v", add[0])
+	}
+
+	// The range of sub's body in HEAD, lines 9-11
+	sub := getLineHistory(t, map[string]interface{}{"file_path": "calc.go", "start_line": float64(9), "end_line": float64(11)})
+	if got := subjects(sub); got != "fix sub,add calc" {
+		t.Errorf("expected sub's history, got %s", got)
+	}
+
+	limited := getLineHistory(t, map[string]interface{}{"file_path": "calc.go", "function_name": "add", "limit": float64(1)})
+	if got := subjects(limited); got != "inline the sum" {
+		t.Errorf("expected only the newest change with limit 1, got %s", got)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"function_name": "add"},
+		{"file_path": "calc.go"},
+		{"file_path": "calc.go", "function_name": "add|sub"},
+		{"file_path": "calc.go", "function_name": "add", "start_line": float64(1), "end_line": float64(2)},
+		{"file_path": "calc.go", "start_line": float64(5), "end_line": float64(2)},
+		{"file_path": "calc.go", "start_line": float64(0), "end_line": float64(2)},
+		{"file_path": "--output=x", "function_name": "add"},
+		{"file_path": "../calc.go", "function_name": "add"},
+		{"file_path": "a:b.go", "function_name": "add"},
+		{"file_path": "calc.go", "function_name": "missing"},
+	} {
+		if _, err := toolGetLineHistory(args); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
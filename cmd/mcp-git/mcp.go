@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+
+	"github.com/code-aria/internal-mcp/internal/mcp"
 )
 
 // handleInitialize processes the MCP initialize request
@@ -56,6 +58,8 @@ func handleInitialize(scanner *bufio.Scanner, encoder *json.Encoder) error {
 // handleRequest routes MCP requests to appropriate handlers
 func handleRequest(msg *MCPMessage, encoder *json.Encoder) {
 	switch msg.Method {
+	case "ping":
+		mcp.SendPing(encoder, msg.ID)
 	case "tools/list":
 		handleToolsList(msg, encoder)
 	case "tools/call":
@@ -83,7 +87,7 @@ func handleToolsList(msg *MCPMessage, encoder *json.Encoder) {
 							"properties": map[string]interface{}{
 								"type": map[string]interface{}{
 									"type":        "string",
-									"description": "Operation type: get_git_status, get_file_diff, get_commit_history, get_changed_files, get_all_working_changes, stage_files, commit_changes, unstage_files",
+									"description": "Operation type: get_git_status, get_file_diff, get_commit_history, get_changed_files, get_all_working_changes, stage_files, commit_changes, unstage_files, get_branch_status, get_repo_info, list_tags, apply_patch, get_contributors, get_diff_summary, get_line_history, get_authorship, preview_merge, list_remotes. get_repo_info takes no params and returns {current_branch, detached_head, head_commit, remote_url, is_dirty, upstream, ahead_of_upstream, behind_upstream}. get_branch_status takes base_branch (default main) and target_branch (default HEAD) and returns {merge_base, ahead, behind}, where ahead/behind count commits of target_branch relative to base_branch. list_tags takes an optional pattern (e.g. v1.*) and returns {tags: [{name, commit, annotated, tagger, date}], count}, newest first. apply_patch takes patch (unified diff) and index (bool, also stage the changes) and runs git apply after git apply --check; it is disabled unless the server runs with MCP_GIT_ALLOW_WRITE=true. get_contributors takes an optional file_path and returns {contributors: [{name, email, commits}], count}, most commits first. get_file_diff takes word_diff (bool) to return {file_path, hunks: [{old_start, old_lines, new_start, new_lines, lines: [{old_line, new_line, segments: [{type: context|added|removed, text}]}]}], added_segments, removed_segments} instead of a line diff, in any comparison mode. get_file_diff takes staged (bool) to diff the index against HEAD (git diff --cached), taking precedence over the other comparison params. get_changed_files accepts comparison_type staged to list only the files staged for the next commit (git diff --cached --name-status); renames and copies there carry old_path. get_diff_summary takes comparison_type and the ref params of get_changed_files and returns {comparison_type, files_changed, insertions, deletions} from git diff --numstat, plus files: [{file_path, insertions, deletions, binary}] with include_files (bool); it is much cheaper than fetching every file's diff, and working leaves out untracked files. get_line_history takes file_path and either function_name (an identifier, found by git's funcname detection) or start_line and end_line, plus limit (default 10, max 100), and returns {file_path, changes: [{commit, author, email, date, subject, diff}], count} from git log -L, newest first. get_authorship takes file_path and blames it as of HEAD, returning {file_path, authors: [{author, email, lines, percent}], total_lines}, most lines first; files not in HEAD are an error. preview_merge takes source_branch and optional target_branch (default HEAD) and returns {source_branch, target_branch, will_conflict, conflicting_files} from git merge-tree --write-tree (git 2.38+), without touching the working tree, the index or any ref. list_remotes takes no params and returns {remotes: [{name, fetch_url, push_url}], count} from git remote -v, one entry per remote and an empty list when there are none",
 								},
 							},
 						},
@@ -127,130 +131,34 @@ func handleToolCall(msg *MCPMessage, encoder *json.Encoder) {
 	sendError(encoder, msg.ID, -32601, fmt.Sprintf("Unknown tool: %s. Use apply_operations for batch operations", req.Name), nil)
 }
 
+// batchRunner dispatches apply_operations to the operation handlers
+var batchRunner = mcp.NewBatchRunner(map[string]mcp.OperationHandler{
+	"get_git_status":          toolGetGitStatus,
+	"get_file_diff":           toolGetFileDiff,
+	"get_commit_history":      toolGetCommitHistory,
+	"get_changed_files":       toolGetChangedFiles,
+	"get_all_working_changes": toolGetAllWorkingChanges,
+	"stage_files":             toolStageFiles,
+	"commit_changes":          toolCommitChanges,
+	"unstage_files":           toolUnstageFiles,
+	"get_branch_status":       toolGetBranchStatus,
+	"get_repo_info":           toolGetRepoInfo,
+	"list_tags":               toolListTags,
+	"apply_patch":             toolApplyPatch,
+	"get_contributors":        toolGetContributors,
+	"get_diff_summary":        toolGetDiffSummary,
+	"get_line_history":        toolGetLineHistory,
+	"get_authorship":          toolGetAuthorship,
+	"preview_merge":           toolPreviewMerge,
+	"list_remotes":            toolListRemotes,
+})
+
 // handleBatchOperations processes a batch of operations
 func handleBatchOperations(msg *MCPMessage, encoder *json.Encoder, args map[string]interface{}) {
-	operations, ok := args["operations"].([]interface{})
-	if !ok {
-		sendError(encoder, msg.ID, -32602, "operations array is required", nil)
-		return
-	}
-
-	if len(operations) == 0 {
-		sendError(encoder, msg.ID, -32602, "operations array cannot be empty", nil)
-		return
-	}
-
-	var results []map[string]interface{}
-
-	for _, op := range operations {
-		opMap, ok := op.(map[string]interface{})
-		if !ok {
-			results = append(results, map[string]interface{}{
-				"operation": "unknown",
-				"params":    map[string]interface{}{},
-				"status":    "Error",
-				"message":   "Invalid operation format",
-			})
-			continue
-		}
-
-		opType, ok := opMap["type"].(string)
-		if !ok {
-			results = append(results, map[string]interface{}{
-				"operation": "unknown",
-				"params":    map[string]interface{}{},
-				"status":    "Error",
-				"message":   "Operation type is required",
-			})
-			continue
-		}
-
-		// Extract operation-specific arguments as params
-		params := make(map[string]interface{})
-		for k, v := range opMap {
-			if k != "type" {
-				params[k] = v
-			}
-		}
-
-		// Execute operation based on type
-		var result string
-		var err error
-
-		switch opType {
-		case "get_git_status":
-			result, err = toolGetGitStatus(params)
-		case "get_file_diff":
-			result, err = toolGetFileDiff(params)
-		case "get_commit_history":
-			result, err = toolGetCommitHistory(params)
-		case "get_changed_files":
-			result, err = toolGetChangedFiles(params)
-		case "get_all_working_changes":
-			result, err = toolGetAllWorkingChanges(params)
-		case "stage_files":
-			result, err = toolStageFiles(params)
-		case "commit_changes":
-			result, err = toolCommitChanges(params)
-		case "unstage_files":
-			result, err = toolUnstageFiles(params)
-		default:
-			err = fmt.Errorf("unknown operation type: %s", opType)
-		}
-
-		if err != nil {
-			results = append(results, map[string]interface{}{
-				"operation": opType,
-				"params":    params,
-				"status":    "Error",
-				"message":   err.Error(),
-			})
-		} else {
-			// Parse JSON result if possible, otherwise use as string
-			var parsedResult interface{}
-			if jsonErr := json.Unmarshal([]byte(result), &parsedResult); jsonErr == nil {
-				// Successfully parsed JSON, use parsed result
-			} else {
-				// Not JSON, use string as-is
-				parsedResult = result
-			}
-
-			results = append(results, map[string]interface{}{
-				"operation": opType,
-				"params":    params,
-				"status":    "Success",
-				"result":    parsedResult,
-			})
-		}
-	}
-
-	// Serialize results to JSON text for MCP-compliant response format
-	resultsJSON, err := json.Marshal(map[string]interface{}{
-		"results": results,
-	})
-	if err != nil {
-		sendError(encoder, msg.ID, -32700, fmt.Sprintf("Failed to marshal results: %v", err), nil)
-		return
-	}
-
-	// Return results in MCP-compliant format using ToolsCallResponse
-	response := MCPMessage{
-		JSONRPC: "2.0",
-		ID:      msg.ID,
-		Result: ToolsCallResponse{
-			Content: []Content{
-				{
-					Type: "text",
-					Text: string(resultsJSON),
-				},
-			},
-			IsError: false,
-		},
-	}
-
-	encoder.Encode(response)
+	batchRunner.Handle(msg, encoder, args)
 }
 
+
 // sendError sends an error response
 func sendError(encoder *json.Encoder, id interface{}, code int, message string, data interface{}) {
 	response := MCPMessage{
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// toolPreviewMerge reports whether merging source_branch into target_branch (default
+// HEAD) would conflict, and in which files. The merge is done by git merge-tree
+// --write-tree, which merges in the object database only: the working tree, the index and
+// every ref are left alone and no commit is created.
+func toolPreviewMerge(args map[string]interface{}) (string, error) {
+	repoPath := os.Getenv("REPO_PATH")
+	if repoPath == "" {
+		return "", fmt.Errorf("REPO_PATH not set")
+	}
+
+	sourceBranch, _ := args["source_branch"].(string)
+	if sourceBranch == "" {
+		return "", fmt.Errorf("source_branch is required")
+	}
+	targetBranch := "HEAD"
+	if tb, ok := args["target_branch"].(string); ok && tb != "" {
+		targetBranch = tb
+	}
+
+	if err := validateRefName(sourceBranch); err != nil {
+		return "", fmt.Errorf("invalid source_branch: %w", err)
+	}
+	if err := validateRefName(targetBranch); err != nil {
+		return "", fmt.Errorf("invalid target_branch: %w", err)
+	}
+	for _, ref := range []string{sourceBranch, targetBranch} {
+		if _, err := runGitCommand(repoPath, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+			return "", fmt.Errorf("unknown branch or commit: %s", ref)
+		}
+	}
+
+	conflicting, err := mergeTreeConflicts(repoPath, targetBranch, sourceBranch)
+	if err != nil {
+		return "", err
+	}
+
+	resultJSON, err := json.Marshal(map[string]interface{}{
+		"source_branch":     sourceBranch,
+		"target_branch":     targetBranch,
+		"will_conflict":     len(conflicting) > 0,
+		"conflicting_files": conflicting,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal merge preview: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// mergeTreeConflicts merges source into target with git merge-tree --write-tree and
+// returns the files left with conflicts. merge-tree exits with 1 when the merge conflicts;
+// its output is then the resulting tree followed by the conflicted paths.
+func mergeTreeConflicts(repoPath, target, source string) ([]string, error) {
+	cmd := exec.Command("git", "merge-tree", "--write-tree", "--name-only", "--no-messages", target, source)
+	cmd.Dir = repoPath
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+
+	conflicting := []string{}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return conflicting, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		// The first line is the tree, with conflict markers in the conflicted files
+		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+		for _, line := range lines[1:] {
+			if line != "" {
+				conflicting = append(conflicting, line)
+			}
+		}
+		return conflicting, nil
+	default:
+		if strings.Contains(stderr.String(), "--write-tree") || strings.Contains(stderr.String(), "usage:") {
+			return nil, fmt.Errorf("preview_merge requires git 2.38 or later: %s", strings.TrimSpace(stderr.String()))
+		}
+		return nil, fmt.Errorf("failed to preview merge of %s into %s: %w: %s", source, target, err, strings.TrimSpace(stderr.String()))
+	}
+}
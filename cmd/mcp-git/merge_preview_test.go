@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// previewMerge calls toolPreviewMerge and parses its result
+func previewMerge(t *testing.T, args map[string]interface{}) (bool, []string) {
+	t.Helper()
+	resultJSON, err := toolPreviewMerge(args)
+	if err != nil {
+		t.Fatalf("toolPreviewMerge(%v) returned error: %v", args, err)
+	}
+	var result struct {
+		WillConflict     bool     `json:"will_conflict"`
+		ConflictingFiles []string `json:"conflicting_files"`
+	}
+	if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
+		t.Fatalf("failed to parse result JSON: %v", err)
+	}
+	return result.WillConflict, result.ConflictingFiles
+}
+
+func TestToolPreviewMerge(t *testing.T) {
+	tmpDir := t.TempDir()
+	runGit(t, tmpDir, "init", "-b", "main")
+	runGit(t, tmpDir, "config", "user.email", "test@example.com")
+	runGit(t, tmpDir, "config", "user.name", "Test User")
+	t.Setenv("REPO_PATH", tmpDir)
+
+	commitFile(t, tmpDir, "config.txt", "port=8080\nhost=localhost\n", "base")
+	commitFile(t, tmpDir, "notes.txt", "notes\n", "notes")
+
+	// Both branches change the same line of config.txt; only feature touches notes.txt
+	runGit(t, tmpDir, "checkout", "-b", "feature")
+	commitFile(t, tmpDir, "config.txt", "port=9090\nhost=localhost\n", "feature port")
+	commitFile(t, tmpDir, "notes.txt", "more notes\n", "feature notes")
+	runGit(t, tmpDir, "checkout", "-b", "docs", "main")
+	commitFile(t, tmpDir, "readme.txt", "readme\n", "docs")
+	runGit(t, tmpDir, "checkout", "main")
+	commitFile(t, tmpDir, "config.txt", "port=7070\nhost=localhost\n", "main port")
+
+	headBefore, err := runGitCommand(tmpDir, "rev-parse", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	willConflict, files := previewMerge(t, map[string]interface{}{"source_branch": "feature"})
+	if !willConflict || strings.Join(files, ",") != "config.txt" {
+		t.Errorf("expected a conflict in config.txt only, got %v %v", willConflict, files)
+	}
+
+	if willConflict, files := previewMerge(t, map[string]interface{}{"source_branch": "docs", "target_branch": "feature"}); willConflict || len(files) != 0 {
+		t.Errorf("expected docs to merge cleanly into feature, got %v %v", willConflict, files)
+	}
+
+	// Nothing is merged or committed, and the working tree is untouched
+	if head, _ := runGitCommand(tmpDir, "rev-parse", "HEAD"); head != headBefore {
+		t.Errorf("HEAD moved from %s to %s", headBefore, head)
+	}
+	if status, _ := runGitCommand(tmpDir, "status", "--porcelain"); status != "" {
+		t.Errorf("expected a clean working tree, got %q", status)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{},
+		{"source_branch": "--output=x"},
+		{"source_branch": "feature", "target_branch": "main..feature"},
+		{"source_branch": "no-such-branch"},
+	} {
+		if _, err := toolPreviewMerge(args); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// allowWriteEnv must be "true" for operations that modify the working tree from arbitrary input
+const allowWriteEnv = "MCP_GIT_ALLOW_WRITE"
+
+// PatchFile is a file touched by an applied patch, as reported by git apply --numstat
+type PatchFile struct {
+	Path      string `json:"path"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+}
+
+// toolApplyPatch applies a unified diff to the working tree (and the index when index is
+// true) with git apply. It is disabled unless MCP_GIT_ALLOW_WRITE=true, and the patch is
+// checked with git apply --check first so a patch that does not apply changes nothing.
+func toolApplyPatch(args map[string]interface{}) (string, error) {
+	if os.Getenv(allowWriteEnv) != "true" {
+		return "", fmt.Errorf("write operations disabled: set %s=true to enable apply_patch", allowWriteEnv)
+	}
+
+	repoPath := os.Getenv("REPO_PATH")
+	if repoPath == "" {
+		return "", fmt.Errorf("REPO_PATH not set")
+	}
+
+	patch, ok := args["patch"].(string)
+	if !ok || strings.TrimSpace(patch) == "" {
+		return "", fmt.Errorf("patch is required")
+	}
+	// git apply rejects a final hunk line without a newline as a corrupt patch
+	if !strings.HasSuffix(patch, "\n") {
+		patch += "\n"
+	}
+	index, _ := args["index"].(bool)
+
+	applyArgs := []string{"apply"}
+	if index {
+		applyArgs = append(applyArgs, "--index")
+	}
+
+	if _, err := runGitCommandWithInput(repoPath, patch, append(applyArgs, "--check", "-")...); err != nil {
+		return "", fmt.Errorf("patch does not apply cleanly: %s", strings.Join(patchConflicts(err), "; "))
+	}
+
+	numstat, err := runGitCommandWithInput(repoPath, patch, "apply", "--numstat", "-")
+	if err != nil {
+		return "", fmt.Errorf("failed to read patch stats: %w", err)
+	}
+	files := parseNumstat(numstat)
+
+	if _, err := runGitCommandWithInput(repoPath, patch, append(applyArgs, "-")...); err != nil {
+		return "", fmt.Errorf("failed to apply patch: %w", err)
+	}
+
+	resultJSON, err := json.Marshal(map[string]interface{}{
+		"applied": true,
+		"index":   index,
+		"files":   files,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// patchConflicts extracts the "error:" lines git apply --check prints for each hunk that
+// does not apply, falling back to the whole error
+func patchConflicts(err error) []string {
+	var conflicts []string
+	for _, line := range strings.Split(err.Error(), "\n") {
+		if idx := strings.Index(line, "error: "); idx >= 0 {
+			conflicts = append(conflicts, strings.TrimSpace(line[idx+len("error: "):]))
+		}
+	}
+	if len(conflicts) == 0 {
+		return []string{err.Error()}
+	}
+	return conflicts
+}
+
+// parseNumstat parses "<added>\t<deleted>\t<path>" lines. Binary files report "-" counts,
+// which are left as 0.
+func parseNumstat(output string) []PatchFile {
+	files := []PatchFile{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		additions, _ := strconv.Atoi(fields[0])
+		deletions, _ := strconv.Atoi(fields[1])
+		files = append(files, PatchFile{Path: fields[2], Additions: additions, Deletions: deletions})
+	}
+	return files
+}
+
+// runGitCommandWithInput runs git in repoPath with input on stdin and returns its trimmed stdout
+func runGitCommandWithInput(repoPath, input string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader(input)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupPatchRepo creates a repository with one committed file and returns a patch that
+// changes its second line
+func setupPatchRepo(t *testing.T) (string, string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	runGit(t, tmpDir, "init", "-b", "main")
+	runGit(t, tmpDir, "config", "user.email", "test@example.com")
+	runGit(t, tmpDir, "config", "user.name", "Test User")
+	commitFile(t, tmpDir, "file.txt", "one\ntwo\nthree\n", "initial commit")
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "file.txt"), []byte("one\nTWO\nthree\n"), 0o644); err != nil {
+		t.Fatalf("failed to modify file: %v", err)
+	}
+	cmd := exec.Command("git", "diff")
+	cmd.Dir = tmpDir
+	patch, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git diff failed: %v", err)
+	}
+	runGit(t, tmpDir, "checkout", "--", "file.txt")
+
+	return tmpDir, string(patch)
+}
+
+func TestToolApplyPatch(t *testing.T) {
+	tmpDir, patch := setupPatchRepo(t)
+	t.Setenv("REPO_PATH", tmpDir)
+	t.Setenv(allowWriteEnv, "true")
+
+	resultJSON, err := toolApplyPatch(map[string]interface{}{"patch": patch, "index": true})
+	if err != nil {
+		t.Fatalf("toolApplyPatch returned error: %v", err)
+	}
+
+	var result struct {
+		Applied bool        `json:"applied"`
+		Files   []PatchFile `json:"files"`
+	}
+	if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
+		t.Fatalf("failed to parse result JSON: %v", err)
+	}
+	if !result.Applied || len(result.Files) != 1 || result.Files[0] != (PatchFile{Path: "file.txt", Additions: 1, Deletions: 1}) {
+		t.Errorf("expected file.txt with 1 addition and 1 deletion, got %+v", result)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "file.txt"))
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	if string(content) != "one\nTWO\nthree\n" {
+		t.Errorf("expected patched content, got %q", string(content))
+	}
+	staged, err := runGitCommand(tmpDir, "diff", "--cached", "--name-only")
+	if err != nil {
+		t.Fatalf("git diff --cached failed: %v", err)
+	}
+	if staged != "file.txt" {
+		t.Errorf("expected file.txt to be staged with index=true, got %q", staged)
+	}
+
+	// The same patch no longer applies, and the conflict names the file
+	_, err = toolApplyPatch(map[string]interface{}{"patch": patch})
+	if err == nil || !strings.Contains(err.Error(), "does not apply cleanly") || !strings.Contains(err.Error(), "file.txt") {
+		t.Errorf("expected conflict error naming file.txt, got %v", err)
+	}
+}
+
+func TestToolApplyPatchDisabledByDefault(t *testing.T) {
+	tmpDir, patch := setupPatchRepo(t)
+	t.Setenv("REPO_PATH", tmpDir)
+	t.Setenv(allowWriteEnv, "")
+
+	_, err := toolApplyPatch(map[string]interface{}{"patch": patch})
+	if err == nil || !strings.Contains(err.Error(), "write operations disabled") {
+		t.Fatalf("expected write operations disabled error, got %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "file.txt"))
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	if string(content) != "one\ntwo\nthree\n" {
+		t.Errorf("expected file to be untouched, got %q", string(content))
+	}
+}
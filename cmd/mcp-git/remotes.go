@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// RemoteInfo is a configured remote and the URLs it fetches from and pushes to
+type RemoteInfo struct {
+	Name     string `json:"name"`
+	FetchURL string `json:"fetch_url"`
+	PushURL  string `json:"push_url"`
+}
+
+// toolListRemotes lists the repository's remotes in the order git remote -v prints them.
+// A repository without remotes gets an empty list.
+func toolListRemotes(args map[string]interface{}) (string, error) {
+	repoPath := os.Getenv("REPO_PATH")
+	if repoPath == "" {
+		return "", fmt.Errorf("REPO_PATH not set")
+	}
+
+	output, err := runGitCommand(repoPath, "remote", "-v")
+	if err != nil {
+		return "", fmt.Errorf("failed to list remotes: %w", err)
+	}
+
+	remotes := parseRemotes(output)
+	resultJSON, err := json.Marshal(map[string]interface{}{
+		"remotes": remotes,
+		"count":   len(remotes),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal remotes: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// parseRemotes parses git remote -v, which prints a "name<TAB>url (fetch)" and a
+// "name<TAB>url (push)" line per remote, into one entry per remote. When a remote has
+// several push URLs the first is kept. Passwords in the URLs are redacted.
+func parseRemotes(output string) []RemoteInfo {
+	remotes := []RemoteInfo{}
+	index := make(map[string]int)
+	for _, line := range strings.Split(output, "\n") {
+		name, rest, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		sep := strings.LastIndexByte(rest, ' ')
+		if sep < 0 {
+			continue
+		}
+		remoteURL, kind := redactRemoteURL(rest[:sep]), rest[sep+1:]
+
+		i, seen := index[name]
+		if !seen {
+			i = len(remotes)
+			index[name] = i
+			remotes = append(remotes, RemoteInfo{Name: name})
+		}
+		switch kind {
+		case "(fetch)":
+			remotes[i].FetchURL = remoteURL
+		case "(push)":
+			if remotes[i].PushURL == "" {
+				remotes[i].PushURL = remoteURL
+			}
+		}
+	}
+	return remotes
+}
+
+// redactRemoteURL drops the password from the userinfo of a remote URL, such as the token of
+// an https://x-access-token:<token>@host/... clone, keeping the user name. URLs without a
+// password, including scp-style user@host:path ones, are returned unchanged.
+func redactRemoteURL(remoteURL string) string {
+	u, err := url.Parse(remoteURL)
+	if err != nil || u.User == nil {
+		return remoteURL
+	}
+	if _, hasPassword := u.User.Password(); !hasPassword {
+		return remoteURL
+	}
+	u.User = url.User(u.User.Username())
+	return u.String()
+}
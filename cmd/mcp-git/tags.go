@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// tagFormat prints one NUL-separated line per tag. %(*objectname) is the commit an annotated
+// tag points to; lightweight tags point to the commit directly and have no tagger.
+const tagFormat = "%(refname:short)%00%(objecttype)%00%(objectname)%00%(*objectname)%00%(taggername)%00%(taggeremail)%00%(creatordate:iso-strict)"
+
+// TagInfo describes a tag. Date is the tagger date of an annotated tag and the commit date
+// of a lightweight one.
+type TagInfo struct {
+	Name      string `json:"name"`
+	Commit    string `json:"commit"`
+	Annotated bool   `json:"annotated"`
+	Tagger    string `json:"tagger,omitempty"`
+	Date      string `json:"date"`
+}
+
+// toolListTags lists the repository's tags newest first, optionally filtered by a
+// git tag --list pattern such as "v1.*"
+func toolListTags(args map[string]interface{}) (string, error) {
+	repoPath := os.Getenv("REPO_PATH")
+	if repoPath == "" {
+		return "", fmt.Errorf("REPO_PATH not set")
+	}
+
+	gitArgs := []string{"tag", "--list", "--sort=-creatordate", "--format=" + tagFormat}
+	if pattern, ok := args["pattern"].(string); ok && pattern != "" {
+		if err := validateTagPattern(pattern); err != nil {
+			return "", fmt.Errorf("invalid pattern: %w", err)
+		}
+		gitArgs = append(gitArgs, pattern)
+	}
+
+	output, err := runGitCommand(repoPath, gitArgs...)
+	if err != nil {
+		return "", fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	tags := parseTagList(output)
+	resultJSON, err := json.Marshal(map[string]interface{}{
+		"tags":  tags,
+		"count": len(tags),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal tags: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// parseTagList parses the output of git tag --format=tagFormat
+func parseTagList(output string) []TagInfo {
+	tags := []TagInfo{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 7 {
+			continue
+		}
+		tag := TagInfo{
+			Name:      fields[0],
+			Commit:    fields[2],
+			Annotated: fields[1] == "tag",
+			Date:      fields[6],
+		}
+		if tag.Annotated {
+			tag.Commit = fields[3]
+			tag.Tagger = strings.TrimSpace(fields[4] + " " + fields[5])
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// validateTagPattern rejects patterns git could read as an option. Glob characters are
+// allowed, since the pattern is matched with fnmatch.
+func validateTagPattern(pattern string) error {
+	if strings.HasPrefix(pattern, "-") {
+		return fmt.Errorf("pattern %q must not start with '-'", pattern)
+	}
+	for _, r := range pattern {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return fmt.Errorf("pattern %q contains invalid character %q", pattern, r)
+		}
+	}
+	return nil
+}
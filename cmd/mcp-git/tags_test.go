@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// tagAt creates a tag dated at date; an empty message makes a lightweight tag
+func tagAt(t *testing.T, dir, name, message, date string) {
+	t.Helper()
+	args := []string{"tag", name}
+	if message != "" {
+		args = []string{"tag", "-a", name, "-m", message}
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE="+date)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, string(out))
+	}
+}
+
+func TestToolListTags(t *testing.T) {
+	tmpDir := t.TempDir()
+	runGit(t, tmpDir, "init", "-b", "main")
+	runGit(t, tmpDir, "config", "user.email", "test@example.com")
+	runGit(t, tmpDir, "config", "user.name", "Test User")
+	commitFile(t, tmpDir, "a.txt", "a\n", "first")
+	tagAt(t, tmpDir, "v1.0.0", "Release 1.0.0", "2024-01-01T00:00:00Z")
+	commitFile(t, tmpDir, "b.txt", "b\n", "second")
+	tagAt(t, tmpDir, "v1.1.0", "Release 1.1.0", "2024-02-01T00:00:00Z")
+	tagAt(t, tmpDir, "nightly", "", "2024-03-01T00:00:00Z")
+
+	t.Setenv("REPO_PATH", tmpDir)
+
+	first, err := runGitCommand(tmpDir, "rev-parse", "HEAD~1")
+	if err != nil {
+		t.Fatalf("failed to resolve first commit: %v", err)
+	}
+
+	tags := listTags(t, map[string]interface{}{"pattern": "v1.*"})
+	if len(tags) != 2 || tags[0].Name != "v1.1.0" || tags[1].Name != "v1.0.0" {
+		t.Fatalf("expected v1.1.0 then v1.0.0, got %+v", tags)
+	}
+	if !tags[1].Annotated || tags[1].Commit != first {
+		t.Errorf("expected annotated v1.0.0 at %s, got %+v", first, tags[1])
+	}
+	if tags[1].Tagger != "Test User <test@example.com>" || tags[1].Date != "2024-01-01T00:00:00+00:00" {
+		t.Errorf("expected tagger and date of v1.0.0, got %+v", tags[1])
+	}
+
+	all := listTags(t, map[string]interface{}{})
+	if len(all) != 3 {
+		t.Fatalf("expected 3 tags without a pattern, got %+v", all)
+	}
+	for _, tag := range all {
+		if tag.Name == "nightly" && (tag.Annotated || tag.Tagger != "") {
+			t.Errorf("expected nightly to be a lightweight tag without tagger, got %+v", tag)
+		}
+	}
+
+	if _, err := toolListTags(map[string]interface{}{"pattern": "--contains=HEAD"}); err == nil {
+		t.Error("expected error for a pattern starting with '-'")
+	}
+}
+
+// listTags calls toolListTags and parses its tags
+func listTags(t *testing.T, args map[string]interface{}) []TagInfo {
+	t.Helper()
+	resultJSON, err := toolListTags(args)
+	if err != nil {
+		t.Fatalf("toolListTags returned error: %v", err)
+	}
+	var result struct {
+		Tags []TagInfo `json:"tags"`
+	}
+	if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
+		t.Fatalf("failed to parse result JSON: %v", err)
+	}
+	return result.Tags
+}
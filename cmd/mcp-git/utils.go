@@ -1,8 +1,11 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"unicode"
 )
 
 // resolvePath resolves a file path relative to REPO_PATH or returns absolute path
@@ -18,3 +21,23 @@ func resolvePath(path string) string {
 
 	return filepath.Join(repoPath, path)
 }
+
+// validateRefName rejects branch and commit names that git could read as an option or
+// that cannot be valid refs, before they are passed to a git command
+func validateRefName(ref string) error {
+	if ref == "" {
+		return fmt.Errorf("ref name is empty")
+	}
+	if strings.HasPrefix(ref, "-") {
+		return fmt.Errorf("ref name %q must not start with '-'", ref)
+	}
+	if strings.Contains(ref, "..") {
+		return fmt.Errorf("ref name %q must not contain '..'", ref)
+	}
+	for _, r := range ref {
+		if unicode.IsSpace(r) || unicode.IsControl(r) || strings.ContainsRune(":?*[\\", r) {
+			return fmt.Errorf("ref name %q contains invalid character %q", ref, r)
+		}
+	}
+	return nil
+}
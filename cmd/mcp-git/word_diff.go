@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// WordDiffSegment is a run of text that is unchanged ("context"), added or removed
+type WordDiffSegment struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// WordDiffLine is one line of a word diff. OldLine and NewLine are 1-based and are omitted
+// for a line that exists on one side only.
+type WordDiffLine struct {
+	OldLine  int               `json:"old_line,omitempty"`
+	NewLine  int               `json:"new_line,omitempty"`
+	Segments []WordDiffSegment `json:"segments"`
+}
+
+// WordDiffHunk is a hunk of a word diff with the line ranges from its @@ header
+type WordDiffHunk struct {
+	OldStart int            `json:"old_start"`
+	OldLines int            `json:"old_lines"`
+	NewStart int            `json:"new_start"`
+	NewLines int            `json:"new_lines"`
+	Lines    []WordDiffLine `json:"lines"`
+}
+
+// hunkHeaderPattern matches a unified diff hunk header, whose line counts default to 1
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// getFileWordDiff returns the word-level changes of relPath as JSON, using the same
+// comparison modes as the line diff of get_file_diff
+func getFileWordDiff(repoPath, relPath string, args map[string]interface{}, untracked bool) (string, error) {
+	gitArgs := wordDiffArgs(args, relPath, untracked)
+
+	cmd := exec.Command("git", gitArgs...)
+	cmd.Dir = repoPath
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		// --no-index exits with 1 when the files differ, which is always the case here
+		var exitErr *exec.ExitError
+		if !untracked || !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			return "", fmt.Errorf("failed to get word diff: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+	}
+
+	hunks := parseWordDiff(string(output))
+	added, removed := 0, 0
+	for _, hunk := range hunks {
+		for _, line := range hunk.Lines {
+			for _, segment := range line.Segments {
+				switch segment.Type {
+				case "added":
+					added++
+				case "removed":
+					removed++
+				}
+			}
+		}
+	}
+
+	resultJSON, err := json.Marshal(map[string]interface{}{
+		"file_path":        relPath,
+		"hunks":            hunks,
+		"added_segments":   added,
+		"removed_segments": removed,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal word diff: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// wordDiffArgs builds the git diff arguments for a word diff. The comparison follows the
+// same priority as get_file_diff: untracked files against /dev/null, then compare_working,
+// then base_commit..target_commit, then base_branch, where "HEAD" means the working tree.
+func wordDiffArgs(args map[string]interface{}, relPath string, untracked bool) []string {
+	if untracked {
+		return []string{"diff", "--no-index", "--word-diff=porcelain", "--", "/dev/null", relPath}
+	}
+
+	gitArgs := []string{"diff", "--word-diff=porcelain"}
+	if staged, _ := args["staged"].(bool); staged {
+		gitArgs = append(gitArgs, "--cached")
+	} else if compareWorking, _ := args["compare_working"].(bool); compareWorking {
+		gitArgs = append(gitArgs, "HEAD")
+	} else if baseCommit, _ := args["base_commit"].(string); baseCommit != "" {
+		targetCommit := "HEAD"
+		if tc, ok := args["target_commit"].(string); ok && tc != "" {
+			targetCommit = tc
+		}
+		gitArgs = append(gitArgs, baseCommit, targetCommit)
+	} else {
+		baseBranch := "main"
+		if bb, ok := args["base_branch"].(string); ok && bb != "" {
+			baseBranch = bb
+		}
+		gitArgs = append(gitArgs, baseBranch)
+	}
+	return append(gitArgs, "--", relPath)
+}
+
+// parseWordDiff parses git diff --word-diff=porcelain output. Inside a hunk, each line of
+// output is a segment prefixed by ' ', '+' or '-', and a line holding only '~' ends a line
+// of the file. The file headers before the first hunk are skipped.
+func parseWordDiff(output string) []WordDiffHunk {
+	hunks := []WordDiffHunk{}
+	var hunk *WordDiffHunk
+	var current WordDiffLine
+	oldLine, newLine := 0, 0
+
+	endLine := func() {
+		if hunk == nil {
+			return
+		}
+		hasOld, hasNew := len(current.Segments) == 0, len(current.Segments) == 0
+		for _, segment := range current.Segments {
+			hasOld = hasOld || segment.Type != "added"
+			hasNew = hasNew || segment.Type != "removed"
+		}
+		if hasOld {
+			current.OldLine = oldLine
+			oldLine++
+		}
+		if hasNew {
+			current.NewLine = newLine
+			newLine++
+		}
+		if current.Segments == nil {
+			current.Segments = []WordDiffSegment{}
+		}
+		hunk.Lines = append(hunk.Lines, current)
+		current = WordDiffLine{}
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		if m := hunkHeaderPattern.FindStringSubmatch(line); m != nil {
+			hunks = append(hunks, WordDiffHunk{
+				OldStart: atoiDefault(m[1], 0),
+				OldLines: atoiDefault(m[2], 1),
+				NewStart: atoiDefault(m[3], 0),
+				NewLines: atoiDefault(m[4], 1),
+				Lines:    []WordDiffLine{},
+			})
+			hunk = &hunks[len(hunks)-1]
+			current = WordDiffLine{}
+			oldLine, newLine = hunk.OldStart, hunk.NewStart
+			continue
+		}
+		if hunk == nil || line == "" {
+			continue
+		}
+
+		switch line[0] {
+		case ' ':
+			current.Segments = append(current.Segments, WordDiffSegment{Type: "context", Text: line[1:]})
+		case '+':
+			current.Segments = append(current.Segments, WordDiffSegment{Type: "added", Text: line[1:]})
+		case '-':
+			current.Segments = append(current.Segments, WordDiffSegment{Type: "removed", Text: line[1:]})
+		case '~':
+			endLine()
+		}
+	}
+
+	return hunks
+}
+
+// atoiDefault parses s, returning def when s is empty or not a number
+func atoiDefault(s string, def int) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return n
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWordDiffArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      map[string]interface{}
+		untracked bool
+		want      []string
+	}{
+		{"working", map[string]interface{}{"compare_working": true}, false, []string{"diff", "--word-diff=porcelain", "HEAD", "--", "f.txt"}},
+		{"commits", map[string]interface{}{"base_commit": "abc123", "target_commit": "def456"}, false, []string{"diff", "--word-diff=porcelain", "abc123", "def456", "--", "f.txt"}},
+		{"commit to HEAD", map[string]interface{}{"base_commit": "HEAD~1"}, false, []string{"diff", "--word-diff=porcelain", "HEAD~1", "HEAD", "--", "f.txt"}},
+		{"default branch", map[string]interface{}{}, false, []string{"diff", "--word-diff=porcelain", "main", "--", "f.txt"}},
+		{"branch", map[string]interface{}{"base_branch": "develop"}, false, []string{"diff", "--word-diff=porcelain", "develop", "--", "f.txt"}},
+		{"staged", map[string]interface{}{"staged": true, "compare_working": true}, false, []string{"diff", "--word-diff=porcelain", "--cached", "--", "f.txt"}},
+		{"untracked", map[string]interface{}{"compare_working": true}, true, []string{"diff", "--no-index", "--word-diff=porcelain", "--", "/dev/null", "f.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wordDiffArgs(tt.args, "f.txt", tt.untracked); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("wordDiffArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWordDiff(t *testing.T) {
+	output := "diff --git a/f b/f\nindex 15979ef..8f5c8ba 100644\n--- a/f\n+++ b/f\n" +
+		"@@ -1,3 +1,4 @@\n the quick \n-brown\n+red\n  fox\n~\n \n~\n keep\n~\n+new line\n~\n"
+
+	hunks := parseWordDiff(output)
+	if len(hunks) != 1 {
+		t.Fatalf("Expected 1 hunk, got %+v", hunks)
+	}
+	hunk := hunks[0]
+	if hunk.OldStart != 1 || hunk.OldLines != 3 || hunk.NewStart != 1 || hunk.NewLines != 4 {
+		t.Errorf("Unexpected hunk ranges: %+v", hunk)
+	}
+	if len(hunk.Lines) != 4 {
+		t.Fatalf("Expected 4 lines, got %+v", hunk.Lines)
+	}
+
+	want := []WordDiffSegment{
+		{Type: "context", Text: "the quick "},
+		{Type: "removed", Text: "brown"},
+		{Type: "added", Text: "red"},
+		{Type: "context", Text: " fox"},
+	}
+	if !reflect.DeepEqual(hunk.Lines[0].Segments, want) {
+		t.Errorf("Line 1 segments = %+v, want %+v", hunk.Lines[0].Segments, want)
+	}
+	if line := hunk.Lines[3]; line.OldLine != 0 || line.NewLine != 4 {
+		t.Errorf("Expected an added line with only a new line number, got %+v", line)
+	}
+	if line := hunk.Lines[2]; line.OldLine != 3 || line.NewLine != 3 {
+		t.Errorf("Expected keep at old line 3 and new line 3, got %+v", line)
+	}
+}
+
+func TestToolGetFileDiffWordDiff(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	runGit(t, tmpDir, "init", "-b", "main")
+	runGit(t, tmpDir, "config", "user.email", "test@example.com")
+	runGit(t, tmpDir, "config", "user.name", "Test User")
+	commitFile(t, tmpDir, "notes.txt", "the quick brown fox\njumps\n", "initial commit")
+	runGit(t, tmpDir, "checkout", "-b", "feature")
+	commitFile(t, tmpDir, "notes.txt", "the quick red fox\njumps\n", "red fox")
+	if err := os.WriteFile(filepath.Join(tmpDir, "notes.txt"), []byte("the quick red fox\njumps high\n"), 0o644); err != nil {
+		t.Fatalf("failed to write notes.txt: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "new.txt"), []byte("brand new\n"), 0o644); err != nil {
+		t.Fatalf("failed to write new.txt: %v", err)
+	}
+
+	t.Setenv("REPO_PATH", tmpDir)
+
+	tests := []struct {
+		name    string
+		args    map[string]interface{}
+		added   []string
+		removed []string
+	}{
+		{"working", map[string]interface{}{"file_path": "notes.txt", "compare_working": true}, []string{"high"}, nil},
+		{"commits", map[string]interface{}{"file_path": "notes.txt", "base_commit": "main", "target_commit": "feature"}, []string{"red"}, []string{"brown"}},
+		{"branch", map[string]interface{}{"file_path": "notes.txt", "base_branch": "main"}, []string{"red", "high"}, []string{"brown"}},
+		{"untracked", map[string]interface{}{"file_path": "new.txt"}, []string{"brand new"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args["word_diff"] = true
+			result, err := toolGetFileDiff(tt.args)
+			if err != nil {
+				t.Fatalf("toolGetFileDiff() error = %v", err)
+			}
+
+			var decoded struct {
+				Hunks           []WordDiffHunk `json:"hunks"`
+				AddedSegments   int            `json:"added_segments"`
+				RemovedSegments int            `json:"removed_segments"`
+			}
+			if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+				t.Fatalf("Failed to parse result %q: %v", result, err)
+			}
+
+			var added, removed []string
+			for _, hunk := range decoded.Hunks {
+				for _, line := range hunk.Lines {
+					for _, segment := range line.Segments {
+						switch segment.Type {
+						case "added":
+							added = append(added, strings.TrimSpace(segment.Text))
+						case "removed":
+							removed = append(removed, strings.TrimSpace(segment.Text))
+						}
+					}
+				}
+			}
+			if !reflect.DeepEqual(added, tt.added) || !reflect.DeepEqual(removed, tt.removed) {
+				t.Errorf("added = %q, removed = %q; want %q and %q", added, removed, tt.added, tt.removed)
+			}
+			if decoded.AddedSegments != len(tt.added) || decoded.RemovedSegments != len(tt.removed) {
+				t.Errorf("Unexpected counts in %s", result)
+			}
+		})
+	}
+}
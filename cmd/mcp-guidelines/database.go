@@ -1,14 +1,18 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/lib/pq"
 	_ "github.com/lib/pq"
+
+	"github.com/code-aria/internal-mcp/internal/pgschema"
 )
 
 var db *sql.DB
@@ -35,9 +39,26 @@ func initDatabase() error {
 		return fmt.Errorf("failed to set timezone to UTC: %w", err)
 	}
 
+	if err := checkSchema(); err != nil {
+		return fmt.Errorf("schema check failed: %w", err)
+	}
+
 	return nil
 }
 
+// guidelineSchema is what this server reads and writes; the statements adding it are
+// in migrations/mcp-guidelines
+var guidelineSchema = []pgschema.Requirement{
+	{Table: "guideline_categories", Columns: []string{"id", "name", "description", "color", "icon", "metadata", "is_active", "tenant_id", "created_at", "updated_at", "created_by", "updated_by"}},
+	{Table: "guidelines", Columns: []string{"id", "name", "description", "content", "category_id", "tags", "languages", "tenant_id", "is_active", "metadata", "created_at", "updated_at", "effective_from", "effective_to"}},
+}
+
+// checkSchema returns an error naming any table or column from guidelineSchema that is
+// missing. The tables are managed by the API, so the schema is never changed here.
+func checkSchema() error {
+	return pgschema.Check(context.Background(), db, "migrations/mcp-guidelines", guidelineSchema)
+}
+
 // closeDatabase closes the database connection
 func closeDatabase() error {
 	if db != nil {
@@ -46,10 +67,135 @@ func closeDatabase() error {
 	return nil
 }
 
+// guidelineColumns is the select list shared by guideline queries; scanGuideline reads it
+const guidelineColumns = `g.id, g.name, g.description, g.content, g.category_id, g.tags, g.languages, g.tenant_id, g.is_active, g.metadata, g.created_at, g.updated_at,
+		       g.effective_from, g.effective_to,
+		       gc.id, gc.name, gc.description, gc.color, gc.icon, gc.metadata, gc.is_active, gc.tenant_id, gc.created_at, gc.updated_at, gc.created_by, gc.updated_by`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanGuideline scans a row selected with guidelineColumns, followed by any extra columns
+func scanGuideline(row rowScanner, extra ...interface{}) (Guideline, error) {
+	var g Guideline
+	var description, categoryID, tenantID sql.NullString
+	var tagsJSON, metadataJSON []byte
+	var catID sql.NullString
+	var cat GuidelineCategory
+	var catName, catDescription, catColor, catIcon, catTenantID, catCreatedBy, catUpdatedBy sql.NullString
+	var catIsActive sql.NullBool
+	var catCreatedAt, catUpdatedAt sql.NullTime
+	var catMetadataJSON []byte
+	var effectiveFrom, effectiveTo sql.NullTime
+
+	dest := []interface{}{
+		&g.ID,
+		&g.Name,
+		&description,
+		&g.Content,
+		&categoryID,
+		&tagsJSON,
+		(*pq.StringArray)(&g.Languages),
+		&tenantID,
+		&g.IsActive,
+		&metadataJSON,
+		&g.CreatedAt,
+		&g.UpdatedAt,
+		&effectiveFrom,
+		&effectiveTo,
+		&catID,
+		&catName,
+		&catDescription,
+		&catColor,
+		&catIcon,
+		&catMetadataJSON,
+		&catIsActive,
+		&catTenantID,
+		&catCreatedAt,
+		&catUpdatedAt,
+		&catCreatedBy,
+		&catUpdatedBy,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return g, err
+	}
+
+	if description.Valid {
+		g.Description = description.String
+	}
+	if categoryID.Valid {
+		g.CategoryID = &categoryID.String
+	}
+	if tenantID.Valid {
+		g.TenantID = tenantID.String
+	}
+	if effectiveFrom.Valid {
+		g.EffectiveFrom = &effectiveFrom.Time
+	}
+	if effectiveTo.Valid {
+		g.EffectiveTo = &effectiveTo.Time
+	}
+
+	if catID.Valid {
+		cat.ID = catID.String
+		if catName.Valid {
+			cat.Name = catName.String
+		}
+		if catDescription.Valid {
+			cat.Description = catDescription.String
+		}
+		if catColor.Valid {
+			cat.Color = catColor.String
+		}
+		if catIcon.Valid {
+			cat.Icon = catIcon.String
+		}
+		if catIsActive.Valid {
+			cat.IsActive = catIsActive.Bool
+		}
+		if catTenantID.Valid {
+			cat.TenantID = catTenantID.String
+		}
+		if catCreatedAt.Valid {
+			cat.CreatedAt = catCreatedAt.Time
+		}
+		if catUpdatedAt.Valid {
+			cat.UpdatedAt = catUpdatedAt.Time
+		}
+		if catCreatedBy.Valid {
+			cat.CreatedBy = catCreatedBy.String
+		}
+		if catUpdatedBy.Valid {
+			cat.UpdatedBy = catUpdatedBy.String
+		}
+		if len(catMetadataJSON) > 0 {
+			if err := json.Unmarshal(catMetadataJSON, &cat.Metadata); err != nil {
+				return g, fmt.Errorf("failed to unmarshal category metadata: %w", err)
+			}
+		}
+		g.Category = &cat
+	}
+
+	if len(tagsJSON) > 0 {
+		if err := json.Unmarshal(tagsJSON, &g.Tags); err != nil {
+			return g, fmt.Errorf("failed to unmarshal tags: %w", err)
+		}
+	}
+
+	if len(metadataJSON) > 0 {
+		if err := json.Unmarshal(metadataJSON, &g.Metadata); err != nil {
+			return g, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+	}
+
+	return g, nil
+}
+
 // getGuidelines queries guidelines with optional filters
 func getGuidelines(tenantID *string, category *string, tags []string, isActive *bool, limit int) ([]Guideline, error) {
-	query := `SELECT g.id, g.name, g.description, g.content, g.category_id, g.tags, g.tenant_id, g.is_active, g.metadata, g.created_at, g.updated_at,
-		       gc.id, gc.name, gc.description, gc.color, gc.icon, gc.metadata, gc.is_active, gc.tenant_id, gc.created_at, gc.updated_at, gc.created_by, gc.updated_by
+	query := `SELECT ` + guidelineColumns + `
 		FROM guidelines g
 		LEFT JOIN guideline_categories gc ON g.category_id = gc.id
 		WHERE 1=1`
@@ -63,7 +209,7 @@ func getGuidelines(tenantID *string, category *string, tags []string, isActive *
 	}
 
 	if category != nil {
-		query += fmt.Sprintf(" AND g.category_id = $%d", argPos)
+		query += " AND " + categoryPredicate(argPos)
 		args = append(args, *category)
 		argPos++
 	}
@@ -82,11 +228,7 @@ func getGuidelines(tenantID *string, category *string, tags []string, isActive *
 	// Handle tags filter - check if any tag matches
 	if len(tags) > 0 {
 		query += fmt.Sprintf(" AND g.tags ?| $%d", argPos)
-		tagsJSON, err := json.Marshal(tags)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal tags: %w", err)
-		}
-		args = append(args, string(tagsJSON))
+		args = append(args, pq.Array(tags))
 		argPos++
 	}
 
@@ -101,107 +243,10 @@ func getGuidelines(tenantID *string, category *string, tags []string, isActive *
 
 	var guidelines []Guideline
 	for rows.Next() {
-		var g Guideline
-		var description, categoryID, tenantID sql.NullString
-		var tagsJSON, metadataJSON []byte
-		var catID sql.NullString
-		var cat GuidelineCategory
-		var catName, catDescription, catColor, catIcon, catTenantID, catCreatedBy, catUpdatedBy sql.NullString
-		var catIsActive sql.NullBool
-		var catCreatedAt, catUpdatedAt sql.NullTime
-		var catMetadataJSON []byte
-
-		err := rows.Scan(
-			&g.ID,
-			&g.Name,
-			&description,
-			&g.Content,
-			&categoryID,
-			&tagsJSON,
-			&tenantID,
-			&g.IsActive,
-			&metadataJSON,
-			&g.CreatedAt,
-			&g.UpdatedAt,
-			&catID,
-			&catName,
-			&catDescription,
-			&catColor,
-			&catIcon,
-			&catMetadataJSON,
-			&catIsActive,
-			&catTenantID,
-			&catCreatedAt,
-			&catUpdatedAt,
-			&catCreatedBy,
-			&catUpdatedBy,
-		)
+		g, err := scanGuideline(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan guideline: %w", err)
 		}
-
-		if description.Valid {
-			g.Description = description.String
-		}
-		if categoryID.Valid {
-			g.CategoryID = &categoryID.String
-		}
-		if tenantID.Valid {
-			g.TenantID = tenantID.String
-		}
-
-		if catID.Valid {
-			cat.ID = catID.String
-			if catName.Valid {
-				cat.Name = catName.String
-			}
-			if catDescription.Valid {
-				cat.Description = catDescription.String
-			}
-			if catColor.Valid {
-				cat.Color = catColor.String
-			}
-			if catIcon.Valid {
-				cat.Icon = catIcon.String
-			}
-			if catIsActive.Valid {
-				cat.IsActive = catIsActive.Bool
-			}
-			if catTenantID.Valid {
-				cat.TenantID = catTenantID.String
-			}
-			if catCreatedAt.Valid {
-				cat.CreatedAt = catCreatedAt.Time
-			}
-			if catUpdatedAt.Valid {
-				cat.UpdatedAt = catUpdatedAt.Time
-			}
-			if catCreatedBy.Valid {
-				cat.CreatedBy = catCreatedBy.String
-			}
-			if catUpdatedBy.Valid {
-				cat.UpdatedBy = catUpdatedBy.String
-			}
-			if len(catMetadataJSON) > 0 {
-				if err := json.Unmarshal(catMetadataJSON, &cat.Metadata); err != nil {
-					return nil, fmt.Errorf("failed to unmarshal category metadata: %w", err)
-				}
-			}
-			g.Category = &cat
-		}
-
-		if len(tagsJSON) > 0 {
-			if err := json.Unmarshal(tagsJSON, &g.Tags); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal tags: %w", err)
-			}
-		}
-
-		if len(metadataJSON) > 0 {
-			if err := json.Unmarshal(metadataJSON, &g.Metadata); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
-			}
-		}
-
 		guidelines = append(guidelines, g)
 	}
 
@@ -215,8 +260,7 @@ func getGuidelinesByIDs(ids []string) ([]Guideline, error) {
 	}
 
 	// Build query with ANY array and JOIN category
-	query := `SELECT g.id, g.name, g.description, g.content, g.category_id, g.tags, g.tenant_id, g.is_active, g.metadata, g.created_at, g.updated_at,
-		       gc.id, gc.name, gc.description, gc.color, gc.icon, gc.metadata, gc.is_active, gc.tenant_id, gc.created_at, gc.updated_at, gc.created_by, gc.updated_by
+	query := `SELECT ` + guidelineColumns + `
 		FROM guidelines g
 		LEFT JOIN guideline_categories gc ON g.category_id = gc.id
 		WHERE g.id = ANY($1)`
@@ -228,121 +272,175 @@ func getGuidelinesByIDs(ids []string) ([]Guideline, error) {
 
 	var guidelines []Guideline
 	for rows.Next() {
-		var g Guideline
-		var description, categoryID, tenantID sql.NullString
-		var tagsJSON, metadataJSON []byte
-		var catID sql.NullString
-		var cat GuidelineCategory
-		var catName, catDescription, catColor, catIcon, catTenantID, catCreatedBy, catUpdatedBy sql.NullString
-		var catIsActive sql.NullBool
-		var catCreatedAt, catUpdatedAt sql.NullTime
-		var catMetadataJSON []byte
-
-		err := rows.Scan(
-			&g.ID,
-			&g.Name,
-			&description,
-			&g.Content,
-			&categoryID,
-			&tagsJSON,
-			&tenantID,
-			&g.IsActive,
-			&metadataJSON,
-			&g.CreatedAt,
-			&g.UpdatedAt,
-			&catID,
-			&catName,
-			&catDescription,
-			&catColor,
-			&catIcon,
-			&catMetadataJSON,
-			&catIsActive,
-			&catTenantID,
-			&catCreatedAt,
-			&catUpdatedAt,
-			&catCreatedBy,
-			&catUpdatedBy,
-		)
+		g, err := scanGuideline(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan guideline: %w", err)
 		}
+		guidelines = append(guidelines, g)
+	}
 
-		if description.Valid {
-			g.Description = description.String
-		}
-		if categoryID.Valid {
-			g.CategoryID = &categoryID.String
-		}
-		if tenantID.Valid {
-			g.TenantID = tenantID.String
-		}
+	return guidelines, rows.Err()
+}
 
-		if catID.Valid {
-			cat.ID = catID.String
-			if catName.Valid {
-				cat.Name = catName.String
-			}
-			if catDescription.Valid {
-				cat.Description = catDescription.String
-			}
-			if catColor.Valid {
-				cat.Color = catColor.String
-			}
-			if catIcon.Valid {
-				cat.Icon = catIcon.String
-			}
-			if catIsActive.Valid {
-				cat.IsActive = catIsActive.Bool
-			}
-			if catTenantID.Valid {
-				cat.TenantID = catTenantID.String
-			}
-			if catCreatedAt.Valid {
-				cat.CreatedAt = catCreatedAt.Time
-			}
-			if catUpdatedAt.Valid {
-				cat.UpdatedAt = catUpdatedAt.Time
-			}
-			if catCreatedBy.Valid {
-				cat.CreatedBy = catCreatedBy.String
-			}
-			if catUpdatedBy.Valid {
-				cat.UpdatedBy = catUpdatedBy.String
-			}
-			if len(catMetadataJSON) > 0 {
-				if err := json.Unmarshal(catMetadataJSON, &cat.Metadata); err != nil {
-					return nil, fmt.Errorf("failed to unmarshal category metadata: %w", err)
-				}
-			}
-			g.Category = &cat
-		}
+// categoryPredicate matches the guideline's category by id or, case-insensitively, by the
+// name of the gc join, against the parameter at argPos
+func categoryPredicate(argPos int) string {
+	return fmt.Sprintf("(g.category_id::text = lower($%[1]d) OR lower(gc.name) = lower($%[1]d))", argPos)
+}
 
-		if len(tagsJSON) > 0 {
-			if err := json.Unmarshal(tagsJSON, &g.Tags); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal tags: %w", err)
-			}
-		}
+// buildSearchGuidelinesQuery builds the parameterized search query. Guidelines whose name,
+// description or content contain every word of searchTerm are ranked with ts_rank, weighting
+// name matches above description matches above content matches; a verbatim ILIKE match is
+// also accepted so partial words still find results, with '%' and '_' in searchTerm matched
+// literally. category is a category id or name, as for create_guideline. Only active
+// guidelines are searched unless isActive says otherwise.
+func buildSearchGuidelinesQuery(searchTerm string, tenantID *string, category *string, isActive *bool, limit int) (string, []interface{}) {
+	document := `setweight(to_tsvector('english', coalesce(g.name, '')), 'A') ||
+		       setweight(to_tsvector('english', coalesce(g.description, '')), 'B') ||
+		       setweight(to_tsvector('english', coalesce(g.content, '')), 'C')`
 
-		if len(metadataJSON) > 0 {
-			if err := json.Unmarshal(metadataJSON, &g.Metadata); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
-			}
+	args := []interface{}{}
+	argPos := 1
+
+	rank := "0::real"
+	where := " WHERE 1=1"
+
+	if searchTerm != "" {
+		rank = fmt.Sprintf("ts_rank(%s, plainto_tsquery('english', $%d))", document, argPos)
+		where += fmt.Sprintf(` AND (%s @@ plainto_tsquery('english', $%d)
+		       OR g.name ILIKE $%[3]d ESCAPE '\' OR g.description ILIKE $%[3]d ESCAPE '\' OR g.content ILIKE $%[3]d ESCAPE '\')`,
+			document, argPos, argPos+1)
+		args = append(args, searchTerm, "%"+escapeLike(searchTerm)+"%")
+		argPos += 2
+	}
+
+	if tenantID != nil {
+		where += fmt.Sprintf(" AND g.tenant_id = $%d", argPos)
+		args = append(args, *tenantID)
+		argPos++
+	}
+
+	if category != nil {
+		where += " AND " + categoryPredicate(argPos)
+		args = append(args, *category)
+		argPos++
+	}
+
+	// Default to active only if not specified
+	active := true
+	if isActive != nil {
+		active = *isActive
+	}
+	where += fmt.Sprintf(" AND g.is_active = $%d", argPos)
+	args = append(args, active)
+	argPos++
+
+	query := `SELECT ` + guidelineColumns + `, ` + rank + ` AS rank
+		FROM guidelines g
+		LEFT JOIN guideline_categories gc ON g.category_id = gc.id` + where
+	query += fmt.Sprintf(" ORDER BY rank DESC, g.created_at DESC LIMIT $%d", argPos)
+	args = append(args, limit)
+
+	return query, args
+}
+
+// escapeLike escapes the LIKE wildcards '%' and '_' (and the escape character itself)
+// so that s matches literally in a LIKE/ILIKE pattern using ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// searchGuidelines searches guidelines by keyword and/or category, most relevant first
+func searchGuidelines(searchTerm string, tenantID *string, category *string, isActive *bool, limit int) ([]Guideline, error) {
+	query, args := buildSearchGuidelinesQuery(searchTerm, tenantID, category, isActive, limit)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search guidelines: %w", err)
+	}
+	defer rows.Close()
+
+	guidelines := []Guideline{}
+	for rows.Next() {
+		var rank float64
+		g, err := scanGuideline(rows, &rank)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan guideline: %w", err)
 		}
+		g.Rank = &rank
+		guidelines = append(guidelines, g)
+	}
+
+	return guidelines, rows.Err()
+}
+
+// buildApplicableGuidelinesQuery builds the parameterized query for guidelines that apply to
+// any of the given languages or carry any of the given tags.
+func buildApplicableGuidelinesQuery(languages []string, tags []string, tenantID *string, limit int) (string, []interface{}) {
+	query := `SELECT ` + guidelineColumns + `
+		FROM guidelines g
+		LEFT JOIN guideline_categories gc ON g.category_id = gc.id
+		WHERE g.is_active = true`
+	args := []interface{}{}
+	argPos := 1
+
+	if len(languages) > 0 {
+		query += fmt.Sprintf(" AND g.languages && $%d", argPos)
+		args = append(args, pq.Array(languages))
+		argPos++
+	}
+
+	if len(tags) > 0 {
+		query += fmt.Sprintf(" AND g.tags ?| $%d", argPos)
+		args = append(args, pq.Array(tags))
+		argPos++
+	}
+
+	if tenantID != nil {
+		query += fmt.Sprintf(" AND g.tenant_id = $%d", argPos)
+		args = append(args, *tenantID)
+		argPos++
+	}
 
+	query += fmt.Sprintf(" ORDER BY g.name ASC LIMIT $%d", argPos)
+	args = append(args, limit)
+
+	return query, args
+}
+
+// getApplicableGuidelines returns active guidelines applicable to the given languages and tags
+func getApplicableGuidelines(languages []string, tags []string, tenantID *string, limit int) ([]Guideline, error) {
+	query, args := buildApplicableGuidelinesQuery(languages, tags, tenantID, limit)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query applicable guidelines: %w", err)
+	}
+	defer rows.Close()
+
+	guidelines := []Guideline{}
+	for rows.Next() {
+		g, err := scanGuideline(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan guideline: %w", err)
+		}
 		guidelines = append(guidelines, g)
 	}
 
 	return guidelines, rows.Err()
 }
 
-// searchGuidelines searches guidelines by name, description, or content
-func searchGuidelines(searchTerm string, tenantID *string, category *string, limit int) ([]Guideline, error) {
-	query := `SELECT g.id, g.name, g.description, g.content, g.category_id, g.tags, g.tenant_id, g.is_active, g.metadata, g.created_at, g.updated_at,
-		       gc.id, gc.name, gc.description, gc.color, gc.icon, gc.metadata, gc.is_active, gc.tenant_id, gc.created_at, gc.updated_at, gc.created_by, gc.updated_by
+// buildGuidelinesAsOfQuery builds the parameterized query for active guidelines in effect at
+// asOf: effective_from is unset or not after it, and effective_to is unset or after it.
+// category is a category id or name, as for search_guidelines.
+func buildGuidelinesAsOfQuery(asOf time.Time, tenantID *string, category *string, limit int) (string, []interface{}) {
+	query := `SELECT ` + guidelineColumns + `
 		FROM guidelines g
 		LEFT JOIN guideline_categories gc ON g.category_id = gc.id
-		WHERE (g.name ILIKE $1 OR g.description ILIKE $1 OR g.content ILIKE $1)`
-	args := []interface{}{"%" + searchTerm + "%"}
+		WHERE g.is_active = true
+		  AND (g.effective_from IS NULL OR g.effective_from <= $1)
+		  AND (g.effective_to IS NULL OR g.effective_to > $1)`
+	args := []interface{}{asOf}
 	argPos := 2
 
 	if tenantID != nil {
@@ -352,127 +450,231 @@ func searchGuidelines(searchTerm string, tenantID *string, category *string, lim
 	}
 
 	if category != nil {
-		query += fmt.Sprintf(" AND g.category_id = $%d", argPos)
+		query += " AND " + categoryPredicate(argPos)
 		args = append(args, *category)
 		argPos++
 	}
 
-	query += fmt.Sprintf(" ORDER BY g.created_at DESC LIMIT $%d", argPos)
+	query += fmt.Sprintf(" ORDER BY g.name ASC LIMIT $%d", argPos)
 	args = append(args, limit)
 
+	return query, args
+}
+
+// getGuidelinesAsOf returns the active guidelines in effect at asOf
+func getGuidelinesAsOf(asOf time.Time, tenantID *string, category *string, limit int) ([]Guideline, error) {
+	query, args := buildGuidelinesAsOfQuery(asOf, tenantID, category, limit)
+
 	rows, err := db.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search guidelines: %w", err)
+		return nil, fmt.Errorf("failed to query guidelines as of %s: %w", asOf.Format(time.RFC3339), err)
 	}
 	defer rows.Close()
 
-	var guidelines []Guideline
+	guidelines := []Guideline{}
 	for rows.Next() {
-		var g Guideline
-		var description, categoryID, tenantID sql.NullString
-		var tagsJSON, metadataJSON []byte
-		var catID sql.NullString
-		var cat GuidelineCategory
-		var catName, catDescription, catColor, catIcon, catTenantID, catCreatedBy, catUpdatedBy sql.NullString
-		var catIsActive sql.NullBool
-		var catCreatedAt, catUpdatedAt sql.NullTime
-		var catMetadataJSON []byte
-
-		err := rows.Scan(
-			&g.ID,
-			&g.Name,
-			&description,
-			&g.Content,
-			&categoryID,
-			&tagsJSON,
-			&tenantID,
-			&g.IsActive,
-			&metadataJSON,
-			&g.CreatedAt,
-			&g.UpdatedAt,
-			&catID,
-			&catName,
-			&catDescription,
-			&catColor,
-			&catIcon,
-			&catMetadataJSON,
-			&catIsActive,
-			&catTenantID,
-			&catCreatedAt,
-			&catUpdatedAt,
-			&catCreatedBy,
-			&catUpdatedBy,
-		)
+		g, err := scanGuideline(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan guideline: %w", err)
 		}
+		guidelines = append(guidelines, g)
+	}
 
-		if description.Valid {
-			g.Description = description.String
-		}
-		if categoryID.Valid {
-			g.CategoryID = &categoryID.String
-		}
-		if tenantID.Valid {
-			g.TenantID = tenantID.String
-		}
+	return guidelines, rows.Err()
+}
 
-		if catID.Valid {
-			cat.ID = catID.String
-			if catName.Valid {
-				cat.Name = catName.String
-			}
-			if catDescription.Valid {
-				cat.Description = catDescription.String
-			}
-			if catColor.Valid {
-				cat.Color = catColor.String
-			}
-			if catIcon.Valid {
-				cat.Icon = catIcon.String
-			}
-			if catIsActive.Valid {
-				cat.IsActive = catIsActive.Bool
-			}
-			if catTenantID.Valid {
-				cat.TenantID = catTenantID.String
-			}
-			if catCreatedAt.Valid {
-				cat.CreatedAt = catCreatedAt.Time
-			}
-			if catUpdatedAt.Valid {
-				cat.UpdatedAt = catUpdatedAt.Time
-			}
-			if catCreatedBy.Valid {
-				cat.CreatedBy = catCreatedBy.String
-			}
-			if catUpdatedBy.Valid {
-				cat.UpdatedBy = catUpdatedBy.String
-			}
-			if len(catMetadataJSON) > 0 {
-				if err := json.Unmarshal(catMetadataJSON, &cat.Metadata); err != nil {
-					return nil, fmt.Errorf("failed to unmarshal category metadata: %w", err)
-				}
-			}
-			g.Category = &cat
-		}
+// Guideline CRUD functions
+func createGuideline(g *Guideline) error {
+	query := `
+		INSERT INTO guidelines (id, name, description, content, category_id, tags, languages, tenant_id, is_active, metadata, created_at, updated_at, effective_from, effective_to)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`
 
-		if len(tagsJSON) > 0 {
-			if err := json.Unmarshal(tagsJSON, &g.Tags); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal tags: %w", err)
-			}
+	if g.Tags == nil {
+		g.Tags = []string{}
+	}
+	tagsJSON, err := json.Marshal(g.Tags)
+	if err != nil {
+		return fmt.Errorf("failed to marshal tags: %w", err)
+	}
+
+	metadataJSON, err := json.Marshal(g.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	var tenantID sql.NullString
+	if g.TenantID != "" {
+		tenantID = sql.NullString{String: g.TenantID, Valid: true}
+	}
+
+	_, err = db.Exec(query,
+		g.ID,
+		g.Name,
+		g.Description,
+		g.Content,
+		g.CategoryID,
+		string(tagsJSON),
+		pq.Array(g.Languages),
+		tenantID,
+		g.IsActive,
+		metadataJSON,
+		g.CreatedAt,
+		g.UpdatedAt,
+		g.EffectiveFrom,
+		g.EffectiveTo,
+	)
+
+	return err
+}
+
+// getGuideline retrieves a single guideline by ID, including inactive ones
+func getGuideline(id string) (*Guideline, error) {
+	query := `SELECT ` + guidelineColumns + `
+		FROM guidelines g
+		LEFT JOIN guideline_categories gc ON g.category_id = gc.id
+		WHERE g.id = $1`
+
+	g, err := scanGuideline(db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("guideline not found: %s", id)
 		}
+		return nil, err
+	}
 
-		if len(metadataJSON) > 0 {
-			if err := json.Unmarshal(metadataJSON, &g.Metadata); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
-			}
+	return &g, nil
+}
+
+// updateGuideline applies the non-nil fields of update to a guideline
+func updateGuideline(id string, update GuidelineUpdate) error {
+	query := `
+		UPDATE guidelines
+		SET name = COALESCE($2, name),
+		    description = COALESCE($3, description),
+		    content = COALESCE($4, content),
+		    category_id = COALESCE($5, category_id),
+		    tags = COALESCE($6::jsonb, tags),
+		    languages = COALESCE($7::text[], languages),
+		    is_active = COALESCE($8, is_active),
+		    updated_at = $9,
+		    effective_from = CASE WHEN $12 THEN NULL ELSE COALESCE($10, effective_from) END,
+		    effective_to = CASE WHEN $13 THEN NULL ELSE COALESCE($11, effective_to) END
+		WHERE id = $1
+	`
+
+	var tags, languages interface{}
+	if update.Tags != nil {
+		tagsJSON, err := json.Marshal(*update.Tags)
+		if err != nil {
+			return fmt.Errorf("failed to marshal tags: %w", err)
 		}
+		tags = string(tagsJSON)
+	}
+	if update.Languages != nil {
+		languages = pq.Array(*update.Languages)
+	}
 
-		guidelines = append(guidelines, g)
+	result, err := db.Exec(query,
+		id,
+		update.Name,
+		update.Description,
+		update.Content,
+		update.CategoryID,
+		tags,
+		languages,
+		update.IsActive,
+		time.Now().UTC(),
+		update.EffectiveFrom,
+		update.EffectiveTo,
+		update.ClearEffectiveFrom,
+		update.ClearEffectiveTo,
+	)
+	if err != nil {
+		return err
 	}
 
-	return guidelines, rows.Err()
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("guideline not found: %s", id)
+	}
+
+	return nil
+}
+
+// deleteGuideline soft-deletes a guideline by marking it inactive
+func deleteGuideline(id string) error {
+	query := `
+		UPDATE guidelines
+		SET is_active = false, updated_at = $2
+		WHERE id = $1
+	`
+
+	result, err := db.Exec(query, id, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("guideline not found: %s", id)
+	}
+
+	return nil
+}
+
+// resolveCategoryID returns the id of the category named by category, which is either its
+// id or its name. Names are matched case-insensitively among the categories of tenantID and
+// those without a tenant, or among all categories when tenantID is nil.
+func resolveCategoryID(category string, tenantID *string) (string, error) {
+	var id string
+	err := db.QueryRow(`SELECT id FROM guideline_categories WHERE id::text = lower($1)`, category).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to look up category: %w", err)
+	}
+
+	query := `SELECT id FROM guideline_categories WHERE lower(name) = lower($1)`
+	args := []interface{}{category}
+	if tenantID != nil {
+		query += ` AND (tenant_id = $2 OR tenant_id IS NULL)`
+		args = append(args, *tenantID)
+	}
+	rows, err := db.Query(query+` LIMIT 2`, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up category: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		if err := rows.Scan(&id); err != nil {
+			return "", fmt.Errorf("failed to scan category id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("failed to look up category: %w", err)
+	}
+
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("category not found: %s", category)
+	case 1:
+		return ids[0], nil
+	default:
+		return "", fmt.Errorf("several categories are named %q; pass the category id instead", category)
+	}
 }
 
 // Category CRUD functions
@@ -643,6 +845,52 @@ func listCategories(tenantID *string, isActive *bool) ([]GuidelineCategory, erro
 	return categories, rows.Err()
 }
 
+// countGuidelinesByCategory returns every category used by a guideline with the number of
+// matching guidelines in it, largest first. Uncategorized guidelines are not counted.
+func countGuidelinesByCategory(tenantID *string, isActive *bool) ([]CategoryCount, error) {
+	query := `
+		SELECT g.category_id, gc.name, COUNT(*)
+		FROM guidelines g
+		LEFT JOIN guideline_categories gc ON gc.id = g.category_id
+		WHERE g.category_id IS NOT NULL
+	`
+	args := []interface{}{}
+	argPos := 1
+
+	if tenantID != nil {
+		query += fmt.Sprintf(" AND g.tenant_id = $%d", argPos)
+		args = append(args, *tenantID)
+		argPos++
+	}
+
+	if isActive != nil {
+		query += fmt.Sprintf(" AND g.is_active = $%d", argPos)
+		args = append(args, *isActive)
+		argPos++
+	}
+
+	query += " GROUP BY g.category_id, gc.name ORDER BY COUNT(*) DESC, g.category_id"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count guidelines by category: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []CategoryCount{}
+	for rows.Next() {
+		var count CategoryCount
+		var name sql.NullString
+		if err := rows.Scan(&count.Category, &name, &count.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan category count: %w", err)
+		}
+		count.Name = name.String
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
+
 func updateCategory(category *GuidelineCategory) error {
 	query := `
 		UPDATE guideline_categories
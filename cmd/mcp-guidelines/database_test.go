@@ -0,0 +1,553 @@
+package main
+
+import (
+	"crypto/sha1"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/code-aria/internal-mcp/internal/filelang"
+)
+
+// setupTestDatabase connects to GUIDELINES_DB_DSN and creates session-local guidelines and
+// guideline_categories tables that shadow the real ones for the duration of the test. They
+// have the real column types, so ids must be UUIDs and categories must exist.
+func setupTestDatabase(t *testing.T) {
+	t.Helper()
+
+	dsn := os.Getenv("GUIDELINES_DB_DSN")
+	if dsn == "" {
+		t.Skip("GUIDELINES_DB_DSN environment variable not set, skipping database tests")
+	}
+
+	testDB, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := testDB.Ping(); err != nil {
+		testDB.Close()
+		t.Fatalf("Failed to ping database: %v", err)
+	}
+
+	// Temp tables are per-session, so pin the pool to a single connection
+	testDB.SetMaxOpenConns(1)
+
+	statements := []string{
+		`CREATE TEMP TABLE guideline_categories (
+			id UUID PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			description TEXT,
+			color VARCHAR(50),
+			icon VARCHAR(100),
+			metadata JSONB,
+			is_active BOOLEAN NOT NULL DEFAULT true,
+			tenant_id VARCHAR(255),
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			created_by VARCHAR(255),
+			updated_by VARCHAR(255)
+		)`,
+		`CREATE TEMP TABLE guidelines (
+			id UUID PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			description TEXT,
+			content TEXT NOT NULL,
+			category_id UUID REFERENCES guideline_categories(id),
+			tags JSONB NOT NULL DEFAULT '[]',
+			tenant_id VARCHAR(255),
+			is_active BOOLEAN NOT NULL DEFAULT true,
+			metadata JSONB,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			languages TEXT[] NOT NULL DEFAULT '{}',
+			effective_from TIMESTAMPTZ,
+			effective_to TIMESTAMPTZ
+		)`,
+	}
+	for _, stmt := range statements {
+		if _, err := testDB.Exec(stmt); err != nil {
+			testDB.Close()
+			t.Fatalf("Failed to create temp table: %v", err)
+		}
+	}
+
+	db = testDB
+	t.Cleanup(func() {
+		testDB.Close()
+		db = nil
+	})
+}
+
+// testID returns the UUID standing for key in tests, the same for the same key, so tests
+// can name rows readably while the columns hold UUIDs
+func testID(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// insertTestCategory inserts the category testID(key) named name, unless it exists
+func insertTestCategory(t *testing.T, key, name string) {
+	t.Helper()
+
+	_, err := db.Exec(`INSERT INTO guideline_categories (id, name) VALUES ($1, $2) ON CONFLICT (id) DO NOTHING`, testID(key), name)
+	if err != nil {
+		t.Fatalf("Failed to insert category %s: %v", key, err)
+	}
+}
+
+// insertTestGuideline inserts the guideline testID(id) into the temp guidelines table, in
+// the category testID(category), which is created named category if missing
+func insertTestGuideline(t *testing.T, id, name, description, content, category string, languages ...string) {
+	t.Helper()
+
+	insertTestCategory(t, category, category)
+	_, err := db.Exec(
+		`INSERT INTO guidelines (id, name, description, content, category_id, tags, languages) VALUES ($1, $2, $3, $4, $5, '[]', $6)`,
+		testID(id), name, description, content, testID(category), pq.Array(languages),
+	)
+	if err != nil {
+		t.Fatalf("Failed to insert guideline %s: %v", id, err)
+	}
+}
+
+// TestBuildSearchGuidelinesQuery verifies search terms and filters are passed as parameters
+func TestBuildSearchGuidelinesQuery(t *testing.T) {
+	category := "cat-1"
+	query, args := buildSearchGuidelinesQuery("error handling'; DELETE FROM guidelines; --", nil, &category, nil, 5)
+
+	if strings.Contains(query, "DELETE FROM") {
+		t.Fatal("Search term must not be interpolated into the SQL query")
+	}
+	for _, want := range []string{"plainto_tsquery('english', $1)", "ILIKE $2 ESCAPE '\\'", "g.category_id::text = lower($3)", "lower(gc.name) = lower($3)", "g.is_active = $4", "LIMIT $5", "ORDER BY rank DESC"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("Expected query to contain %q", want)
+		}
+	}
+	if len(args) != 5 {
+		t.Fatalf("Expected 5 args, got %d", len(args))
+	}
+	if args[3] != true {
+		t.Errorf("Expected active guidelines only by default, got is_active = %v", args[3])
+	}
+
+	// Category-only search skips the text match entirely
+	inactive := false
+	query, args = buildSearchGuidelinesQuery("", nil, &category, &inactive, 5)
+	if strings.Contains(query, "plainto_tsquery") {
+		t.Error("Expected no text match for a category-only search")
+	}
+	if !strings.Contains(query, "g.category_id::text = lower($1)") || len(args) != 3 {
+		t.Fatalf("Expected category, is_active and limit args only, got %d args", len(args))
+	}
+	if args[1] != false {
+		t.Errorf("Expected is_active = false to be passed through, got %v", args[1])
+	}
+
+	// LIKE wildcards in the search term match literally
+	_, args = buildSearchGuidelinesQuery(`100%_done\`, nil, nil, nil, 5)
+	if want := `%100\%\_done\\%`; args[1] != want {
+		t.Errorf("Expected ILIKE pattern %q, got %v", want, args[1])
+	}
+}
+
+// TestSearchGuidelinesRanking tests that guidelines are returned most relevant first
+func TestSearchGuidelinesRanking(t *testing.T) {
+	setupTestDatabase(t)
+
+	insertTestGuideline(t, "g-content", "Logging", "How to log", "Wrap errors before returning them.", "backend")
+	insertTestGuideline(t, "g-name", "Error handling", "Errors in Go services", "Always wrap errors with context.", "backend")
+	insertTestGuideline(t, "g-other", "Naming", "Naming conventions", "Use short receiver names.", "style")
+
+	guidelines, err := searchGuidelines("errors", nil, nil, nil, 10)
+	if err != nil {
+		t.Fatalf("searchGuidelines() error = %v", err)
+	}
+	if len(guidelines) != 2 {
+		t.Fatalf("Expected 2 matching guidelines, got %d", len(guidelines))
+	}
+	if guidelines[0].ID != testID("g-name") {
+		t.Errorf("Expected the name match to rank first, got %s", guidelines[0].ID)
+	}
+	if guidelines[0].Rank == nil || guidelines[1].Rank == nil || *guidelines[0].Rank < *guidelines[1].Rank {
+		t.Error("Expected results ordered by descending rank")
+	}
+	if guidelines[0].Content == "" || guidelines[0].CategoryID == nil || *guidelines[0].CategoryID != testID("backend") {
+		t.Errorf("Expected content and category on results, got %+v", guidelines[0])
+	}
+
+	category := testID("style")
+	guidelines, err = searchGuidelines("", nil, &category, nil, 10)
+	if err != nil {
+		t.Fatalf("searchGuidelines(category) error = %v", err)
+	}
+	if len(guidelines) != 1 || guidelines[0].ID != testID("g-other") {
+		t.Errorf("Expected only the style guideline, got %+v", guidelines)
+	}
+
+	categoryName := "STYLE"
+	guidelines, err = searchGuidelines("", nil, &categoryName, nil, 10)
+	if err != nil {
+		t.Fatalf("searchGuidelines(category name) error = %v", err)
+	}
+	if len(guidelines) != 1 || guidelines[0].ID != testID("g-other") {
+		t.Errorf("Expected the category to be matched by name, got %+v", guidelines)
+	}
+
+	guidelines, err = searchGuidelines("kubernetes", nil, nil, nil, 10)
+	if err != nil {
+		t.Fatalf("searchGuidelines(no match) error = %v", err)
+	}
+	if len(guidelines) != 0 {
+		t.Errorf("Expected no matches, got %d", len(guidelines))
+	}
+}
+
+// TestGetApplicableGuidelines tests filtering guidelines by language and the no-match case
+func TestGetApplicableGuidelines(t *testing.T) {
+	setupTestDatabase(t)
+
+	insertTestGuideline(t, "g-go", "Go errors", "", "Wrap errors with %w.", "backend", "go")
+	insertTestGuideline(t, "g-ts", "TS strictness", "", "Enable strict mode.", "frontend", "typescript", "javascript")
+	insertTestGuideline(t, "g-general", "Commit messages", "", "Use imperative mood.", "process")
+
+	t.Run("Language filter", func(t *testing.T) {
+		result, err := toolGetApplicableGuidelines(map[string]interface{}{"language": "Go"})
+		if err != nil {
+			t.Fatalf("toolGetApplicableGuidelines() error = %v", err)
+		}
+
+		var guidelines []Guideline
+		if err := json.Unmarshal([]byte(result), &guidelines); err != nil {
+			t.Fatalf("Failed to parse result: %v", err)
+		}
+		if len(guidelines) != 1 || guidelines[0].ID != testID("g-go") {
+			t.Errorf("Expected only the Go guideline, got %+v", guidelines)
+		}
+	})
+
+	t.Run("File path filter", func(t *testing.T) {
+		guidelines, err := getApplicableGuidelines([]string{filelang.ForPath("web/app.tsx")}, nil, nil, 10)
+		if err != nil {
+			t.Fatalf("getApplicableGuidelines() error = %v", err)
+		}
+		if len(guidelines) != 1 || guidelines[0].ID != testID("g-ts") {
+			t.Errorf("Expected only the TypeScript guideline, got %+v", guidelines)
+		}
+	})
+
+	t.Run("No match returns empty array", func(t *testing.T) {
+		result, err := toolGetApplicableGuidelines(map[string]interface{}{"language": "haskell"})
+		if err != nil {
+			t.Fatalf("toolGetApplicableGuidelines() error = %v", err)
+		}
+		if result != "[]" {
+			t.Errorf("Expected empty JSON array, got %s", result)
+		}
+	})
+}
+
+// TestBuildGuidelinesAsOfQuery verifies the date and filters are passed as parameters
+func TestBuildGuidelinesAsOfQuery(t *testing.T) {
+	asOf := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	tenantID := "tenant-1'; DROP TABLE guidelines; --"
+	category := "Security"
+	query, args := buildGuidelinesAsOfQuery(asOf, &tenantID, &category, 10)
+
+	if strings.Contains(query, "DROP TABLE") || strings.Contains(query, "2025") || strings.Contains(query, "Security") {
+		t.Fatal("Values must not be interpolated into the SQL query")
+	}
+	for _, want := range []string{"g.effective_from <= $1", "g.effective_to > $1", "g.tenant_id = $2", "g.category_id::text = lower($3)", "lower(gc.name) = lower($3)", "LIMIT $4"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("Expected query to contain %q", want)
+		}
+	}
+	if len(args) != 4 || args[0] != asOf {
+		t.Errorf("Expected the date as the first of 4 args, got %v", args)
+	}
+}
+
+// TestGetGuidelinesAsOf tests that only guidelines effective on the given date are returned
+func TestGetGuidelinesAsOf(t *testing.T) {
+	setupTestDatabase(t)
+
+	insertTestGuideline(t, "g-always", "Always", "", "No period set.", "backend")
+	insertTestGuideline(t, "g-current", "Current", "", "Effective since January.", "backend")
+	insertTestGuideline(t, "g-future", "Future", "", "Effective from June.", "backend")
+	insertTestGuideline(t, "g-expired", "Expired", "", "Replaced in February.", "backend")
+	periods := map[string][2]interface{}{
+		"g-current": {"2025-01-01T00:00:00Z", nil},
+		"g-future":  {"2025-06-01T00:00:00Z", nil},
+		"g-expired": {"2024-01-01T00:00:00Z", "2025-02-01T00:00:00Z"},
+	}
+	for id, period := range periods {
+		if _, err := db.Exec(`UPDATE guidelines SET effective_from = $2, effective_to = $3 WHERE id = $1`, testID(id), period[0], period[1]); err != nil {
+			t.Fatalf("Failed to set the period of %s: %v", id, err)
+		}
+	}
+
+	ids := func(args map[string]interface{}) string {
+		t.Helper()
+		result, err := toolGetGuidelinesAsOf(args)
+		if err != nil {
+			t.Fatalf("toolGetGuidelinesAsOf(%v) error = %v", args, err)
+		}
+		var guidelines []Guideline
+		if err := json.Unmarshal([]byte(result), &guidelines); err != nil {
+			t.Fatalf("Failed to parse result: %v", err)
+		}
+		var names []string
+		for _, g := range guidelines {
+			names = append(names, g.Name)
+		}
+		return strings.Join(names, ",")
+	}
+
+	// g-future became effective after the date and g-expired ended before it
+	if got := ids(map[string]interface{}{"date": "2025-03-01"}); got != "Always,Current" {
+		t.Errorf("Expected g-always and g-current on 2025-03-01, got %s", got)
+	}
+	// effective_to is exclusive
+	if got := ids(map[string]interface{}{"date": "2025-02-01T00:00:00Z"}); got != "Always,Current" {
+		t.Errorf("Expected g-expired to end at its effective_to, got %s", got)
+	}
+	if got := ids(map[string]interface{}{"date": "2024-06-01"}); got != "Always,Expired" {
+		t.Errorf("Expected g-always and g-expired on 2024-06-01, got %s", got)
+	}
+	// Without a date, guidelines effective now are returned
+	if got := ids(map[string]interface{}{}); got != "Always,Current,Future" {
+		t.Errorf("Expected the guidelines effective now, got %s", got)
+	}
+}
+
+// TestGuidelineCRUDLifecycle tests creating, updating and deleting a guideline
+func TestGuidelineCRUDLifecycle(t *testing.T) {
+	setupTestDatabase(t)
+	insertTestCategory(t, "backend", "Backend")
+	insertTestCategory(t, "frontend", "Frontend")
+
+	result, err := toolCreateGuideline(map[string]interface{}{
+		"title":     "Error handling",
+		"category":  "backend",
+		"body":      "Wrap errors with context.",
+		"tags":      []interface{}{"errors"},
+		"languages": []interface{}{"Go"},
+	})
+	if err != nil {
+		t.Fatalf("toolCreateGuideline() error = %v", err)
+	}
+
+	var created Guideline
+	if err := json.Unmarshal([]byte(result), &created); err != nil {
+		t.Fatalf("Failed to parse created guideline: %v", err)
+	}
+	if created.ID == "" || created.Name != "Error handling" || created.Content != "Wrap errors with context." {
+		t.Errorf("Unexpected created guideline: %+v", created)
+	}
+	if created.CategoryID == nil || *created.CategoryID != testID("backend") || !created.IsActive {
+		t.Errorf("Expected active guideline in backend category, got %+v", created)
+	}
+	if len(created.Languages) != 1 || created.Languages[0] != "go" {
+		t.Errorf("Expected languages to be lowercased, got %v", created.Languages)
+	}
+
+	result, err = toolUpdateGuideline(map[string]interface{}{
+		"guideline_id": created.ID,
+		"body":         "Always wrap errors with %w.",
+		"category":     testID("frontend"),
+		"tags":         []interface{}{"errors", "go"},
+	})
+	if err != nil {
+		t.Fatalf("toolUpdateGuideline() error = %v", err)
+	}
+
+	var updated Guideline
+	if err := json.Unmarshal([]byte(result), &updated); err != nil {
+		t.Fatalf("Failed to parse updated guideline: %v", err)
+	}
+	if updated.Content != "Always wrap errors with %w." || updated.Name != "Error handling" {
+		t.Errorf("Expected only the body to change, got %+v", updated)
+	}
+	if len(updated.Tags) != 2 {
+		t.Errorf("Expected 2 tags after update, got %v", updated.Tags)
+	}
+	if updated.CategoryID == nil || *updated.CategoryID != testID("frontend") {
+		t.Errorf("Expected the category to be changed by id, got %v", updated.CategoryID)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"title": "t", "category": "mobile", "body": "b"},
+		{"title": "t", "category": testID("mobile"), "body": "b"},
+	} {
+		if _, err := toolCreateGuideline(args); err == nil || !strings.Contains(err.Error(), "category not found") {
+			t.Errorf("Expected an unknown category to be refused, got %v", err)
+		}
+	}
+
+	if _, err := toolDeleteGuideline(map[string]interface{}{"guideline_id": created.ID}); err != nil {
+		t.Fatalf("toolDeleteGuideline() error = %v", err)
+	}
+
+	deleted, err := getGuideline(created.ID)
+	if err != nil {
+		t.Fatalf("getGuideline() error = %v", err)
+	}
+	if deleted.IsActive {
+		t.Error("Expected deleted guideline to be inactive")
+	}
+
+	active := true
+	guidelines, err := getGuidelines(nil, nil, nil, &active, 10)
+	if err != nil {
+		t.Fatalf("getGuidelines() error = %v", err)
+	}
+	if len(guidelines) != 0 {
+		t.Errorf("Expected no active guidelines after delete, got %d", len(guidelines))
+	}
+
+	if _, err := toolUpdateGuideline(map[string]interface{}{"guideline_id": testID("missing"), "title": "x"}); err == nil {
+		t.Error("Expected error updating a missing guideline")
+	}
+	if _, err := toolDeleteGuideline(map[string]interface{}{"guideline_id": testID("missing")}); err == nil {
+		t.Error("Expected error deleting a missing guideline")
+	}
+}
+
+// TestUpdateGuidelineCategoryByNameInTenant tests that a category name given to
+// update_guideline is looked up among the guideline's own tenant's categories
+func TestUpdateGuidelineCategoryByNameInTenant(t *testing.T) {
+	setupTestDatabase(t)
+	for _, tenant := range []string{"tenant-a", "tenant-b"} {
+		for _, name := range []string{"Backend", "Security"} {
+			if _, err := db.Exec(`INSERT INTO guideline_categories (id, name, tenant_id) VALUES ($1, $2, $3)`, testID(tenant+name), name, tenant); err != nil {
+				t.Fatalf("Failed to insert category: %v", err)
+			}
+		}
+	}
+
+	result, err := toolCreateGuideline(map[string]interface{}{
+		"title": "Secrets", "category": "backend", "body": "Never log secrets.", "tenant_id": "tenant-a",
+	})
+	if err != nil {
+		t.Fatalf("toolCreateGuideline() error = %v", err)
+	}
+	var created Guideline
+	if err := json.Unmarshal([]byte(result), &created); err != nil {
+		t.Fatalf("Failed to parse created guideline: %v", err)
+	}
+
+	result, err = toolUpdateGuideline(map[string]interface{}{"guideline_id": created.ID, "category": "security"})
+	if err != nil {
+		t.Fatalf("toolUpdateGuideline() error = %v", err)
+	}
+	var updated Guideline
+	if err := json.Unmarshal([]byte(result), &updated); err != nil {
+		t.Fatalf("Failed to parse updated guideline: %v", err)
+	}
+	if updated.CategoryID == nil || *updated.CategoryID != testID("tenant-aSecurity") {
+		t.Errorf("Expected tenant-a's Security category, got %v", updated.CategoryID)
+	}
+}
+
+// TestUpdateGuidelineEffectivePeriod tests clearing effective dates with null and checking a
+// single new bound against the stored one
+func TestUpdateGuidelineEffectivePeriod(t *testing.T) {
+	setupTestDatabase(t)
+	insertTestCategory(t, "backend", "Backend")
+
+	result, err := toolCreateGuideline(map[string]interface{}{
+		"title": "Retries", "category": "backend", "body": "Retry with backoff.",
+		"effective_from": "2025-01-01", "effective_to": "2025-06-01",
+	})
+	if err != nil {
+		t.Fatalf("toolCreateGuideline() error = %v", err)
+	}
+	var created Guideline
+	if err := json.Unmarshal([]byte(result), &created); err != nil {
+		t.Fatalf("Failed to parse created guideline: %v", err)
+	}
+
+	// A new start after the stored end would never apply
+	if _, err := toolUpdateGuideline(map[string]interface{}{"guideline_id": created.ID, "effective_from": "2025-07-01"}); err == nil || !strings.Contains(err.Error(), "effective_to must be after effective_from") {
+		t.Errorf("Expected a start after the stored end to be refused, got %v", err)
+	}
+
+	// Clearing the end makes the same start valid
+	result, err = toolUpdateGuideline(map[string]interface{}{"guideline_id": created.ID, "effective_from": "2025-07-01", "effective_to": nil})
+	if err != nil {
+		t.Fatalf("toolUpdateGuideline() error = %v", err)
+	}
+	var updated Guideline
+	if err := json.Unmarshal([]byte(result), &updated); err != nil {
+		t.Fatalf("Failed to parse updated guideline: %v", err)
+	}
+	if updated.EffectiveTo != nil || updated.EffectiveFrom == nil || updated.EffectiveFrom.Format("2006-01-02") != "2025-07-01" {
+		t.Errorf("Expected an open-ended period from 2025-07-01, got %v to %v", updated.EffectiveFrom, updated.EffectiveTo)
+	}
+
+	result, err = toolUpdateGuideline(map[string]interface{}{"guideline_id": created.ID, "effective_from": nil})
+	if err != nil {
+		t.Fatalf("toolUpdateGuideline() error = %v", err)
+	}
+	var cleared Guideline
+	if err := json.Unmarshal([]byte(result), &cleared); err != nil {
+		t.Fatalf("Failed to parse updated guideline: %v", err)
+	}
+	if cleared.EffectiveFrom != nil {
+		t.Errorf("Expected effective_from to be cleared, got %v", cleared.EffectiveFrom)
+	}
+}
+
+// TestListCategoryCounts tests counting guidelines per category
+func TestListCategoryCounts(t *testing.T) {
+	setupTestDatabase(t)
+
+	result, err := toolListCategories(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("toolListCategories() error = %v", err)
+	}
+	if result != "[]" {
+		t.Errorf("Expected empty JSON array for an empty table, got %s", result)
+	}
+
+	insertTestCategory(t, "backend", "Backend")
+	insertTestGuideline(t, "g1", "Errors", "", "Wrap errors.", "backend")
+	insertTestGuideline(t, "g2", "Logging", "", "Log once.", "backend")
+	insertTestGuideline(t, "g3", "Context", "", "Pass context.", "backend")
+	insertTestGuideline(t, "g4", "Components", "", "Keep components small.", "frontend")
+	insertTestGuideline(t, "g5", "Hooks", "", "Name hooks use*.", "frontend")
+	insertTestGuideline(t, "g6", "Indexes", "", "Index foreign keys.", "database")
+	insertTestGuideline(t, "g7", "Old", "", "Removed.", "database")
+	if err := deleteGuideline(testID("g7")); err != nil {
+		t.Fatalf("deleteGuideline() error = %v", err)
+	}
+
+	result, err = toolListCategories(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("toolListCategories() error = %v", err)
+	}
+
+	var counts []CategoryCount
+	if err := json.Unmarshal([]byte(result), &counts); err != nil {
+		t.Fatalf("Failed to parse categories: %v", err)
+	}
+
+	expected := []CategoryCount{
+		{Category: testID("backend"), Name: "Backend", Count: 3},
+		{Category: testID("frontend"), Name: "frontend", Count: 2},
+		{Category: testID("database"), Name: "database", Count: 1},
+	}
+	if len(counts) != len(expected) {
+		t.Fatalf("Expected %d categories, got %+v", len(expected), counts)
+	}
+	for i, want := range expected {
+		if counts[i] != want {
+			t.Errorf("Category %d: expected %+v, got %+v", i, want, counts[i])
+		}
+	}
+}
@@ -3,6 +3,11 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
+
+	"github.com/code-aria/internal-mcp/internal/filelang"
+	"github.com/code-aria/internal-mcp/internal/uuid"
 )
 
 // toolGetGuidelines handles the get_guidelines tool call
@@ -92,10 +97,8 @@ func toolGetGuidelineContent(args map[string]interface{}) (string, error) {
 
 // toolSearchGuidelines handles the search_guidelines tool call
 func toolSearchGuidelines(args map[string]interface{}) (string, error) {
-	searchTerm, ok := args["search_term"].(string)
-	if !ok || searchTerm == "" {
-		return "", fmt.Errorf("search_term is required")
-	}
+	searchTerm, _ := args["search_term"].(string)
+	searchTerm = strings.TrimSpace(searchTerm)
 
 	var tenantID *string
 	if tid, ok := args["tenant_id"].(string); ok && tid != "" {
@@ -107,6 +110,15 @@ func toolSearchGuidelines(args map[string]interface{}) (string, error) {
 		category = &cat
 	}
 
+	if searchTerm == "" && category == nil {
+		return "", fmt.Errorf("search_term or category is required")
+	}
+
+	var isActive *bool
+	if active, ok := args["is_active"].(bool); ok {
+		isActive = &active
+	}
+
 	limit := 20
 	if lim, ok := args["limit"].(float64); ok {
 		limit = int(lim)
@@ -118,7 +130,7 @@ func toolSearchGuidelines(args map[string]interface{}) (string, error) {
 		}
 	}
 
-	guidelines, err := searchGuidelines(searchTerm, tenantID, category, limit)
+	guidelines, err := searchGuidelines(searchTerm, tenantID, category, isActive, limit)
 	if err != nil {
 		return "", fmt.Errorf("failed to search guidelines: %w", err)
 	}
@@ -131,15 +143,450 @@ func toolSearchGuidelines(args map[string]interface{}) (string, error) {
 	return string(resultJSON), nil
 }
 
+// toolGetApplicableGuidelines handles the get_applicable_guidelines tool call
+func toolGetApplicableGuidelines(args map[string]interface{}) (string, error) {
+	var languages []string
+	if lang, ok := args["language"].(string); ok && strings.TrimSpace(lang) != "" {
+		languages = append(languages, strings.ToLower(strings.TrimSpace(lang)))
+	}
+
+	if filePath, ok := args["file_path"].(string); ok && filePath != "" {
+		lang := filelang.ForPath(filePath)
+		if lang == "" && len(languages) == 0 {
+			// Unknown file type and no explicit language: nothing can apply
+			return "[]", nil
+		}
+		if lang != "" {
+			languages = append(languages, lang)
+		}
+	}
+
+	var tags []string
+	if tagsInterface, ok := args["tags"].([]interface{}); ok {
+		for _, tag := range tagsInterface {
+			if tagStr, ok := tag.(string); ok && tagStr != "" {
+				tags = append(tags, tagStr)
+			}
+		}
+	}
+
+	if len(languages) == 0 && len(tags) == 0 {
+		return "", fmt.Errorf("at least one of language, file_path or tags is required")
+	}
+
+	var tenantID *string
+	if tid, ok := args["tenant_id"].(string); ok && tid != "" {
+		tenantID = &tid
+	}
+
+	limit := 50
+	if lim, ok := args["limit"].(float64); ok {
+		limit = int(lim)
+		if limit > 100 {
+			limit = 100
+		}
+		if limit < 1 {
+			limit = 1
+		}
+	}
+
+	guidelines, err := getApplicableGuidelines(languages, tags, tenantID, limit)
+	if err != nil {
+		return "", fmt.Errorf("failed to get applicable guidelines: %w", err)
+	}
+
+	resultJSON, err := json.Marshal(guidelines)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal guidelines: %w", err)
+	}
+
+	return string(resultJSON), nil
+}
+
+// toolGetGuidelinesAsOf handles the get_guidelines_as_of tool call
+func toolGetGuidelinesAsOf(args map[string]interface{}) (string, error) {
+	asOf := time.Now().UTC()
+	if value, ok := stringArg(args, "date", "as_of"); ok && value != "" {
+		date, err := parseDateArg(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid date: %w", err)
+		}
+		asOf = date
+	}
+
+	var tenantID *string
+	if tid, ok := args["tenant_id"].(string); ok && tid != "" {
+		tenantID = &tid
+	}
+
+	var category *string
+	if cat, ok := args["category"].(string); ok && cat != "" {
+		category = &cat
+	}
+
+	limit := 50
+	if lim, ok := args["limit"].(float64); ok {
+		limit = int(lim)
+		if limit > 100 {
+			limit = 100
+		}
+		if limit < 1 {
+			limit = 1
+		}
+	}
+
+	guidelines, err := getGuidelinesAsOf(asOf, tenantID, category, limit)
+	if err != nil {
+		return "", fmt.Errorf("failed to get guidelines: %w", err)
+	}
+
+	resultJSON, err := json.Marshal(guidelines)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal guidelines: %w", err)
+	}
+
+	return string(resultJSON), nil
+}
+
+// parseDateArg parses an RFC 3339 timestamp or a YYYY-MM-DD date, which means the start of
+// that day in UTC
+func parseDateArg(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a YYYY-MM-DD date or RFC 3339 timestamp", value)
+	}
+	return t, nil
+}
+
+// effectivePeriodArgs reads effective_from and effective_to, rejecting a period that ends
+// before it starts. Unset or empty values are returned as nil.
+func effectivePeriodArgs(args map[string]interface{}) (*time.Time, *time.Time, error) {
+	var period [2]*time.Time
+	for i, key := range []string{"effective_from", "effective_to"} {
+		value, ok := stringArg(args, key)
+		if !ok || value == "" {
+			continue
+		}
+		t, err := parseDateArg(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %s: %w", key, err)
+		}
+		period[i] = &t
+	}
+	if period[0] != nil && period[1] != nil && !period[1].After(*period[0]) {
+		return nil, nil, fmt.Errorf("effective_to must be after effective_from")
+	}
+	return period[0], period[1], nil
+}
+
+// nullArg reports whether key is present in args with a JSON null value
+func nullArg(args map[string]interface{}, key string) bool {
+	value, ok := args[key]
+	return ok && value == nil
+}
+
+// stringArg returns the trimmed value of the first of the given keys that holds a string,
+// even an empty one, so callers can accept both the guideline column name and its
+// friendlier alias (e.g. title/name) and tell a blanked field from an absent one
+func stringArg(args map[string]interface{}, keys ...string) (string, bool) {
+	for _, key := range keys {
+		if v, ok := args[key].(string); ok {
+			return strings.TrimSpace(v), true
+		}
+	}
+	return "", false
+}
+
+// stringListArg converts an array argument to a string slice, reporting whether it was set
+func stringListArg(args map[string]interface{}, key string) ([]string, bool, error) {
+	raw, ok := args[key]
+	if !ok || raw == nil {
+		return nil, false, nil
+	}
+
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, false, fmt.Errorf("%s must be an array of strings", key)
+	}
+
+	values := []string{}
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, false, fmt.Errorf("%s must be an array of strings", key)
+		}
+		if s = strings.TrimSpace(s); s != "" {
+			values = append(values, s)
+		}
+	}
+	return values, true, nil
+}
+
+// toolCreateGuideline handles the create_guideline tool call
+func toolCreateGuideline(args map[string]interface{}) (string, error) {
+	title, _ := stringArg(args, "title", "name")
+	if title == "" {
+		return "", fmt.Errorf("title is required")
+	}
+
+	category, _ := stringArg(args, "category", "category_id")
+	if category == "" {
+		return "", fmt.Errorf("category is required")
+	}
+
+	body, _ := stringArg(args, "body", "content")
+	if body == "" {
+		return "", fmt.Errorf("body is required")
+	}
+
+	tags, _, err := stringListArg(args, "tags")
+	if err != nil {
+		return "", err
+	}
+
+	languages, _, err := stringListArg(args, "languages")
+	if err != nil {
+		return "", err
+	}
+	for i, lang := range languages {
+		languages[i] = strings.ToLower(lang)
+	}
+
+	effectiveFrom, effectiveTo, err := effectivePeriodArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	var tenantID *string
+	if tid, ok := stringArg(args, "tenant_id"); ok && tid != "" {
+		tenantID = &tid
+	}
+	categoryID, err := resolveCategoryID(category, tenantID)
+	if err != nil {
+		return "", err
+	}
 
+	id, err := uuid.New()
+	if err != nil {
+		return "", err
+	}
 
+	now := time.Now().UTC()
+	guideline := &Guideline{
+		ID:            id,
+		Name:          title,
+		Content:       body,
+		CategoryID:    &categoryID,
+		Tags:          tags,
+		Languages:     languages,
+		IsActive:      true,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		EffectiveFrom: effectiveFrom,
+		EffectiveTo:   effectiveTo,
+	}
+	if description, ok := stringArg(args, "description"); ok {
+		guideline.Description = description
+	}
+	if tenantID != nil {
+		guideline.TenantID = *tenantID
+	}
 
+	if err := createGuideline(guideline); err != nil {
+		return "", fmt.Errorf("failed to create guideline: %w", err)
+	}
 
+	return marshalGuideline(id)
+}
 
+// toolUpdateGuideline handles the update_guideline tool call
+func toolUpdateGuideline(args map[string]interface{}) (string, error) {
+	id, _ := stringArg(args, "guideline_id", "id")
+	if id == "" {
+		return "", fmt.Errorf("guideline_id is required")
+	}
 
+	var update GuidelineUpdate
+	changed := false
+
+	// Required fields may be changed but not blanked out
+	required := []struct {
+		name  string
+		keys  []string
+		field **string
+	}{
+		{"title", []string{"title", "name"}, &update.Name},
+		{"category", []string{"category", "category_id"}, &update.CategoryID},
+		{"body", []string{"body", "content"}, &update.Content},
+	}
+	for _, r := range required {
+		if v, ok := stringArg(args, r.keys...); ok {
+			if v == "" {
+				return "", fmt.Errorf("%s cannot be empty", r.name)
+			}
+			value := v
+			*r.field = &value
+			changed = true
+		}
+	}
 
+	if description, ok := stringArg(args, "description"); ok {
+		update.Description = &description
+		changed = true
+	}
 
+	tags, ok, err := stringListArg(args, "tags")
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		update.Tags = &tags
+		changed = true
+	}
 
+	languages, ok, err := stringListArg(args, "languages")
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		for i, lang := range languages {
+			languages[i] = strings.ToLower(lang)
+		}
+		update.Languages = &languages
+		changed = true
+	}
 
+	if active, ok := args["is_active"].(bool); ok {
+		update.IsActive = &active
+		changed = true
+	}
 
+	// A bound given as null is cleared, leaving that end of the period open
+	effectiveFrom, effectiveTo, err := effectivePeriodArgs(args)
+	if err != nil {
+		return "", err
+	}
+	update.EffectiveFrom = effectiveFrom
+	update.EffectiveTo = effectiveTo
+	update.ClearEffectiveFrom = nullArg(args, "effective_from")
+	update.ClearEffectiveTo = nullArg(args, "effective_to")
+	periodChanged := effectiveFrom != nil || effectiveTo != nil || update.ClearEffectiveFrom || update.ClearEffectiveTo
+	if periodChanged {
+		changed = true
+	}
 
+	if !changed {
+		return "", fmt.Errorf("at least one field to update is required")
+	}
+
+	var existing *Guideline
+	if update.CategoryID != nil || periodChanged {
+		existing, err = getGuideline(id)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// A single new bound must still fit the stored other one
+	if periodChanged {
+		from, to := existing.EffectiveFrom, existing.EffectiveTo
+		if update.ClearEffectiveFrom {
+			from = nil
+		} else if effectiveFrom != nil {
+			from = effectiveFrom
+		}
+		if update.ClearEffectiveTo {
+			to = nil
+		} else if effectiveTo != nil {
+			to = effectiveTo
+		}
+		if from != nil && to != nil && !to.After(*from) {
+			return "", fmt.Errorf("effective_to must be after effective_from")
+		}
+	}
+
+	if update.CategoryID != nil {
+		// Category names are looked up among the guideline's own tenant's categories
+		var tenantID *string
+		if existing.TenantID != "" {
+			tenantID = &existing.TenantID
+		}
+		categoryID, err := resolveCategoryID(*update.CategoryID, tenantID)
+		if err != nil {
+			return "", err
+		}
+		update.CategoryID = &categoryID
+	}
+
+	if err := updateGuideline(id, update); err != nil {
+		return "", fmt.Errorf("failed to update guideline: %w", err)
+	}
+
+	return marshalGuideline(id)
+}
+
+// toolDeleteGuideline handles the delete_guideline tool call
+func toolDeleteGuideline(args map[string]interface{}) (string, error) {
+	id, _ := stringArg(args, "guideline_id", "id")
+	if id == "" {
+		return "", fmt.Errorf("guideline_id is required")
+	}
+
+	if err := deleteGuideline(id); err != nil {
+		return "", fmt.Errorf("failed to delete guideline: %w", err)
+	}
+
+	resultJSON, err := json.Marshal(map[string]interface{}{
+		"id":      id,
+		"deleted": true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(resultJSON), nil
+}
+
+// toolListCategories handles the list_categories tool call
+func toolListCategories(args map[string]interface{}) (string, error) {
+	var tenantID *string
+	if tid, ok := args["tenant_id"].(string); ok && tid != "" {
+		tenantID = &tid
+	}
+
+	// Deleted guidelines are inactive, so only active ones are counted unless asked otherwise
+	isActive := true
+	if active, ok := args["is_active"].(bool); ok {
+		isActive = active
+	}
+
+	counts, err := countGuidelinesByCategory(tenantID, &isActive)
+	if err != nil {
+		return "", fmt.Errorf("failed to list categories: %w", err)
+	}
+
+	resultJSON, err := json.Marshal(counts)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal categories: %w", err)
+	}
+
+	return string(resultJSON), nil
+}
+
+// marshalGuideline loads a guideline by ID and returns it as JSON
+func marshalGuideline(id string) (string, error) {
+	guideline, err := getGuideline(id)
+	if err != nil {
+		return "", fmt.Errorf("failed to load guideline: %w", err)
+	}
+
+	resultJSON, err := json.Marshal(guideline)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal guideline: %w", err)
+	}
+
+	return string(resultJSON), nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -187,15 +188,65 @@ func TestToolSearchGuidelinesParameterValidation(t *testing.T) {
 	}
 }
 
+// TestToolGetApplicableGuidelinesParameterValidation tests validation that happens before any query
+func TestToolGetApplicableGuidelinesParameterValidation(t *testing.T) {
+	if _, err := toolGetApplicableGuidelines(map[string]interface{}{}); err == nil {
+		t.Error("Expected error when no language, file_path or tags are given")
+	}
 
+	// An unrecognised file type can't match any language, so no query is needed
+	result, err := toolGetApplicableGuidelines(map[string]interface{}{"file_path": "notes.unknown"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != "[]" {
+		t.Errorf("Expected empty array for unknown file type, got %s", result)
+	}
+}
 
+// TestGuidelineCRUDParameterValidation tests that required fields are checked before any query
+func TestGuidelineCRUDParameterValidation(t *testing.T) {
+	createTests := []struct {
+		name    string
+		args    map[string]interface{}
+		wantErr string
+	}{
+		{"Missing title", map[string]interface{}{"category": "c", "body": "b"}, "title is required"},
+		{"Blank title", map[string]interface{}{"title": "  ", "category": "c", "body": "b"}, "title is required"},
+		{"Missing category", map[string]interface{}{"title": "t", "body": "b"}, "category is required"},
+		{"Missing body", map[string]interface{}{"title": "t", "category": "c"}, "body is required"},
+		{"Invalid tags", map[string]interface{}{"title": "t", "category": "c", "body": "b", "tags": "go"}, "tags must be an array of strings"},
+	}
+	for _, tt := range createTests {
+		t.Run("create "+tt.name, func(t *testing.T) {
+			_, err := toolCreateGuideline(tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
 
-
-
-
-
-
-
-
-
-
+	if _, err := toolUpdateGuideline(map[string]interface{}{"title": "t"}); err == nil {
+		t.Error("Expected error when guideline_id is missing")
+	}
+	if _, err := toolUpdateGuideline(map[string]interface{}{"guideline_id": "g1"}); err == nil {
+		t.Error("Expected error when no fields are given to update")
+	}
+	if _, err := toolUpdateGuideline(map[string]interface{}{"guideline_id": "g1", "body": ""}); err == nil {
+		t.Error("Expected error when blanking a required field")
+	}
+	if _, err := toolUpdateGuideline(map[string]interface{}{"guideline_id": "g1", "effective_from": "next week"}); err == nil {
+		t.Error("Expected error for an invalid effective_from")
+	}
+	if _, err := toolCreateGuideline(map[string]interface{}{
+		"title": "t", "category": "c", "body": "b", "effective_from": "2025-06-01", "effective_to": "2025-01-01",
+	}); err == nil || !strings.Contains(err.Error(), "effective_to must be after effective_from") {
+		t.Errorf("Expected error for a period ending before it starts, got %v", err)
+	}
+	if _, err := toolGetGuidelinesAsOf(map[string]interface{}{"date": "01/03/2025"}); err == nil {
+		t.Error("Expected error for an invalid date")
+	}
+	if _, err := toolDeleteGuideline(map[string]interface{}{}); err == nil {
+		t.Error("Expected error when guideline_id is missing")
+	}
+}
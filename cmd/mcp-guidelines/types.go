@@ -1,61 +1,23 @@
 package main
 
 import (
-	"encoding/json"
 	"time"
-)
-
-// MCP protocol types
-type MCPMessage struct {
-	JSONRPC string          `json:"jsonrpc"`
-	ID      interface{}     `json:"id,omitempty"`
-	Method  string          `json:"method,omitempty"`
-	Params  json.RawMessage `json:"params,omitempty"`
-	Result  interface{}     `json:"result,omitempty"`
-	Error   *MCPError       `json:"error,omitempty"`
-}
-
-type MCPError struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-}
-
-type InitializeResponse struct {
-	ProtocolVersion string                 `json:"protocolVersion"`
-	Capabilities    map[string]interface{} `json:"capabilities"`
-	ServerInfo      ServerInfo             `json:"serverInfo"`
-}
-
-type ServerInfo struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
-}
-
-type Tool struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	InputSchema map[string]interface{} `json:"inputSchema"`
-}
-
-type ToolsListResponse struct {
-	Tools []Tool `json:"tools"`
-}
-
-type ToolsCallRequest struct {
-	Name      string                 `json:"name"`
-	Arguments map[string]interface{} `json:"arguments,omitempty"`
-}
 
-type ToolsCallResponse struct {
-	Content []Content `json:"content"`
-	IsError bool      `json:"isError,omitempty"`
-}
+	"github.com/code-aria/internal-mcp/internal/mcp"
+)
 
-type Content struct {
-	Type string `json:"type"`
-	Text string `json:"text,omitempty"`
-}
+// MCP protocol types, shared with the other servers
+type (
+	MCPMessage         = mcp.Message
+	MCPError           = mcp.Error
+	InitializeResponse = mcp.InitializeResponse
+	ServerInfo         = mcp.ServerInfo
+	Tool               = mcp.Tool
+	ToolsListResponse  = mcp.ToolsListResponse
+	ToolsCallRequest   = mcp.ToolsCallRequest
+	ToolsCallResponse  = mcp.ToolsCallResponse
+	Content            = mcp.Content
+)
 
 // Guideline data structures
 type GuidelineCategory struct {
@@ -74,17 +36,46 @@ type GuidelineCategory struct {
 }
 
 type Guideline struct {
-	ID          string                 `json:"id"`
-	Name        string                 `json:"name"`
-	Description string                 `json:"description,omitempty"`
-	Content     string                 `json:"content"`
-	CategoryID  *string                `json:"category_id,omitempty"`
-	Category    *GuidelineCategory     `json:"category,omitempty"` // Populated when joined
-	CategoryOld string                 `json:"category,omitempty"` // Deprecated: kept for backward compatibility
-	Tags        []string               `json:"tags,omitempty"`
-	TenantID    string                 `json:"tenant_id,omitempty"`
-	IsActive    bool                   `json:"is_active"`
-	Metadata    map[string]interface{} `json:"metadata,omitempty"`
-	CreatedAt   time.Time              `json:"created_at"`
-	UpdatedAt   time.Time              `json:"updated_at"`
+	ID            string                 `json:"id"`
+	Name          string                 `json:"name"`
+	Description   string                 `json:"description,omitempty"`
+	Content       string                 `json:"content"`
+	CategoryID    *string                `json:"category_id,omitempty"`
+	Category      *GuidelineCategory     `json:"category,omitempty"` // Populated when joined
+	CategoryOld   string                 `json:"-"`                  // Deprecated: kept for backward compatibility
+	Tags          []string               `json:"tags,omitempty"`
+	Languages     []string               `json:"languages,omitempty"` // Languages the guideline applies to
+	TenantID      string                 `json:"tenant_id,omitempty"`
+	IsActive      bool                   `json:"is_active"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+	Rank          *float64               `json:"rank,omitempty"`           // Search relevance, set by searchGuidelines
+	EffectiveFrom *time.Time             `json:"effective_from,omitempty"` // Applies from this time on; nil means since always
+	EffectiveTo   *time.Time             `json:"effective_to,omitempty"`   // Applies until just before this time; nil means indefinitely
+}
+
+// GuidelineUpdate holds the fields to change on a guideline; nil fields are left unchanged.
+// ClearEffectiveFrom and ClearEffectiveTo reset a bound to NULL, leaving the period open.
+type GuidelineUpdate struct {
+	Name          *string
+	Description   *string
+	Content       *string
+	CategoryID    *string
+	Tags          *[]string
+	Languages     *[]string
+	IsActive      *bool
+	EffectiveFrom *time.Time
+	EffectiveTo   *time.Time
+
+	ClearEffectiveFrom bool
+	ClearEffectiveTo   bool
+}
+
+// CategoryCount is a guideline category with the number of guidelines in it. Name is the
+// category's name from guideline_categories, when the category has a row there.
+type CategoryCount struct {
+	Category string `json:"category"`
+	Name     string `json:"name,omitempty"`
+	Count    int    `json:"count"`
 }
@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+
+	"github.com/code-aria/internal-mcp/internal/mcp"
 )
 
 // handleInitialize processes the MCP initialize request
@@ -56,6 +58,8 @@ func handleInitialize(scanner *bufio.Scanner, encoder *json.Encoder) error {
 // handleRequest routes MCP requests to appropriate handlers
 func handleRequest(msg *MCPMessage, encoder *json.Encoder) {
 	switch msg.Method {
+	case "ping":
+		mcp.SendPing(encoder, msg.ID)
 	case "tools/list":
 		handleToolsList(msg, encoder)
 	case "tools/call":
@@ -140,107 +144,33 @@ func handleToolCall(msg *MCPMessage, encoder *json.Encoder) {
 	sendError(encoder, msg.ID, -32601, fmt.Sprintf("Unknown tool: %s. Use apply_operations for batch operations", req.Name), nil)
 }
 
-// handleBatchOperations processes a batch of operations
-func handleBatchOperations(msg *MCPMessage, encoder *json.Encoder, args map[string]interface{}) {
-	operations, ok := args["operations"].([]interface{})
-	if !ok {
-		sendError(encoder, msg.ID, -32602, "operations array is required", nil)
-		return
-	}
-
-	if len(operations) == 0 {
-		sendError(encoder, msg.ID, -32602, "operations array cannot be empty", nil)
-		return
-	}
-
-	var results []map[string]interface{}
+// batchRunner dispatches apply_operations to the operation handlers
+var batchRunner = mcp.NewBatchRunner(map[string]mcp.OperationHandler{
+	"lint": jsonHandler(toolLintEmbedded),
+})
 
-	for _, op := range operations {
-		opMap, ok := op.(map[string]interface{})
-		if !ok {
-			results = append(results, map[string]interface{}{
-				"operation": "unknown",
-				"params":    map[string]interface{}{},
-				"status":    "Error",
-				"message":   "Invalid operation format",
-			})
-			continue
-		}
-
-		opType, ok := opMap["type"].(string)
-		if !ok {
-			results = append(results, map[string]interface{}{
-				"operation": "unknown",
-				"params":    map[string]interface{}{},
-				"status":    "Error",
-				"message":   "Operation type is required",
-			})
-			continue
-		}
-
-		// Extract operation-specific arguments as params
-		params := make(map[string]interface{})
-		for k, v := range opMap {
-			if k != "type" {
-				params[k] = v
-			}
-		}
-
-		// Execute operation based on type
-		var result interface{}
-		var err error
-
-		switch opType {
-		case "lint":
-			result, err = toolLintEmbedded(params)
-		default:
-			err = fmt.Errorf("unknown operation type: %s", opType)
+// jsonHandler adapts a handler returning a Go value to an mcp.OperationHandler by
+// marshaling its result, which the batch runner embeds as parsed JSON
+func jsonHandler(handler func(map[string]interface{}) (interface{}, error)) mcp.OperationHandler {
+	return func(params map[string]interface{}) (string, error) {
+		result, err := handler(params)
+		if err != nil {
+			return "", err
 		}
-
+		data, err := json.Marshal(result)
 		if err != nil {
-			results = append(results, map[string]interface{}{
-				"operation": opType,
-				"params":    params,
-				"status":    "Error",
-				"message":   err.Error(),
-			})
-		} else {
-			results = append(results, map[string]interface{}{
-				"operation": opType,
-				"params":    params,
-				"status":    "Success",
-				"result":    result,
-			})
+			return "", fmt.Errorf("failed to marshal result: %w", err)
 		}
+		return string(data), nil
 	}
+}
 
-	// Serialize results to JSON text for MCP-compliant response format
-	resultsJSON, err := json.Marshal(map[string]interface{}{
-		"results": results,
-	})
-	if err != nil {
-		sendError(encoder, msg.ID, -32700, fmt.Sprintf("Failed to marshal results: %v", err), nil)
-		return
-	}
-
-	// Return results in MCP-compliant format using ToolsCallResponse
-	response := MCPMessage{
-		JSONRPC: "2.0",
-		ID:      msg.ID,
-		Result: ToolsCallResponse{
-			Content: []Content{
-				{
-					Type: "text",
-					Text: string(resultsJSON),
-				},
-			},
-			IsError: false,
-		},
-	}
-
-	encoder.Encode(response)
+// handleBatchOperations processes a batch of operations
+func handleBatchOperations(msg *MCPMessage, encoder *json.Encoder, args map[string]interface{}) {
+	batchRunner.Handle(msg, encoder, args)
 }
 
+
 // sendError sends an error response
 func sendError(encoder *json.Encoder, id interface{}, code int, message string, data interface{}) {
 	response := MCPMessage{
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// requestTimeout is the deadline of each tools/call, set by POSTGRES_REQUEST_TIMEOUT; 0,
+// the default, means none
+var requestTimeout time.Duration
+
+// serverCtx is cancelled when the server receives SIGINT or SIGTERM, so a query still
+// running is cancelled on the database server instead of outliving the process
+var serverCtx, stopServer = context.WithCancel(context.Background())
+
+// requestCtx is the context of the tools/call being handled; the read operations run their
+// queries with it. Requests are handled one at a time, so it needs no locking.
+var requestCtx = context.Background()
+
+// requestMu is held while a tools/call runs, so shutdown can wait for it to respond
+var requestMu sync.Mutex
+
+// initRequestTimeout reads the request deadline from POSTGRES_REQUEST_TIMEOUT, a duration
+// such as 30s or 2m, or a number of seconds. Unset or 0 leaves requests without one.
+func initRequestTimeout() error {
+	value := os.Getenv("POSTGRES_REQUEST_TIMEOUT")
+	if value == "" {
+		return nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			return fmt.Errorf("invalid POSTGRES_REQUEST_TIMEOUT %q: expected a duration such as 30s", value)
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+	if timeout < 0 {
+		return fmt.Errorf("invalid POSTGRES_REQUEST_TIMEOUT %q: must not be negative", value)
+	}
+	requestTimeout = timeout
+	return nil
+}
+
+// beginRequest sets requestCtx for one tools/call: it ends when the server stops or
+// requestTimeout passes. The returned function must be called when the request is done.
+func beginRequest() func() {
+	requestMu.Lock()
+	ctx, cancel := serverCtx, context.CancelFunc(func() {})
+	if requestTimeout > 0 {
+		ctx, cancel = context.WithTimeout(serverCtx, requestTimeout)
+	}
+	requestCtx = ctx
+	return func() {
+		cancel()
+		requestCtx = context.Background()
+		requestMu.Unlock()
+	}
+}
+
+// handleSignals cancels serverCtx on SIGINT or SIGTERM, waits for the request being
+// handled to send its response, and exits. The end of stdin is not a signal: requests
+// already read run to completion and the server exits once they have responded.
+func handleSignals() {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		stopServer()
+		requestMu.Lock()
+		os.Exit(0)
+	}()
+}
+
+// cancelledError explains err when it was caused by the request being cancelled, since the
+// driver only reports that the statement was cancelled
+func cancelledError(err error) error {
+	switch requestCtx.Err() {
+	case context.DeadlineExceeded:
+		return fmt.Errorf("cancelled after the %v request timeout (POSTGRES_REQUEST_TIMEOUT): %w", requestTimeout, err)
+	case context.Canceled:
+		return fmt.Errorf("cancelled because the server is shutting down: %w", err)
+	}
+	return err
+}
+
+// contextQueryer runs queries in a transaction with requestCtx, so the driver cancels them
+// on the server when the request ends
+type contextQueryer struct {
+	*sql.Tx
+}
+
+// Query prepares query and runs it with requestCtx. lib/pq sends a query without args as a
+// simple query, which may hold several statements, so "SELECT 1; COMMIT; SELECT nextval(...)"
+// would end the read-only transaction and write in the implicit one after it. Postgres refuses
+// to prepare more than one statement. The statement is closed with the transaction.
+func (q contextQueryer) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, err := q.Tx.PrepareContext(requestCtx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.QueryContext(requestCtx, args...)
+}
+
+// QueryRow runs query with requestCtx. It is not prepared, so it is only used for the
+// queries of this server; user SQL always goes through Query.
+func (q contextQueryer) QueryRow(query string, args ...interface{}) *sql.Row {
+	return q.Tx.QueryRowContext(requestCtx, query, args...)
+}
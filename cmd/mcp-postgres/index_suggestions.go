@@ -0,0 +1,260 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// defaultSuggestMinRows is the estimated row count from which a sequentially scanned table
+// counts as large
+const defaultSuggestMinRows = 10000
+
+// planNode is the part of an EXPLAIN (VERBOSE, FORMAT JSON) plan node that suggest_indexes
+// reads. Schema is only printed with VERBOSE.
+type planNode struct {
+	NodeType     string     `json:"Node Type"`
+	RelationName string     `json:"Relation Name"`
+	Schema       string     `json:"Schema"`
+	Alias        string     `json:"Alias"`
+	Filter       string     `json:"Filter"`
+	PlanRows     float64    `json:"Plan Rows"`
+	TotalCost    float64    `json:"Total Cost"`
+	Plans        []planNode `json:"Plans"`
+}
+
+// SeqScan is a sequential scan found in the plan. EstimatedTableRows is pg_class.reltuples,
+// -1 before the table is first analyzed.
+type SeqScan struct {
+	Schema             string   `json:"schema"`
+	Table              string   `json:"table"`
+	Alias              string   `json:"alias,omitempty"`
+	Filter             string   `json:"filter,omitempty"`
+	FilterColumns      []string `json:"filter_columns"`
+	EstimatedTableRows int64    `json:"estimated_table_rows"`
+	PlanRows           int64    `json:"plan_rows"`
+	TotalCost          float64  `json:"total_cost"`
+	Large              bool     `json:"large"`
+}
+
+// IndexSuggestion is a candidate index for a large sequentially scanned table
+type IndexSuggestion struct {
+	Schema    string   `json:"schema"`
+	Table     string   `json:"table"`
+	Columns   []string `json:"columns"`
+	Statement string   `json:"statement"`
+	Reason    string   `json:"reason"`
+}
+
+// filterColumnPattern matches a column compared in a plan filter, e.g. status in
+// (o.status = 'open'::text) or email in ((o.email)::text = $1). The column may carry a cast
+// and the table alias that VERBOSE plans print; columns wrapped in a function call are not
+// matched, as a plain index would not serve them. Group 2 is the column and group 3 the
+// operator.
+var filterColumnPattern = regexp.MustCompile(`(^|[\s(])(?:(?:[A-Za-z_][A-Za-z0-9_$]*|"(?:[^"]|"")+")\.)?([A-Za-z_][A-Za-z0-9_$]*|"(?:[^"]|"")+")\)?(?:::[A-Za-z_][A-Za-z0-9_ ]*(?:\[\])?)?\s*(=|<>|!=|<=|>=|<|>|~~\*?|!~~\*?|IS\s)`)
+
+// filterKeywords are words of a filter that are never columns
+var filterKeywords = map[string]bool{"AND": true, "OR": true, "NOT": true, "NULL": true, "TRUE": true, "FALSE": true}
+
+// toolSuggestIndexes runs EXPLAIN on a SELECT query, reports its sequential scans, and
+// suggests an index on the filtered columns of each scan of a large table. The suggestions
+// are heuristics read from the plan's filter text: they do not account for selectivity,
+// existing indexes the planner chose not to use, or write overhead, and should be checked
+// with EXPLAIN ANALYZE before an index is created. The query is planned, never run.
+func toolSuggestIndexes(params map[string]interface{}) (string, error) {
+	connStr, err := getConnectionString(params)
+	if err != nil {
+		return "", err
+	}
+
+	query, ok := params["query"].(string)
+	if !ok || query == "" {
+		return "", fmt.Errorf("query parameter is required")
+	}
+	if err := validateSelectQuery(query); err != nil {
+		return "", err
+	}
+
+	minRows := int64(defaultSuggestMinRows)
+	if m, ok := params["min_rows"].(float64); ok && m >= 0 {
+		minRows = int64(m)
+	}
+
+	db, release, err := openReader(connStr)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	var args []interface{}
+	if paramsArray, ok := params["params"].([]interface{}); ok {
+		args = paramsArray
+	}
+	// VERBOSE is needed for the plan to name each relation's schema. Query, not QueryRow, so the
+	// user's SQL is prepared and cannot carry a second statement.
+	planJSON, err := explainPlan(db, "EXPLAIN (VERBOSE, FORMAT JSON) "+query, args)
+	if err != nil {
+		return "", maskConnectionError(connStr, cancelledError(readOnlyError(fmt.Errorf("failed to explain query: %w", err))))
+	}
+	seqScans, err := planSeqScans(planJSON)
+	if err != nil {
+		return "", err
+	}
+
+	suggestions := []IndexSuggestion{}
+	suggested := make(map[string]bool)
+	for i := range seqScans {
+		scan := &seqScans[i]
+		err := db.QueryRow(`
+			SELECT c.reltuples::bigint
+			FROM pg_class c
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE n.nspname = $1 AND c.relname = $2
+		`, scan.Schema, scan.Table).Scan(&scan.EstimatedTableRows)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			// Not a table of pg_class we can see; report the scan without a size
+			scan.EstimatedTableRows = -1
+		case err != nil:
+			return "", maskConnectionError(connStr, cancelledError(fmt.Errorf("failed to get the size of %s.%s: %w", scan.Schema, scan.Table, err)))
+		}
+		scan.Large = scan.EstimatedTableRows >= minRows
+
+		if !scan.Large || len(scan.FilterColumns) == 0 {
+			continue
+		}
+		key := scan.Schema + "." + scan.Table + "(" + strings.Join(scan.FilterColumns, ",") + ")"
+		if suggested[key] {
+			continue
+		}
+		suggested[key] = true
+		suggestions = append(suggestions, indexSuggestion(*scan))
+	}
+
+	resultJSON, err := json.Marshal(map[string]interface{}{
+		"seq_scans":   seqScans,
+		"suggestions": suggestions,
+		"min_rows":    minRows,
+		"heuristic":   true,
+		"note":        "Suggestions are heuristics read from the EXPLAIN plan's filters. They ignore selectivity, existing indexes and write cost; confirm with EXPLAIN ANALYZE before creating an index.",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(resultJSON), nil
+}
+
+// explainPlan runs an EXPLAIN statement and returns the plan of its single row
+func explainPlan(db queryer, explain string, args []interface{}) (string, error) {
+	rows, err := db.Query(explain, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return "", err
+		}
+		return "", sql.ErrNoRows
+	}
+	var planJSON string
+	if err := rows.Scan(&planJSON); err != nil {
+		return "", err
+	}
+	return planJSON, rows.Err()
+}
+
+// planSeqScans parses EXPLAIN (VERBOSE, FORMAT JSON) output into its sequential scans, in
+// plan order. A scan whose relation has no schema, as in plans explained without VERBOSE,
+// is left out, since its table cannot be looked up reliably.
+func planSeqScans(planJSON string) ([]SeqScan, error) {
+	var plans []struct {
+		Plan planNode `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(planJSON), &plans); err != nil {
+		return nil, fmt.Errorf("failed to parse query plan: %w", err)
+	}
+	if len(plans) == 0 {
+		return nil, fmt.Errorf("failed to parse query plan: no plan returned")
+	}
+
+	seqScans := []SeqScan{}
+	for _, node := range collectSeqScans(plans[0].Plan) {
+		if node.Schema == "" {
+			continue
+		}
+		scan := SeqScan{
+			Schema:        node.Schema,
+			Table:         node.RelationName,
+			Filter:        node.Filter,
+			FilterColumns: filterColumns(node.Filter),
+			PlanRows:      int64(node.PlanRows),
+			TotalCost:     node.TotalCost,
+		}
+		if node.Alias != node.RelationName {
+			scan.Alias = node.Alias
+		}
+		seqScans = append(seqScans, scan)
+	}
+	return seqScans, nil
+}
+
+// collectSeqScans returns the Seq Scan nodes of the plan tree, parallel ones included, in
+// plan order
+func collectSeqScans(node planNode) []planNode {
+	var scans []planNode
+	if node.NodeType == "Seq Scan" && node.RelationName != "" {
+		scans = append(scans, node)
+	}
+	for _, child := range node.Plans {
+		scans = append(scans, collectSeqScans(child)...)
+	}
+	return scans
+}
+
+// filterColumns returns the columns compared in a plan filter, equality comparisons first
+// as they make the better leading index columns, each once
+func filterColumns(filter string) []string {
+	var equality, other []string
+	seen := make(map[string]bool)
+	for _, m := range filterColumnPattern.FindAllStringSubmatch(filter, -1) {
+		column := m[2]
+		if strings.HasPrefix(column, `"`) {
+			column = strings.ReplaceAll(column[1:len(column)-1], `""`, `"`)
+		} else if filterKeywords[strings.ToUpper(column)] {
+			continue
+		}
+		if seen[column] {
+			continue
+		}
+		seen[column] = true
+		if m[3] == "=" {
+			equality = append(equality, column)
+		} else {
+			other = append(other, column)
+		}
+	}
+	return append(equality, other...)
+}
+
+// indexSuggestion builds the candidate index for a large sequentially scanned table
+func indexSuggestion(scan SeqScan) IndexSuggestion {
+	quoted := make([]string, len(scan.FilterColumns))
+	for i, column := range scan.FilterColumns {
+		quoted[i] = pq.QuoteIdentifier(column)
+	}
+	return IndexSuggestion{
+		Schema:    scan.Schema,
+		Table:     scan.Table,
+		Columns:   scan.FilterColumns,
+		Statement: fmt.Sprintf("CREATE INDEX ON %s.%s (%s)", pq.QuoteIdentifier(scan.Schema), pq.QuoteIdentifier(scan.Table), strings.Join(quoted, ", ")),
+		Reason:    fmt.Sprintf("sequential scan of %s.%s (about %d rows) filtered by %s", scan.Schema, scan.Table, scan.EstimatedTableRows, strings.Join(scan.FilterColumns, ", ")),
+	}
+}
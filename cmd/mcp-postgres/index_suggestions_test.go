@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestFilterColumns tests reading the compared columns from EXPLAIN filter text
+func TestFilterColumns(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   []string
+	}{
+		{"(customer_id = 42)", []string{"customer_id"}},
+		{"((email)::text = 'a@example.com'::text)", []string{"email"}},
+		{"((created_at > '2024-01-01'::date) AND (status = 'open'::text))", []string{"status", "created_at"}},
+		{"((o.total >= '100'::numeric) OR (o.total IS NULL))", []string{"total"}},
+		{"((\"Region\")::text ~~ 'eu-%'::text)", []string{"Region"}},
+		{"(status = ANY ('{open,held}'::text[]))", []string{"status"}},
+		{"((\"O\".total > '5'::numeric) AND (\"O\".\"Region\" = 'eu'::text))", []string{"Region", "total"}},
+		{"(lower((email)::text) = 'a@example.com'::text)", nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		got := filterColumns(tt.filter)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("filterColumns(%q) = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
+
+// explainVerboseJoin is EXPLAIN (VERBOSE, FORMAT JSON) output, in the layout PostgreSQL 16
+// prints, for SELECT o.id FROM public.orders o JOIN public.customers c ON c.id =
+// o.customer_id WHERE o.status = 'open' AND o.email = 'a@example.com', email being varchar,
+// on two unindexed tables
+const explainVerboseJoin = `[
+  {
+    "Plan": {
+      "Node Type": "Hash Join",
+      "Parallel Aware": false,
+      "Async Capable": false,
+      "Join Type": "Inner",
+      "Startup Cost": 38.58,
+      "Total Cost": 444.52,
+      "Plan Rows": 10,
+      "Plan Width": 4,
+      "Output": ["o.id"],
+      "Inner Unique": false,
+      "Hash Cond": "(o.customer_id = c.id)",
+      "Plans": [
+        {
+          "Node Type": "Seq Scan",
+          "Parent Relationship": "Outer",
+          "Parallel Aware": false,
+          "Async Capable": false,
+          "Relation Name": "orders",
+          "Schema": "public",
+          "Alias": "o",
+          "Startup Cost": 0.00,
+          "Total Cost": 408.00,
+          "Plan Rows": 10,
+          "Plan Width": 8,
+          "Output": ["o.id", "o.customer_id", "o.status", "o.email"],
+          "Filter": "((o.status = 'open'::text) AND ((o.email)::text = 'a@example.com'::text))"
+        },
+        {
+          "Node Type": "Hash",
+          "Parent Relationship": "Inner",
+          "Parallel Aware": false,
+          "Async Capable": false,
+          "Startup Cost": 22.70,
+          "Total Cost": 22.70,
+          "Plan Rows": 1270,
+          "Plan Width": 4,
+          "Output": ["c.id"],
+          "Plans": [
+            {
+              "Node Type": "Seq Scan",
+              "Parent Relationship": "Outer",
+              "Parallel Aware": false,
+              "Async Capable": false,
+              "Relation Name": "customers",
+              "Schema": "public",
+              "Alias": "c",
+              "Startup Cost": 0.00,
+              "Total Cost": 22.70,
+              "Plan Rows": 1270,
+              "Plan Width": 4,
+              "Output": ["c.id"]
+            }
+          ]
+        }
+      ]
+    }
+  }
+]`
+
+// TestPlanSeqScans tests reading the sequential scans, their schema and filter columns from
+// a VERBOSE plan, and leaving out scans without a schema as plans without VERBOSE have
+func TestPlanSeqScans(t *testing.T) {
+	scans, err := planSeqScans(explainVerboseJoin)
+	if err != nil {
+		t.Fatalf("planSeqScans() error = %v", err)
+	}
+	if len(scans) != 2 {
+		t.Fatalf("Expected the scans of orders and customers, got %+v", scans)
+	}
+	orders, customers := scans[0], scans[1]
+	if orders.Schema != "public" || orders.Table != "orders" || orders.Alias != "o" || orders.PlanRows != 10 {
+		t.Errorf("Unexpected orders scan %+v", orders)
+	}
+	if strings.Join(orders.FilterColumns, ",") != "status,email" {
+		t.Errorf("Expected filter columns status, email, got %v", orders.FilterColumns)
+	}
+	if customers.Table != "customers" || customers.Filter != "" || len(customers.FilterColumns) != 0 {
+		t.Errorf("Expected an unfiltered customers scan, got %+v", customers)
+	}
+
+	suggestion := indexSuggestion(SeqScan{Schema: orders.Schema, Table: orders.Table, FilterColumns: orders.FilterColumns, EstimatedTableRows: 50000})
+	if suggestion.Statement != `CREATE INDEX ON "public"."orders" ("status", "email")` {
+		t.Errorf("Unexpected statement %q", suggestion.Statement)
+	}
+
+	withoutSchema := strings.ReplaceAll(explainVerboseJoin, `"Schema": "public",`, "")
+	if scans, err := planSeqScans(withoutSchema); err != nil || len(scans) != 0 {
+		t.Errorf("Expected scans without a schema to be left out, got %+v (%v)", scans, err)
+	}
+	if _, err := planSeqScans("not json"); err == nil {
+		t.Error("Expected an error for output that is not a plan")
+	}
+}
+
+// TestToolSuggestIndexes tests that a filter on an unindexed column of a large table gets
+// an index suggestion, and a filter on the indexed column does not
+func TestToolSuggestIndexes(t *testing.T) {
+	setupTestDB(t)
+
+	schema := createTestSchema(t,
+		`CREATE TABLE %s.orders (id INTEGER PRIMARY KEY, customer_id INTEGER NOT NULL, status TEXT NOT NULL)`,
+		`INSERT INTO %s.orders SELECT i, i % 500, CASE WHEN i % 10 = 0 THEN 'open' ELSE 'closed' END FROM generate_series(1, 20000) AS i`,
+		`ANALYZE %s.orders`,
+	)
+
+	suggest := func(query string) map[string]interface{} {
+		t.Helper()
+		result, err := toolSuggestIndexes(map[string]interface{}{
+			"connection_name": getTestConnectionName(),
+			"query":           query,
+			"params":          []interface{}{float64(7)},
+			"min_rows":        float64(1000),
+		})
+		if err != nil {
+			t.Fatalf("toolSuggestIndexes(%q) error = %v", query, err)
+		}
+		var decoded map[string]interface{}
+		if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+			t.Fatalf("Failed to parse result: %v", err)
+		}
+		return decoded
+	}
+
+	result := suggest("SELECT * FROM " + schema + ".orders WHERE customer_id = $1")
+	if result["heuristic"] != true {
+		t.Errorf("Expected the result to be marked heuristic, got %v", result)
+	}
+	suggestions, _ := result["suggestions"].([]interface{})
+	if len(suggestions) != 1 {
+		t.Fatalf("Expected one suggestion, got %v", result)
+	}
+	suggestion := suggestions[0].(map[string]interface{})
+	columns, _ := suggestion["columns"].([]interface{})
+	if suggestion["table"] != "orders" || len(columns) != 1 || columns[0] != "customer_id" {
+		t.Errorf("Expected an index on orders (customer_id), got %v", suggestion)
+	}
+	if statement, _ := suggestion["statement"].(string); !strings.Contains(statement, `("customer_id")`) {
+		t.Errorf("Unexpected statement %q", statement)
+	}
+	if scans, _ := result["seq_scans"].([]interface{}); len(scans) != 1 || scans[0].(map[string]interface{})["large"] != true || scans[0].(map[string]interface{})["schema"] != schema {
+		t.Errorf("Expected one large sequential scan of %s.orders, got %v", schema, result["seq_scans"])
+	}
+
+	// The primary key serves this filter, so there is no sequential scan to fix
+	result = suggest("SELECT * FROM " + schema + ".orders WHERE id = $1")
+	if suggestions, _ := result["suggestions"].([]interface{}); len(suggestions) != 0 {
+		t.Errorf("Expected no suggestion for the indexed column, got %v", suggestions)
+	}
+
+	if _, err := toolSuggestIndexes(map[string]interface{}{
+		"connection_name": getTestConnectionName(),
+		"query":           "DELETE FROM " + schema + ".orders",
+	}); err == nil {
+		t.Error("Expected a non-SELECT query to be rejected")
+	}
+}
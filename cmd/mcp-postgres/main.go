@@ -11,6 +11,8 @@ import (
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 	_ "modernc.org/sqlite"
+
+	"github.com/code-aria/internal-mcp/internal/mcp"
 )
 
 func main() {
@@ -23,9 +25,16 @@ func main() {
 		os.Exit(1)
 	}
 
-	scanner := bufio.NewScanner(os.Stdin)
-	// Increase buffer size to handle large JSON-RPC messages
-	scanner.Buffer(nil, 10*1024*1024) // 10MB buffer
+	if err := initRequestTimeout(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize: %v\n", err)
+		os.Exit(1)
+	}
+
+	// SIGINT and SIGTERM cancel whatever query is still running; the end of stdin does not
+	handleSignals()
+
+	// Messages up to MCP_MAX_MESSAGE_BYTES (default 10MB) are accepted
+	scanner := mcp.NewScanner(os.Stdin)
 	encoder := json.NewEncoder(os.Stdout)
 
 	// Initialize handshake
@@ -35,32 +44,7 @@ func main() {
 	}
 
 	// Handle requests
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
-		}
-		// Log large requests for debugging (warn if > 500KB, error if > 1MB)
-		if len(line) > 1_000_000 {
-			fmt.Fprintf(os.Stderr, "[ERROR] mcp-postgres: Request size %d bytes exceeds 1MB buffer\n", len(line))
-		} else if len(line) > 500_000 {
-			fmt.Fprintf(os.Stderr, "[WARN] mcp-postgres: Large request size %d bytes\n", len(line))
-		}
-
-		var msg MCPMessage
-		if err := json.Unmarshal(line, &msg); err != nil {
-			continue
-		}
-
-		if msg.Method != "" {
-			handleRequest(&msg, encoder)
-		}
-	}
-
-	// Check for scanner errors (e.g., token too long)
-	if err := scanner.Err(); err != nil {
-		fmt.Fprintf(os.Stderr, "[ERROR] mcp-postgres: Scanner error: %v (buffer max: 10MB)\n", err)
-	}
+	mcp.Serve(scanner, encoder, "mcp-postgres", handleRequest)
 }
 
 // initMasterDB initializes the master database connection and sets up the mcp_connections table
@@ -160,6 +144,8 @@ func handleInitialize(scanner *bufio.Scanner, encoder *json.Encoder) error {
 
 func handleRequest(msg *MCPMessage, encoder *json.Encoder) {
 	switch msg.Method {
+	case "ping":
+		mcp.SendPing(encoder, msg.ID)
 	case "tools/list":
 		handleToolsList(msg, encoder)
 	case "tools/call":
@@ -188,50 +174,102 @@ Database Operations:
 
 3. describe_table - Get detailed table schema information including columns, data types, constraints, indexes, and metadata
    Parameters: connection_name (optional, required in SQLite mode), table_name (required), schema (optional, defaults to 'public')
-   Returns: Table schema object with columns array containing name, type, nullable, default, constraints, indexes, and position
+   Returns: Table schema object with columns array containing name, type, nullable, default, constraints, indexes, position, and comment (only when set); the table's own comment is included the same way
 
 4. query - Execute a SELECT query to retrieve data from the database
-   Parameters: connection_name (optional, required in SQLite mode), query (required, must be a SELECT statement), params (optional array for parameterized queries), limit (optional, default 1000, max 10000)
-   Returns: Array of result objects (one per row) with column names as keys
-   Security: Only SELECT queries are allowed. INSERT, UPDATE, DELETE, DROP, and other modification operations are rejected.
+   Parameters: connection_name (optional, required in SQLite mode), query (required, must be a SELECT statement), params (optional array for parameterized queries), limit (optional, default 1000, max 10000), max_total_bytes (optional, default 10485760, max 104857600)
+   Returns: Array of result objects (one per row) with column names as keys. When the estimated size of the rows would pass max_total_bytes, rows stop being read and the result is instead an object with rows (the rows read so far), count, truncated (true) and max_total_bytes
+   Security: Only SELECT queries are allowed. INSERT, UPDATE, DELETE, DROP, and other modification operations are rejected, and every query runs in a read-only transaction so writes made by functions such as nextval() are refused by the database.
 
 5. get_connection_info - Get connection information including host, port, database, user (password is masked for security)
    Parameters: connection_name (optional, required in SQLite mode)
-   Returns: Connection info object with masked connection string and parsed components (host, port, database, user, sslmode, description)
+   Returns: Connection info object with masked connection string and parsed components (host, port, database, user, sslmode, options, description)
+
+6. get_database_size - Get the total on-disk size of the connected database
+   Parameters: connection_name (optional, required in SQLite mode)
+   Returns: Object with database, size_bytes, and size (human-readable, e.g. "42 MB")
+
+7. get_table_sizes - Get the on-disk size of every table in a schema, largest first
+   Parameters: connection_name (optional, required in SQLite mode), schema (optional, defaults to 'public')
+   Returns: Array of objects with schema, table, total_bytes, total_size (human-readable), table_bytes, and index_bytes. total_bytes includes indexes and TOAST data
+
+8. list_indexes - List every index in a schema at once, sorted by table then index name
+   Parameters: connection_name (optional, required in SQLite mode), schema (optional, defaults to 'public')
+   Returns: Array of index objects with schema, table, index_name, definition (CREATE INDEX statement), is_unique, and is_primary
+
+9. get_connection_health - Check that a connection's server answers and how quickly
+   Parameters: connection_name (optional, required in SQLite mode)
+   Returns: Object with reachable, latency_ms (ping round trip), server_version (SELECT version()), server_time (server now() in UTC), and error when the server could not be reached
+
+10. sample_table - Return the first rows of a table to see what it holds without writing a query
+    Parameters: connection_name (optional, required in SQLite mode), table_name (required), schema (optional, defaults to 'public'), limit (optional, default 10, max 100)
+    Returns: Object with schema, table_name, columns (column names in table order), rows (one object per row, like query), and count
+
+11. list_views - List the views of a schema with their definitions, sorted by name
+    Parameters: connection_name (optional, required in SQLite mode), schema (optional, defaults to 'public')
+    Returns: Array of view objects with schema, view_name, definition (the view's SELECT, null for views owned by another user), and is_updatable
+
+12. list_sequences - List the sequences of a schema, sorted by name
+    Parameters: connection_name (optional, required in SQLite mode), schema (optional, defaults to 'public')
+    Returns: Array of sequence objects with schema, sequence_name, data_type, start_value, min_value, max_value, increment, cycle, and owned_by ("table.column" for serial and identity columns, otherwise null)
+
+13. get_table_statistics - Get the planner statistics of a table for query tuning, read from the pg_class, pg_stat_user_tables and pg_stats catalog views (nothing is vacuumed or analyzed)
+    Parameters: connection_name (optional, required in SQLite mode), table_name (required), schema (optional, defaults to 'public')
+    Returns: Object with schema, table, estimated_rows (pg_class.reltuples, -1 before the first ANALYZE), live_rows, dead_rows, rows_modified_since_analyze, seq_scans, index_scans, last_vacuum, last_autovacuum, last_analyze, last_autoanalyze (null when never run), and columns (per-column null_frac, n_distinct, avg_width and correlation, in table order; empty until the table is analyzed). A negative n_distinct is the number of distinct values as a fraction of the row count
+
+14. list_privileges - List the table privileges granted in a schema, or on one table, from information_schema.role_table_grants (read-only; only grants the connected role is grantor or grantee of, directly or through a role, are visible)
+    Parameters: connection_name (optional, required in SQLite mode), schema (optional, defaults to 'public'), table_name (optional, limits the result to that table)
+    Returns: Array of privilege objects with schema, table_name, grantee, privilege_type (SELECT, INSERT, UPDATE, DELETE, TRUNCATE, REFERENCES or TRIGGER), grantor, and is_grantable, sorted by table, grantee and privilege
+
+15. suggest_indexes - Run EXPLAIN on a SELECT query and suggest candidate indexes for the sequential scans of large tables (heuristic; the query is planned, never run)
+    Parameters: connection_name (optional, required in SQLite mode), query (required, SELECT only, validated like query), params (optional, for $1, $2 placeholders), min_rows (optional, default 10000, the estimated table rows from which a scan counts as large)
+    Returns: Object with seq_scans (schema, table, alias, filter, filter_columns, estimated_table_rows from pg_class.reltuples, plan_rows, total_cost, large), suggestions (schema, table, columns, statement, reason), min_rows, heuristic (always true) and note. Filter columns are parsed from the plan's filter text, equality comparisons first; columns inside function calls are not suggested. Tables never analyzed have estimated_table_rows -1 and are not large. Check every suggestion with EXPLAIN ANALYZE before creating the index
 
 Connection Management Operations:
-6. create_connection - Create a new database connection configuration
-   Parameters: name (required), host (required), port (optional, default 5432), database (required), user (required), password (required), sslmode (optional, default 'disable'), description (optional)
-   Returns: Created connection object (password masked)
+16. create_connection - Create a new database connection configuration
+    Parameters: name (required), host (required), port (optional, default 5432), database (required), user (required), password (required), sslmode (optional, default 'disable'), options (optional object of extra libpq parameters, e.g. {"connect_timeout": "5"}), description (optional)
+    Returns: Created connection object (password masked)
 
-7. list_connections - List all configured connections (passwords are masked)
-   Parameters: None
-   Returns: Array of connection objects (passwords masked)
+17. list_connections - List all configured connections (passwords are masked)
+    Parameters: None
+    Returns: Array of connection objects (passwords masked)
 
-8. get_connection - Get a connection configuration by name (password is masked)
-   Parameters: name (required)
-   Returns: Connection object (password masked)
+18. get_connection - Get a connection configuration by name (password is masked)
+    Parameters: name (required)
+    Returns: Connection object (password masked)
 
-9. update_connection - Update a connection configuration
-   Parameters: name (required), other fields optional (host, port, database, user, password, sslmode, description)
-   Returns: Updated connection object (password masked)
+19. update_connection - Update a connection configuration
+    Parameters: name (required), other fields optional (host, port, database, user, password, sslmode, options, description). options replaces the stored set; pass {} to clear it
+    Returns: Updated connection object (password masked)
 
-10. delete_connection - Delete a connection configuration (cannot delete 'master' connection)
+20. delete_connection - Delete a connection configuration (cannot delete 'master' connection)
     Parameters: name (required)
     Returns: Success confirmation
 
-11. rename_connection - Rename a connection (cannot rename 'master' connection)
+21. rename_connection - Rename a connection (cannot rename 'master' connection)
     Parameters: old_name (required), new_name (required)
     Returns: Renamed connection object (password masked)
 
 Connection Management: Connections are stored in the master database (configured via POSTGRES_DB_DSN) or in SQLite fallback mode (when POSTGRES_DB_DSN is not set). In PostgreSQL mode, the master connection is automatically created on startup with the name 'master'. In SQLite mode, you must explicitly create connections and always provide connection_name for database operations. Use connection management operations to add, view, update, or remove connections.
 
+Consistent snapshots: pass "snapshot": true next to "operations" to run every read operation of the batch in one REPEATABLE READ, read-only transaction per connection. Queries in the batch then see the same data even while other sessions write. The transactions are rolled back when the batch ends. A query repeated in the batch, e.g. with different params, is prepared once per connection and its statement reused.
+
 Examples:
 - List schemas (uses master by default in PostgreSQL mode): {"type": "list_schemas"}
 - List schemas with explicit connection: {"type": "list_schemas", "connection_name": "my_connection"}
 - List tables: {"type": "list_tables", "connection_name": "my_connection", "schema": "public"}
 - Describe a table: {"type": "describe_table", "connection_name": "my_connection", "table_name": "users", "schema": "public"}
 - Query with parameters: {"type": "query", "connection_name": "my_connection", "query": "SELECT * FROM users WHERE id = $1", "params": [123], "limit": 10}
+- Database size: {"type": "get_database_size", "connection_name": "my_connection"}
+- Table sizes: {"type": "get_table_sizes", "connection_name": "my_connection", "schema": "public"}
+- List indexes: {"type": "list_indexes", "connection_name": "my_connection", "schema": "public"}
+- Connection health: {"type": "get_connection_health", "connection_name": "my_connection"}
+- Sample a table: {"type": "sample_table", "connection_name": "my_connection", "table_name": "users", "limit": 5}
+- List views: {"type": "list_views", "connection_name": "my_connection", "schema": "public"}
+- List sequences: {"type": "list_sequences", "connection_name": "my_connection", "schema": "public"}
+- List privileges: {"type": "list_privileges", "connection_name": "my_connection", "schema": "public", "table_name": "orders"}
+- Table statistics: {"type": "get_table_statistics", "connection_name": "my_connection", "table_name": "orders"}
+- Suggest indexes: {"type": "suggest_indexes", "connection_name": "my_connection", "query": "SELECT * FROM orders WHERE customer_id = $1", "params": [42]}
 - Create connection: {"type": "create_connection", "name": "prod_db", "host": "prod.example.com", "database": "mydb", "user": "myuser", "password": "mypass"}
 - List connections: {"type": "list_connections"}
 - Get connection: {"type": "get_connection", "name": "prod_db"}
@@ -244,39 +282,54 @@ Examples:
 						"description": "List of PostgreSQL operations to execute. Each operation is an object with a 'type' field and operation-specific parameters.",
 						"items": map[string]interface{}{
 							"type":        "object",
-							"description": "Operation object. Must include 'type' field. Available types: list_schemas, list_tables, describe_table, query, get_connection_info, create_connection, list_connections, get_connection, update_connection, delete_connection, rename_connection",
+							"description": "Operation object. Must include 'type' field. Available types: list_schemas, list_tables, describe_table, query, get_connection_info, get_database_size, get_table_sizes, list_indexes, get_connection_health, sample_table, list_views, list_sequences, get_table_statistics, list_privileges, suggest_indexes, create_connection, list_connections, get_connection, update_connection, delete_connection, rename_connection",
 							"properties": map[string]interface{}{
 								"type": map[string]interface{}{
 									"type":        "string",
-									"enum":        []string{"list_schemas", "list_tables", "describe_table", "query", "get_connection_info", "create_connection", "list_connections", "get_connection", "update_connection", "delete_connection", "rename_connection"},
-									"description": "Operation type. Database operations: 'list_schemas', 'list_tables', 'describe_table', 'query', 'get_connection_info'. Connection management: 'create_connection', 'list_connections', 'get_connection', 'update_connection', 'delete_connection', 'rename_connection'.",
+									"enum":        []string{"list_schemas", "list_tables", "describe_table", "query", "get_connection_info", "get_database_size", "get_table_sizes", "list_indexes", "get_connection_health", "sample_table", "list_views", "list_sequences", "get_table_statistics", "list_privileges", "suggest_indexes", "create_connection", "list_connections", "get_connection", "update_connection", "delete_connection", "rename_connection"},
+									"description": "Operation type. Database operations: 'list_schemas', 'list_tables', 'describe_table', 'query', 'get_connection_info', 'get_database_size', 'get_table_sizes', 'list_indexes', 'get_connection_health', 'sample_table', 'list_views', 'list_sequences', 'get_table_statistics', 'list_privileges', 'suggest_indexes'. Connection management: 'create_connection', 'list_connections', 'get_connection', 'update_connection', 'delete_connection', 'rename_connection'.",
+								},
+								"id": map[string]interface{}{
+									"type":        "string",
+									"description": "Optional name for this operation's output. A later operation in the same batch can use it in any top-level string param as {{id.field}}, following nested fields and array indexes, e.g. {{tables.0.table_name}} after a list_tables operation with id 'tables'. A param that is exactly one reference takes the referenced value; references inside longer strings are inserted as text. An unresolvable reference fails that operation.",
 								},
 								"connection_name": map[string]interface{}{
 									"type":        "string",
-									"description": "Connection name. Optional for database operations (list_schemas, list_tables, describe_table, query, get_connection_info). Defaults to 'master' if not provided. Must be a configured connection name.",
+									"description": "Connection name. Optional for database operations (list_schemas, list_tables, describe_table, query, get_connection_info, get_database_size, get_table_sizes, list_indexes, get_connection_health, sample_table, list_views, list_sequences, get_table_statistics, list_privileges, suggest_indexes). Defaults to 'master' if not provided. Must be a configured connection name.",
 								},
 								"schema": map[string]interface{}{
 									"type":        "string",
-									"description": "Schema name. Used by list_tables and describe_table operations. Defaults to 'public' if not specified.",
+									"description": "Schema name. Used by list_tables, describe_table, get_table_sizes, list_indexes, sample_table, list_views, list_sequences, get_table_statistics and list_privileges operations. Defaults to 'public' if not specified.",
 								},
 								"table_name": map[string]interface{}{
 									"type":        "string",
-									"description": "Table name. Required for describe_table operation. Should be the name of the table you want to inspect.",
+									"description": "Table name. Required for describe_table, sample_table and get_table_statistics operations; optional for list_privileges. Should be the name of the table you want to inspect.",
 								},
 								"query": map[string]interface{}{
 									"type":        "string",
-									"description": "SQL SELECT query to execute. Required for query operation. Must be a SELECT statement only - INSERT, UPDATE, DELETE, DROP and other modification operations are rejected for security. Supports parameterized queries using $1, $2, etc. placeholders.",
+									"description": "SQL SELECT query to execute. Required for query and suggest_indexes operations. Must be a SELECT statement only - INSERT, UPDATE, DELETE, DROP and other modification operations are rejected for security. Supports parameterized queries using $1, $2, etc. placeholders.",
 								},
 								"params": map[string]interface{}{
 									"type":        "array",
-									"description": "Query parameters for parameterized queries. Used with query operation. Array of values that correspond to $1, $2, etc. placeholders in the query string. Example: [123, 'text'] for query with $1 and $2.",
+									"description": "Query parameters for parameterized queries. Used with query operation. Array of values that correspond to $1, $2, etc. placeholders in the query string. Example: [123, 'text'] for query with $1 and $2. Also used with suggest_indexes.",
 								},
 								"limit": map[string]interface{}{
 									"type":        "integer",
-									"description": "Maximum number of rows to return. Used with query operation. Default: 1000, Maximum: 10000. Automatically adds LIMIT clause if not present in query.",
+									"description": "Maximum number of rows to return. Used with query operation (default: 1000, maximum: 10000; a LIMIT clause is added if not present in the query) and sample_table operation (default: 10, maximum: 100).",
 									"minimum":     1,
 									"maximum":     10000,
 								},
+								"max_total_bytes": map[string]interface{}{
+									"type":        "integer",
+									"description": "Size budget of a query result in bytes (default: 10485760, maximum: 104857600). Rows stop being read once their estimated JSON size would pass it, and the result becomes {rows, count, truncated: true, max_total_bytes}, so wide text or jsonb rows cannot exhaust memory. Used with query operation.",
+									"minimum":     1,
+									"maximum":     104857600,
+								},
+								"min_rows": map[string]interface{}{
+									"type":        "integer",
+									"description": "Estimated table rows (pg_class.reltuples) from which a sequential scan counts as a scan of a large table and gets an index suggestion. Used with suggest_indexes operation (default: 10000).",
+									"minimum":     0,
+								},
 								"name": map[string]interface{}{
 									"type":        "string",
 									"description": "Connection name. Required for create_connection, get_connection, update_connection, delete_connection operations.",
@@ -307,6 +360,13 @@ Examples:
 									"type":        "string",
 									"description": "SSL mode. Optional for create_connection and update_connection (default: 'disable'). Common values: 'disable', 'require', 'verify-ca', 'verify-full'.",
 								},
+								"options": map[string]interface{}{
+									"type":        "object",
+									"description": "Extra libpq connection parameters appended to the connection string, e.g. {\"connect_timeout\": \"5\", \"application_name\": \"mcp\"}. Optional for create_connection and update_connection. On update the whole set is replaced. host, port, dbname, user, password and sslmode must use their own parameters.",
+									"additionalProperties": map[string]interface{}{
+										"type": "string",
+									},
+								},
 								"description": map[string]interface{}{
 									"type":        "string",
 									"description": "Connection description. Optional for create_connection and update_connection.",
@@ -323,6 +383,10 @@ Examples:
 							"required": []string{"type"},
 						},
 					},
+					"snapshot": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Run the read operations of the batch (list_schemas, list_tables, describe_table, query, get_database_size, get_table_sizes, list_indexes, list_views, list_sequences, get_table_statistics, list_privileges, suggest_indexes) in one REPEATABLE READ, read-only transaction per connection, so they all see the same data. The transaction is rolled back when the batch ends. Default: false",
+					},
 				},
 				"required": []string{"operations"},
 			},
@@ -355,6 +419,8 @@ func handleToolCall(msg *MCPMessage, encoder *json.Encoder) {
 	}
 
 	if req.Name == "apply_operations" {
+		endRequest := beginRequest()
+		defer endRequest()
 		handleBatchOperations(msg, encoder, req.Arguments)
 		return
 	}
@@ -363,138 +429,53 @@ func handleToolCall(msg *MCPMessage, encoder *json.Encoder) {
 	sendError(encoder, msg.ID, -32601, fmt.Sprintf("Unknown tool: %s. Use apply_operations for batch operations", req.Name), nil)
 }
 
+// batchRunner dispatches apply_operations to the operation handlers
+var batchRunner = mcp.NewBatchRunner(map[string]mcp.OperationHandler{
+	"list_schemas":          toolListSchemas,
+	"list_tables":           toolListTables,
+	"describe_table":        toolDescribeTable,
+	"query":                 toolQuery,
+	"get_connection_info":   toolGetConnectionInfo,
+	"get_database_size":     toolGetDatabaseSize,
+	"get_table_sizes":       toolGetTableSizes,
+	"list_indexes":          toolListIndexes,
+	"get_connection_health": toolGetConnectionHealth,
+	"sample_table":          toolSampleTable,
+	"list_views":            toolListViews,
+	"list_sequences":        toolListSequences,
+	"get_table_statistics":  toolGetTableStatistics,
+	"list_privileges":       toolListPrivileges,
+	"suggest_indexes":       toolSuggestIndexes,
+	"create_connection":     toolCreateConnection,
+	"list_connections":      toolListConnections,
+	"get_connection":        toolGetConnection,
+	"update_connection":     toolUpdateConnection,
+	"delete_connection":     toolDeleteConnection,
+	"rename_connection":     toolRenameConnection,
+}).WithParamsFilter(optimizeParams).WithRequiredParams(mcp.RequiredParams{
+	"describe_table":       {{"table_name"}},
+	"query":                {{"query"}},
+	"get_table_statistics": {{"table_name"}},
+	"suggest_indexes":      {{"query"}},
+})
+
+// handleBatchOperations processes a batch of operations. With "snapshot": true the read
+// operations share one consistent snapshot per connection. Operations may refer to the
+// output of earlier ones with {{id.field}}; see runWithReferences.
 func handleBatchOperations(msg *MCPMessage, encoder *json.Encoder, args map[string]interface{}) {
-	operations, ok := args["operations"].([]interface{})
-	if !ok {
-		sendError(encoder, msg.ID, -32602, "operations array is required", nil)
-		return
-	}
-
-	if len(operations) == 0 {
-		sendError(encoder, msg.ID, -32602, "operations array cannot be empty", nil)
-		return
-	}
-
-	var results []map[string]interface{}
-
-	for _, op := range operations {
-		opMap, ok := op.(map[string]interface{})
-		if !ok {
-			results = append(results, map[string]interface{}{
-				"operation": "unknown",
-				"params":    map[string]interface{}{},
-				"status":    "Error",
-				"message":   "Invalid operation format",
-			})
-			continue
-		}
-
-		opType, ok := opMap["type"].(string)
-		if !ok {
-			results = append(results, map[string]interface{}{
-				"operation": "unknown",
-				"params":    map[string]interface{}{},
-				"status":    "Error",
-				"message":   "Operation type is required",
-			})
-			continue
-		}
-
-		// Extract operation-specific arguments as params
-		params := make(map[string]interface{})
-		for k, v := range opMap {
-			if k != "type" {
-				params[k] = v
-			}
-		}
-
-		// Execute operation based on type
-		var result string
-		var err error
-
-		switch opType {
-		case "list_schemas":
-			result, err = toolListSchemas(params)
-		case "list_tables":
-			result, err = toolListTables(params)
-		case "describe_table":
-			result, err = toolDescribeTable(params)
-		case "query":
-			result, err = toolQuery(params)
-		case "get_connection_info":
-			result, err = toolGetConnectionInfo(params)
-		case "create_connection":
-			result, err = toolCreateConnection(params)
-		case "list_connections":
-			result, err = toolListConnections(params)
-		case "get_connection":
-			result, err = toolGetConnection(params)
-		case "update_connection":
-			result, err = toolUpdateConnection(params)
-		case "delete_connection":
-			result, err = toolDeleteConnection(params)
-		case "rename_connection":
-			result, err = toolRenameConnection(params)
-		default:
-			err = fmt.Errorf("unknown operation type: %s", opType)
-		}
-
-		// Optimize params before adding to results
-		optimizedParams := optimizeParams(opType, params)
-
-		if err != nil {
-			results = append(results, map[string]interface{}{
-				"operation": opType,
-				"params":    optimizedParams,
-				"status":    "Error",
-				"message":   err.Error(),
-			})
-		} else {
-			// Parse JSON result if possible, otherwise use as string
-			var parsedResult interface{}
-			if jsonErr := json.Unmarshal([]byte(result), &parsedResult); jsonErr == nil {
-				// Successfully parsed JSON, use parsed result
-			} else {
-				// Not JSON, use string as-is
-				parsedResult = result
-			}
-
-			results = append(results, map[string]interface{}{
-				"operation": opType,
-				"params":    optimizedParams,
-				"status":    "Success",
-				"result":    parsedResult,
-			})
-		}
-	}
-
-	// Serialize results to JSON text for MCP-compliant response format
-	resultsJSON, err := json.Marshal(map[string]interface{}{
-		"results": results,
-	})
+	operations, err := mcp.Operations(args)
 	if err != nil {
-		sendError(encoder, msg.ID, -32700, fmt.Sprintf("Failed to marshal results: %v", err), nil)
+		sendError(encoder, msg.ID, mcp.CodeInvalidParams, err.Error(), nil)
 		return
 	}
 
-	// Return results in MCP-compliant format using ToolsCallResponse
-	response := MCPMessage{
-		JSONRPC: "2.0",
-		ID:      msg.ID,
-		Result: ToolsCallResponse{
-			Content: []Content{
-				{
-					Type: "text",
-					Text: string(resultsJSON),
-				},
-			},
-			IsError: false,
-		},
-	}
-
-	if err := encoder.Encode(response); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to encode batch operations response: %v\n", err)
+	if snapshot, _ := args["snapshot"].(bool); snapshot {
+		beginSnapshot()
+		defer endSnapshot()
 	}
+	mcp.SendBatchResponse(encoder, msg.ID, map[string]interface{}{
+		"results": runWithReferences(operations),
+	})
 }
 
 // optimizeParams optimizes params for response by truncating long string values (> 20 lines)
@@ -507,6 +488,8 @@ func optimizeParams(opType string, params map[string]interface{}) map[string]int
 		"schema":          true,
 		"table_name":      true,
 		"limit":           true,
+		"max_total_bytes": true,
+		"min_rows":        true,
 		"name":            true,
 		"host":            true,
 		"port":            true,
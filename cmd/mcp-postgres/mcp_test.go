@@ -368,6 +368,36 @@ func TestHandleBatchOperations(t *testing.T) {
 	}
 }
 
+// TestBatchOperationsMissingRequiredParams tests that describe_table and query report the
+// missing field before touching the database
+func TestBatchOperationsMissingRequiredParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		op      map[string]interface{}
+		missing string
+	}{
+		{"describe_table without table_name", map[string]interface{}{"type": "describe_table", "schema": "public"}, "table_name"},
+		{"describe_table with empty table_name", map[string]interface{}{"type": "describe_table", "table_name": ""}, "table_name"},
+		{"query without query", map[string]interface{}{"type": "query", "limit": float64(10)}, "query"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := batchRunner.Run([]interface{}{tt.op})[0]
+
+			if result["success"] != false || result["error_code"] != "invalid_params" {
+				t.Fatalf("Expected invalid_params error, got %v", result)
+			}
+			if result["missing_field"] != tt.missing {
+				t.Errorf("missing_field = %v, want %s", result["missing_field"], tt.missing)
+			}
+			if message, _ := result["error"].(string); !strings.Contains(message, tt.missing) {
+				t.Errorf("Expected error to name %s, got %q", tt.missing, message)
+			}
+		})
+	}
+}
+
 // TestSendError tests error response generation
 func TestSendError(t *testing.T) {
 	var output bytes.Buffer
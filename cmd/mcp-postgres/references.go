@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/code-aria/internal-mcp/internal/mcp"
+)
+
+// referencePattern matches a {{id.field}} reference to the output of an earlier operation
+// in the batch. The path may continue through nested fields and array indexes, e.g.
+// {{tables.0.table_name}}.
+var referencePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][\w-]*)((?:\.[\w-]+)+)\s*\}\}`)
+
+// runWithReferences runs a batch like batchRunner.Run, but lets an operation name its
+// output with an "id" param and later operations use it through {{id.field}} references in
+// their top-level string params. References are resolved just before each operation runs.
+func runWithReferences(operations []interface{}) []map[string]interface{} {
+	outputs := make(map[string]interface{})
+	results := make([]map[string]interface{}, 0, len(operations))
+
+	for i, op := range operations {
+		opMap, ok := op.(map[string]interface{})
+		if !ok {
+			results = append(results, batchRunner.RunOperation(i, op))
+			continue
+		}
+
+		resolved, err := resolveReferences(opMap, outputs)
+		if err != nil {
+			opType, _ := opMap["type"].(string)
+			results = append(results, mcp.ErrorResult(i, opType, optimizeParams(opType, mcp.OperationParams(opMap)), err.Error()))
+			continue
+		}
+
+		result := batchRunner.RunOperation(i, resolved)
+		results = append(results, result)
+
+		if id, ok := opMap["id"].(string); ok && id != "" && result["success"] == true {
+			outputs[id] = result["result"]
+		}
+	}
+
+	return results
+}
+
+// resolveReferences returns a copy of op with the references in its top-level string
+// params replaced. A param that is a single reference takes the referenced value as is,
+// so numbers stay numbers; references inside longer strings are formatted as text.
+func resolveReferences(op map[string]interface{}, outputs map[string]interface{}) (map[string]interface{}, error) {
+	resolved := make(map[string]interface{}, len(op))
+	for key, value := range op {
+		resolved[key] = value
+
+		str, ok := value.(string)
+		if !ok || key == "type" || key == "id" || !strings.Contains(str, "{{") {
+			continue
+		}
+
+		if m := referencePattern.FindStringSubmatch(str); m != nil && m[0] == strings.TrimSpace(str) {
+			v, err := lookupReference(m[1], m[2], outputs)
+			if err != nil {
+				return nil, fmt.Errorf("param %s: %w", key, err)
+			}
+			resolved[key] = v
+			continue
+		}
+
+		var lookupErr error
+		resolved[key] = referencePattern.ReplaceAllStringFunc(str, func(ref string) string {
+			m := referencePattern.FindStringSubmatch(ref)
+			v, err := lookupReference(m[1], m[2], outputs)
+			if err != nil {
+				if lookupErr == nil {
+					lookupErr = err
+				}
+				return ref
+			}
+			return fmt.Sprint(v)
+		})
+		if lookupErr != nil {
+			return nil, fmt.Errorf("param %s: %w", key, lookupErr)
+		}
+	}
+	return resolved, nil
+}
+
+// lookupReference follows path, a dotted sequence of ".field" or ".index" segments, into
+// the output of operation id. The value found must be a string, number or boolean.
+func lookupReference(id, path string, outputs map[string]interface{}) (interface{}, error) {
+	value, ok := outputs[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown reference {{%s%s}}: no earlier successful operation has id %q", id, path, id)
+	}
+
+	for _, segment := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			field, ok := v[segment]
+			if !ok {
+				return nil, fmt.Errorf("reference {{%s%s}}: field %q not found", id, path, segment)
+			}
+			value = field
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, fmt.Errorf("reference {{%s%s}}: index %q out of range for %d items", id, path, segment, len(v))
+			}
+			value = v[index]
+		default:
+			return nil, fmt.Errorf("reference {{%s%s}}: cannot look up %q in a %T", id, path, segment, value)
+		}
+	}
+
+	switch value.(type) {
+	case string, float64, bool:
+		return value, nil
+	}
+	return nil, fmt.Errorf("reference {{%s%s}} is not a string, number or boolean", id, path)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestResolveReferences tests substituting {{id.field}} references from earlier outputs
+func TestResolveReferences(t *testing.T) {
+	outputs := map[string]interface{}{
+		"tables": []interface{}{
+			map[string]interface{}{"schema": "public", "table_name": "users"},
+		},
+		"stats": map[string]interface{}{"limit": float64(5), "nested": map[string]interface{}{}},
+	}
+
+	resolved, err := resolveReferences(map[string]interface{}{
+		"type":       "sample_table",
+		"id":         "{{tables.0.table_name}}",
+		"table_name": "{{ tables.0.table_name }}",
+		"query":      "SELECT * FROM {{tables.0.schema}}.{{tables.0.table_name}} LIMIT {{stats.limit}}",
+		"limit":      "{{stats.limit}}",
+		"schema":     "public",
+	}, outputs)
+	if err != nil {
+		t.Fatalf("resolveReferences() error = %v", err)
+	}
+	if resolved["table_name"] != "users" {
+		t.Errorf("table_name = %v, want users", resolved["table_name"])
+	}
+	if resolved["query"] != "SELECT * FROM public.users LIMIT 5" {
+		t.Errorf("query = %v", resolved["query"])
+	}
+	if resolved["limit"] != float64(5) {
+		t.Errorf("Expected a whole-value reference to keep its number type, got %#v", resolved["limit"])
+	}
+	if resolved["id"] != "{{tables.0.table_name}}" {
+		t.Errorf("Expected id to be left alone, got %v", resolved["id"])
+	}
+
+	errorCases := map[string]string{
+		"unknown id":     "{{missing.name}}",
+		"missing field":  "{{tables.0.owner}}",
+		"bad index":      "{{tables.3.table_name}}",
+		"non-scalar":     "{{stats.nested}}",
+		"embedded error": "SELECT * FROM {{tables.x}}",
+	}
+	for name, value := range errorCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := resolveReferences(map[string]interface{}{"type": "query", "query": value}, outputs)
+			if err == nil || !strings.Contains(err.Error(), "param query") {
+				t.Errorf("Expected an error naming the param, got %v", err)
+			}
+		})
+	}
+}
+
+// TestRunWithReferences tests a query that uses a table name listed earlier in the batch
+func TestRunWithReferences(t *testing.T) {
+	setupTestDB(t)
+
+	schema := createTestSchema(t,
+		`CREATE TABLE %s.widgets (id SERIAL PRIMARY KEY, name TEXT)`,
+		`INSERT INTO %s.widgets (name) VALUES ('a'), ('b'), ('c')`,
+	)
+	connectionName := getTestConnectionName()
+
+	results := runWithReferences([]interface{}{
+		map[string]interface{}{
+			"type":            "list_tables",
+			"id":              "tables",
+			"connection_name": connectionName,
+			"schema":          schema,
+		},
+		map[string]interface{}{
+			"type":            "query",
+			"connection_name": connectionName,
+			"query":           "SELECT count(*) AS n FROM {{tables.0.schema}}.{{tables.0.table_name}}",
+		},
+		map[string]interface{}{
+			"type":            "sample_table",
+			"connection_name": connectionName,
+			"schema":          schema,
+			"table_name":      "{{tables.5.table_name}}",
+		},
+	})
+
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	if results[0]["success"] != true {
+		t.Fatalf("list_tables failed: %v", results[0])
+	}
+	if results[1]["success"] != true {
+		t.Fatalf("Referencing query failed: %v", results[1])
+	}
+	rows, ok := results[1]["result"].([]interface{})
+	if !ok || len(rows) != 1 {
+		t.Fatalf("Expected one row, got %v", results[1]["result"])
+	}
+	if n := rows[0].(map[string]interface{})["n"]; n != float64(3) {
+		t.Errorf("Expected 3 widgets, got %v", n)
+	}
+	if results[2]["success"] != false {
+		t.Errorf("Expected an out-of-range reference to fail, got %v", results[2])
+	}
+}
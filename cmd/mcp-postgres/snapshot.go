@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// queryer is the part of *sql.DB and *sql.Tx that the read operations use
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// readSnapshot holds the transactions of a snapshot batch: one REPEATABLE READ, read-only
+// transaction per connection string, so every read on a connection sees the same data.
+// Statements prepared in a transaction are kept for the rest of the batch, keyed by
+// connection string and query text, so a query repeated with different params is parsed
+// and planned once.
+type readSnapshot struct {
+	dbs   map[string]*sql.DB
+	txs   map[string]*sql.Tx
+	stmts map[string]*sql.Stmt
+}
+
+// activeSnapshot is set while a snapshot batch runs. Requests are handled one at a time,
+// so it needs no locking.
+var activeSnapshot *readSnapshot
+
+// beginSnapshot makes the read operations of the current batch share snapshot transactions.
+// Transactions are started lazily, the first time an operation uses a connection.
+func beginSnapshot() {
+	activeSnapshot = &readSnapshot{
+		dbs:   make(map[string]*sql.DB),
+		txs:   make(map[string]*sql.Tx),
+		stmts: make(map[string]*sql.Stmt),
+	}
+}
+
+// endSnapshot closes the prepared statements, then rolls back every snapshot transaction and
+// closes its connection
+func endSnapshot() {
+	if activeSnapshot == nil {
+		return
+	}
+	for _, stmt := range activeSnapshot.stmts {
+		stmt.Close()
+	}
+	for connStr, tx := range activeSnapshot.txs {
+		tx.Rollback()
+		activeSnapshot.dbs[connStr].Close()
+	}
+	activeSnapshot = nil
+}
+
+// transaction returns the snapshot transaction for connStr, starting it on first use
+func (s *readSnapshot) transaction(connStr string) (*sql.Tx, error) {
+	if tx, ok := s.txs[connStr]; ok {
+		return tx, nil
+	}
+
+	db, err := openDatabase(connStr)
+	if err != nil {
+		return nil, err
+	}
+	// A single connection, so the transaction and everything it runs share one session
+	db.SetMaxOpenConns(1)
+
+	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		db.Close()
+		return nil, maskConnectionError(connStr, fmt.Errorf("failed to start snapshot transaction: %w", err))
+	}
+	if _, err := tx.Exec("SET LOCAL timezone = 'UTC'"); err != nil {
+		tx.Rollback()
+		db.Close()
+		return nil, fmt.Errorf("failed to set timezone to UTC: %w", err)
+	}
+
+	s.dbs[connStr] = db
+	s.txs[connStr] = tx
+	return tx, nil
+}
+
+// statement returns the prepared statement for query on connStr's transaction, preparing
+// it on first use. A statement that fails to prepare is not cached.
+func (s *readSnapshot) statement(connStr string, tx *sql.Tx, query string) (*sql.Stmt, error) {
+	key := connStr + "\x00" + query
+	if stmt, ok := s.stmts[key]; ok {
+		return stmt, nil
+	}
+
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	s.stmts[key] = stmt
+	return stmt, nil
+}
+
+// snapshotQueryer runs the queries of an operation in a snapshot transaction with requestCtx,
+// reusing the batch's prepared statements. QueryRow is only used for one-off metadata lookups
+// and is not prepared; user SQL always goes through Query, as a prepared statement cannot hold
+// several statements.
+type snapshotQueryer struct {
+	*sql.Tx
+	connStr string
+}
+
+// Query runs query through the statement prepared for its text
+func (q snapshotQueryer) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, err := activeSnapshot.statement(q.connStr, q.Tx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.QueryContext(requestCtx, args...)
+}
+
+// QueryRow runs query in the transaction with requestCtx
+func (q snapshotQueryer) QueryRow(query string, args ...interface{}) *sql.Row {
+	return q.Tx.QueryRowContext(requestCtx, query, args...)
+}
+
+// openReader returns what a read operation should query and a function that releases it.
+// Outside a snapshot batch that is a read-only transaction on a new connection. Inside one it
+// is the batch's transaction, wrapped in a savepoint so that a failing operation does not
+// abort the ones after it. Only the batch's statements are kept: a transaction outside one
+// lasts for a single operation, so its statements are prepared only to run one statement at a
+// time and are closed with it. Either way the server itself refuses writes, including those
+// made by functions such as nextval() that validateSelectQuery cannot see.
+func openReader(connStr string) (queryer, func(), error) {
+	if activeSnapshot == nil {
+		db, err := openDatabase(connStr)
+		if err != nil {
+			return nil, nil, err
+		}
+		// BEGIN READ ONLY, the same as SET TRANSACTION READ ONLY at the start of the transaction
+		tx, err := db.BeginTx(requestCtx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			db.Close()
+			return nil, nil, maskConnectionError(connStr, cancelledError(fmt.Errorf("failed to start read-only transaction: %w", err)))
+		}
+		return contextQueryer{Tx: tx}, func() {
+			tx.Rollback()
+			db.Close()
+		}, nil
+	}
+
+	tx, err := activeSnapshot.transaction(connStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := tx.Exec("SAVEPOINT snapshot_operation"); err != nil {
+		return nil, nil, fmt.Errorf("failed to create savepoint: %w", err)
+	}
+	return snapshotQueryer{Tx: tx, connStr: connStr}, func() {
+		// Nothing is written, so this only clears a failed operation; the snapshot is kept
+		tx.Exec("ROLLBACK TO SAVEPOINT snapshot_operation")
+		tx.Exec("RELEASE SAVEPOINT snapshot_operation")
+	}, nil
+}
@@ -1,19 +1,19 @@
 package main
 
 import (
-	"bufio"
 	"encoding/json"
 	"log"
 	"os"
+
+	"github.com/code-aria/internal-mcp/internal/mcp"
 )
 
 func main() {
 	log.Println("MCP Savepoints Server starting...")
 
 	// Setup MCP communication
-	scanner := bufio.NewScanner(os.Stdin)
-	// Increase buffer size to handle large JSON-RPC messages
-	scanner.Buffer(nil, 10*1024*1024) // 10MB buffer
+	// Messages up to MCP_MAX_MESSAGE_BYTES (default 10MB) are accepted
+	scanner := mcp.NewScanner(os.Stdin)
 	encoder := json.NewEncoder(os.Stdout)
 
 	// Handle initialize request
@@ -33,17 +33,5 @@ func main() {
 	log.Println("MCP Savepoints Server initialized")
 
 	// Handle requests
-	for scanner.Scan() {
-		var msg MCPMessage
-		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
-			log.Printf("Failed to parse message: %v", err)
-			continue
-		}
-
-		handleRequest(&msg, encoder)
-	}
-
-	if err := scanner.Err(); err != nil {
-		log.Fatalf("Scanner error: %v", err)
-	}
+	mcp.Serve(scanner, encoder, "mcp-savepoints", handleRequest)
 }
@@ -6,6 +6,8 @@ import (
 	"os"
 	"sync"
 	"time"
+
+	"github.com/code-aria/internal-mcp/internal/auditlog"
 )
 
 var (
@@ -13,6 +15,8 @@ var (
 	auditMutex    sync.Mutex
 	auditEnabled  bool
 	auditFilePath string
+	auditMaxBytes = auditlog.DefaultMaxBytes
+	auditMaxFiles = auditlog.DefaultMaxFiles
 )
 
 // InitAuditLogger initializes the audit logging system
@@ -34,6 +38,8 @@ func InitAuditLogger() error {
 		// Default to current directory
 		auditFilePath = "mcp-systeminfo-audit.log"
 	}
+	auditMaxBytes = auditlog.MaxBytes()
+	auditMaxFiles = auditlog.MaxFiles()
 
 	// Open audit file in append mode
 	var err error
@@ -82,19 +88,7 @@ func CloseAuditLogger() {
 
 // auditLog logs an audit entry for systeminfo operations
 func auditLog(operation, command, workingDir string, user string, result *CommandResult, security *SecurityResult, durationMs int64, success bool, errorCode int, errorType string) {
-	if !auditEnabled {
-		return
-	}
-
-	auditMutex.Lock()
-	defer auditMutex.Unlock()
-
-	if auditFile == nil {
-		return
-	}
-
-	// Build audit entry
-	entry := AuditLog{
+	auditLogEntry(AuditLog{
 		Timestamp:  time.Now().UTC(),
 		Operation:  operation,
 		Command:    command,
@@ -106,6 +100,32 @@ func auditLog(operation, command, workingDir string, user string, result *Comman
 		Success:    success,
 		ErrorCode:  errorCode,
 		ErrorType:  errorType,
+	})
+}
+
+// auditLogWithTimeout logs an operation that ran commands under the given effective timeout
+func auditLogWithTimeout(operation, command string, timeoutSeconds int, durationMs int64, success bool) {
+	auditLogEntry(AuditLog{
+		Timestamp:      time.Now().UTC(),
+		Operation:      operation,
+		Command:        command,
+		DurationMs:     durationMs,
+		Success:        success,
+		TimeoutSeconds: timeoutSeconds,
+	})
+}
+
+// auditLogEntry writes an operation audit entry
+func auditLogEntry(entry AuditLog) {
+	if !auditEnabled {
+		return
+	}
+
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+
+	if auditFile == nil {
+		return
 	}
 
 	// Convert to JSON and write
@@ -125,11 +145,18 @@ func auditLog(operation, command, workingDir string, user string, result *Comman
 		"server_name":   "mcp-systeminfo",
 		"server_version": "1.0.0",
 	}
+	if entry.TimeoutSeconds > 0 {
+		auditData["timeout_seconds"] = entry.TimeoutSeconds
+	}
+	if entry.CacheHit {
+		auditData["cache_hit"] = true
+	}
 
 	writeAuditEntry(auditData)
 }
 
-// writeAuditEntry writes an audit entry to the audit file
+// writeAuditEntry writes an audit entry to the audit file, rotating it first if the entry
+// would push it past auditMaxBytes. Callers must hold auditMutex.
 func writeAuditEntry(entry map[string]interface{}) error {
 	if auditFile == nil {
 		return fmt.Errorf("audit file not open")
@@ -141,6 +168,12 @@ func writeAuditEntry(entry map[string]interface{}) error {
 		return fmt.Errorf("failed to marshal audit entry: %w", err)
 	}
 
+	if fileInfo, err := auditFile.Stat(); err == nil && auditlog.ShouldRotate(fileInfo.Size(), int64(len(jsonData)+1), auditMaxBytes) {
+		if err := rotateAuditFile(); err != nil {
+			return err
+		}
+	}
+
 	// Write to file with newline
 	if _, err := auditFile.Write(append(jsonData, '\n')); err != nil {
 		return fmt.Errorf("failed to write audit entry: %w", err)
@@ -150,6 +183,64 @@ func writeAuditEntry(entry map[string]interface{}) error {
 	return auditFile.Sync()
 }
 
+// rotateAuditFile moves the full audit file aside and opens a fresh one. Callers must
+// hold auditMutex.
+func rotateAuditFile() error {
+	auditFile.Close()
+	auditFile = nil
+
+	rotateErr := auditlog.Rotate(auditFilePath, auditMaxFiles)
+
+	// Reopen even if the rename failed so logging carries on in the old file
+	file, err := os.OpenFile(auditFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to reopen audit file: %w", err)
+	}
+	auditFile = file
+
+	return rotateErr
+}
+
+// toolQueryAuditLog returns the most recent audit entries matching operation, since and success
+func toolQueryAuditLog(args map[string]interface{}) (string, error) {
+	filter, limit, err := auditlog.ParseQueryArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	startTime := time.Now()
+	result, err := auditlog.Query(currentAuditFilePath(), filter, limit)
+	duration := time.Since(startTime).Milliseconds()
+
+	// Logged after the read so the query does not return itself
+	auditLog("query_audit_log", "", "", "", nil, nil, duration, err == nil, 0, "")
+
+	if err != nil {
+		return "", err
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal audit entries: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// currentAuditFilePath returns the audit file in use, falling back to
+// MCP_SYSTEMINFO_AUDIT_FILE and then the default when the logger has not been initialized
+func currentAuditFilePath() string {
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+
+	if auditFilePath != "" {
+		return auditFilePath
+	}
+	if path := os.Getenv("MCP_SYSTEMINFO_AUDIT_FILE"); path != "" {
+		return path
+	}
+	return "mcp-systeminfo-audit.log"
+}
+
 // GetAuditStats returns audit logging statistics
 func GetAuditStats() map[string]interface{} {
 	auditMutex.Lock()
@@ -7,6 +7,9 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
+
+	"github.com/code-aria/internal-mcp/internal/auditlog"
 )
 
 // TestInitAuditLogger tests the InitAuditLogger function
@@ -287,6 +290,87 @@ func TestAuditLogDisabled(t *testing.T) {
 	// But we can at least verify the function doesn't panic
 }
 
+// TestAuditLogRotation tests that the audit file is rotated once it passes MCP_AUDIT_MAX_BYTES
+func TestAuditLogRotation(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "test-audit.log")
+	t.Setenv("MCP_SYSTEMINFO_AUDIT_FILE", tempFile)
+	t.Setenv("MCP_AUDIT_MAX_BYTES", "1024")
+	t.Setenv("MCP_AUDIT_MAX_FILES", "2")
+
+	if err := InitAuditLogger(); err != nil {
+		t.Fatalf("InitAuditLogger() failed: %v", err)
+	}
+	defer CloseAuditLogger()
+
+	for i := 0; i < 30; i++ {
+		auditLog("test_operation", "test_command", "/test/dir", "test_user", nil, nil, 1, true, 0, "")
+	}
+
+	rotated, err := auditlog.RotatedFiles(tempFile)
+	if err != nil {
+		t.Fatalf("RotatedFiles() error = %v", err)
+	}
+	if len(rotated) != 2 {
+		t.Fatalf("Expected 2 rotated files to be kept, got %v", rotated)
+	}
+
+	info, err := os.Stat(tempFile)
+	if err != nil {
+		t.Fatalf("Expected a fresh audit file after rotation: %v", err)
+	}
+	if info.Size() > 1024 {
+		t.Errorf("Current audit file is %d bytes, expected at most 1024", info.Size())
+	}
+}
+
+// TestToolQueryAuditLog tests that recent operations can be read back from the audit file
+func TestToolQueryAuditLog(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "test-audit.log")
+	t.Setenv("MCP_SYSTEMINFO_AUDIT_FILE", tempFile)
+
+	if err := InitAuditLogger(); err != nil {
+		t.Fatalf("InitAuditLogger() failed: %v", err)
+	}
+	defer CloseAuditLogger()
+
+	since := time.Now().UTC().Add(-time.Second).Format(time.RFC3339)
+	auditLog("get_os_info", "", "", "", nil, nil, 1, true, 0, "")
+	auditLog("check_command", "git", "", "", nil, nil, 1, false, 0, "")
+	auditLog("get_os_info", "", "", "", nil, nil, 1, true, 0, "")
+
+	result, err := toolQueryAuditLog(map[string]interface{}{"operation": "get_os_info", "since": since})
+	if err != nil {
+		t.Fatalf("toolQueryAuditLog() error = %v", err)
+	}
+
+	var response struct {
+		Entries []map[string]interface{} `json:"entries"`
+		Count   int                      `json:"count"`
+	}
+	if err := json.Unmarshal([]byte(result), &response); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if response.Count != 2 || len(response.Entries) != 2 {
+		t.Fatalf("Expected 2 get_os_info entries, got %v", response.Entries)
+	}
+	for _, entry := range response.Entries {
+		if entry["operation"] != "get_os_info" || entry["event_type"] != "operation" {
+			t.Errorf("Unexpected entry: %v", entry)
+		}
+	}
+
+	result, err = toolQueryAuditLog(map[string]interface{}{"success": false})
+	if err != nil {
+		t.Fatalf("toolQueryAuditLog(success=false) error = %v", err)
+	}
+	if err := json.Unmarshal([]byte(result), &response); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if response.Count != 1 || response.Entries[0]["command"] != "git" {
+		t.Errorf("Expected only the failed check_command entry, got %v", response.Entries)
+	}
+}
+
 // TestWriteAuditEntry tests the writeAuditEntry function
 func TestWriteAuditEntry(t *testing.T) {
 	// Create a temporary audit file
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/code-aria/internal-mcp/internal/mcp"
+)
+
+// defaultCacheTTL is how long results of the cached operations are reused
+const defaultCacheTTL = 30 * time.Second
+
+// maxCacheEntries caps the number of cached results, as every distinct set of params is
+// cached separately
+const maxCacheEntries = 256
+
+var (
+	// cacheTTL is the lifetime of a cached result; 0 turns caching off
+	cacheTTL = defaultCacheTTL
+
+	cacheMutex   sync.Mutex
+	cacheEntries = map[string]cacheEntry{}
+)
+
+// cacheEntry is the JSON result of one operation call and when it expires
+type cacheEntry struct {
+	result  string
+	expires time.Time
+}
+
+// InitCache reads the cache TTL from MCP_SYSTEMINFO_CACHE_TTL, a duration such as 30s or
+// 2m, or a number of seconds. 0 turns caching off.
+func InitCache() error {
+	value := os.Getenv("MCP_SYSTEMINFO_CACHE_TTL")
+	if value == "" {
+		return nil
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			return fmt.Errorf("invalid MCP_SYSTEMINFO_CACHE_TTL %q: expected a duration such as 30s", value)
+		}
+		ttl = time.Duration(seconds) * time.Second
+	}
+	if ttl < 0 {
+		return fmt.Errorf("invalid MCP_SYSTEMINFO_CACHE_TTL %q: must not be negative", value)
+	}
+	cacheTTL = ttl
+	return nil
+}
+
+// cachedOperation wraps an operation so that calls with the same params within cacheTTL
+// return the first call's result instead of gathering it again. force_refresh: true
+// bypasses the cache and stores the fresh result. Errors are never cached.
+func cachedOperation(operation string, handler mcp.OperationHandler) mcp.OperationHandler {
+	return func(args map[string]interface{}) (string, error) {
+		forceRefresh, _ := args["force_refresh"].(bool)
+		key, err := cacheKey(operation, args)
+		if err != nil || cacheTTL <= 0 {
+			return handler(args)
+		}
+
+		if !forceRefresh {
+			cacheMutex.Lock()
+			entry, ok := cacheEntries[key]
+			cacheMutex.Unlock()
+			if ok && time.Now().Before(entry.expires) {
+				auditLogEntry(AuditLog{
+					Timestamp: time.Now().UTC(),
+					Operation: operation,
+					Success:   true,
+					CacheHit:  true,
+				})
+				return entry.result, nil
+			}
+		}
+
+		result, err := handler(args)
+		if err != nil {
+			return "", err
+		}
+
+		cacheMutex.Lock()
+		storeCacheEntry(key, cacheEntry{result: result, expires: time.Now().Add(cacheTTL)})
+		cacheMutex.Unlock()
+		return result, nil
+	}
+}
+
+// storeCacheEntry adds entry under key, first dropping expired entries and, when the cache
+// is still full, the entry closest to expiring. cacheMutex must be held.
+func storeCacheEntry(key string, entry cacheEntry) {
+	if _, ok := cacheEntries[key]; !ok && len(cacheEntries) >= maxCacheEntries {
+		now := time.Now()
+		for k, e := range cacheEntries {
+			if !now.Before(e.expires) {
+				delete(cacheEntries, k)
+			}
+		}
+		if len(cacheEntries) >= maxCacheEntries {
+			oldestKey := ""
+			var oldest time.Time
+			for k, e := range cacheEntries {
+				if oldestKey == "" || e.expires.Before(oldest) {
+					oldestKey, oldest = k, e.expires
+				}
+			}
+			delete(cacheEntries, oldestKey)
+		}
+	}
+	cacheEntries[key] = entry
+}
+
+// cacheKey identifies an operation call by its name and params, leaving out force_refresh
+func cacheKey(operation string, args map[string]interface{}) (string, error) {
+	params := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		if k != "force_refresh" {
+			params[k] = v
+		}
+	}
+	// encoding/json sorts map keys, so equal params give equal keys
+	data, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	return operation + ":" + string(data), nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingOperation returns an operation handler that counts how often it gathers
+func countingOperation(calls *int32) func(map[string]interface{}) (string, error) {
+	return func(args map[string]interface{}) (string, error) {
+		n := atomic.AddInt32(calls, 1)
+		return strings.Repeat("x", int(n)), nil
+	}
+}
+
+// resetCache empties the cache and restores the TTL when the test ends
+func resetCache(t *testing.T, ttl time.Duration) {
+	t.Helper()
+	origTTL := cacheTTL
+	cacheTTL = ttl
+	cacheMutex.Lock()
+	cacheEntries = map[string]cacheEntry{}
+	cacheMutex.Unlock()
+	t.Cleanup(func() {
+		cacheTTL = origTTL
+		cacheMutex.Lock()
+		cacheEntries = map[string]cacheEntry{}
+		cacheMutex.Unlock()
+	})
+}
+
+// TestCachedOperation tests that rapid calls gather once and force_refresh gathers again
+func TestCachedOperation(t *testing.T) {
+	origAuditEnabled := auditEnabled
+	auditEnabled = false
+	defer func() { auditEnabled = origAuditEnabled }()
+	resetCache(t, time.Minute)
+
+	var calls int32
+	op := cachedOperation("get_test_info", countingOperation(&calls))
+
+	first, _ := op(map[string]interface{}{})
+	second, _ := op(map[string]interface{}{})
+	if calls != 1 || first != second {
+		t.Fatalf("Expected two rapid calls to gather once, got %d gathers", calls)
+	}
+
+	refreshed, _ := op(map[string]interface{}{"force_refresh": true})
+	if calls != 2 || refreshed == first {
+		t.Errorf("Expected force_refresh to gather again, got %d gathers", calls)
+	}
+	if again, _ := op(map[string]interface{}{}); again != refreshed {
+		t.Errorf("Expected the refreshed result to be cached, got %q", again)
+	}
+
+	// Different params are cached separately
+	op(map[string]interface{}{"timeout_seconds": float64(5)})
+	if calls != 3 {
+		t.Errorf("Expected different params to gather, got %d gathers", calls)
+	}
+}
+
+// TestCachedOperationEviction tests that the cache stays within maxCacheEntries
+func TestCachedOperationEviction(t *testing.T) {
+	origAuditEnabled := auditEnabled
+	auditEnabled = false
+	defer func() { auditEnabled = origAuditEnabled }()
+	resetCache(t, time.Minute)
+
+	var calls int32
+	op := cachedOperation("get_test_info", countingOperation(&calls))
+	for i := 0; i < maxCacheEntries+10; i++ {
+		op(map[string]interface{}{"timeout_seconds": float64(i)})
+	}
+
+	cacheMutex.Lock()
+	size := len(cacheEntries)
+	cacheMutex.Unlock()
+	if size != maxCacheEntries {
+		t.Errorf("Expected the cache to hold %d entries, got %d", maxCacheEntries, size)
+	}
+
+	// The most recent result is kept and the first ones were evicted
+	last := calls
+	op(map[string]interface{}{"timeout_seconds": float64(maxCacheEntries + 9)})
+	if calls != last {
+		t.Error("Expected the most recent result to still be cached")
+	}
+	op(map[string]interface{}{"timeout_seconds": float64(0)})
+	if calls != last+1 {
+		t.Error("Expected the oldest result to have been evicted")
+	}
+}
+
+// TestCachedOperationConcurrent tests the cache under concurrent calls
+func TestCachedOperationConcurrent(t *testing.T) {
+	origAuditEnabled := auditEnabled
+	auditEnabled = false
+	defer func() { auditEnabled = origAuditEnabled }()
+	resetCache(t, time.Minute)
+
+	var calls int32
+	op := cachedOperation("get_test_info", countingOperation(&calls))
+	op(map[string]interface{}{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			op(map[string]interface{}{})
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("Expected concurrent calls to reuse the cached result, got %d gathers", calls)
+	}
+}
+
+// TestCachedOperationExpiry tests that results expire after the TTL and that 0 disables caching
+func TestCachedOperationExpiry(t *testing.T) {
+	origAuditEnabled := auditEnabled
+	auditEnabled = false
+	defer func() { auditEnabled = origAuditEnabled }()
+	resetCache(t, 20*time.Millisecond)
+
+	var calls int32
+	op := cachedOperation("get_test_info", countingOperation(&calls))
+	op(map[string]interface{}{})
+	time.Sleep(30 * time.Millisecond)
+	op(map[string]interface{}{})
+	if calls != 2 {
+		t.Errorf("Expected an expired result to be gathered again, got %d gathers", calls)
+	}
+
+	cacheTTL = 0
+	op(map[string]interface{}{})
+	op(map[string]interface{}{})
+	if calls != 4 {
+		t.Errorf("Expected a TTL of 0 to disable caching, got %d gathers", calls)
+	}
+}
+
+// TestCachedOperationAuditsHits tests that cache hits are audit-logged with cache_hit
+func TestCachedOperationAuditsHits(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "test-audit.log")
+	os.Setenv("MCP_SYSTEMINFO_AUDIT_FILE", tempFile)
+	defer os.Unsetenv("MCP_SYSTEMINFO_AUDIT_FILE")
+
+	if err := InitAuditLogger(); err != nil {
+		t.Fatalf("InitAuditLogger() failed: %v", err)
+	}
+	resetCache(t, time.Minute)
+
+	var calls int32
+	op := cachedOperation("get_test_info", countingOperation(&calls))
+	op(map[string]interface{}{})
+	op(map[string]interface{}{})
+	CloseAuditLogger()
+
+	data, err := os.ReadFile(tempFile)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if strings.Count(string(data), `"cache_hit":true`) != 1 {
+		t.Errorf("Expected one cache_hit entry, got:\n%s", data)
+	}
+}
+
+// TestInitCache tests reading the TTL from MCP_SYSTEMINFO_CACHE_TTL
+func TestInitCache(t *testing.T) {
+	origTTL := cacheTTL
+	defer func() { cacheTTL = origTTL }()
+
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"2m", 2 * time.Minute, false},
+		{"45", 45 * time.Second, false},
+		{"0", 0, false},
+		{"soon", 0, true},
+		{"-5s", 0, true},
+	}
+	for _, tt := range tests {
+		cacheTTL = defaultCacheTTL
+		t.Setenv("MCP_SYSTEMINFO_CACHE_TTL", tt.value)
+		err := InitCache()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("InitCache(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && cacheTTL != tt.want {
+			t.Errorf("InitCache(%q) TTL = %v, want %v", tt.value, cacheTTL, tt.want)
+		}
+		if tt.wantErr && cacheTTL != defaultCacheTTL {
+			t.Errorf("InitCache(%q) changed the TTL to %v on error", tt.value, cacheTTL)
+		}
+	}
+}
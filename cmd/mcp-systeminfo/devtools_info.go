@@ -4,45 +4,46 @@ import (
 	"context"
 	"os"
 	"os/exec"
+	"regexp"
 	"strings"
 	"time"
 )
 
 // getDevelopmentToolsInfo gathers development tools information
-func getDevelopmentToolsInfo() (*DevelopmentToolsInfo, error) {
+func getDevelopmentToolsInfo(timeout int) (*DevelopmentToolsInfo, error) {
 	devToolsInfo := &DevelopmentToolsInfo{}
 
 	// Check various development tools
-	devToolsInfo.Go = getToolInfo("go")
-	devToolsInfo.Node = getToolInfo("node")
-	devToolsInfo.Python = getToolInfo("python")
-	devToolsInfo.Python3 = getToolInfo("python3")
-	devToolsInfo.Ruby = getToolInfo("ruby")
-	devToolsInfo.Java = getToolInfo("java")
-	devToolsInfo.Git = getToolInfo("git")
-	devToolsInfo.Docker = getToolInfo("docker")
-	devToolsInfo.PowerShell = getToolInfo("pwsh")
+	devToolsInfo.Go = getToolInfo("go", timeout)
+	devToolsInfo.Node = getToolInfo("node", timeout)
+	devToolsInfo.Python = getToolInfo("python", timeout)
+	devToolsInfo.Python3 = getToolInfo("python3", timeout)
+	devToolsInfo.Ruby = getToolInfo("ruby", timeout)
+	devToolsInfo.Java = getToolInfo("java", timeout)
+	devToolsInfo.Git = getToolInfo("git", timeout)
+	devToolsInfo.Docker = getToolInfo("docker", timeout)
+	devToolsInfo.PowerShell = getToolInfo("pwsh", timeout)
 	if devToolsInfo.PowerShell == nil || !devToolsInfo.PowerShell.Installed {
-		devToolsInfo.PowerShell = getToolInfo("powershell")
+		devToolsInfo.PowerShell = getToolInfo("powershell", timeout)
 	}
-	devToolsInfo.CMake = getToolInfo("cmake")
-	devToolsInfo.Maven = getToolInfo("mvn")
-	devToolsInfo.Gradle = getToolInfo("gradle")
-	devToolsInfo.Make = getToolInfo("make")
-	devToolsInfo.Cargo = getToolInfo("cargo")
-	devToolsInfo.Rustc = getToolInfo("rustc")
-	devToolsInfo.GCC = getToolInfo("gcc")
-	devToolsInfo.Clang = getToolInfo("clang")
-	devToolsInfo.Dotnet = getToolInfo("dotnet")
+	devToolsInfo.CMake = getToolInfo("cmake", timeout)
+	devToolsInfo.Maven = getToolInfo("mvn", timeout)
+	devToolsInfo.Gradle = getToolInfo("gradle", timeout)
+	devToolsInfo.Make = getToolInfo("make", timeout)
+	devToolsInfo.Cargo = getToolInfo("cargo", timeout)
+	devToolsInfo.Rustc = getToolInfo("rustc", timeout)
+	devToolsInfo.GCC = getToolInfo("gcc", timeout)
+	devToolsInfo.Clang = getToolInfo("clang", timeout)
+	devToolsInfo.Dotnet = getToolInfo("dotnet", timeout)
 
 	// Get package managers
-	devToolsInfo.PackageMgrs = getPackageManagers()
+	devToolsInfo.PackageMgrs = getPackageManagers(timeout)
 
 	return devToolsInfo, nil
 }
 
 // getToolInfo checks if a tool is installed and gets its information
-func getToolInfo(toolName string) *ToolInfo {
+func getToolInfo(toolName string, timeout int) *ToolInfo {
 	toolInfo := &ToolInfo{
 		Executable: toolName,
 		Installed:  false,
@@ -58,7 +59,7 @@ func getToolInfo(toolName string) *ToolInfo {
 	toolInfo.Path = path
 
 	// Get version information
-	version := getToolVersion(toolName, path)
+	version := getToolVersionWithTimeout(toolName, path, probeTimeout(timeout, 3*time.Second))
 	toolInfo.Version = version
 
 	// Get tool-specific features
@@ -68,14 +69,20 @@ func getToolInfo(toolName string) *ToolInfo {
 	return toolInfo
 }
 
-// getToolVersion gets version information for a specific tool
-func getToolVersion(toolName, path string) string {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+// getToolVersionWithTimeout gets version information for a specific tool, running the binary
+// at path when given so the version matches the resolved executable
+func getToolVersionWithTimeout(toolName, path string, timeout time.Duration) string {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
+	bin := toolName
+	if path != "" {
+		bin = path
+	}
+
 	switch toolName {
 	case "go":
-		cmd := exec.CommandContext(ctx, "go", "version")
+		cmd := exec.CommandContext(ctx, bin, "version")
 		var stdout strings.Builder
 		cmd.Stdout = &stdout
 		if err := cmd.Run(); err == nil {
@@ -89,7 +96,7 @@ func getToolVersion(toolName, path string) string {
 		}
 
 	case "node":
-		cmd := exec.CommandContext(ctx, "node", "--version")
+		cmd := exec.CommandContext(ctx, bin, "--version")
 		var stdout strings.Builder
 		cmd.Stdout = &stdout
 		if err := cmd.Run(); err == nil {
@@ -97,15 +104,17 @@ func getToolVersion(toolName, path string) string {
 		}
 
 	case "python", "python3":
-		cmd := exec.CommandContext(ctx, toolName, "--version")
-		var stderr strings.Builder
-		cmd.Stderr = &stderr
+		// Python 2 prints its version to stderr, Python 3.4+ to stdout
+		cmd := exec.CommandContext(ctx, bin, "--version")
+		var output strings.Builder
+		cmd.Stdout = &output
+		cmd.Stderr = &output
 		if err := cmd.Run(); err == nil {
-			return strings.TrimSpace(stderr.String())
+			return strings.TrimSpace(output.String())
 		}
 
 	case "git":
-		cmd := exec.CommandContext(ctx, "git", "--version")
+		cmd := exec.CommandContext(ctx, bin, "--version")
 		var stdout strings.Builder
 		cmd.Stdout = &stdout
 		if err := cmd.Run(); err == nil {
@@ -119,7 +128,7 @@ func getToolVersion(toolName, path string) string {
 		}
 
 	case "docker":
-		cmd := exec.CommandContext(ctx, "docker", "--version")
+		cmd := exec.CommandContext(ctx, bin, "--version")
 		var stdout strings.Builder
 		cmd.Stdout = &stdout
 		if err := cmd.Run(); err == nil {
@@ -127,7 +136,7 @@ func getToolVersion(toolName, path string) string {
 		}
 
 	case "cargo":
-		cmd := exec.CommandContext(ctx, "cargo", "--version")
+		cmd := exec.CommandContext(ctx, bin, "--version")
 		var stdout strings.Builder
 		cmd.Stdout = &stdout
 		if err := cmd.Run(); err == nil {
@@ -135,7 +144,7 @@ func getToolVersion(toolName, path string) string {
 		}
 
 	case "rustc":
-		cmd := exec.CommandContext(ctx, "rustc", "--version")
+		cmd := exec.CommandContext(ctx, bin, "--version")
 		var stdout strings.Builder
 		cmd.Stdout = &stdout
 		if err := cmd.Run(); err == nil {
@@ -143,7 +152,7 @@ func getToolVersion(toolName, path string) string {
 		}
 
 	case "gcc":
-		cmd := exec.CommandContext(ctx, "gcc", "--version")
+		cmd := exec.CommandContext(ctx, bin, "--version")
 		var stdout strings.Builder
 		cmd.Stdout = &stdout
 		if err := cmd.Run(); err == nil {
@@ -155,7 +164,7 @@ func getToolVersion(toolName, path string) string {
 		}
 
 	case "clang":
-		cmd := exec.CommandContext(ctx, "clang", "--version")
+		cmd := exec.CommandContext(ctx, bin, "--version")
 		var stdout strings.Builder
 		cmd.Stdout = &stdout
 		if err := cmd.Run(); err == nil {
@@ -167,7 +176,7 @@ func getToolVersion(toolName, path string) string {
 		}
 
 	case "dotnet":
-		cmd := exec.CommandContext(ctx, "dotnet", "--version")
+		cmd := exec.CommandContext(ctx, bin, "--version")
 		var stdout strings.Builder
 		cmd.Stdout = &stdout
 		if err := cmd.Run(); err == nil {
@@ -175,7 +184,7 @@ func getToolVersion(toolName, path string) string {
 		}
 
 	case "java":
-		cmd := exec.CommandContext(ctx, "java", "-version")
+		cmd := exec.CommandContext(ctx, bin, "-version")
 		var stderr strings.Builder
 		cmd.Stderr = &stderr
 		if err := cmd.Run(); err == nil {
@@ -186,7 +195,7 @@ func getToolVersion(toolName, path string) string {
 		}
 
 	case "cmake":
-		cmd := exec.CommandContext(ctx, "cmake", "--version")
+		cmd := exec.CommandContext(ctx, bin, "--version")
 		var stdout strings.Builder
 		cmd.Stdout = &stdout
 		if err := cmd.Run(); err == nil {
@@ -198,7 +207,7 @@ func getToolVersion(toolName, path string) string {
 		}
 
 	case "make":
-		cmd := exec.CommandContext(ctx, "make", "--version")
+		cmd := exec.CommandContext(ctx, bin, "--version")
 		var stdout strings.Builder
 		cmd.Stdout = &stdout
 		if err := cmd.Run(); err == nil {
@@ -210,7 +219,7 @@ func getToolVersion(toolName, path string) string {
 		}
 
 	case "powershell", "pwsh":
-		cmd := exec.CommandContext(ctx, toolName, "-NoProfile", "-Command", "$PSVersionTable.PSVersion")
+		cmd := exec.CommandContext(ctx, bin, "-NoProfile", "-Command", "$PSVersionTable.PSVersion")
 		var stdout strings.Builder
 		cmd.Stdout = &stdout
 		if err := cmd.Run(); err == nil {
@@ -218,7 +227,7 @@ func getToolVersion(toolName, path string) string {
 		}
 
 	case "mvn":
-		cmd := exec.CommandContext(ctx, "mvn", "--version")
+		cmd := exec.CommandContext(ctx, bin, "--version")
 		var stdout strings.Builder
 		cmd.Stdout = &stdout
 		if err := cmd.Run(); err == nil {
@@ -230,7 +239,7 @@ func getToolVersion(toolName, path string) string {
 		}
 
 	case "gradle":
-		cmd := exec.CommandContext(ctx, "gradle", "--version")
+		cmd := exec.CommandContext(ctx, bin, "--version")
 		var stdout strings.Builder
 		cmd.Stdout = &stdout
 		if err := cmd.Run(); err == nil {
@@ -249,7 +258,7 @@ func getToolVersion(toolName, path string) string {
 		// Try common version flags
 		versionFlags := []string{"--version", "-V", "-v", "version", "ver"}
 		for _, flag := range versionFlags {
-			cmd := exec.CommandContext(ctx, toolName, flag)
+			cmd := exec.CommandContext(ctx, bin, flag)
 			var stdout strings.Builder
 			cmd.Stdout = &stdout
 			if err := cmd.Run(); err == nil {
@@ -303,7 +312,7 @@ func getToolFeatures(toolName string) []string {
 }
 
 // getPackageManagers returns information about available package managers
-func getPackageManagers() []PackageMgrInfo {
+func getPackageManagers(timeout int) []PackageMgrInfo {
 	var packageManagers []PackageMgrInfo
 
 	// Check for various package managers
@@ -323,12 +332,12 @@ func getPackageManagers() []PackageMgrInfo {
 	}
 
 	for tool, pkgType := range pkgManagerTools {
-		if toolInfo := getToolInfo(tool); toolInfo.Installed {
+		if toolInfo := getToolInfo(tool, timeout); toolInfo.Installed {
 			version := toolInfo.Version
 			if version == "" {
 				// Try to get version in a different way
 				if tool == "apt" || tool == "apt-get" {
-					version = getAptVersion()
+					version = getAptVersion(timeout)
 				}
 			}
 
@@ -344,8 +353,8 @@ func getPackageManagers() []PackageMgrInfo {
 }
 
 // getAptVersion gets APT version specifically
-func getAptVersion() string {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+func getAptVersion(timeout int) string {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(timeout, 3*time.Second))
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, "apt", "--version")
@@ -361,8 +370,28 @@ func getAptVersion() string {
 	return ""
 }
 
-// checkCommandExists checks if a command exists in PATH or specified paths
-func checkCommandExists(command string, searchPaths []string) *CommandExistsResult {
+// versionedCommands are the commands check_command will execute to report a version
+var versionedCommands = map[string]bool{
+	"go": true, "node": true, "python": true, "python3": true, "git": true, "docker": true,
+}
+
+var versionNumberPattern = regexp.MustCompile(`\d+\.\d+(\.\d+)?`)
+
+// parseVersionNumber extracts the first dotted version number from version output,
+// e.g. "go1.24.1" -> "1.24.1", falling back to the trimmed output
+func parseVersionNumber(output string) string {
+	output = strings.TrimSpace(output)
+	if match := versionNumberPattern.FindString(output); match != "" {
+		return match
+	}
+	return output
+}
+
+// checkCommandExists checks if a command exists in PATH or specified paths. The version is
+// only looked up when includeVersion is set and the command is in versionedCommands, and only
+// from the binary exec.LookPath finds in PATH: a match in searchPaths is reported but never
+// executed, so arbitrary binaries found on disk are never run.
+func checkCommandExists(command string, searchPaths []string, includeVersion bool, timeout int) *CommandExistsResult {
 	result := &CommandExistsResult{
 		Exists:  false,
 		Command: command,
@@ -378,26 +407,41 @@ func checkCommandExists(command string, searchPaths []string) *CommandExistsResu
 			if _, err := os.Stat(cmdPath); err == nil {
 				result.Exists = true
 				result.Path = cmdPath
-				// Try to get version
-				if version := getToolVersion(command, cmdPath); version != "" {
-					result.Version = version
-				}
-				return result
+				break
 			}
 		}
 	}
 
 	// Check in PATH
-	if path, err := exec.LookPath(command); err == nil {
-		result.Exists = true
-		result.Path = path
-		// Try to get version
-		if version := getToolVersion(command, path); version != "" {
-			result.Version = version
+	if !result.Exists {
+		if path, err := exec.LookPath(command); err == nil {
+			result.Exists = true
+			result.Path = path
+		} else {
+			result.Error = err.Error()
+		}
+	}
+
+	if result.Exists && includeVersion {
+		if pathBin, err := exec.LookPath(command); err == nil {
+			result.Version = lookupCommandVersion(command, pathBin, timeout)
 		}
-	} else {
-		result.Error = err.Error()
 	}
 
 	return result
-}
\ No newline at end of file
+}
+
+// lookupCommandVersion runs the version flag of a known command under the security policy.
+// path must come from exec.LookPath.
+func lookupCommandVersion(command, path string, timeout int) string {
+	if !versionedCommands[command] {
+		return ""
+	}
+	if security := validateCommand(command+" --version", false); !security.Valid {
+		return ""
+	}
+	if output := getToolVersionWithTimeout(command, path, probeTimeout(timeout, 3*time.Second)); output != "" {
+		return parseVersionNumber(output)
+	}
+	return ""
+}
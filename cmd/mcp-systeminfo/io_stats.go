@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Linux I/O counter files
+const (
+	procDiskstatsPath = "/proc/diskstats"
+	procNetDevPath    = "/proc/net/dev"
+)
+
+// diskSectorBytes is the unit of the sector counts in /proc/diskstats, regardless of the
+// device's physical sector size
+const diskSectorBytes = 512
+
+// maxIOSampleIntervalMs caps how long get_io_stats waits between its two samples
+const maxIOSampleIntervalMs = 5000
+
+// toolGetIOStats returns disk and network I/O counters. With interval_ms it samples twice
+// that far apart and adds per-second rates.
+func toolGetIOStats(args map[string]interface{}) (string, error) {
+	intervalMs := 0
+	if interval, ok := args["interval_ms"].(float64); ok {
+		intervalMs = int(interval)
+		if intervalMs > maxIOSampleIntervalMs {
+			intervalMs = maxIOSampleIntervalMs
+		}
+		if intervalMs < 0 {
+			intervalMs = 0
+		}
+	}
+
+	startTime := time.Now()
+	result := getIOStats(procDiskstatsPath, procNetDevPath, time.Duration(intervalMs)*time.Millisecond)
+	duration := time.Since(startTime).Milliseconds()
+
+	// Audit logging (read-only operation)
+	auditLog("get_io_stats", "", "", "", nil, nil, duration, true, 0, "")
+
+	// Return JSON result
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal I/O stats: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// getIOStats reads the counters in diskstatsPath and netDevPath. Only Linux is supported;
+// other platforms get an unsupported result rather than an error. A positive interval
+// takes a second sample and fills in the rates.
+func getIOStats(diskstatsPath, netDevPath string, interval time.Duration) *IOStatsResult {
+	result := &IOStatsResult{Disks: []DiskIOStats{}, Interfaces: []NetworkIOStats{}}
+
+	if runtime.GOOS != "linux" {
+		result.Message = fmt.Sprintf("I/O statistics are not supported on %s", runtime.GOOS)
+		return result
+	}
+
+	disks, diskErr := readDiskStats(diskstatsPath)
+	interfaces, netErr := readNetDevStats(netDevPath)
+	if diskErr != nil && netErr != nil {
+		result.Message = fmt.Sprintf("failed to read I/O counters: %v; %v", diskErr, netErr)
+		return result
+	}
+	result.Supported = true
+
+	var messages []string
+	if diskErr != nil {
+		messages = append(messages, diskErr.Error())
+	}
+	if netErr != nil {
+		messages = append(messages, netErr.Error())
+	}
+	result.Message = strings.Join(messages, "; ")
+
+	if interval > 0 {
+		start := time.Now()
+		time.Sleep(interval)
+		seconds := time.Since(start).Seconds()
+
+		if later, err := readDiskStats(diskstatsPath); err == nil {
+			addDiskRates(later, disks, seconds)
+			disks = later
+		}
+		if later, err := readNetDevStats(netDevPath); err == nil {
+			addNetworkRates(later, interfaces, seconds)
+			interfaces = later
+		}
+		result.IntervalMs = int(interval.Milliseconds())
+	}
+
+	if disks != nil {
+		result.Disks = disks
+	}
+	if interfaces != nil {
+		result.Interfaces = interfaces
+	}
+	return result
+}
+
+// readDiskStats reads per-device counters from a /proc/diskstats file
+func readDiskStats(path string) ([]DiskIOStats, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return parseDiskStats(string(data)), nil
+}
+
+// parseDiskStats parses /proc/diskstats, whose lines are "major minor name" followed by
+// reads completed, reads merged, sectors read, ms reading, writes completed, writes merged
+// and sectors written. Loop and RAM devices are skipped.
+func parseDiskStats(content string) []DiskIOStats {
+	disks := []DiskIOStats{}
+
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 10 {
+			continue
+		}
+		name := fields[2]
+		if strings.HasPrefix(name, "loop") || strings.HasPrefix(name, "ram") {
+			continue
+		}
+
+		counters, ok := parseCounters(fields[3:10])
+		if !ok {
+			continue
+		}
+		disks = append(disks, DiskIOStats{
+			Device:          name,
+			ReadsCompleted:  counters[0],
+			ReadBytes:       counters[2] * diskSectorBytes,
+			WritesCompleted: counters[4],
+			WriteBytes:      counters[6] * diskSectorBytes,
+		})
+	}
+
+	return disks
+}
+
+// readNetDevStats reads per-interface counters from a /proc/net/dev file
+func readNetDevStats(path string) ([]NetworkIOStats, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return parseNetDev(string(data)), nil
+}
+
+// parseNetDev parses /proc/net/dev: two header lines, then "iface: " followed by eight
+// receive counters (bytes first, packets second) and eight transmit counters
+func parseNetDev(content string) []NetworkIOStats {
+	interfaces := []NetworkIOStats{}
+
+	for _, line := range strings.Split(content, "\n") {
+		name, rest, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) < 10 {
+			continue
+		}
+
+		counters, ok := parseCounters(fields[:10])
+		if !ok {
+			continue
+		}
+		interfaces = append(interfaces, NetworkIOStats{
+			Interface: strings.TrimSpace(name),
+			RxBytes:   counters[0],
+			RxPackets: counters[1],
+			TxBytes:   counters[8],
+			TxPackets: counters[9],
+		})
+	}
+
+	return interfaces
+}
+
+// parseCounters parses unsigned counter fields, reporting false if any is malformed
+func parseCounters(fields []string) ([]uint64, bool) {
+	counters := make([]uint64, len(fields))
+	for i, field := range fields {
+		n, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		counters[i] = n
+	}
+	return counters, true
+}
+
+// addDiskRates sets the rates of the later sample from the counters of the earlier one.
+// Devices that appeared between the samples get no rate.
+func addDiskRates(later, earlier []DiskIOStats, seconds float64) {
+	previous := make(map[string]DiskIOStats, len(earlier))
+	for _, disk := range earlier {
+		previous[disk.Device] = disk
+	}
+	for i := range later {
+		prev, ok := previous[later[i].Device]
+		if !ok {
+			continue
+		}
+		later[i].ReadBytesPerSec = counterRate(prev.ReadBytes, later[i].ReadBytes, seconds)
+		later[i].WriteBytesPerSec = counterRate(prev.WriteBytes, later[i].WriteBytes, seconds)
+	}
+}
+
+// addNetworkRates sets the rates of the later sample from the counters of the earlier one.
+// Interfaces that appeared between the samples get no rate.
+func addNetworkRates(later, earlier []NetworkIOStats, seconds float64) {
+	previous := make(map[string]NetworkIOStats, len(earlier))
+	for _, iface := range earlier {
+		previous[iface.Interface] = iface
+	}
+	for i := range later {
+		prev, ok := previous[later[i].Interface]
+		if !ok {
+			continue
+		}
+		later[i].RxBytesPerSec = counterRate(prev.RxBytes, later[i].RxBytes, seconds)
+		later[i].TxBytesPerSec = counterRate(prev.TxBytes, later[i].TxBytes, seconds)
+	}
+}
+
+// counterRate returns the per-second change of a counter. A counter that went backwards
+// was reset or wrapped, so its rate is unknown.
+func counterRate(before, after uint64, seconds float64) *float64 {
+	if after < before || seconds <= 0 {
+		return nil
+	}
+	rate := float64(after-before) / seconds
+	return &rate
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+const testDiskstats = `   7       0 loop0 120 0 2400 10 0 0 0 0 0 20 10 0 0 0 0 0 0
+   8       0 sda 1000 50 20000 300 400 20 8000 900 0 1100 1200 0 0 0 0 0 0
+   8       1 sda1 900 50 18000 280 400 20 8000 900 0 1000 1180 0 0 0 0 0 0
+ 259       0 nvme0n1 bogus 0 0 0 0 0 0 0 0 0 0
+`
+
+const testNetDev = `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo:    5000      50    0    0    0     0          0         0     5000      50    0    0    0     0       0          0
+  eth0: 1234567    1000    0    0    0     0          0         0   654321     800    0    0    0     0       0          0
+`
+
+// TestToolGetIOStats tests that get_io_stats returns a well-formed response on any platform
+func TestToolGetIOStats(t *testing.T) {
+	origAuditEnabled := auditEnabled
+	auditEnabled = false
+	defer func() { auditEnabled = origAuditEnabled }()
+
+	result, err := toolGetIOStats(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("toolGetIOStats() error = %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+
+	supported, ok := decoded["supported"].(bool)
+	if !ok {
+		t.Fatalf("Expected boolean supported field, got %v", decoded["supported"])
+	}
+	if _, ok := decoded["disks"].([]interface{}); !ok {
+		t.Errorf("Expected disks array, got %v", decoded["disks"])
+	}
+	if _, ok := decoded["interfaces"].([]interface{}); !ok {
+		t.Errorf("Expected interfaces array, got %v", decoded["interfaces"])
+	}
+	if runtime.GOOS != "linux" && (supported || decoded["message"] == nil) {
+		t.Errorf("Expected an unsupported result with a message on %s, got %v", runtime.GOOS, decoded)
+	}
+}
+
+// TestParseDiskStats tests parsing /proc/diskstats lines into byte counters
+func TestParseDiskStats(t *testing.T) {
+	disks := parseDiskStats(testDiskstats)
+	if len(disks) != 2 {
+		t.Fatalf("Expected sda and sda1 (loop and malformed lines skipped), got %+v", disks)
+	}
+	want := DiskIOStats{Device: "sda", ReadsCompleted: 1000, ReadBytes: 20000 * 512, WritesCompleted: 400, WriteBytes: 8000 * 512}
+	if disks[0] != want {
+		t.Errorf("sda = %+v, want %+v", disks[0], want)
+	}
+}
+
+// TestParseNetDev tests parsing /proc/net/dev into receive and transmit counters
+func TestParseNetDev(t *testing.T) {
+	interfaces := parseNetDev(testNetDev)
+	if len(interfaces) != 2 {
+		t.Fatalf("Expected lo and eth0, got %+v", interfaces)
+	}
+	want := NetworkIOStats{Interface: "eth0", RxBytes: 1234567, RxPackets: 1000, TxBytes: 654321, TxPackets: 800}
+	if interfaces[1] != want {
+		t.Errorf("eth0 = %+v, want %+v", interfaces[1], want)
+	}
+}
+
+// TestGetIOStatsRates tests that a second sample adds per-second rates
+func TestGetIOStatsRates(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("I/O statistics are only read on Linux")
+	}
+
+	dir := t.TempDir()
+	diskstats := filepath.Join(dir, "diskstats")
+	netDev := filepath.Join(dir, "dev")
+	if err := os.WriteFile(diskstats, []byte(testDiskstats), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(netDev, []byte(testNetDev), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result := getIOStats(diskstats, netDev, 20*time.Millisecond)
+	if !result.Supported || result.IntervalMs != 20 {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+	// The counters did not change between the samples
+	if rate := result.Disks[0].ReadBytesPerSec; rate == nil || *rate != 0 {
+		t.Errorf("Expected a zero read rate for sda, got %v", rate)
+	}
+	if rate := result.Interfaces[1].RxBytesPerSec; rate == nil || *rate != 0 {
+		t.Errorf("Expected a zero rx rate for eth0, got %v", rate)
+	}
+
+	missing := getIOStats(filepath.Join(dir, "missing"), netDev, 0)
+	if !missing.Supported || len(missing.Interfaces) != 2 || missing.Message == "" {
+		t.Errorf("Expected network counters and a message when diskstats is missing, got %+v", missing)
+	}
+}
+
+// TestCounterRate tests rate calculation, including counters that reset
+func TestCounterRate(t *testing.T) {
+	if rate := counterRate(1000, 3000, 2); rate == nil || *rate != 1000 {
+		t.Errorf("counterRate(1000, 3000, 2) = %v, want 1000", rate)
+	}
+	if rate := counterRate(3000, 1000, 2); rate != nil {
+		t.Errorf("Expected no rate for a reset counter, got %v", *rate)
+	}
+}
@@ -187,6 +187,47 @@ func TestMainWithMultipleRequests(t *testing.T) {
 	}
 }
 
+// TestPingAfterHandshake tests that ping gets a prompt empty result with the same id
+func TestPingAfterHandshake(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{}}}
+{"jsonrpc":"2.0","method":"notifications/initialized"}
+{"jsonrpc":"2.0","id":"health-1","method":"ping"}
+`
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	var output bytes.Buffer
+	encoder := json.NewEncoder(&output)
+
+	if err := handleInitialize(scanner, encoder); err != nil {
+		t.Fatalf("handleInitialize failed: %v", err)
+	}
+	output.Reset()
+
+	if !scanner.Scan() {
+		t.Fatal("Expected ping request")
+	}
+	var msg MCPMessage
+	if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+		t.Fatalf("Failed to parse ping request: %v", err)
+	}
+
+	start := time.Now()
+	handleRequest(&msg, encoder)
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("ping took %v, expected an immediate response", elapsed)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(output.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse ping response: %v", err)
+	}
+	if response["id"] != "health-1" || response["error"] != nil {
+		t.Errorf("Unexpected ping response: %v", response)
+	}
+	if result, ok := response["result"].(map[string]interface{}); !ok || len(result) != 0 {
+		t.Errorf("Expected empty result, got %v", response["result"])
+	}
+}
+
 // TestMainGracefulShutdown tests graceful shutdown behavior
 func TestMainGracefulShutdown(t *testing.T) {
 	// This test verifies that the audit logger is closed on shutdown
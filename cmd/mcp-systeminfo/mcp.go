@@ -4,58 +4,23 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+
+	"github.com/code-aria/internal-mcp/internal/mcp"
 )
 
 // handleInitialize processes the MCP initialize request
 func handleInitialize(scanner *bufio.Scanner, encoder *json.Encoder) error {
-	if !scanner.Scan() {
-		return fmt.Errorf("no initialize request")
-	}
-
-	var initReq MCPMessage
-	if err := json.Unmarshal(scanner.Bytes(), &initReq); err != nil {
-		return fmt.Errorf("failed to parse initialize: %w", err)
-	}
-
-	response := MCPMessage{
-		JSONRPC: "2.0",
-		ID:      initReq.ID,
-		Result: InitializeResponse{
-			ProtocolVersion: "2024-11-05",
-			Capabilities: map[string]interface{}{
-				"tools": map[string]interface{}{},
-			},
-			ServerInfo: ServerInfo{
-				Name:    "mcp-systeminfo",
-				Version: "1.0.0",
-			},
-		},
-	}
-
-	if err := encoder.Encode(response); err != nil {
-		return fmt.Errorf("failed to send initialize response: %w", err)
-	}
-
-	if !scanner.Scan() {
-		return fmt.Errorf("no initialized notification")
-	}
-
-	var initializedMsg MCPMessage
-	if err := json.Unmarshal(scanner.Bytes(), &initializedMsg); err != nil {
-		return fmt.Errorf("failed to parse initialized notification: %w", err)
-	}
-
-	// Validate that this is the initialized notification
-	if initializedMsg.Method != "notifications/initialized" {
-		return fmt.Errorf("expected initialized notification, got method: %s", initializedMsg.Method)
-	}
-
-	return nil
+	return mcp.ReadInitialize(scanner, encoder, ServerInfo{
+		Name:    "mcp-systeminfo",
+		Version: "1.0.0",
+	})
 }
 
 // handleRequest routes MCP requests to appropriate handlers
 func handleRequest(msg *MCPMessage, encoder *json.Encoder) {
 	switch msg.Method {
+	case "ping":
+		mcp.SendPing(encoder, msg.ID)
 	case "tools/list":
 		handleToolsList(msg, encoder)
 	case "tools/call":
@@ -83,7 +48,7 @@ func handleToolsList(msg *MCPMessage, encoder *json.Encoder) {
 							"properties": map[string]interface{}{
 								"type": map[string]interface{}{
 									"type":        "string",
-									"description": "Operation type: get_system_info, get_os_info, get_hardware_info, get_environment_info, get_shell_info, get_development_tools, get_network_info, detect_repositories, check_command, get_recommendations",
+									"description": "Operation type: get_system_info, get_os_info, get_hardware_info, get_environment_info, get_env_var, get_shell_info, get_development_tools, get_network_info, detect_repositories, check_command, check_commands, get_recommendations, get_process_list, get_sensors, get_io_stats, list_packages, detect_environment, get_security_policy, query_audit_log, get_path_analysis, get_time. get_system_info, get_os_info, get_hardware_info, get_shell_info, get_development_tools, get_network_info and detect_environment results are cached per operation and params for MCP_SYSTEMINFO_CACHE_TTL (default 30s); pass force_refresh: true to gather them again. check_commands takes commands (array of names, max 100) plus the search_paths, include_version and timeout_seconds of check_command, and returns a map of command name to its check_command result, e.g. {\"go\": {\"command\": \"go\", \"exists\": true, \"path\": \"/usr/local/go/bin/go\"}}; an invalid name fails the whole call. get_path_analysis takes no params and returns {entries: [{path, exists, is_dir, writable, duplicate, error}], count, missing} for each PATH entry in order; missing counts entries that are not existing directories, and nothing is written to check writability. get_time takes no params and returns {utc, local, timezone, zone_abbreviation, offset_seconds, unix_ms, monotonic_uptime}: RFC 3339 timestamps of the same instant, the IANA timezone name (or the zone abbreviation when none is configured), the local offset from UTC in seconds, and the server's uptime in seconds from the monotonic clock. get_recommendations returns {recommendations, categorized}: recommendations is the list of messages and categorized the same recommendations as [{category, message}], where category is platform (OS, container, VM and cloud advice), hardware, tools or general; pass categories (e.g. [\"hardware\", \"tools\"]) to return only those, an unknown category is an error, and without it every recommendation is returned. get_system_info lists the recommendation messages only. get_system_info, get_os_info, get_hardware_info, get_shell_info, get_development_tools, get_network_info, detect_repositories and get_recommendations accept timeout_seconds (clamped to 1-30), the limit for each command they run; without it each command keeps its own limit, 3s for version and network probes and 5s for the others",
 								},
 							},
 						},
@@ -127,144 +92,37 @@ func handleToolCall(msg *MCPMessage, encoder *json.Encoder) {
 	sendError(encoder, msg.ID, -32601, fmt.Sprintf("Unknown tool: %s. Use apply_operations for batch operations", req.Name), nil)
 }
 
+// batchRunner dispatches apply_operations to the operation handlers
+var batchRunner = mcp.NewBatchRunner(map[string]mcp.OperationHandler{
+	"get_system_info":       cachedOperation("get_system_info", toolGetSystemInfo),
+	"get_os_info":           cachedOperation("get_os_info", toolGetOSInfo),
+	"get_hardware_info":     cachedOperation("get_hardware_info", toolGetHardwareInfo),
+	"get_environment_info":  toolGetEnvironmentInfo,
+	"get_env_var":           toolGetEnvVar,
+	"get_shell_info":        cachedOperation("get_shell_info", toolGetShellInfo),
+	"get_development_tools": cachedOperation("get_development_tools", toolGetDevelopmentTools),
+	"get_network_info":      cachedOperation("get_network_info", toolGetNetworkInfo),
+	"detect_repositories":   toolDetectRepositories,
+	"check_command":         toolCheckCommand,
+	"check_commands":        toolCheckCommands,
+	"get_recommendations":   toolGetRecommendations,
+	"get_process_list":      toolGetProcessList,
+	"get_sensors":           toolGetSensors,
+	"get_io_stats":          toolGetIOStats,
+	"list_packages":         toolListPackages,
+	"detect_environment":    cachedOperation("detect_environment", toolDetectEnvironment),
+	"get_security_policy":   toolGetSecurityPolicy,
+	"query_audit_log":       toolQueryAuditLog,
+	"get_path_analysis":     toolGetPathAnalysis,
+	"get_time":              toolGetTime,
+})
+
 // handleBatchOperations processes a batch of operations
 func handleBatchOperations(msg *MCPMessage, encoder *json.Encoder, args map[string]interface{}) {
-	operations, ok := args["operations"].([]interface{})
-	if !ok {
-		sendError(encoder, msg.ID, -32602, "operations array is required", nil)
-		return
-	}
-
-	if len(operations) == 0 {
-		sendError(encoder, msg.ID, -32602, "operations array cannot be empty", nil)
-		return
-	}
-
-	var results []map[string]interface{}
-
-	for _, op := range operations {
-		opMap, ok := op.(map[string]interface{})
-		if !ok {
-			results = append(results, map[string]interface{}{
-				"operation": "unknown",
-				"params":    map[string]interface{}{},
-				"status":    "Error",
-				"message":   "Invalid operation format",
-			})
-			continue
-		}
-
-		opType, ok := opMap["type"].(string)
-		if !ok {
-			results = append(results, map[string]interface{}{
-				"operation": "unknown",
-				"params":    map[string]interface{}{},
-				"status":    "Error",
-				"message":   "Operation type is required",
-			})
-			continue
-		}
-
-		// Extract operation-specific arguments as params
-		params := make(map[string]interface{})
-		for k, v := range opMap {
-			if k != "type" {
-				params[k] = v
-			}
-		}
-
-		// Execute operation based on type
-		var result string
-		var err error
-
-		switch opType {
-		case "get_system_info":
-			result, err = toolGetSystemInfo(params)
-		case "get_os_info":
-			result, err = toolGetOSInfo(params)
-		case "get_hardware_info":
-			result, err = toolGetHardwareInfo(params)
-		case "get_environment_info":
-			result, err = toolGetEnvironmentInfo(params)
-		case "get_shell_info":
-			result, err = toolGetShellInfo(params)
-		case "get_development_tools":
-			result, err = toolGetDevelopmentTools(params)
-		case "get_network_info":
-			result, err = toolGetNetworkInfo(params)
-		case "detect_repositories":
-			result, err = toolDetectRepositories(params)
-		case "check_command":
-			result, err = toolCheckCommand(params)
-		case "get_recommendations":
-			result, err = toolGetRecommendations(params)
-		default:
-			err = fmt.Errorf("unknown operation type: %s", opType)
-		}
-
-		if err != nil {
-			results = append(results, map[string]interface{}{
-				"operation": opType,
-				"params":    params,
-				"status":    "Error",
-				"message":   err.Error(),
-			})
-		} else {
-			// Parse JSON result if possible, otherwise use as string
-			var parsedResult interface{}
-			if jsonErr := json.Unmarshal([]byte(result), &parsedResult); jsonErr == nil {
-				// Successfully parsed JSON, use parsed result
-			} else {
-				// Not JSON, use string as-is
-				parsedResult = result
-			}
-
-			results = append(results, map[string]interface{}{
-				"operation": opType,
-				"params":    params,
-				"status":    "Success",
-				"result":    parsedResult,
-			})
-		}
-	}
-
-	// Serialize results to JSON text for MCP-compliant response format
-	resultsJSON, err := json.Marshal(map[string]interface{}{
-		"results": results,
-	})
-	if err != nil {
-		sendError(encoder, msg.ID, -32700, fmt.Sprintf("Failed to marshal results: %v", err), nil)
-		return
-	}
-
-	// Return results in MCP-compliant format using ToolsCallResponse
-	response := MCPMessage{
-		JSONRPC: "2.0",
-		ID:      msg.ID,
-		Result: ToolsCallResponse{
-			Content: []Content{
-				{
-					Type: "text",
-					Text: string(resultsJSON),
-				},
-			},
-			IsError: false,
-		},
-	}
-
-	encoder.Encode(response)
+	batchRunner.Handle(msg, encoder, args)
 }
 
 // sendError sends an error response
 func sendError(encoder *json.Encoder, id interface{}, code int, message string, data interface{}) {
-	response := MCPMessage{
-		JSONRPC: "2.0",
-		ID:      id,
-		Error: &MCPError{
-			Code:    code,
-			Message: message,
-			Data:    data,
-		},
-	}
-	encoder.Encode(response)
-}
\ No newline at end of file
+	mcp.SendError(encoder, id, code, message, data)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+// batchResultFields returns the sorted field names of a batch result
+func batchResultFields(result map[string]interface{}) []string {
+	fields := make([]string, 0, len(result))
+	for field := range result {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// TestBatchResultSchema checks batch results carry the shared schema. mcp-bash has the
+// same test so both servers are held to identical field presence.
+func TestBatchResultSchema(t *testing.T) {
+	origAuditEnabled := auditEnabled
+	auditEnabled = false
+	defer func() { auditEnabled = origAuditEnabled }()
+
+	results := batchRunner.Run([]interface{}{
+		map[string]interface{}{"type": "get_security_policy"},
+		map[string]interface{}{"type": "no_such_operation"},
+	})
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+
+	wantSuccess := "index,operation,params,result,status,success"
+	if got := strings.Join(batchResultFields(results[0]), ","); got != wantSuccess {
+		t.Errorf("Success result fields = %s, want %s", got, wantSuccess)
+	}
+	wantError := "error,index,message,operation,params,status,success"
+	if got := strings.Join(batchResultFields(results[1]), ","); got != wantError {
+		t.Errorf("Error result fields = %s, want %s", got, wantError)
+	}
+
+	if results[0]["success"] != true || results[1]["success"] != false || results[1]["index"] != 1 {
+		t.Errorf("Unexpected success or index values: %v, %v", results[0], results[1])
+	}
+}
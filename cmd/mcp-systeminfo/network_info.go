@@ -12,7 +12,7 @@ import (
 )
 
 // getNetworkInfo gathers network information
-func getNetworkInfo() (*NetworkInfo, error) {
+func getNetworkInfo(timeout int) (*NetworkInfo, error) {
 	networkInfo := &NetworkInfo{}
 
 	// Get hostname
@@ -21,7 +21,7 @@ func getNetworkInfo() (*NetworkInfo, error) {
 	}
 
 	// Get IP address
-	ip, mac := getNetworkAddresses()
+	ip, mac := getNetworkAddresses(timeout)
 	networkInfo.IPAddress = ip
 	networkInfo.MAC = mac
 
@@ -31,11 +31,11 @@ func getNetworkInfo() (*NetworkInfo, error) {
 	}
 
 	// Get gateway
-	gateway := getGateway()
+	gateway := getGateway(timeout)
 	networkInfo.Gateway = gateway
 
 	// Get DNS servers
-	dns := getDNSServers()
+	dns := getDNSServers(timeout)
 	networkInfo.DNS = dns
 
 	// Get proxy settings
@@ -47,7 +47,7 @@ func getNetworkInfo() (*NetworkInfo, error) {
 	networkInfo.InternetAccess = hasInternetAccess()
 
 	// Get public IP (optional, may take longer)
-	if publicIP := getPublicIP(); publicIP != "" {
+	if publicIP := getPublicIP(timeout); publicIP != "" {
 		networkInfo.PublicIP = publicIP
 	}
 
@@ -55,7 +55,7 @@ func getNetworkInfo() (*NetworkInfo, error) {
 }
 
 // getNetworkAddresses gets local IP and MAC addresses
-func getNetworkAddresses() (string, string) {
+func getNetworkAddresses(timeout int) (string, string) {
 	// Get IP address using Go's net package
 	addrs, err := net.InterfaceAddrs()
 	if err == nil {
@@ -69,7 +69,7 @@ func getNetworkAddresses() (string, string) {
 	}
 
 	// Fallback to system commands
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(timeout, 3*time.Second))
 	defer cancel()
 
 	if runtime.GOOS == "windows" {
@@ -100,8 +100,8 @@ func getNetworkAddresses() (string, string) {
 }
 
 // getGateway gets the default gateway
-func getGateway() string {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+func getGateway(timeout int) string {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(timeout, 3*time.Second))
 	defer cancel()
 
 	if runtime.GOOS == "windows" {
@@ -132,10 +132,10 @@ func getGateway() string {
 }
 
 // getDNSServers gets DNS servers
-func getDNSServers() []string {
+func getDNSServers(timeout int) []string {
 	var dnsServers []string
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(timeout, 3*time.Second))
 	defer cancel()
 
 	if runtime.GOOS == "windows" {
@@ -241,8 +241,8 @@ func hasInternetAccess() bool {
 }
 
 // getPublicIP gets the public IP address (optional)
-func getPublicIP() string {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func getPublicIP(timeout int) string {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(timeout, 5*time.Second))
 	defer cancel()
 
 	// Try to get public IP from a reliable service
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPackageLimit = 100
+	maxPackageLimit     = 5000
+)
+
+// packageManager is a read-only command that lists installed packages and the parser for
+// its output
+type packageManager struct {
+	commands [][]string
+	parse    func(output string) ([]PackageInfo, error)
+}
+
+// packageManagers maps each manager name to its list command. When a manager has more
+// than one command, the first whose binary is installed is used.
+var packageManagers = map[string]packageManager{
+	"apt": {
+		commands: [][]string{{"apt", "list", "--installed"}},
+		parse:    parseAptList,
+	},
+	"dpkg": {
+		commands: [][]string{{"dpkg-query", "-W", "-f=${Package}\t${Version}\n"}},
+		parse:    parseTabSeparatedPackages,
+	},
+	"brew": {
+		commands: [][]string{{"brew", "list", "--versions"}},
+		parse:    parseBrewList,
+	},
+	"pip": {
+		commands: [][]string{{"pip3", "list", "--format=json"}, {"pip", "list", "--format=json"}},
+		parse:    parsePipList,
+	},
+	"npm": {
+		commands: [][]string{{"npm", "ls", "--global", "--depth=0", "--json"}},
+		parse:    parseNpmList,
+	},
+}
+
+// toolListPackages lists the packages installed by manager, sorted by name
+func toolListPackages(args map[string]interface{}) (string, error) {
+	managerName, _ := args["manager"].(string)
+	manager, ok := packageManagers[managerName]
+	if !ok {
+		return "", fmt.Errorf("unsupported manager %q (supported: %s)", managerName, strings.Join(packageManagerNames(), ", "))
+	}
+
+	limit := defaultPackageLimit
+	if lim, ok := args["limit"].(float64); ok {
+		limit = int(lim)
+		if limit > maxPackageLimit {
+			limit = maxPackageLimit
+		}
+		if limit < 1 {
+			limit = 1
+		}
+	}
+
+	timeout := resolveTimeout(args)
+
+	startTime := time.Now()
+	command, packages, err := listPackages(manager, timeout)
+	duration := time.Since(startTime).Milliseconds()
+
+	// Audit logging (read-only operation)
+	auditLogWithTimeout("list_packages", command, timeout, duration, err == nil)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to list %s packages: %w", managerName, err)
+	}
+
+	total := len(packages)
+	if len(packages) > limit {
+		packages = packages[:limit]
+	}
+
+	// Return JSON result
+	resultJSON, err := json.Marshal(map[string]interface{}{
+		"manager":   managerName,
+		"packages":  packages,
+		"count":     len(packages),
+		"total":     total,
+		"truncated": total > len(packages),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal package list: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// packageManagerNames returns the supported manager names in sorted order
+func packageManagerNames() []string {
+	names := make([]string, 0, len(packageManagers))
+	for name := range packageManagers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// listPackages runs the manager's first installed list command and parses its output.
+// It returns the command line it ran, for the audit log.
+func listPackages(manager packageManager, timeout int) (string, []PackageInfo, error) {
+	var argv []string
+	for _, candidate := range manager.commands {
+		if _, err := exec.LookPath(candidate[0]); err == nil {
+			argv = candidate
+			break
+		}
+	}
+	if argv == nil {
+		return strings.Join(manager.commands[0], " "), nil, fmt.Errorf("%s not found", manager.commands[0][0])
+	}
+	command := strings.Join(argv, " ")
+
+	if !defaultSecurityPolicy.AllowedCommands[argv[0]] {
+		return command, nil, fmt.Errorf("command not allowed: %s", argv[0])
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	runErr := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return command, nil, fmt.Errorf("command timed out after %v", time.Duration(timeout)*time.Second)
+	}
+
+	// npm ls exits non-zero for problems such as extraneous packages while still
+	// printing the full list, so the output wins when it parses
+	packages, parseErr := manager.parse(stdout.String())
+	if runErr != nil && (parseErr != nil || len(packages) == 0) {
+		return command, nil, fmt.Errorf("%w: %s", runErr, strings.TrimSpace(stderr.String()))
+	}
+	if parseErr != nil {
+		return command, nil, parseErr
+	}
+
+	sort.SliceStable(packages, func(i, j int) bool {
+		return packages[i].Name < packages[j].Name
+	})
+	return command, packages, nil
+}
+
+// parseAptList parses apt list --installed, whose lines are "name/suites version arch [flags]"
+// after a "Listing..." header
+func parseAptList(output string) ([]PackageInfo, error) {
+	packages := []PackageInfo{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		name, _, found := strings.Cut(fields[0], "/")
+		if !found {
+			continue
+		}
+		packages = append(packages, PackageInfo{Name: name, Version: fields[1]})
+	}
+	return packages, nil
+}
+
+// parseTabSeparatedPackages parses "name\tversion" lines, as printed by dpkg-query -W
+func parseTabSeparatedPackages(output string) ([]PackageInfo, error) {
+	packages := []PackageInfo{}
+	for _, line := range strings.Split(output, "\n") {
+		name, version, found := strings.Cut(line, "\t")
+		if !found || name == "" {
+			continue
+		}
+		packages = append(packages, PackageInfo{Name: name, Version: strings.TrimSpace(version)})
+	}
+	return packages, nil
+}
+
+// parseBrewList parses brew list --versions, whose lines are "name version [version...]".
+// Only the last, newest version of each package is reported.
+func parseBrewList(output string) ([]PackageInfo, error) {
+	packages := []PackageInfo{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		packages = append(packages, PackageInfo{Name: fields[0], Version: fields[len(fields)-1]})
+	}
+	return packages, nil
+}
+
+// parsePipList parses pip list --format=json
+func parsePipList(output string) ([]PackageInfo, error) {
+	packages := []PackageInfo{}
+	if err := json.Unmarshal([]byte(output), &packages); err != nil {
+		return nil, fmt.Errorf("failed to parse pip output: %w", err)
+	}
+	return packages, nil
+}
+
+// parseNpmList parses npm ls --json, whose top-level packages are under "dependencies"
+func parseNpmList(output string) ([]PackageInfo, error) {
+	var tree struct {
+		Dependencies map[string]struct {
+			Version string `json:"version"`
+		} `json:"dependencies"`
+	}
+	if err := json.Unmarshal([]byte(output), &tree); err != nil {
+		return nil, fmt.Errorf("failed to parse npm output: %w", err)
+	}
+
+	packages := make([]PackageInfo, 0, len(tree.Dependencies))
+	for name, dep := range tree.Dependencies {
+		packages = append(packages, PackageInfo{Name: name, Version: dep.Version})
+	}
+	return packages, nil
+}
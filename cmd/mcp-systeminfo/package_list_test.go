@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestToolListPackages tests that dpkg and apt listings return entries on Linux
+func TestToolListPackages(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("dpkg and apt are only available on Linux")
+	}
+
+	origAuditEnabled := auditEnabled
+	auditEnabled = false
+	defer func() { auditEnabled = origAuditEnabled }()
+
+	for _, tc := range []struct{ manager, binary string }{
+		{"dpkg", "dpkg-query"},
+		{"apt", "apt"},
+	} {
+		t.Run(tc.manager, func(t *testing.T) {
+			if _, err := exec.LookPath(tc.binary); err != nil {
+				t.Skipf("%s is not installed", tc.binary)
+			}
+
+			result, err := toolListPackages(map[string]interface{}{"manager": tc.manager, "limit": float64(5)})
+			if err != nil {
+				t.Fatalf("toolListPackages() error = %v", err)
+			}
+
+			var decoded struct {
+				Packages []PackageInfo `json:"packages"`
+				Count    int           `json:"count"`
+				Total    int           `json:"total"`
+			}
+			if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+				t.Fatalf("Failed to parse result: %v", err)
+			}
+			if decoded.Total == 0 {
+				t.Skipf("No packages installed through %s", tc.manager)
+			}
+			if decoded.Count != len(decoded.Packages) || decoded.Count > 5 {
+				t.Errorf("Expected at most 5 packages, got count %d and %d entries", decoded.Count, len(decoded.Packages))
+			}
+			for _, pkg := range decoded.Packages {
+				if pkg.Name == "" || pkg.Version == "" {
+					t.Errorf("Expected a name and version, got %+v", pkg)
+				}
+			}
+		})
+	}
+}
+
+// TestToolListPackagesUnknownManager tests that managers outside the list are rejected
+func TestToolListPackagesUnknownManager(t *testing.T) {
+	for _, manager := range []string{"", "rpm", "sh"} {
+		_, err := toolListPackages(map[string]interface{}{"manager": manager})
+		if err == nil || !strings.Contains(err.Error(), "unsupported manager") {
+			t.Errorf("toolListPackages(%q) error = %v, want unsupported manager", manager, err)
+		}
+	}
+}
+
+// TestParsePackageLists tests parsing each manager's list output
+func TestParsePackageLists(t *testing.T) {
+	tests := []struct {
+		name   string
+		parse  func(string) ([]PackageInfo, error)
+		output string
+		want   PackageInfo
+		count  int
+	}{
+		{"apt", parseAptList, "Listing... Done\nbash/jammy,now 5.1-6ubuntu1 amd64 [installed]\ncurl/jammy-updates 7.81.0-1 amd64 [installed,automatic]\n", PackageInfo{Name: "bash", Version: "5.1-6ubuntu1"}, 2},
+		{"dpkg", parseTabSeparatedPackages, "bash\t5.1-6ubuntu1\ncurl\t7.81.0-1\n", PackageInfo{Name: "bash", Version: "5.1-6ubuntu1"}, 2},
+		{"brew", parseBrewList, "git 2.42.0 2.43.0\nwget 1.21.4\n", PackageInfo{Name: "git", Version: "2.43.0"}, 2},
+		{"pip", parsePipList, `[{"name": "requests", "version": "2.31.0"}]`, PackageInfo{Name: "requests", Version: "2.31.0"}, 1},
+		{"npm", parseNpmList, `{"name": "lib", "dependencies": {"typescript": {"version": "5.3.3"}}}`, PackageInfo{Name: "typescript", Version: "5.3.3"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packages, err := tt.parse(tt.output)
+			if err != nil {
+				t.Fatalf("parse error = %v", err)
+			}
+			if len(packages) != tt.count {
+				t.Fatalf("Expected %d packages, got %+v", tt.count, packages)
+			}
+			if packages[0] != tt.want {
+				t.Errorf("First package = %+v, want %+v", packages[0], tt.want)
+			}
+		})
+	}
+
+	if _, err := parsePipList("not json"); err == nil {
+		t.Error("Expected an error for malformed pip output")
+	}
+}
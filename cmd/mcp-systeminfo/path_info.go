@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// PathEntry is the state of one PATH directory
+type PathEntry struct {
+	Path      string `json:"path"`
+	Exists    bool   `json:"exists"`
+	IsDir     bool   `json:"is_dir"`
+	Writable  bool   `json:"writable"`
+	Duplicate bool   `json:"duplicate,omitempty"` // an earlier entry has the same path
+	Error     string `json:"error,omitempty"`     // why the entry could not be checked, other than not existing
+}
+
+// toolGetPathAnalysis reports, for each PATH entry in order, whether it exists, is a
+// directory and is writable by the server's user, to diagnose tools that install into or
+// are looked up from the wrong directory. Nothing is written: entries are only stat'ed and
+// checked for write permission.
+func toolGetPathAnalysis(args map[string]interface{}) (string, error) {
+	entries := analyzePath(os.Getenv("PATH"))
+
+	missing := 0
+	for _, entry := range entries {
+		if !entry.Exists || !entry.IsDir {
+			missing++
+		}
+	}
+
+	// Audit logging
+	auditLog("get_path_analysis", "", "", "", nil, nil, 0, true, 0, "")
+
+	// Return JSON result
+	resultJSON, err := json.Marshal(map[string]interface{}{
+		"entries": entries,
+		"count":   len(entries),
+		"missing": missing,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal PATH analysis: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// analyzePath checks each entry of a PATH value
+func analyzePath(pathValue string) []PathEntry {
+	entries := []PathEntry{}
+	if pathValue == "" {
+		return entries
+	}
+
+	seen := make(map[string]bool)
+	for _, dir := range strings.Split(pathValue, string(os.PathListSeparator)) {
+		entry := PathEntry{Path: dir, Duplicate: seen[dir]}
+		seen[dir] = true
+
+		// Stat follows symlinks, so a linked directory counts as a directory
+		info, err := os.Stat(dir)
+		switch {
+		case err == nil:
+			entry.Exists = true
+			entry.IsDir = info.IsDir()
+			entry.Writable = entry.IsDir && isWritable(dir, info)
+		case !os.IsNotExist(err):
+			entry.Error = err.Error()
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
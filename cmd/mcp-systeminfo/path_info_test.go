@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestGetPathAnalysis tests that PATH entries are reported in order with their existence,
+// type and writability, and that repeated entries are flagged
+func TestGetPathAnalysis(t *testing.T) {
+	origAuditEnabled := auditEnabled
+	auditEnabled = false
+	defer func() { auditEnabled = origAuditEnabled }()
+
+	binDir := "/usr/bin"
+	if runtime.GOOS == "windows" {
+		binDir = os.Getenv("SystemRoot")
+	}
+	tmpBin := t.TempDir()
+	missing := filepath.Join(tmpBin, "missing")
+	file := filepath.Join(tmpBin, "tool")
+	if err := os.WriteFile(file, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", strings.Join([]string{binDir, tmpBin, missing, file, tmpBin}, string(os.PathListSeparator)))
+
+	resultJSON, err := toolGetPathAnalysis(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("toolGetPathAnalysis() error = %v", err)
+	}
+	var result struct {
+		Entries []PathEntry `json:"entries"`
+		Count   int         `json:"count"`
+		Missing int         `json:"missing"`
+	}
+	if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if result.Count != 5 || len(result.Entries) != 5 || result.Missing != 2 {
+		t.Fatalf("Expected 5 entries with 2 missing, got %+v", result)
+	}
+
+	if bin := result.Entries[0]; bin.Path != binDir || !bin.Exists || !bin.IsDir {
+		t.Errorf("Expected %s to exist as a directory, got %+v", binDir, bin)
+	}
+	want := []PathEntry{
+		{Path: tmpBin, Exists: true, IsDir: true, Writable: true},
+		{Path: missing},
+		{Path: file, Exists: true},
+		{Path: tmpBin, Exists: true, IsDir: true, Writable: true, Duplicate: true},
+	}
+	for i, entry := range result.Entries[1:] {
+		if entry != want[i] {
+			t.Errorf("Entry %d = %+v, want %+v", i+1, entry, want[i])
+		}
+	}
+}
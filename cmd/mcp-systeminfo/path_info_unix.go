@@ -0,0 +1,17 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// accessWriteOK is W_OK of access(2)
+const accessWriteOK = 0x2
+
+// isWritable reports whether the server's user may create files in dir, asking the kernel
+// so that ownership, group membership and ACLs are all taken into account
+func isWritable(dir string, _ os.FileInfo) bool {
+	return syscall.Access(dir, accessWriteOK) == nil
+}
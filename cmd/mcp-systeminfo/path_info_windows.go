@@ -0,0 +1,9 @@
+package main
+
+import "os"
+
+// isWritable reports whether dir lacks the read-only attribute. ACLs are not checked, so a
+// directory denied by its ACL can still be reported writable.
+func isWritable(_ string, info os.FileInfo) bool {
+	return info.Mode().Perm()&0200 != 0
+}
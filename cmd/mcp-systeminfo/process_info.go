@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// toolGetProcessList returns the top processes by memory usage
+func toolGetProcessList(args map[string]interface{}) (string, error) {
+	limit := 20
+	if lim, ok := args["limit"].(float64); ok {
+		limit = int(lim)
+		if limit > 100 {
+			limit = 100
+		}
+		if limit < 1 {
+			limit = 1
+		}
+	}
+
+	timeout := resolveTimeout(args)
+
+	startTime := time.Now()
+	processes, err := getProcessList(limit, timeout)
+	duration := time.Since(startTime).Milliseconds()
+
+	// Audit logging (read-only operation)
+	auditLogWithTimeout("get_process_list", processListCommand(), timeout, duration, err == nil)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to get process list: %w", err)
+	}
+
+	// Return JSON result
+	resultJSON, err := json.Marshal(map[string]interface{}{
+		"processes": processes,
+		"count":     len(processes),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal process list: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// processListCommand returns the command used to list processes on this platform
+func processListCommand() string {
+	if runtime.GOOS == "windows" {
+		return "powershell Get-Process"
+	}
+	return "ps -eo pid=,rss=,pcpu=,comm="
+}
+
+// getProcessList lists running processes sorted by memory usage, descending.
+// A limit of 0 or less returns every process.
+func getProcessList(limit int, timeout int) ([]ProcessInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	var processes []ProcessInfo
+	var err error
+	if runtime.GOOS == "windows" {
+		processes, err = getWindowsProcessList(ctx)
+	} else {
+		processes, err = getUnixProcessList(ctx)
+	}
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("command timed out after %v", time.Duration(timeout)*time.Second)
+		}
+		return nil, err
+	}
+
+	sort.SliceStable(processes, func(i, j int) bool {
+		return processes[i].MemBytes > processes[j].MemBytes
+	})
+
+	if limit > 0 && len(processes) > limit {
+		processes = processes[:limit]
+	}
+
+	return processes, nil
+}
+
+// getUnixProcessList lists processes using ps
+func getUnixProcessList(ctx context.Context) ([]ProcessInfo, error) {
+	if !defaultSecurityPolicy.AllowedCommands["ps"] {
+		return nil, fmt.Errorf("command not allowed: ps")
+	}
+
+	// Trailing '=' suppresses the header; comm goes last since it may contain spaces
+	cmd := exec.CommandContext(ctx, "ps", "-eo", "pid=,rss=,pcpu=,comm=")
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	return parsePsOutput(stdout.String()), nil
+}
+
+// parsePsOutput parses "pid rss pcpu comm" lines from ps, with rss in kilobytes
+func parsePsOutput(output string) []ProcessInfo {
+	processes := []ProcessInfo{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+
+		process := ProcessInfo{
+			PID:  pid,
+			Name: strings.Join(fields[3:], " "),
+		}
+		if rss, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			process.MemBytes = rss * 1024 // Convert KB to bytes
+		}
+		if cpu, err := strconv.ParseFloat(fields[2], 64); err == nil {
+			process.CPUPercent = cpu
+		}
+		processes = append(processes, process)
+	}
+	return processes
+}
+
+// getWindowsProcessList lists processes using PowerShell Get-Process
+func getWindowsProcessList(ctx context.Context) ([]ProcessInfo, error) {
+	if !defaultSecurityPolicy.AllowedCommands["powershell"] {
+		return nil, fmt.Errorf("command not allowed: powershell")
+	}
+
+	// CPU is total processor seconds; average it over the process lifetime like ps does.
+	// StartTime is not readable for some system processes, which leaves CpuPercent null.
+	script := "Get-Process | Select-Object Id, ProcessName, WorkingSet64, " +
+		"@{Name='CpuPercent';Expression={$_.CPU / ((Get-Date) - $_.StartTime).TotalSeconds * 100 / [Environment]::ProcessorCount}} | ConvertTo-Json"
+	cmd := exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command", script)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	var results []map[string]interface{}
+	if err := json.Unmarshal(stdout.Bytes(), &results); err != nil {
+		return nil, fmt.Errorf("failed to parse Get-Process output: %w", err)
+	}
+
+	processes := []ProcessInfo{}
+	for _, result := range results {
+		id, ok := result["Id"].(float64)
+		if !ok {
+			continue
+		}
+		process := ProcessInfo{PID: int(id)}
+		if name, ok := result["ProcessName"].(string); ok {
+			process.Name = name
+		}
+		if ws, ok := result["WorkingSet64"].(float64); ok {
+			process.MemBytes = uint64(ws)
+		}
+		if cpu, ok := result["CpuPercent"].(float64); ok {
+			process.CPUPercent = cpu
+		}
+		processes = append(processes, process)
+	}
+	return processes, nil
+}
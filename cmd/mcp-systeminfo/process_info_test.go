@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// TestGetProcessListIncludesCurrentProcess tests that the test binary shows up in the process list
+func TestGetProcessListIncludesCurrentProcess(t *testing.T) {
+	processes, err := getProcessList(0, defaultSecurityPolicy.DefaultTimeout)
+	if err != nil {
+		t.Fatalf("getProcessList() error = %v", err)
+	}
+
+	pid := os.Getpid()
+	found := false
+	for _, p := range processes {
+		if p.PID == pid {
+			found = true
+			if p.Name == "" {
+				t.Error("Expected current process to have a name")
+			}
+			if p.MemBytes == 0 {
+				t.Error("Expected current process to report memory usage")
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Current process %d not found in %d processes", pid, len(processes))
+	}
+
+	for i := 1; i < len(processes); i++ {
+		if processes[i-1].MemBytes < processes[i].MemBytes {
+			t.Fatal("Expected processes sorted by memory, descending")
+		}
+	}
+}
+
+// TestToolGetProcessList tests the limit parameter and response shape
+func TestToolGetProcessList(t *testing.T) {
+	origAuditEnabled := auditEnabled
+	auditEnabled = false
+	defer func() { auditEnabled = origAuditEnabled }()
+
+	result, err := toolGetProcessList(map[string]interface{}{"limit": float64(3)})
+	if err != nil {
+		t.Fatalf("toolGetProcessList() error = %v", err)
+	}
+
+	var response struct {
+		Processes []ProcessInfo `json:"processes"`
+		Count     int           `json:"count"`
+	}
+	if err := json.Unmarshal([]byte(result), &response); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if response.Count != len(response.Processes) || response.Count == 0 || response.Count > 3 {
+		t.Errorf("Expected 1-3 processes, got count=%d len=%d", response.Count, len(response.Processes))
+	}
+}
+
+// TestParsePsOutput tests parsing of ps output, including names with spaces
+func TestParsePsOutput(t *testing.T) {
+	output := "    1  9116  0.2 init\n  42  2048 12.5 Google Chrome Helper\nbad line\n"
+	processes := parsePsOutput(output)
+
+	if len(processes) != 2 {
+		t.Fatalf("Expected 2 processes, got %d", len(processes))
+	}
+	if processes[0].PID != 1 || processes[0].MemBytes != 9116*1024 || processes[0].CPUPercent != 0.2 {
+		t.Errorf("Unexpected first process: %+v", processes[0])
+	}
+	if processes[1].Name != "Google Chrome Helper" {
+		t.Errorf("Expected name with spaces, got %q", processes[1].Name)
+	}
+}
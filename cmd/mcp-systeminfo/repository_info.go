@@ -12,7 +12,7 @@ import (
 )
 
 // detectRepositories detects version control repositories
-func detectRepositories() ([]RepositoryInfo, error) {
+func detectRepositories(timeout int) ([]RepositoryInfo, error) {
 	var repositories []RepositoryInfo
 
 	// Start from current working directory
@@ -22,12 +22,12 @@ func detectRepositories() ([]RepositoryInfo, error) {
 	}
 
 	// Look for repositories in current directory and parent directories
-	repositories = scanDirectoryForRepos(startDir)
+	repositories = scanDirectoryForRepos(startDir, timeout)
 
 	// Also check REPO_PATH if set
 	if repoPath := os.Getenv("REPO_PATH"); repoPath != "" && repoPath != startDir {
 		if absPath, err := filepath.Abs(repoPath); err == nil {
-			repos := scanDirectoryForRepos(absPath)
+			repos := scanDirectoryForRepos(absPath, timeout)
 			// Merge with existing repos, avoiding duplicates
 			for _, repo := range repos {
 				found := false
@@ -48,11 +48,11 @@ func detectRepositories() ([]RepositoryInfo, error) {
 }
 
 // scanDirectoryForRepos scans a directory for version control repositories
-func scanDirectoryForRepos(dir string) []RepositoryInfo {
+func scanDirectoryForRepos(dir string, timeout int) []RepositoryInfo {
 	var repositories []RepositoryInfo
 
 	// Check if current directory is a repository
-	if repo := checkDirectoryRepo(dir); repo != nil {
+	if repo := checkDirectoryRepo(dir, timeout); repo != nil {
 		repositories = append(repositories, *repo)
 	}
 
@@ -64,7 +64,7 @@ func scanDirectoryForRepos(dir string) []RepositoryInfo {
 			break // Reached root
 		}
 
-		if repo := checkDirectoryRepo(parent); repo != nil {
+		if repo := checkDirectoryRepo(parent, timeout); repo != nil {
 			repositories = append(repositories, *repo)
 		}
 		currentDir = parent
@@ -74,19 +74,19 @@ func scanDirectoryForRepos(dir string) []RepositoryInfo {
 }
 
 // checkDirectoryRepo checks if a directory contains a version control repository
-func checkDirectoryRepo(dir string) *RepositoryInfo {
+func checkDirectoryRepo(dir string, timeout int) *RepositoryInfo {
 	// Check for Git repository
-	if gitRepo := checkGitRepo(dir); gitRepo != nil {
+	if gitRepo := checkGitRepo(dir, timeout); gitRepo != nil {
 		return gitRepo
 	}
 
 	// Check for SVN repository
-	if svnRepo := checkSVNRepo(dir); svnRepo != nil {
+	if svnRepo := checkSVNRepo(dir, timeout); svnRepo != nil {
 		return svnRepo
 	}
 
 	// Check for Mercurial repository
-	if hgRepo := checkMercurialRepo(dir); hgRepo != nil {
+	if hgRepo := checkMercurialRepo(dir, timeout); hgRepo != nil {
 		return hgRepo
 	}
 
@@ -94,7 +94,7 @@ func checkDirectoryRepo(dir string) *RepositoryInfo {
 }
 
 // checkGitRepo checks if directory is a Git repository
-func checkGitRepo(dir string) *RepositoryInfo {
+func checkGitRepo(dir string, timeout int) *RepositoryInfo {
 	gitDir := filepath.Join(dir, ".git")
 	if stat, err := os.Stat(gitDir); err != nil || !stat.IsDir() {
 		// Also check for git file (worktree)
@@ -108,7 +108,7 @@ func checkGitRepo(dir string) *RepositoryInfo {
 		Type: "git",
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(timeout, 5*time.Second))
 	defer cancel()
 
 	// Get current branch
@@ -168,7 +168,7 @@ func checkGitRepo(dir string) *RepositoryInfo {
 }
 
 // checkSVNRepo checks if directory is an SVN repository
-func checkSVNRepo(dir string) *RepositoryInfo {
+func checkSVNRepo(dir string, timeout int) *RepositoryInfo {
 	svnDir := filepath.Join(dir, ".svn")
 	if _, err := os.Stat(svnDir); os.IsNotExist(err) {
 		return nil
@@ -179,7 +179,7 @@ func checkSVNRepo(dir string) *RepositoryInfo {
 		Type: "svn",
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(timeout, 5*time.Second))
 	defer cancel()
 
 	// Get SVN info
@@ -224,7 +224,7 @@ func checkSVNRepo(dir string) *RepositoryInfo {
 }
 
 // checkMercurialRepo checks if directory is a Mercurial repository
-func checkMercurialRepo(dir string) *RepositoryInfo {
+func checkMercurialRepo(dir string, timeout int) *RepositoryInfo {
 	hgDir := filepath.Join(dir, ".hg")
 	if _, err := os.Stat(hgDir); os.IsNotExist(err) {
 		return nil
@@ -235,7 +235,7 @@ func checkMercurialRepo(dir string) *RepositoryInfo {
 		Type: "hg",
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(timeout, 5*time.Second))
 	defer cancel()
 
 	// Get current branch
@@ -280,44 +280,72 @@ func checkMercurialRepo(dir string) *RepositoryInfo {
 	return repo
 }
 
-// getSystemRecommendations generates system-specific recommendations
-func getSystemRecommendations(osInfo *OSInfo, hardwareInfo *HardwareInfo, devToolsInfo *DevelopmentToolsInfo) []string {
-	var recommendations []string
+// Recommendation categories
+const (
+	recommendationPlatform = "platform"
+	recommendationHardware = "hardware"
+	recommendationTools    = "tools"
+	recommendationGeneral  = "general"
+)
+
+// recommendationCategories lists the categories in the order recommendations are generated
+var recommendationCategories = []string{recommendationPlatform, recommendationHardware, recommendationTools, recommendationGeneral}
+
+// getSystemRecommendations generates system-specific recommendations, each tagged with its
+// category
+func getSystemRecommendations(osInfo *OSInfo, hardwareInfo *HardwareInfo, devToolsInfo *DevelopmentToolsInfo, runtimeEnv *RuntimeEnvironment) []Recommendation {
+	var recommendations []Recommendation
+	add := func(category, message string) {
+		recommendations = append(recommendations, Recommendation{Category: category, Message: message})
+	}
 
 	// OS-specific recommendations
 	if osInfo != nil {
 		switch strings.ToLower(osInfo.Name) {
 		case "windows":
-			recommendations = append(recommendations, "Consider using Windows Terminal for better shell experience")
-			recommendations = append(recommendations, "Enable Windows Subsystem for Linux (WSL) for better Unix tool support")
+			add(recommendationPlatform, "Consider using Windows Terminal for better shell experience")
+			add(recommendationPlatform, "Enable Windows Subsystem for Linux (WSL) for better Unix tool support")
 			if devToolsInfo.PowerShell == nil || !devToolsInfo.PowerShell.Installed {
-				recommendations = append(recommendations, "Install PowerShell 7+ for enhanced scripting capabilities")
+				add(recommendationTools, "Install PowerShell 7+ for enhanced scripting capabilities")
 			}
 		case "linux":
-			recommendations = append(recommendations, "Use a modern terminal emulator like Tilix, Alacritty, or Kitty")
+			add(recommendationPlatform, "Use a modern terminal emulator like Tilix, Alacritty, or Kitty")
 			if osInfo.Distribution == "ubuntu" || osInfo.Distribution == "debian" {
-				recommendations = append(recommendations, "Keep system updated: sudo apt update && sudo apt upgrade")
+				add(recommendationPlatform, "Keep system updated: sudo apt update && sudo apt upgrade")
 			}
 		case "macos":
-			recommendations = append(recommendations, "Use Homebrew for package management")
-			recommendations = append(recommendations, "Consider installing iTerm2 for advanced terminal features")
+			add(recommendationPlatform, "Use Homebrew for package management")
+			add(recommendationPlatform, "Consider installing iTerm2 for advanced terminal features")
+		}
+	}
+
+	// Container and virtual machine recommendations
+	if runtimeEnv != nil {
+		if runtimeEnv.Containerized {
+			add(recommendationPlatform, fmt.Sprintf("Running in a %s container: install tools in the image rather than the running container, as changes are lost when it is recreated", runtimeEnv.Virtualization))
+			add(recommendationPlatform, "CPU and memory figures may describe the host; check the container's cgroup limits before sizing parallel builds")
+		} else if runtimeEnv.Virtualization != virtualizationNone {
+			add(recommendationPlatform, fmt.Sprintf("Running in a %s virtual machine: disk and network performance depend on the host", runtimeEnv.Virtualization))
+		}
+		if runtimeEnv.Cloud != cloudNone {
+			add(recommendationPlatform, fmt.Sprintf("Running on %s: prefer instance roles over stored credentials for cloud access", runtimeEnv.Cloud))
 		}
 	}
 
 	// Hardware-specific recommendations
 	if hardwareInfo != nil {
 		if hardwareInfo.Memory.UsagePercent > 80 {
-			recommendations = append(recommendations, "High memory usage detected. Consider closing unused applications")
+			add(recommendationHardware, "High memory usage detected. Consider closing unused applications")
 		}
 
 		if hardwareInfo.CPU.Threads >= 8 {
-			recommendations = append(recommendations, "Multi-core CPU detected. Parallel compilation enabled")
+			add(recommendationHardware, "Multi-core CPU detected. Parallel compilation enabled")
 		}
 
 		// Check disk space
 		for _, storage := range hardwareInfo.Storage {
 			if storage.UsagePercent > 90 {
-				recommendations = append(recommendations, fmt.Sprintf("Low disk space on %s (%.1f%% used). Consider cleanup", storage.Mountpoint, storage.UsagePercent))
+				add(recommendationHardware, fmt.Sprintf("Low disk space on %s (%.1f%% used). Consider cleanup", storage.Mountpoint, storage.UsagePercent))
 			}
 		}
 	}
@@ -339,7 +367,7 @@ func getSystemRecommendations(osInfo *OSInfo, hardwareInfo *HardwareInfo, devToo
 		}
 
 		if len(missingTools) > 0 {
-			recommendations = append(recommendations, fmt.Sprintf("Consider installing: %s", strings.Join(missingTools, ", ")))
+			add(recommendationTools, fmt.Sprintf("Consider installing: %s", strings.Join(missingTools, ", ")))
 		}
 
 		// Package manager recommendations
@@ -352,7 +380,7 @@ func getSystemRecommendations(osInfo *OSInfo, hardwareInfo *HardwareInfo, devToo
 				}
 			}
 			if !hasWindowsPkgMgr {
-				recommendations = append(recommendations, "Consider installing a Windows package manager (Chocolatey, winget, or Scoop)")
+				add(recommendationTools, "Consider installing a Windows package manager (Chocolatey, winget, or Scoop)")
 			}
 		} else if runtime.GOOS == "macos" {
 			hasHomebrew := false
@@ -363,18 +391,58 @@ func getSystemRecommendations(osInfo *OSInfo, hardwareInfo *HardwareInfo, devToo
 				}
 			}
 			if !hasHomebrew {
-				recommendations = append(recommendations, "Consider installing Homebrew for package management")
+				add(recommendationTools, "Consider installing Homebrew for package management")
 			}
 		}
 	}
 
 	// General recommendations
-	recommendations = append(recommendations, "Use MCP PowerShell server on Windows for better Windows command support")
-	recommendations = append(recommendations, "Use MCP Bash server on Unix systems for Unix command support")
+	add(recommendationGeneral, "Use MCP PowerShell server on Windows for better Windows command support")
+	add(recommendationGeneral, "Use MCP Bash server on Unix systems for Unix command support")
 
 	if len(recommendations) == 0 {
-		recommendations = append(recommendations, "System appears well-configured for development")
+		add(recommendationGeneral, "System appears well-configured for development")
 	}
 
 	return recommendations
-}
\ No newline at end of file
+}
+
+// filterRecommendations keeps the recommendations in the given categories. With no
+// categories every recommendation is kept. An unknown category is an error, so a typo does
+// not silently return nothing.
+func filterRecommendations(recommendations []Recommendation, categories []string) ([]Recommendation, error) {
+	if len(categories) == 0 {
+		return recommendations, nil
+	}
+	wanted := make(map[string]bool, len(categories))
+	for _, category := range categories {
+		known := false
+		for _, c := range recommendationCategories {
+			if c == category {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown recommendation category %q (valid: %s)", category, strings.Join(recommendationCategories, ", "))
+		}
+		wanted[category] = true
+	}
+
+	filtered := []Recommendation{}
+	for _, r := range recommendations {
+		if wanted[r.Category] {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered, nil
+}
+
+// recommendationMessages returns the messages of recommendations, without categories
+func recommendationMessages(recommendations []Recommendation) []string {
+	messages := make([]string, len(recommendations))
+	for i, r := range recommendations {
+		messages[i] = r.Message
+	}
+	return messages
+}
@@ -157,6 +157,42 @@ func containsShellFeatures(command string) bool {
 	return false
 }
 
+// resolveTimeout returns the timeout_seconds argument clamped to [1, MaxTimeout],
+// or the policy's DefaultTimeout when it isn't given
+func resolveTimeout(args map[string]interface{}) int {
+	t, ok := args["timeout_seconds"].(float64)
+	if !ok {
+		return defaultSecurityPolicy.DefaultTimeout
+	}
+
+	timeout := int(t)
+	if timeout < 1 {
+		timeout = 1
+	}
+	if timeout > defaultSecurityPolicy.MaxTimeout {
+		timeout = defaultSecurityPolicy.MaxTimeout
+	}
+	return timeout
+}
+
+// resolveProbeTimeout returns the timeout_seconds argument clamped like resolveTimeout, or 0
+// when it isn't given, so that each probe keeps its own default limit
+func resolveProbeTimeout(args map[string]interface{}) int {
+	if _, ok := args["timeout_seconds"].(float64); !ok {
+		return 0
+	}
+	return resolveTimeout(args)
+}
+
+// probeTimeout is the limit for one probe: the caller's timeout in seconds when one was given,
+// otherwise the probe's own fallback
+func probeTimeout(timeout int, fallback time.Duration) time.Duration {
+	if timeout <= 0 {
+		return fallback
+	}
+	return time.Duration(timeout) * time.Second
+}
+
 // executeCommandWithTimeout executes a command with timeout (for systeminfo server)
 func executeCommandWithTimeout(command string, timeout int) (*CommandResult, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
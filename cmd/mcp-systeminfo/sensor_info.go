@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// thermalZoneRoot is where Linux exposes thermal zones
+const thermalZoneRoot = "/sys/class/thermal"
+
+// toolGetSensors returns temperature sensor readings
+func toolGetSensors(args map[string]interface{}) (string, error) {
+	timeout := resolveProbeTimeout(args)
+
+	startTime := time.Now()
+	result := getSensors(timeout)
+	duration := time.Since(startTime).Milliseconds()
+
+	// Audit logging (read-only operation)
+	auditLogWithTimeout("get_sensors", result.Source, timeout, duration, true)
+
+	// Return JSON result
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal sensor readings: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// getSensors reads temperature sensors. Only Linux is supported; other platforms
+// get an unsupported result rather than an error.
+func getSensors(timeout int) *SensorsResult {
+	result := &SensorsResult{Sensors: []SensorReading{}}
+
+	if runtime.GOOS != "linux" {
+		result.Message = fmt.Sprintf("temperature sensors are not supported on %s", runtime.GOOS)
+		return result
+	}
+	result.Supported = true
+
+	if readings := readThermalZones(thermalZoneRoot); len(readings) > 0 {
+		result.Source = "sysfs"
+		result.Sensors = readings
+		return result
+	}
+
+	if readings, err := readLmSensors(timeout); err == nil && len(readings) > 0 {
+		result.Source = "sensors"
+		result.Sensors = readings
+		return result
+	}
+
+	result.Message = "no temperature sensors found"
+	return result
+}
+
+// readThermalZones reads root/thermal_zone*/temp, which holds millidegrees Celsius
+func readThermalZones(root string) []SensorReading {
+	readings := []SensorReading{}
+
+	zones, _ := filepath.Glob(filepath.Join(root, "thermal_zone*"))
+	sort.Strings(zones)
+
+	for _, zone := range zones {
+		data, err := os.ReadFile(filepath.Join(zone, "temp"))
+		if err != nil {
+			continue
+		}
+		milli, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+		if err != nil {
+			continue
+		}
+
+		reading := SensorReading{
+			Name:         filepath.Base(zone),
+			Zone:         filepath.Base(zone),
+			TemperatureC: milli / 1000.0,
+		}
+		if zoneType, err := os.ReadFile(filepath.Join(zone, "type")); err == nil {
+			if name := strings.TrimSpace(string(zoneType)); name != "" {
+				reading.Name = name
+			}
+		}
+		readings = append(readings, reading)
+	}
+
+	return readings
+}
+
+// readLmSensors reads temperatures from lm-sensors' `sensors` command
+func readLmSensors(timeout int) ([]SensorReading, error) {
+	if !defaultSecurityPolicy.AllowedCommands["sensors"] {
+		return nil, fmt.Errorf("command not allowed: sensors")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(timeout, 5*time.Second))
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sensors")
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	return parseSensorsOutput(stdout.String()), nil
+}
+
+var sensorsTempPattern = regexp.MustCompile(`^([^:]+):\s+([+-]?[\d.]+)°C`)
+
+// parseSensorsOutput parses `sensors` output, where each chip is a header line followed
+// by "label: +45.0°C  (high = ...)" readings
+func parseSensorsOutput(output string) []SensorReading {
+	readings := []SensorReading{}
+	chip := ""
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			chip = ""
+			continue
+		}
+		if chip == "" && !strings.Contains(line, ":") {
+			chip = strings.TrimSpace(line)
+			continue
+		}
+
+		m := sensorsTempPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		temp, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			continue
+		}
+		readings = append(readings, SensorReading{
+			Name:         strings.TrimSpace(m[1]),
+			Zone:         chip,
+			TemperatureC: temp,
+		})
+	}
+
+	return readings
+}
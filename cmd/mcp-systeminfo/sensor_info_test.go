@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// TestToolGetSensors tests that get_sensors returns a well-formed response on any platform
+func TestToolGetSensors(t *testing.T) {
+	origAuditEnabled := auditEnabled
+	auditEnabled = false
+	defer func() { auditEnabled = origAuditEnabled }()
+
+	result, err := toolGetSensors(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("toolGetSensors() error = %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+
+	supported, ok := decoded["supported"].(bool)
+	if !ok {
+		t.Fatalf("Expected boolean supported field, got %v", decoded["supported"])
+	}
+	if _, ok := decoded["sensors"].([]interface{}); !ok {
+		t.Errorf("Expected sensors array, got %v", decoded["sensors"])
+	}
+	if supported != (runtime.GOOS == "linux") {
+		t.Errorf("Expected supported=%v on %s", runtime.GOOS == "linux", runtime.GOOS)
+	}
+	if !supported && decoded["message"] == nil {
+		t.Error("Expected a message explaining why sensors are unsupported")
+	}
+}
+
+// TestReadThermalZones tests reading millidegree temperatures from a fake sysfs tree
+func TestReadThermalZones(t *testing.T) {
+	root := t.TempDir()
+	zones := map[string][2]string{
+		"thermal_zone0": {"45000\n", "x86_pkg_temp\n"},
+		"thermal_zone1": {"38500\n", ""},
+		"thermal_zone2": {"invalid\n", "acpitz\n"},
+	}
+	for zone, files := range zones {
+		dir := filepath.Join(root, zone)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		os.WriteFile(filepath.Join(dir, "temp"), []byte(files[0]), 0644)
+		if files[1] != "" {
+			os.WriteFile(filepath.Join(dir, "type"), []byte(files[1]), 0644)
+		}
+	}
+
+	readings := readThermalZones(root)
+	if len(readings) != 2 {
+		t.Fatalf("Expected 2 readable zones, got %d", len(readings))
+	}
+	if r := readings[0]; r.Name != "x86_pkg_temp" || r.Zone != "thermal_zone0" || r.TemperatureC != 45 {
+		t.Errorf("Unexpected first reading: %+v", r)
+	}
+	if r := readings[1]; r.Name != "thermal_zone1" || r.TemperatureC != 38.5 {
+		t.Errorf("Unexpected second reading: %+v", r)
+	}
+
+	if readings := readThermalZones(filepath.Join(root, "missing")); readings == nil || len(readings) != 0 {
+		t.Errorf("Expected empty readings for a missing root, got %v", readings)
+	}
+}
+
+// TestParseSensorsOutput tests parsing lm-sensors output
+func TestParseSensorsOutput(t *testing.T) {
+	output := `coretemp-isa-0000
+Adapter: ISA adapter
+Package id 0:  +45.0°C  (high = +100.0°C, crit = +100.0°C)
+Core 0:        +43.0°C  (high = +100.0°C, crit = +100.0°C)
+
+nvme-pci-0100
+Adapter: PCI adapter
+Composite:    +36.9°C  (low  = -273.1°C, high = +84.8°C)
+fan1:        1200 RPM
+`
+	readings := parseSensorsOutput(output)
+	if len(readings) != 3 {
+		t.Fatalf("Expected 3 readings, got %d: %+v", len(readings), readings)
+	}
+	if r := readings[0]; r.Name != "Package id 0" || r.Zone != "coretemp-isa-0000" || r.TemperatureC != 45 {
+		t.Errorf("Unexpected first reading: %+v", r)
+	}
+	if r := readings[2]; r.Name != "Composite" || r.Zone != "nvme-pci-0100" || r.TemperatureC != 36.9 {
+		t.Errorf("Unexpected last reading: %+v", r)
+	}
+}
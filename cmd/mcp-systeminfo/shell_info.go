@@ -11,7 +11,7 @@ import (
 )
 
 // getShellInfo gathers shell information
-func getShellInfo() (*ShellInfo, error) {
+func getShellInfo(timeout int) (*ShellInfo, error) {
 	shellInfo := &ShellInfo{}
 
 	// Determine current shell
@@ -32,7 +32,7 @@ func getShellInfo() (*ShellInfo, error) {
 	shellInfo.Type = getShellType(shellInfo.Name)
 
 	// Get shell version
-	version := getShellVersion(shellInfo.Name)
+	version := getShellVersion(shellInfo.Name, timeout)
 	shellInfo.Version = version
 
 	// Get shell features
@@ -40,7 +40,7 @@ func getShellInfo() (*ShellInfo, error) {
 
 	// Get shell aliases (if possible)
 	if shellInfo.Type == "bash" || shellInfo.Type == "zsh" {
-		aliases := getShellAliases(shellInfo.Path)
+		aliases := getShellAliases(shellInfo.Path, timeout)
 		shellInfo.Aliases = aliases
 	}
 
@@ -97,8 +97,8 @@ func getShellType(shellName string) string {
 }
 
 // getShellVersion gets shell version
-func getShellVersion(shellName string) string {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func getShellVersion(shellName string, timeout int) string {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(timeout, 5*time.Second))
 	defer cancel()
 
 	switch strings.ToLower(shellName) {
@@ -215,10 +215,10 @@ func getShellFeatures(shellName string) []string {
 }
 
 // getShellAliases gets shell aliases (basic implementation)
-func getShellAliases(shellPath string) map[string]string {
+func getShellAliases(shellPath string, timeout int) map[string]string {
 	aliases := make(map[string]string)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(timeout, 5*time.Second))
 	defer cancel()
 
 	// For bash, try to get aliases
@@ -9,6 +9,7 @@ import (
 	"os/exec"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -23,7 +24,8 @@ var defaultSecurityPolicy = SecurityPolicy{
 		"lsusb": true, "dmidecode": true, "uptime": true, "date": true,
 		"env": true, "printenv": true, "which": true, "whereis": true,
 		"wmic": true, "systeminfo": true, "powershell": true, "cmd": true,
-		"ps": true, "top": true, "htop": true, "netstat": true, "ss": true,
+		"ps": true, "top": true, "htop": true, "netstat": true, "ss": true, "sensors": true,
+		"systemd-detect-virt": true, "sysctl": true, "vm_stat": true,
 		"ip": true, "ifconfig": true, "route": true, "ping": true, "curl": true,
 		"wget": true, "git": true, "npm": true, "node": true, "go": true,
 		"python": true, "python3": true, "pip": true, "pip3": true,
@@ -35,7 +37,7 @@ var defaultSecurityPolicy = SecurityPolicy{
 		// Package managers
 		"apt": true, "apt-get": true, "yum": true, "dnf": true, "pacman": true,
 		"zypper": true, "brew": true, "port": true, "pkg": true, "pkgin": true,
-		"choco": true, "winget": true, "scoop": true,
+		"choco": true, "winget": true, "scoop": true, "dpkg-query": true,
 	},
 	BlockedPatterns: []string{
 		// Block dangerous operations even though these are read-only
@@ -64,15 +66,19 @@ var defaultSecurityPolicy = SecurityPolicy{
 
 // toolGetSystemInfo returns comprehensive system information
 func toolGetSystemInfo(args map[string]interface{}) (string, error) {
+	timeout := resolveProbeTimeout(args)
+	startTime := time.Now()
+
 	// Get all system information components
-	osInfo, _ := getOSInfo()
-	hardwareInfo, _ := getHardwareInfo()
+	osInfo, _ := getOSInfo(timeout)
+	hardwareInfo, _ := getHardwareInfo(timeout)
 	environmentInfo, _ := getEnvironmentInfo()
-	shellInfo, _ := getShellInfo()
-	devToolsInfo, _ := getDevelopmentToolsInfo()
-	networkInfo, _ := getNetworkInfo()
-	reposInfo, _ := detectRepositories()
-	recommendations := getSystemRecommendations(osInfo, hardwareInfo, devToolsInfo)
+	shellInfo, _ := getShellInfo(timeout)
+	devToolsInfo, _ := getDevelopmentToolsInfo(timeout)
+	networkInfo, _ := getNetworkInfo(timeout)
+	reposInfo, _ := detectRepositories(timeout)
+	runtimeEnv := detectEnvironment(timeout)
+	recommendations := getSystemRecommendations(osInfo, hardwareInfo, devToolsInfo, runtimeEnv)
 
 	systemInfo := SystemInfo{
 		Timestamp:      time.Now().UTC(),
@@ -83,11 +89,18 @@ func toolGetSystemInfo(args map[string]interface{}) (string, error) {
 		Development:    *devToolsInfo,
 		Networking:     *networkInfo,
 		Repositories:   reposInfo,
-		Recommendations: recommendations,
+		Recommendations: recommendationMessages(recommendations),
 	}
 
 	// Audit logging
-	auditLog("get_system_info", "", environmentInfo.WorkingDir, "", nil, nil, 0, true, 0, "")
+	auditLogEntry(AuditLog{
+		Timestamp:      time.Now().UTC(),
+		Operation:      "get_system_info",
+		WorkingDir:     environmentInfo.WorkingDir,
+		DurationMs:     time.Since(startTime).Milliseconds(),
+		Success:        true,
+		TimeoutSeconds: timeout,
+	})
 
 	// Return JSON result
 	resultJSON, err := json.Marshal(systemInfo)
@@ -99,13 +112,15 @@ func toolGetSystemInfo(args map[string]interface{}) (string, error) {
 
 // toolGetOSInfo returns operating system information
 func toolGetOSInfo(args map[string]interface{}) (string, error) {
-	osInfo, err := getOSInfo()
+	timeout := resolveProbeTimeout(args)
+	startTime := time.Now()
+	osInfo, err := getOSInfo(timeout)
 	if err != nil {
 		return "", fmt.Errorf("failed to get OS info: %w", err)
 	}
 
 	// Audit logging
-	auditLog("get_os_info", "", "", "", nil, nil, 0, true, 0, "")
+	auditLogWithTimeout("get_os_info", "", timeout, time.Since(startTime).Milliseconds(), true)
 
 	// Return JSON result
 	resultJSON, err := json.Marshal(osInfo)
@@ -117,13 +132,15 @@ func toolGetOSInfo(args map[string]interface{}) (string, error) {
 
 // toolGetHardwareInfo returns hardware information
 func toolGetHardwareInfo(args map[string]interface{}) (string, error) {
-	hardwareInfo, err := getHardwareInfo()
+	timeout := resolveProbeTimeout(args)
+	startTime := time.Now()
+	hardwareInfo, err := getHardwareInfo(timeout)
 	if err != nil {
 		return "", fmt.Errorf("failed to get hardware info: %w", err)
 	}
 
 	// Audit logging
-	auditLog("get_hardware_info", "", "", "", nil, nil, 0, true, 0, "")
+	auditLogWithTimeout("get_hardware_info", "", timeout, time.Since(startTime).Milliseconds(), true)
 
 	// Return JSON result
 	resultJSON, err := json.Marshal(hardwareInfo)
@@ -151,15 +168,52 @@ func toolGetEnvironmentInfo(args map[string]interface{}) (string, error) {
 	return string(resultJSON), nil
 }
 
+// toolGetEnvVar returns the value of a single environment variable. Variables that
+// isSensitiveEnvVar flags are refused rather than returned.
+func toolGetEnvVar(args map[string]interface{}) (string, error) {
+	name, _ := args["name"].(string)
+	if name == "" || strings.ContainsAny(name, "=\x00") {
+		return "", fmt.Errorf("name must be a non-empty environment variable name")
+	}
+
+	if isSensitiveEnvVar(name) {
+		security := &SecurityResult{
+			Valid:  false,
+			Reason: fmt.Sprintf("Environment variable may contain sensitive information: %s", name),
+			Rule:   "sensitive_env_var",
+		}
+		auditLog("get_env_var", name, "", "", nil, security, 0, false, -32001, "Security")
+		return "", fmt.Errorf("refusing to return sensitive environment variable: %s", name)
+	}
+
+	value, set := os.LookupEnv(name)
+
+	// Audit logging
+	auditLog("get_env_var", name, "", "", nil, nil, 0, true, 0, "")
+
+	// Return JSON result
+	resultJSON, err := json.Marshal(map[string]interface{}{
+		"name":  name,
+		"value": value,
+		"set":   set,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal environment variable: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
 // toolGetShellInfo returns shell information
 func toolGetShellInfo(args map[string]interface{}) (string, error) {
-	shellInfo, err := getShellInfo()
+	timeout := resolveProbeTimeout(args)
+	startTime := time.Now()
+	shellInfo, err := getShellInfo(timeout)
 	if err != nil {
 		return "", fmt.Errorf("failed to get shell info: %w", err)
 	}
 
 	// Audit logging
-	auditLog("get_shell_info", "", "", "", nil, nil, 0, true, 0, "")
+	auditLogWithTimeout("get_shell_info", "", timeout, time.Since(startTime).Milliseconds(), true)
 
 	// Return JSON result
 	resultJSON, err := json.Marshal(shellInfo)
@@ -171,13 +225,15 @@ func toolGetShellInfo(args map[string]interface{}) (string, error) {
 
 // toolGetDevelopmentTools returns development tools information
 func toolGetDevelopmentTools(args map[string]interface{}) (string, error) {
-	devToolsInfo, err := getDevelopmentToolsInfo()
+	timeout := resolveProbeTimeout(args)
+	startTime := time.Now()
+	devToolsInfo, err := getDevelopmentToolsInfo(timeout)
 	if err != nil {
 		return "", fmt.Errorf("failed to get development tools info: %w", err)
 	}
 
 	// Audit logging
-	auditLog("get_development_tools", "", "", "", nil, nil, 0, true, 0, "")
+	auditLogWithTimeout("get_development_tools", "", timeout, time.Since(startTime).Milliseconds(), true)
 
 	// Return JSON result
 	resultJSON, err := json.Marshal(devToolsInfo)
@@ -189,13 +245,15 @@ func toolGetDevelopmentTools(args map[string]interface{}) (string, error) {
 
 // toolGetNetworkInfo returns network information
 func toolGetNetworkInfo(args map[string]interface{}) (string, error) {
-	networkInfo, err := getNetworkInfo()
+	timeout := resolveProbeTimeout(args)
+	startTime := time.Now()
+	networkInfo, err := getNetworkInfo(timeout)
 	if err != nil {
 		return "", fmt.Errorf("failed to get network info: %w", err)
 	}
 
 	// Audit logging
-	auditLog("get_network_info", "", "", "", nil, nil, 0, true, 0, "")
+	auditLogWithTimeout("get_network_info", "", timeout, time.Since(startTime).Milliseconds(), true)
 
 	// Return JSON result
 	resultJSON, err := json.Marshal(networkInfo)
@@ -207,13 +265,15 @@ func toolGetNetworkInfo(args map[string]interface{}) (string, error) {
 
 // toolDetectRepositories detects version control repositories
 func toolDetectRepositories(args map[string]interface{}) (string, error) {
-	reposInfo, err := detectRepositories()
+	timeout := resolveProbeTimeout(args)
+	startTime := time.Now()
+	reposInfo, err := detectRepositories(timeout)
 	if err != nil {
 		return "", fmt.Errorf("failed to detect repositories: %w", err)
 	}
 
 	// Audit logging
-	auditLog("detect_repositories", "", "", "", nil, nil, 0, true, 0, "")
+	auditLogWithTimeout("detect_repositories", "", timeout, time.Since(startTime).Milliseconds(), true)
 
 	// Return JSON result
 	resultJSON, err := json.Marshal(reposInfo)
@@ -223,6 +283,13 @@ func toolDetectRepositories(args map[string]interface{}) (string, error) {
 	return string(resultJSON), nil
 }
 
+// commandNamePattern is the accepted format of command names checked by check_command and
+// check_commands
+var commandNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// maxCheckCommands caps the number of commands check_commands looks up in one call
+const maxCheckCommands = 100
+
 // toolCheckCommand checks if a command is available
 func toolCheckCommand(args map[string]interface{}) (string, error) {
 	// Extract and validate parameters
@@ -232,7 +299,7 @@ func toolCheckCommand(args map[string]interface{}) (string, error) {
 	}
 
 	// Validate command name format
-	if !regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`).MatchString(command) {
+	if !commandNamePattern.MatchString(command) {
 		return "", fmt.Errorf("invalid command name format: %s", command)
 	}
 
@@ -245,11 +312,16 @@ func toolCheckCommand(args map[string]interface{}) (string, error) {
 		}
 	}
 
+	includeVersion, _ := args["include_version"].(bool)
+	timeout := resolveProbeTimeout(args)
+
 	// Check if command exists
-	result := checkCommandExists(command, searchPaths)
+	startTime := time.Now()
+	result := checkCommandExists(command, searchPaths, includeVersion, timeout)
+	duration := time.Since(startTime).Milliseconds()
 
 	// Audit logging (read-only operation)
-	auditLog("check_command", command, "", "", nil, nil, 0, result.Exists, 0, "")
+	auditLogWithTimeout("check_command", command, timeout, duration, result.Exists)
 
 	// Return JSON result
 	resultJSON, err := json.Marshal(result)
@@ -259,20 +331,94 @@ func toolCheckCommand(args map[string]interface{}) (string, error) {
 	return string(resultJSON), nil
 }
 
-// toolGetRecommendations returns system-specific recommendations
+// toolCheckCommands checks several commands in one call and returns a map of command name to
+// its check_command result. Every name is validated before any is looked up.
+func toolCheckCommands(args map[string]interface{}) (string, error) {
+	rawCommands, ok := args["commands"].([]interface{})
+	if !ok || len(rawCommands) == 0 {
+		return "", fmt.Errorf("commands is required")
+	}
+	if len(rawCommands) > maxCheckCommands {
+		return "", fmt.Errorf("too many commands: %d (max %d)", len(rawCommands), maxCheckCommands)
+	}
+
+	commands := make([]string, 0, len(rawCommands))
+	for _, raw := range rawCommands {
+		command, ok := raw.(string)
+		if !ok || !commandNamePattern.MatchString(command) {
+			return "", fmt.Errorf("invalid command name format: %v", raw)
+		}
+		commands = append(commands, command)
+	}
+
+	var searchPaths []string
+	if sp, ok := args["search_paths"].([]interface{}); ok {
+		for _, path := range sp {
+			if p, ok := path.(string); ok {
+				searchPaths = append(searchPaths, p)
+			}
+		}
+	}
+
+	includeVersion, _ := args["include_version"].(bool)
+	timeout := resolveProbeTimeout(args)
+
+	startTime := time.Now()
+	results := make(map[string]*CommandExistsResult, len(commands))
+	allExist := true
+	for _, command := range commands {
+		if _, done := results[command]; done {
+			continue
+		}
+		results[command] = checkCommandExists(command, searchPaths, includeVersion, timeout)
+		allExist = allExist && results[command].Exists
+	}
+	duration := time.Since(startTime).Milliseconds()
+
+	// One audit entry for the batch, listing every command checked
+	auditLogWithTimeout("check_commands", strings.Join(commands, " "), timeout, duration, allExist)
+
+	resultJSON, err := json.Marshal(results)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal command check results: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// toolGetRecommendations returns system-specific recommendations, limited to the categories
+// param when it is given. recommendations keeps its original shape, a list of messages;
+// categorized holds the same recommendations tagged with their category.
 func toolGetRecommendations(args map[string]interface{}) (string, error) {
-	osInfo, _ := getOSInfo()
-	hardwareInfo, _ := getHardwareInfo()
-	devToolsInfo, _ := getDevelopmentToolsInfo()
+	var categories []string
+	if raw, ok := args["categories"].([]interface{}); ok {
+		for _, c := range raw {
+			category, ok := c.(string)
+			if !ok {
+				return "", fmt.Errorf("categories must be an array of strings")
+			}
+			categories = append(categories, strings.ToLower(category))
+		}
+	}
+
+	timeout := resolveProbeTimeout(args)
+	startTime := time.Now()
+	osInfo, _ := getOSInfo(timeout)
+	hardwareInfo, _ := getHardwareInfo(timeout)
+	devToolsInfo, _ := getDevelopmentToolsInfo(timeout)
+	runtimeEnv := detectEnvironment(timeout)
 
-	recommendations := getSystemRecommendations(osInfo, hardwareInfo, devToolsInfo)
+	recommendations, err := filterRecommendations(getSystemRecommendations(osInfo, hardwareInfo, devToolsInfo, runtimeEnv), categories)
+	if err != nil {
+		return "", err
+	}
 
 	// Audit logging
-	auditLog("get_recommendations", "", "", "", nil, nil, 0, true, 0, "")
+	auditLogWithTimeout("get_recommendations", "", timeout, time.Since(startTime).Milliseconds(), true)
 
 	// Return JSON result
 	resultJSON, err := json.Marshal(map[string]interface{}{
-		"recommendations": recommendations,
+		"recommendations": recommendationMessages(recommendations),
+		"categorized":     recommendations,
 	})
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal recommendations: %w", err)
@@ -280,8 +426,37 @@ func toolGetRecommendations(args map[string]interface{}) (string, error) {
 	return string(resultJSON), nil
 }
 
+// toolGetSecurityPolicy returns the effective security policy so clients can tell in
+// advance which commands will be allowed
+func toolGetSecurityPolicy(args map[string]interface{}) (string, error) {
+	allowedCommands := make([]string, 0, len(defaultSecurityPolicy.AllowedCommands))
+	for command, allowed := range defaultSecurityPolicy.AllowedCommands {
+		if allowed {
+			allowedCommands = append(allowedCommands, command)
+		}
+	}
+	sort.Strings(allowedCommands)
+
+	// Audit logging
+	auditLog("get_security_policy", "", "", "", nil, nil, 0, true, 0, "")
+
+	// Return JSON result
+	resultJSON, err := json.Marshal(map[string]interface{}{
+		"allowed_commands":   allowedCommands,
+		"blocked_patterns":   defaultSecurityPolicy.BlockedPatterns,
+		"max_command_len":    defaultSecurityPolicy.MaxCommandLen,
+		"default_timeout":    defaultSecurityPolicy.DefaultTimeout,
+		"max_timeout":        defaultSecurityPolicy.MaxTimeout,
+		"allow_shell_access": defaultSecurityPolicy.AllowShellAccess,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal security policy: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
 // getOSInfo gathers operating system information
-func getOSInfo() (*OSInfo, error) {
+func getOSInfo(timeout int) (*OSInfo, error) {
 	osInfo := &OSInfo{
 		Name:         runtime.GOOS,
 		Architecture: runtime.GOARCH,
@@ -289,15 +464,15 @@ func getOSInfo() (*OSInfo, error) {
 	}
 
 	if runtime.GOOS == "windows" {
-		return getWindowsOSInfo(osInfo)
+		return getWindowsOSInfo(osInfo, timeout)
 	} else {
-		return getUnixOSInfo(osInfo)
+		return getUnixOSInfo(osInfo, timeout)
 	}
 }
 
 // getWindowsOSInfo gets Windows-specific OS information
-func getWindowsOSInfo(osInfo *OSInfo) (*OSInfo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func getWindowsOSInfo(osInfo *OSInfo, timeout int) (*OSInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(timeout, 5*time.Second))
 	defer cancel()
 
 	// Try PowerShell first for detailed info
@@ -354,8 +529,8 @@ func getWindowsOSInfo(osInfo *OSInfo) (*OSInfo, error) {
 }
 
 // getUnixOSInfo gets Unix/Linux/macOS-specific OS information
-func getUnixOSInfo(osInfo *OSInfo) (*OSInfo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func getUnixOSInfo(osInfo *OSInfo, timeout int) (*OSInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(timeout, 5*time.Second))
 	defer cancel()
 
 	// Get detailed OS information using uname
@@ -462,39 +637,43 @@ func parseVersion(osInfo *OSInfo) {
 }
 
 // getHardwareInfo gathers hardware information
-func getHardwareInfo() (*HardwareInfo, error) {
+func getHardwareInfo(timeout int) (*HardwareInfo, error) {
 	hardwareInfo := &HardwareInfo{}
 
 	// Get CPU information
-	cpuInfo, _ := getCPUInfo()
+	cpuInfo, _ := getCPUInfo(timeout)
 	hardwareInfo.CPU = *cpuInfo
 
 	// Get memory information
-	memInfo, _ := getMemoryInfo()
+	memInfo, _ := getMemoryInfo(timeout)
 	hardwareInfo.Memory = *memInfo
 
 	// Get storage information
-	storageInfo, _ := getStorageInfo()
+	storageInfo, _ := getStorageInfo(timeout)
 	hardwareInfo.Storage = storageInfo
 
 	// Get display information (if available)
-	displayInfo, _ := getDisplayInfo()
+	displayInfo, _ := getDisplayInfo(timeout)
 	hardwareInfo.Displays = displayInfo
 
+	// Get GPU information (if available)
+	gpuInfo, _ := getGPUInfo(timeout)
+	hardwareInfo.GPU = gpuInfo
+
 	// Get network cards information (if available)
-	networkCardsInfo, _ := getNetworkCardsInfo()
+	networkCardsInfo, _ := getNetworkCardsInfo(timeout)
 	hardwareInfo.NetworkCards = networkCardsInfo
 
 	return hardwareInfo, nil
 }
 
 // getCPUInfo gathers CPU information
-func getCPUInfo() (*CPUInfo, error) {
+func getCPUInfo(timeout int) (*CPUInfo, error) {
 	cpuInfo := &CPUInfo{
 		Architecture: runtime.GOARCH,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(timeout, 5*time.Second))
 	defer cancel()
 
 	if runtime.GOOS == "windows" {
@@ -598,10 +777,10 @@ func getCPUInfo() (*CPUInfo, error) {
 }
 
 // getMemoryInfo gathers memory information
-func getMemoryInfo() (*MemoryInfo, error) {
+func getMemoryInfo(timeout int) (*MemoryInfo, error) {
 	memInfo := &MemoryInfo{}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(timeout, 5*time.Second))
 	defer cancel()
 
 	if runtime.GOOS == "windows" {
@@ -621,6 +800,25 @@ func getMemoryInfo() (*MemoryInfo, error) {
 				}
 			}
 		}
+	} else if runtime.GOOS == "darwin" {
+		// macOS has no /proc; the total comes from sysctl and the page counts from vm_stat
+		cmd := exec.CommandContext(ctx, "sysctl", "-n", "hw.memsize")
+		var stdout bytes.Buffer
+		cmd.Stdout = &stdout
+
+		if err := cmd.Run(); err == nil {
+			if total, err := strconv.ParseUint(strings.TrimSpace(stdout.String()), 10, 64); err == nil {
+				memInfo.Total = total
+			}
+		}
+
+		cmd = exec.CommandContext(ctx, "vm_stat")
+		stdout.Reset()
+		cmd.Stdout = &stdout
+
+		if err := cmd.Run(); err == nil {
+			memInfo.Free, memInfo.Available = parseVMStat(stdout.String())
+		}
 	} else {
 		// Unix/Linux memory info
 		if data, err := os.ReadFile("/proc/meminfo"); err == nil {
@@ -681,11 +879,41 @@ func getMemoryInfo() (*MemoryInfo, error) {
 	return memInfo, nil
 }
 
+// vmStatPageSizePattern matches vm_stat's header, e.g. "(page size of 16384 bytes)"
+var vmStatPageSizePattern = regexp.MustCompile(`page size of (\d+) bytes`)
+
+// parseVMStat returns free and available bytes from macOS vm_stat output, whose lines are
+// page counts such as "Pages free:   12345.". Speculative pages count as free, and inactive
+// pages as available since the kernel reclaims them on demand.
+func parseVMStat(output string) (free, available uint64) {
+	pageSize := uint64(4096)
+	if m := vmStatPageSizePattern.FindStringSubmatch(output); m != nil {
+		if size, err := strconv.ParseUint(m[1], 10, 64); err == nil {
+			pageSize = size
+		}
+	}
+
+	pages := make(map[string]uint64)
+	for _, line := range strings.Split(output, "\n") {
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		if count, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(value), "."), 10, 64); err == nil {
+			pages[strings.TrimSpace(name)] = count
+		}
+	}
+
+	free = (pages["Pages free"] + pages["Pages speculative"]) * pageSize
+	available = free + pages["Pages inactive"]*pageSize
+	return free, available
+}
+
 // getStorageInfo gathers storage/disk information
-func getStorageInfo() ([]StorageInfo, error) {
+func getStorageInfo(timeout int) ([]StorageInfo, error) {
 	var storageInfo []StorageInfo
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(timeout, 5*time.Second))
 	defer cancel()
 
 	if runtime.GOOS == "windows" {
@@ -759,18 +987,340 @@ func getStorageInfo() ([]StorageInfo, error) {
 	return storageInfo, nil
 }
 
-// getDisplayInfo gathers display information (basic implementation)
-func getDisplayInfo() ([]DisplayInfo, error) {
-	// This is a basic implementation - in a real scenario, you might want to use
-	// platform-specific APIs or libraries like xrandr (Linux) or DirectX (Windows)
-	return []DisplayInfo{}, nil
+// getDisplayInfo gathers connected display information, returning an empty slice when headless
+func getDisplayInfo(timeout int) ([]DisplayInfo, error) {
+	displays := []DisplayInfo{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(timeout, 5*time.Second))
+	defer cancel()
+
+	switch runtime.GOOS {
+	case "windows":
+		// Best effort: reports the current mode of each video controller
+		cmd := exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command", "Get-CimInstance -ClassName Win32_VideoController | Select-Object Name, CurrentHorizontalResolution, CurrentVerticalResolution, CurrentRefreshRate | ConvertTo-Json")
+		var stdout bytes.Buffer
+		cmd.Stdout = &stdout
+
+		if err := cmd.Run(); err == nil {
+			for _, result := range parsePowerShellJSONList(stdout.Bytes()) {
+				width, _ := result["CurrentHorizontalResolution"].(float64)
+				height, _ := result["CurrentVerticalResolution"].(float64)
+				if width == 0 || height == 0 {
+					continue
+				}
+				display := DisplayInfo{
+					ID:         len(displays),
+					Resolution: fmt.Sprintf("%dx%d", int(width), int(height)),
+					Primary:    len(displays) == 0,
+				}
+				if name, ok := result["Name"].(string); ok {
+					display.Name = name
+				}
+				if rate, ok := result["CurrentRefreshRate"].(float64); ok {
+					display.RefreshRate = rate
+				}
+				displays = append(displays, display)
+			}
+		}
+	case "darwin":
+		cmd := exec.CommandContext(ctx, "system_profiler", "SPDisplaysDataType")
+		var stdout bytes.Buffer
+		cmd.Stdout = &stdout
+
+		if err := cmd.Run(); err == nil {
+			displays = append(displays, parseSystemProfilerDisplays(stdout.String())...)
+		}
+	default:
+		// xrandr needs an X server (or XWayland); without one there are no displays to report
+		if os.Getenv("DISPLAY") == "" {
+			return displays, nil
+		}
+
+		cmd := exec.CommandContext(ctx, "xrandr", "--query")
+		var stdout bytes.Buffer
+		cmd.Stdout = &stdout
+
+		if err := cmd.Run(); err == nil {
+			displays = append(displays, parseXrandrDisplays(stdout.String())...)
+		}
+	}
+
+	return displays, nil
+}
+
+var (
+	xrandrGeometryPattern = regexp.MustCompile(`^(\d+)x(\d+)\+\d+\+\d+$`)
+	xrandrPhysicalPattern = regexp.MustCompile(`(\d+)mm x (\d+)mm`)
+	resolutionPattern     = regexp.MustCompile(`(\d+)\s*x\s*(\d+)`)
+	refreshRatePattern    = regexp.MustCompile(`@\s*([\d.]+)\s*Hz`)
+)
+
+// parseXrandrDisplays extracts connected outputs from `xrandr --query` output. The current
+// mode of each output is the mode line marked with '*'.
+func parseXrandrDisplays(output string) []DisplayInfo {
+	var displays []DisplayInfo
+	var current *DisplayInfo
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if !strings.HasPrefix(line, " ") {
+			if current != nil {
+				displays = append(displays, *current)
+				current = nil
+			}
+			if len(fields) < 2 || fields[1] != "connected" {
+				continue
+			}
+
+			current = &DisplayInfo{ID: len(displays), Name: fields[0]}
+			var width int
+			for _, field := range fields[2:] {
+				if field == "primary" {
+					current.Primary = true
+				}
+				if m := xrandrGeometryPattern.FindStringSubmatch(field); m != nil {
+					current.Resolution = m[1] + "x" + m[2]
+					width, _ = strconv.Atoi(m[1])
+				}
+			}
+			if m := xrandrPhysicalPattern.FindStringSubmatch(line); m != nil && width > 0 {
+				if widthMM, err := strconv.Atoi(m[1]); err == nil && widthMM > 0 {
+					current.DPI = int(float64(width) / (float64(widthMM) / 25.4))
+				}
+			}
+			continue
+		}
+
+		// Mode line, e.g. "   1920x1080     60.01*+  59.97"
+		if current == nil {
+			continue
+		}
+		for _, field := range fields[1:] {
+			if strings.Contains(field, "*") {
+				if rate, err := strconv.ParseFloat(strings.Trim(field, "*+"), 64); err == nil {
+					current.RefreshRate = rate
+				}
+				if current.Resolution == "" {
+					current.Resolution = fields[0]
+				}
+			}
+		}
+	}
+	if current != nil {
+		displays = append(displays, *current)
+	}
+	return displays
+}
+
+// parseSystemProfilerDisplays extracts displays from `system_profiler SPDisplaysDataType` output.
+// Displays are the named entries nested under each GPU's "Displays:" section.
+func parseSystemProfilerDisplays(output string) []DisplayInfo {
+	var displays []DisplayInfo
+	var current *DisplayInfo
+	displaysIndent := -1
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		if trimmed == "Displays:" {
+			displaysIndent = indent
+			continue
+		}
+		if displaysIndent < 0 {
+			continue
+		}
+		if indent <= displaysIndent {
+			// Left the Displays section, e.g. the next GPU
+			displaysIndent = -1
+			continue
+		}
+
+		key, value, _ := strings.Cut(trimmed, ":")
+		value = strings.TrimSpace(value)
+
+		if value == "" && strings.HasSuffix(trimmed, ":") {
+			if current != nil {
+				displays = append(displays, *current)
+			}
+			current = &DisplayInfo{ID: len(displays), Name: key}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		switch key {
+		case "Resolution", "UI Looks like":
+			if m := resolutionPattern.FindStringSubmatch(value); m != nil && (key == "Resolution" || current.Resolution == "") {
+				current.Resolution = m[1] + "x" + m[2]
+			}
+			if m := refreshRatePattern.FindStringSubmatch(value); m != nil {
+				if rate, err := strconv.ParseFloat(m[1], 64); err == nil {
+					current.RefreshRate = rate
+				}
+			}
+		case "Main Display":
+			current.Primary = value == "Yes"
+		}
+	}
+	if current != nil {
+		displays = append(displays, *current)
+	}
+	return displays
+}
+
+// getGPUInfo gathers graphics adapter information, returning an empty slice when none are found
+func getGPUInfo(timeout int) ([]GPUInfo, error) {
+	gpus := []GPUInfo{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(timeout, 5*time.Second))
+	defer cancel()
+
+	switch runtime.GOOS {
+	case "windows":
+		cmd := exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command", "Get-WmiObject -Class Win32_VideoController | Select-Object Name, AdapterCompatibility, AdapterRAM | ConvertTo-Json")
+		var stdout bytes.Buffer
+		cmd.Stdout = &stdout
+
+		if err := cmd.Run(); err == nil {
+			for _, result := range parsePowerShellJSONList(stdout.Bytes()) {
+				if name, ok := result["Name"].(string); ok {
+					gpu := GPUInfo{Name: name}
+					if vendor, ok := result["AdapterCompatibility"].(string); ok {
+						gpu.Vendor = vendor
+					}
+					if ram, ok := result["AdapterRAM"].(float64); ok && ram > 0 {
+						gpu.VRAMBytes = uint64(ram)
+					}
+					gpus = append(gpus, gpu)
+				}
+			}
+		}
+	case "darwin":
+		cmd := exec.CommandContext(ctx, "system_profiler", "SPDisplaysDataType")
+		var stdout bytes.Buffer
+		cmd.Stdout = &stdout
+
+		if err := cmd.Run(); err == nil {
+			gpus = append(gpus, parseSystemProfilerGPUs(stdout.String())...)
+		}
+	default:
+		cmd := exec.CommandContext(ctx, "lspci")
+		var stdout bytes.Buffer
+		cmd.Stdout = &stdout
+
+		if err := cmd.Run(); err == nil {
+			gpus = append(gpus, parseLspciGPUs(stdout.String())...)
+		}
+	}
+
+	return gpus, nil
+}
+
+var lspciRevisionPattern = regexp.MustCompile(`\s*\(rev [0-9a-f]+\)$`)
+
+// parseLspciGPUs extracts graphics adapters from lspci output, e.g.
+// "00:02.0 VGA compatible controller: Intel Corporation UHD Graphics 620 (rev 07)"
+func parseLspciGPUs(output string) []GPUInfo {
+	var gpus []GPUInfo
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.Contains(line, "VGA compatible controller") && !strings.Contains(line, "3D controller") {
+			continue
+		}
+		_, description, found := strings.Cut(line, ": ")
+		if !found {
+			continue
+		}
+		name := strings.TrimSpace(lspciRevisionPattern.ReplaceAllString(description, ""))
+		gpu := GPUInfo{Name: name}
+		if fields := strings.Fields(name); len(fields) > 0 {
+			gpu.Vendor = fields[0]
+		}
+		gpus = append(gpus, gpu)
+	}
+	return gpus
+}
+
+// parseSystemProfilerGPUs extracts graphics adapters from `system_profiler SPDisplaysDataType` output
+func parseSystemProfilerGPUs(output string) []GPUInfo {
+	var gpus []GPUInfo
+	var current *GPUInfo
+
+	for _, line := range strings.Split(output, "\n") {
+		key, value, found := strings.Cut(strings.TrimSpace(line), ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch {
+		case key == "Chipset Model":
+			if current != nil {
+				gpus = append(gpus, *current)
+			}
+			current = &GPUInfo{Name: value}
+		case current == nil:
+			continue
+		case key == "Vendor":
+			current.Vendor = strings.TrimSpace(strings.Split(value, "(")[0])
+		case strings.HasPrefix(key, "VRAM"):
+			current.VRAMBytes = parseSizeToBytes(value)
+		}
+	}
+	if current != nil {
+		gpus = append(gpus, *current)
+	}
+	return gpus
+}
+
+// parseSizeToBytes converts sizes like "1536 MB" or "8 GB" to bytes, returning 0 if unparseable
+func parseSizeToBytes(size string) uint64 {
+	fields := strings.Fields(size)
+	if len(fields) < 2 {
+		return 0
+	}
+	value, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return 0
+	}
+	switch strings.ToUpper(fields[1]) {
+	case "KB":
+		return value * 1024
+	case "MB":
+		return value * 1024 * 1024
+	case "GB":
+		return value * 1024 * 1024 * 1024
+	}
+	return 0
+}
+
+// parsePowerShellJSONList decodes ConvertTo-Json output, which is a single object rather than
+// an array when the pipeline yields only one item
+func parsePowerShellJSONList(data []byte) []map[string]interface{} {
+	var results []map[string]interface{}
+	if json.Unmarshal(data, &results) == nil {
+		return results
+	}
+	var result map[string]interface{}
+	if json.Unmarshal(data, &result) == nil {
+		return []map[string]interface{}{result}
+	}
+	return nil
 }
 
 // getNetworkCardsInfo gathers network card information
-func getNetworkCardsInfo() ([]NetworkCardInfo, error) {
+func getNetworkCardsInfo(timeout int) ([]NetworkCardInfo, error) {
 	var networkCards []NetworkCardInfo
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(timeout, 5*time.Second))
 	defer cancel()
 
 	if runtime.GOOS == "windows" {
@@ -0,0 +1,623 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestGetGPUInfo tests that GPU detection succeeds on the current platform, even without a GPU
+func TestGetGPUInfo(t *testing.T) {
+	gpus, err := getGPUInfo(defaultSecurityPolicy.DefaultTimeout)
+	if err != nil {
+		t.Fatalf("getGPUInfo(defaultSecurityPolicy.DefaultTimeout) error = %v", err)
+	}
+	if gpus == nil {
+		t.Error("Expected an empty slice rather than nil when no GPUs are found")
+	}
+
+	hardwareInfo, err := getHardwareInfo(defaultSecurityPolicy.DefaultTimeout)
+	if err != nil {
+		t.Fatalf("getHardwareInfo(defaultSecurityPolicy.DefaultTimeout) error = %v", err)
+	}
+	data, err := json.Marshal(hardwareInfo)
+	if err != nil {
+		t.Fatalf("Failed to marshal hardware info: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to parse hardware info: %v", err)
+	}
+	if _, ok := decoded["gpu"].([]interface{}); !ok {
+		t.Errorf("Expected gpu array in hardware info, got %v", decoded["gpu"])
+	}
+}
+
+// TestParseLspciGPUs tests extracting graphics adapters from lspci output
+func TestParseLspciGPUs(t *testing.T) {
+	output := `00:00.0 Host bridge: Intel Corporation Xeon E3-1200 v6/7th Gen Core Processor Host Bridge/DRAM Registers (rev 08)
+00:02.0 VGA compatible controller: Intel Corporation UHD Graphics 620 (rev 07)
+01:00.0 3D controller: NVIDIA Corporation GP108M [GeForce MX150] (rev a1)
+`
+	gpus := parseLspciGPUs(output)
+	if len(gpus) != 2 {
+		t.Fatalf("Expected 2 GPUs, got %d", len(gpus))
+	}
+	if gpus[0].Name != "Intel Corporation UHD Graphics 620" || gpus[0].Vendor != "Intel" {
+		t.Errorf("Unexpected first GPU: %+v", gpus[0])
+	}
+	if gpus[1].Vendor != "NVIDIA" {
+		t.Errorf("Unexpected second GPU: %+v", gpus[1])
+	}
+}
+
+// TestParseSystemProfilerGPUs tests extracting graphics adapters from system_profiler output
+func TestParseSystemProfilerGPUs(t *testing.T) {
+	output := `Graphics/Displays:
+
+    Intel Iris Plus Graphics 655:
+
+      Chipset Model: Intel Iris Plus Graphics 655
+      Type: GPU
+      Bus: Built-In
+      VRAM (Dynamic, Max): 1536 MB
+      Vendor: Intel
+      Device ID: 0x3ea5
+`
+	gpus := parseSystemProfilerGPUs(output)
+	if len(gpus) != 1 {
+		t.Fatalf("Expected 1 GPU, got %d", len(gpus))
+	}
+	if gpus[0].Name != "Intel Iris Plus Graphics 655" || gpus[0].Vendor != "Intel" || gpus[0].VRAMBytes != 1536*1024*1024 {
+		t.Errorf("Unexpected GPU: %+v", gpus[0])
+	}
+}
+
+// TestParsePowerShellJSONList tests decoding both single-object and array ConvertTo-Json output
+func TestParsePowerShellJSONList(t *testing.T) {
+	if got := parsePowerShellJSONList([]byte(`{"Name":"GPU"}`)); len(got) != 1 {
+		t.Errorf("Expected 1 item for a single object, got %d", len(got))
+	}
+	if got := parsePowerShellJSONList([]byte(`[{"Name":"A"},{"Name":"B"}]`)); len(got) != 2 {
+		t.Errorf("Expected 2 items for an array, got %d", len(got))
+	}
+	if got := parsePowerShellJSONList([]byte(`not json`)); len(got) != 0 {
+		t.Errorf("Expected no items for invalid output, got %d", len(got))
+	}
+}
+
+// TestGetDisplayInfoHeadless tests that display detection succeeds without a display attached
+func TestGetDisplayInfoHeadless(t *testing.T) {
+	t.Setenv("DISPLAY", "")
+
+	displays, err := getDisplayInfo(defaultSecurityPolicy.DefaultTimeout)
+	if err != nil {
+		t.Fatalf("getDisplayInfo(defaultSecurityPolicy.DefaultTimeout) error = %v", err)
+	}
+	if displays == nil {
+		t.Error("Expected an empty slice rather than nil when headless")
+	}
+}
+
+// TestParseXrandrDisplays tests extracting connected outputs and their current mode from xrandr
+func TestParseXrandrDisplays(t *testing.T) {
+	output := `Screen 0: minimum 8 x 8, current 3840 x 1080, maximum 32767 x 32767
+eDP-1 connected primary 1920x1080+0+0 (normal left inverted right x axis y axis) 344mm x 194mm
+   1920x1080     60.01*+  59.97    59.96
+   1680x1050     59.95    59.88
+HDMI-1 disconnected (normal left inverted right x axis y axis)
+DP-1 connected 1920x1080+1920+0 (normal left inverted right x axis y axis) 527mm x 296mm
+   1920x1080     60.00 +  74.97*
+`
+	displays := parseXrandrDisplays(output)
+	if len(displays) != 2 {
+		t.Fatalf("Expected 2 connected displays, got %d", len(displays))
+	}
+
+	if d := displays[0]; d.Name != "eDP-1" || d.Resolution != "1920x1080" || d.RefreshRate != 60.01 || !d.Primary || d.DPI != 141 {
+		t.Errorf("Unexpected first display: %+v", d)
+	}
+	if d := displays[1]; d.Name != "DP-1" || d.ID != 1 || d.RefreshRate != 74.97 || d.Primary {
+		t.Errorf("Unexpected second display: %+v", d)
+	}
+}
+
+// TestParseSystemProfilerDisplays tests extracting displays from system_profiler output
+func TestParseSystemProfilerDisplays(t *testing.T) {
+	output := `Graphics/Displays:
+
+    Apple M1:
+
+      Chipset Model: Apple M1
+      Type: GPU
+      Displays:
+        Color LCD:
+          Display Type: Built-In Retina LCD
+          Resolution: 2560 x 1600 Retina
+          Main Display: Yes
+          UI Looks like: 1440 x 900 @ 60.00Hz
+        LG HDR 4K:
+          Resolution: 3840 x 2160 (2160p/4K UHD 1 - Ultra High Definition)
+          UI Looks like: 1920 x 1080 @ 30.00Hz
+
+    Other GPU:
+
+      Chipset Model: Other
+`
+	displays := parseSystemProfilerDisplays(output)
+	if len(displays) != 2 {
+		t.Fatalf("Expected 2 displays, got %d: %+v", len(displays), displays)
+	}
+	if d := displays[0]; d.Name != "Color LCD" || d.Resolution != "2560x1600" || d.RefreshRate != 60 || !d.Primary {
+		t.Errorf("Unexpected first display: %+v", d)
+	}
+	if d := displays[1]; d.Name != "LG HDR 4K" || d.Resolution != "3840x2160" || d.RefreshRate != 30 || d.Primary {
+		t.Errorf("Unexpected second display: %+v", d)
+	}
+}
+
+// TestToolCheckCommandVersion tests that check_command only reports a version when asked
+func TestToolCheckCommandVersion(t *testing.T) {
+	origAuditEnabled := auditEnabled
+	auditEnabled = false
+	defer func() { auditEnabled = origAuditEnabled }()
+
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not found in PATH")
+	}
+
+	tests := []struct {
+		name        string
+		args        map[string]interface{}
+		wantExists  bool
+		wantVersion bool
+	}{
+		{"go without version", map[string]interface{}{"command": "go"}, true, false},
+		{"go with version", map[string]interface{}{"command": "go", "include_version": true}, true, true},
+		{"nonexistent command", map[string]interface{}{"command": "definitely-not-a-real-command-xyz", "include_version": true}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := toolCheckCommand(tt.args)
+			if err != nil {
+				t.Fatalf("toolCheckCommand() error = %v", err)
+			}
+
+			var check CommandExistsResult
+			if err := json.Unmarshal([]byte(result), &check); err != nil {
+				t.Fatalf("Failed to parse result: %v", err)
+			}
+			if check.Exists != tt.wantExists {
+				t.Errorf("Exists = %v, want %v", check.Exists, tt.wantExists)
+			}
+			if tt.wantVersion && !regexp.MustCompile(`^\d+\.\d+`).MatchString(check.Version) {
+				t.Errorf("Expected a parsed version number, got %q", check.Version)
+			}
+			if !tt.wantVersion && check.Version != "" {
+				t.Errorf("Expected no version, got %q", check.Version)
+			}
+		})
+	}
+}
+
+// TestCheckCommandSearchPathsNotExecuted tests that a binary found in search_paths is reported
+// but its version flag is never run
+func TestCheckCommandSearchPathsNotExecuted(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	origAuditEnabled := auditEnabled
+	auditEnabled = false
+	defer func() { auditEnabled = origAuditEnabled }()
+
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "ran")
+	script := "#!/bin/sh\ntouch " + marker + "\necho 'go version go9.9.9 fake/arch'\n"
+	if err := os.WriteFile(filepath.Join(dir, "go"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := toolCheckCommand(map[string]interface{}{"command": "go", "search_paths": []interface{}{dir}, "include_version": true})
+	if err != nil {
+		t.Fatalf("toolCheckCommand() error = %v", err)
+	}
+	var check CommandExistsResult
+	if err := json.Unmarshal([]byte(result), &check); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if !check.Exists || check.Path != filepath.Join(dir, "go") {
+		t.Errorf("Expected the search_paths binary to be reported, got %+v", check)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("Expected the search_paths binary not to be executed")
+	}
+	if check.Version == "9.9.9" {
+		t.Error("Expected the version not to come from the search_paths binary")
+	}
+}
+
+// TestToolCheckCommands tests checking several commands in one call
+func TestToolCheckCommands(t *testing.T) {
+	origAuditEnabled := auditEnabled
+	auditEnabled = false
+	defer func() { auditEnabled = origAuditEnabled }()
+
+	for _, command := range []string{"go", "git"} {
+		if _, err := exec.LookPath(command); err != nil {
+			t.Skipf("%s not found in PATH", command)
+		}
+	}
+
+	result, err := toolCheckCommands(map[string]interface{}{
+		"commands": []interface{}{"go", "git", "definitely-not-a-command"},
+	})
+	if err != nil {
+		t.Fatalf("toolCheckCommands() error = %v", err)
+	}
+
+	var checks map[string]CommandExistsResult
+	if err := json.Unmarshal([]byte(result), &checks); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if len(checks) != 3 {
+		t.Fatalf("Expected 3 results, got %d: %s", len(checks), result)
+	}
+	for _, command := range []string{"go", "git"} {
+		if !checks[command].Exists || checks[command].Path == "" {
+			t.Errorf("Expected %s to exist with a path, got %+v", command, checks[command])
+		}
+	}
+	if missing := checks["definitely-not-a-command"]; missing.Exists || missing.Path != "" {
+		t.Errorf("Expected definitely-not-a-command not to exist, got %+v", missing)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{},
+		{"commands": []interface{}{}},
+		{"commands": []interface{}{"go", "rm -rf /"}},
+		{"commands": []interface{}{"go", 42.0}},
+	} {
+		if _, err := toolCheckCommands(args); err == nil {
+			t.Errorf("Expected an error for %v", args)
+		}
+	}
+}
+
+// TestParseVersionNumber tests extracting version numbers from tool output
+func TestParseVersionNumber(t *testing.T) {
+	tests := map[string]string{
+		"go1.24.1":                             "1.24.1",
+		"v20.11.0":                             "20.11.0",
+		"Python 3.12.2":                        "3.12.2",
+		"Docker version 24.0.7, build afdd53b": "24.0.7",
+		"unknown":                              "unknown",
+	}
+	for input, want := range tests {
+		if got := parseVersionNumber(input); got != want {
+			t.Errorf("parseVersionNumber(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+// TestResolveTimeout tests that timeout_seconds is clamped to [1, MaxTimeout]
+func TestResolveTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		want int
+	}{
+		{"default", map[string]interface{}{}, defaultSecurityPolicy.DefaultTimeout},
+		{"within range", map[string]interface{}{"timeout_seconds": float64(20)}, 20},
+		{"above max is clamped", map[string]interface{}{"timeout_seconds": float64(3600)}, defaultSecurityPolicy.MaxTimeout},
+		{"below min is clamped", map[string]interface{}{"timeout_seconds": float64(0)}, 1},
+		{"wrong type uses default", map[string]interface{}{"timeout_seconds": "60"}, defaultSecurityPolicy.DefaultTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveTimeout(tt.args); got != tt.want {
+				t.Errorf("resolveTimeout() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestProbeTimeout tests that probes keep their own limits unless timeout_seconds is given
+func TestProbeTimeout(t *testing.T) {
+	if got := resolveProbeTimeout(map[string]interface{}{}); got != 0 {
+		t.Errorf("Expected no override without timeout_seconds, got %d", got)
+	}
+	if got := resolveProbeTimeout(map[string]interface{}{"timeout_seconds": float64(3600)}); got != defaultSecurityPolicy.MaxTimeout {
+		t.Errorf("Expected an override clamped to %d, got %d", defaultSecurityPolicy.MaxTimeout, got)
+	}
+	if got := probeTimeout(0, 3*time.Second); got != 3*time.Second {
+		t.Errorf("Expected the probe's own 3s without an override, got %v", got)
+	}
+	if got := probeTimeout(20, 3*time.Second); got != 20*time.Second {
+		t.Errorf("Expected the 20s override, got %v", got)
+	}
+}
+
+// TestCheckCommandAuditsEffectiveTimeout tests that an over-limit timeout is clamped in the audit log
+func TestCheckCommandAuditsEffectiveTimeout(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "test-audit.log")
+	t.Setenv("MCP_SYSTEMINFO_AUDIT_FILE", tempFile)
+
+	if err := InitAuditLogger(); err != nil {
+		t.Fatalf("InitAuditLogger() failed: %v", err)
+	}
+	defer CloseAuditLogger()
+
+	if _, err := toolCheckCommand(map[string]interface{}{"command": "sh", "timeout_seconds": float64(3600)}); err != nil {
+		t.Fatalf("toolCheckCommand() error = %v", err)
+	}
+
+	data, err := os.ReadFile(tempFile)
+	if err != nil {
+		t.Fatalf("Failed to read audit file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &entry); err != nil {
+		t.Fatalf("Failed to parse audit entry: %v", err)
+	}
+	if entry["operation"] != "check_command" {
+		t.Fatalf("Expected check_command audit entry, got %v", entry["operation"])
+	}
+	if got, ok := entry["timeout_seconds"].(float64); !ok || int(got) != defaultSecurityPolicy.MaxTimeout {
+		t.Errorf("Expected audited timeout_seconds = %d, got %v", defaultSecurityPolicy.MaxTimeout, entry["timeout_seconds"])
+	}
+}
+
+// TestInfoOperationsAuditTimeout tests that the information operations running commands
+// take timeout_seconds and record it in the audit log
+func TestInfoOperationsAuditTimeout(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "test-audit.log")
+	t.Setenv("MCP_SYSTEMINFO_AUDIT_FILE", tempFile)
+
+	if err := InitAuditLogger(); err != nil {
+		t.Fatalf("InitAuditLogger() failed: %v", err)
+	}
+	defer CloseAuditLogger()
+
+	for _, op := range []struct {
+		name    string
+		handler func(map[string]interface{}) (string, error)
+	}{
+		{"get_os_info", toolGetOSInfo},
+		{"get_shell_info", toolGetShellInfo},
+		{"detect_repositories", toolDetectRepositories},
+	} {
+		if _, err := op.handler(map[string]interface{}{"timeout_seconds": float64(2)}); err != nil {
+			t.Fatalf("%s error = %v", op.name, err)
+		}
+
+		data, err := os.ReadFile(tempFile)
+		if err != nil {
+			t.Fatalf("Failed to read audit file: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[len(lines)-1]), &entry); err != nil {
+			t.Fatalf("Failed to parse audit entry: %v", err)
+		}
+		if entry["operation"] != op.name {
+			t.Fatalf("Expected %s audit entry, got %v", op.name, entry["operation"])
+		}
+		if got, ok := entry["timeout_seconds"].(float64); !ok || got != 2 {
+			t.Errorf("Expected %s to audit timeout_seconds = 2, got %v", op.name, entry["timeout_seconds"])
+		}
+	}
+}
+
+// TestToolGetSecurityPolicy tests that the effective policy is returned with known allowlist entries
+func TestToolGetSecurityPolicy(t *testing.T) {
+	origAuditEnabled := auditEnabled
+	auditEnabled = false
+	defer func() { auditEnabled = origAuditEnabled }()
+
+	result, err := toolGetSecurityPolicy(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("toolGetSecurityPolicy() error = %v", err)
+	}
+
+	var policy struct {
+		AllowedCommands  []string `json:"allowed_commands"`
+		BlockedPatterns  []string `json:"blocked_patterns"`
+		DefaultTimeout   int      `json:"default_timeout"`
+		MaxTimeout       int      `json:"max_timeout"`
+		AllowShellAccess bool     `json:"allow_shell_access"`
+	}
+	if err := json.Unmarshal([]byte(result), &policy); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+
+	for _, want := range []string{"go", "git"} {
+		found := false
+		for _, command := range policy.AllowedCommands {
+			if command == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected %q in allowed_commands", want)
+		}
+	}
+	if len(policy.BlockedPatterns) != len(defaultSecurityPolicy.BlockedPatterns) {
+		t.Errorf("Expected %d blocked patterns, got %d", len(defaultSecurityPolicy.BlockedPatterns), len(policy.BlockedPatterns))
+	}
+	if policy.DefaultTimeout != defaultSecurityPolicy.DefaultTimeout || policy.MaxTimeout != defaultSecurityPolicy.MaxTimeout {
+		t.Errorf("Unexpected timeouts: default=%d max=%d", policy.DefaultTimeout, policy.MaxTimeout)
+	}
+	if policy.AllowShellAccess != defaultSecurityPolicy.AllowShellAccess {
+		t.Errorf("Unexpected allow_shell_access: %v", policy.AllowShellAccess)
+	}
+}
+
+// TestGetMemoryInfoDarwin tests that macOS reports its memory size
+func TestGetMemoryInfoDarwin(t *testing.T) {
+	if runtime.GOOS != "darwin" {
+		t.Skip("sysctl and vm_stat are only used on macOS")
+	}
+
+	memInfo, err := getMemoryInfo(defaultSecurityPolicy.DefaultTimeout)
+	if err != nil {
+		t.Fatalf("getMemoryInfo(defaultSecurityPolicy.DefaultTimeout) error = %v", err)
+	}
+	if memInfo.Total == 0 {
+		t.Error("Expected a non-zero total from sysctl hw.memsize")
+	}
+	if memInfo.UsagePercent <= 0 || memInfo.UsagePercent > 100 {
+		t.Errorf("Expected a usage percent in (0, 100], got %v", memInfo.UsagePercent)
+	}
+}
+
+// TestParseVMStat tests converting vm_stat page counts to bytes
+func TestParseVMStat(t *testing.T) {
+	output := `Mach Virtual Memory Statistics: (page size of 16384 bytes)
+Pages free:                               10000.
+Pages active:                            200000.
+Pages inactive:                          150000.
+Pages speculative:                         5000.
+Pages wired down:                         80000.
+"Translation faults":                  12345678.
+`
+	free, available := parseVMStat(output)
+	if free != 15000*16384 {
+		t.Errorf("free = %d, want %d", free, 15000*16384)
+	}
+	if available != 165000*16384 {
+		t.Errorf("available = %d, want %d", available, 165000*16384)
+	}
+}
+
+// TestToolGetEnvVar tests reading a set variable, an unset variable and a refused sensitive one
+func TestToolGetEnvVar(t *testing.T) {
+	origAuditEnabled := auditEnabled
+	auditEnabled = false
+	defer func() { auditEnabled = origAuditEnabled }()
+
+	t.Setenv("MCP_TEST_EDITOR", "vim")
+	os.Unsetenv("MCP_TEST_UNSET_VAR")
+
+	tests := []struct {
+		name      string
+		wantValue string
+		wantSet   bool
+	}{
+		{"MCP_TEST_EDITOR", "vim", true},
+		{"MCP_TEST_UNSET_VAR", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := toolGetEnvVar(map[string]interface{}{"name": tt.name})
+			if err != nil {
+				t.Fatalf("toolGetEnvVar() error = %v", err)
+			}
+
+			var decoded struct {
+				Name  string `json:"name"`
+				Value string `json:"value"`
+				Set   bool   `json:"set"`
+			}
+			if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+				t.Fatalf("Failed to parse result: %v", err)
+			}
+			if decoded.Name != tt.name || decoded.Value != tt.wantValue || decoded.Set != tt.wantSet {
+				t.Errorf("Got %+v, want value %q set %v", decoded, tt.wantValue, tt.wantSet)
+			}
+		})
+	}
+
+	t.Setenv("MCP_TEST_API_TOKEN", "s3cr3t")
+	result, err := toolGetEnvVar(map[string]interface{}{"name": "MCP_TEST_API_TOKEN"})
+	if err == nil || !strings.Contains(err.Error(), "sensitive") {
+		t.Errorf("Expected a sensitive variable error, got %v", err)
+	}
+	if strings.Contains(result, "s3cr3t") {
+		t.Errorf("Sensitive value leaked in result: %s", result)
+	}
+
+	if _, err := toolGetEnvVar(map[string]interface{}{}); err == nil {
+		t.Error("Expected an error for a missing name")
+	}
+}
+
+// TestFilterRecommendations tests that a hardware-only filter keeps the hardware advice and
+// drops the tool advice, and that no filter keeps every recommendation
+func TestFilterRecommendations(t *testing.T) {
+	hardware := &HardwareInfo{Memory: MemoryInfo{UsagePercent: 95}}
+	recommendations := getSystemRecommendations(nil, hardware, &DevelopmentToolsInfo{}, nil)
+
+	hardwareOnly, err := filterRecommendations(recommendations, []string{"hardware"})
+	if err != nil {
+		t.Fatalf("filterRecommendations() error = %v", err)
+	}
+	if len(hardwareOnly) != 1 || !strings.Contains(hardwareOnly[0].Message, "memory") {
+		t.Errorf("Expected only the memory recommendation, got %+v", hardwareOnly)
+	}
+	for _, r := range hardwareOnly {
+		if r.Category != recommendationHardware || strings.Contains(r.Message, "Consider installing") {
+			t.Errorf("Expected no tool advice in the hardware filter, got %+v", r)
+		}
+	}
+
+	all, err := filterRecommendations(recommendations, nil)
+	if err != nil || len(all) != len(recommendations) {
+		t.Errorf("Expected all %d recommendations without a filter, got %d (%v)", len(recommendations), len(all), err)
+	}
+	hasTools := false
+	for _, r := range all {
+		hasTools = hasTools || (r.Category == recommendationTools && strings.Contains(r.Message, "Consider installing"))
+	}
+	if !hasTools {
+		t.Errorf("Expected tool advice without a filter, got %+v", all)
+	}
+
+	if _, err := filterRecommendations(recommendations, []string{"hardwear"}); err == nil {
+		t.Error("Expected an error for an unknown category")
+	}
+}
+
+// TestToolGetRecommendationsCategories tests that every returned recommendation is tagged
+// with one of the requested categories
+func TestToolGetRecommendationsCategories(t *testing.T) {
+	origAuditEnabled := auditEnabled
+	auditEnabled = false
+	defer func() { auditEnabled = origAuditEnabled }()
+
+	resultJSON, err := toolGetRecommendations(map[string]interface{}{"categories": []interface{}{"Hardware", "general"}})
+	if err != nil {
+		t.Fatalf("toolGetRecommendations() error = %v", err)
+	}
+	var result struct {
+		Recommendations []string         `json:"recommendations"`
+		Categorized     []Recommendation `json:"categorized"`
+	}
+	if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if len(result.Categorized) == 0 {
+		t.Fatal("Expected the general recommendations at least")
+	}
+	if len(result.Recommendations) != len(result.Categorized) || result.Recommendations[0] != result.Categorized[0].Message {
+		t.Errorf("Expected recommendations to list the messages of categorized, got %v and %+v", result.Recommendations, result.Categorized)
+	}
+	for _, r := range result.Categorized {
+		if r.Category != recommendationHardware && r.Category != recommendationGeneral {
+			t.Errorf("Expected only hardware and general recommendations, got %+v", r)
+		}
+	}
+
+	if _, err := toolGetRecommendations(map[string]interface{}{"categories": []interface{}{1}}); err == nil {
+		t.Error("Expected an error for a non-string category")
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// processStart is when the server started. It carries a monotonic clock reading, so
+// uptime measured from it is not affected by changes to the wall clock.
+var processStart = time.Now()
+
+// toolGetTime reports the server's clock in UTC and local time, its timezone and UTC
+// offset, and how long the server has been running, so timestamps from the machine can be
+// interpreted. The timezone is the IANA name when TZ or the system names one, otherwise
+// the zone abbreviation.
+func toolGetTime(args map[string]interface{}) (string, error) {
+	now := time.Now()
+	local := now.Local()
+	abbreviation, offset := local.Zone()
+
+	timezone := time.Local.String()
+	if timezone == "Local" || timezone == "" {
+		timezone = abbreviation
+	}
+
+	// Audit logging
+	auditLog("get_time", "", "", "", nil, nil, 0, true, 0, "")
+
+	// Return JSON result
+	resultJSON, err := json.Marshal(map[string]interface{}{
+		"utc":               now.UTC().Format(time.RFC3339Nano),
+		"local":             local.Format(time.RFC3339Nano),
+		"timezone":          timezone,
+		"zone_abbreviation": abbreviation,
+		"offset_seconds":    offset,
+		"unix_ms":           now.UnixMilli(),
+		"monotonic_uptime":  now.Sub(processStart).Seconds(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal time: %w", err)
+	}
+	return string(resultJSON), nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// TestGetTime tests that the UTC and local times are the same instant, differing in wall
+// clock by the reported offset, and that uptime is positive
+func TestGetTime(t *testing.T) {
+	origAuditEnabled := auditEnabled
+	auditEnabled = false
+	defer func() { auditEnabled = origAuditEnabled }()
+
+	resultJSON, err := toolGetTime(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("toolGetTime() error = %v", err)
+	}
+	var result struct {
+		UTC             string  `json:"utc"`
+		Local           string  `json:"local"`
+		Timezone        string  `json:"timezone"`
+		OffsetSeconds   int     `json:"offset_seconds"`
+		MonotonicUptime float64 `json:"monotonic_uptime"`
+	}
+	if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+
+	utc, err := time.Parse(time.RFC3339Nano, result.UTC)
+	if err != nil {
+		t.Fatalf("utc %q is not RFC 3339: %v", result.UTC, err)
+	}
+	local, err := time.Parse(time.RFC3339Nano, result.Local)
+	if err != nil {
+		t.Fatalf("local %q is not RFC 3339: %v", result.Local, err)
+	}
+
+	if !utc.Equal(local) {
+		t.Errorf("Expected utc %s and local %s to be the same instant", result.UTC, result.Local)
+	}
+	if _, offset := utc.Zone(); offset != 0 {
+		t.Errorf("Expected utc to have no offset, got %d", offset)
+	}
+	if _, offset := local.Zone(); offset != result.OffsetSeconds {
+		t.Errorf("Expected local to carry offset_seconds %d, got %d", result.OffsetSeconds, offset)
+	}
+	// The local wall clock minus the offset is the UTC wall clock
+	localWall := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), time.UTC)
+	if !localWall.Add(-time.Duration(result.OffsetSeconds) * time.Second).Equal(utc) {
+		t.Errorf("Expected local %s minus %ds to be utc %s", result.Local, result.OffsetSeconds, result.UTC)
+	}
+
+	if result.Timezone == "" || result.MonotonicUptime <= 0 {
+		t.Errorf("Expected a timezone and a positive uptime, got %+v", result)
+	}
+}
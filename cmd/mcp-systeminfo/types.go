@@ -1,61 +1,23 @@
 package main
 
 import (
-	"encoding/json"
 	"time"
-)
-
-// MCP protocol types
-type MCPMessage struct {
-	JSONRPC string          `json:"jsonrpc"`
-	ID      interface{}     `json:"id,omitempty"`
-	Method  string          `json:"method,omitempty"`
-	Params  json.RawMessage `json:"params,omitempty"`
-	Result  interface{}     `json:"result,omitempty"`
-	Error   *MCPError       `json:"error,omitempty"`
-}
-
-type MCPError struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-}
-
-type InitializeResponse struct {
-	ProtocolVersion string                 `json:"protocolVersion"`
-	Capabilities    map[string]interface{} `json:"capabilities"`
-	ServerInfo      ServerInfo             `json:"serverInfo"`
-}
-
-type ServerInfo struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
-}
 
-type Tool struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	InputSchema map[string]interface{} `json:"inputSchema"`
-}
-
-type ToolsListResponse struct {
-	Tools []Tool `json:"tools"`
-}
-
-type ToolsCallRequest struct {
-	Name      string                 `json:"name"`
-	Arguments map[string]interface{} `json:"arguments,omitempty"`
-}
-
-type ToolsCallResponse struct {
-	Content []Content `json:"content"`
-	IsError bool      `json:"isError,omitempty"`
-}
+	"github.com/code-aria/internal-mcp/internal/mcp"
+)
 
-type Content struct {
-	Type string `json:"type"`
-	Text string `json:"text,omitempty"`
-}
+// MCP protocol types, shared with the other servers
+type (
+	MCPMessage         = mcp.Message
+	MCPError           = mcp.Error
+	InitializeResponse = mcp.InitializeResponse
+	ServerInfo         = mcp.ServerInfo
+	Tool               = mcp.Tool
+	ToolsListResponse  = mcp.ToolsListResponse
+	ToolsCallRequest   = mcp.ToolsCallRequest
+	ToolsCallResponse  = mcp.ToolsCallResponse
+	Content            = mcp.Content
+)
 
 // SystemInfo operation types
 type SystemInfoOperation struct {
@@ -87,6 +49,13 @@ type SystemInfo struct {
 	Recommendations []string               `json:"recommendations"`
 }
 
+// Recommendation is one piece of advice and its category: platform, hardware, tools or
+// general
+type Recommendation struct {
+	Category string `json:"category"`
+	Message  string `json:"message"`
+}
+
 type OSInfo struct {
 	Name           string `json:"name"`
 	Version        string `json:"version"`
@@ -106,6 +75,7 @@ type HardwareInfo struct {
 	Memory        MemoryInfo   `json:"memory"`
 	Storage       []StorageInfo `json:"storage"`
 	Displays      []DisplayInfo `json:"displays,omitempty"`
+	GPU           []GPUInfo     `json:"gpu"`
 	NetworkCards  []NetworkCardInfo `json:"network_cards,omitempty"`
 }
 
@@ -153,10 +123,18 @@ type StorageInfo struct {
 }
 
 type DisplayInfo struct {
-	ID       int     `json:"id"`
-	Resolution string `json:"resolution"`
-	DPI      int     `json:"dpi,omitempty"`
-	Primary  bool    `json:"primary"`
+	ID          int     `json:"id"`
+	Name        string  `json:"name,omitempty"`
+	Resolution  string  `json:"resolution"`
+	RefreshRate float64 `json:"refresh_rate_hz,omitempty"`
+	DPI         int     `json:"dpi,omitempty"`
+	Primary     bool    `json:"primary"`
+}
+
+type GPUInfo struct {
+	Name      string `json:"name"`
+	Vendor    string `json:"vendor,omitempty"`
+	VRAMBytes uint64 `json:"vram_bytes,omitempty"`
 }
 
 type NetworkCardInfo struct {
@@ -290,6 +268,8 @@ type AuditLog struct {
 	Success      bool                   `json:"success"`
 	ErrorCode    int                    `json:"error_code,omitempty"`
 	ErrorType    string                 `json:"error_type,omitempty"`
+	TimeoutSeconds int                  `json:"timeout_seconds,omitempty"`
+	CacheHit       bool                 `json:"cache_hit,omitempty"`
 }
 
 // Security policy configuration
@@ -309,4 +289,71 @@ type CommandExistsResult struct {
 	Path    string `json:"path,omitempty"`
 	Version string `json:"version,omitempty"`
 	Error   string `json:"error,omitempty"`
-}
\ No newline at end of file
+}
+// Process information
+type ProcessInfo struct {
+	PID        int     `json:"pid"`
+	Name       string  `json:"name"`
+	CPUPercent float64 `json:"cpu_percent"`
+	MemBytes   uint64  `json:"mem_bytes"`
+}
+
+// Installed package reported by list_packages
+type PackageInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Temperature sensor reading
+type SensorReading struct {
+	Name         string  `json:"name"`
+	Zone         string  `json:"zone,omitempty"`
+	TemperatureC float64 `json:"temperature_c"`
+}
+
+// Sensor readings result
+type SensorsResult struct {
+	Supported bool            `json:"supported"`
+	Source    string          `json:"source,omitempty"` // "sysfs" or "sensors"
+	Sensors   []SensorReading `json:"sensors"`
+	Message   string          `json:"message,omitempty"`
+}
+
+// Container and virtualization environment
+type RuntimeEnvironment struct {
+	Containerized  bool     `json:"containerized"`
+	Virtualization string   `json:"virtualization"` // container runtime or hypervisor, e.g. "docker", "kvm", or "none"
+	Cloud          string   `json:"cloud"`          // "aws", "gcp", "azure" or "none"
+	Evidence       []string `json:"evidence"`
+}
+
+// Disk I/O counters for one block device
+type DiskIOStats struct {
+	Device           string   `json:"device"`
+	ReadsCompleted   uint64   `json:"reads_completed"`
+	WritesCompleted  uint64   `json:"writes_completed"`
+	ReadBytes        uint64   `json:"read_bytes"`
+	WriteBytes       uint64   `json:"write_bytes"`
+	ReadBytesPerSec  *float64 `json:"read_bytes_per_sec,omitempty"`
+	WriteBytesPerSec *float64 `json:"write_bytes_per_sec,omitempty"`
+}
+
+// Network I/O counters for one interface
+type NetworkIOStats struct {
+	Interface     string   `json:"interface"`
+	RxBytes       uint64   `json:"rx_bytes"`
+	TxBytes       uint64   `json:"tx_bytes"`
+	RxPackets     uint64   `json:"rx_packets"`
+	TxPackets     uint64   `json:"tx_packets"`
+	RxBytesPerSec *float64 `json:"rx_bytes_per_sec,omitempty"`
+	TxBytesPerSec *float64 `json:"tx_bytes_per_sec,omitempty"`
+}
+
+// Disk and network I/O statistics result
+type IOStatsResult struct {
+	Supported  bool             `json:"supported"`
+	Disks      []DiskIOStats    `json:"disks"`
+	Interfaces []NetworkIOStats `json:"interfaces"`
+	IntervalMs int              `json:"interval_ms,omitempty"` // sampling interval the rates were computed over
+	Message    string           `json:"message,omitempty"`
+}
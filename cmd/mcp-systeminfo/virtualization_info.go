@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Values reported when no container, hypervisor or cloud provider is detected
+const (
+	virtualizationNone = "none"
+	cloudNone          = "none"
+)
+
+// containerCgroupHints maps substrings of /proc/1/cgroup to the container runtime they imply
+var containerCgroupHints = []struct {
+	hint    string
+	runtime string
+}{
+	{"kubepods", "kubernetes"},
+	{"docker", "docker"},
+	{"containerd", "containerd"},
+	{"libpod", "podman"},
+	{"lxc", "lxc"},
+}
+
+// toolDetectEnvironment reports whether the server runs in a container or virtual machine,
+// and on which cloud provider
+func toolDetectEnvironment(args map[string]interface{}) (string, error) {
+	timeout := resolveProbeTimeout(args)
+
+	startTime := time.Now()
+	result := detectEnvironment(timeout)
+	duration := time.Since(startTime).Milliseconds()
+
+	// Audit logging (read-only operation)
+	auditLogWithTimeout("detect_environment", result.Virtualization, timeout, duration, true)
+
+	// Return JSON result
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal environment detection: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// detectEnvironment probes the local system. Every probe only reads files or runs
+// systemd-detect-virt, so nothing reaches the network; cloud providers are recognized
+// from the DMI data the hypervisor exposes rather than their metadata services.
+func detectEnvironment(timeout int) *RuntimeEnvironment {
+	result := &RuntimeEnvironment{
+		Virtualization: virtualizationNone,
+		Cloud:          cloudNone,
+		Evidence:       []string{},
+	}
+
+	if runtime.GOOS != "linux" {
+		return result
+	}
+
+	if container, evidence := detectContainer("/"); container != "" {
+		result.Containerized = true
+		result.Virtualization = container
+		result.Evidence = append(result.Evidence, evidence)
+	} else if virt := runSystemdDetectVirt(timeout); virt != "" {
+		result.Virtualization = virt
+		result.Evidence = append(result.Evidence, "systemd-detect-virt: "+virt)
+	} else if hypervisor, evidence := detectHypervisorFromDMI("/sys/class/dmi/id"); hypervisor != "" {
+		result.Virtualization = hypervisor
+		result.Evidence = append(result.Evidence, evidence)
+	}
+
+	if cloud, evidence := detectCloudFromDMI("/sys/class/dmi/id"); cloud != "" {
+		result.Cloud = cloud
+		result.Evidence = append(result.Evidence, evidence)
+	}
+
+	return result
+}
+
+// detectContainer looks for container marker files and cgroup hints under root and returns
+// the container runtime with the evidence for it, or "" outside a container
+func detectContainer(root string) (string, string) {
+	if _, err := os.Stat(filepath.Join(root, ".dockerenv")); err == nil {
+		return "docker", "/.dockerenv exists"
+	}
+	if _, err := os.Stat(filepath.Join(root, "run", ".containerenv")); err == nil {
+		return "podman", "/run/.containerenv exists"
+	}
+
+	cgroup, err := os.ReadFile(filepath.Join(root, "proc", "1", "cgroup"))
+	if err != nil {
+		return "", ""
+	}
+	for _, h := range containerCgroupHints {
+		if strings.Contains(string(cgroup), h.hint) {
+			return h.runtime, fmt.Sprintf("/proc/1/cgroup mentions %s", h.hint)
+		}
+	}
+	return "", ""
+}
+
+// runSystemdDetectVirt returns the hypervisor reported by systemd-detect-virt --vm, or ""
+// when it is not installed, not allowed or reports none
+func runSystemdDetectVirt(timeout int) string {
+	if !defaultSecurityPolicy.AllowedCommands["systemd-detect-virt"] {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(timeout, 5*time.Second))
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "systemd-detect-virt", "--vm")
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	// Exits non-zero when no virtualization is found
+	if err := cmd.Run(); err != nil {
+		return ""
+	}
+
+	virt := strings.TrimSpace(stdout.String())
+	if virt == virtualizationNone {
+		return ""
+	}
+	return virt
+}
+
+// detectHypervisorFromDMI recognizes common hypervisors from the product and vendor strings
+// in dmiRoot, for systems without systemd-detect-virt
+func detectHypervisorFromDMI(dmiRoot string) (string, string) {
+	productName := readDMIField(dmiRoot, "product_name")
+	sysVendor := readDMIField(dmiRoot, "sys_vendor")
+
+	hypervisors := []struct {
+		hint string
+		name string
+	}{
+		{"kvm", "kvm"},
+		{"qemu", "qemu"},
+		{"virtualbox", "oracle"},
+		{"vmware", "vmware"},
+		{"xen", "xen"},
+		{"microsoft corporation", "microsoft"},
+		{"amazon ec2", "amazon"},
+		{"google compute engine", "kvm"},
+	}
+
+	for _, field := range []string{productName, sysVendor} {
+		lower := strings.ToLower(field)
+		for _, h := range hypervisors {
+			if strings.Contains(lower, h.hint) {
+				return h.name, fmt.Sprintf("DMI reports %q", field)
+			}
+		}
+	}
+	return "", ""
+}
+
+// detectCloudFromDMI recognizes AWS, GCP and Azure from the DMI strings in dmiRoot
+func detectCloudFromDMI(dmiRoot string) (string, string) {
+	sysVendor := readDMIField(dmiRoot, "sys_vendor")
+	productName := readDMIField(dmiRoot, "product_name")
+	biosVersion := readDMIField(dmiRoot, "bios_version")
+	assetTag := readDMIField(dmiRoot, "chassis_asset_tag")
+
+	switch {
+	case strings.Contains(sysVendor, "Amazon EC2") || strings.Contains(strings.ToLower(biosVersion), "amazon"):
+		return "aws", fmt.Sprintf("DMI vendor %q", sysVendor)
+	case strings.Contains(productName, "Google Compute Engine") || sysVendor == "Google":
+		return "gcp", fmt.Sprintf("DMI product %q", productName)
+	case assetTag == "7783-7084-3265-9085-8269-3286-77":
+		// Every Azure VM carries this chassis asset tag
+		return "azure", "DMI chassis asset tag is Azure's"
+	}
+	return "", ""
+}
+
+// readDMIField returns the trimmed content of a DMI file, or "" if it cannot be read
+func readDMIField(dmiRoot, name string) string {
+	data, err := os.ReadFile(filepath.Join(dmiRoot, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
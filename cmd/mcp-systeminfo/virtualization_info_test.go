@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestToolDetectEnvironment tests that detect_environment returns a well-formed response on any host
+func TestToolDetectEnvironment(t *testing.T) {
+	origAuditEnabled := auditEnabled
+	auditEnabled = false
+	defer func() { auditEnabled = origAuditEnabled }()
+
+	result, err := toolDetectEnvironment(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("toolDetectEnvironment() error = %v", err)
+	}
+
+	var env RuntimeEnvironment
+	if err := json.Unmarshal([]byte(result), &env); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if env.Virtualization == "" || env.Cloud == "" {
+		t.Errorf("Expected virtualization and cloud to be set (\"none\" if undetected), got %+v", env)
+	}
+	if env.Containerized && env.Virtualization == virtualizationNone {
+		t.Errorf("Expected a container runtime when containerized, got %+v", env)
+	}
+	if env.Evidence == nil {
+		t.Error("Expected an evidence array")
+	}
+}
+
+// TestDetectContainer tests container detection against fake root filesystems
+func TestDetectContainer(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"dockerenv", map[string]string{".dockerenv": ""}, "docker"},
+		{"podman", map[string]string{"run/.containerenv": ""}, "podman"},
+		{"kubernetes cgroup", map[string]string{"proc/1/cgroup": "0::/kubepods/besteffort/pod1234/abcd\n"}, "kubernetes"},
+		{"host cgroup", map[string]string{"proc/1/cgroup": "0::/init.scope\n"}, ""},
+		{"nothing", map[string]string{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeFakeFiles(t, root, tt.files)
+
+			got, evidence := detectContainer(root)
+			if got != tt.want {
+				t.Errorf("detectContainer() = %q, want %q", got, tt.want)
+			}
+			if got != "" && evidence == "" {
+				t.Error("Expected evidence for a detected container")
+			}
+		})
+	}
+}
+
+// TestDetectFromDMI tests hypervisor and cloud detection from fake DMI data
+func TestDetectFromDMI(t *testing.T) {
+	tests := []struct {
+		name           string
+		files          map[string]string
+		wantHypervisor string
+		wantCloud      string
+	}{
+		{"aws", map[string]string{"sys_vendor": "Amazon EC2\n", "product_name": "m5.large\n"}, "amazon", "aws"},
+		{"gcp", map[string]string{"sys_vendor": "Google\n", "product_name": "Google Compute Engine\n"}, "kvm", "gcp"},
+		{"azure", map[string]string{"sys_vendor": "Microsoft Corporation\n", "product_name": "Virtual Machine\n", "chassis_asset_tag": "7783-7084-3265-9085-8269-3286-77\n"}, "microsoft", "azure"},
+		{"local kvm", map[string]string{"sys_vendor": "QEMU\n", "product_name": "Standard PC (Q35 + ICH9, 2009)\n"}, "qemu", ""},
+		{"bare metal", map[string]string{"sys_vendor": "Dell Inc.\n", "product_name": "PowerEdge R640\n"}, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeFakeFiles(t, root, tt.files)
+
+			if got, _ := detectHypervisorFromDMI(root); got != tt.wantHypervisor {
+				t.Errorf("detectHypervisorFromDMI() = %q, want %q", got, tt.wantHypervisor)
+			}
+			if got, _ := detectCloudFromDMI(root); got != tt.wantCloud {
+				t.Errorf("detectCloudFromDMI() = %q, want %q", got, tt.wantCloud)
+			}
+		})
+	}
+}
+
+// TestRecommendationsForContainer tests that a containerized environment gets container advice
+func TestRecommendationsForContainer(t *testing.T) {
+	env := &RuntimeEnvironment{Containerized: true, Virtualization: "docker", Cloud: cloudNone}
+
+	recommendations := getSystemRecommendations(nil, nil, nil, env)
+	found := false
+	for _, r := range recommendations {
+		if r.Category == recommendationPlatform && strings.Contains(r.Message, "docker container") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected a docker container recommendation, got %v", recommendations)
+	}
+}
+
+// writeFakeFiles creates files (relative path to content) under root
+func writeFakeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
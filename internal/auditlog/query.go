@@ -0,0 +1,168 @@
+package auditlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// DefaultQueryLimit is how many entries a query returns when no limit is given
+	DefaultQueryLimit = 50
+
+	// MaxQueryLimit caps how many entries a single query can return
+	MaxQueryLimit = 500
+
+	// maxLineBytes bounds a single audit line; longer lines are skipped
+	maxLineBytes = 1024 * 1024
+)
+
+// Filter selects audit entries. Zero values match everything.
+type Filter struct {
+	Operation string
+	Since     time.Time
+	Success   *bool
+}
+
+// QueryResult holds the newest matching entries, oldest first
+type QueryResult struct {
+	Entries   []map[string]interface{} `json:"entries"`
+	Count     int                      `json:"count"`
+	Matched   int                      `json:"matched"`
+	Truncated bool                     `json:"truncated"`
+	File      string                   `json:"audit_file"`
+}
+
+// ParseQueryArgs reads the operation, since (RFC 3339), success and limit arguments of a
+// query_audit_log operation
+func ParseQueryArgs(args map[string]interface{}) (Filter, int, error) {
+	var filter Filter
+
+	if operation, ok := args["operation"].(string); ok {
+		filter.Operation = operation
+	}
+
+	if since, ok := args["since"].(string); ok && since != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, since)
+		if err != nil {
+			return filter, 0, fmt.Errorf("since must be an RFC 3339 timestamp: %w", err)
+		}
+		filter.Since = parsed
+	}
+
+	if success, ok := args["success"].(bool); ok {
+		filter.Success = &success
+	}
+
+	limit := 0
+	if l, ok := args["limit"].(float64); ok {
+		limit = int(l)
+	}
+
+	return filter, limit, nil
+}
+
+// Query streams the JSON-lines audit file at path and returns the last limit operation
+// entries matching filter. Only limit entries are held in memory regardless of file size.
+// Lines that are not JSON, or have no "operation" field, are skipped.
+func Query(path string, filter Filter, limit int) (*QueryResult, error) {
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+	if limit > MaxQueryLimit {
+		limit = MaxQueryLimit
+	}
+
+	result := &QueryResult{Entries: []map[string]interface{}{}, File: path}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	// Ring buffer of the newest matches
+	ring := make([]map[string]interface{}, limit)
+	reader := bufio.NewReader(file)
+	for {
+		line, err := readLine(reader)
+		if len(line) > 0 {
+			var entry map[string]interface{}
+			if json.Unmarshal(line, &entry) == nil && filter.matches(entry) {
+				ring[result.Matched%limit] = entry
+				result.Matched++
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	count := result.Matched
+	if count > limit {
+		count = limit
+	}
+	start := result.Matched - count
+	for i := 0; i < count; i++ {
+		result.Entries = append(result.Entries, ring[(start+i)%limit])
+	}
+	result.Count = count
+	result.Truncated = result.Matched > count
+
+	return result, nil
+}
+
+// readLine reads one line without its newline. Lines longer than maxLineBytes come back
+// empty. At the end of input it returns the reader's error, io.EOF included.
+func readLine(reader *bufio.Reader) ([]byte, error) {
+	var line []byte
+	tooLong := false
+	for {
+		chunk, isPrefix, err := reader.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+		if !tooLong {
+			line = append(line, chunk...)
+			if len(line) > maxLineBytes {
+				tooLong = true
+				line = nil
+			}
+		}
+		if !isPrefix {
+			return line, nil
+		}
+	}
+}
+
+// matches reports whether an audit entry passes the filter
+func (f Filter) matches(entry map[string]interface{}) bool {
+	operation, ok := entry["operation"].(string)
+	if !ok || operation == "" {
+		return false
+	}
+	if f.Operation != "" && operation != f.Operation {
+		return false
+	}
+
+	if f.Success != nil {
+		if success, ok := entry["success"].(bool); !ok || success != *f.Success {
+			return false
+		}
+	}
+
+	if !f.Since.IsZero() {
+		raw, _ := entry["timestamp"].(string)
+		timestamp, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil || timestamp.Before(f.Since) {
+			return false
+		}
+	}
+
+	return true
+}
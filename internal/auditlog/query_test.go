@@ -0,0 +1,98 @@
+package auditlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeAuditLines writes JSON-lines audit entries to a temp file and returns its path
+func writeAuditLines(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write audit log: %v", err)
+	}
+	return path
+}
+
+// TestQuery tests filtering by operation, success and since, and keeping the newest entries
+func TestQuery(t *testing.T) {
+	path := writeAuditLines(t,
+		`{"timestamp":"2025-01-01T10:00:00Z","event_type":"startup"}`,
+		`{"timestamp":"2025-01-01T10:00:01Z","operation":"check_command","success":true}`,
+		`not json`,
+		`{"timestamp":"2025-01-01T10:00:02Z","operation":"get_sensors","success":false}`,
+		`{"timestamp":"2025-01-01T10:00:03.5Z","operation":"check_command","success":false}`,
+		`{"timestamp":"2025-01-01T10:00:04Z","operation":"check_command","success":true}`,
+	)
+
+	success := true
+	tests := []struct {
+		name    string
+		filter  Filter
+		limit   int
+		want    []string
+		matched int
+	}{
+		{"all operations", Filter{}, 0, []string{"10:00:01", "10:00:02", "10:00:03.5", "10:00:04"}, 4},
+		{"by operation", Filter{Operation: "check_command"}, 0, []string{"10:00:01", "10:00:03.5", "10:00:04"}, 3},
+		{"by success", Filter{Success: &success}, 0, []string{"10:00:01", "10:00:04"}, 2},
+		{"since", Filter{Since: time.Date(2025, 1, 1, 10, 0, 3, 0, time.UTC)}, 0, []string{"10:00:03.5", "10:00:04"}, 2},
+		{"last N", Filter{}, 2, []string{"10:00:03.5", "10:00:04"}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Query(path, tt.filter, tt.limit)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+
+			var got []string
+			for _, entry := range result.Entries {
+				got = append(got, strings.TrimSuffix(strings.TrimPrefix(entry["timestamp"].(string), "2025-01-01T"), "Z"))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Entries = %v, want %v", got, tt.want)
+			}
+			if result.Matched != tt.matched || result.Count != len(tt.want) || result.Truncated != (tt.matched > len(tt.want)) {
+				t.Errorf("Unexpected counts: %+v", result)
+			}
+		})
+	}
+}
+
+// TestQueryMissingFile tests that a missing audit file yields no entries rather than an error
+func TestQueryMissingFile(t *testing.T) {
+	result, err := Query(filepath.Join(t.TempDir(), "missing.log"), Filter{}, 10)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if result.Count != 0 || len(result.Entries) != 0 {
+		t.Errorf("Expected no entries, got %+v", result)
+	}
+}
+
+// TestParseQueryArgs tests argument parsing and since validation
+func TestParseQueryArgs(t *testing.T) {
+	filter, limit, err := ParseQueryArgs(map[string]interface{}{
+		"operation": "check_command",
+		"since":     "2025-01-01T10:00:00Z",
+		"success":   false,
+		"limit":     float64(5),
+	})
+	if err != nil {
+		t.Fatalf("ParseQueryArgs() error = %v", err)
+	}
+	if filter.Operation != "check_command" || filter.Since.IsZero() || filter.Success == nil || *filter.Success || limit != 5 {
+		t.Errorf("Unexpected filter %+v, limit %d", filter, limit)
+	}
+
+	if _, _, err := ParseQueryArgs(map[string]interface{}{"since": "yesterday"}); err == nil {
+		t.Error("Expected error for a non RFC 3339 since")
+	}
+}
@@ -0,0 +1,91 @@
+// Package auditlog holds the size-based rotation and JSON-lines querying shared by the
+// servers' audit logs.
+package auditlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultMaxBytes is the audit file size that triggers rotation when MCP_AUDIT_MAX_BYTES is unset
+	DefaultMaxBytes int64 = 10 * 1024 * 1024
+
+	// DefaultMaxFiles is how many rotated files are kept when MCP_AUDIT_MAX_FILES is unset
+	DefaultMaxFiles = 5
+
+	// rotatedSuffixFormat sorts lexically in time order and does not collide for rotations
+	// within the same second
+	rotatedSuffixFormat = "20060102T150405.000000000"
+)
+
+// MaxBytes returns the rotation threshold from MCP_AUDIT_MAX_BYTES, or DefaultMaxBytes
+func MaxBytes() int64 {
+	if value, err := strconv.ParseInt(os.Getenv("MCP_AUDIT_MAX_BYTES"), 10, 64); err == nil && value > 0 {
+		return value
+	}
+	return DefaultMaxBytes
+}
+
+// MaxFiles returns the number of rotated files to keep from MCP_AUDIT_MAX_FILES, or DefaultMaxFiles
+func MaxFiles() int {
+	if value, err := strconv.Atoi(os.Getenv("MCP_AUDIT_MAX_FILES")); err == nil && value > 0 {
+		return value
+	}
+	return DefaultMaxFiles
+}
+
+// ShouldRotate reports whether appending n bytes to a file of the given size would cross
+// maxBytes. An empty file is never rotated, so a single oversized entry still gets written.
+func ShouldRotate(size, n, maxBytes int64) bool {
+	return size > 0 && size+n > maxBytes
+}
+
+// Rotate renames path to path.<timestamp> and deletes the oldest rotated files beyond keep.
+// The caller must close the file first and reopen path afterwards.
+func Rotate(path string, keep int) error {
+	rotated := path + "." + time.Now().UTC().Format(rotatedSuffixFormat)
+	if err := os.Rename(path, rotated); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+
+	existing, err := RotatedFiles(path)
+	if err != nil {
+		return err
+	}
+	for len(existing) > keep {
+		if err := os.Remove(existing[0]); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove old audit log: %w", err)
+		}
+		existing = existing[1:]
+	}
+
+	return nil
+}
+
+// RotatedFiles returns the rotated copies of path, oldest first
+func RotatedFiles(path string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rotated audit logs: %w", err)
+	}
+
+	var rotated []string
+	prefix := filepath.Base(path) + "."
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if _, err := time.Parse(rotatedSuffixFormat, name[len(prefix):]); err == nil {
+			rotated = append(rotated, filepath.Join(filepath.Dir(path), name))
+		}
+	}
+	sort.Strings(rotated)
+	return rotated, nil
+}
@@ -0,0 +1,67 @@
+package auditlog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRotate tests that rotation moves the file aside and prunes the oldest copies
+func TestRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	for i := 0; i < 4; i++ {
+		if err := os.WriteFile(path, []byte("entry\n"), 0644); err != nil {
+			t.Fatalf("Failed to write audit log: %v", err)
+		}
+		if err := Rotate(path, 2); err != nil {
+			t.Fatalf("Rotate() error = %v", err)
+		}
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be renamed away, stat error = %v", path, err)
+	}
+
+	rotated, err := RotatedFiles(path)
+	if err != nil {
+		t.Fatalf("RotatedFiles() error = %v", err)
+	}
+	if len(rotated) != 2 {
+		t.Fatalf("Expected 2 rotated files to be kept, got %v", rotated)
+	}
+	if rotated[0] >= rotated[1] {
+		t.Errorf("Expected rotated files oldest first, got %v", rotated)
+	}
+}
+
+// TestShouldRotate tests the rotation threshold
+func TestShouldRotate(t *testing.T) {
+	tests := []struct {
+		size, n, max int64
+		want         bool
+	}{
+		{0, 500, 100, false},
+		{50, 40, 100, false},
+		{50, 60, 100, true},
+		{100, 1, 100, true},
+	}
+	for _, tt := range tests {
+		if got := ShouldRotate(tt.size, tt.n, tt.max); got != tt.want {
+			t.Errorf("ShouldRotate(%d, %d, %d) = %v, want %v", tt.size, tt.n, tt.max, got, tt.want)
+		}
+	}
+}
+
+// TestMaxBytes tests the MCP_AUDIT_MAX_BYTES override and its fallback
+func TestMaxBytes(t *testing.T) {
+	t.Setenv("MCP_AUDIT_MAX_BYTES", "2048")
+	if got := MaxBytes(); got != 2048 {
+		t.Errorf("MaxBytes() = %d, want 2048", got)
+	}
+
+	t.Setenv("MCP_AUDIT_MAX_BYTES", "not a number")
+	if got := MaxBytes(); got != DefaultMaxBytes {
+		t.Errorf("MaxBytes() = %d, want default %d", got, DefaultMaxBytes)
+	}
+}
@@ -0,0 +1,56 @@
+// Package filelang names the language of a file from its extension, so that every server
+// agrees on what a .tsx or .sh file is.
+package filelang
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// extensions maps lowercase file extensions to language names
+var extensions = map[string]string{
+	".go":    "go",
+	".ts":    "typescript",
+	".tsx":   "typescript",
+	".js":    "javascript",
+	".jsx":   "javascript",
+	".mjs":   "javascript",
+	".cjs":   "javascript",
+	".py":    "python",
+	".rs":    "rust",
+	".java":  "java",
+	".kt":    "kotlin",
+	".rb":    "ruby",
+	".php":   "php",
+	".cs":    "csharp",
+	".c":     "c",
+	".h":     "c",
+	".cc":    "cpp",
+	".cpp":   "cpp",
+	".hpp":   "cpp",
+	".swift": "swift",
+	".sql":   "sql",
+	".sh":    "shell",
+	".bash":  "shell",
+	".zsh":   "shell",
+	".ps1":   "powershell",
+	".pl":    "perl",
+	".r":     "r",
+	".lua":   "lua",
+	".hs":    "haskell",
+	".lisp":  "lisp",
+	".el":    "lisp",
+	".clj":   "clojure",
+	".mk":    "makefile",
+	".json":  "json",
+	".yaml":  "yaml",
+	".yml":   "yaml",
+	".toml":  "toml",
+	".md":    "markdown",
+}
+
+// ForPath returns the language of filePath from its extension, case-insensitively, or ""
+// when the extension is not recognized
+func ForPath(filePath string) string {
+	return extensions[strings.ToLower(filepath.Ext(filePath))]
+}
@@ -0,0 +1,27 @@
+package filelang
+
+import "testing"
+
+// TestForPath tests extension to language mapping
+func TestForPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"cmd/server/main.go", "go"},
+		{"scripts/build.py", "python"},
+		{"web/App.TSX", "typescript"},
+		{"scripts/build.sh", "shell"},
+		{"scripts/setup.bash", "shell"},
+		{"config/app.yml", "yaml"},
+		{"Makefile", ""},
+		{"README", ""},
+		{"data/file.xyz", ""},
+	}
+
+	for _, tt := range tests {
+		if got := ForPath(tt.path); got != tt.want {
+			t.Errorf("ForPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// OperationHandler executes one batch operation and returns its result, which is embedded
+// as parsed JSON when it is valid JSON and as a plain string otherwise
+type OperationHandler func(params map[string]interface{}) (string, error)
+
+// ParamsFilter rewrites an operation's params before they are echoed back in its result,
+// e.g. to drop large content or credentials
+type ParamsFilter func(opType string, params map[string]interface{}) map[string]interface{}
+
+// RequiredParams lists the params each operation type needs before it is dispatched.
+// Each requirement is a set of alternative names, any one of which satisfies it.
+type RequiredParams map[string][][]string
+
+// ErrCodeInvalidParams is the error_code of results rejected by required param validation
+const ErrCodeInvalidParams = "invalid_params"
+
+// BatchRunner executes apply_operations batches by dispatching each operation's "type"
+// to a registered handler
+type BatchRunner struct {
+	handlers       map[string]OperationHandler
+	paramsFilter   ParamsFilter
+	requiredParams RequiredParams
+}
+
+// NewBatchRunner creates a BatchRunner for the given operation handlers
+func NewBatchRunner(handlers map[string]OperationHandler) *BatchRunner {
+	return &BatchRunner{handlers: handlers}
+}
+
+// WithParamsFilter sets the filter applied to params echoed back in results
+func (r *BatchRunner) WithParamsFilter(filter ParamsFilter) *BatchRunner {
+	r.paramsFilter = filter
+	return r
+}
+
+// WithRequiredParams sets the params checked before each operation is dispatched
+func (r *BatchRunner) WithRequiredParams(required RequiredParams) *BatchRunner {
+	r.requiredParams = required
+	return r
+}
+
+// missingParam returns the first requirement of opType not satisfied by params, or nil.
+// A param counts as missing when it is absent, null or an empty string.
+func (r *BatchRunner) missingParam(opType string, params map[string]interface{}) []string {
+	for _, names := range r.requiredParams[opType] {
+		satisfied := false
+		for _, name := range names {
+			if value, ok := params[name]; ok && value != nil && value != "" {
+				satisfied = true
+				break
+			}
+		}
+		if !satisfied {
+			return names
+		}
+	}
+	return nil
+}
+
+// invalidParamsResult builds the batch result for an operation missing a required param
+func invalidParamsResult(index int, opType string, params map[string]interface{}, names []string) map[string]interface{} {
+	message := fmt.Sprintf("invalid_params: missing required field %q", names[0])
+	for _, alt := range names[1:] {
+		message += fmt.Sprintf(" (or %q)", alt)
+	}
+
+	result := ErrorResult(index, opType, params, message)
+	result["error_code"] = ErrCodeInvalidParams
+	result["missing_field"] = names[0]
+	return result
+}
+
+// SuccessResult builds the batch result for a successful operation
+func SuccessResult(index int, opType string, params map[string]interface{}, result interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"index":     index,
+		"operation": opType,
+		"params":    params,
+		"success":   true,
+		"status":    "Success",
+		"result":    result,
+	}
+}
+
+// ErrorResult builds the batch result for a failed operation. "message" duplicates "error"
+// for clients written against the original schema.
+func ErrorResult(index int, opType string, params map[string]interface{}, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"index":     index,
+		"operation": opType,
+		"params":    params,
+		"success":   false,
+		"status":    "Error",
+		"error":     message,
+		"message":   message,
+	}
+}
+
+// Run executes the operations in order. Every result has "index", "operation", "params",
+// "success" and "status" ("Success" or "Error"), plus "result" on success or "error" on
+// failure. Operations missing a required param are not dispatched and fail with
+// "error_code" "invalid_params" and "missing_field". A failing operation does not stop
+// the batch.
+func (r *BatchRunner) Run(operations []interface{}) []map[string]interface{} {
+	results := make([]map[string]interface{}, 0, len(operations))
+	for i, op := range operations {
+		results = append(results, r.RunOperation(i, op))
+	}
+	return results
+}
+
+// RunOperation executes a single operation at position index of a batch and returns its
+// result in the schema described on Run
+func (r *BatchRunner) RunOperation(index int, op interface{}) map[string]interface{} {
+	opMap, ok := op.(map[string]interface{})
+	if !ok {
+		return ErrorResult(index, "unknown", map[string]interface{}{}, "Invalid operation format")
+	}
+
+	opType, ok := opMap["type"].(string)
+	if !ok {
+		return ErrorResult(index, "unknown", map[string]interface{}{}, "Operation type is required")
+	}
+
+	// Extract operation-specific arguments as params
+	params := OperationParams(opMap)
+
+	echoedParams := params
+	if r.paramsFilter != nil {
+		echoedParams = r.paramsFilter(opType, params)
+	}
+
+	handler, ok := r.handlers[opType]
+	if !ok {
+		return ErrorResult(index, opType, echoedParams, fmt.Sprintf("unknown operation type: %s", opType))
+	}
+	if missing := r.missingParam(opType, params); missing != nil {
+		return invalidParamsResult(index, opType, echoedParams, missing)
+	}
+
+	result, err := handler(params)
+	if err != nil {
+		return ErrorResult(index, opType, echoedParams, err.Error())
+	}
+
+	// Parse JSON result if possible, otherwise use as string
+	var parsedResult interface{}
+	if jsonErr := json.Unmarshal([]byte(result), &parsedResult); jsonErr != nil {
+		parsedResult = result
+	}
+
+	return SuccessResult(index, opType, echoedParams, parsedResult)
+}
+
+// OperationParams returns an operation's params: every field except "type"
+func OperationParams(op map[string]interface{}) map[string]interface{} {
+	params := make(map[string]interface{})
+	for k, v := range op {
+		if k != "type" {
+			params[k] = v
+		}
+	}
+	return params
+}
+
+// Operations returns the "operations" argument of an apply_operations call, or an error
+// message suitable for an invalid params response
+func Operations(args map[string]interface{}) ([]interface{}, error) {
+	operations, ok := args["operations"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("operations array is required")
+	}
+	if len(operations) == 0 {
+		return nil, fmt.Errorf("operations array cannot be empty")
+	}
+	return operations, nil
+}
+
+// Handle runs the "operations" argument of an apply_operations call and writes the
+// results as a text content tool response
+func (r *BatchRunner) Handle(msg *Message, encoder *json.Encoder, args map[string]interface{}) {
+	operations, err := Operations(args)
+	if err != nil {
+		SendError(encoder, msg.ID, CodeInvalidParams, err.Error(), nil)
+		return
+	}
+
+	SendBatchResponse(encoder, msg.ID, map[string]interface{}{
+		"results": r.Run(operations),
+	})
+}
+
+// SendBatchResponse writes body, normally {"results": [...]}, as the JSON text content of
+// an apply_operations tool response
+func SendBatchResponse(encoder *json.Encoder, id interface{}, body map[string]interface{}) {
+	// Serialize results to JSON text for MCP-compliant response format
+	resultsJSON, err := json.Marshal(body)
+	if err != nil {
+		SendError(encoder, id, CodeParseError, fmt.Sprintf("Failed to marshal results: %v", err), nil)
+		return
+	}
+
+	response := Message{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result: ToolsCallResponse{
+			Content: []Content{
+				{
+					Type: "text",
+					Text: string(resultsJSON),
+				},
+			},
+			IsError: false,
+		},
+	}
+
+	if err := encoder.Encode(response); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to encode batch operations response: %v\n", err)
+	}
+}
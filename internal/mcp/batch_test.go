@@ -0,0 +1,214 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func testBatchRunner() *BatchRunner {
+	return NewBatchRunner(map[string]OperationHandler{
+		"echo_json": func(params map[string]interface{}) (string, error) {
+			data, err := json.Marshal(params)
+			return string(data), err
+		},
+		"echo_text": func(params map[string]interface{}) (string, error) {
+			return "plain text", nil
+		},
+		"fail": func(params map[string]interface{}) (string, error) {
+			return "", fmt.Errorf("operation failed")
+		},
+	})
+}
+
+// TestBatchRunnerRun tests result shapes for successful, failing and malformed operations
+func TestBatchRunnerRun(t *testing.T) {
+	results := testBatchRunner().Run([]interface{}{
+		map[string]interface{}{"type": "echo_json", "value": "x"},
+		map[string]interface{}{"type": "echo_text"},
+		map[string]interface{}{"type": "fail"},
+		map[string]interface{}{"type": "missing"},
+		map[string]interface{}{"value": "no type"},
+		"not an object",
+	})
+
+	if len(results) != 6 {
+		t.Fatalf("Expected 6 results, got %d", len(results))
+	}
+
+	if results[0]["status"] != "Success" || results[0]["operation"] != "echo_json" {
+		t.Errorf("Unexpected first result: %v", results[0])
+	}
+	if parsed, ok := results[0]["result"].(map[string]interface{}); !ok || parsed["value"] != "x" {
+		t.Errorf("Expected JSON result to be parsed, got %v", results[0]["result"])
+	}
+	if params, _ := results[0]["params"].(map[string]interface{}); params["type"] != nil || params["value"] != "x" {
+		t.Errorf("Expected params without type, got %v", results[0]["params"])
+	}
+
+	for i, result := range results {
+		if result["index"] != i {
+			t.Errorf("Result %d has index %v", i, result["index"])
+		}
+	}
+	if results[0]["success"] != true {
+		t.Errorf("Expected success true on first result, got %v", results[0]["success"])
+	}
+	if _, ok := results[0]["error"]; ok {
+		t.Error("Successful result should not have an error field")
+	}
+
+	if results[1]["result"] != "plain text" {
+		t.Errorf("Expected non-JSON result as string, got %v", results[1]["result"])
+	}
+
+	expectedErrors := []struct {
+		operation string
+		message   string
+	}{
+		{"fail", "operation failed"},
+		{"missing", "unknown operation type: missing"},
+		{"unknown", "Operation type is required"},
+		{"unknown", "Invalid operation format"},
+	}
+	for i, want := range expectedErrors {
+		result := results[i+2]
+		if result["status"] != "Error" || result["success"] != false || result["operation"] != want.operation {
+			t.Errorf("Result %d = %v, want error for %q", i+2, result, want.operation)
+		}
+		if result["error"] != want.message || result["message"] != want.message {
+			t.Errorf("Result %d = %v, want error and message %q", i+2, result, want.message)
+		}
+		if _, ok := result["result"]; ok {
+			t.Errorf("Result %d should not have a result field", i+2)
+		}
+	}
+}
+
+// TestBatchRunnerParamsFilter tests that echoed params go through the filter but handlers
+// still receive the originals
+func TestBatchRunnerParamsFilter(t *testing.T) {
+	runner := testBatchRunner().WithParamsFilter(func(opType string, params map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"filtered": opType}
+	})
+
+	results := runner.Run([]interface{}{
+		map[string]interface{}{"type": "echo_json", "value": "x"},
+		map[string]interface{}{"type": "fail", "value": "y"},
+	})
+
+	if parsed, _ := results[0]["result"].(map[string]interface{}); parsed["value"] != "x" {
+		t.Errorf("Expected handler to receive unfiltered params, got %v", results[0]["result"])
+	}
+	for i, want := range []string{"echo_json", "fail"} {
+		if params, _ := results[i]["params"].(map[string]interface{}); params["filtered"] != want {
+			t.Errorf("Result %d params = %v, want filtered params", i, results[i]["params"])
+		}
+	}
+}
+
+// TestBatchRunnerRequiredParams tests that operations missing a required param are rejected
+// before their handler runs
+func TestBatchRunnerRequiredParams(t *testing.T) {
+	called := false
+	runner := NewBatchRunner(map[string]OperationHandler{
+		"edit": func(params map[string]interface{}) (string, error) {
+			called = true
+			return "ok", nil
+		},
+	}).WithRequiredParams(RequiredParams{
+		"edit": {{"file_path", "path"}, {"diff"}},
+	})
+
+	tests := []struct {
+		name    string
+		params  map[string]interface{}
+		missing string
+		message string
+	}{
+		{"missing file_path", map[string]interface{}{"diff": "d"}, "file_path", `invalid_params: missing required field "file_path" (or "path")`},
+		{"empty diff", map[string]interface{}{"path": "a.go", "diff": ""}, "diff", `invalid_params: missing required field "diff"`},
+		{"null diff", map[string]interface{}{"file_path": "a.go", "diff": nil}, "diff", `invalid_params: missing required field "diff"`},
+		{"all present", map[string]interface{}{"path": "a.go", "diff": "d"}, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called = false
+			op := map[string]interface{}{"type": "edit"}
+			for k, v := range tt.params {
+				op[k] = v
+			}
+			result := runner.Run([]interface{}{op})[0]
+
+			if tt.missing == "" {
+				if result["success"] != true || !called {
+					t.Errorf("Expected handler to run, got %v", result)
+				}
+				return
+			}
+			if called {
+				t.Error("Handler should not run when a required param is missing")
+			}
+			if result["error_code"] != ErrCodeInvalidParams || result["missing_field"] != tt.missing {
+				t.Errorf("Expected invalid_params for %s, got %v", tt.missing, result)
+			}
+			if result["error"] != tt.message {
+				t.Errorf("error = %v, want %s", result["error"], tt.message)
+			}
+		})
+	}
+}
+
+// TestBatchRunnerHandle tests the tool response envelope and argument validation
+func TestBatchRunnerHandle(t *testing.T) {
+	runner := testBatchRunner()
+	msg := &Message{JSONRPC: "2.0", ID: 3, Method: "tools/call"}
+
+	var output bytes.Buffer
+	runner.Handle(msg, json.NewEncoder(&output), map[string]interface{}{
+		"operations": []interface{}{map[string]interface{}{"type": "echo_text"}},
+	})
+
+	var response struct {
+		ID     float64           `json:"id"`
+		Result ToolsCallResponse `json:"result"`
+		Error  *Error            `json:"error"`
+	}
+	if err := json.Unmarshal(output.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if response.Error != nil || response.ID != 3 {
+		t.Fatalf("Unexpected response: %+v", response)
+	}
+	if len(response.Result.Content) != 1 || response.Result.Content[0].Type != "text" {
+		t.Fatalf("Expected one text content item, got %+v", response.Result.Content)
+	}
+
+	var body struct {
+		Results []map[string]interface{} `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(response.Result.Content[0].Text), &body); err != nil {
+		t.Fatalf("Failed to parse results: %v", err)
+	}
+	if len(body.Results) != 1 || body.Results[0]["status"] != "Success" {
+		t.Errorf("Unexpected results: %v", body.Results)
+	}
+
+	for name, args := range map[string]map[string]interface{}{
+		"missing operations": {},
+		"empty operations":   {"operations": []interface{}{}},
+	} {
+		output.Reset()
+		runner.Handle(msg, json.NewEncoder(&output), args)
+
+		var errResponse Message
+		if err := json.Unmarshal(output.Bytes(), &errResponse); err != nil {
+			t.Fatalf("%s: failed to parse response: %v", name, err)
+		}
+		if errResponse.Error == nil || errResponse.Error.Code != CodeInvalidParams {
+			t.Errorf("%s: expected invalid params error, got %+v", name, errResponse.Error)
+		}
+	}
+}
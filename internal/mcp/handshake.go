@@ -0,0 +1,78 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+)
+
+// ReadInitialize performs the MCP initialize handshake: it reads the initialize request,
+// replies with the server's info and tools capability, and then expects the
+// notifications/initialized notification.
+func ReadInitialize(scanner *bufio.Scanner, encoder *json.Encoder, info ServerInfo) error {
+	if !scanner.Scan() {
+		return fmt.Errorf("no initialize request")
+	}
+
+	var initReq Message
+	if err := json.Unmarshal(scanner.Bytes(), &initReq); err != nil {
+		return fmt.Errorf("failed to parse initialize: %w", err)
+	}
+
+	response := Message{
+		JSONRPC: "2.0",
+		ID:      initReq.ID,
+		Result: InitializeResponse{
+			ProtocolVersion: ProtocolVersion,
+			Capabilities: map[string]interface{}{
+				"tools": map[string]interface{}{},
+			},
+			ServerInfo: info,
+		},
+	}
+
+	if err := encoder.Encode(response); err != nil {
+		return fmt.Errorf("failed to send initialize response: %w", err)
+	}
+
+	if !scanner.Scan() {
+		return fmt.Errorf("no initialized notification")
+	}
+
+	var initializedMsg Message
+	if err := json.Unmarshal(scanner.Bytes(), &initializedMsg); err != nil {
+		return fmt.Errorf("failed to parse initialized notification: %w", err)
+	}
+
+	// Validate that this is the initialized notification
+	if initializedMsg.Method != "notifications/initialized" {
+		return fmt.Errorf("expected initialized notification, got method: %s", initializedMsg.Method)
+	}
+
+	return nil
+}
+
+// SendError sends a JSON-RPC error response
+func SendError(encoder *json.Encoder, id interface{}, code int, message string, data interface{}) {
+	response := Message{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error: &Error{
+			Code:    code,
+			Message: message,
+			Data:    data,
+		},
+	}
+	encoder.Encode(response)
+}
+
+// SendPing answers a ping request with an empty result, letting supervisors check the
+// server is responsive without running a tool
+func SendPing(encoder *json.Encoder, id interface{}) {
+	response := Message{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  map[string]interface{}{},
+	}
+	encoder.Encode(response)
+}
@@ -0,0 +1,95 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestReadInitialize tests a complete initialize handshake
+func TestReadInitialize(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"1.0"}}}
+{"jsonrpc":"2.0","method":"notifications/initialized"}
+`
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	var output bytes.Buffer
+
+	err := ReadInitialize(scanner, json.NewEncoder(&output), ServerInfo{Name: "mcp-test", Version: "1.0.0"})
+	if err != nil {
+		t.Fatalf("ReadInitialize() error = %v", err)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(output.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if response["jsonrpc"] != "2.0" || response["id"] != float64(1) {
+		t.Errorf("Unexpected envelope: %v", response)
+	}
+
+	result, ok := response["result"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected result object, got %v", response["result"])
+	}
+	if result["protocolVersion"] != ProtocolVersion {
+		t.Errorf("protocolVersion = %v, want %s", result["protocolVersion"], ProtocolVersion)
+	}
+	serverInfo, _ := result["serverInfo"].(map[string]interface{})
+	if serverInfo["name"] != "mcp-test" || serverInfo["version"] != "1.0.0" {
+		t.Errorf("Unexpected serverInfo: %v", serverInfo)
+	}
+	capabilities, _ := result["capabilities"].(map[string]interface{})
+	if _, ok := capabilities["tools"]; !ok {
+		t.Errorf("Expected tools capability, got %v", capabilities)
+	}
+}
+
+// TestReadInitializeErrors tests the handshake failure modes
+func TestReadInitializeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"No input", "", "no initialize request"},
+		{"Invalid initialize", "not json\n", "failed to parse initialize"},
+		{"Missing notification", `{"jsonrpc":"2.0","id":1,"method":"initialize"}` + "\n", "no initialized notification"},
+		{"Invalid notification", `{"jsonrpc":"2.0","id":1,"method":"initialize"}` + "\nnot json\n", "failed to parse initialized notification"},
+		{"Wrong notification", `{"jsonrpc":"2.0","id":1,"method":"initialize"}` + "\n" + `{"jsonrpc":"2.0","method":"tools/list"}` + "\n", "expected initialized notification"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := bufio.NewScanner(strings.NewReader(tt.input))
+			var output bytes.Buffer
+
+			err := ReadInitialize(scanner, json.NewEncoder(&output), ServerInfo{Name: "mcp-test", Version: "1.0.0"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ReadInitialize() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestSendError tests the JSON-RPC error response shape
+func TestSendError(t *testing.T) {
+	var output bytes.Buffer
+	SendError(json.NewEncoder(&output), 7, CodeMethodNotFound, "Unknown method: foo", "details")
+
+	expected := `{"jsonrpc":"2.0","id":7,"error":{"code":-32601,"message":"Unknown method: foo","data":"details"}}`
+	if got := strings.TrimSpace(output.String()); got != expected {
+		t.Errorf("SendError() wrote %s, want %s", got, expected)
+	}
+}
+
+// TestSendPing tests the ping response is an empty result echoing the request id
+func TestSendPing(t *testing.T) {
+	var output bytes.Buffer
+	SendPing(json.NewEncoder(&output), "ping-1")
+
+	if got := strings.TrimSpace(output.String()); got != `{"jsonrpc":"2.0","id":"ping-1","result":{}}` {
+		t.Errorf("SendPing() wrote %s", got)
+	}
+}
@@ -0,0 +1,92 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// MaxMessageBytesEnv overrides the size of the largest message a server accepts
+const MaxMessageBytesEnv = "MCP_MAX_MESSAGE_BYTES"
+
+// DefaultMaxMessageBytes is the message size limit when MCP_MAX_MESSAGE_BYTES is not set.
+// It is well above bufio.Scanner's 64KB default, which large batch edits exceed.
+const DefaultMaxMessageBytes = 10 * 1024 * 1024
+
+// NullID is the id of a response to a request whose id could not be read. JSON-RPC
+// requires it to be null, which a nil ID would omit.
+var NullID = json.RawMessage("null")
+
+// MaxMessageBytes returns the message size limit from MCP_MAX_MESSAGE_BYTES, falling back
+// to DefaultMaxMessageBytes when it is unset or not a positive integer
+func MaxMessageBytes() int {
+	value := os.Getenv(MaxMessageBytesEnv)
+	if value == "" {
+		return DefaultMaxMessageBytes
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		fmt.Fprintf(os.Stderr, "[WARN] invalid %s %q, using %d\n", MaxMessageBytesEnv, value, DefaultMaxMessageBytes)
+		return DefaultMaxMessageBytes
+	}
+	return n
+}
+
+// NewScanner returns a line scanner over r that accepts messages up to MaxMessageBytes
+func NewScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, MaxMessageBytes())
+	return scanner
+}
+
+// Serve reads one JSON-RPC message per line from scanner until it is exhausted, passing
+// each request to handle. Lines that are not valid JSON are answered with a parse error,
+// since the client would otherwise wait for a reply that never comes. name prefixes the
+// diagnostics written to stderr.
+//
+// A read error, such as a message larger than MaxMessageBytes, is also sent to the client
+// before Serve returns: the scanner cannot resynchronize with the input after it.
+func Serve(scanner *bufio.Scanner, encoder *json.Encoder, name string, handle func(*Message, *json.Encoder)) {
+	maxBytes := MaxMessageBytes()
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		// Log large requests for debugging
+		if len(line) > maxBytes/2 {
+			fmt.Fprintf(os.Stderr, "[WARN] %s: Large request size %d bytes (limit %d)\n", name, len(line), maxBytes)
+		}
+
+		var msg Message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			SendParseError(encoder, err)
+			continue
+		}
+
+		if msg.Method != "" {
+			handle(&msg, encoder)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			fmt.Fprintf(os.Stderr, "[ERROR] %s: Request exceeds %d bytes; set %s to raise the limit\n", name, maxBytes, MaxMessageBytesEnv)
+			SendError(encoder, NullID, CodeInvalidRequest,
+				fmt.Sprintf("Message exceeds the %d byte limit (set %s to raise it)", maxBytes, MaxMessageBytesEnv), nil)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "[ERROR] %s: Scanner error: %v\n", name, err)
+		SendError(encoder, NullID, CodeInternalError, fmt.Sprintf("Failed to read message: %v", err), nil)
+	}
+}
+
+// SendParseError answers a message that is not valid JSON with a -32700 error and a null id
+func SendParseError(encoder *json.Encoder, err error) {
+	SendError(encoder, NullID, CodeParseError, "Parse error", err.Error())
+}
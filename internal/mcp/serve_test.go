@@ -0,0 +1,106 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestServeMalformedJSON tests that garbage gets a parse error and later requests are still served
+func TestServeMalformedJSON(t *testing.T) {
+	input := "{this is not json\n\n" + `{"jsonrpc":"2.0","id":7,"method":"ping"}` + "\n"
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	var output bytes.Buffer
+
+	var handled []interface{}
+	Serve(scanner, json.NewEncoder(&output), "mcp-test", func(msg *Message, encoder *json.Encoder) {
+		handled = append(handled, msg.ID)
+		SendPing(encoder, msg.ID)
+	})
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 responses (blank lines are skipped), got %d: %s", len(lines), output.String())
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	id, hasID := response["id"]
+	if !hasID || id != nil {
+		t.Errorf("Expected an explicit null id, got %s", lines[0])
+	}
+	errObj, ok := response["error"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected error object, got %s", lines[0])
+	}
+	if errObj["code"] != float64(CodeParseError) {
+		t.Errorf("code = %v, want %d", errObj["code"], CodeParseError)
+	}
+	if data, _ := errObj["data"].(string); data == "" {
+		t.Errorf("Expected the JSON error as data, got %v", errObj["data"])
+	}
+
+	if len(handled) != 1 || handled[0] != float64(7) {
+		t.Errorf("Expected the ping after the garbage to be handled, got %v", handled)
+	}
+}
+
+// TestServeMessageSizeLimit tests messages above bufio's 64KB default and above MCP_MAX_MESSAGE_BYTES
+func TestServeMessageSizeLimit(t *testing.T) {
+	request := `{"jsonrpc":"2.0","id":1,"method":"ping","params":{"padding":"` + strings.Repeat("x", 100*1024) + `"}}` + "\n"
+
+	t.Run("Default limit", func(t *testing.T) {
+		t.Setenv(MaxMessageBytesEnv, "")
+		var output bytes.Buffer
+		handled := 0
+		Serve(NewScanner(strings.NewReader(request)), json.NewEncoder(&output), "mcp-test", func(msg *Message, encoder *json.Encoder) {
+			handled++
+		})
+		if handled != 1 || output.Len() != 0 {
+			t.Errorf("Expected the 100KB request to be handled, handled %d, output %s", handled, output.String())
+		}
+	})
+
+	t.Run("Over the configured limit", func(t *testing.T) {
+		t.Setenv(MaxMessageBytesEnv, "65536")
+		var output bytes.Buffer
+		Serve(NewScanner(strings.NewReader(request)), json.NewEncoder(&output), "mcp-test", func(msg *Message, encoder *json.Encoder) {
+			t.Error("Oversize request must not be handled")
+		})
+
+		var response Message
+		if err := json.Unmarshal(output.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response %q: %v", output.String(), err)
+		}
+		if response.Error == nil || response.Error.Code != CodeInvalidRequest {
+			t.Fatalf("Expected a %d error, got %+v", CodeInvalidRequest, response.Error)
+		}
+		if !strings.Contains(response.Error.Message, MaxMessageBytesEnv) {
+			t.Errorf("Expected the error to name %s, got %q", MaxMessageBytesEnv, response.Error.Message)
+		}
+	})
+}
+
+// TestMaxMessageBytes tests reading the limit from the environment
+func TestMaxMessageBytes(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"", DefaultMaxMessageBytes},
+		{"1048576", 1048576},
+		{"0", DefaultMaxMessageBytes},
+		{"-5", DefaultMaxMessageBytes},
+		{"lots", DefaultMaxMessageBytes},
+	}
+	for _, tt := range tests {
+		t.Setenv(MaxMessageBytesEnv, tt.value)
+		if got := MaxMessageBytes(); got != tt.want {
+			t.Errorf("MaxMessageBytes() with %q = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Package pgschema checks at startup that the tables and columns a server relies on exist.
+// The servers never change the schema themselves; a database missing a migration fails
+// with an error naming what is missing and where the migrations are.
+package pgschema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Requirement is a table and the columns a server reads or writes in it
+type Requirement struct {
+	Table   string
+	Columns []string
+}
+
+// Check returns an error listing every missing table and column of required, pointing
+// at migrationsDir for the statements that add them
+func Check(ctx context.Context, db *sql.DB, migrationsDir string, required []Requirement) error {
+	var missing []string
+	for _, req := range required {
+		present, err := tableColumns(ctx, db, req.Table)
+		if err != nil {
+			return err
+		}
+		missing = append(missing, missingColumns(req, present)...)
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("database schema is missing %s; apply the migrations in %s", strings.Join(missing, ", "), migrationsDir)
+}
+
+// tableColumns returns the columns of table in the schemas on the search path; none if
+// the table does not exist
+func tableColumns(ctx context.Context, db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT column_name FROM information_schema.columns
+		WHERE table_schema = ANY(current_schemas(false)) AND table_name = $1`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+// missingColumns describes what req needs that present lacks: the whole table when it has
+// no columns at all, otherwise each missing column as table.column
+func missingColumns(req Requirement, present map[string]bool) []string {
+	if len(present) == 0 {
+		return []string{"table " + req.Table}
+	}
+
+	var missing []string
+	for _, column := range req.Columns {
+		if !present[column] {
+			missing = append(missing, "column "+req.Table+"."+column)
+		}
+	}
+	return missing
+}
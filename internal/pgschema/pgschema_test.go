@@ -0,0 +1,30 @@
+package pgschema
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestMissingColumns tests naming a missing table once and each missing column of an
+// existing table
+func TestMissingColumns(t *testing.T) {
+	req := Requirement{Table: "documents", Columns: []string{"slug", "deleted_at", "related_ids"}}
+
+	tests := []struct {
+		name    string
+		present map[string]bool
+		want    []string
+	}{
+		{"table missing", map[string]bool{}, []string{"table documents"}},
+		{"columns missing", map[string]bool{"id": true, "deleted_at": true}, []string{"column documents.slug", "column documents.related_ids"}},
+		{"complete", map[string]bool{"slug": true, "deleted_at": true, "related_ids": true}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := missingColumns(req, tt.present); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("missingColumns() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
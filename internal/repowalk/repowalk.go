@@ -0,0 +1,88 @@
+// Package repowalk selects the repository files a tool looks at, the same way in every
+// server: hidden and vendored directories are skipped, symlinks are not followed, and
+// file patterns are globs matched against the file name or, when they contain a slash,
+// against the path from the repository root.
+package repowalk
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// VendoredDirs hold third-party code and are never walked
+var VendoredDirs = map[string]bool{
+	"vendor":       true,
+	"node_modules": true,
+	"third_party":  true,
+}
+
+// SkipDir reports whether a directory named name is left out of walks: hidden
+// directories such as .git, and VendoredDirs
+func SkipDir(name string) bool {
+	return strings.HasPrefix(name, ".") || VendoredDirs[name]
+}
+
+// MatchesAny reports whether relPath matches one of the glob patterns. Patterns without a
+// slash match the base name, others the slash-separated path from the repository root.
+func MatchesAny(relPath string, patterns []string) bool {
+	slashPath := filepath.ToSlash(relPath)
+	for _, pattern := range patterns {
+		target := slashPath
+		if !strings.Contains(pattern, "/") {
+			target = filepath.Base(relPath)
+		}
+		if matched, _ := filepath.Match(pattern, target); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidatePatterns returns an error for the first malformed glob in patterns
+func ValidatePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Walk calls fn, in lexical order, for every regular file under root whose path matches
+// one of patterns, or any path when patterns is empty, and none of excludes. Directories
+// for which SkipDir is true or that match excludes are not entered, and entries that
+// cannot be read are skipped. fn returning filepath.SkipAll ends the walk early; any
+// other error from fn is returned.
+func Walk(root string, patterns, excludes []string, fn func(path, relPath string, d fs.DirEntry) error) error {
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && (SkipDir(d.Name()) || MatchesAny(relPath, excludes)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if (len(patterns) > 0 && !MatchesAny(relPath, patterns)) || MatchesAny(relPath, excludes) {
+			return nil
+		}
+		return fn(path, relPath, d)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk repository: %w", err)
+	}
+	return nil
+}
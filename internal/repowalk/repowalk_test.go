@@ -0,0 +1,85 @@
+package repowalk
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestMatchesAny tests base-name patterns and patterns matched against the path
+func TestMatchesAny(t *testing.T) {
+	tests := []struct {
+		relPath  string
+		patterns []string
+		want     bool
+	}{
+		{"main.go", []string{"*.go"}, true},
+		{"cmd/server/main.go", []string{"*.go"}, true},
+		{"cmd/server/main.go", []string{"cmd/*/*.go"}, true},
+		{"cmd/server/main.go", []string{"internal/*/*.go"}, false},
+		{"cmd/server/main.go", []string{"*.py", "main.*"}, true},
+		{"main.go", nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchesAny(tt.relPath, tt.patterns); got != tt.want {
+			t.Errorf("MatchesAny(%q, %v) = %v, want %v", tt.relPath, tt.patterns, got, tt.want)
+		}
+	}
+}
+
+// TestWalk tests which files are visited: skipped and excluded directories, path patterns
+// and symlinks
+func TestWalk(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{
+		"main.go",
+		"README.md",
+		"cmd/server/main.go",
+		"internal/util/util.go",
+		"internal/util/util_test.go",
+		".git/config.go",
+		"vendor/lib/lib.go",
+		"third_party/x/x.go",
+		"testdata/fixture.go",
+	} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("package x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(root, "main.go"), filepath.Join(root, "link.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	walk := func(patterns, excludes []string) []string {
+		t.Helper()
+		var visited []string
+		err := Walk(root, patterns, excludes, func(path, relPath string, d os.DirEntry) error {
+			visited = append(visited, filepath.ToSlash(relPath))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Walk() error = %v", err)
+		}
+		return visited
+	}
+
+	if got, want := walk([]string{"*.go"}, []string{"testdata", "*_test.go"}), []string{"cmd/server/main.go", "internal/util/util.go", "main.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk(*.go) visited %v, want %v", got, want)
+	}
+	if got, want := walk([]string{"internal/*/*.go"}, nil), []string{"internal/util/util.go", "internal/util/util_test.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk(internal/*/*.go) visited %v, want %v", got, want)
+	}
+	if got := walk(nil, nil); len(got) != 6 {
+		t.Errorf("Expected every non-skipped regular file without patterns, got %v", got)
+	}
+
+	if err := Walk(filepath.Join(root, "missing"), nil, nil, func(string, string, os.DirEntry) error { return nil }); err == nil {
+		t.Error("Expected an error for a missing root")
+	}
+}
@@ -0,0 +1,26 @@
+package uuid
+
+import (
+	"regexp"
+	"testing"
+)
+
+// TestNew tests that ids are version 4 UUIDs and differ between calls
+func TestNew(t *testing.T) {
+	pattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	first, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	second, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !pattern.MatchString(first) || !pattern.MatchString(second) {
+		t.Errorf("Expected version 4 UUIDs, got %s and %s", first, second)
+	}
+	if first == second {
+		t.Errorf("Expected different ids, got %s twice", first)
+	}
+}
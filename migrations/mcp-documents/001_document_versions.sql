@@ -0,0 +1,13 @@
+-- Content history for update_document, restore_document_version and upsert_document
+CREATE TABLE IF NOT EXISTS document_versions (
+    id BIGSERIAL PRIMARY KEY,
+    document_id TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    name TEXT,
+    description TEXT,
+    content TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (document_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_document_versions_document_id ON document_versions (document_id);
@@ -0,0 +1,2 @@
+-- Ids of the documents a document links to, listed as related by get_document_with_related
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS related_ids JSONB NOT NULL DEFAULT '[]';
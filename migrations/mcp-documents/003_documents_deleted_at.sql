@@ -0,0 +1,2 @@
+-- Soft deletion for delete_document and restore_document; NULL for live documents
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
@@ -0,0 +1,5 @@
+-- Stable key for upsert_document. upsert_document relies on the unique index for
+-- ON CONFLICT (slug); documents without a slug are not constrained
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS slug TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_documents_slug ON documents (slug);
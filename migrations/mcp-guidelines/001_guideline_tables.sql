@@ -0,0 +1,32 @@
+-- The guideline tables, for databases the API has not created them in
+CREATE TABLE IF NOT EXISTS guideline_categories (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    color VARCHAR(50),
+    icon VARCHAR(100),
+    metadata JSONB,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    tenant_id VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_by VARCHAR(255),
+    updated_by VARCHAR(255)
+);
+
+CREATE TABLE IF NOT EXISTS guidelines (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    content TEXT NOT NULL,
+    category_id UUID REFERENCES guideline_categories(id),
+    tags JSONB NOT NULL DEFAULT '[]',
+    tenant_id VARCHAR(255),
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    metadata JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_guidelines_category_id ON guidelines (category_id);
+CREATE INDEX IF NOT EXISTS idx_guidelines_tenant_id ON guidelines (tenant_id);
@@ -0,0 +1,2 @@
+-- Lowercase language names a guideline applies to, e.g. {go,typescript}
+ALTER TABLE guidelines ADD COLUMN IF NOT EXISTS languages TEXT[] NOT NULL DEFAULT '{}';
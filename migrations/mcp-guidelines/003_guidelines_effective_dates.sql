@@ -0,0 +1,3 @@
+-- When a guideline applies, [effective_from, effective_to); NULL leaves that end open
+ALTER TABLE guidelines ADD COLUMN IF NOT EXISTS effective_from TIMESTAMPTZ;
+ALTER TABLE guidelines ADD COLUMN IF NOT EXISTS effective_to TIMESTAMPTZ;